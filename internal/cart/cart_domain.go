@@ -3,24 +3,34 @@ package cart
 import (
 	"database/sql"
 	"time"
+
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
 )
 
 type status string
 
 const (
-	StatusActive  status = "active"
-	StatusGuest   status = "guest"
-	StatusSaved   status = "saved"
-	StatusOrdered status = "ordered"
+	StatusActive    status = "active"
+	StatusGuest     status = "guest"
+	StatusSaved     status = "saved"
+	StatusOrdered   status = "ordered"
+	StatusAbandoned status = "abandoned"
 )
 
+// Cart saved carts belong to a user, the one without a name is the
+// saved-for-later list and named ones are wishlists
 type Cart struct {
 	ID        string         `json:"id"`
 	UserID    sql.NullInt64  `json:"user_id"`
 	SessionID sql.NullString `json:"sesstion_id"`
 	Status    status         `json:"status"`
+	Name      sql.NullString `json:"name"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
+
+	// Active & guest carts, no country is the home country
+	ShippingMethod  string         `json:"shipping_method,omitempty"`
+	ShippingCountry sql.NullString `json:"shipping_country"`
 }
 
 type CartItem struct {
@@ -32,3 +42,55 @@ type CartItem struct {
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
 }
+
+// ChangedItem cart line whose price or available stock moved since it was added
+type ChangedItem struct {
+	CartItemID int64   `json:"cart_item_id"`
+	ProductID  int64   `json:"product_id"`
+	Name       string  `json:"name"`
+	PriceAtAdd float64 `json:"price_at_add"`
+	Price      float64 `json:"price"`
+	Quantity   int     `json:"quantity"`
+	Available  int     `json:"available"`
+}
+
+// ChangesError checkout found changes the user has not accepted yet,
+// errors.Is matches errs.ErrCartChanged
+type ChangesError struct {
+	Items []*ChangedItem
+}
+
+func (e *ChangesError) Error() string {
+	return errs.ErrCartChanged.Error()
+}
+
+func (e *ChangesError) Unwrap() error {
+	return errs.ErrCartChanged
+}
+
+// AbandonedCart user cart idle past the threshold, waiting for its
+// recovery notification
+type AbandonedCart struct {
+	RecoveryID int64
+	CartID     string
+	UserID     int64
+	Email      string
+	ItemCount  int
+	Total      float64
+}
+
+type RecoveryStatsFilter struct {
+	From time.Time `form:"from" time_format:"2006-01-02"`
+	To   time.Time `form:"to" time_format:"2006-01-02"`
+}
+
+// RecoveryStats carts abandoned in the period, RecoveryRate is
+// recovered / abandoned
+type RecoveryStats struct {
+	From         time.Time `json:"from"`
+	To           time.Time `json:"to"`
+	Abandoned    int       `json:"abandoned"`
+	Notified     int       `json:"notified"`
+	Recovered    int       `json:"recovered"`
+	RecoveryRate float64   `json:"recovery_rate"`
+}
@@ -8,3 +8,26 @@ type AddItemReq struct {
 type UpdateItemQuantityReq struct {
 	NewQuantity int   `json:"new_quantity"`
 }
+
+type WishlistReq struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+type WishlistItemReq struct {
+	ProductID int64 `json:"product_id" binding:"required"`
+	Quantity  int   `json:"quantity" binding:"omitempty,gt=0"`
+}
+
+type RecoverCartReq struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ShippingReq no country is the home country
+type ShippingReq struct {
+	Method  string `json:"method" binding:"required,oneof=standard express pickup"`
+	Country string `json:"country" binding:"omitempty,len=2,alpha"`
+}
+
+type ShippingOptionsQuery struct {
+	Country string `form:"country" binding:"omitempty,len=2,alpha"`
+}
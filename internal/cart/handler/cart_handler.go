@@ -1,7 +1,11 @@
 package carthandler
 
 import (
+	"errors"
+
 	cartusecase "github.com/codepnw/mini-ecommerce/internal/cart/usecase"
+	"github.com/codepnw/mini-ecommerce/internal/product"
+	"github.com/codepnw/mini-ecommerce/internal/shipping"
 	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
 	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
 	"github.com/codepnw/mini-ecommerce/internal/utils/helper"
@@ -26,8 +30,14 @@ func (h *cartHandler) AddItemToCart(c *gin.Context) {
 
 	result, err := h.uc.AddItemToCart(c.Request.Context(), req.ProductID, req.Quantity)
 	if err != nil {
+		var ruleErr *product.RuleError
+		if errors.As(err, &ruleErr) {
+			response.UnprocessableEntity(c, err.Error(), ruleErr)
+			return
+		}
+
 		switch err {
-		case errs.ErrProductNotEnough:
+		case errs.ErrProductNotEnough, errs.ErrProductUnavailable:
 			response.BadRequest(c, err.Error())
 			return
 		case errs.ErrProductNotFound:
@@ -65,6 +75,12 @@ func (h *cartHandler) UpdateItemQuantity(c *gin.Context) {
 
 	result, err := h.uc.UpdateItemQuantity(c.Request.Context(), id, req.NewQuantity)
 	if err != nil {
+		var ruleErr *product.RuleError
+		if errors.As(err, &ruleErr) {
+			response.UnprocessableEntity(c, err.Error(), ruleErr)
+			return
+		}
+
 		switch err {
 		case errs.ErrInvalidQuantity:
 			response.BadRequest(c, err.Error())
@@ -72,7 +88,7 @@ func (h *cartHandler) UpdateItemQuantity(c *gin.Context) {
 		case errs.ErrItemNotInCart:
 			response.BadRequest(c, err.Error())
 			return
-		case errs.ErrProductNotEnough:
+		case errs.ErrProductNotEnough, errs.ErrProductUnavailable:
 			response.BadRequest(c, err.Error())
 			return
 		case errs.ErrProductNotFound:
@@ -100,3 +116,42 @@ func (h *cartHandler) RemoveItemFromCart(c *gin.Context) {
 	}
 	response.OK(c, "item remove", result)
 }
+
+func (h *cartHandler) AcceptChanges(c *gin.Context) {
+	result, err := h.uc.AcceptChanges(c.Request.Context())
+	if err != nil {
+		response.InternalServerError(c, err)
+		return
+	}
+	response.OK(c, "cart changes accepted", result)
+}
+
+func (h *cartHandler) SetShipping(c *gin.Context) {
+	req := new(ShippingReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.SetShipping(c.Request.Context(), shipping.Method(req.Method), req.Country)
+	if err != nil {
+		response.InternalServerError(c, err)
+		return
+	}
+	response.OK(c, "shipping updated", result)
+}
+
+func (h *cartHandler) ShippingOptions(c *gin.Context) {
+	query := new(ShippingOptionsQuery)
+	if err := c.ShouldBindQuery(query); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.ShippingOptions(c.Request.Context(), query.Country)
+	if err != nil {
+		response.InternalServerError(c, err)
+		return
+	}
+	response.OK(c, "", result)
+}
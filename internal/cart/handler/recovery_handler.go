@@ -0,0 +1,65 @@
+package carthandler
+
+import (
+	"github.com/codepnw/mini-ecommerce/internal/cart"
+	cartusecase "github.com/codepnw/mini-ecommerce/internal/cart/usecase"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+type recoveryHandler struct {
+	uc cartusecase.RecoveryUsecase
+}
+
+func NewRecoveryHandler(uc cartusecase.RecoveryUsecase) *recoveryHandler {
+	return &recoveryHandler{uc: uc}
+}
+
+func (h *recoveryHandler) RecoverCart(c *gin.Context) {
+	req := new(RecoverCartReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.RecoverCart(c.Request.Context(), req.Token)
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrCartRecoveryInvalid:
+			response.BadRequest(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "cart restored", result)
+}
+
+func (h *recoveryHandler) RecoveryStats(c *gin.Context) {
+	filter := new(cart.RecoveryStatsFilter)
+	if err := c.ShouldBindQuery(filter); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.RecoveryStats(c.Request.Context(), filter)
+	if err != nil {
+		switch err {
+		case errs.ErrNoPermissions:
+			response.Forbidden(c, err.Error())
+			return
+		case errs.ErrInvalidDateRange:
+			response.BadRequest(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "", result)
+}
@@ -0,0 +1,302 @@
+package carthandler
+
+import (
+	"errors"
+
+	"github.com/codepnw/mini-ecommerce/internal/product"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/internal/utils/helper"
+	"github.com/codepnw/mini-ecommerce/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *cartHandler) SaveForLater(c *gin.Context) {
+	id, err := helper.GetParamInt(c, consts.CartItemID)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.SaveForLater(c.Request.Context(), id)
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrItemNotInCart:
+			response.BadRequest(c, err.Error())
+			return
+		case errs.ErrProductNotFound:
+			response.NotFound(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "item saved for later", result)
+}
+
+func (h *cartHandler) GetSavedItems(c *gin.Context) {
+	result, err := h.uc.GetSavedItems(c.Request.Context())
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "", result)
+}
+
+func (h *cartHandler) MoveSavedToCart(c *gin.Context) {
+	id, err := helper.GetParamInt(c, consts.CartItemID)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.MoveSavedToCart(c.Request.Context(), id)
+	if err != nil {
+		var ruleErr *product.RuleError
+		if errors.As(err, &ruleErr) {
+			response.UnprocessableEntity(c, err.Error(), ruleErr)
+			return
+		}
+
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrItemNotInCart:
+			response.BadRequest(c, err.Error())
+			return
+		case errs.ErrProductNotEnough, errs.ErrProductUnavailable:
+			response.BadRequest(c, err.Error())
+			return
+		case errs.ErrProductNotFound:
+			response.NotFound(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "item moved to cart", result)
+}
+
+func (h *cartHandler) RemoveSavedItem(c *gin.Context) {
+	id, err := helper.GetParamInt(c, consts.CartItemID)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.RemoveSavedItem(c.Request.Context(), id)
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "item remove", result)
+}
+
+func (h *cartHandler) ListWishlists(c *gin.Context) {
+	result, err := h.uc.ListWishlists(c.Request.Context())
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "", result)
+}
+
+func (h *cartHandler) CreateWishlist(c *gin.Context) {
+	req := new(WishlistReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.CreateWishlist(c.Request.Context(), req.Name)
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrWishlistNameRequired, errs.ErrWishlistNameExists:
+			response.BadRequest(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.Created(c, result)
+}
+
+func (h *cartHandler) GetWishlist(c *gin.Context) {
+	result, err := h.uc.GetWishlist(c.Request.Context(), c.Param(consts.ParamWishlistID))
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrWishlistNotFound:
+			response.NotFound(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "", result)
+}
+
+func (h *cartHandler) RenameWishlist(c *gin.Context) {
+	req := new(WishlistReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.RenameWishlist(c.Request.Context(), c.Param(consts.ParamWishlistID), req.Name)
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrWishlistNameRequired, errs.ErrWishlistNameExists:
+			response.BadRequest(c, err.Error())
+			return
+		case errs.ErrWishlistNotFound:
+			response.NotFound(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "wishlist updated", result)
+}
+
+func (h *cartHandler) DeleteWishlist(c *gin.Context) {
+	if err := h.uc.DeleteWishlist(c.Request.Context(), c.Param(consts.ParamWishlistID)); err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrWishlistNotFound:
+			response.NotFound(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.NoContent(c)
+}
+
+func (h *cartHandler) AddWishlistItem(c *gin.Context) {
+	req := new(WishlistItemReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+
+	result, err := h.uc.AddWishlistItem(c.Request.Context(), c.Param(consts.ParamWishlistID), req.ProductID, req.Quantity)
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrInvalidQuantity, errs.ErrProductUnavailable:
+			response.BadRequest(c, err.Error())
+			return
+		case errs.ErrWishlistNotFound, errs.ErrProductNotFound:
+			response.NotFound(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "item added", result)
+}
+
+func (h *cartHandler) RemoveWishlistItem(c *gin.Context) {
+	id, err := helper.GetParamInt(c, consts.CartItemID)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.RemoveWishlistItem(c.Request.Context(), c.Param(consts.ParamWishlistID), id)
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrWishlistNotFound:
+			response.NotFound(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "item remove", result)
+}
+
+func (h *cartHandler) MoveWishlistItemToCart(c *gin.Context) {
+	id, err := helper.GetParamInt(c, consts.CartItemID)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.MoveWishlistItemToCart(c.Request.Context(), c.Param(consts.ParamWishlistID), id)
+	if err != nil {
+		var ruleErr *product.RuleError
+		if errors.As(err, &ruleErr) {
+			response.UnprocessableEntity(c, err.Error(), ruleErr)
+			return
+		}
+
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrItemNotInCart:
+			response.BadRequest(c, err.Error())
+			return
+		case errs.ErrProductNotEnough, errs.ErrProductUnavailable:
+			response.BadRequest(c, err.Error())
+			return
+		case errs.ErrWishlistNotFound, errs.ErrProductNotFound:
+			response.NotFound(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "item moved to cart", result)
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/codepnw/mini-ecommerce/internal/cart"
+	"github.com/codepnw/mini-ecommerce/internal/product"
 	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
 	"github.com/codepnw/mini-ecommerce/pkg/database"
 )
@@ -15,6 +18,7 @@ import (
 type CartRepository interface {
 	GetOrCreateActiveCart(ctx context.Context, userID sql.NullInt64, sessionID sql.NullString) (*cart.Cart, error)
 	GetCartItemDetails(ctx context.Context, cartItemID int64, cartID string) (*cart.CartItem, error)
+	SetShipping(ctx context.Context, cartID, method string, country sql.NullString) error
 
 	// DB or Tx
 	GetCartItems(ctx context.Context, exec database.DBExec, cartID string) ([]*CartItemDB, error)
@@ -22,10 +26,32 @@ type CartRepository interface {
 
 	// Transaction
 	UpdateItemQuantity(ctx context.Context, tx *sql.Tx, cartID string, cartItemID int64, quantity int) error
+	RepriceItem(ctx context.Context, tx *sql.Tx, cartID string, cartItemID int64, quantity int, price float64) error
 	UpsertItem(ctx context.Context, tx *sql.Tx, item *cart.CartItem) error
 	RemoveItem(ctx context.Context, tx *sql.Tx, cartID string, cartItemID int64) error
 	GetCartItemForUpdate(ctx context.Context, tx *sql.Tx, cartItemID int64, cartID string) (*cart.CartItem, error)
 	GetActiveCartByUserID(ctx context.Context, tx *sql.Tx, userID int64) (*cart.Cart, error)
+	GetGuestCartBySessionID(ctx context.Context, tx *sql.Tx, sessionID string) (*cart.Cart, error)
+
+	// Saved for later & Wishlists
+	GetOrCreateSavedCart(ctx context.Context, userID int64) (*cart.Cart, error)
+	ListWishlists(ctx context.Context, userID int64) ([]*cart.Cart, error)
+	GetWishlist(ctx context.Context, userID int64, wishlistID string) (*cart.Cart, error)
+	CreateWishlist(ctx context.Context, input *cart.Cart) error
+	RenameWishlist(ctx context.Context, userID int64, wishlistID, name string) (*cart.Cart, error)
+	DeleteWishlist(ctx context.Context, userID int64, wishlistID string) error
+
+	// Abandoned carts
+	MarkAbandoned(ctx context.Context, idleBefore time.Time) (int64, error)
+	PendingRecoveries(ctx context.Context, limit, maxAttempts int) ([]*cart.AbandonedCart, error)
+	MarkRecoveryNotified(ctx context.Context, recoveryID int64) error
+	MarkRecoveryFailed(ctx context.Context, recoveryID int64, reason string) error
+	GetAbandonedCartForUpdate(ctx context.Context, tx *sql.Tx, cartID string, userID int64) (*cart.Cart, error)
+	RestoreCart(ctx context.Context, tx *sql.Tx, cartID string) error
+	MergeCart(ctx context.Context, tx *sql.Tx, fromCartID, toCartID string) error
+	MarkRecovered(ctx context.Context, tx *sql.Tx, cartID string) error
+	RecoveryStats(ctx context.Context, from, to time.Time) (*cart.RecoveryStats, error)
+	PurgeGuestCarts(ctx context.Context, idleBefore time.Time) (int64, error)
 }
 
 type cartRepository struct {
@@ -39,22 +65,16 @@ func NewCartRepository(db *sql.DB) CartRepository {
 func (r *cartRepository) GetOrCreateActiveCart(ctx context.Context, userID sql.NullInt64, sessionID sql.NullString) (*cart.Cart, error) {
 	var query string
 	var args []any
-	c := new(cart.Cart)
 
 	if userID.Valid {
-		query = `SELECT id, user_id, session_id, status FROM carts WHERE user_id = $1 AND status = 'active' LIMIT 1`
+		query = `SELECT ` + activeCartColumns + ` FROM carts WHERE user_id = $1 AND status = 'active' LIMIT 1`
 		args = append(args, userID.Int64)
 	} else {
-		query = `SELECT id, user_id, session_id, status FROM carts WHERE session_id = $1 AND status = 'guest' LIMIT 1`
+		query = `SELECT ` + activeCartColumns + ` FROM carts WHERE session_id = $1 AND status = 'guest' LIMIT 1`
 		args = append(args, sessionID.String)
 	}
 
-	err := r.db.QueryRowContext(ctx, query, args...).Scan(
-		&c.ID,
-		&c.UserID,
-		&c.SessionID,
-		&c.Status,
-	)
+	c, err := scanActiveCart(r.db.QueryRowContext(ctx, query, args...))
 	if err == nil {
 		// Found Cart
 		return c, nil
@@ -69,20 +89,14 @@ func (r *cartRepository) GetOrCreateActiveCart(ctx context.Context, userID sql.N
 	var insertArgs []any
 
 	if userID.Valid {
-		insertQuery = `INSERT INTO carts (user_id, status) VALUES ($1, 'active') RETURNING id, user_id, session_id, status`
+		insertQuery = `INSERT INTO carts (user_id, status) VALUES ($1, 'active') RETURNING ` + activeCartColumns
 		insertArgs = append(insertArgs, userID.Int64)
 	} else {
-		insertQuery = `INSERT INTO carts (session_id, status) VALUES ($1, 'guest') RETURNING id, user_id, session_id, status`
+		insertQuery = `INSERT INTO carts (session_id, status) VALUES ($1, 'guest') RETURNING ` + activeCartColumns
 		insertArgs = append(insertArgs, sessionID.String)
 	}
 
-	newCart := new(cart.Cart)
-	err = r.db.QueryRowContext(ctx, insertQuery, insertArgs...).Scan(
-		&newCart.ID,
-		&newCart.UserID,
-		&newCart.SessionID,
-		&newCart.Status,
-	)
+	newCart, err := scanActiveCart(r.db.QueryRowContext(ctx, insertQuery, insertArgs...))
 	if err != nil {
 		return nil, err
 	}
@@ -97,19 +111,17 @@ func (r *cartRepository) UpsertItem(ctx context.Context, tx *sql.Tx, item *cart.
 		DO UPDATE SET
 			quantity = cart_items.quantity + EXCLUDED.quantity,
 			updated_at = NOW()
+		RETURNING id, quantity
 	`
-	_, err := tx.ExecContext(
+	// Line quantity after upsert, needed for the reservation
+	return tx.QueryRowContext(
 		ctx,
 		query,
 		item.CartID,
 		item.ProductID,
 		item.Quantity,
 		item.PriceAtAdd,
-	)
-	if err != nil {
-		return err
-	}
-	return nil
+	).Scan(&item.ID, &item.Quantity)
 }
 
 type CartItemDB struct {
@@ -118,17 +130,29 @@ type CartItemDB struct {
 	Quantity   int
 	PriceAtAdd float64
 	// From Products
-	Name  string
-	Price float64
-	Stock int
-	SKU   sql.NullString
+	Name      string
+	Price     float64
+	Stock     int
+	SKU       sql.NullString
+	Status    string
+	DeletedAt sql.NullTime
+	Parcel    product.Parcel
+	// From Inventory Reservations
+	ReservedUntil sql.NullTime
+}
+
+// IsAvailable product still published and not deleted
+func (i *CartItemDB) IsAvailable() bool {
+	return !i.DeletedAt.Valid && i.Status == "published"
 }
 
 func (r *cartRepository) GetCartItems(ctx context.Context, exec database.DBExec, cartID string) ([]*CartItemDB, error) {
 	query := `
-		SELECT ci.id, ci.product_id, ci.quantity, ci.price_at_add, p.name, p.price, p.stock, p.sku
+		SELECT ci.id, ci.product_id, ci.quantity, ci.price_at_add, p.name, p.price, p.stock, p.sku, p.status, p.deleted_at,
+			p.weight_grams, p.length_cm, p.width_cm, p.height_cm, r.expires_at
 		FROM cart_items ci
 		INNER JOIN products p ON ci.product_id = p.id
+		LEFT JOIN inventory_reservations r ON r.cart_item_id = ci.id
 		WHERE ci.cart_id = $1
 		ORDER BY ci.created_at DESC
 	`
@@ -151,6 +175,13 @@ func (r *cartRepository) GetCartItems(ctx context.Context, exec database.DBExec,
 			&item.Price,
 			&item.Stock,
 			&item.SKU,
+			&item.Status,
+			&item.DeletedAt,
+			&item.Parcel.WeightGrams,
+			&item.Parcel.LengthCM,
+			&item.Parcel.WidthCM,
+			&item.Parcel.HeightCM,
+			&item.ReservedUntil,
 		)
 		if err != nil {
 			return nil, err
@@ -175,6 +206,19 @@ func (r *cartRepository) UpdateItemQuantity(ctx context.Context, tx *sql.Tx, car
 	return nil
 }
 
+// RepriceItem the user accepted the current price & quantity of the line
+func (r *cartRepository) RepriceItem(ctx context.Context, tx *sql.Tx, cartID string, cartItemID int64, quantity int, price float64) error {
+	query := `
+		UPDATE cart_items SET quantity = $1, price_at_add = $2, updated_at = NOW()
+		WHERE id = $3 AND cart_id = $4
+	`
+	_, err := tx.ExecContext(ctx, query, quantity, price, cartItemID, cartID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 func (r *cartRepository) RemoveItem(ctx context.Context, tx *sql.Tx, cartID string, cartItemID int64) error {
 	query := `DELETE FROM cart_items WHERE cart_id = $1 AND id = $2`
 	_, err := tx.ExecContext(ctx, query, cartID, cartItemID)
@@ -184,6 +228,16 @@ func (r *cartRepository) RemoveItem(ctx context.Context, tx *sql.Tx, cartID stri
 	return nil
 }
 
+// SetShipping method & destination country used to price the cart
+func (r *cartRepository) SetShipping(ctx context.Context, cartID, method string, country sql.NullString) error {
+	query := `UPDATE carts SET shipping_method = $1, shipping_country = $2, updated_at = NOW() WHERE id = $3`
+	_, err := r.db.ExecContext(ctx, query, method, country, cartID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 func (r *cartRepository) ClearCart(ctx context.Context, exec database.DBExec, cartID string) error {
 	_, err := exec.ExecContext(ctx, "DELETE FROM cart_items WHERE cart_id = $1", cartID)
 	if err != nil {
@@ -239,18 +293,357 @@ func (r *cartRepository) GetCartItemForUpdate(ctx context.Context, tx *sql.Tx, c
 
 func (r *cartRepository) GetActiveCartByUserID(ctx context.Context, tx *sql.Tx, userID int64) (*cart.Cart, error) {
 	query := `
-		SELECT id, user_id, session_id, status
+		SELECT ` + activeCartColumns + `
 		FROM carts WHERE user_id = $1 AND status = 'active' LIMIT 1
 	`
+	c, err := scanActiveCart(tx.QueryRowContext(ctx, query, userID))
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (r *cartRepository) GetGuestCartBySessionID(ctx context.Context, tx *sql.Tx, sessionID string) (*cart.Cart, error) {
+	query := `
+		SELECT ` + activeCartColumns + `
+		FROM carts WHERE session_id = $1 AND status = 'guest' LIMIT 1
+	`
+	c, err := scanActiveCart(tx.QueryRowContext(ctx, query, sessionID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrCartIsEmpty
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+const savedCartColumns = "id, user_id, session_id, status, name, created_at, updated_at"
+
+const activeCartColumns = "id, user_id, session_id, status, shipping_method, shipping_country"
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanActiveCart(row rowScanner) (*cart.Cart, error) {
+	c := new(cart.Cart)
+	err := row.Scan(
+		&c.ID,
+		&c.UserID,
+		&c.SessionID,
+		&c.Status,
+		&c.ShippingMethod,
+		&c.ShippingCountry,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func scanSavedCart(row rowScanner) (*cart.Cart, error) {
 	c := new(cart.Cart)
-	err := tx.QueryRowContext(ctx, query, userID).Scan(
+	err := row.Scan(
 		&c.ID,
 		&c.UserID,
 		&c.SessionID,
 		&c.Status,
+		&c.Name,
+		&c.CreatedAt,
+		&c.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
 	return c, nil
 }
+
+func (r *cartRepository) GetOrCreateSavedCart(ctx context.Context, userID int64) (*cart.Cart, error) {
+	query := `SELECT ` + savedCartColumns + ` FROM carts WHERE user_id = $1 AND status = 'saved' AND name IS NULL LIMIT 1`
+
+	c, err := scanSavedCart(r.db.QueryRowContext(ctx, query, userID))
+	if err == nil {
+		return c, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	// Two first saves at once, the loser picks up the winner's row
+	insertQuery := `
+		INSERT INTO carts (user_id, status) VALUES ($1, 'saved')
+		ON CONFLICT (user_id) WHERE status = 'saved' AND name IS NULL
+		DO UPDATE SET updated_at = NOW()
+		RETURNING ` + savedCartColumns
+	return scanSavedCart(r.db.QueryRowContext(ctx, insertQuery, userID))
+}
+
+func (r *cartRepository) ListWishlists(ctx context.Context, userID int64) ([]*cart.Cart, error) {
+	query := `
+		SELECT ` + savedCartColumns + `
+		FROM carts
+		WHERE user_id = $1 AND status = 'saved' AND name IS NOT NULL
+		ORDER BY created_at
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lists := make([]*cart.Cart, 0)
+	for rows.Next() {
+		c, err := scanSavedCart(rows)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return lists, nil
+}
+
+func (r *cartRepository) GetWishlist(ctx context.Context, userID int64, wishlistID string) (*cart.Cart, error) {
+	query := `
+		SELECT ` + savedCartColumns + `
+		FROM carts
+		WHERE id = $1 AND user_id = $2 AND status = 'saved' AND name IS NOT NULL
+	`
+	c, err := scanSavedCart(r.db.QueryRowContext(ctx, query, wishlistID, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrWishlistNotFound
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+func (r *cartRepository) CreateWishlist(ctx context.Context, input *cart.Cart) error {
+	query := `
+		INSERT INTO carts (user_id, status, name) VALUES ($1, 'saved', $2)
+		RETURNING id, status, created_at, updated_at
+	`
+	err := r.db.QueryRowContext(ctx, query, input.UserID, input.Name).Scan(
+		&input.ID,
+		&input.Status,
+		&input.CreatedAt,
+		&input.UpdatedAt,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
+			return errs.ErrWishlistNameExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *cartRepository) RenameWishlist(ctx context.Context, userID int64, wishlistID, name string) (*cart.Cart, error) {
+	query := `
+		UPDATE carts SET name = $1, updated_at = NOW()
+		WHERE id = $2 AND user_id = $3 AND status = 'saved' AND name IS NOT NULL
+		RETURNING ` + savedCartColumns
+	c, err := scanSavedCart(r.db.QueryRowContext(ctx, query, name, wishlistID, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrWishlistNotFound
+		}
+		if strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
+			return nil, errs.ErrWishlistNameExists
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+func (r *cartRepository) DeleteWishlist(ctx context.Context, userID int64, wishlistID string) error {
+	query := `
+		DELETE FROM carts
+		WHERE id = $1 AND user_id = $2 AND status = 'saved' AND name IS NOT NULL
+	`
+	res, err := r.db.ExecContext(ctx, query, wishlistID, userID)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errs.ErrWishlistNotFound
+	}
+	return nil
+}
+
+// MarkAbandoned user carts with items and no activity since idleBefore,
+// their stock holds are released and a recovery is queued for each
+func (r *cartRepository) MarkAbandoned(ctx context.Context, idleBefore time.Time) (int64, error) {
+	query := `
+		WITH abandoned AS (
+			UPDATE carts c SET status = 'abandoned', updated_at = NOW()
+			WHERE c.status = 'active' AND c.user_id IS NOT NULL AND c.updated_at < $1
+				AND EXISTS (SELECT 1 FROM cart_items ci WHERE ci.cart_id = c.id)
+				AND NOT EXISTS (SELECT 1 FROM cart_items ci WHERE ci.cart_id = c.id AND ci.updated_at >= $1)
+			RETURNING c.id, c.user_id
+		), released AS (
+			DELETE FROM inventory_reservations ir USING abandoned a WHERE ir.cart_id = a.id
+		)
+		INSERT INTO cart_recoveries (cart_id, user_id)
+		SELECT id, user_id FROM abandoned
+	`
+	res, err := r.db.ExecContext(ctx, query, idleBefore)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r *cartRepository) PendingRecoveries(ctx context.Context, limit, maxAttempts int) ([]*cart.AbandonedCart, error) {
+	query := `
+		SELECT cr.id, cr.cart_id, cr.user_id, u.email, COUNT(ci.id), COALESCE(SUM(ci.quantity * p.price), 0)
+		FROM cart_recoveries cr
+		INNER JOIN carts c ON c.id = cr.cart_id AND c.status = 'abandoned'
+		INNER JOIN users u ON u.id = cr.user_id
+		LEFT JOIN cart_items ci ON ci.cart_id = cr.cart_id
+		LEFT JOIN products p ON p.id = ci.product_id
+		WHERE cr.notified_at IS NULL AND cr.recovered_at IS NULL AND cr.attempts < $2
+		GROUP BY cr.id, u.email
+		ORDER BY cr.attempts, cr.id
+		LIMIT $1
+	`
+	rows, err := r.db.QueryContext(ctx, query, limit, maxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	carts := make([]*cart.AbandonedCart, 0)
+	for rows.Next() {
+		c := new(cart.AbandonedCart)
+		err := rows.Scan(
+			&c.RecoveryID,
+			&c.CartID,
+			&c.UserID,
+			&c.Email,
+			&c.ItemCount,
+			&c.Total,
+		)
+		if err != nil {
+			return nil, err
+		}
+		carts = append(carts, c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return carts, nil
+}
+
+func (r *cartRepository) MarkRecoveryNotified(ctx context.Context, recoveryID int64) error {
+	query := `UPDATE cart_recoveries SET notified_at = NOW() WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, recoveryID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *cartRepository) MarkRecoveryFailed(ctx context.Context, recoveryID int64, reason string) error {
+	query := `UPDATE cart_recoveries SET attempts = attempts + 1, last_error = $2 WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, recoveryID, reason)
+	return err
+}
+
+func (r *cartRepository) GetAbandonedCartForUpdate(ctx context.Context, tx *sql.Tx, cartID string, userID int64) (*cart.Cart, error) {
+	query := `
+		SELECT ` + savedCartColumns + `
+		FROM carts
+		WHERE id = $1 AND user_id = $2 AND status = 'abandoned'
+		FOR UPDATE
+	`
+	c, err := scanSavedCart(tx.QueryRowContext(ctx, query, cartID, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrCartRecoveryInvalid
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+// RestoreCart the abandoned cart becomes the active cart again
+func (r *cartRepository) RestoreCart(ctx context.Context, tx *sql.Tx, cartID string) error {
+	query := `UPDATE carts SET status = 'active', updated_at = NOW() WHERE id = $1 AND status = 'abandoned'`
+	_, err := tx.ExecContext(ctx, query, cartID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// MergeCart moves the lines of an abandoned cart into the active cart,
+// a product in both keeps the larger quantity. The emptied cart is kept
+// for the recovery stats.
+func (r *cartRepository) MergeCart(ctx context.Context, tx *sql.Tx, fromCartID, toCartID string) error {
+	query := `
+		INSERT INTO cart_items (cart_id, product_id, quantity, price_at_add)
+		SELECT $2, product_id, quantity, price_at_add FROM cart_items WHERE cart_id = $1
+		ON CONFLICT (cart_id, product_id)
+		DO UPDATE SET
+			quantity = GREATEST(cart_items.quantity, EXCLUDED.quantity),
+			updated_at = NOW()
+	`
+	if _, err := tx.ExecContext(ctx, query, fromCartID, toCartID); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, "DELETE FROM cart_items WHERE cart_id = $1", fromCartID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *cartRepository) MarkRecovered(ctx context.Context, tx *sql.Tx, cartID string) error {
+	query := `UPDATE cart_recoveries SET recovered_at = NOW() WHERE cart_id = $1 AND recovered_at IS NULL`
+	_, err := tx.ExecContext(ctx, query, cartID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *cartRepository) RecoveryStats(ctx context.Context, from, to time.Time) (*cart.RecoveryStats, error) {
+	query := `
+		SELECT COUNT(*), COUNT(notified_at), COUNT(recovered_at)
+		FROM cart_recoveries
+		WHERE abandoned_at >= $1 AND abandoned_at < $2
+	`
+	stats := &cart.RecoveryStats{From: from, To: to}
+	err := r.db.QueryRowContext(ctx, query, from, to).Scan(
+		&stats.Abandoned,
+		&stats.Notified,
+		&stats.Recovered,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// PurgeGuestCarts cart_items and stock holds are deleted by ON DELETE CASCADE
+func (r *cartRepository) PurgeGuestCarts(ctx context.Context, idleBefore time.Time) (int64, error) {
+	query := `
+		DELETE FROM carts c
+		WHERE c.status = 'guest' AND c.updated_at < $1
+			AND NOT EXISTS (SELECT 1 FROM cart_items ci WHERE ci.cart_id = c.id AND ci.updated_at >= $1)
+	`
+	res, err := r.db.ExecContext(ctx, query, idleBefore)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
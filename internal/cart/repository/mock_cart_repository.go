@@ -8,6 +8,7 @@ import (
 	context "context"
 	sql "database/sql"
 	reflect "reflect"
+	time "time"
 
 	cart "github.com/codepnw/mini-ecommerce/internal/cart"
 	database "github.com/codepnw/mini-ecommerce/pkg/database"
@@ -51,6 +52,49 @@ func (mr *MockCartRepositoryMockRecorder) ClearCart(ctx, exec, cartID interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearCart", reflect.TypeOf((*MockCartRepository)(nil).ClearCart), ctx, exec, cartID)
 }
 
+// CreateWishlist mocks base method.
+func (m *MockCartRepository) CreateWishlist(ctx context.Context, input *cart.Cart) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWishlist", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWishlist indicates an expected call of CreateWishlist.
+func (mr *MockCartRepositoryMockRecorder) CreateWishlist(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWishlist", reflect.TypeOf((*MockCartRepository)(nil).CreateWishlist), ctx, input)
+}
+
+// DeleteWishlist mocks base method.
+func (m *MockCartRepository) DeleteWishlist(ctx context.Context, userID int64, wishlistID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWishlist", ctx, userID, wishlistID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWishlist indicates an expected call of DeleteWishlist.
+func (mr *MockCartRepositoryMockRecorder) DeleteWishlist(ctx, userID, wishlistID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWishlist", reflect.TypeOf((*MockCartRepository)(nil).DeleteWishlist), ctx, userID, wishlistID)
+}
+
+// GetAbandonedCartForUpdate mocks base method.
+func (m *MockCartRepository) GetAbandonedCartForUpdate(ctx context.Context, tx *sql.Tx, cartID string, userID int64) (*cart.Cart, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAbandonedCartForUpdate", ctx, tx, cartID, userID)
+	ret0, _ := ret[0].(*cart.Cart)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAbandonedCartForUpdate indicates an expected call of GetAbandonedCartForUpdate.
+func (mr *MockCartRepositoryMockRecorder) GetAbandonedCartForUpdate(ctx, tx, cartID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAbandonedCartForUpdate", reflect.TypeOf((*MockCartRepository)(nil).GetAbandonedCartForUpdate), ctx, tx, cartID, userID)
+}
+
 // GetActiveCartByUserID mocks base method.
 func (m *MockCartRepository) GetActiveCartByUserID(ctx context.Context, tx *sql.Tx, userID int64) (*cart.Cart, error) {
 	m.ctrl.T.Helper()
@@ -111,6 +155,21 @@ func (mr *MockCartRepositoryMockRecorder) GetCartItems(ctx, exec, cartID interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCartItems", reflect.TypeOf((*MockCartRepository)(nil).GetCartItems), ctx, exec, cartID)
 }
 
+// GetGuestCartBySessionID mocks base method.
+func (m *MockCartRepository) GetGuestCartBySessionID(ctx context.Context, tx *sql.Tx, sessionID string) (*cart.Cart, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGuestCartBySessionID", ctx, tx, sessionID)
+	ret0, _ := ret[0].(*cart.Cart)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGuestCartBySessionID indicates an expected call of GetGuestCartBySessionID.
+func (mr *MockCartRepositoryMockRecorder) GetGuestCartBySessionID(ctx, tx, sessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGuestCartBySessionID", reflect.TypeOf((*MockCartRepository)(nil).GetGuestCartBySessionID), ctx, tx, sessionID)
+}
+
 // GetOrCreateActiveCart mocks base method.
 func (m *MockCartRepository) GetOrCreateActiveCart(ctx context.Context, userID sql.NullInt64, sessionID sql.NullString) (*cart.Cart, error) {
 	m.ctrl.T.Helper()
@@ -126,6 +185,167 @@ func (mr *MockCartRepositoryMockRecorder) GetOrCreateActiveCart(ctx, userID, ses
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrCreateActiveCart", reflect.TypeOf((*MockCartRepository)(nil).GetOrCreateActiveCart), ctx, userID, sessionID)
 }
 
+// GetOrCreateSavedCart mocks base method.
+func (m *MockCartRepository) GetOrCreateSavedCart(ctx context.Context, userID int64) (*cart.Cart, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrCreateSavedCart", ctx, userID)
+	ret0, _ := ret[0].(*cart.Cart)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrCreateSavedCart indicates an expected call of GetOrCreateSavedCart.
+func (mr *MockCartRepositoryMockRecorder) GetOrCreateSavedCart(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrCreateSavedCart", reflect.TypeOf((*MockCartRepository)(nil).GetOrCreateSavedCart), ctx, userID)
+}
+
+// GetWishlist mocks base method.
+func (m *MockCartRepository) GetWishlist(ctx context.Context, userID int64, wishlistID string) (*cart.Cart, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWishlist", ctx, userID, wishlistID)
+	ret0, _ := ret[0].(*cart.Cart)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWishlist indicates an expected call of GetWishlist.
+func (mr *MockCartRepositoryMockRecorder) GetWishlist(ctx, userID, wishlistID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWishlist", reflect.TypeOf((*MockCartRepository)(nil).GetWishlist), ctx, userID, wishlistID)
+}
+
+// ListWishlists mocks base method.
+func (m *MockCartRepository) ListWishlists(ctx context.Context, userID int64) ([]*cart.Cart, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWishlists", ctx, userID)
+	ret0, _ := ret[0].([]*cart.Cart)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWishlists indicates an expected call of ListWishlists.
+func (mr *MockCartRepositoryMockRecorder) ListWishlists(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWishlists", reflect.TypeOf((*MockCartRepository)(nil).ListWishlists), ctx, userID)
+}
+
+// MarkAbandoned mocks base method.
+func (m *MockCartRepository) MarkAbandoned(ctx context.Context, idleBefore time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkAbandoned", ctx, idleBefore)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkAbandoned indicates an expected call of MarkAbandoned.
+func (mr *MockCartRepositoryMockRecorder) MarkAbandoned(ctx, idleBefore interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAbandoned", reflect.TypeOf((*MockCartRepository)(nil).MarkAbandoned), ctx, idleBefore)
+}
+
+// MarkRecovered mocks base method.
+func (m *MockCartRepository) MarkRecovered(ctx context.Context, tx *sql.Tx, cartID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRecovered", ctx, tx, cartID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkRecovered indicates an expected call of MarkRecovered.
+func (mr *MockCartRepositoryMockRecorder) MarkRecovered(ctx, tx, cartID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRecovered", reflect.TypeOf((*MockCartRepository)(nil).MarkRecovered), ctx, tx, cartID)
+}
+
+// MarkRecoveryFailed mocks base method.
+func (m *MockCartRepository) MarkRecoveryFailed(ctx context.Context, recoveryID int64, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRecoveryFailed", ctx, recoveryID, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkRecoveryFailed indicates an expected call of MarkRecoveryFailed.
+func (mr *MockCartRepositoryMockRecorder) MarkRecoveryFailed(ctx, recoveryID, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRecoveryFailed", reflect.TypeOf((*MockCartRepository)(nil).MarkRecoveryFailed), ctx, recoveryID, reason)
+}
+
+// MarkRecoveryNotified mocks base method.
+func (m *MockCartRepository) MarkRecoveryNotified(ctx context.Context, recoveryID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRecoveryNotified", ctx, recoveryID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkRecoveryNotified indicates an expected call of MarkRecoveryNotified.
+func (mr *MockCartRepositoryMockRecorder) MarkRecoveryNotified(ctx, recoveryID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRecoveryNotified", reflect.TypeOf((*MockCartRepository)(nil).MarkRecoveryNotified), ctx, recoveryID)
+}
+
+// MergeCart mocks base method.
+func (m *MockCartRepository) MergeCart(ctx context.Context, tx *sql.Tx, fromCartID, toCartID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergeCart", ctx, tx, fromCartID, toCartID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MergeCart indicates an expected call of MergeCart.
+func (mr *MockCartRepositoryMockRecorder) MergeCart(ctx, tx, fromCartID, toCartID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergeCart", reflect.TypeOf((*MockCartRepository)(nil).MergeCart), ctx, tx, fromCartID, toCartID)
+}
+
+// PendingRecoveries mocks base method.
+func (m *MockCartRepository) PendingRecoveries(ctx context.Context, limit, maxAttempts int) ([]*cart.AbandonedCart, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PendingRecoveries", ctx, limit, maxAttempts)
+	ret0, _ := ret[0].([]*cart.AbandonedCart)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PendingRecoveries indicates an expected call of PendingRecoveries.
+func (mr *MockCartRepositoryMockRecorder) PendingRecoveries(ctx, limit, maxAttempts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PendingRecoveries", reflect.TypeOf((*MockCartRepository)(nil).PendingRecoveries), ctx, limit, maxAttempts)
+}
+
+// PurgeGuestCarts mocks base method.
+func (m *MockCartRepository) PurgeGuestCarts(ctx context.Context, idleBefore time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeGuestCarts", ctx, idleBefore)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeGuestCarts indicates an expected call of PurgeGuestCarts.
+func (mr *MockCartRepositoryMockRecorder) PurgeGuestCarts(ctx, idleBefore interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeGuestCarts", reflect.TypeOf((*MockCartRepository)(nil).PurgeGuestCarts), ctx, idleBefore)
+}
+
+// RecoveryStats mocks base method.
+func (m *MockCartRepository) RecoveryStats(ctx context.Context, from, to time.Time) (*cart.RecoveryStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecoveryStats", ctx, from, to)
+	ret0, _ := ret[0].(*cart.RecoveryStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecoveryStats indicates an expected call of RecoveryStats.
+func (mr *MockCartRepositoryMockRecorder) RecoveryStats(ctx, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecoveryStats", reflect.TypeOf((*MockCartRepository)(nil).RecoveryStats), ctx, from, to)
+}
+
 // RemoveItem mocks base method.
 func (m *MockCartRepository) RemoveItem(ctx context.Context, tx *sql.Tx, cartID string, cartItemID int64) error {
 	m.ctrl.T.Helper()
@@ -140,6 +360,63 @@ func (mr *MockCartRepositoryMockRecorder) RemoveItem(ctx, tx, cartID, cartItemID
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveItem", reflect.TypeOf((*MockCartRepository)(nil).RemoveItem), ctx, tx, cartID, cartItemID)
 }
 
+// RenameWishlist mocks base method.
+func (m *MockCartRepository) RenameWishlist(ctx context.Context, userID int64, wishlistID, name string) (*cart.Cart, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenameWishlist", ctx, userID, wishlistID, name)
+	ret0, _ := ret[0].(*cart.Cart)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RenameWishlist indicates an expected call of RenameWishlist.
+func (mr *MockCartRepositoryMockRecorder) RenameWishlist(ctx, userID, wishlistID, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenameWishlist", reflect.TypeOf((*MockCartRepository)(nil).RenameWishlist), ctx, userID, wishlistID, name)
+}
+
+// RepriceItem mocks base method.
+func (m *MockCartRepository) RepriceItem(ctx context.Context, tx *sql.Tx, cartID string, cartItemID int64, quantity int, price float64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RepriceItem", ctx, tx, cartID, cartItemID, quantity, price)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RepriceItem indicates an expected call of RepriceItem.
+func (mr *MockCartRepositoryMockRecorder) RepriceItem(ctx, tx, cartID, cartItemID, quantity, price interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RepriceItem", reflect.TypeOf((*MockCartRepository)(nil).RepriceItem), ctx, tx, cartID, cartItemID, quantity, price)
+}
+
+// RestoreCart mocks base method.
+func (m *MockCartRepository) RestoreCart(ctx context.Context, tx *sql.Tx, cartID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreCart", ctx, tx, cartID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreCart indicates an expected call of RestoreCart.
+func (mr *MockCartRepositoryMockRecorder) RestoreCart(ctx, tx, cartID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreCart", reflect.TypeOf((*MockCartRepository)(nil).RestoreCart), ctx, tx, cartID)
+}
+
+// SetShipping mocks base method.
+func (m *MockCartRepository) SetShipping(ctx context.Context, cartID, method string, country sql.NullString) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetShipping", ctx, cartID, method, country)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetShipping indicates an expected call of SetShipping.
+func (mr *MockCartRepositoryMockRecorder) SetShipping(ctx, cartID, method, country interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetShipping", reflect.TypeOf((*MockCartRepository)(nil).SetShipping), ctx, cartID, method, country)
+}
+
 // UpdateItemQuantity mocks base method.
 func (m *MockCartRepository) UpdateItemQuantity(ctx context.Context, tx *sql.Tx, cartID string, cartItemID int64, quantity int) error {
 	m.ctrl.T.Helper()
@@ -167,3 +444,44 @@ func (mr *MockCartRepositoryMockRecorder) UpsertItem(ctx, tx, item interface{})
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertItem", reflect.TypeOf((*MockCartRepository)(nil).UpsertItem), ctx, tx, item)
 }
+
+// MockrowScanner is a mock of rowScanner interface.
+type MockrowScanner struct {
+	ctrl     *gomock.Controller
+	recorder *MockrowScannerMockRecorder
+}
+
+// MockrowScannerMockRecorder is the mock recorder for MockrowScanner.
+type MockrowScannerMockRecorder struct {
+	mock *MockrowScanner
+}
+
+// NewMockrowScanner creates a new mock instance.
+func NewMockrowScanner(ctrl *gomock.Controller) *MockrowScanner {
+	mock := &MockrowScanner{ctrl: ctrl}
+	mock.recorder = &MockrowScannerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockrowScanner) EXPECT() *MockrowScannerMockRecorder {
+	return m.recorder
+}
+
+// Scan mocks base method.
+func (m *MockrowScanner) Scan(dest ...any) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range dest {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Scan", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Scan indicates an expected call of Scan.
+func (mr *MockrowScannerMockRecorder) Scan(dest ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockrowScanner)(nil).Scan), dest...)
+}
@@ -5,10 +5,16 @@ import (
 	"database/sql"
 	"errors"
 	"math"
+	"time"
 
 	"github.com/codepnw/mini-ecommerce/internal/cart"
 	cartrepository "github.com/codepnw/mini-ecommerce/internal/cart/repository"
+	"github.com/codepnw/mini-ecommerce/internal/inventory"
+	inventoryrepository "github.com/codepnw/mini-ecommerce/internal/inventory/repository"
+	"github.com/codepnw/mini-ecommerce/internal/product"
 	productrepository "github.com/codepnw/mini-ecommerce/internal/product/repository"
+	"github.com/codepnw/mini-ecommerce/internal/shipping"
+	shippingusecase "github.com/codepnw/mini-ecommerce/internal/shipping/usecase"
 	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
 	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
 	"github.com/codepnw/mini-ecommerce/pkg/auth"
@@ -20,21 +26,58 @@ type CartUsecase interface {
 	GetCart(ctx context.Context) (*CartView, error)
 	UpdateItemQuantity(ctx context.Context, cartItemID int64, newQuantity int) (*CartView, error)
 	RemoveItemFromCart(ctx context.Context, cartItemID int64) (*CartView, error)
+	AcceptChanges(ctx context.Context) (*CartView, error)
+
+	// Shipping
+	SetShipping(ctx context.Context, method shipping.Method, country string) (*CartView, error)
+	ShippingOptions(ctx context.Context, country string) ([]*shipping.Quote, error)
+
+	// Saved for later
+	SaveForLater(ctx context.Context, cartItemID int64) (*CartView, error)
+	GetSavedItems(ctx context.Context) (*CartView, error)
+	MoveSavedToCart(ctx context.Context, cartItemID int64) (*CartView, error)
+	RemoveSavedItem(ctx context.Context, cartItemID int64) (*CartView, error)
+
+	// Wishlists
+	ListWishlists(ctx context.Context) ([]*cart.Cart, error)
+	CreateWishlist(ctx context.Context, name string) (*cart.Cart, error)
+	GetWishlist(ctx context.Context, wishlistID string) (*CartView, error)
+	RenameWishlist(ctx context.Context, wishlistID, name string) (*cart.Cart, error)
+	DeleteWishlist(ctx context.Context, wishlistID string) error
+	AddWishlistItem(ctx context.Context, wishlistID string, productID int64, quantity int) (*CartView, error)
+	RemoveWishlistItem(ctx context.Context, wishlistID string, cartItemID int64) (*CartView, error)
+	MoveWishlistItemToCart(ctx context.Context, wishlistID string, cartItemID int64) (*CartView, error)
 }
 
 type cartUsecase struct {
-	cartRepo    cartrepository.CartRepository
-	productRepo productrepository.ProductRepository
-	tx          database.TxManager
-	db          database.DBExec
+	cartRepo      cartrepository.CartRepository
+	productRepo   productrepository.ProductRepository
+	inventoryRepo inventoryrepository.InventoryRepository
+	shipping      shippingusecase.ShippingUsecase
+	tx            database.TxManager
+	db            database.DBExec
+	holdTTL       time.Duration
 }
 
-func NewCartUsecase(cartRepo cartrepository.CartRepository, productRepo productrepository.ProductRepository, tx database.TxManager, db database.DBExec) CartUsecase {
+// NewCartUsecase holdTTL > 0 enables reservation mode, stock is held
+// for the cart line until it expires
+func NewCartUsecase(
+	cartRepo cartrepository.CartRepository,
+	productRepo productrepository.ProductRepository,
+	inventoryRepo inventoryrepository.InventoryRepository,
+	shipping shippingusecase.ShippingUsecase,
+	tx database.TxManager,
+	db database.DBExec,
+	holdTTL time.Duration,
+) CartUsecase {
 	return &cartUsecase{
-		cartRepo:    cartRepo,
-		productRepo: productRepo,
-		tx:          tx,
-		db:          db,
+		cartRepo:      cartRepo,
+		productRepo:   productRepo,
+		inventoryRepo: inventoryRepo,
+		shipping:      shipping,
+		tx:            tx,
+		db:            db,
+		holdTTL:       holdTTL,
 	}
 }
 
@@ -50,6 +93,9 @@ func (u *cartUsecase) AddItemToCart(ctx context.Context, productID int64, quanti
 		}
 		return nil, err
 	}
+	if !product.IsAvailable() {
+		return nil, errs.ErrProductUnavailable
+	}
 	if product.Stock < quantity {
 		return nil, errs.ErrProductNotEnough
 	}
@@ -73,7 +119,23 @@ func (u *cartUsecase) AddItemToCart(ctx context.Context, productID int64, quanti
 			Quantity:   quantity,
 			PriceAtAdd: product.Price,
 		}
-		return u.cartRepo.UpsertItem(ctx, tx, item)
+		if err := u.cartRepo.UpsertItem(ctx, tx, item); err != nil {
+			return err
+		}
+		// Rules apply to the line total after the upsert
+		if err := u.checkRules(ctx, tx, product, item.Quantity); err != nil {
+			return err
+		}
+		if u.holdTTL <= 0 {
+			return nil
+		}
+
+		// Reservation Mode: lock product, concurrent holds wait here
+		locked, err := u.productRepo.FindByIDForUpdate(ctx, tx, productID)
+		if err != nil {
+			return err
+		}
+		return u.hold(ctx, tx, cartData.ID, item.ID, locked, item.Quantity)
 	})
 	if err != nil {
 		return nil, err
@@ -91,19 +153,32 @@ type CartItemView struct {
 	Price float64 `json:"price"`
 	Stock int     `json:"stock"`
 	SKU   string  `json:"sku"`
+	// Shipping
+	Parcel product.Parcel `json:"-"`
 	// Validation
 	PriceAtAdd     float64 `json:"-"`
 	IsPriceChanged bool    `json:"is_price_changed"`
 	IsOutOfStock   bool    `json:"is_out_of_stock"`
+	IsUnavailable  bool    `json:"is_unavailable"`
+	// Reservation Mode
+	ReservedUntil *time.Time `json:"reserved_until,omitempty"`
 }
 
 type CartView struct {
 	CartID     string          `json:"cart_id"`
+	Name       string          `json:"name,omitempty"`
 	UserID     *int64          `json:"user_id"`
 	Items      []*CartItemView `json:"items"`
 	TotalPrice float64         `json:"total_price"`
 	TotalItems int             `json:"total_items"`
 	HasChanged bool            `json:"has_changed"`
+
+	// Active cart only, ShippingError is why the chosen method
+	// cannot ship the cart
+	Shipping      *shipping.Quote `json:"shipping,omitempty"`
+	ShippingError string          `json:"shipping_error,omitempty"`
+	// GrandTotal items & shipping
+	GrandTotal float64 `json:"grand_total"`
 }
 
 func (u *cartUsecase) GetCart(ctx context.Context) (*CartView, error) {
@@ -124,6 +199,20 @@ func (u *cartUsecase) getCartView(ctx context.Context) (*CartView, error) {
 	if err != nil {
 		return nil, err
 	}
+	view, err := u.buildCartView(ctx, cartData)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.priceShipping(ctx, cartData, view); err != nil {
+		return nil, err
+	}
+	return view, nil
+}
+
+// buildCartView items with the current price & stock, shared by the
+// active cart, the saved-for-later list and wishlists
+func (u *cartUsecase) buildCartView(ctx context.Context, cartData *cart.Cart) (*CartView, error) {
+	userID := auth.GetUserID(ctx)
 
 	// Get Items
 	items, err := u.cartRepo.GetCartItems(ctx, u.db, cartData.ID)
@@ -142,8 +231,10 @@ func (u *cartUsecase) getCartView(ctx context.Context) (*CartView, error) {
 		isOutOfStock := item.Quantity > item.Stock
 		// Check Current Price
 		isPriceChanged := math.Abs(item.PriceAtAdd-item.Price) > 0
+		// Check Archived or Deleted
+		isUnavailable := !item.IsAvailable()
 
-		if isOutOfStock || isPriceChanged {
+		if isOutOfStock || isPriceChanged || isUnavailable {
 			hasChanged = true
 		}
 
@@ -161,13 +252,18 @@ func (u *cartUsecase) getCartView(ctx context.Context) (*CartView, error) {
 			Price:          item.Price,
 			Stock:          item.Stock,
 			SKU:            finalSKU,
+			Parcel:         item.Parcel,
 			PriceAtAdd:     item.PriceAtAdd,
 			IsPriceChanged: isPriceChanged,
 			IsOutOfStock:   isOutOfStock,
+			IsUnavailable:  isUnavailable,
+		}
+		if item.ReservedUntil.Valid && item.ReservedUntil.Time.After(time.Now()) {
+			viewItem.ReservedUntil = &item.ReservedUntil.Time
 		}
 		finalItems = append(finalItems, viewItem)
 
-		if !isOutOfStock {
+		if !isOutOfStock && !isUnavailable {
 			totalPrice += (item.Price * float64(item.Quantity))
 		}
 		totalItems += item.Quantity
@@ -180,11 +276,13 @@ func (u *cartUsecase) getCartView(ctx context.Context) (*CartView, error) {
 
 	return &CartView{
 		CartID:     cartData.ID,
+		Name:       cartData.Name.String,
 		UserID:     finalUserID,
 		Items:      finalItems,
 		TotalPrice: totalPrice,
 		TotalItems: totalItems,
 		HasChanged: hasChanged,
+		GrandTotal: totalPrice,
 	}, nil
 }
 
@@ -221,8 +319,14 @@ func (u *cartUsecase) UpdateItemQuantity(ctx context.Context, cartItemID int64,
 			}
 			return err
 		}
-		if product.Stock < newQuantity {
-			return errs.ErrProductNotEnough
+		if !product.IsAvailable() {
+			return errs.ErrProductUnavailable
+		}
+		if err := u.checkRules(ctx, tx, product, newQuantity); err != nil {
+			return err
+		}
+		if err := u.hold(ctx, tx, cartData.ID, cartItemID, product, newQuantity); err != nil {
+			return err
 		}
 
 		return u.cartRepo.UpdateItemQuantity(ctx, tx, cartData.ID, cartItemID, newQuantity)
@@ -257,3 +361,137 @@ func (u *cartUsecase) RemoveItemFromCart(ctx context.Context, cartItemID int64)
 
 	return u.getCartView(ctx)
 }
+
+// AcceptChanges takes the current price of every line and clamps quantities
+// to the available stock, lines with nothing left to buy are removed
+func (u *cartUsecase) AcceptChanges(ctx context.Context) (*CartView, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	userID := auth.GetUserID(ctx)
+	sessionID := auth.GetSessionID(ctx)
+	nullUserID := sql.NullInt64{Int64: userID, Valid: userID > 0}
+	nullSessionID := sql.NullString{String: sessionID, Valid: sessionID != ""}
+
+	cartData, err := u.cartRepo.GetOrCreateActiveCart(ctx, nullUserID, nullSessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.tx.WithTransaction(ctx, func(tx *sql.Tx) error {
+		items, err := u.cartRepo.GetCartItems(ctx, tx, cartData.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			// Same lock order as checkout
+			product, err := u.productRepo.FindByIDForUpdate(ctx, tx, item.ProductID)
+			if err != nil {
+				return err
+			}
+
+			available := 0
+			if product.IsAvailable() {
+				available, err = u.available(ctx, tx, cartData.ID, product)
+				if err != nil {
+					return err
+				}
+			}
+			// Largest quantity in stock that checkout accepts
+			purchased, err := u.purchased(ctx, tx, product)
+			if err != nil {
+				return err
+			}
+			quantity := product.Rules.Allowed(min(item.Quantity, available), purchased)
+
+			if quantity <= 0 {
+				if err := u.cartRepo.RemoveItem(ctx, tx, cartData.ID, item.CartItemID); err != nil {
+					return err
+				}
+				continue
+			}
+			if quantity == item.Quantity && math.Abs(item.PriceAtAdd-product.Price) == 0 {
+				continue
+			}
+			if err := u.cartRepo.RepriceItem(ctx, tx, cartData.ID, item.CartItemID, quantity, product.Price); err != nil {
+				return err
+			}
+			if quantity != item.Quantity {
+				if err := u.reserve(ctx, tx, cartData.ID, item.CartItemID, product.ID, quantity); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return u.getCartView(ctx)
+}
+
+// available stock the cart line can take, in reservation mode other carts'
+// active holds are not available. Product must be locked by the caller.
+func (u *cartUsecase) available(ctx context.Context, tx *sql.Tx, cartID string, productData *product.Product) (int, error) {
+	if u.holdTTL <= 0 {
+		return productData.Stock, nil
+	}
+
+	reserved, err := u.inventoryRepo.ReservedQuantity(ctx, tx, productData.ID, cartID)
+	if err != nil {
+		return 0, err
+	}
+	return productData.Stock - reserved, nil
+}
+
+// checkRules purchase rules of the cart line, guests are checked against
+// the customer limit at checkout
+func (u *cartUsecase) checkRules(ctx context.Context, tx *sql.Tx, productData *product.Product, quantity int) error {
+	purchased, err := u.purchased(ctx, tx, productData)
+	if err != nil {
+		return err
+	}
+	return productData.Rules.Check(productData.ID, quantity, purchased)
+}
+
+// purchased of the product by the signed in user, only looked up when the
+// product has a customer limit
+func (u *cartUsecase) purchased(ctx context.Context, tx *sql.Tx, productData *product.Product) (int, error) {
+	userID := auth.GetUserID(ctx)
+	if userID <= 0 || productData.Rules.CustomerLimit <= 0 {
+		return 0, nil
+	}
+	return u.productRepo.PurchasedQuantity(ctx, tx, userID, productData.ID)
+}
+
+// hold checks stock for the cart line, in reservation mode the quantity is
+// held and other carts' active holds are not available. Product must be
+// locked by the caller.
+func (u *cartUsecase) hold(ctx context.Context, tx *sql.Tx, cartID string, cartItemID int64, productData *product.Product, quantity int) error {
+	available, err := u.available(ctx, tx, cartID, productData)
+	if err != nil {
+		return err
+	}
+	if available < quantity {
+		return errs.ErrProductNotEnough
+	}
+	return u.reserve(ctx, tx, cartID, cartItemID, productData.ID, quantity)
+}
+
+// reserve creates or moves the hold of the cart line, no-op when
+// reservation mode is off
+func (u *cartUsecase) reserve(ctx context.Context, tx *sql.Tx, cartID string, cartItemID, productID int64, quantity int) error {
+	if u.holdTTL <= 0 {
+		return nil
+	}
+
+	return u.inventoryRepo.UpsertReservation(ctx, tx, &inventory.Reservation{
+		CartItemID: cartItemID,
+		CartID:     cartID,
+		ProductID:  productID,
+		Quantity:   quantity,
+		ExpiresAt:  time.Now().Add(u.holdTTL),
+	})
+}
@@ -5,12 +5,18 @@ import (
 	"database/sql"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/codepnw/mini-ecommerce/internal/cart"
 	cartrepository "github.com/codepnw/mini-ecommerce/internal/cart/repository"
 	cartusecase "github.com/codepnw/mini-ecommerce/internal/cart/usecase"
+	"github.com/codepnw/mini-ecommerce/internal/inventory"
+	inventoryrepository "github.com/codepnw/mini-ecommerce/internal/inventory/repository"
 	"github.com/codepnw/mini-ecommerce/internal/product"
 	productrepository "github.com/codepnw/mini-ecommerce/internal/product/repository"
+	"github.com/codepnw/mini-ecommerce/internal/shipping"
+	shippingrepository "github.com/codepnw/mini-ecommerce/internal/shipping/repository"
+	shippingusecase "github.com/codepnw/mini-ecommerce/internal/shipping/usecase"
 	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
 	"github.com/codepnw/mini-ecommerce/pkg/auth"
 	"github.com/codepnw/mini-ecommerce/pkg/jwt"
@@ -93,8 +99,9 @@ func TestAddItemToCart(t *testing.T) {
 			quantity:  5,
 			mockFn: func(mockCartRepo *cartrepository.MockCartRepository, mockProdRepo *productrepository.MockProductRepository, productID int64, quantity int) {
 				mockProd := &product.Product{
-					ID:    101,
-					Stock: 10,
+					ID:     101,
+					Stock:  10,
+					Status: product.StatusPublished,
 				}
 				mockProdRepo.EXPECT().FindByID(gomock.Any(), int64(101)).Return(mockProd, nil).Times(1)
 
@@ -229,6 +236,75 @@ func TestUpdateItemQuantity(t *testing.T) {
 	}
 }
 
+func TestAddItemToCartReservation(t *testing.T) {
+	type testCase struct {
+		name        string
+		quantity    int
+		reserved    int
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name:        "success hold created",
+			quantity:    5,
+			reserved:    10,
+			expectedErr: nil,
+		},
+		{
+			name:        "fail stock held by other carts",
+			quantity:    5,
+			reserved:    18,
+			expectedErr: errs.ErrProductNotEnough,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, mockCartRepo, mockProdRepo, mockInvRepo := setupReservation(t)
+
+			p := mockProduct()
+			c := mockCart()
+			mockProdRepo.EXPECT().FindByID(gomock.Any(), p.ID).Return(p, nil).Times(1)
+			mockCartRepo.EXPECT().GetOrCreateActiveCart(gomock.Any(), gomock.Any(), gomock.Any()).Return(c, nil).Times(1)
+
+			mockCartRepo.EXPECT().UpsertItem(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ *sql.Tx, item *cart.CartItem) error {
+					item.ID = 500
+					return nil
+				},
+			).Times(1)
+			mockProdRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), p.ID).Return(p, nil).Times(1)
+			mockInvRepo.EXPECT().ReservedQuantity(gomock.Any(), gomock.Any(), p.ID, c.ID).Return(tc.reserved, nil).Times(1)
+
+			if tc.expectedErr == nil {
+				mockInvRepo.EXPECT().UpsertReservation(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ *sql.Tx, r *inventory.Reservation) error {
+						assert.Equal(t, int64(500), r.CartItemID)
+						assert.Equal(t, tc.quantity, r.Quantity)
+						assert.True(t, r.ExpiresAt.After(time.Now()))
+						return nil
+					},
+				).Times(1)
+
+				// Return getCartView
+				mockCartRepo.EXPECT().GetOrCreateActiveCart(gomock.Any(), gomock.Any(), gomock.Any()).Return(c, nil).Times(1)
+				mockCartRepo.EXPECT().GetCartItems(gomock.Any(), gomock.Any(), c.ID).Return(mockCartItems(), nil).Times(1)
+			}
+
+			result, err := uc.AddItemToCart(mockUser(), p.ID, tc.quantity)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
 func TestRemoveItemFromCart(t *testing.T) {
 	type testCase struct {
 		name        string
@@ -289,6 +365,462 @@ func TestRemoveItemFromCart(t *testing.T) {
 	}
 }
 
+func TestSaveForLater(t *testing.T) {
+	type testCase struct {
+		name        string
+		ctx         context.Context
+		cartItemID  int64
+		mockFn      func(mockCartRepo *cartrepository.MockCartRepository, mockProdRepo *productrepository.MockProductRepository, cartItemID int64)
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name:       "success",
+			ctx:        mockUserID(),
+			cartItemID: 100,
+			mockFn: func(mockCartRepo *cartrepository.MockCartRepository, mockProdRepo *productrepository.MockProductRepository, cartItemID int64) {
+				active := mockActiveCart()
+				saved := mockSavedCart()
+				mockCartRepo.EXPECT().GetOrCreateActiveCart(gomock.Any(), gomock.Any(), gomock.Any()).Return(active, nil).Times(1)
+				mockCartRepo.EXPECT().GetOrCreateSavedCart(gomock.Any(), int64(10)).Return(saved, nil).Times(1)
+
+				item := &cart.CartItem{ID: cartItemID, CartID: active.ID, ProductID: 101, Quantity: 2}
+				mockCartRepo.EXPECT().GetCartItemForUpdate(gomock.Any(), gomock.Any(), cartItemID, active.ID).Return(item, nil).Times(1)
+				p := mockProduct()
+				p.Price = 250
+				mockProdRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), item.ProductID).Return(p, nil).Times(1)
+				mockCartRepo.EXPECT().RemoveItem(gomock.Any(), gomock.Any(), active.ID, cartItemID).Return(nil).Times(1)
+				mockCartRepo.EXPECT().UpsertItem(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ *sql.Tx, moved *cart.CartItem) error {
+						assert.Equal(t, saved.ID, moved.CartID)
+						assert.Equal(t, 2, moved.Quantity)
+						assert.Equal(t, 250.0, moved.PriceAtAdd)
+						return nil
+					},
+				).Times(1)
+
+				// Return getCartView
+				mockCartRepo.EXPECT().GetOrCreateActiveCart(gomock.Any(), gomock.Any(), gomock.Any()).Return(active, nil).Times(1)
+				mockCartRepo.EXPECT().GetCartItems(gomock.Any(), gomock.Any(), active.ID).Return(mockCartItems(), nil).Times(1)
+			},
+			expectedErr: nil,
+		},
+		{
+			name:        "fail guest",
+			ctx:         context.Background(),
+			cartItemID:  100,
+			mockFn:      func(mockCartRepo *cartrepository.MockCartRepository, mockProdRepo *productrepository.MockProductRepository, cartItemID int64) {},
+			expectedErr: errs.ErrUnauthorized,
+		},
+		{
+			name:       "fail item not in cart",
+			ctx:        mockUserID(),
+			cartItemID: 100,
+			mockFn: func(mockCartRepo *cartrepository.MockCartRepository, mockProdRepo *productrepository.MockProductRepository, cartItemID int64) {
+				active := mockActiveCart()
+				mockCartRepo.EXPECT().GetOrCreateActiveCart(gomock.Any(), gomock.Any(), gomock.Any()).Return(active, nil).Times(1)
+				mockCartRepo.EXPECT().GetOrCreateSavedCart(gomock.Any(), int64(10)).Return(mockSavedCart(), nil).Times(1)
+				mockCartRepo.EXPECT().GetCartItemForUpdate(gomock.Any(), gomock.Any(), cartItemID, active.ID).Return(nil, errs.ErrItemNotInCart).Times(1)
+			},
+			expectedErr: errs.ErrItemNotInCart,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, mockCartRepo, mockProdRepo, _ := setup(t)
+
+			tc.mockFn(mockCartRepo, mockProdRepo, tc.cartItemID)
+
+			result, err := uc.SaveForLater(tc.ctx, tc.cartItemID)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestMoveSavedToCartReservation(t *testing.T) {
+	type testCase struct {
+		name        string
+		reserved    int
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name:        "success hold created",
+			reserved:    0,
+			expectedErr: nil,
+		},
+		{
+			name:        "fail stock held by other carts",
+			reserved:    19,
+			expectedErr: errs.ErrProductNotEnough,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, mockCartRepo, mockProdRepo, mockInvRepo := setupReservation(t)
+
+			active := mockActiveCart()
+			saved := mockSavedCart()
+			p := mockProduct()
+			mockCartRepo.EXPECT().GetOrCreateSavedCart(gomock.Any(), int64(10)).Return(saved, nil).Times(1)
+			mockCartRepo.EXPECT().GetOrCreateActiveCart(gomock.Any(), gomock.Any(), gomock.Any()).Return(active, nil).Times(1)
+
+			item := &cart.CartItem{ID: 300, CartID: saved.ID, ProductID: p.ID, Quantity: 2}
+			mockCartRepo.EXPECT().GetCartItemForUpdate(gomock.Any(), gomock.Any(), item.ID, saved.ID).Return(item, nil).Times(1)
+			mockProdRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), p.ID).Return(p, nil).Times(1)
+			mockCartRepo.EXPECT().RemoveItem(gomock.Any(), gomock.Any(), saved.ID, item.ID).Return(nil).Times(1)
+			mockCartRepo.EXPECT().UpsertItem(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ *sql.Tx, moved *cart.CartItem) error {
+					moved.ID = 500
+					return nil
+				},
+			).Times(1)
+			mockInvRepo.EXPECT().ReservedQuantity(gomock.Any(), gomock.Any(), p.ID, active.ID).Return(tc.reserved, nil).Times(1)
+
+			if tc.expectedErr == nil {
+				mockInvRepo.EXPECT().UpsertReservation(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ *sql.Tx, r *inventory.Reservation) error {
+						assert.Equal(t, int64(500), r.CartItemID)
+						assert.Equal(t, active.ID, r.CartID)
+						return nil
+					},
+				).Times(1)
+
+				// Return getCartView
+				mockCartRepo.EXPECT().GetOrCreateActiveCart(gomock.Any(), gomock.Any(), gomock.Any()).Return(active, nil).Times(1)
+				mockCartRepo.EXPECT().GetCartItems(gomock.Any(), gomock.Any(), active.ID).Return(mockCartItems(), nil).Times(1)
+			}
+
+			result, err := uc.MoveSavedToCart(mockUserID(), item.ID)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestCreateWishlist(t *testing.T) {
+	type testCase struct {
+		name        string
+		wishlist    string
+		mockFn      func(mockCartRepo *cartrepository.MockCartRepository)
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name:     "success",
+			wishlist: "  Birthday  ",
+			mockFn: func(mockCartRepo *cartrepository.MockCartRepository) {
+				mockCartRepo.EXPECT().CreateWishlist(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, c *cart.Cart) error {
+						assert.Equal(t, "Birthday", c.Name.String)
+						assert.Equal(t, int64(10), c.UserID.Int64)
+						c.ID = "uuid-wishlist-id"
+						return nil
+					},
+				).Times(1)
+			},
+			expectedErr: nil,
+		},
+		{
+			name:        "fail name required",
+			wishlist:    "   ",
+			mockFn:      func(mockCartRepo *cartrepository.MockCartRepository) {},
+			expectedErr: errs.ErrWishlistNameRequired,
+		},
+		{
+			name:     "fail name exists",
+			wishlist: "Birthday",
+			mockFn: func(mockCartRepo *cartrepository.MockCartRepository) {
+				mockCartRepo.EXPECT().CreateWishlist(gomock.Any(), gomock.Any()).Return(errs.ErrWishlistNameExists).Times(1)
+			},
+			expectedErr: errs.ErrWishlistNameExists,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, mockCartRepo, _, _ := setup(t)
+
+			tc.mockFn(mockCartRepo)
+
+			result, err := uc.CreateWishlist(mockUserID(), tc.wishlist)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "uuid-wishlist-id", result.ID)
+			}
+		})
+	}
+}
+
+func TestGetWishlist(t *testing.T) {
+	wishlistID := "6f1c2b8e-2a4d-4c1e-9a51-3d7c2e8f9b10"
+
+	type testCase struct {
+		name        string
+		wishlistID  string
+		mockFn      func(mockCartRepo *cartrepository.MockCartRepository)
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name:       "success current price and stock",
+			wishlistID: wishlistID,
+			mockFn: func(mockCartRepo *cartrepository.MockCartRepository) {
+				w := &cart.Cart{ID: wishlistID, Name: sql.NullString{String: "Birthday", Valid: true}}
+				mockCartRepo.EXPECT().GetWishlist(gomock.Any(), int64(10), wishlistID).Return(w, nil).Times(1)
+				items := []*cartrepository.CartItemDB{
+					{CartItemID: 1, Quantity: 1, PriceAtAdd: 100, Price: 120, Stock: 5, Status: "published"},
+				}
+				mockCartRepo.EXPECT().GetCartItems(gomock.Any(), gomock.Any(), wishlistID).Return(items, nil).Times(1)
+			},
+			expectedErr: nil,
+		},
+		{
+			name:        "fail malformed id",
+			wishlistID:  "not-a-uuid",
+			mockFn:      func(mockCartRepo *cartrepository.MockCartRepository) {},
+			expectedErr: errs.ErrWishlistNotFound,
+		},
+		{
+			name:       "fail other user's wishlist",
+			wishlistID: wishlistID,
+			mockFn: func(mockCartRepo *cartrepository.MockCartRepository) {
+				mockCartRepo.EXPECT().GetWishlist(gomock.Any(), int64(10), wishlistID).Return(nil, errs.ErrWishlistNotFound).Times(1)
+			},
+			expectedErr: errs.ErrWishlistNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, mockCartRepo, _, _ := setup(t)
+
+			tc.mockFn(mockCartRepo)
+
+			result, err := uc.GetWishlist(mockUserID(), tc.wishlistID)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "Birthday", result.Name)
+				assert.Equal(t, 120.0, result.Items[0].Price)
+				assert.True(t, result.Items[0].IsPriceChanged)
+				assert.True(t, result.HasChanged)
+			}
+		})
+	}
+}
+
+func TestAcceptChanges(t *testing.T) {
+	uc, mockCartRepo, mockProdRepo, _ := setup(t)
+
+	c := mockCart()
+	mockCartRepo.EXPECT().GetOrCreateActiveCart(gomock.Any(), gomock.Any(), gomock.Any()).Return(c, nil).Times(1)
+
+	items := []*cartrepository.CartItemDB{
+		{CartItemID: 1, ProductID: 11, Quantity: 2, PriceAtAdd: 100}, // price went up
+		{CartItemID: 2, ProductID: 12, Quantity: 5, PriceAtAdd: 50},  // stock dropped
+		{CartItemID: 3, ProductID: 13, Quantity: 1, PriceAtAdd: 30},  // unchanged
+		{CartItemID: 4, ProductID: 14, Quantity: 1, PriceAtAdd: 10},  // sold out
+	}
+	mockCartRepo.EXPECT().GetCartItems(gomock.Any(), gomock.Any(), c.ID).Return(items, nil).Times(1)
+
+	products := []*product.Product{
+		{ID: 11, Price: 120, Stock: 10, Status: product.StatusPublished},
+		{ID: 12, Price: 50, Stock: 3, Status: product.StatusPublished},
+		{ID: 13, Price: 30, Stock: 10, Status: product.StatusPublished},
+		{ID: 14, Price: 10, Stock: 0, Status: product.StatusPublished},
+	}
+	for _, p := range products {
+		mockProdRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), p.ID).Return(p, nil).Times(1)
+	}
+
+	mockCartRepo.EXPECT().RepriceItem(gomock.Any(), gomock.Any(), c.ID, int64(1), 2, 120.0).Return(nil).Times(1)
+	mockCartRepo.EXPECT().RepriceItem(gomock.Any(), gomock.Any(), c.ID, int64(2), 3, 50.0).Return(nil).Times(1)
+	mockCartRepo.EXPECT().RemoveItem(gomock.Any(), gomock.Any(), c.ID, int64(4)).Return(nil).Times(1)
+
+	// Return getCartView
+	mockCartRepo.EXPECT().GetOrCreateActiveCart(gomock.Any(), gomock.Any(), gomock.Any()).Return(c, nil).Times(1)
+	mockCartRepo.EXPECT().GetCartItems(gomock.Any(), gomock.Any(), c.ID).Return(mockCartItems(), nil).Times(1)
+
+	result, err := uc.AcceptChanges(context.Background())
+
+	assert.NoError(t, err)
+	assert.NotNil(t, result)
+}
+
+func TestAcceptChangesReservation(t *testing.T) {
+	uc, mockCartRepo, mockProdRepo, mockInvRepo := setupReservation(t)
+
+	c := mockCart()
+	p := mockProduct()
+	mockCartRepo.EXPECT().GetOrCreateActiveCart(gomock.Any(), gomock.Any(), gomock.Any()).Return(c, nil).Times(1)
+
+	items := []*cartrepository.CartItemDB{
+		{CartItemID: 1, ProductID: p.ID, Quantity: 8, PriceAtAdd: p.Price},
+	}
+	mockCartRepo.EXPECT().GetCartItems(gomock.Any(), gomock.Any(), c.ID).Return(items, nil).Times(1)
+	mockProdRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), p.ID).Return(p, nil).Times(1)
+	// 15 of 20 held by other carts
+	mockInvRepo.EXPECT().ReservedQuantity(gomock.Any(), gomock.Any(), p.ID, c.ID).Return(15, nil).Times(1)
+
+	mockCartRepo.EXPECT().RepriceItem(gomock.Any(), gomock.Any(), c.ID, int64(1), 5, p.Price).Return(nil).Times(1)
+	mockInvRepo.EXPECT().UpsertReservation(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ *sql.Tx, r *inventory.Reservation) error {
+			assert.Equal(t, int64(1), r.CartItemID)
+			assert.Equal(t, 5, r.Quantity)
+			return nil
+		},
+	).Times(1)
+
+	// Return getCartView
+	mockCartRepo.EXPECT().GetOrCreateActiveCart(gomock.Any(), gomock.Any(), gomock.Any()).Return(c, nil).Times(1)
+	mockCartRepo.EXPECT().GetCartItems(gomock.Any(), gomock.Any(), c.ID).Return(mockCartItems(), nil).Times(1)
+
+	result, err := uc.AcceptChanges(context.Background())
+
+	assert.NoError(t, err)
+	assert.NotNil(t, result)
+}
+
+func TestAcceptChangesPurchaseRules(t *testing.T) {
+	uc, mockCartRepo, mockProdRepo, _ := setup(t)
+
+	c := mockCart()
+	mockCartRepo.EXPECT().GetOrCreateActiveCart(gomock.Any(), gomock.Any(), gomock.Any()).Return(c, nil).Times(1)
+
+	items := []*cartrepository.CartItemDB{
+		{CartItemID: 1, ProductID: 11, Quantity: 18, PriceAtAdd: 10}, // pack of 6, 13 left
+		{CartItemID: 2, ProductID: 12, Quantity: 6, PriceAtAdd: 10},  // pack of 6, 5 left
+		{CartItemID: 3, ProductID: 13, Quantity: 4, PriceAtAdd: 10},  // min 3, 2 left
+	}
+	mockCartRepo.EXPECT().GetCartItems(gomock.Any(), gomock.Any(), c.ID).Return(items, nil).Times(1)
+
+	products := []*product.Product{
+		{ID: 11, Price: 10, Stock: 13, Status: product.StatusPublished, Rules: product.PurchaseRules{QuantityStep: 6}},
+		{ID: 12, Price: 10, Stock: 5, Status: product.StatusPublished, Rules: product.PurchaseRules{QuantityStep: 6}},
+		{ID: 13, Price: 10, Stock: 2, Status: product.StatusPublished, Rules: product.PurchaseRules{MinQuantity: 3}},
+	}
+	for _, p := range products {
+		mockProdRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), p.ID).Return(p, nil).Times(1)
+	}
+
+	mockCartRepo.EXPECT().RepriceItem(gomock.Any(), gomock.Any(), c.ID, int64(1), 12, 10.0).Return(nil).Times(1)
+	mockCartRepo.EXPECT().RemoveItem(gomock.Any(), gomock.Any(), c.ID, int64(2)).Return(nil).Times(1)
+	mockCartRepo.EXPECT().RemoveItem(gomock.Any(), gomock.Any(), c.ID, int64(3)).Return(nil).Times(1)
+
+	// Return getCartView
+	mockCartRepo.EXPECT().GetOrCreateActiveCart(gomock.Any(), gomock.Any(), gomock.Any()).Return(c, nil).Times(1)
+	mockCartRepo.EXPECT().GetCartItems(gomock.Any(), gomock.Any(), c.ID).Return(mockCartItems(), nil).Times(1)
+
+	result, err := uc.AcceptChanges(context.Background())
+
+	assert.NoError(t, err)
+	assert.NotNil(t, result)
+}
+
+func TestAddItemToCartPurchaseRules(t *testing.T) {
+	type testCase struct {
+		name         string
+		rules        product.PurchaseRules
+		quantity     int
+		purchased    int
+		expectedRule product.PurchaseRule
+	}
+
+	testCases := []testCase{
+		{
+			name:     "success within rules",
+			rules:    product.PurchaseRules{MinQuantity: 2, MaxQuantity: 6, QuantityStep: 2, CustomerLimit: 10},
+			quantity: 4,
+		},
+		{
+			name:         "fail below minimum",
+			rules:        product.PurchaseRules{MinQuantity: 2},
+			quantity:     1,
+			expectedRule: product.RuleMinQuantity,
+		},
+		{
+			name:         "fail above maximum",
+			rules:        product.PurchaseRules{MaxQuantity: 2},
+			quantity:     3,
+			expectedRule: product.RuleMaxQuantity,
+		},
+		{
+			name:         "fail off step",
+			rules:        product.PurchaseRules{QuantityStep: 6},
+			quantity:     4,
+			expectedRule: product.RuleQuantityStep,
+		},
+		{
+			name:         "fail customer limit reached",
+			rules:        product.PurchaseRules{CustomerLimit: 4},
+			quantity:     2,
+			purchased:    3,
+			expectedRule: product.RuleCustomerLimit,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, mockCartRepo, mockProdRepo, _ := setup(t)
+
+			p := mockProduct()
+			p.Rules = tc.rules
+			c := mockActiveCart()
+			mockProdRepo.EXPECT().FindByID(gomock.Any(), p.ID).Return(p, nil).Times(1)
+			mockCartRepo.EXPECT().GetOrCreateActiveCart(gomock.Any(), gomock.Any(), gomock.Any()).Return(c, nil).Times(1)
+			mockCartRepo.EXPECT().UpsertItem(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+
+			if tc.rules.CustomerLimit > 0 {
+				mockProdRepo.EXPECT().PurchasedQuantity(gomock.Any(), gomock.Any(), int64(10), p.ID).Return(tc.purchased, nil).Times(1)
+			}
+			if tc.expectedRule == "" {
+				mockCartRepo.EXPECT().GetOrCreateActiveCart(gomock.Any(), gomock.Any(), gomock.Any()).Return(c, nil).Times(1)
+				mockCartRepo.EXPECT().GetCartItems(gomock.Any(), gomock.Any(), c.ID).Return(mockCartItems(), nil).Times(1)
+			}
+
+			result, err := uc.AddItemToCart(mockUserID(), p.ID, tc.quantity)
+
+			if tc.expectedRule != "" {
+				var ruleErr *product.RuleError
+				assert.ErrorIs(t, err, errs.ErrPurchaseRule)
+				assert.ErrorAs(t, err, &ruleErr)
+				assert.Equal(t, tc.expectedRule, ruleErr.Rule)
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
 // =================== Helper ==============
 // -----------------------------------------
 func setup(t *testing.T) (cartusecase.CartUsecase, *cartrepository.MockCartRepository, *productrepository.MockProductRepository, *mockTxManager) {
@@ -299,13 +831,49 @@ func setup(t *testing.T) (cartusecase.CartUsecase, *cartrepository.MockCartRepos
 
 	mockCartRepo := cartrepository.NewMockCartRepository(ctrl)
 	mockProdRepo := productrepository.NewMockProductRepository(ctrl)
+	mockInvRepo := inventoryrepository.NewMockInventoryRepository(ctrl)
 	mockTx := &mockTxManager{}
 	mockDB := &mockDB{}
 
-	uc := cartusecase.NewCartUsecase(mockCartRepo, mockProdRepo, mockTx, mockDB)
+	// Reservation mode off
+	uc := cartusecase.NewCartUsecase(mockCartRepo, mockProdRepo, mockInvRepo, mockShippingUsecase(t, ctrl), mockTx, mockDB, 0)
 	return uc, mockCartRepo, mockProdRepo, mockTx
 }
 
+func setupReservation(t *testing.T) (cartusecase.CartUsecase, *cartrepository.MockCartRepository, *productrepository.MockProductRepository, *inventoryrepository.MockInventoryRepository) {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCartRepo := cartrepository.NewMockCartRepository(ctrl)
+	mockProdRepo := productrepository.NewMockProductRepository(ctrl)
+	mockInvRepo := inventoryrepository.NewMockInventoryRepository(ctrl)
+
+	uc := cartusecase.NewCartUsecase(mockCartRepo, mockProdRepo, mockInvRepo, mockShippingUsecase(t, ctrl), &mockTxManager{}, &mockDB{}, 15*time.Minute)
+	return uc, mockCartRepo, mockProdRepo, mockInvRepo
+}
+
+// mockShippingUsecase ships free with standard to the home country
+func mockShippingUsecase(t *testing.T, ctrl *gomock.Controller) shippingusecase.ShippingUsecase {
+	t.Helper()
+
+	mockShipRepo := shippingrepository.NewMockShippingRepository(ctrl)
+	mockShipRepo.EXPECT().GetRate(gomock.Any(), shipping.MethodStandard, shipping.ZoneDomestic).Return(&shipping.Rate{
+		Method: shipping.MethodStandard,
+		Zone:   shipping.ZoneDomestic,
+	}, nil).AnyTimes()
+
+	uc, err := shippingusecase.NewShippingUsecase(&shippingusecase.ShippingUsecaseConfig{
+		Repo:        mockShipRepo,
+		HomeCountry: "TH",
+	})
+	if err != nil {
+		t.Fatalf("init shipping usecase failed: %v", err)
+	}
+	return uc
+}
+
 func mockUser() context.Context {
 	userClaims := &jwt.UserClaims{
 		ID:    10,
@@ -315,8 +883,20 @@ func mockUser() context.Context {
 	return auth.SetCurrentUser(context.Background(), userClaims)
 }
 
+func mockUserID() context.Context {
+	return auth.SetUserID(context.Background(), 10)
+}
+
+func mockActiveCart() *cart.Cart {
+	return &cart.Cart{ID: "uuid-cart-id", Status: cart.StatusActive}
+}
+
+func mockSavedCart() *cart.Cart {
+	return &cart.Cart{ID: "uuid-saved-id", Status: cart.StatusSaved}
+}
+
 func mockProduct() *product.Product {
-	return &product.Product{ID: 101, Stock: 20}
+	return &product.Product{ID: 101, Stock: 20, Status: product.StatusPublished}
 }
 
 func mockCart() *cart.Cart {
@@ -0,0 +1,230 @@
+package cartusecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/codepnw/mini-ecommerce/internal/cart"
+	cartrepository "github.com/codepnw/mini-ecommerce/internal/cart/repository"
+	"github.com/codepnw/mini-ecommerce/internal/user"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/pkg/auth"
+	"github.com/codepnw/mini-ecommerce/pkg/database"
+	"github.com/codepnw/mini-ecommerce/pkg/jwt"
+	"github.com/codepnw/mini-ecommerce/pkg/notify"
+	"github.com/codepnw/mini-ecommerce/pkg/validate"
+)
+
+type RecoveryUsecase interface {
+	// Background Jobs
+	AbandonIdleCarts(ctx context.Context) (int64, error)
+	SendRecoveryNotifications(ctx context.Context) (int, error)
+	PurgeGuestCarts(ctx context.Context) (int64, error)
+
+	RecoverCart(ctx context.Context, token string) (*CartView, error)
+
+	// Admin
+	RecoveryStats(ctx context.Context, filter *cart.RecoveryStatsFilter) (*cart.RecoveryStats, error)
+}
+
+type RecoveryUsecaseConfig struct {
+	CartRepo cartrepository.CartRepository `validate:"required"`
+	Carts    CartUsecase                   `validate:"required"`
+	Notifier notify.Notifier               `validate:"required"`
+	Token    *jwt.JWTToken                 `validate:"required"`
+	Tx       database.TxManager            `validate:"required"`
+
+	AbandonAfter time.Duration `validate:"gt=0"`
+	GuestTTL     time.Duration `validate:"gt=0"`
+	RecoveryTTL  time.Duration `validate:"gt=0"`
+	RecoveryURL  string        `validate:"required"`
+}
+
+type recoveryUsecase struct {
+	cartRepo cartrepository.CartRepository
+	carts    CartUsecase
+	notifier notify.Notifier
+	token    *jwt.JWTToken
+	tx       database.TxManager
+
+	abandonAfter time.Duration
+	guestTTL     time.Duration
+	recoveryTTL  time.Duration
+	recoveryURL  string
+}
+
+func NewRecoveryUsecase(cfg *RecoveryUsecaseConfig) (RecoveryUsecase, error) {
+	if err := validate.Struct(cfg); err != nil {
+		return nil, err
+	}
+	return &recoveryUsecase{
+		cartRepo:     cfg.CartRepo,
+		carts:        cfg.Carts,
+		notifier:     cfg.Notifier,
+		token:        cfg.Token,
+		tx:           cfg.Tx,
+		abandonAfter: cfg.AbandonAfter,
+		guestTTL:     cfg.GuestTTL,
+		recoveryTTL:  cfg.RecoveryTTL,
+		recoveryURL:  cfg.RecoveryURL,
+	}, nil
+}
+
+// AbandonIdleCarts run by the cart job, user carts idle past abandonAfter
+func (u *recoveryUsecase) AbandonIdleCarts(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return u.cartRepo.MarkAbandoned(ctx, time.Now().Add(-u.abandonAfter))
+}
+
+// SendRecoveryNotifications a recovery stays pending when sending fails
+// and is retried after the others on the next runs. Returns the number sent,
+// a failed recovery does not stop the others.
+func (u *recoveryUsecase) SendRecoveryNotifications(ctx context.Context) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout*3)
+	defer cancel()
+
+	pending, err := u.cartRepo.PendingRecoveries(ctx, consts.CartRecoveryBatch, consts.CartRecoveryMaxAttempts)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var failed []error
+	for _, c := range pending {
+		if err := u.sendRecovery(ctx, c); err != nil {
+			if ctx.Err() != nil {
+				return sent, ctx.Err()
+			}
+			if err := u.cartRepo.MarkRecoveryFailed(ctx, c.RecoveryID, err.Error()); err != nil {
+				return sent, err
+			}
+			failed = append(failed, fmt.Errorf("cart recovery #%d: %w", c.RecoveryID, err))
+			continue
+		}
+		sent++
+	}
+	return sent, errors.Join(failed...)
+}
+
+func (u *recoveryUsecase) sendRecovery(ctx context.Context, c *cart.AbandonedCart) error {
+	link, err := u.recoveryLink(c)
+	if err != nil {
+		return err
+	}
+
+	err = u.notifier.Send(ctx, &notify.Message{
+		To:      c.Email,
+		Subject: "You left items in your cart",
+		Body:    fmt.Sprintf("Your cart still has %d items (%.2f), pick up where you left off: %s", c.ItemCount, c.Total, link),
+	})
+	if err != nil {
+		return err
+	}
+	return u.cartRepo.MarkRecoveryNotified(ctx, c.RecoveryID)
+}
+
+// PurgeGuestCarts guest carts can not be recovered, they are deleted
+// after guestTTL
+func (u *recoveryUsecase) PurgeGuestCarts(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return u.cartRepo.PurgeGuestCarts(ctx, time.Now().Add(-u.guestTTL))
+}
+
+// RecoverCart restores the cart of the link for the signed in owner, lines
+// are merged when a new cart was started since. Prices & stock are checked
+// again at checkout.
+func (u *recoveryUsecase) RecoverCart(ctx context.Context, token string) (*CartView, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := u.token.VerifyCartRecoveryToken(token)
+	if err != nil || claims.UserID != userID {
+		return nil, errs.ErrCartRecoveryInvalid
+	}
+
+	err = u.tx.WithTransaction(ctx, func(tx *sql.Tx) error {
+		abandoned, err := u.cartRepo.GetAbandonedCartForUpdate(ctx, tx, claims.CartID, userID)
+		if err != nil {
+			return err
+		}
+
+		activeCart, err := u.cartRepo.GetActiveCartByUserID(ctx, tx, userID)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = u.cartRepo.RestoreCart(ctx, tx, abandoned.ID)
+		case err == nil:
+			err = u.cartRepo.MergeCart(ctx, tx, abandoned.ID, activeCart.ID)
+		}
+		if err != nil {
+			return err
+		}
+		return u.cartRepo.MarkRecovered(ctx, tx, abandoned.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return u.carts.GetCart(ctx)
+}
+
+func (u *recoveryUsecase) RecoveryStats(ctx context.Context, filter *cart.RecoveryStatsFilter) (*cart.RecoveryStats, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if !isAdmin(ctx) {
+		return nil, errs.ErrNoPermissions
+	}
+
+	// Default last 30 days, To is inclusive
+	to := filter.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+	to = to.Truncate(24*time.Hour).AddDate(0, 0, 1)
+	from := filter.From
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -30)
+	}
+	if !from.Before(to) {
+		return nil, errs.ErrInvalidDateRange
+	}
+
+	stats, err := u.cartRepo.RecoveryStats(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if stats.Abandoned > 0 {
+		stats.RecoveryRate = float64(stats.Recovered) / float64(stats.Abandoned)
+	}
+	return stats, nil
+}
+
+func (u *recoveryUsecase) recoveryLink(c *cart.AbandonedCart) (string, error) {
+	token, err := u.token.GenerateCartRecoveryToken(c.CartID, c.UserID, u.recoveryTTL)
+	if err != nil {
+		return "", err
+	}
+	return u.recoveryURL + "?token=" + url.QueryEscape(token), nil
+}
+
+func isAdmin(ctx context.Context) bool {
+	currentUser, err := auth.GetCurrentUser(ctx)
+	if err != nil {
+		return false
+	}
+	return currentUser.Role == string(user.RoleAdmin)
+}
@@ -0,0 +1,359 @@
+package cartusecase_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codepnw/mini-ecommerce/internal/cart"
+	cartrepository "github.com/codepnw/mini-ecommerce/internal/cart/repository"
+	cartusecase "github.com/codepnw/mini-ecommerce/internal/cart/usecase"
+	inventoryrepository "github.com/codepnw/mini-ecommerce/internal/inventory/repository"
+	productrepository "github.com/codepnw/mini-ecommerce/internal/product/repository"
+	"github.com/codepnw/mini-ecommerce/internal/user"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/pkg/auth"
+	"github.com/codepnw/mini-ecommerce/pkg/config"
+	"github.com/codepnw/mini-ecommerce/pkg/jwt"
+	"github.com/codepnw/mini-ecommerce/pkg/notify"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendRecoveryNotifications(t *testing.T) {
+	type testCase struct {
+		name        string
+		notifier    *mockNotifier
+		mockFn      func(mockCartRepo *cartrepository.MockCartRepository)
+		expected    int
+		expectedTo  []string
+		expectedErr error
+	}
+
+	pending := []*cart.AbandonedCart{
+		{RecoveryID: 1, CartID: "uuid-cart-1", UserID: 10, Email: "a@mail.com", ItemCount: 2, Total: 300},
+		{RecoveryID: 2, CartID: "uuid-cart-2", UserID: 11, Email: "b@mail.com", ItemCount: 1, Total: 50},
+	}
+
+	testCases := []testCase{
+		{
+			name:     "success",
+			notifier: &mockNotifier{},
+			mockFn: func(mockCartRepo *cartrepository.MockCartRepository) {
+				mockCartRepo.EXPECT().PendingRecoveries(gomock.Any(), gomock.Any(), gomock.Any()).Return(pending, nil).Times(1)
+				mockCartRepo.EXPECT().MarkRecoveryNotified(gomock.Any(), int64(1)).Return(nil).Times(1)
+				mockCartRepo.EXPECT().MarkRecoveryNotified(gomock.Any(), int64(2)).Return(nil).Times(1)
+			},
+			expected:   2,
+			expectedTo: []string{"a@mail.com", "b@mail.com"},
+		},
+		{
+			name:     "failed recovery does not stop the others",
+			notifier: &mockNotifier{err: errors.New("mailbox unavailable"), failTo: "a@mail.com"},
+			mockFn: func(mockCartRepo *cartrepository.MockCartRepository) {
+				mockCartRepo.EXPECT().PendingRecoveries(gomock.Any(), gomock.Any(), gomock.Any()).Return(pending, nil).Times(1)
+				mockCartRepo.EXPECT().MarkRecoveryFailed(gomock.Any(), int64(1), "mailbox unavailable").Return(nil).Times(1)
+				mockCartRepo.EXPECT().MarkRecoveryNotified(gomock.Any(), int64(2)).Return(nil).Times(1)
+			},
+			expected:    1,
+			expectedTo:  []string{"b@mail.com"},
+			expectedErr: errors.New("mailbox unavailable"),
+		},
+		{
+			name:     "fail send stays pending",
+			notifier: &mockNotifier{err: errors.New("smtp down")},
+			mockFn: func(mockCartRepo *cartrepository.MockCartRepository) {
+				mockCartRepo.EXPECT().PendingRecoveries(gomock.Any(), gomock.Any(), gomock.Any()).Return(pending, nil).Times(1)
+				mockCartRepo.EXPECT().MarkRecoveryFailed(gomock.Any(), int64(1), "smtp down").Return(nil).Times(1)
+				mockCartRepo.EXPECT().MarkRecoveryFailed(gomock.Any(), int64(2), "smtp down").Return(nil).Times(1)
+				mockCartRepo.EXPECT().MarkRecoveryNotified(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedErr: errors.New("smtp down"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			notifier := tc.notifier
+			uc, mockCartRepo, token := setupRecovery(t, notifier)
+
+			tc.mockFn(mockCartRepo)
+
+			sent, err := uc.SendRecoveryNotifications(context.Background())
+
+			if tc.expectedErr != nil {
+				assert.ErrorContains(t, err, tc.expectedErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expected, sent)
+
+			var to []string
+			for _, m := range notifier.sent {
+				to = append(to, m.To)
+			}
+			assert.Equal(t, tc.expectedTo, to)
+
+			// Deep link carries a token for the cart & its owner
+			if tc.expectedErr == nil && assert.Len(t, notifier.sent, 2) {
+				msg := notifier.sent[0]
+				assert.Equal(t, "a@mail.com", msg.To)
+
+				link := msg.Body[strings.Index(msg.Body, "http"):]
+				u, err := url.Parse(link)
+				assert.NoError(t, err)
+
+				claims, err := token.VerifyCartRecoveryToken(u.Query().Get("token"))
+				assert.NoError(t, err)
+				assert.Equal(t, "uuid-cart-1", claims.CartID)
+				assert.Equal(t, int64(10), claims.UserID)
+			}
+		})
+	}
+}
+
+func TestRecoverCart(t *testing.T) {
+	abandoned := &cart.Cart{ID: "uuid-abandoned-id", Status: cart.StatusAbandoned}
+
+	type testCase struct {
+		name        string
+		token       func(token *jwt.JWTToken) string
+		mockFn      func(mockCartRepo *cartrepository.MockCartRepository)
+		expectedErr error
+	}
+
+	validToken := func(token *jwt.JWTToken) string {
+		s, _ := token.GenerateCartRecoveryToken(abandoned.ID, 10, time.Hour)
+		return s
+	}
+
+	testCases := []testCase{
+		{
+			name:  "success restore as active cart",
+			token: validToken,
+			mockFn: func(mockCartRepo *cartrepository.MockCartRepository) {
+				mockCartRepo.EXPECT().GetAbandonedCartForUpdate(gomock.Any(), gomock.Any(), abandoned.ID, int64(10)).Return(abandoned, nil).Times(1)
+				mockCartRepo.EXPECT().GetActiveCartByUserID(gomock.Any(), gomock.Any(), int64(10)).Return(nil, sql.ErrNoRows).Times(1)
+				mockCartRepo.EXPECT().RestoreCart(gomock.Any(), gomock.Any(), abandoned.ID).Return(nil).Times(1)
+				mockCartRepo.EXPECT().MarkRecovered(gomock.Any(), gomock.Any(), abandoned.ID).Return(nil).Times(1)
+
+				// Return getCartView
+				c := &cart.Cart{ID: abandoned.ID, Status: cart.StatusActive}
+				mockCartRepo.EXPECT().GetOrCreateActiveCart(gomock.Any(), gomock.Any(), gomock.Any()).Return(c, nil).Times(1)
+				mockCartRepo.EXPECT().GetCartItems(gomock.Any(), gomock.Any(), c.ID).Return(mockCartItems(), nil).Times(1)
+			},
+		},
+		{
+			name:  "success merge into newer cart",
+			token: validToken,
+			mockFn: func(mockCartRepo *cartrepository.MockCartRepository) {
+				c := mockActiveCart()
+				mockCartRepo.EXPECT().GetAbandonedCartForUpdate(gomock.Any(), gomock.Any(), abandoned.ID, int64(10)).Return(abandoned, nil).Times(1)
+				mockCartRepo.EXPECT().GetActiveCartByUserID(gomock.Any(), gomock.Any(), int64(10)).Return(c, nil).Times(1)
+				mockCartRepo.EXPECT().MergeCart(gomock.Any(), gomock.Any(), abandoned.ID, c.ID).Return(nil).Times(1)
+				mockCartRepo.EXPECT().RestoreCart(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				mockCartRepo.EXPECT().MarkRecovered(gomock.Any(), gomock.Any(), abandoned.ID).Return(nil).Times(1)
+
+				// Return getCartView
+				mockCartRepo.EXPECT().GetOrCreateActiveCart(gomock.Any(), gomock.Any(), gomock.Any()).Return(c, nil).Times(1)
+				mockCartRepo.EXPECT().GetCartItems(gomock.Any(), gomock.Any(), c.ID).Return(mockCartItems(), nil).Times(1)
+			},
+		},
+		{
+			name: "fail invalid token",
+			token: func(token *jwt.JWTToken) string {
+				return "not-a-token"
+			},
+			mockFn:      func(mockCartRepo *cartrepository.MockCartRepository) {},
+			expectedErr: errs.ErrCartRecoveryInvalid,
+		},
+		{
+			name: "fail token of another user",
+			token: func(token *jwt.JWTToken) string {
+				s, _ := token.GenerateCartRecoveryToken(abandoned.ID, 99, time.Hour)
+				return s
+			},
+			mockFn:      func(mockCartRepo *cartrepository.MockCartRepository) {},
+			expectedErr: errs.ErrCartRecoveryInvalid,
+		},
+		{
+			name: "fail token expired",
+			token: func(token *jwt.JWTToken) string {
+				s, _ := token.GenerateCartRecoveryToken(abandoned.ID, 10, -time.Minute)
+				return s
+			},
+			mockFn:      func(mockCartRepo *cartrepository.MockCartRepository) {},
+			expectedErr: errs.ErrCartRecoveryInvalid,
+		},
+		{
+			name: "fail access token is not a recovery token",
+			token: func(token *jwt.JWTToken) string {
+				s, _ := token.GenerateAccessToken(mockUserEntity())
+				return s
+			},
+			mockFn:      func(mockCartRepo *cartrepository.MockCartRepository) {},
+			expectedErr: errs.ErrCartRecoveryInvalid,
+		},
+		{
+			name:  "fail already recovered",
+			token: validToken,
+			mockFn: func(mockCartRepo *cartrepository.MockCartRepository) {
+				mockCartRepo.EXPECT().GetAbandonedCartForUpdate(gomock.Any(), gomock.Any(), abandoned.ID, int64(10)).Return(nil, errs.ErrCartRecoveryInvalid).Times(1)
+			},
+			expectedErr: errs.ErrCartRecoveryInvalid,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, mockCartRepo, token := setupRecovery(t, &mockNotifier{})
+
+			tc.mockFn(mockCartRepo)
+
+			result, err := uc.RecoverCart(mockUserID(), tc.token(token))
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestRecoveryStats(t *testing.T) {
+	type testCase struct {
+		name         string
+		ctx          context.Context
+		filter       *cart.RecoveryStatsFilter
+		mockFn       func(mockCartRepo *cartrepository.MockCartRepository)
+		expectedRate float64
+		expectedErr  error
+	}
+
+	day := func(s string) time.Time {
+		d, _ := time.Parse(time.DateOnly, s)
+		return d
+	}
+
+	testCases := []testCase{
+		{
+			name:   "success rate",
+			ctx:    mockAdmin(),
+			filter: &cart.RecoveryStatsFilter{From: day("2026-01-01"), To: day("2026-01-31")},
+			mockFn: func(mockCartRepo *cartrepository.MockCartRepository) {
+				// To is inclusive
+				mockCartRepo.EXPECT().RecoveryStats(gomock.Any(), day("2026-01-01"), day("2026-02-01")).Return(
+					&cart.RecoveryStats{Abandoned: 8, Notified: 8, Recovered: 2}, nil,
+				).Times(1)
+			},
+			expectedRate: 0.25,
+		},
+		{
+			name:   "success nothing abandoned",
+			ctx:    mockAdmin(),
+			filter: &cart.RecoveryStatsFilter{},
+			mockFn: func(mockCartRepo *cartrepository.MockCartRepository) {
+				mockCartRepo.EXPECT().RecoveryStats(gomock.Any(), gomock.Any(), gomock.Any()).Return(&cart.RecoveryStats{}, nil).Times(1)
+			},
+			expectedRate: 0,
+		},
+		{
+			name:        "fail not admin",
+			ctx:         mockUser(),
+			filter:      &cart.RecoveryStatsFilter{},
+			mockFn:      func(mockCartRepo *cartrepository.MockCartRepository) {},
+			expectedErr: errs.ErrNoPermissions,
+		},
+		{
+			name:        "fail from after to",
+			ctx:         mockAdmin(),
+			filter:      &cart.RecoveryStatsFilter{From: day("2026-02-01"), To: day("2026-01-01")},
+			mockFn:      func(mockCartRepo *cartrepository.MockCartRepository) {},
+			expectedErr: errs.ErrInvalidDateRange,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, mockCartRepo, _ := setupRecovery(t, &mockNotifier{})
+
+			tc.mockFn(mockCartRepo)
+
+			result, err := uc.RecoveryStats(tc.ctx, tc.filter)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedRate, result.RecoveryRate)
+			}
+		})
+	}
+}
+
+func setupRecovery(t *testing.T, notifier notify.Notifier) (cartusecase.RecoveryUsecase, *cartrepository.MockCartRepository, *jwt.JWTToken) {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCartRepo := cartrepository.NewMockCartRepository(ctrl)
+	mockProdRepo := productrepository.NewMockProductRepository(ctrl)
+	mockInvRepo := inventoryrepository.NewMockInventoryRepository(ctrl)
+
+	token, err := jwt.InitJWT(config.JWTConfig{
+		SecretKey:  "mock_secret_key",
+		RefreshKey: "mock_refresh_key",
+	})
+	if err != nil {
+		t.Fatalf("init jwt failed: %v", err)
+	}
+
+	carts := cartusecase.NewCartUsecase(mockCartRepo, mockProdRepo, mockInvRepo, mockShippingUsecase(t, ctrl), &mockTxManager{}, &mockDB{}, 0)
+	uc, err := cartusecase.NewRecoveryUsecase(&cartusecase.RecoveryUsecaseConfig{
+		CartRepo:     mockCartRepo,
+		Carts:        carts,
+		Notifier:     notifier,
+		Token:        token,
+		Tx:           &mockTxManager{},
+		AbandonAfter: 24 * time.Hour,
+		GuestTTL:     30 * 24 * time.Hour,
+		RecoveryTTL:  7 * 24 * time.Hour,
+		RecoveryURL:  "http://localhost:3000/cart/recover",
+	})
+	if err != nil {
+		t.Fatalf("recovery usecase failed: %v", err)
+	}
+	return uc, mockCartRepo, token
+}
+
+func mockAdmin() context.Context {
+	return auth.SetCurrentUser(context.Background(), &jwt.UserClaims{ID: 1, Role: "admin"})
+}
+
+func mockUserEntity() *user.User {
+	return &user.User{ID: 10, Email: "example@mail.com", Role: "user"}
+}
+
+type mockNotifier struct {
+	sent []*notify.Message
+	err  error
+	// failTo only sends to this address fail when set
+	failTo string
+}
+
+func (m *mockNotifier) Send(ctx context.Context, msg *notify.Message) error {
+	if m.err != nil && (m.failTo == "" || m.failTo == msg.To) {
+		return m.err
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
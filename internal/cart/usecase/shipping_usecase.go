@@ -0,0 +1,104 @@
+package cartusecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/codepnw/mini-ecommerce/internal/cart"
+	"github.com/codepnw/mini-ecommerce/internal/shipping"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/pkg/auth"
+)
+
+// SetShipping method & destination country the cart is priced with, no
+// country is the home country
+func (u *cartUsecase) SetShipping(ctx context.Context, method shipping.Method, country string) (*CartView, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	cartData, err := u.activeCart(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if method == "" {
+		method = shipping.MethodStandard
+	}
+	country = strings.ToUpper(strings.TrimSpace(country))
+	nullCountry := sql.NullString{String: country, Valid: country != ""}
+	if err := u.cartRepo.SetShipping(ctx, cartData.ID, string(method), nullCountry); err != nil {
+		return nil, err
+	}
+	return u.getCartView(ctx)
+}
+
+// ShippingOptions every method able to ship the cart, country defaults to
+// the cart's destination
+func (u *cartUsecase) ShippingOptions(ctx context.Context, country string) ([]*shipping.Quote, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	cartData, err := u.activeCart(ctx)
+	if err != nil {
+		return nil, err
+	}
+	view, err := u.buildCartView(ctx, cartData)
+	if err != nil {
+		return nil, err
+	}
+
+	req := shippingRequest(cartData, view)
+	if country = strings.TrimSpace(country); country != "" {
+		req.Country = country
+	}
+	return u.shipping.Options(ctx, req)
+}
+
+func (u *cartUsecase) activeCart(ctx context.Context) (*cart.Cart, error) {
+	userID := auth.GetUserID(ctx)
+	sessionID := auth.GetSessionID(ctx)
+	nullUserID := sql.NullInt64{Int64: userID, Valid: userID > 0}
+	nullSessionID := sql.NullString{String: sessionID, Valid: sessionID != ""}
+
+	return u.cartRepo.GetOrCreateActiveCart(ctx, nullUserID, nullSessionID)
+}
+
+// priceShipping adds the chosen method's cost to the view, a cart the
+// method cannot ship keeps the items total with the reason
+func (u *cartUsecase) priceShipping(ctx context.Context, cartData *cart.Cart, view *CartView) error {
+	req := shippingRequest(cartData, view)
+	if len(req.Items) == 0 {
+		return nil
+	}
+
+	quote, err := u.shipping.Price(ctx, req)
+	if errors.Is(err, errs.ErrShippingUnavailable) || errors.Is(err, errs.ErrShippingTooHeavy) {
+		view.ShippingError = err.Error()
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	view.Shipping = quote
+	view.GrandTotal = view.TotalPrice + quote.Cost
+	return nil
+}
+
+// shippingRequest of the lines counted in the cart total
+func shippingRequest(cartData *cart.Cart, view *CartView) *shipping.Request {
+	req := &shipping.Request{
+		Method:   shipping.Method(cartData.ShippingMethod),
+		Country:  cartData.ShippingCountry.String,
+		Subtotal: view.TotalPrice,
+	}
+	for _, i := range view.Items {
+		if i.IsOutOfStock || i.IsUnavailable {
+			continue
+		}
+		req.Items = append(req.Items, &shipping.Item{Quantity: i.Quantity, Parcel: i.Parcel})
+	}
+	return req
+}
@@ -0,0 +1,316 @@
+package cartusecase
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/codepnw/mini-ecommerce/internal/cart"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/pkg/auth"
+	"github.com/google/uuid"
+)
+
+// SaveForLater moves an active cart line to the user's saved list,
+// the stock hold is released with the line
+func (u *cartUsecase) SaveForLater(ctx context.Context, cartItemID int64) (*CartView, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	activeCart, err := u.cartRepo.GetOrCreateActiveCart(ctx, sql.NullInt64{Int64: userID, Valid: true}, sql.NullString{})
+	if err != nil {
+		return nil, err
+	}
+	savedCart, err := u.cartRepo.GetOrCreateSavedCart(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.moveItem(ctx, activeCart.ID, cartItemID, savedCart); err != nil {
+		return nil, err
+	}
+	return u.getCartView(ctx)
+}
+
+func (u *cartUsecase) GetSavedItems(ctx context.Context) (*CartView, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	savedCart, err := u.cartRepo.GetOrCreateSavedCart(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return u.buildCartView(ctx, savedCart)
+}
+
+// MoveSavedToCart checks stock like adding to the cart
+func (u *cartUsecase) MoveSavedToCart(ctx context.Context, cartItemID int64) (*CartView, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	savedCart, err := u.cartRepo.GetOrCreateSavedCart(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	activeCart, err := u.cartRepo.GetOrCreateActiveCart(ctx, sql.NullInt64{Int64: userID, Valid: true}, sql.NullString{})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.moveItem(ctx, savedCart.ID, cartItemID, activeCart); err != nil {
+		return nil, err
+	}
+	return u.getCartView(ctx)
+}
+
+func (u *cartUsecase) RemoveSavedItem(ctx context.Context, cartItemID int64) (*CartView, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	savedCart, err := u.cartRepo.GetOrCreateSavedCart(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.tx.WithTransaction(ctx, func(tx *sql.Tx) error {
+		return u.cartRepo.RemoveItem(ctx, tx, savedCart.ID, cartItemID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u.buildCartView(ctx, savedCart)
+}
+
+func (u *cartUsecase) ListWishlists(ctx context.Context) ([]*cart.Cart, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return u.cartRepo.ListWishlists(ctx, userID)
+}
+
+func (u *cartUsecase) CreateWishlist(ctx context.Context, name string) (*cart.Cart, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errs.ErrWishlistNameRequired
+	}
+
+	input := &cart.Cart{
+		UserID: sql.NullInt64{Int64: userID, Valid: true},
+		Name:   sql.NullString{String: name, Valid: true},
+	}
+	if err := u.cartRepo.CreateWishlist(ctx, input); err != nil {
+		return nil, err
+	}
+	return input, nil
+}
+
+func (u *cartUsecase) GetWishlist(ctx context.Context, wishlistID string) (*CartView, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	wishlist, err := u.getWishlist(ctx, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+	return u.buildCartView(ctx, wishlist)
+}
+
+func (u *cartUsecase) RenameWishlist(ctx context.Context, wishlistID, name string) (*cart.Cart, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := uuid.Validate(wishlistID); err != nil {
+		return nil, errs.ErrWishlistNotFound
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errs.ErrWishlistNameRequired
+	}
+	return u.cartRepo.RenameWishlist(ctx, userID, wishlistID, name)
+}
+
+func (u *cartUsecase) DeleteWishlist(ctx context.Context, wishlistID string) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return err
+	}
+	if err := uuid.Validate(wishlistID); err != nil {
+		return errs.ErrWishlistNotFound
+	}
+	return u.cartRepo.DeleteWishlist(ctx, userID, wishlistID)
+}
+
+// AddWishlistItem no stock check, wishlists may hold sold out products
+func (u *cartUsecase) AddWishlistItem(ctx context.Context, wishlistID string, productID int64, quantity int) (*CartView, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if quantity <= 0 {
+		return nil, errs.ErrInvalidQuantity
+	}
+
+	wishlist, err := u.getWishlist(ctx, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+
+	productData, err := u.productRepo.FindByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if !productData.IsAvailable() {
+		return nil, errs.ErrProductUnavailable
+	}
+
+	err = u.tx.WithTransaction(ctx, func(tx *sql.Tx) error {
+		return u.cartRepo.UpsertItem(ctx, tx, &cart.CartItem{
+			CartID:     wishlist.ID,
+			ProductID:  productID,
+			Quantity:   quantity,
+			PriceAtAdd: productData.Price,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u.buildCartView(ctx, wishlist)
+}
+
+func (u *cartUsecase) RemoveWishlistItem(ctx context.Context, wishlistID string, cartItemID int64) (*CartView, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	wishlist, err := u.getWishlist(ctx, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.tx.WithTransaction(ctx, func(tx *sql.Tx) error {
+		return u.cartRepo.RemoveItem(ctx, tx, wishlist.ID, cartItemID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u.buildCartView(ctx, wishlist)
+}
+
+func (u *cartUsecase) MoveWishlistItemToCart(ctx context.Context, wishlistID string, cartItemID int64) (*CartView, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	wishlist, err := u.getWishlist(ctx, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+	activeCart, err := u.cartRepo.GetOrCreateActiveCart(ctx, wishlist.UserID, sql.NullString{})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.moveItem(ctx, wishlist.ID, cartItemID, activeCart); err != nil {
+		return nil, err
+	}
+	return u.getCartView(ctx)
+}
+
+// getWishlist owned by the current user, a malformed id is not found
+func (u *cartUsecase) getWishlist(ctx context.Context, wishlistID string) (*cart.Cart, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := uuid.Validate(wishlistID); err != nil {
+		return nil, errs.ErrWishlistNotFound
+	}
+	return u.cartRepo.GetWishlist(ctx, userID, wishlistID)
+}
+
+// moveItem moves a line between two carts of the user at the current price,
+// moving into the active cart checks stock like adding to it
+func (u *cartUsecase) moveItem(ctx context.Context, fromCartID string, cartItemID int64, to *cart.Cart) error {
+	return u.tx.WithTransaction(ctx, func(tx *sql.Tx) error {
+		item, err := u.cartRepo.GetCartItemForUpdate(ctx, tx, cartItemID, fromCartID)
+		if err != nil {
+			return err
+		}
+
+		productData, err := u.productRepo.FindByIDForUpdate(ctx, tx, item.ProductID)
+		if err != nil {
+			return err
+		}
+		toActive := to.Status == cart.StatusActive
+		if toActive && !productData.IsAvailable() {
+			return errs.ErrProductUnavailable
+		}
+
+		if err := u.cartRepo.RemoveItem(ctx, tx, fromCartID, cartItemID); err != nil {
+			return err
+		}
+		moved := &cart.CartItem{
+			CartID:     to.ID,
+			ProductID:  item.ProductID,
+			Quantity:   item.Quantity,
+			PriceAtAdd: productData.Price,
+		}
+		if err := u.cartRepo.UpsertItem(ctx, tx, moved); err != nil {
+			return err
+		}
+		if !toActive {
+			return nil
+		}
+		if err := u.checkRules(ctx, tx, productData, moved.Quantity); err != nil {
+			return err
+		}
+		return u.hold(ctx, tx, to.ID, moved.ID, productData, moved.Quantity)
+	})
+}
+
+// requireUser saved lists belong to an account, guests only have a cart
+func requireUser(ctx context.Context) (int64, error) {
+	userID := auth.GetUserID(ctx)
+	if userID <= 0 {
+		return 0, errs.ErrUnauthorized
+	}
+	return userID, nil
+}
@@ -0,0 +1,158 @@
+package inventory
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+)
+
+const (
+	StrategyPriority = "priority"
+	StrategyNearest  = "nearest"
+)
+
+type GeoPoint struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type AllocationLine struct {
+	ProductID int64
+	Quantity  int
+}
+
+type AllocationRequest struct {
+	Lines      []AllocationLine
+	Warehouses []*Warehouse // Active only
+	Levels     []*StockLevel
+	// Optional, used by the nearest strategy
+	Destination *GeoPoint
+}
+
+// Allocation productID -> warehouseID
+type Allocation map[int64]int64
+
+// AllocationStrategy picks the warehouse of each order line
+type AllocationStrategy interface {
+	Allocate(req *AllocationRequest) (Allocation, error)
+}
+
+func NewAllocationStrategy(name string) (AllocationStrategy, error) {
+	switch name {
+	case StrategyPriority:
+		return PriorityStrategy{}, nil
+	case StrategyNearest:
+		return NearestStrategy{}, nil
+	}
+	return nil, fmt.Errorf("unknown allocation strategy: %s", name)
+}
+
+// PriorityStrategy ranks warehouses by Priority
+type PriorityStrategy struct{}
+
+func (PriorityStrategy) Allocate(req *AllocationRequest) (Allocation, error) {
+	ranked := append([]*Warehouse(nil), req.Warehouses...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return byPriority(ranked[i], ranked[j])
+	})
+	return firstFit(ranked, req)
+}
+
+// NearestStrategy ranks warehouses by distance to the destination,
+// without a destination it works like PriorityStrategy
+type NearestStrategy struct{}
+
+func (NearestStrategy) Allocate(req *AllocationRequest) (Allocation, error) {
+	if req.Destination == nil {
+		return PriorityStrategy{}.Allocate(req)
+	}
+
+	// Warehouses without a location go last
+	distance := make(map[int64]float64, len(req.Warehouses))
+	for _, w := range req.Warehouses {
+		distance[w.ID] = math.Inf(1)
+		if w.Latitude != nil && w.Longitude != nil {
+			distance[w.ID] = haversineKm(*req.Destination, GeoPoint{Latitude: *w.Latitude, Longitude: *w.Longitude})
+		}
+	}
+
+	ranked := append([]*Warehouse(nil), req.Warehouses...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		di, dj := distance[ranked[i].ID], distance[ranked[j].ID]
+		if di != dj {
+			return di < dj
+		}
+		return byPriority(ranked[i], ranked[j])
+	})
+	return firstFit(ranked, req)
+}
+
+func byPriority(a, b *Warehouse) bool {
+	if a.Priority != b.Priority {
+		return a.Priority < b.Priority
+	}
+	return a.ID < b.ID
+}
+
+// firstFit ships the whole order from the first ranked warehouse that has every line,
+// otherwise each line comes from the first ranked warehouse that has it
+func firstFit(ranked []*Warehouse, req *AllocationRequest) (Allocation, error) {
+	stock := make(map[int64]map[int64]int) // warehouseID -> productID -> quantity
+	for _, l := range req.Levels {
+		if stock[l.WarehouseID] == nil {
+			stock[l.WarehouseID] = make(map[int64]int)
+		}
+		stock[l.WarehouseID][l.ProductID] += l.Quantity
+	}
+
+	has := func(warehouseID int64, line AllocationLine) bool {
+		return stock[warehouseID][line.ProductID] >= line.Quantity
+	}
+
+	// Whole order
+	for _, w := range ranked {
+		fits := true
+		for _, line := range req.Lines {
+			if !has(w.ID, line) {
+				fits = false
+				break
+			}
+		}
+		if fits {
+			allocation := make(Allocation, len(req.Lines))
+			for _, line := range req.Lines {
+				allocation[line.ProductID] = w.ID
+			}
+			return allocation, nil
+		}
+	}
+
+	// Split by line
+	allocation := make(Allocation, len(req.Lines))
+	for _, line := range req.Lines {
+		for _, w := range ranked {
+			if has(w.ID, line) {
+				allocation[line.ProductID] = w.ID
+				break
+			}
+		}
+		if _, ok := allocation[line.ProductID]; !ok {
+			return nil, errs.ErrProductNotEnough
+		}
+	}
+	return allocation, nil
+}
+
+func haversineKm(a, b GeoPoint) float64 {
+	const earthRadiusKm = 6371.0
+
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := (b.Latitude - a.Latitude) * math.Pi / 180
+	dLng := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,29 @@
+package inventoryhandler
+
+type ReconcileQuery struct {
+	// Default only products with drift, all=true lists every product
+	All bool `form:"all"`
+}
+
+type WarehouseCreateReq struct {
+	Code      string   `json:"code" binding:"required,max=50"`
+	Name      string   `json:"name" binding:"required,min=2"`
+	Latitude  *float64 `json:"latitude" binding:"omitempty,gte=-90,lte=90"`
+	Longitude *float64 `json:"longitude" binding:"omitempty,gte=-180,lte=180"`
+	Priority  int      `json:"priority"`
+	IsActive  *bool    `json:"is_active"`
+}
+
+type WarehouseUpdateReq struct {
+	Name      *string  `json:"name" binding:"omitempty,min=2"`
+	Latitude  *float64 `json:"latitude" binding:"omitempty,gte=-90,lte=90"`
+	Longitude *float64 `json:"longitude" binding:"omitempty,gte=-180,lte=180"`
+	Priority  *int     `json:"priority"`
+	IsActive  *bool    `json:"is_active"`
+}
+
+type StockSetReq struct {
+	ProductID int64  `json:"product_id" binding:"required"`
+	Quantity  *int   `json:"quantity" binding:"required,gte=0"`
+	Note      string `json:"note" binding:"max=255"`
+}
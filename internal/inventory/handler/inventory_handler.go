@@ -0,0 +1,208 @@
+package inventoryhandler
+
+import (
+	"strings"
+
+	"github.com/codepnw/mini-ecommerce/internal/inventory"
+	inventoryusecase "github.com/codepnw/mini-ecommerce/internal/inventory/usecase"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/internal/utils/helper"
+	"github.com/codepnw/mini-ecommerce/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+type inventoryHandler struct {
+	uc inventoryusecase.InventoryUsecase
+}
+
+func NewInventoryHandler(uc inventoryusecase.InventoryUsecase) *inventoryHandler {
+	return &inventoryHandler{uc: uc}
+}
+
+func (h *inventoryHandler) ListMovements(c *gin.Context) {
+	filter := new(inventory.MovementFilter)
+	if err := c.ShouldBindQuery(filter); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	resp, err := h.uc.ListMovements(c.Request.Context(), filter)
+	if err != nil {
+		if err == errs.ErrNoPermissions {
+			response.Forbidden(c, err.Error())
+			return
+		}
+		response.InternalServerError(c, err)
+		return
+	}
+	response.OK(c, "", resp)
+}
+
+func (h *inventoryHandler) Reconcile(c *gin.Context) {
+	query := new(ReconcileQuery)
+	if err := c.ShouldBindQuery(query); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	resp, err := h.uc.Reconcile(c.Request.Context(), !query.All)
+	if err != nil {
+		if err == errs.ErrNoPermissions {
+			response.Forbidden(c, err.Error())
+			return
+		}
+		response.InternalServerError(c, err)
+		return
+	}
+	response.OK(c, "", resp)
+}
+
+func (h *inventoryHandler) ListWarehouses(c *gin.Context) {
+	resp, err := h.uc.ListWarehouses(c.Request.Context())
+	if err != nil {
+		if err == errs.ErrNoPermissions {
+			response.Forbidden(c, err.Error())
+			return
+		}
+		response.InternalServerError(c, err)
+		return
+	}
+	response.OK(c, "", resp)
+}
+
+func (h *inventoryHandler) CreateWarehouse(c *gin.Context) {
+	req := new(WarehouseCreateReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	input := &inventory.Warehouse{
+		Code:      strings.ToUpper(strings.TrimSpace(req.Code)),
+		Name:      req.Name,
+		Latitude:  req.Latitude,
+		Longitude: req.Longitude,
+		Priority:  req.Priority,
+		IsActive:  req.IsActive == nil || *req.IsActive,
+	}
+	resp, err := h.uc.CreateWarehouse(c.Request.Context(), input)
+	if err != nil {
+		switch err {
+		case errs.ErrNoPermissions:
+			response.Forbidden(c, err.Error())
+			return
+		case errs.ErrWarehouseCodeExists, errs.ErrWarehouseLocationInvalid:
+			response.BadRequest(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.Created(c, resp)
+}
+
+func (h *inventoryHandler) UpdateWarehouse(c *gin.Context) {
+	warehouseID, err := helper.GetParamInt(c, consts.ParamWarehouseID)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	req := new(WarehouseUpdateReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	input := &inventory.WarehouseUpdate{
+		ID:        warehouseID,
+		Name:      req.Name,
+		Latitude:  req.Latitude,
+		Longitude: req.Longitude,
+		Priority:  req.Priority,
+		IsActive:  req.IsActive,
+	}
+	resp, err := h.uc.UpdateWarehouse(c.Request.Context(), input)
+	if err != nil {
+		switch err {
+		case errs.ErrNoPermissions:
+			response.Forbidden(c, err.Error())
+			return
+		case errs.ErrWarehouseNotFound:
+			response.NotFound(c, err.Error())
+			return
+		case errs.ErrNoFieldsToUpdate, errs.ErrWarehouseDefault, errs.ErrWarehouseLocationInvalid:
+			response.BadRequest(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "", resp)
+}
+
+func (h *inventoryHandler) GetStockLevels(c *gin.Context) {
+	productID, err := helper.GetParamInt(c, consts.ParamProductID)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	resp, err := h.uc.GetStockLevels(c.Request.Context(), productID)
+	if err != nil {
+		switch err {
+		case errs.ErrNoPermissions:
+			response.Forbidden(c, err.Error())
+			return
+		case errs.ErrProductNotFound:
+			response.NotFound(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "", resp)
+}
+
+func (h *inventoryHandler) SetStock(c *gin.Context) {
+	warehouseID, err := helper.GetParamInt(c, consts.ParamWarehouseID)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	req := new(StockSetReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	input := &inventory.StockSet{
+		WarehouseID: warehouseID,
+		ProductID:   req.ProductID,
+		Quantity:    *req.Quantity,
+		Note:        req.Note,
+	}
+	resp, err := h.uc.SetStock(c.Request.Context(), input)
+	if err != nil {
+		switch err {
+		case errs.ErrNoPermissions:
+			response.Forbidden(c, err.Error())
+			return
+		case errs.ErrWarehouseNotFound, errs.ErrProductNotFound:
+			response.NotFound(c, err.Error())
+			return
+		case errs.ErrProductStockInvalid, errs.ErrWarehouseNotEnough:
+			response.BadRequest(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "", resp)
+}
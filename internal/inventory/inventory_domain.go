@@ -0,0 +1,189 @@
+package inventory
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Reservation stock held by a cart line until ExpiresAt
+type Reservation struct {
+	ID         int64     `json:"id"`
+	CartItemID int64     `json:"cart_item_id"`
+	CartID     string    `json:"cart_id"`
+	ProductID  int64     `json:"product_id"`
+	Quantity   int       `json:"quantity"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type MovementReason string
+
+const (
+	ReasonOrder   MovementReason = "order"
+	ReasonCancel  MovementReason = "cancel"
+	ReasonRestock MovementReason = "restock"
+	ReasonAdjust  MovementReason = "adjust"
+	ReasonReturn  MovementReason = "return"
+)
+
+// Reference types of a stock movement
+const (
+	RefOrder   = "order"
+	RefProduct = "product"
+	RefReturn  = "return"
+	RefRefund  = "refund"
+)
+
+// StockMovement one ledger row, StockAfter is products.stock after Delta
+type StockMovement struct {
+	ID          int64          `json:"id"`
+	ProductID   int64          `json:"product_id"`
+	Delta       int            `json:"delta"`
+	StockAfter  int            `json:"stock_after"`
+	Reason      MovementReason `json:"reason"`
+	RefType     string         `json:"ref_type,omitempty"`
+	RefID       int64          `json:"ref_id,omitempty"`
+	ActorID     int64          `json:"actor_id,omitempty"`
+	Note        string         `json:"note,omitempty"`
+	WarehouseID int64          `json:"warehouse_id,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+type MovementFilter struct {
+	ProductID   int64  `form:"product_id"`
+	Reason      string `form:"reason" binding:"omitempty,oneof=order cancel restock adjust return"`
+	RefType     string `form:"ref_type"`
+	RefID       int64  `form:"ref_id"`
+	WarehouseID int64  `form:"warehouse_id"`
+	Page        int    `form:"page"`
+	Limit       int    `form:"limit"`
+}
+
+// StockDrift products.stock compared with the sum of its ledger and warehouses
+type StockDrift struct {
+	ProductID   int64  `json:"product_id"`
+	SKU         string `json:"sku"`
+	Name        string `json:"name"`
+	Stock       int    `json:"stock"`
+	LedgerStock int    `json:"ledger_stock"`
+	Drift       int    `json:"drift"`
+	// Sum of warehouse_stock, should equal Stock
+	WarehouseStock int `json:"warehouse_stock"`
+	WarehouseDrift int `json:"warehouse_drift"`
+}
+
+// Warehouse lower Priority is picked first by allocation
+type Warehouse struct {
+	ID        int64     `json:"id"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	Latitude  *float64  `json:"latitude,omitempty"`
+	Longitude *float64  `json:"longitude,omitempty"`
+	Priority  int       `json:"priority"`
+	IsDefault bool      `json:"is_default"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WarehouseUpdate nil fields are not changed
+type WarehouseUpdate struct {
+	ID        int64
+	Name      *string
+	Latitude  *float64
+	Longitude *float64
+	Priority  *int
+	IsActive  *bool
+}
+
+func (w *WarehouseUpdate) HasChanges() bool {
+	return w.Name != nil || w.Latitude != nil || w.Longitude != nil || w.Priority != nil || w.IsActive != nil
+}
+
+// StockLevel quantity of a product in one warehouse
+type StockLevel struct {
+	WarehouseID   int64     `json:"warehouse_id"`
+	WarehouseCode string    `json:"warehouse_code"`
+	ProductID     int64     `json:"product_id"`
+	Quantity      int       `json:"quantity"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// StockSet sets the quantity of a product in a warehouse,
+// the difference goes to products.stock and the ledger
+type StockSet struct {
+	WarehouseID int64
+	ProductID   int64
+	Quantity    int
+	Note        string
+}
+
+type StockEventKind string
+
+const (
+	EventLowStock    StockEventKind = "low_stock"
+	EventBackInStock StockEventKind = "back_in_stock"
+)
+
+// StockEvent written with the stock change, notifications are sent by a background job
+type StockEvent struct {
+	ID          int64          `json:"id"`
+	ProductID   int64          `json:"product_id"`
+	Kind        StockEventKind `json:"kind"`
+	Stock       int            `json:"stock"`
+	CreatedAt   time.Time      `json:"created_at"`
+	ProcessedAt *time.Time     `json:"processed_at,omitempty"`
+}
+
+// DetectStockEvents events caused by a ledger movement, threshold is the
+// product low stock threshold (0 is off)
+func DetectStockEvents(m *StockMovement, threshold int) []*StockEvent {
+	before, after := m.StockAfter-m.Delta, m.StockAfter
+
+	var events []*StockEvent
+	if threshold > 0 && before > threshold && after <= threshold {
+		events = append(events, &StockEvent{ProductID: m.ProductID, Kind: EventLowStock, Stock: after})
+	}
+	if before <= 0 && after > 0 {
+		events = append(events, &StockEvent{ProductID: m.ProductID, Kind: EventBackInStock, Stock: after})
+	}
+	return events
+}
+
+// EventWriter queues stock events, implemented by the inventory repository
+type EventWriter interface {
+	InsertEvent(ctx context.Context, tx *sql.Tx, input *StockEvent) error
+}
+
+// RecordStockEvents queues the events of a ledger movement in its transaction
+func RecordStockEvents(ctx context.Context, tx *sql.Tx, w EventWriter, m *StockMovement, threshold int) error {
+	for _, e := range DetectStockEvents(m, threshold) {
+		if err := w.InsertEvent(ctx, tx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Subscription back-in-stock request, NotifiedAt is set once notified
+type Subscription struct {
+	ID         int64      `json:"id"`
+	ProductID  int64      `json:"product_id"`
+	UserID     int64      `json:"user_id"`
+	Email      string     `json:"email"`
+	CreatedAt  time.Time  `json:"created_at"`
+	NotifiedAt *time.Time `json:"notified_at,omitempty"`
+}
+
+// AlertProduct product details for stock notifications
+type AlertProduct struct {
+	ID         int64
+	Name       string
+	SKU        string
+	Slug       string
+	Stock      int
+	Threshold  int
+	OwnerEmail string
+}
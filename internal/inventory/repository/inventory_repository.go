@@ -0,0 +1,633 @@
+package inventoryrepository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/codepnw/mini-ecommerce/internal/inventory"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/pkg/database"
+	"github.com/lib/pq"
+)
+
+//go:generate mockgen -source=inventory_repository.go -destination=mock_inventory_repository.go -package=inventoryrepository
+
+type InventoryRepository interface {
+	// Reservations
+	ReservedQuantity(ctx context.Context, exec database.DBExec, productID int64, excludeCartID string) (int, error)
+	DeleteExpiredReservations(ctx context.Context) (int64, error)
+
+	// Stock Ledger
+	ListMovements(ctx context.Context, filter *inventory.MovementFilter) ([]*inventory.StockMovement, error)
+	Reconcile(ctx context.Context, driftOnly bool) ([]*inventory.StockDrift, error)
+
+	// Warehouses
+	ListWarehouses(ctx context.Context, activeOnly bool) ([]*inventory.Warehouse, error)
+	InsertWarehouse(ctx context.Context, input *inventory.Warehouse) error
+	UpdateWarehouse(ctx context.Context, input *inventory.WarehouseUpdate) (*inventory.Warehouse, error)
+
+	// Stock Alerts
+	PendingEvents(ctx context.Context, limit, maxAttempts int) ([]*inventory.StockEvent, error)
+	MarkEventProcessed(ctx context.Context, eventID int64) error
+	MarkEventFailed(ctx context.Context, eventID int64, reason string) error
+	FindAlertProduct(ctx context.Context, productID int64) (*inventory.AlertProduct, error)
+	Subscribe(ctx context.Context, input *inventory.Subscription) error
+	Unsubscribe(ctx context.Context, productID, userID int64) error
+	PendingSubscriptions(ctx context.Context, productID int64) ([]*inventory.Subscription, error)
+	MarkSubscriptionNotified(ctx context.Context, subscriptionID int64) error
+
+	// DB or Tx
+	FindWarehouseByID(ctx context.Context, exec database.DBExec, id int64) (*inventory.Warehouse, error)
+	DefaultWarehouse(ctx context.Context, exec database.DBExec) (*inventory.Warehouse, error)
+	StockLevels(ctx context.Context, exec database.DBExec, productIDs []int64) ([]*inventory.StockLevel, error)
+
+	// Transaction
+	UpsertReservation(ctx context.Context, tx *sql.Tx, input *inventory.Reservation) error
+	InsertMovement(ctx context.Context, tx *sql.Tx, input *inventory.StockMovement) error
+	AdjustWarehouseStock(ctx context.Context, tx *sql.Tx, warehouseID, productID int64, delta int) error
+	InsertEvent(ctx context.Context, tx *sql.Tx, input *inventory.StockEvent) error
+}
+
+type inventoryRepository struct {
+	db *sql.DB
+}
+
+func NewInventoryRepository(db *sql.DB) InventoryRepository {
+	return &inventoryRepository{db: db}
+}
+
+// ReservedQuantity active holds of a product, holds of excludeCartID are not counted
+func (r *inventoryRepository) ReservedQuantity(ctx context.Context, exec database.DBExec, productID int64, excludeCartID string) (int, error) {
+	query := `
+		SELECT COALESCE(SUM(quantity), 0) FROM inventory_reservations
+		WHERE product_id = $1 AND expires_at > NOW() AND cart_id <> $2::uuid
+	`
+	var reserved int
+	if err := exec.QueryRowContext(ctx, query, productID, excludeCartID).Scan(&reserved); err != nil {
+		return 0, err
+	}
+	return reserved, nil
+}
+
+func (r *inventoryRepository) DeleteExpiredReservations(ctx context.Context) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM inventory_reservations WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// UpsertReservation one hold per cart line, quantity & expiry are replaced
+func (r *inventoryRepository) UpsertReservation(ctx context.Context, tx *sql.Tx, input *inventory.Reservation) error {
+	query := `
+		INSERT INTO inventory_reservations (cart_item_id, cart_id, product_id, quantity, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (cart_item_id)
+		DO UPDATE SET
+			quantity = EXCLUDED.quantity,
+			expires_at = EXCLUDED.expires_at,
+			updated_at = NOW()
+		RETURNING id, created_at, updated_at
+	`
+	return tx.QueryRowContext(
+		ctx,
+		query,
+		input.CartItemID,
+		input.CartID,
+		input.ProductID,
+		input.Quantity,
+		input.ExpiresAt,
+	).Scan(&input.ID, &input.CreatedAt, &input.UpdatedAt)
+}
+
+// InsertMovement must run in the transaction that changed the stock,
+// stock_after is read from the updated product row
+func (r *inventoryRepository) InsertMovement(ctx context.Context, tx *sql.Tx, input *inventory.StockMovement) error {
+	query := `
+		INSERT INTO stock_movements (product_id, delta, stock_after, reason, ref_type, ref_id, actor_id, note, warehouse_id)
+		VALUES ($1, $2, (SELECT stock FROM products WHERE id = $1), $3, $4, $5, $6, $7, $8)
+		RETURNING id, stock_after, created_at
+	`
+	return tx.QueryRowContext(
+		ctx,
+		query,
+		input.ProductID,
+		input.Delta,
+		input.Reason,
+		sql.NullString{String: input.RefType, Valid: input.RefType != ""},
+		sql.NullInt64{Int64: input.RefID, Valid: input.RefID > 0},
+		sql.NullInt64{Int64: input.ActorID, Valid: input.ActorID > 0},
+		sql.NullString{String: input.Note, Valid: input.Note != ""},
+		sql.NullInt64{Int64: input.WarehouseID, Valid: input.WarehouseID > 0},
+	).Scan(&input.ID, &input.StockAfter, &input.CreatedAt)
+}
+
+func (r *inventoryRepository) ListMovements(ctx context.Context, filter *inventory.MovementFilter) ([]*inventory.StockMovement, error) {
+	query := `
+		SELECT id, product_id, delta, stock_after, reason, ref_type, ref_id, actor_id, note, warehouse_id, created_at
+		FROM stock_movements WHERE 1=1
+	`
+	var args []any
+	idx := 1
+
+	if filter.ProductID > 0 {
+		query += fmt.Sprintf(" AND product_id = $%d", idx)
+		args = append(args, filter.ProductID)
+		idx++
+	}
+	if filter.Reason != "" {
+		query += fmt.Sprintf(" AND reason = $%d", idx)
+		args = append(args, filter.Reason)
+		idx++
+	}
+	if filter.RefType != "" {
+		query += fmt.Sprintf(" AND ref_type = $%d", idx)
+		args = append(args, filter.RefType)
+		idx++
+	}
+	if filter.RefID > 0 {
+		query += fmt.Sprintf(" AND ref_id = $%d", idx)
+		args = append(args, filter.RefID)
+		idx++
+	}
+	if filter.WarehouseID > 0 {
+		query += fmt.Sprintf(" AND warehouse_id = $%d", idx)
+		args = append(args, filter.WarehouseID)
+		idx++
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d OFFSET $%d", idx, idx+1)
+	args = append(args, filter.Limit, offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movements := make([]*inventory.StockMovement, 0)
+	for rows.Next() {
+		var (
+			m           = new(inventory.StockMovement)
+			refType     sql.NullString
+			refID       sql.NullInt64
+			actorID     sql.NullInt64
+			note        sql.NullString
+			warehouseID sql.NullInt64
+		)
+		err := rows.Scan(
+			&m.ID,
+			&m.ProductID,
+			&m.Delta,
+			&m.StockAfter,
+			&m.Reason,
+			&refType,
+			&refID,
+			&actorID,
+			&note,
+			&warehouseID,
+			&m.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		m.RefType = refType.String
+		m.RefID = refID.Int64
+		m.ActorID = actorID.Int64
+		m.Note = note.String
+		m.WarehouseID = warehouseID.Int64
+
+		movements = append(movements, m)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+// Reconcile compares products.stock with SUM(delta) of the ledger and SUM(quantity) of warehouse_stock
+func (r *inventoryRepository) Reconcile(ctx context.Context, driftOnly bool) ([]*inventory.StockDrift, error) {
+	query := `
+		SELECT p.id, p.sku, p.name, p.stock, COALESCE(m.total, 0), COALESCE(w.total, 0)
+		FROM products p
+		LEFT JOIN (
+			SELECT product_id, SUM(delta) AS total FROM stock_movements GROUP BY product_id
+		) m ON m.product_id = p.id
+		LEFT JOIN (
+			SELECT product_id, SUM(quantity) AS total FROM warehouse_stock GROUP BY product_id
+		) w ON w.product_id = p.id
+	`
+	if driftOnly {
+		query += ` WHERE p.stock <> COALESCE(m.total, 0) OR p.stock <> COALESCE(w.total, 0)`
+	}
+	query += ` ORDER BY p.id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	drifts := make([]*inventory.StockDrift, 0)
+	for rows.Next() {
+		d := new(inventory.StockDrift)
+		if err := rows.Scan(&d.ProductID, &d.SKU, &d.Name, &d.Stock, &d.LedgerStock, &d.WarehouseStock); err != nil {
+			return nil, err
+		}
+		d.Drift = d.Stock - d.LedgerStock
+		d.WarehouseDrift = d.Stock - d.WarehouseStock
+		drifts = append(drifts, d)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return drifts, nil
+}
+
+const warehouseColumns = `id, code, name, latitude, longitude, priority, is_default, is_active, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanWarehouse(row rowScanner) (*inventory.Warehouse, error) {
+	var (
+		w         = new(inventory.Warehouse)
+		latitude  sql.NullFloat64
+		longitude sql.NullFloat64
+	)
+	err := row.Scan(
+		&w.ID,
+		&w.Code,
+		&w.Name,
+		&latitude,
+		&longitude,
+		&w.Priority,
+		&w.IsDefault,
+		&w.IsActive,
+		&w.CreatedAt,
+		&w.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if latitude.Valid && longitude.Valid {
+		w.Latitude = &latitude.Float64
+		w.Longitude = &longitude.Float64
+	}
+	return w, nil
+}
+
+func (r *inventoryRepository) ListWarehouses(ctx context.Context, activeOnly bool) ([]*inventory.Warehouse, error) {
+	query := `SELECT ` + warehouseColumns + ` FROM warehouses`
+	if activeOnly {
+		query += ` WHERE is_active`
+	}
+	query += ` ORDER BY priority, id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	warehouses := make([]*inventory.Warehouse, 0)
+	for rows.Next() {
+		w, err := scanWarehouse(rows)
+		if err != nil {
+			return nil, err
+		}
+		warehouses = append(warehouses, w)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return warehouses, nil
+}
+
+func (r *inventoryRepository) FindWarehouseByID(ctx context.Context, exec database.DBExec, id int64) (*inventory.Warehouse, error) {
+	query := `SELECT ` + warehouseColumns + ` FROM warehouses WHERE id = $1`
+
+	w, err := scanWarehouse(exec.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrWarehouseNotFound
+		}
+		return nil, err
+	}
+	return w, nil
+}
+
+// DefaultWarehouse receives stock that is not tied to a warehouse (product create & update)
+func (r *inventoryRepository) DefaultWarehouse(ctx context.Context, exec database.DBExec) (*inventory.Warehouse, error) {
+	query := `SELECT ` + warehouseColumns + ` FROM warehouses WHERE is_default LIMIT 1`
+
+	w, err := scanWarehouse(exec.QueryRowContext(ctx, query))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrWarehouseNotFound
+		}
+		return nil, err
+	}
+	return w, nil
+}
+
+func (r *inventoryRepository) InsertWarehouse(ctx context.Context, input *inventory.Warehouse) error {
+	query := `
+		INSERT INTO warehouses (code, name, latitude, longitude, priority, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, is_default, created_at, updated_at
+	`
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		input.Code,
+		input.Name,
+		input.Latitude,
+		input.Longitude,
+		input.Priority,
+		input.IsActive,
+	).Scan(&input.ID, &input.IsDefault, &input.CreatedAt, &input.UpdatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
+			return errs.ErrWarehouseCodeExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *inventoryRepository) UpdateWarehouse(ctx context.Context, input *inventory.WarehouseUpdate) (*inventory.Warehouse, error) {
+	var (
+		sets []string
+		args []any
+	)
+	set := func(col string, v any) {
+		args = append(args, v)
+		sets = append(sets, fmt.Sprintf("%s = $%d", col, len(args)))
+	}
+
+	if input.Name != nil {
+		set("name", *input.Name)
+	}
+	if input.Latitude != nil {
+		set("latitude", *input.Latitude)
+	}
+	if input.Longitude != nil {
+		set("longitude", *input.Longitude)
+	}
+	if input.Priority != nil {
+		set("priority", *input.Priority)
+	}
+	if input.IsActive != nil {
+		set("is_active", *input.IsActive)
+	}
+	sets = append(sets, "updated_at = NOW()")
+	args = append(args, input.ID)
+
+	query := fmt.Sprintf(
+		`UPDATE warehouses SET %s WHERE id = $%d RETURNING %s`,
+		strings.Join(sets, ", "),
+		len(args),
+		warehouseColumns,
+	)
+
+	w, err := scanWarehouse(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrWarehouseNotFound
+		}
+		return nil, err
+	}
+	return w, nil
+}
+
+// StockLevels quantities of the products in every warehouse that has them
+func (r *inventoryRepository) StockLevels(ctx context.Context, exec database.DBExec, productIDs []int64) ([]*inventory.StockLevel, error) {
+	query := `
+		SELECT s.warehouse_id, w.code, s.product_id, s.quantity, s.updated_at
+		FROM warehouse_stock s
+		INNER JOIN warehouses w ON w.id = s.warehouse_id
+		WHERE s.product_id = ANY($1)
+		ORDER BY s.product_id, w.priority, w.id
+	`
+	rows, err := exec.QueryContext(ctx, query, pq.Array(productIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	levels := make([]*inventory.StockLevel, 0)
+	for rows.Next() {
+		l := new(inventory.StockLevel)
+		err := rows.Scan(
+			&l.WarehouseID,
+			&l.WarehouseCode,
+			&l.ProductID,
+			&l.Quantity,
+			&l.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		levels = append(levels, l)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return levels, nil
+}
+
+// AdjustWarehouseStock adds delta to the warehouse quantity,
+// a decrease below zero returns ErrWarehouseNotEnough
+func (r *inventoryRepository) AdjustWarehouseStock(ctx context.Context, tx *sql.Tx, warehouseID, productID int64, delta int) error {
+	if delta >= 0 {
+		query := `
+			INSERT INTO warehouse_stock (warehouse_id, product_id, quantity)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (warehouse_id, product_id)
+			DO UPDATE SET
+				quantity = warehouse_stock.quantity + EXCLUDED.quantity,
+				updated_at = NOW()
+		`
+		_, err := tx.ExecContext(ctx, query, warehouseID, productID, delta)
+		return err
+	}
+
+	query := `
+		UPDATE warehouse_stock
+		SET quantity = quantity + $3, updated_at = NOW()
+		WHERE warehouse_id = $1 AND product_id = $2 AND quantity + $3 >= 0
+	`
+	res, err := tx.ExecContext(ctx, query, warehouseID, productID, delta)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errs.ErrWarehouseNotEnough
+	}
+	return nil
+}
+
+// InsertEvent must run in the transaction that changed the stock,
+// a rolled back change leaves no event behind
+func (r *inventoryRepository) InsertEvent(ctx context.Context, tx *sql.Tx, input *inventory.StockEvent) error {
+	query := `
+		INSERT INTO stock_events (product_id, kind, stock)
+		VALUES ($1, $2, $3) RETURNING id, created_at
+	`
+	return tx.QueryRowContext(
+		ctx,
+		query,
+		input.ProductID,
+		input.Kind,
+		input.Stock,
+	).Scan(&input.ID, &input.CreatedAt)
+}
+
+// PendingEvents oldest first, failed events after the others. Events that
+// failed maxAttempts times are given up.
+func (r *inventoryRepository) PendingEvents(ctx context.Context, limit, maxAttempts int) ([]*inventory.StockEvent, error) {
+	query := `
+		SELECT id, product_id, kind, stock, created_at
+		FROM stock_events WHERE processed_at IS NULL AND attempts < $2
+		ORDER BY attempts, id LIMIT $1
+	`
+	rows, err := r.db.QueryContext(ctx, query, limit, maxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]*inventory.StockEvent, 0)
+	for rows.Next() {
+		e := new(inventory.StockEvent)
+		if err := rows.Scan(&e.ID, &e.ProductID, &e.Kind, &e.Stock, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *inventoryRepository) MarkEventProcessed(ctx context.Context, eventID int64) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE stock_events SET processed_at = NOW() WHERE id = $1`, eventID)
+	return err
+}
+
+func (r *inventoryRepository) MarkEventFailed(ctx context.Context, eventID int64, reason string) error {
+	query := `UPDATE stock_events SET attempts = attempts + 1, last_error = $2 WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, eventID, reason)
+	return err
+}
+
+// FindAlertProduct OwnerEmail is empty for products without an owner
+func (r *inventoryRepository) FindAlertProduct(ctx context.Context, productID int64) (*inventory.AlertProduct, error) {
+	query := `
+		SELECT p.id, p.name, p.sku, p.slug, p.stock, p.low_stock_threshold, u.email
+		FROM products p
+		LEFT JOIN users u ON u.id = p.owner_id
+		WHERE p.id = $1
+	`
+	var ownerEmail sql.NullString
+	p := new(inventory.AlertProduct)
+	err := r.db.QueryRowContext(ctx, query, productID).Scan(
+		&p.ID,
+		&p.Name,
+		&p.SKU,
+		&p.Slug,
+		&p.Stock,
+		&p.Threshold,
+		&ownerEmail,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrProductNotFound
+		}
+		return nil, err
+	}
+	p.OwnerEmail = ownerEmail.String
+	return p, nil
+}
+
+// Subscribe keeps one active subscription per user & product, subscribing again is a no-op
+func (r *inventoryRepository) Subscribe(ctx context.Context, input *inventory.Subscription) error {
+	query := `
+		INSERT INTO stock_subscriptions (product_id, user_id, email)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (product_id, user_id) WHERE notified_at IS NULL
+		DO UPDATE SET email = EXCLUDED.email
+		RETURNING id, created_at
+	`
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		input.ProductID,
+		input.UserID,
+		input.Email,
+	).Scan(&input.ID, &input.CreatedAt)
+}
+
+func (r *inventoryRepository) Unsubscribe(ctx context.Context, productID, userID int64) error {
+	query := `
+		DELETE FROM stock_subscriptions
+		WHERE product_id = $1 AND user_id = $2 AND notified_at IS NULL
+	`
+	res, err := r.db.ExecContext(ctx, query, productID, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errs.ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+func (r *inventoryRepository) PendingSubscriptions(ctx context.Context, productID int64) ([]*inventory.Subscription, error) {
+	query := `
+		SELECT id, product_id, user_id, email, created_at
+		FROM stock_subscriptions
+		WHERE product_id = $1 AND notified_at IS NULL
+		ORDER BY id
+	`
+	rows, err := r.db.QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := make([]*inventory.Subscription, 0)
+	for rows.Next() {
+		s := new(inventory.Subscription)
+		if err := rows.Scan(&s.ID, &s.ProductID, &s.UserID, &s.Email, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		subs = append(subs, s)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+func (r *inventoryRepository) MarkSubscriptionNotified(ctx context.Context, subscriptionID int64) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE stock_subscriptions SET notified_at = NOW() WHERE id = $1`, subscriptionID)
+	return err
+}
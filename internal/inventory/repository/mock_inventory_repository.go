@@ -0,0 +1,399 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: inventory_repository.go
+
+// Package inventoryrepository is a generated GoMock package.
+package inventoryrepository
+
+import (
+	context "context"
+	sql "database/sql"
+	reflect "reflect"
+
+	inventory "github.com/codepnw/mini-ecommerce/internal/inventory"
+	database "github.com/codepnw/mini-ecommerce/pkg/database"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockInventoryRepository is a mock of InventoryRepository interface.
+type MockInventoryRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockInventoryRepositoryMockRecorder
+}
+
+// MockInventoryRepositoryMockRecorder is the mock recorder for MockInventoryRepository.
+type MockInventoryRepositoryMockRecorder struct {
+	mock *MockInventoryRepository
+}
+
+// NewMockInventoryRepository creates a new mock instance.
+func NewMockInventoryRepository(ctrl *gomock.Controller) *MockInventoryRepository {
+	mock := &MockInventoryRepository{ctrl: ctrl}
+	mock.recorder = &MockInventoryRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInventoryRepository) EXPECT() *MockInventoryRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AdjustWarehouseStock mocks base method.
+func (m *MockInventoryRepository) AdjustWarehouseStock(ctx context.Context, tx *sql.Tx, warehouseID, productID int64, delta int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdjustWarehouseStock", ctx, tx, warehouseID, productID, delta)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AdjustWarehouseStock indicates an expected call of AdjustWarehouseStock.
+func (mr *MockInventoryRepositoryMockRecorder) AdjustWarehouseStock(ctx, tx, warehouseID, productID, delta interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdjustWarehouseStock", reflect.TypeOf((*MockInventoryRepository)(nil).AdjustWarehouseStock), ctx, tx, warehouseID, productID, delta)
+}
+
+// DefaultWarehouse mocks base method.
+func (m *MockInventoryRepository) DefaultWarehouse(ctx context.Context, exec database.DBExec) (*inventory.Warehouse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DefaultWarehouse", ctx, exec)
+	ret0, _ := ret[0].(*inventory.Warehouse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DefaultWarehouse indicates an expected call of DefaultWarehouse.
+func (mr *MockInventoryRepositoryMockRecorder) DefaultWarehouse(ctx, exec interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DefaultWarehouse", reflect.TypeOf((*MockInventoryRepository)(nil).DefaultWarehouse), ctx, exec)
+}
+
+// DeleteExpiredReservations mocks base method.
+func (m *MockInventoryRepository) DeleteExpiredReservations(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredReservations", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredReservations indicates an expected call of DeleteExpiredReservations.
+func (mr *MockInventoryRepositoryMockRecorder) DeleteExpiredReservations(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredReservations", reflect.TypeOf((*MockInventoryRepository)(nil).DeleteExpiredReservations), ctx)
+}
+
+// FindAlertProduct mocks base method.
+func (m *MockInventoryRepository) FindAlertProduct(ctx context.Context, productID int64) (*inventory.AlertProduct, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAlertProduct", ctx, productID)
+	ret0, _ := ret[0].(*inventory.AlertProduct)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAlertProduct indicates an expected call of FindAlertProduct.
+func (mr *MockInventoryRepositoryMockRecorder) FindAlertProduct(ctx, productID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAlertProduct", reflect.TypeOf((*MockInventoryRepository)(nil).FindAlertProduct), ctx, productID)
+}
+
+// FindWarehouseByID mocks base method.
+func (m *MockInventoryRepository) FindWarehouseByID(ctx context.Context, exec database.DBExec, id int64) (*inventory.Warehouse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindWarehouseByID", ctx, exec, id)
+	ret0, _ := ret[0].(*inventory.Warehouse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindWarehouseByID indicates an expected call of FindWarehouseByID.
+func (mr *MockInventoryRepositoryMockRecorder) FindWarehouseByID(ctx, exec, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindWarehouseByID", reflect.TypeOf((*MockInventoryRepository)(nil).FindWarehouseByID), ctx, exec, id)
+}
+
+// InsertEvent mocks base method.
+func (m *MockInventoryRepository) InsertEvent(ctx context.Context, tx *sql.Tx, input *inventory.StockEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertEvent", ctx, tx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertEvent indicates an expected call of InsertEvent.
+func (mr *MockInventoryRepositoryMockRecorder) InsertEvent(ctx, tx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertEvent", reflect.TypeOf((*MockInventoryRepository)(nil).InsertEvent), ctx, tx, input)
+}
+
+// InsertMovement mocks base method.
+func (m *MockInventoryRepository) InsertMovement(ctx context.Context, tx *sql.Tx, input *inventory.StockMovement) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertMovement", ctx, tx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertMovement indicates an expected call of InsertMovement.
+func (mr *MockInventoryRepositoryMockRecorder) InsertMovement(ctx, tx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertMovement", reflect.TypeOf((*MockInventoryRepository)(nil).InsertMovement), ctx, tx, input)
+}
+
+// InsertWarehouse mocks base method.
+func (m *MockInventoryRepository) InsertWarehouse(ctx context.Context, input *inventory.Warehouse) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertWarehouse", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertWarehouse indicates an expected call of InsertWarehouse.
+func (mr *MockInventoryRepositoryMockRecorder) InsertWarehouse(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertWarehouse", reflect.TypeOf((*MockInventoryRepository)(nil).InsertWarehouse), ctx, input)
+}
+
+// ListMovements mocks base method.
+func (m *MockInventoryRepository) ListMovements(ctx context.Context, filter *inventory.MovementFilter) ([]*inventory.StockMovement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMovements", ctx, filter)
+	ret0, _ := ret[0].([]*inventory.StockMovement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMovements indicates an expected call of ListMovements.
+func (mr *MockInventoryRepositoryMockRecorder) ListMovements(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMovements", reflect.TypeOf((*MockInventoryRepository)(nil).ListMovements), ctx, filter)
+}
+
+// ListWarehouses mocks base method.
+func (m *MockInventoryRepository) ListWarehouses(ctx context.Context, activeOnly bool) ([]*inventory.Warehouse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWarehouses", ctx, activeOnly)
+	ret0, _ := ret[0].([]*inventory.Warehouse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWarehouses indicates an expected call of ListWarehouses.
+func (mr *MockInventoryRepositoryMockRecorder) ListWarehouses(ctx, activeOnly interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWarehouses", reflect.TypeOf((*MockInventoryRepository)(nil).ListWarehouses), ctx, activeOnly)
+}
+
+// MarkEventFailed mocks base method.
+func (m *MockInventoryRepository) MarkEventFailed(ctx context.Context, eventID int64, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkEventFailed", ctx, eventID, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkEventFailed indicates an expected call of MarkEventFailed.
+func (mr *MockInventoryRepositoryMockRecorder) MarkEventFailed(ctx, eventID, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkEventFailed", reflect.TypeOf((*MockInventoryRepository)(nil).MarkEventFailed), ctx, eventID, reason)
+}
+
+// MarkEventProcessed mocks base method.
+func (m *MockInventoryRepository) MarkEventProcessed(ctx context.Context, eventID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkEventProcessed", ctx, eventID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkEventProcessed indicates an expected call of MarkEventProcessed.
+func (mr *MockInventoryRepositoryMockRecorder) MarkEventProcessed(ctx, eventID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkEventProcessed", reflect.TypeOf((*MockInventoryRepository)(nil).MarkEventProcessed), ctx, eventID)
+}
+
+// MarkSubscriptionNotified mocks base method.
+func (m *MockInventoryRepository) MarkSubscriptionNotified(ctx context.Context, subscriptionID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkSubscriptionNotified", ctx, subscriptionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkSubscriptionNotified indicates an expected call of MarkSubscriptionNotified.
+func (mr *MockInventoryRepositoryMockRecorder) MarkSubscriptionNotified(ctx, subscriptionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkSubscriptionNotified", reflect.TypeOf((*MockInventoryRepository)(nil).MarkSubscriptionNotified), ctx, subscriptionID)
+}
+
+// PendingEvents mocks base method.
+func (m *MockInventoryRepository) PendingEvents(ctx context.Context, limit, maxAttempts int) ([]*inventory.StockEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PendingEvents", ctx, limit, maxAttempts)
+	ret0, _ := ret[0].([]*inventory.StockEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PendingEvents indicates an expected call of PendingEvents.
+func (mr *MockInventoryRepositoryMockRecorder) PendingEvents(ctx, limit, maxAttempts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PendingEvents", reflect.TypeOf((*MockInventoryRepository)(nil).PendingEvents), ctx, limit, maxAttempts)
+}
+
+// PendingSubscriptions mocks base method.
+func (m *MockInventoryRepository) PendingSubscriptions(ctx context.Context, productID int64) ([]*inventory.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PendingSubscriptions", ctx, productID)
+	ret0, _ := ret[0].([]*inventory.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PendingSubscriptions indicates an expected call of PendingSubscriptions.
+func (mr *MockInventoryRepositoryMockRecorder) PendingSubscriptions(ctx, productID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PendingSubscriptions", reflect.TypeOf((*MockInventoryRepository)(nil).PendingSubscriptions), ctx, productID)
+}
+
+// Reconcile mocks base method.
+func (m *MockInventoryRepository) Reconcile(ctx context.Context, driftOnly bool) ([]*inventory.StockDrift, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reconcile", ctx, driftOnly)
+	ret0, _ := ret[0].([]*inventory.StockDrift)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reconcile indicates an expected call of Reconcile.
+func (mr *MockInventoryRepositoryMockRecorder) Reconcile(ctx, driftOnly interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reconcile", reflect.TypeOf((*MockInventoryRepository)(nil).Reconcile), ctx, driftOnly)
+}
+
+// ReservedQuantity mocks base method.
+func (m *MockInventoryRepository) ReservedQuantity(ctx context.Context, exec database.DBExec, productID int64, excludeCartID string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReservedQuantity", ctx, exec, productID, excludeCartID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReservedQuantity indicates an expected call of ReservedQuantity.
+func (mr *MockInventoryRepositoryMockRecorder) ReservedQuantity(ctx, exec, productID, excludeCartID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReservedQuantity", reflect.TypeOf((*MockInventoryRepository)(nil).ReservedQuantity), ctx, exec, productID, excludeCartID)
+}
+
+// StockLevels mocks base method.
+func (m *MockInventoryRepository) StockLevels(ctx context.Context, exec database.DBExec, productIDs []int64) ([]*inventory.StockLevel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StockLevels", ctx, exec, productIDs)
+	ret0, _ := ret[0].([]*inventory.StockLevel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StockLevels indicates an expected call of StockLevels.
+func (mr *MockInventoryRepositoryMockRecorder) StockLevels(ctx, exec, productIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StockLevels", reflect.TypeOf((*MockInventoryRepository)(nil).StockLevels), ctx, exec, productIDs)
+}
+
+// Subscribe mocks base method.
+func (m *MockInventoryRepository) Subscribe(ctx context.Context, input *inventory.Subscription) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockInventoryRepositoryMockRecorder) Subscribe(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockInventoryRepository)(nil).Subscribe), ctx, input)
+}
+
+// Unsubscribe mocks base method.
+func (m *MockInventoryRepository) Unsubscribe(ctx context.Context, productID, userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unsubscribe", ctx, productID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unsubscribe indicates an expected call of Unsubscribe.
+func (mr *MockInventoryRepositoryMockRecorder) Unsubscribe(ctx, productID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unsubscribe", reflect.TypeOf((*MockInventoryRepository)(nil).Unsubscribe), ctx, productID, userID)
+}
+
+// UpdateWarehouse mocks base method.
+func (m *MockInventoryRepository) UpdateWarehouse(ctx context.Context, input *inventory.WarehouseUpdate) (*inventory.Warehouse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWarehouse", ctx, input)
+	ret0, _ := ret[0].(*inventory.Warehouse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateWarehouse indicates an expected call of UpdateWarehouse.
+func (mr *MockInventoryRepositoryMockRecorder) UpdateWarehouse(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWarehouse", reflect.TypeOf((*MockInventoryRepository)(nil).UpdateWarehouse), ctx, input)
+}
+
+// UpsertReservation mocks base method.
+func (m *MockInventoryRepository) UpsertReservation(ctx context.Context, tx *sql.Tx, input *inventory.Reservation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertReservation", ctx, tx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertReservation indicates an expected call of UpsertReservation.
+func (mr *MockInventoryRepositoryMockRecorder) UpsertReservation(ctx, tx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertReservation", reflect.TypeOf((*MockInventoryRepository)(nil).UpsertReservation), ctx, tx, input)
+}
+
+// MockrowScanner is a mock of rowScanner interface.
+type MockrowScanner struct {
+	ctrl     *gomock.Controller
+	recorder *MockrowScannerMockRecorder
+}
+
+// MockrowScannerMockRecorder is the mock recorder for MockrowScanner.
+type MockrowScannerMockRecorder struct {
+	mock *MockrowScanner
+}
+
+// NewMockrowScanner creates a new mock instance.
+func NewMockrowScanner(ctrl *gomock.Controller) *MockrowScanner {
+	mock := &MockrowScanner{ctrl: ctrl}
+	mock.recorder = &MockrowScannerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockrowScanner) EXPECT() *MockrowScannerMockRecorder {
+	return m.recorder
+}
+
+// Scan mocks base method.
+func (m *MockrowScanner) Scan(dest ...any) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range dest {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Scan", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Scan indicates an expected call of Scan.
+func (mr *MockrowScannerMockRecorder) Scan(dest ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockrowScanner)(nil).Scan), dest...)
+}
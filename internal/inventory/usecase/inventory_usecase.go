@@ -0,0 +1,333 @@
+package inventoryusecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/codepnw/mini-ecommerce/internal/inventory"
+	inventoryrepository "github.com/codepnw/mini-ecommerce/internal/inventory/repository"
+	productrepository "github.com/codepnw/mini-ecommerce/internal/product/repository"
+	"github.com/codepnw/mini-ecommerce/internal/user"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/pkg/auth"
+	"github.com/codepnw/mini-ecommerce/pkg/database"
+	"github.com/codepnw/mini-ecommerce/pkg/notify"
+)
+
+type InventoryUsecase interface {
+	ReleaseExpiredReservations(ctx context.Context) (int64, error)
+	ProcessStockEvents(ctx context.Context) (int, error)
+
+	// Admin
+	ListMovements(ctx context.Context, filter *inventory.MovementFilter) ([]*inventory.StockMovement, error)
+	Reconcile(ctx context.Context, driftOnly bool) ([]*inventory.StockDrift, error)
+	ListWarehouses(ctx context.Context) ([]*inventory.Warehouse, error)
+	CreateWarehouse(ctx context.Context, input *inventory.Warehouse) (*inventory.Warehouse, error)
+	UpdateWarehouse(ctx context.Context, input *inventory.WarehouseUpdate) (*inventory.Warehouse, error)
+	GetStockLevels(ctx context.Context, productID int64) ([]*inventory.StockLevel, error)
+	SetStock(ctx context.Context, input *inventory.StockSet) ([]*inventory.StockLevel, error)
+}
+
+type inventoryUsecase struct {
+	repo        inventoryrepository.InventoryRepository
+	productRepo productrepository.ProductRepository
+	notifier    notify.Notifier
+	tx          database.TxManager
+	db          database.DBExec
+}
+
+func NewInventoryUsecase(
+	repo inventoryrepository.InventoryRepository,
+	productRepo productrepository.ProductRepository,
+	notifier notify.Notifier,
+	tx database.TxManager,
+	db database.DBExec,
+) InventoryUsecase {
+	return &inventoryUsecase{
+		repo:        repo,
+		productRepo: productRepo,
+		notifier:    notifier,
+		tx:          tx,
+		db:          db,
+	}
+}
+
+// ReleaseExpiredReservations run by the sweeper, expired holds are already
+// ignored by stock checks so this only keeps the table small
+func (u *inventoryUsecase) ReleaseExpiredReservations(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	return u.repo.DeleteExpiredReservations(ctx)
+}
+
+// ProcessStockEvents run by the event job, a failed event stays pending and is
+// retried after the others on the next runs. Returns the number processed, a
+// failed event does not stop the others.
+func (u *inventoryUsecase) ProcessStockEvents(ctx context.Context) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout*3)
+	defer cancel()
+
+	events, err := u.repo.PendingEvents(ctx, consts.StockEventBatch, consts.StockEventMaxAttempts)
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	var failed []error
+	for _, e := range events {
+		if err := u.processEvent(ctx, e); err != nil {
+			if ctx.Err() != nil {
+				return processed, ctx.Err()
+			}
+			// Retried after the others next run, a failing event does not
+			// hold back the queue
+			if err := u.repo.MarkEventFailed(ctx, e.ID, err.Error()); err != nil {
+				return processed, err
+			}
+			failed = append(failed, fmt.Errorf("stock event #%d: %w", e.ID, err))
+			continue
+		}
+		processed++
+	}
+	return processed, errors.Join(failed...)
+}
+
+func (u *inventoryUsecase) processEvent(ctx context.Context, e *inventory.StockEvent) error {
+	productData, err := u.repo.FindAlertProduct(ctx, e.ProductID)
+	if err != nil {
+		return err
+	}
+
+	switch e.Kind {
+	case inventory.EventLowStock:
+		err = u.notifyLowStock(ctx, e, productData)
+	case inventory.EventBackInStock:
+		err = u.notifyBackInStock(ctx, productData)
+	}
+	if err != nil {
+		return err
+	}
+	return u.repo.MarkEventProcessed(ctx, e.ID)
+}
+
+// notifyLowStock alerts the owner, products without one have nobody to alert
+func (u *inventoryUsecase) notifyLowStock(ctx context.Context, e *inventory.StockEvent, p *inventory.AlertProduct) error {
+	if p.OwnerEmail == "" {
+		return nil
+	}
+	return u.notifier.Send(ctx, &notify.Message{
+		To:      p.OwnerEmail,
+		Subject: fmt.Sprintf("Low stock: %s", p.Name),
+		Body:    fmt.Sprintf("%s (SKU %s) is down to %d, alert threshold is %d.", p.Name, p.SKU, e.Stock, p.Threshold),
+	})
+}
+
+// notifyBackInStock each subscriber is notified once, sold out again
+// before the job ran keeps the subscriptions for the next restock
+func (u *inventoryUsecase) notifyBackInStock(ctx context.Context, p *inventory.AlertProduct) error {
+	if p.Stock <= 0 {
+		return nil
+	}
+
+	subs, err := u.repo.PendingSubscriptions(ctx, p.ID)
+	if err != nil {
+		return err
+	}
+	for _, s := range subs {
+		err := u.notifier.Send(ctx, &notify.Message{
+			To:      s.Email,
+			Subject: fmt.Sprintf("%s is back in stock", p.Name),
+			Body:    fmt.Sprintf("%s is available again: /products/slug/%s", p.Name, p.Slug),
+		})
+		if err != nil {
+			return err
+		}
+		if err := u.repo.MarkSubscriptionNotified(ctx, s.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *inventoryUsecase) ListMovements(ctx context.Context, filter *inventory.MovementFilter) ([]*inventory.StockMovement, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if !isAdmin(ctx) {
+		return nil, errs.ErrNoPermissions
+	}
+
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	return u.repo.ListMovements(ctx, filter)
+}
+
+func (u *inventoryUsecase) Reconcile(ctx context.Context, driftOnly bool) ([]*inventory.StockDrift, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if !isAdmin(ctx) {
+		return nil, errs.ErrNoPermissions
+	}
+	return u.repo.Reconcile(ctx, driftOnly)
+}
+
+func (u *inventoryUsecase) ListWarehouses(ctx context.Context) ([]*inventory.Warehouse, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if !isAdmin(ctx) {
+		return nil, errs.ErrNoPermissions
+	}
+	return u.repo.ListWarehouses(ctx, false)
+}
+
+func (u *inventoryUsecase) CreateWarehouse(ctx context.Context, input *inventory.Warehouse) (*inventory.Warehouse, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if !isAdmin(ctx) {
+		return nil, errs.ErrNoPermissions
+	}
+	if (input.Latitude == nil) != (input.Longitude == nil) {
+		return nil, errs.ErrWarehouseLocationInvalid
+	}
+
+	if err := u.repo.InsertWarehouse(ctx, input); err != nil {
+		return nil, err
+	}
+	return input, nil
+}
+
+func (u *inventoryUsecase) UpdateWarehouse(ctx context.Context, input *inventory.WarehouseUpdate) (*inventory.Warehouse, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if !isAdmin(ctx) {
+		return nil, errs.ErrNoPermissions
+	}
+	if !input.HasChanges() {
+		return nil, errs.ErrNoFieldsToUpdate
+	}
+	if (input.Latitude == nil) != (input.Longitude == nil) {
+		return nil, errs.ErrWarehouseLocationInvalid
+	}
+
+	current, err := u.repo.FindWarehouseByID(ctx, u.db, input.ID)
+	if err != nil {
+		return nil, err
+	}
+	// Product stock edits land in the default warehouse
+	if current.IsDefault && input.IsActive != nil && !*input.IsActive {
+		return nil, errs.ErrWarehouseDefault
+	}
+	return u.repo.UpdateWarehouse(ctx, input)
+}
+
+func (u *inventoryUsecase) GetStockLevels(ctx context.Context, productID int64) ([]*inventory.StockLevel, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if !isAdmin(ctx) {
+		return nil, errs.ErrNoPermissions
+	}
+	if _, err := u.productRepo.FindByID(ctx, productID); err != nil {
+		return nil, err
+	}
+	return u.repo.StockLevels(ctx, u.db, []int64{productID})
+}
+
+// SetStock moves the difference into products.stock and the ledger, so the
+// total stays the sum of all warehouses
+func (u *inventoryUsecase) SetStock(ctx context.Context, input *inventory.StockSet) ([]*inventory.StockLevel, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	currentUser, err := auth.GetCurrentUser(ctx)
+	if err != nil || currentUser.Role != string(user.RoleAdmin) {
+		return nil, errs.ErrNoPermissions
+	}
+	if input.Quantity < 0 {
+		return nil, errs.ErrProductStockInvalid
+	}
+
+	var levels []*inventory.StockLevel
+
+	err = u.tx.WithTransaction(ctx, func(tx *sql.Tx) error {
+		// Lock product, orders allocate under the same lock
+		productData, err := u.productRepo.FindByIDForUpdate(ctx, tx, input.ProductID)
+		if err != nil {
+			return err
+		}
+		if _, err := u.repo.FindWarehouseByID(ctx, tx, input.WarehouseID); err != nil {
+			return err
+		}
+
+		current, err := u.repo.StockLevels(ctx, tx, []int64{input.ProductID})
+		if err != nil {
+			return err
+		}
+		delta := input.Quantity
+		for _, l := range current {
+			if l.WarehouseID == input.WarehouseID {
+				delta = input.Quantity - l.Quantity
+			}
+		}
+		if delta == 0 {
+			levels = current
+			return nil
+		}
+
+		if err := u.repo.AdjustWarehouseStock(ctx, tx, input.WarehouseID, input.ProductID, delta); err != nil {
+			return err
+		}
+		if delta > 0 {
+			err = u.productRepo.IncreaseStock(ctx, tx, input.ProductID, delta)
+		} else {
+			err = u.productRepo.DecreaseStock(ctx, tx, input.ProductID, -delta)
+		}
+		if err != nil {
+			return err
+		}
+
+		movement := &inventory.StockMovement{
+			ProductID:   input.ProductID,
+			Delta:       delta,
+			Reason:      inventory.ReasonAdjust,
+			RefType:     inventory.RefProduct,
+			RefID:       input.ProductID,
+			ActorID:     currentUser.ID,
+			Note:        input.Note,
+			WarehouseID: input.WarehouseID,
+		}
+		if err := u.repo.InsertMovement(ctx, tx, movement); err != nil {
+			return err
+		}
+		if err := inventory.RecordStockEvents(ctx, tx, u.repo, movement, productData.LowStockThreshold); err != nil {
+			return err
+		}
+
+		levels, err = u.repo.StockLevels(ctx, tx, []int64{input.ProductID})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return levels, nil
+}
+
+func isAdmin(ctx context.Context) bool {
+	currentUser, err := auth.GetCurrentUser(ctx)
+	if err != nil {
+		return false
+	}
+	return currentUser.Role == string(user.RoleAdmin)
+}
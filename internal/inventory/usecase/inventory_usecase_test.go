@@ -0,0 +1,568 @@
+package inventoryusecase_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/codepnw/mini-ecommerce/internal/inventory"
+	inventoryrepository "github.com/codepnw/mini-ecommerce/internal/inventory/repository"
+	inventoryusecase "github.com/codepnw/mini-ecommerce/internal/inventory/usecase"
+	"github.com/codepnw/mini-ecommerce/internal/product"
+	productrepository "github.com/codepnw/mini-ecommerce/internal/product/repository"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/pkg/auth"
+	"github.com/codepnw/mini-ecommerce/pkg/jwt"
+	"github.com/codepnw/mini-ecommerce/pkg/notify"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReleaseExpiredReservations(t *testing.T) {
+	type testCase struct {
+		name        string
+		mockFn      func(mockRepo *inventoryrepository.MockInventoryRepository)
+		expected    int64
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name: "success",
+			mockFn: func(mockRepo *inventoryrepository.MockInventoryRepository) {
+				mockRepo.EXPECT().DeleteExpiredReservations(gomock.Any()).Return(int64(3), nil).Times(1)
+			},
+			expected: 3,
+		},
+		{
+			name: "fail db error",
+			mockFn: func(mockRepo *inventoryrepository.MockInventoryRepository) {
+				mockRepo.EXPECT().DeleteExpiredReservations(gomock.Any()).Return(int64(0), errDBMock).Times(1)
+			},
+			expectedErr: errDBMock,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, mockRepo := setup(t)
+
+			tc.mockFn(mockRepo)
+
+			released, err := uc.ReleaseExpiredReservations(context.Background())
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, released)
+			}
+		})
+	}
+}
+
+func TestProcessStockEvents(t *testing.T) {
+	lowStock := &inventory.StockEvent{ID: 1, ProductID: 10, Kind: inventory.EventLowStock, Stock: 2}
+	backInStock := &inventory.StockEvent{ID: 2, ProductID: 10, Kind: inventory.EventBackInStock, Stock: 5}
+	alertProduct := func(stock int) *inventory.AlertProduct {
+		return &inventory.AlertProduct{
+			ID:         10,
+			Name:       "iPhone",
+			SKU:        "IP15",
+			Slug:       "iphone",
+			Stock:      stock,
+			Threshold:  3,
+			OwnerEmail: "seller@mail.com",
+		}
+	}
+	subs := []*inventory.Subscription{
+		{ID: 100, ProductID: 10, UserID: 2, Email: "a@mail.com"},
+		{ID: 101, ProductID: 10, UserID: 3, Email: "b@mail.com"},
+	}
+
+	type testCase struct {
+		name        string
+		sendErr     error
+		mockFn      func(mockRepo *inventoryrepository.MockInventoryRepository)
+		expected    int
+		expectedTo  []string
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name: "success low stock notifies owner",
+			mockFn: func(mockRepo *inventoryrepository.MockInventoryRepository) {
+				mockRepo.EXPECT().PendingEvents(gomock.Any(), gomock.Any(), gomock.Any()).Return([]*inventory.StockEvent{lowStock}, nil).Times(1)
+				mockRepo.EXPECT().FindAlertProduct(gomock.Any(), int64(10)).Return(alertProduct(2), nil).Times(1)
+				mockRepo.EXPECT().MarkEventProcessed(gomock.Any(), int64(1)).Return(nil).Times(1)
+			},
+			expected:   1,
+			expectedTo: []string{"seller@mail.com"},
+		},
+		{
+			name: "success back in stock notifies subscribers",
+			mockFn: func(mockRepo *inventoryrepository.MockInventoryRepository) {
+				mockRepo.EXPECT().PendingEvents(gomock.Any(), gomock.Any(), gomock.Any()).Return([]*inventory.StockEvent{backInStock}, nil).Times(1)
+				mockRepo.EXPECT().FindAlertProduct(gomock.Any(), int64(10)).Return(alertProduct(5), nil).Times(1)
+				mockRepo.EXPECT().PendingSubscriptions(gomock.Any(), int64(10)).Return(subs, nil).Times(1)
+				mockRepo.EXPECT().MarkSubscriptionNotified(gomock.Any(), int64(100)).Return(nil).Times(1)
+				mockRepo.EXPECT().MarkSubscriptionNotified(gomock.Any(), int64(101)).Return(nil).Times(1)
+				mockRepo.EXPECT().MarkEventProcessed(gomock.Any(), int64(2)).Return(nil).Times(1)
+			},
+			expected:   1,
+			expectedTo: []string{"a@mail.com", "b@mail.com"},
+		},
+		{
+			name: "success sold out again keeps subscriptions",
+			mockFn: func(mockRepo *inventoryrepository.MockInventoryRepository) {
+				mockRepo.EXPECT().PendingEvents(gomock.Any(), gomock.Any(), gomock.Any()).Return([]*inventory.StockEvent{backInStock}, nil).Times(1)
+				mockRepo.EXPECT().FindAlertProduct(gomock.Any(), int64(10)).Return(alertProduct(0), nil).Times(1)
+				mockRepo.EXPECT().MarkEventProcessed(gomock.Any(), int64(2)).Return(nil).Times(1)
+			},
+			expected: 1,
+		},
+		{
+			name: "success product without owner",
+			mockFn: func(mockRepo *inventoryrepository.MockInventoryRepository) {
+				ownerless := alertProduct(2)
+				ownerless.OwnerEmail = ""
+				mockRepo.EXPECT().PendingEvents(gomock.Any(), gomock.Any(), gomock.Any()).Return([]*inventory.StockEvent{lowStock}, nil).Times(1)
+				mockRepo.EXPECT().FindAlertProduct(gomock.Any(), int64(10)).Return(ownerless, nil).Times(1)
+				mockRepo.EXPECT().MarkEventProcessed(gomock.Any(), int64(1)).Return(nil).Times(1)
+			},
+			expected: 1,
+		},
+		{
+			name: "failed event does not stop the others",
+			mockFn: func(mockRepo *inventoryrepository.MockInventoryRepository) {
+				missing := &inventory.StockEvent{ID: 3, ProductID: 11, Kind: inventory.EventLowStock, Stock: 1}
+				mockRepo.EXPECT().PendingEvents(gomock.Any(), gomock.Any(), gomock.Any()).Return([]*inventory.StockEvent{missing, lowStock}, nil).Times(1)
+				mockRepo.EXPECT().FindAlertProduct(gomock.Any(), int64(11)).Return(nil, errs.ErrProductNotFound).Times(1)
+				mockRepo.EXPECT().MarkEventFailed(gomock.Any(), int64(3), errs.ErrProductNotFound.Error()).Return(nil).Times(1)
+
+				mockRepo.EXPECT().FindAlertProduct(gomock.Any(), int64(10)).Return(alertProduct(2), nil).Times(1)
+				mockRepo.EXPECT().MarkEventProcessed(gomock.Any(), int64(1)).Return(nil).Times(1)
+			},
+			expected:    1,
+			expectedTo:  []string{"seller@mail.com"},
+			expectedErr: errs.ErrProductNotFound,
+		},
+		{
+			name:    "fail send error leaves event pending",
+			sendErr: errors.New("smtp down"),
+			mockFn: func(mockRepo *inventoryrepository.MockInventoryRepository) {
+				mockRepo.EXPECT().PendingEvents(gomock.Any(), gomock.Any(), gomock.Any()).Return([]*inventory.StockEvent{lowStock}, nil).Times(1)
+				mockRepo.EXPECT().FindAlertProduct(gomock.Any(), int64(10)).Return(alertProduct(2), nil).Times(1)
+				mockRepo.EXPECT().MarkEventFailed(gomock.Any(), int64(1), "smtp down").Return(nil).Times(1)
+				mockRepo.EXPECT().MarkEventProcessed(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedErr: errors.New("smtp down"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			notifier := &mockNotifier{err: tc.sendErr}
+			uc, mockRepo := setupWithNotifier(t, notifier)
+
+			tc.mockFn(mockRepo)
+
+			processed, err := uc.ProcessStockEvents(context.Background())
+
+			if tc.expectedErr != nil {
+				assert.ErrorContains(t, err, tc.expectedErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expected, processed)
+
+			var to []string
+			for _, m := range notifier.sent {
+				to = append(to, m.To)
+			}
+			assert.Equal(t, tc.expectedTo, to)
+		})
+	}
+}
+
+func TestListMovements(t *testing.T) {
+	type testCase struct {
+		name          string
+		ctx           context.Context
+		filter        *inventory.MovementFilter
+		mockFn        func(mockRepo *inventoryrepository.MockInventoryRepository)
+		expectedLimit int
+		expectedErr   error
+	}
+
+	testCases := []testCase{
+		{
+			name:   "success default paging",
+			ctx:    mockClaims("admin"),
+			filter: &inventory.MovementFilter{ProductID: 1},
+			mockFn: func(mockRepo *inventoryrepository.MockInventoryRepository) {
+				mockRepo.EXPECT().ListMovements(gomock.Any(), gomock.Any()).Return([]*inventory.StockMovement{{ID: 1, ProductID: 1, Delta: -2}}, nil).Times(1)
+			},
+			expectedLimit: 20,
+		},
+		{
+			name:   "success limit capped",
+			ctx:    mockClaims("admin"),
+			filter: &inventory.MovementFilter{Limit: 1000},
+			mockFn: func(mockRepo *inventoryrepository.MockInventoryRepository) {
+				mockRepo.EXPECT().ListMovements(gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
+			},
+			expectedLimit: 20,
+		},
+		{
+			name:        "fail not admin",
+			ctx:         mockClaims("user"),
+			filter:      &inventory.MovementFilter{},
+			mockFn:      func(mockRepo *inventoryrepository.MockInventoryRepository) {},
+			expectedErr: errs.ErrNoPermissions,
+		},
+		{
+			name:   "fail db error",
+			ctx:    mockClaims("admin"),
+			filter: &inventory.MovementFilter{},
+			mockFn: func(mockRepo *inventoryrepository.MockInventoryRepository) {
+				mockRepo.EXPECT().ListMovements(gomock.Any(), gomock.Any()).Return(nil, errDBMock).Times(1)
+			},
+			expectedErr: errDBMock,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, mockRepo := setup(t)
+
+			tc.mockFn(mockRepo)
+
+			_, err := uc.ListMovements(tc.ctx, tc.filter)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, 1, tc.filter.Page)
+				assert.Equal(t, tc.expectedLimit, tc.filter.Limit)
+			}
+		})
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	type testCase struct {
+		name        string
+		ctx         context.Context
+		mockFn      func(mockRepo *inventoryrepository.MockInventoryRepository)
+		expectedLen int
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name: "success",
+			ctx:  mockClaims("admin"),
+			mockFn: func(mockRepo *inventoryrepository.MockInventoryRepository) {
+				drift := &inventory.StockDrift{ProductID: 1, Stock: 10, LedgerStock: 8, Drift: 2}
+				mockRepo.EXPECT().Reconcile(gomock.Any(), true).Return([]*inventory.StockDrift{drift}, nil).Times(1)
+			},
+			expectedLen: 1,
+		},
+		{
+			name:        "fail not admin",
+			ctx:         mockClaims("seller"),
+			mockFn:      func(mockRepo *inventoryrepository.MockInventoryRepository) {},
+			expectedErr: errs.ErrNoPermissions,
+		},
+		{
+			name:        "fail no user",
+			ctx:         context.Background(),
+			mockFn:      func(mockRepo *inventoryrepository.MockInventoryRepository) {},
+			expectedErr: errs.ErrNoPermissions,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, mockRepo := setup(t)
+
+			tc.mockFn(mockRepo)
+
+			result, err := uc.Reconcile(tc.ctx, true)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, result, tc.expectedLen)
+			}
+		})
+	}
+}
+
+func TestUpdateWarehouse(t *testing.T) {
+	type testCase struct {
+		name        string
+		input       *inventory.WarehouseUpdate
+		mockFn      func(mockRepo *inventoryrepository.MockInventoryRepository, input *inventory.WarehouseUpdate)
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name:  "success",
+			input: &inventory.WarehouseUpdate{ID: 2, Priority: ptr(5), IsActive: ptr(false)},
+			mockFn: func(mockRepo *inventoryrepository.MockInventoryRepository, input *inventory.WarehouseUpdate) {
+				current := &inventory.Warehouse{ID: 2, Code: "CNX", IsActive: true}
+				mockRepo.EXPECT().FindWarehouseByID(gomock.Any(), gomock.Any(), input.ID).Return(current, nil).Times(1)
+
+				updated := &inventory.Warehouse{ID: 2, Code: "CNX", Priority: 5}
+				mockRepo.EXPECT().UpdateWarehouse(gomock.Any(), input).Return(updated, nil).Times(1)
+			},
+		},
+		{
+			name:        "fail no fields",
+			input:       &inventory.WarehouseUpdate{ID: 2},
+			mockFn:      func(mockRepo *inventoryrepository.MockInventoryRepository, input *inventory.WarehouseUpdate) {},
+			expectedErr: errs.ErrNoFieldsToUpdate,
+		},
+		{
+			name:        "fail latitude without longitude",
+			input:       &inventory.WarehouseUpdate{ID: 2, Latitude: ptr(13.75)},
+			mockFn:      func(mockRepo *inventoryrepository.MockInventoryRepository, input *inventory.WarehouseUpdate) {},
+			expectedErr: errs.ErrWarehouseLocationInvalid,
+		},
+		{
+			name:  "fail deactivate default",
+			input: &inventory.WarehouseUpdate{ID: 1, IsActive: ptr(false)},
+			mockFn: func(mockRepo *inventoryrepository.MockInventoryRepository, input *inventory.WarehouseUpdate) {
+				current := &inventory.Warehouse{ID: 1, Code: "MAIN", IsDefault: true, IsActive: true}
+				mockRepo.EXPECT().FindWarehouseByID(gomock.Any(), gomock.Any(), input.ID).Return(current, nil).Times(1)
+			},
+			expectedErr: errs.ErrWarehouseDefault,
+		},
+		{
+			name:  "fail not found",
+			input: &inventory.WarehouseUpdate{ID: 9, Priority: ptr(1)},
+			mockFn: func(mockRepo *inventoryrepository.MockInventoryRepository, input *inventory.WarehouseUpdate) {
+				mockRepo.EXPECT().FindWarehouseByID(gomock.Any(), gomock.Any(), input.ID).Return(nil, errs.ErrWarehouseNotFound).Times(1)
+			},
+			expectedErr: errs.ErrWarehouseNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, mockRepo := setup(t)
+
+			tc.mockFn(mockRepo, tc.input)
+
+			result, err := uc.UpdateWarehouse(mockClaims("admin"), tc.input)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestSetStock(t *testing.T) {
+	type testCase struct {
+		name        string
+		ctx         context.Context
+		input       *inventory.StockSet
+		mockFn      func(mockRepo *inventoryrepository.MockInventoryRepository, mockProdRepo *productrepository.MockProductRepository, input *inventory.StockSet)
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name:  "success increase",
+			ctx:   mockClaims("admin"),
+			input: &inventory.StockSet{WarehouseID: 2, ProductID: 1, Quantity: 8},
+			mockFn: func(mockRepo *inventoryrepository.MockInventoryRepository, mockProdRepo *productrepository.MockProductRepository, input *inventory.StockSet) {
+				mockProdRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), input.ProductID).Return(&product.Product{ID: 1, Stock: 10}, nil).Times(1)
+				mockRepo.EXPECT().FindWarehouseByID(gomock.Any(), gomock.Any(), input.WarehouseID).Return(&inventory.Warehouse{ID: 2}, nil).Times(1)
+
+				current := []*inventory.StockLevel{
+					{WarehouseID: 1, ProductID: 1, Quantity: 7},
+					{WarehouseID: 2, ProductID: 1, Quantity: 3},
+				}
+				mockRepo.EXPECT().StockLevels(gomock.Any(), gomock.Any(), []int64{1}).Return(current, nil).Times(1)
+
+				mockRepo.EXPECT().AdjustWarehouseStock(gomock.Any(), gomock.Any(), int64(2), int64(1), 5).Return(nil).Times(1)
+				mockProdRepo.EXPECT().IncreaseStock(gomock.Any(), gomock.Any(), int64(1), 5).Return(nil).Times(1)
+				mockRepo.EXPECT().InsertMovement(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ *sql.Tx, m *inventory.StockMovement) error {
+						assert.Equal(t, inventory.ReasonAdjust, m.Reason)
+						assert.Equal(t, 5, m.Delta)
+						assert.Equal(t, int64(2), m.WarehouseID)
+						return nil
+					},
+				).Times(1)
+				mockRepo.EXPECT().StockLevels(gomock.Any(), gomock.Any(), []int64{1}).Return(current, nil).Times(1)
+			},
+		},
+		{
+			name:  "success decrease new warehouse row",
+			ctx:   mockClaims("admin"),
+			input: &inventory.StockSet{WarehouseID: 1, ProductID: 1, Quantity: 0},
+			mockFn: func(mockRepo *inventoryrepository.MockInventoryRepository, mockProdRepo *productrepository.MockProductRepository, input *inventory.StockSet) {
+				mockProdRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), input.ProductID).Return(&product.Product{ID: 1, Stock: 4}, nil).Times(1)
+				mockRepo.EXPECT().FindWarehouseByID(gomock.Any(), gomock.Any(), input.WarehouseID).Return(&inventory.Warehouse{ID: 1}, nil).Times(1)
+
+				current := []*inventory.StockLevel{{WarehouseID: 1, ProductID: 1, Quantity: 4}}
+				mockRepo.EXPECT().StockLevels(gomock.Any(), gomock.Any(), []int64{1}).Return(current, nil).Times(2)
+
+				mockRepo.EXPECT().AdjustWarehouseStock(gomock.Any(), gomock.Any(), int64(1), int64(1), -4).Return(nil).Times(1)
+				mockProdRepo.EXPECT().DecreaseStock(gomock.Any(), gomock.Any(), int64(1), 4).Return(nil).Times(1)
+				mockRepo.EXPECT().InsertMovement(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+		},
+		{
+			name:  "success unchanged",
+			ctx:   mockClaims("admin"),
+			input: &inventory.StockSet{WarehouseID: 1, ProductID: 1, Quantity: 4},
+			mockFn: func(mockRepo *inventoryrepository.MockInventoryRepository, mockProdRepo *productrepository.MockProductRepository, input *inventory.StockSet) {
+				mockProdRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), input.ProductID).Return(&product.Product{ID: 1, Stock: 4}, nil).Times(1)
+				mockRepo.EXPECT().FindWarehouseByID(gomock.Any(), gomock.Any(), input.WarehouseID).Return(&inventory.Warehouse{ID: 1}, nil).Times(1)
+
+				current := []*inventory.StockLevel{{WarehouseID: 1, ProductID: 1, Quantity: 4}}
+				mockRepo.EXPECT().StockLevels(gomock.Any(), gomock.Any(), []int64{1}).Return(current, nil).Times(1)
+			},
+		},
+		{
+			name:  "fail not admin",
+			ctx:   mockClaims("seller"),
+			input: &inventory.StockSet{WarehouseID: 1, ProductID: 1, Quantity: 4},
+			mockFn: func(mockRepo *inventoryrepository.MockInventoryRepository, mockProdRepo *productrepository.MockProductRepository, input *inventory.StockSet) {
+			},
+			expectedErr: errs.ErrNoPermissions,
+		},
+		{
+			name:  "fail negative quantity",
+			ctx:   mockClaims("admin"),
+			input: &inventory.StockSet{WarehouseID: 1, ProductID: 1, Quantity: -1},
+			mockFn: func(mockRepo *inventoryrepository.MockInventoryRepository, mockProdRepo *productrepository.MockProductRepository, input *inventory.StockSet) {
+			},
+			expectedErr: errs.ErrProductStockInvalid,
+		},
+		{
+			name:  "fail warehouse not found",
+			ctx:   mockClaims("admin"),
+			input: &inventory.StockSet{WarehouseID: 9, ProductID: 1, Quantity: 4},
+			mockFn: func(mockRepo *inventoryrepository.MockInventoryRepository, mockProdRepo *productrepository.MockProductRepository, input *inventory.StockSet) {
+				mockProdRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), input.ProductID).Return(&product.Product{ID: 1}, nil).Times(1)
+				mockRepo.EXPECT().FindWarehouseByID(gomock.Any(), gomock.Any(), input.WarehouseID).Return(nil, errs.ErrWarehouseNotFound).Times(1)
+			},
+			expectedErr: errs.ErrWarehouseNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, mockRepo, mockProdRepo := setupWithProduct(t)
+
+			tc.mockFn(mockRepo, mockProdRepo, tc.input)
+
+			levels, err := uc.SetStock(tc.ctx, tc.input)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, levels)
+			} else {
+				assert.NoError(t, err)
+				assert.NotEmpty(t, levels)
+			}
+		})
+	}
+}
+
+func setup(t *testing.T) (inventoryusecase.InventoryUsecase, *inventoryrepository.MockInventoryRepository) {
+	t.Helper()
+
+	uc, mockRepo, _ := setupWithProduct(t)
+	return uc, mockRepo
+}
+
+func setupWithProduct(t *testing.T) (inventoryusecase.InventoryUsecase, *inventoryrepository.MockInventoryRepository, *productrepository.MockProductRepository) {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := inventoryrepository.NewMockInventoryRepository(ctrl)
+	mockProdRepo := productrepository.NewMockProductRepository(ctrl)
+	uc := inventoryusecase.NewInventoryUsecase(mockRepo, mockProdRepo, &mockNotifier{}, &mockTxManager{}, &mockDB{})
+
+	return uc, mockRepo, mockProdRepo
+}
+
+func setupWithNotifier(t *testing.T, notifier notify.Notifier) (inventoryusecase.InventoryUsecase, *inventoryrepository.MockInventoryRepository) {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := inventoryrepository.NewMockInventoryRepository(ctrl)
+	mockProdRepo := productrepository.NewMockProductRepository(ctrl)
+	uc := inventoryusecase.NewInventoryUsecase(mockRepo, mockProdRepo, notifier, &mockTxManager{}, &mockDB{})
+
+	return uc, mockRepo
+}
+
+func mockClaims(role string) context.Context {
+	mockUser := &jwt.UserClaims{
+		ID:    1,
+		Email: "user@mail.com",
+		Role:  role,
+	}
+	return auth.SetCurrentUser(context.Background(), mockUser)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+type mockNotifier struct {
+	sent []*notify.Message
+	err  error
+}
+
+func (m *mockNotifier) Send(ctx context.Context, msg *notify.Message) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+type mockTxManager struct{}
+
+func (m *mockTxManager) WithTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return fn(nil)
+}
+
+type mockDB struct{}
+
+func (m *mockDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return nil
+}
+
+func (m *mockDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return nil, nil
+}
+
+func (m *mockDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return nil, nil
+}
+
+var errDBMock = errors.New("db error")
@@ -58,6 +58,33 @@ func (a *AuthMiddleware) AuthorizedMiddleware() gin.HandlerFunc {
 	}
 }
 
+// OptionalAuthMiddleware sets user claims when a valid token is sent,
+// public routes use it to show extra data to owners and admins.
+func (a *AuthMiddleware) OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.Request.Header.Get("Authorization")
+		parts := strings.Split(authHeader, " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			c.Next()
+			return
+		}
+
+		claims, err := a.token.VerifyAccessToken(parts[1])
+		if err != nil {
+			// Skip invalid token, continue as guest
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		ctx = context.WithValue(ctx, consts.UserClaimsKey, claims)
+		ctx = context.WithValue(ctx, consts.UserIDKey, claims.ID)
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
 func (a *AuthMiddleware) SessionMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var userID int64 = 0
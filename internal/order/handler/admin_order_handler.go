@@ -0,0 +1,62 @@
+package orderhandler
+
+import (
+	"github.com/codepnw/mini-ecommerce/internal/order"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *orderHandler) ListOrders(c *gin.Context) {
+	filter := new(order.AdminOrderFilter)
+	if err := c.ShouldBindQuery(filter); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.ListOrders(c.Request.Context(), filter)
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrNoPermissions:
+			response.Forbidden(c, err.Error())
+			return
+		case errs.ErrInvalidDateRange, errs.ErrInvalidTotalRange:
+			response.BadRequest(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "", result)
+}
+
+func (h *orderHandler) BulkUpdateOrderStatus(c *gin.Context) {
+	req := new(BulkStatusReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.BulkUpdateOrderStatus(c.Request.Context(), req.OrderIDs, order.OrderStatus(req.Status))
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrNoPermissions:
+			response.Forbidden(c, err.Error())
+			return
+		case errs.ErrTooManyOrders:
+			response.BadRequest(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "", result)
+}
@@ -0,0 +1,137 @@
+package orderhandler
+
+import (
+	"errors"
+
+	"github.com/codepnw/mini-ecommerce/internal/cart"
+	"github.com/codepnw/mini-ecommerce/internal/inventory"
+	"github.com/codepnw/mini-ecommerce/internal/order"
+	"github.com/codepnw/mini-ecommerce/internal/product"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *orderHandler) GuestCheckout(c *gin.Context) {
+	req := new(GuestCheckoutReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	input := &order.GuestCheckoutInput{
+		Email:   req.Email,
+		QuoteID: req.QuoteID,
+		Shipping: &order.ShippingAddress{
+			Name:       req.Shipping.Name,
+			Phone:      req.Shipping.Phone,
+			Address:    req.Shipping.Address,
+			City:       req.Shipping.City,
+			PostalCode: req.Shipping.PostalCode,
+			Country:    req.Shipping.Country,
+		},
+	}
+	if req.Latitude != nil && req.Longitude != nil {
+		input.Destination = &inventory.GeoPoint{Latitude: *req.Latitude, Longitude: *req.Longitude}
+	}
+
+	result, err := h.uc.GuestCheckout(c.Request.Context(), input)
+	if err != nil {
+		var ruleErr *product.RuleError
+		if errors.As(err, &ruleErr) {
+			response.UnprocessableEntity(c, err.Error(), ruleErr)
+			return
+		}
+
+		var changesErr *cart.ChangesError
+		if errors.As(err, &changesErr) {
+			response.Conflict(c, err.Error(), changesErr.Items)
+			return
+		}
+
+		switch err {
+		case errs.ErrGuestCheckoutSignedIn, errs.ErrQuoteChanged:
+			response.Conflict(c, err.Error(), nil)
+			return
+		case errs.ErrQuoteInvalid:
+			response.BadRequest(c, err.Error())
+			return
+		case errs.ErrCartIsEmpty:
+			response.BadRequest(c, err.Error())
+			return
+		case errs.ErrProductNotEnough, errs.ErrProductUnavailable:
+			response.BadRequest(c, err.Error())
+			return
+		case errs.ErrShippingUnavailable, errs.ErrShippingTooHeavy:
+			response.BadRequest(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.Created(c, result)
+}
+
+func (h *orderHandler) LookupGuestOrder(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		response.BadRequest(c, errs.ErrOrderLookupInvalid.Error())
+		return
+	}
+
+	result, err := h.uc.LookupGuestOrder(c.Request.Context(), token)
+	if err != nil {
+		switch err {
+		case errs.ErrOrderLookupInvalid:
+			response.NotFound(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "", result)
+}
+
+func (h *orderHandler) RequestGuestOrderClaim(c *gin.Context) {
+	count, err := h.uc.RequestGuestOrderClaim(c.Request.Context())
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrNoGuestOrders:
+			response.NotFound(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "claim link sent to your email", gin.H{"orders": count})
+}
+
+func (h *orderHandler) ConfirmGuestOrderClaim(c *gin.Context) {
+	req := new(ClaimOrdersReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	claimed, err := h.uc.ConfirmGuestOrderClaim(c.Request.Context(), req.Token)
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrOrderClaimInvalid:
+			response.BadRequest(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "guest orders added to your account", gin.H{"orders": claimed})
+}
@@ -1,5 +1,79 @@
 package orderhandler
 
+import (
+	"strings"
+
+	"github.com/codepnw/mini-ecommerce/internal/order"
+)
+
+// UpdateStatusReq the carrier & tracking number are kept when shipping
 type UpdateStatusReq struct {
 	Status string `json:"status" binding:"required,oneof=paid shipped cancelled completed"`
+	ShipmentReq
+}
+
+// ShipmentReq optional, a tracking number is polled until delivered
+type ShipmentReq struct {
+	Carrier        string `json:"carrier" binding:"required_with=TrackingNumber,max=50"`
+	TrackingNumber string `json:"tracking_number" binding:"max=100"`
+}
+
+func (r *ShipmentReq) shipment() *order.ShipmentInput {
+	return &order.ShipmentInput{
+		Carrier:        strings.TrimSpace(r.Carrier),
+		TrackingNumber: strings.TrimSpace(r.TrackingNumber),
+	}
+}
+
+// TrackingReq carrier & tracking number of a parcel shipped without them
+type TrackingReq struct {
+	Carrier        string `json:"carrier" binding:"required,max=50"`
+	TrackingNumber string `json:"tracking_number" binding:"required,max=100"`
+}
+
+func (r *TrackingReq) shipment() *order.ShipmentInput {
+	return &order.ShipmentInput{
+		Carrier:        strings.TrimSpace(r.Carrier),
+		TrackingNumber: strings.TrimSpace(r.TrackingNumber),
+	}
+}
+
+type BulkStatusReq struct {
+	OrderIDs []int64 `json:"order_ids" binding:"required,min=1,dive,gt=0"`
+	Status   string  `json:"status" binding:"required,oneof=paid shipped cancelled completed"`
+}
+
+// CreateOrderReq optional body, the destination picks the nearest warehouse.
+// QuoteID from POST /checkout/quote fails the order if the cart drifted
+type CreateOrderReq struct {
+	Latitude  *float64 `json:"latitude" binding:"required_with=Longitude,omitempty,gte=-90,lte=90"`
+	Longitude *float64 `json:"longitude" binding:"required_with=Latitude,omitempty,gte=-180,lte=180"`
+	QuoteID   string   `json:"quote_id"`
+}
+
+// QuoteReq optional body, same destination as the order
+type QuoteReq struct {
+	Latitude  *float64 `json:"latitude" binding:"required_with=Longitude,omitempty,gte=-90,lte=90"`
+	Longitude *float64 `json:"longitude" binding:"required_with=Latitude,omitempty,gte=-180,lte=180"`
+}
+
+type GuestCheckoutReq struct {
+	Email     string      `json:"email" binding:"required,email"`
+	Shipping  ShippingReq `json:"shipping" binding:"required"`
+	Latitude  *float64    `json:"latitude" binding:"required_with=Longitude,omitempty,gte=-90,lte=90"`
+	Longitude *float64    `json:"longitude" binding:"required_with=Latitude,omitempty,gte=-180,lte=180"`
+	QuoteID   string      `json:"quote_id"`
+}
+
+type ShippingReq struct {
+	Name       string `json:"name" binding:"required,max=100"`
+	Phone      string `json:"phone" binding:"required,max=30"`
+	Address    string `json:"address" binding:"required,max=255"`
+	City       string `json:"city" binding:"required,max=100"`
+	PostalCode string `json:"postal_code" binding:"required,max=20"`
+	Country    string `json:"country" binding:"required,len=2"`
+}
+
+type ClaimOrdersReq struct {
+	Token string `json:"token" binding:"required"`
 }
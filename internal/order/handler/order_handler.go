@@ -1,8 +1,14 @@
 package orderhandler
 
 import (
+	"errors"
+	"io"
+
+	"github.com/codepnw/mini-ecommerce/internal/cart"
+	"github.com/codepnw/mini-ecommerce/internal/inventory"
 	"github.com/codepnw/mini-ecommerce/internal/order"
 	orderusecase "github.com/codepnw/mini-ecommerce/internal/order/usecase"
+	"github.com/codepnw/mini-ecommerce/internal/product"
 	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
 	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
 	"github.com/codepnw/mini-ecommerce/internal/utils/helper"
@@ -20,16 +26,89 @@ func NewOrderHandler(uc orderusecase.OrderUsecase) *orderHandler {
 }
 
 func (h *orderHandler) CreateOrder(c *gin.Context) {
-	result, err := h.uc.CreateOrder(c.Request.Context())
+	req := new(CreateOrderReq)
+	if err := c.ShouldBindJSON(req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	input := &order.CreateOrderInput{QuoteID: req.QuoteID}
+	if req.Latitude != nil && req.Longitude != nil {
+		input.Destination = &inventory.GeoPoint{Latitude: *req.Latitude, Longitude: *req.Longitude}
+	}
+
+	result, err := h.uc.CreateOrder(c.Request.Context(), input)
 	if err != nil {
+		var ruleErr *product.RuleError
+		if errors.As(err, &ruleErr) {
+			response.UnprocessableEntity(c, err.Error(), ruleErr)
+			return
+		}
+
+		var changesErr *cart.ChangesError
+		if errors.As(err, &changesErr) {
+			response.Conflict(c, err.Error(), changesErr.Items)
+			return
+		}
+
 		switch err {
 		case errs.ErrUnauthorized:
 			response.Unauthorized(c, err.Error())
 			return
+		case errs.ErrCartIsEmpty, errs.ErrQuoteInvalid:
+			response.BadRequest(c, err.Error())
+			return
+		case errs.ErrProductNotEnough, errs.ErrProductUnavailable:
+			response.BadRequest(c, err.Error())
+			return
+		case errs.ErrShippingUnavailable, errs.ErrShippingTooHeavy:
+			response.BadRequest(c, err.Error())
+			return
+		case errs.ErrQuoteChanged:
+			response.Conflict(c, err.Error(), nil)
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "", result)
+}
+
+func (h *orderHandler) CheckoutQuote(c *gin.Context) {
+	req := new(QuoteReq)
+	if err := c.ShouldBindJSON(req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	input := new(order.QuoteInput)
+	if req.Latitude != nil && req.Longitude != nil {
+		input.Destination = &inventory.GeoPoint{Latitude: *req.Latitude, Longitude: *req.Longitude}
+	}
+
+	result, err := h.uc.Quote(c.Request.Context(), input)
+	if err != nil {
+		var ruleErr *product.RuleError
+		if errors.As(err, &ruleErr) {
+			response.UnprocessableEntity(c, err.Error(), ruleErr)
+			return
+		}
+
+		var changesErr *cart.ChangesError
+		if errors.As(err, &changesErr) {
+			response.Conflict(c, err.Error(), changesErr.Items)
+			return
+		}
+
+		switch err {
 		case errs.ErrCartIsEmpty:
 			response.BadRequest(c, err.Error())
 			return
-		case errs.ErrProductNotEnough:
+		case errs.ErrProductNotEnough, errs.ErrProductUnavailable:
+			response.BadRequest(c, err.Error())
+			return
+		case errs.ErrShippingUnavailable, errs.ErrShippingTooHeavy:
 			response.BadRequest(c, err.Error())
 			return
 		default:
@@ -132,7 +211,7 @@ func (h *orderHandler) UpdateOrderStatus(c *gin.Context) {
 		return
 	}
 
-	err = h.uc.UpdateOrderStatus(c.Request.Context(), orderID, order.OrderStatus(req.Status))
+	err = h.uc.UpdateOrderStatus(c.Request.Context(), orderID, order.OrderStatus(req.Status), req.shipment())
 	if err != nil {
 		switch err {
 		case errs.ErrUnauthorized:
@@ -141,9 +220,12 @@ func (h *orderHandler) UpdateOrderStatus(c *gin.Context) {
 		case errs.ErrNoPermissions:
 			response.Forbidden(c, err.Error())
 			return
-		case errs.ErrInvalidStatusChange:
+		case errs.ErrInvalidStatusChange, errs.ErrCancelPaidOrder:
 			response.BadRequest(c, err.Error())
 			return
+		case errs.ErrOrderNotFound:
+			response.NotFound(c, err.Error())
+			return
 		default:
 			response.InternalServerError(c, err)
 			return
@@ -152,3 +234,92 @@ func (h *orderHandler) UpdateOrderStatus(c *gin.Context) {
 
 	response.OK(c, "order status updated", nil)
 }
+
+func (h *orderHandler) UpdateSubOrderStatus(c *gin.Context) {
+	// Param ID
+	orderID, err := helper.GetParamInt(c, consts.ParamOrderID)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	subOrderID, err := helper.GetParamInt(c, consts.ParamSubOrderID)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	// Validate Request
+	req := new(UpdateStatusReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	err = h.uc.UpdateSubOrderStatus(c.Request.Context(), orderID, subOrderID, order.OrderStatus(req.Status), req.shipment())
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrNoPermissions:
+			response.Forbidden(c, err.Error())
+			return
+		case errs.ErrInvalidStatusChange, errs.ErrCancelPaidOrder:
+			response.BadRequest(c, err.Error())
+			return
+		case errs.ErrOrderNotFound, errs.ErrSubOrderNotFound:
+			response.NotFound(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+
+	response.OK(c, "sub-order status updated", nil)
+}
+
+func (h *orderHandler) SetShipmentTracking(c *gin.Context) {
+	// Param ID
+	orderID, err := helper.GetParamInt(c, consts.ParamOrderID)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	subOrderID, err := helper.GetParamInt(c, consts.ParamSubOrderID)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	// Validate Request
+	req := new(TrackingReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.SetShipmentTracking(c.Request.Context(), orderID, subOrderID, req.shipment())
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrNoPermissions:
+			response.Forbidden(c, err.Error())
+			return
+		case errs.ErrOrderNotFound, errs.ErrSubOrderNotFound, errs.ErrShipmentNotFound:
+			response.NotFound(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+
+	response.OK(c, "shipment tracking updated", result)
+}
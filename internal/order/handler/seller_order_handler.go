@@ -0,0 +1,122 @@
+package orderhandler
+
+import (
+	"errors"
+	"io"
+
+	"github.com/codepnw/mini-ecommerce/internal/order"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/internal/utils/helper"
+	"github.com/codepnw/mini-ecommerce/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *orderHandler) GetSellerOrders(c *gin.Context) {
+	filter := new(order.SellerOrderFilter)
+	if err := c.ShouldBindQuery(filter); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.GetSellerOrders(c.Request.Context(), filter)
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "", result)
+}
+
+func (h *orderHandler) GetSellerOrder(c *gin.Context) {
+	orderID, err := helper.GetParamInt(c, consts.ParamOrderID)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.GetSellerOrder(c.Request.Context(), orderID)
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrOrderNotFound:
+			response.NotFound(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "", result)
+}
+
+func (h *orderHandler) ShipSellerOrder(c *gin.Context) {
+	orderID, err := helper.GetParamInt(c, consts.ParamOrderID)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	// Optional Body
+	req := new(ShipmentReq)
+	if err := c.ShouldBindJSON(req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.ShipSellerOrder(c.Request.Context(), orderID, req.shipment())
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrOrderNotFound:
+			response.NotFound(c, err.Error())
+			return
+		case errs.ErrOrderNotPaid, errs.ErrInvalidStatusChange:
+			response.BadRequest(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "order shipped", result)
+}
+
+func (h *orderHandler) SetSellerShipmentTracking(c *gin.Context) {
+	orderID, err := helper.GetParamInt(c, consts.ParamOrderID)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	req := new(TrackingReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.SetSellerShipmentTracking(c.Request.Context(), orderID, req.shipment())
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrOrderNotFound, errs.ErrShipmentNotFound:
+			response.NotFound(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "shipment tracking updated", result)
+}
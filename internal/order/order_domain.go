@@ -1,6 +1,15 @@
 package order
 
-import "time"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/codepnw/mini-ecommerce/internal/inventory"
+	"github.com/codepnw/mini-ecommerce/internal/shipping"
+)
 
 type OrderStatus string
 
@@ -10,6 +19,22 @@ const (
 	StatusShipped   OrderStatus = "shipped"
 	StatusCancelled OrderStatus = "cancelled"
 	StatusCompleted OrderStatus = "completed"
+
+	// Order only, some sub-orders shipped while others are still paid
+	StatusPartiallyShipped OrderStatus = "partially_shipped"
+
+	// Every line refunded, a sub-order is refunded once all its lines are
+	StatusRefunded OrderStatus = "refunded"
+)
+
+// RefundStatus money paid back on the order, kept apart from the status so
+// a partially refunded order still ships & completes
+type RefundStatus string
+
+const (
+	RefundNone    RefundStatus = "none"
+	RefundPartial RefundStatus = "partial"
+	RefundFull    RefundStatus = "full"
 )
 
 type Order struct {
@@ -19,12 +44,242 @@ type Order struct {
 	Status    string    `json:"status"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	// Guest orders have no user until claimed
+	GuestEmail string           `json:"guest_email,omitempty"`
+	Shipping   *ShippingAddress `json:"shipping,omitempty"`
+
+	// ShippingAmount is included in Total, orders placed before shipping
+	// rates have no method
+	ShippingMethod string  `json:"shipping_method,omitempty"`
+	ShippingAmount float64 `json:"shipping_amount"`
+
+	RefundStatus string `json:"refund_status"`
+}
+
+type ShippingAddress struct {
+	Name       string `json:"name"`
+	Phone      string `json:"phone"`
+	Address    string `json:"address"`
+	City       string `json:"city"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+}
+
+// SubOrder the part of an order one seller fulfils, with its own status
+type SubOrder struct {
+	ID        int64      `json:"id"`
+	OrderID   int64      `json:"order_id"`
+	SellerID  int64      `json:"seller_id"`
+	Total     float64    `json:"total"`
+	Status    string     `json:"status"`
+	ShippedAt *time.Time `json:"shipped_at,omitempty"`
+	// CompletedAt starts the return window of its lines
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// DeriveStatus order status from its sub-orders, cancelled & refunded
+// sub-orders only count when no sub-order is left active
+func DeriveStatus(subs []*SubOrder) OrderStatus {
+	var active []OrderStatus
+	refunded := false
+	for _, s := range subs {
+		switch OrderStatus(s.Status) {
+		case StatusCancelled:
+		case StatusRefunded:
+			refunded = true
+		default:
+			active = append(active, OrderStatus(s.Status))
+		}
+	}
+	if len(active) == 0 {
+		if refunded {
+			return StatusRefunded
+		}
+		return StatusCancelled
+	}
+
+	has := make(map[OrderStatus]bool)
+	for _, s := range active {
+		has[s] = true
+	}
+	switch {
+	case len(has) == 1:
+		return active[0]
+	case has[StatusPending]:
+		return StatusPending
+	case has[StatusPaid]:
+		return StatusPartiallyShipped
+	}
+	// Shipped & completed
+	return StatusShipped
+}
+
+type ShipmentStatus string
+
+const (
+	ShipmentInTransit ShipmentStatus = "in_transit"
+	ShipmentDelivered ShipmentStatus = "delivered"
+)
+
+// Shipment the parcel of a shipped sub-order, tracked by the carrier until
+// delivered. No tracking number, no tracking.
+type Shipment struct {
+	ID             int64           `json:"id"`
+	OrderID        int64           `json:"order_id"`
+	SubOrderID     int64           `json:"sub_order_id"`
+	Carrier        string          `json:"carrier,omitempty"`
+	TrackingNumber string          `json:"tracking_number,omitempty"`
+	Status         ShipmentStatus  `json:"status"`
+	ShippedAt      time.Time       `json:"shipped_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	Items          []*ShipmentItem `json:"items"`
+}
+
+type ShipmentItem struct {
+	OrderItemID int64 `json:"order_item_id"`
+	Quantity    int   `json:"quantity"`
+}
+
+// ShipmentInput the parcel details given when shipping, both optional
+type ShipmentInput struct {
+	Carrier        string
+	TrackingNumber string
 }
 
 type OrderItem struct {
 	ID              int64   `json:"id"`
 	OrderID         int64   `json:"order_id"`
+	SubOrderID      int64   `json:"sub_order_id"`
 	ProductID       int64   `json:"product_id"`
 	PriceAtPurchase float64 `json:"price_at_purchase"`
 	Quantity        int     `json:"quantity"`
+	WarehouseID     int64   `json:"warehouse_id"`
+}
+
+// CreateOrderInput Destination is optional, used by the nearest allocation strategy.
+// QuoteID is optional, the order fails when the cart drifted from the quote
+type CreateOrderInput struct {
+	Destination *inventory.GeoPoint
+	QuoteID     string
+}
+
+// GuestCheckoutInput order from the session's guest cart, Email receives
+// the order and can later claim it
+type GuestCheckoutInput struct {
+	Email       string
+	Shipping    *ShippingAddress
+	Destination *inventory.GeoPoint
+	QuoteID     string
+}
+
+type QuoteInput struct {
+	Destination *inventory.GeoPoint
+}
+
+// Quote checkout preview, priced like the order would be created now
+type Quote struct {
+	ID        string          `json:"quote_id"`
+	ExpiresAt time.Time       `json:"expires_at"`
+	CartID    string          `json:"-"`
+	Lines     []*QuoteLine    `json:"lines"`
+	Subtotal  float64         `json:"subtotal"`
+	Discount  float64         `json:"discount"`
+	Shipping  *shipping.Quote `json:"shipping"`
+	Tax       float64         `json:"tax"`
+	Total     float64         `json:"total"`
+}
+
+type QuoteLine struct {
+	CartItemID int64   `json:"cart_item_id"`
+	ProductID  int64   `json:"product_id"`
+	Name       string  `json:"name"`
+	Quantity   int     `json:"quantity"`
+	UnitPrice  float64 `json:"unit_price"`
+	LineTotal  float64 `json:"line_total"`
+}
+
+// Digest of the priced cart, any change to a line, the shipping method,
+// the discount, the tax or the total gives another digest
+func (q *Quote) Digest() string {
+	lines := make([]*QuoteLine, len(q.Lines))
+	copy(lines, q.Lines)
+	sort.Slice(lines, func(i, j int) bool { return lines[i].CartItemID < lines[j].CartItemID })
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s|%.2f|%.2f|%.2f", q.CartID, q.Discount, q.Tax, q.Total)
+	if q.Shipping != nil {
+		fmt.Fprintf(h, "|%s:%s", q.Shipping.Method, q.Shipping.Zone)
+	}
+	for _, l := range lines {
+		fmt.Fprintf(h, "|%d:%d:%d:%.2f", l.CartItemID, l.ProductID, l.Quantity, l.UnitPrice)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SellerOrder an order as seen by one seller through its sub-order, Total
+// & Items only cover the seller's lines
+type SellerOrder struct {
+	ID          int64              `json:"id"`
+	SubOrderID  int64              `json:"sub_order_id"`
+	Status      string             `json:"status"`
+	OrderStatus string             `json:"order_status"`
+	ShippedAt   *time.Time         `json:"shipped_at,omitempty"`
+	Total       float64            `json:"total"`
+	CreatedAt   time.Time          `json:"created_at"`
+	Shipping    *ShippingAddress   `json:"shipping,omitempty"`
+	Items       []*SellerOrderItem `json:"items"`
+}
+
+type SellerOrderItem struct {
+	ID          int64   `json:"id"`
+	OrderID     int64   `json:"-"`
+	ProductID   int64   `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	ProductSKU  string  `json:"product_sku"`
+	Quantity    int     `json:"quantity"`
+	Price       float64 `json:"price"`
+	Warehouse   string  `json:"warehouse,omitempty"`
+}
+
+// SellerOrderFilter Status of the seller's sub-order
+type SellerOrderFilter struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending paid shipped cancelled completed refunded"`
+	Page   int    `form:"page"`
+	Limit  int    `form:"limit"`
+
+	SellerID int64 `form:"-"`
+}
+
+// AdminOrderFilter To is inclusive, zero values are not filtered. Sort is a
+// column, prefixed with - for descending
+type AdminOrderFilter struct {
+	Status       string    `form:"status" binding:"omitempty,oneof=pending paid partially_shipped shipped cancelled completed refunded"`
+	RefundStatus string    `form:"refund_status" binding:"omitempty,oneof=none partial full"`
+	From         time.Time `form:"from" time_format:"2006-01-02"`
+	To           time.Time `form:"to" time_format:"2006-01-02"`
+	UserID       int64     `form:"user_id" binding:"omitempty,gt=0"`
+	MinTotal     float64   `form:"min_total" binding:"omitempty,gte=0"`
+	MaxTotal     float64   `form:"max_total" binding:"omitempty,gte=0"`
+	ProductID    int64     `form:"product_id" binding:"omitempty,gt=0"`
+	Sort         string    `form:"sort" binding:"omitempty,oneof=created_at -created_at total -total id -id"`
+	Page         int       `form:"page"`
+	Limit        int       `form:"limit"`
+}
+
+// BulkStatusResult one order of a bulk change, Error is why it was left
+// unchanged
+type BulkStatusResult struct {
+	OrderID int64  `json:"order_id"`
+	Updated bool   `json:"updated"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BulkStatusReport each order is changed in its own transaction, a failed
+// order does not undo the others
+type BulkStatusReport struct {
+	Updated int                 `json:"updated"`
+	Failed  int                 `json:"failed"`
+	Results []*BulkStatusResult `json:"results"`
 }
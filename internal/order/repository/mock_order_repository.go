@@ -8,6 +8,7 @@ import (
 	context "context"
 	sql "database/sql"
 	reflect "reflect"
+	time "time"
 
 	order "github.com/codepnw/mini-ecommerce/internal/order"
 	database "github.com/codepnw/mini-ecommerce/pkg/database"
@@ -37,6 +38,36 @@ func (m *MockOrderRepository) EXPECT() *MockOrderRepositoryMockRecorder {
 	return m.recorder
 }
 
+// ClaimGuestOrders mocks base method.
+func (m *MockOrderRepository) ClaimGuestOrders(ctx context.Context, userID int64, email string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimGuestOrders", ctx, userID, email)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimGuestOrders indicates an expected call of ClaimGuestOrders.
+func (mr *MockOrderRepositoryMockRecorder) ClaimGuestOrders(ctx, userID, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimGuestOrders", reflect.TypeOf((*MockOrderRepository)(nil).ClaimGuestOrders), ctx, userID, email)
+}
+
+// CountGuestOrders mocks base method.
+func (m *MockOrderRepository) CountGuestOrders(ctx context.Context, email string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountGuestOrders", ctx, email)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountGuestOrders indicates an expected call of CountGuestOrders.
+func (mr *MockOrderRepositoryMockRecorder) CountGuestOrders(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountGuestOrders", reflect.TypeOf((*MockOrderRepository)(nil).CountGuestOrders), ctx, email)
+}
+
 // CreateOrder mocks base method.
 func (m *MockOrderRepository) CreateOrder(ctx context.Context, tx *sql.Tx, input *order.Order) (int64, error) {
 	m.ctrl.T.Helper()
@@ -66,6 +97,21 @@ func (mr *MockOrderRepositoryMockRecorder) CreateOrderItem(ctx, tx, input interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrderItem", reflect.TypeOf((*MockOrderRepository)(nil).CreateOrderItem), ctx, tx, input)
 }
 
+// CreateSubOrder mocks base method.
+func (m *MockOrderRepository) CreateSubOrder(ctx context.Context, tx *sql.Tx, input *order.SubOrder) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSubOrder", ctx, tx, input)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSubOrder indicates an expected call of CreateSubOrder.
+func (mr *MockOrderRepositoryMockRecorder) CreateSubOrder(ctx, tx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSubOrder", reflect.TypeOf((*MockOrderRepository)(nil).CreateSubOrder), ctx, tx, input)
+}
+
 // GetMyOrders mocks base method.
 func (m *MockOrderRepository) GetMyOrders(ctx context.Context, userID int64) ([]*order.Order, error) {
 	m.ctrl.T.Helper()
@@ -111,6 +157,197 @@ func (mr *MockOrderRepositoryMockRecorder) GetOrderItems(ctx, exec, orderID inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderItems", reflect.TypeOf((*MockOrderRepository)(nil).GetOrderItems), ctx, exec, orderID)
 }
 
+// GetSellerOrder mocks base method.
+func (m *MockOrderRepository) GetSellerOrder(ctx context.Context, exec database.DBExec, sellerID, orderID int64) (*order.SellerOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSellerOrder", ctx, exec, sellerID, orderID)
+	ret0, _ := ret[0].(*order.SellerOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSellerOrder indicates an expected call of GetSellerOrder.
+func (mr *MockOrderRepositoryMockRecorder) GetSellerOrder(ctx, exec, sellerID, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSellerOrder", reflect.TypeOf((*MockOrderRepository)(nil).GetSellerOrder), ctx, exec, sellerID, orderID)
+}
+
+// GetSellerOrderItems mocks base method.
+func (m *MockOrderRepository) GetSellerOrderItems(ctx context.Context, exec database.DBExec, sellerID int64, orderIDs []int64) ([]*order.SellerOrderItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSellerOrderItems", ctx, exec, sellerID, orderIDs)
+	ret0, _ := ret[0].([]*order.SellerOrderItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSellerOrderItems indicates an expected call of GetSellerOrderItems.
+func (mr *MockOrderRepositoryMockRecorder) GetSellerOrderItems(ctx, exec, sellerID, orderIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSellerOrderItems", reflect.TypeOf((*MockOrderRepository)(nil).GetSellerOrderItems), ctx, exec, sellerID, orderIDs)
+}
+
+// GetStatusForUpdate mocks base method.
+func (m *MockOrderRepository) GetStatusForUpdate(ctx context.Context, tx *sql.Tx, orderID int64) (order.OrderStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStatusForUpdate", ctx, tx, orderID)
+	ret0, _ := ret[0].(order.OrderStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStatusForUpdate indicates an expected call of GetStatusForUpdate.
+func (mr *MockOrderRepositoryMockRecorder) GetStatusForUpdate(ctx, tx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatusForUpdate", reflect.TypeOf((*MockOrderRepository)(nil).GetStatusForUpdate), ctx, tx, orderID)
+}
+
+// InsertShipment mocks base method.
+func (m *MockOrderRepository) InsertShipment(ctx context.Context, tx *sql.Tx, input *order.Shipment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertShipment", ctx, tx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertShipment indicates an expected call of InsertShipment.
+func (mr *MockOrderRepositoryMockRecorder) InsertShipment(ctx, tx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertShipment", reflect.TypeOf((*MockOrderRepository)(nil).InsertShipment), ctx, tx, input)
+}
+
+// ListOrders mocks base method.
+func (m *MockOrderRepository) ListOrders(ctx context.Context, filter *order.AdminOrderFilter) ([]*order.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOrders", ctx, filter)
+	ret0, _ := ret[0].([]*order.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOrders indicates an expected call of ListOrders.
+func (mr *MockOrderRepositoryMockRecorder) ListOrders(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrders", reflect.TypeOf((*MockOrderRepository)(nil).ListOrders), ctx, filter)
+}
+
+// ListSellerOrders mocks base method.
+func (m *MockOrderRepository) ListSellerOrders(ctx context.Context, filter *order.SellerOrderFilter) ([]*order.SellerOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSellerOrders", ctx, filter)
+	ret0, _ := ret[0].([]*order.SellerOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSellerOrders indicates an expected call of ListSellerOrders.
+func (mr *MockOrderRepositoryMockRecorder) ListSellerOrders(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSellerOrders", reflect.TypeOf((*MockOrderRepository)(nil).ListSellerOrders), ctx, filter)
+}
+
+// ListShipments mocks base method.
+func (m *MockOrderRepository) ListShipments(ctx context.Context, exec database.DBExec, orderID int64) ([]*order.Shipment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListShipments", ctx, exec, orderID)
+	ret0, _ := ret[0].([]*order.Shipment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListShipments indicates an expected call of ListShipments.
+func (mr *MockOrderRepositoryMockRecorder) ListShipments(ctx, exec, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListShipments", reflect.TypeOf((*MockOrderRepository)(nil).ListShipments), ctx, exec, orderID)
+}
+
+// ListShipmentsToTrack mocks base method.
+func (m *MockOrderRepository) ListShipmentsToTrack(ctx context.Context, limit int) ([]*order.Shipment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListShipmentsToTrack", ctx, limit)
+	ret0, _ := ret[0].([]*order.Shipment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListShipmentsToTrack indicates an expected call of ListShipmentsToTrack.
+func (mr *MockOrderRepositoryMockRecorder) ListShipmentsToTrack(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListShipmentsToTrack", reflect.TypeOf((*MockOrderRepository)(nil).ListShipmentsToTrack), ctx, limit)
+}
+
+// ListSubOrders mocks base method.
+func (m *MockOrderRepository) ListSubOrders(ctx context.Context, exec database.DBExec, orderID int64) ([]*order.SubOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSubOrders", ctx, exec, orderID)
+	ret0, _ := ret[0].([]*order.SubOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSubOrders indicates an expected call of ListSubOrders.
+func (mr *MockOrderRepositoryMockRecorder) ListSubOrders(ctx, exec, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSubOrders", reflect.TypeOf((*MockOrderRepository)(nil).ListSubOrders), ctx, exec, orderID)
+}
+
+// SetShipmentChecked mocks base method.
+func (m *MockOrderRepository) SetShipmentChecked(ctx context.Context, shipmentID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetShipmentChecked", ctx, shipmentID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetShipmentChecked indicates an expected call of SetShipmentChecked.
+func (mr *MockOrderRepositoryMockRecorder) SetShipmentChecked(ctx, shipmentID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetShipmentChecked", reflect.TypeOf((*MockOrderRepository)(nil).SetShipmentChecked), ctx, shipmentID)
+}
+
+// SetShipmentDelivered mocks base method.
+func (m *MockOrderRepository) SetShipmentDelivered(ctx context.Context, tx *sql.Tx, shipmentID int64, deliveredAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetShipmentDelivered", ctx, tx, shipmentID, deliveredAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetShipmentDelivered indicates an expected call of SetShipmentDelivered.
+func (mr *MockOrderRepositoryMockRecorder) SetShipmentDelivered(ctx, tx, shipmentID, deliveredAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetShipmentDelivered", reflect.TypeOf((*MockOrderRepository)(nil).SetShipmentDelivered), ctx, tx, shipmentID, deliveredAt)
+}
+
+// SetShipmentTracking mocks base method.
+func (m *MockOrderRepository) SetShipmentTracking(ctx context.Context, tx *sql.Tx, subOrderID int64, input *order.ShipmentInput) (*order.Shipment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetShipmentTracking", ctx, tx, subOrderID, input)
+	ret0, _ := ret[0].(*order.Shipment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetShipmentTracking indicates an expected call of SetShipmentTracking.
+func (mr *MockOrderRepositoryMockRecorder) SetShipmentTracking(ctx, tx, subOrderID, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetShipmentTracking", reflect.TypeOf((*MockOrderRepository)(nil).SetShipmentTracking), ctx, tx, subOrderID, input)
+}
+
+// UpdateRefundStatus mocks base method.
+func (m *MockOrderRepository) UpdateRefundStatus(ctx context.Context, tx *sql.Tx, orderID int64, status string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRefundStatus", ctx, tx, orderID, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRefundStatus indicates an expected call of UpdateRefundStatus.
+func (mr *MockOrderRepositoryMockRecorder) UpdateRefundStatus(ctx, tx, orderID, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRefundStatus", reflect.TypeOf((*MockOrderRepository)(nil).UpdateRefundStatus), ctx, tx, orderID, status)
+}
+
 // UpdateStatus mocks base method.
 func (m *MockOrderRepository) UpdateStatus(ctx context.Context, tx *sql.Tx, orderID int64, status string) error {
 	m.ctrl.T.Helper()
@@ -124,3 +361,58 @@ func (mr *MockOrderRepositoryMockRecorder) UpdateStatus(ctx, tx, orderID, status
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockOrderRepository)(nil).UpdateStatus), ctx, tx, orderID, status)
 }
+
+// UpdateSubOrderStatus mocks base method.
+func (m *MockOrderRepository) UpdateSubOrderStatus(ctx context.Context, tx *sql.Tx, subOrderID int64, status string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSubOrderStatus", ctx, tx, subOrderID, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSubOrderStatus indicates an expected call of UpdateSubOrderStatus.
+func (mr *MockOrderRepositoryMockRecorder) UpdateSubOrderStatus(ctx, tx, subOrderID, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSubOrderStatus", reflect.TypeOf((*MockOrderRepository)(nil).UpdateSubOrderStatus), ctx, tx, subOrderID, status)
+}
+
+// MockrowScanner is a mock of rowScanner interface.
+type MockrowScanner struct {
+	ctrl     *gomock.Controller
+	recorder *MockrowScannerMockRecorder
+}
+
+// MockrowScannerMockRecorder is the mock recorder for MockrowScanner.
+type MockrowScannerMockRecorder struct {
+	mock *MockrowScanner
+}
+
+// NewMockrowScanner creates a new mock instance.
+func NewMockrowScanner(ctrl *gomock.Controller) *MockrowScanner {
+	mock := &MockrowScanner{ctrl: ctrl}
+	mock.recorder = &MockrowScannerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockrowScanner) EXPECT() *MockrowScannerMockRecorder {
+	return m.recorder
+}
+
+// Scan mocks base method.
+func (m *MockrowScanner) Scan(dest ...any) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range dest {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Scan", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Scan indicates an expected call of Scan.
+func (mr *MockrowScannerMockRecorder) Scan(dest ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockrowScanner)(nil).Scan), dest...)
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/codepnw/mini-ecommerce/internal/order"
 	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
 	"github.com/codepnw/mini-ecommerce/pkg/database"
+	"github.com/lib/pq"
 )
 
 //go:generate mockgen -source=order_repository.go -destination=mock_order_repository.go -package=orderrepository
@@ -16,6 +19,9 @@ type OrderRepository interface {
 	GetMyOrders(ctx context.Context, userID int64) ([]*order.Order, error)
 	GetOrder(ctx context.Context, orderID int64) (*order.Order, error)
 
+	// Admin
+	ListOrders(ctx context.Context, filter *order.AdminOrderFilter) ([]*order.Order, error)
+
 	// DB or Tx
 	GetOrderItems(ctx context.Context, exec database.DBExec, orderID int64) ([]*OrderItemDetail, error)
 
@@ -23,6 +29,30 @@ type OrderRepository interface {
 	CreateOrder(ctx context.Context, tx *sql.Tx, input *order.Order) (int64, error)
 	CreateOrderItem(ctx context.Context, tx *sql.Tx, input *order.OrderItem) error
 	UpdateStatus(ctx context.Context, tx *sql.Tx, orderID int64, status string) error
+	UpdateRefundStatus(ctx context.Context, tx *sql.Tx, orderID int64, status string) error
+
+	// Sub Orders
+	CreateSubOrder(ctx context.Context, tx *sql.Tx, input *order.SubOrder) (int64, error)
+	ListSubOrders(ctx context.Context, exec database.DBExec, orderID int64) ([]*order.SubOrder, error)
+	UpdateSubOrderStatus(ctx context.Context, tx *sql.Tx, subOrderID int64, status string) error
+
+	// Guest Orders
+	CountGuestOrders(ctx context.Context, email string) (int, error)
+	ClaimGuestOrders(ctx context.Context, userID int64, email string) (int64, error)
+
+	// Seller
+	ListSellerOrders(ctx context.Context, filter *order.SellerOrderFilter) ([]*order.SellerOrder, error)
+	GetSellerOrder(ctx context.Context, exec database.DBExec, sellerID, orderID int64) (*order.SellerOrder, error)
+	GetSellerOrderItems(ctx context.Context, exec database.DBExec, sellerID int64, orderIDs []int64) ([]*order.SellerOrderItem, error)
+	GetStatusForUpdate(ctx context.Context, tx *sql.Tx, orderID int64) (order.OrderStatus, error)
+
+	// Shipments
+	InsertShipment(ctx context.Context, tx *sql.Tx, input *order.Shipment) error
+	ListShipments(ctx context.Context, exec database.DBExec, orderID int64) ([]*order.Shipment, error)
+	ListShipmentsToTrack(ctx context.Context, limit int) ([]*order.Shipment, error)
+	SetShipmentChecked(ctx context.Context, shipmentID int64) error
+	SetShipmentDelivered(ctx context.Context, tx *sql.Tx, shipmentID int64, deliveredAt time.Time) error
+	SetShipmentTracking(ctx context.Context, tx *sql.Tx, subOrderID int64, input *order.ShipmentInput) (*order.Shipment, error)
 }
 
 type orderRepository struct {
@@ -35,16 +65,32 @@ func NewOrderRepository(db *sql.DB) OrderRepository {
 
 func (r *orderRepository) CreateOrder(ctx context.Context, tx *sql.Tx, input *order.Order) (int64, error) {
 	query := `
-		INSERT INTO orders (user_id, total, status)
-		VALUES ($1, $2, $3) RETURNING id
+		INSERT INTO orders (
+			user_id, guest_email, total, status, ship_name, ship_phone, ship_address, ship_city, ship_postal_code, ship_country,
+			shipping_method, shipping_amount
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id
 	`
+	ship := input.Shipping
+	if ship == nil {
+		ship = new(order.ShippingAddress)
+	}
 	var orderID int64
 	err := tx.QueryRowContext(
 		ctx,
 		query,
-		input.UserID,
+		sql.NullInt64{Int64: input.UserID, Valid: input.UserID > 0},
+		sql.NullString{String: input.GuestEmail, Valid: input.GuestEmail != ""},
 		input.Total,
 		input.Status,
+		sql.NullString{String: ship.Name, Valid: input.Shipping != nil},
+		sql.NullString{String: ship.Phone, Valid: input.Shipping != nil},
+		sql.NullString{String: ship.Address, Valid: input.Shipping != nil},
+		sql.NullString{String: ship.City, Valid: input.Shipping != nil},
+		sql.NullString{String: ship.PostalCode, Valid: input.Shipping != nil},
+		sql.NullString{String: ship.Country, Valid: input.Shipping != nil},
+		sql.NullString{String: input.ShippingMethod, Valid: input.ShippingMethod != ""},
+		input.ShippingAmount,
 	).Scan(&orderID)
 	if err != nil {
 		return 0, err
@@ -54,40 +100,169 @@ func (r *orderRepository) CreateOrder(ctx context.Context, tx *sql.Tx, input *or
 
 func (r *orderRepository) CreateOrderItem(ctx context.Context, tx *sql.Tx, input *order.OrderItem) error {
 	query := `
-		INSERT INTO order_items (order_id, product_id, price, quantity)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO order_items (order_id, sub_order_id, product_id, price, quantity, warehouse_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
 	`
 	_, err := tx.ExecContext(
 		ctx,
 		query,
 		input.OrderID,
+		sql.NullInt64{Int64: input.SubOrderID, Valid: input.SubOrderID > 0},
 		input.ProductID,
 		input.PriceAtPurchase,
 		input.Quantity,
+		sql.NullInt64{Int64: input.WarehouseID, Valid: input.WarehouseID > 0},
 	)
 	return err
 }
 
+const orderQuery = `
+	SELECT o.id, o.user_id, o.guest_email, o.total, o.shipping_method, o.shipping_amount, o.status, o.refund_status, o.created_at, o.updated_at,
+		o.ship_name, o.ship_phone, o.ship_address, o.ship_city, o.ship_postal_code, o.ship_country
+	FROM orders o
+`
+
 func (r *orderRepository) GetOrder(ctx context.Context, orderID int64) (*order.Order, error) {
-	query := `
-		SELECT id, user_id, total, status, created_at, updated_at
-		FROM orders WHERE id = $1 LIMIT 1
-	`
-	o := new(order.Order)
-	err := r.db.QueryRowContext(ctx, query, orderID).Scan(
+	query := orderQuery + " WHERE o.id = $1 LIMIT 1"
+
+	o, err := scanOrder(r.db.QueryRowContext(ctx, query, orderID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrOrderNotFound
+		}
+		return nil, err
+	}
+	return o, nil
+}
+
+// ListOrders filter dates are [From, To), the product filter matches
+// orders holding the product
+func (r *orderRepository) ListOrders(ctx context.Context, filter *order.AdminOrderFilter) ([]*order.Order, error) {
+	query := orderQuery + " WHERE TRUE"
+	args := []any{}
+	idx := 1
+
+	if filter.Status != "" {
+		query += fmt.Sprintf(" AND o.status = $%d", idx)
+		args = append(args, filter.Status)
+		idx++
+	}
+	if filter.RefundStatus != "" {
+		query += fmt.Sprintf(" AND o.refund_status = $%d", idx)
+		args = append(args, filter.RefundStatus)
+		idx++
+	}
+	if !filter.From.IsZero() {
+		query += fmt.Sprintf(" AND o.created_at >= $%d", idx)
+		args = append(args, filter.From)
+		idx++
+	}
+	if !filter.To.IsZero() {
+		query += fmt.Sprintf(" AND o.created_at < $%d", idx)
+		args = append(args, filter.To)
+		idx++
+	}
+	if filter.UserID > 0 {
+		query += fmt.Sprintf(" AND o.user_id = $%d", idx)
+		args = append(args, filter.UserID)
+		idx++
+	}
+	if filter.MinTotal > 0 {
+		query += fmt.Sprintf(" AND o.total >= $%d", idx)
+		args = append(args, filter.MinTotal)
+		idx++
+	}
+	if filter.MaxTotal > 0 {
+		query += fmt.Sprintf(" AND o.total <= $%d", idx)
+		args = append(args, filter.MaxTotal)
+		idx++
+	}
+	if filter.ProductID > 0 {
+		query += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM order_items oi WHERE oi.order_id = o.id AND oi.product_id = $%d)", idx)
+		args = append(args, filter.ProductID)
+		idx++
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+
+	query += fmt.Sprintf(" ORDER BY %s LIMIT $%d OFFSET $%d", orderSorts[filter.Sort], idx, idx+1)
+	args = append(args, filter.Limit, offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := make([]*order.Order, 0)
+	for rows.Next() {
+		o, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, o)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// orderSorts sort values of the admin list, never put the raw value in SQL
+var orderSorts = map[string]string{
+	"":            "o.created_at DESC, o.id DESC",
+	"created_at":  "o.created_at, o.id",
+	"-created_at": "o.created_at DESC, o.id DESC",
+	"total":       "o.total, o.id",
+	"-total":      "o.total DESC, o.id DESC",
+	"id":          "o.id",
+	"-id":         "o.id DESC",
+}
+
+func scanOrder(row rowScanner) (*order.Order, error) {
+	var (
+		o              = new(order.Order)
+		userID         sql.NullInt64
+		guestEmail     sql.NullString
+		shippingMethod sql.NullString
+		ship           [6]sql.NullString
+	)
+	err := row.Scan(
 		&o.ID,
-		&o.UserID,
+		&userID,
+		&guestEmail,
 		&o.Total,
+		&shippingMethod,
+		&o.ShippingAmount,
 		&o.Status,
+		&o.RefundStatus,
 		&o.CreatedAt,
 		&o.UpdatedAt,
+		&ship[0],
+		&ship[1],
+		&ship[2],
+		&ship[3],
+		&ship[4],
+		&ship[5],
 	)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errs.ErrOrderNotFound
-		}
 		return nil, err
 	}
+	o.UserID = userID.Int64
+	o.GuestEmail = guestEmail.String
+	o.ShippingMethod = shippingMethod.String
+	// Orders placed before shipping addresses have none
+	if ship[0].Valid {
+		o.Shipping = &order.ShippingAddress{
+			Name:       ship[0].String,
+			Phone:      ship[1].String,
+			Address:    ship[2].String,
+			City:       ship[3].String,
+			PostalCode: ship[4].String,
+			Country:    ship[5].String,
+		}
+	}
 	return o, nil
 }
 
@@ -98,13 +273,18 @@ type OrderItemDetail struct {
 	ProductID       int64   `json:"product_id"`
 	ProductName     string  `json:"product_name"`
 	ProductSKU      string  `json:"product_sku"`
+	SubOrderID      int64   `json:"sub_order_id"`
+	// Zero for orders placed before warehouses
+	WarehouseID   int64  `json:"warehouse_id"`
+	WarehouseCode string `json:"warehouse_code"`
 }
 
 func (r *orderRepository) GetOrderItems(ctx context.Context, exec database.DBExec, orderID int64) ([]*OrderItemDetail, error) {
 	query := `
-		SELECT oi.id, oi.product_id, oi.quantity, oi.price, p.name, p.sku
+		SELECT oi.id, oi.sub_order_id, oi.product_id, oi.quantity, oi.price, p.name, p.sku, oi.warehouse_id, w.code
 		FROM order_items oi
 		INNER JOIN products p ON oi.product_id = p.id
+		LEFT JOIN warehouses w ON oi.warehouse_id = w.id
 		WHERE oi.order_id = $1
 	`
 	rows, err := exec.QueryContext(ctx, query, orderID)
@@ -115,18 +295,29 @@ func (r *orderRepository) GetOrderItems(ctx context.Context, exec database.DBExe
 
 	items := make([]*OrderItemDetail, 0)
 	for rows.Next() {
-		i := new(OrderItemDetail)
+		var (
+			i             = new(OrderItemDetail)
+			subOrderID    sql.NullInt64
+			warehouseID   sql.NullInt64
+			warehouseCode sql.NullString
+		)
 		err = rows.Scan(
 			&i.ID,
+			&subOrderID,
 			&i.ProductID,
 			&i.Quantity,
 			&i.PriceAtPurchase,
 			&i.ProductName,
 			&i.ProductSKU,
+			&warehouseID,
+			&warehouseCode,
 		)
 		if err != nil {
 			return nil, err
 		}
+		i.SubOrderID = subOrderID.Int64
+		i.WarehouseID = warehouseID.Int64
+		i.WarehouseCode = warehouseCode.String
 		items = append(items, i)
 	}
 
@@ -138,7 +329,7 @@ func (r *orderRepository) GetOrderItems(ctx context.Context, exec database.DBExe
 
 func (r *orderRepository) GetMyOrders(ctx context.Context, userID int64) ([]*order.Order, error) {
 	query := `
-		SELECT id, user_id, total, status, created_at
+		SELECT id, user_id, total, status, refund_status, created_at
 		FROM orders WHERE user_id = $1
 		ORDER BY created_at DESC
 	`
@@ -156,6 +347,7 @@ func (r *orderRepository) GetMyOrders(ctx context.Context, userID int64) ([]*ord
 			&o.UserID,
 			&o.Total,
 			&o.Status,
+			&o.RefundStatus,
 			&o.CreatedAt,
 		)
 		if err != nil {
@@ -187,3 +379,493 @@ func (r *orderRepository) UpdateStatus(ctx context.Context, tx *sql.Tx, orderID
 	}
 	return nil
 }
+
+func (r *orderRepository) UpdateRefundStatus(ctx context.Context, tx *sql.Tx, orderID int64, status string) error {
+	query := `UPDATE orders SET refund_status = $1 WHERE id = $2`
+	res, err := tx.ExecContext(ctx, query, status, orderID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errs.ErrOrderNotFound
+	}
+	return nil
+}
+
+// CountGuestOrders not yet claimed by an account
+func (r *orderRepository) CountGuestOrders(ctx context.Context, email string) (int, error) {
+	query := `SELECT COUNT(*) FROM orders WHERE user_id IS NULL AND LOWER(guest_email) = LOWER($1)`
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, email).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *orderRepository) ClaimGuestOrders(ctx context.Context, userID int64, email string) (int64, error) {
+	query := `
+		UPDATE orders SET user_id = $1, updated_at = NOW()
+		WHERE user_id IS NULL AND LOWER(guest_email) = LOWER($2)
+	`
+	res, err := r.db.ExecContext(ctx, query, userID, email)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// sellerOrderQuery orders with a sub-order of the seller ($1), totals only
+// sum the seller's lines
+const sellerOrderQuery = `
+	SELECT o.id, s.id, s.status, o.status, s.shipped_at, s.total, o.created_at,
+		o.ship_name, o.ship_phone, o.ship_address, o.ship_city, o.ship_postal_code, o.ship_country
+	FROM sub_orders s
+	INNER JOIN orders o ON o.id = s.order_id
+	WHERE s.seller_id = $1
+`
+
+func (r *orderRepository) ListSellerOrders(ctx context.Context, filter *order.SellerOrderFilter) ([]*order.SellerOrder, error) {
+	query := sellerOrderQuery
+	args := []any{filter.SellerID}
+	idx := 2
+
+	if filter.Status != "" {
+		query += fmt.Sprintf(" AND s.status = $%d", idx)
+		args = append(args, filter.Status)
+		idx++
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+
+	query += fmt.Sprintf(" ORDER BY s.created_at DESC, s.id DESC LIMIT $%d OFFSET $%d", idx, idx+1)
+	args = append(args, filter.Limit, offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := make([]*order.SellerOrder, 0)
+	for rows.Next() {
+		o, err := scanSellerOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, o)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// GetSellerOrder not found when the order has no sub-order of the seller
+func (r *orderRepository) GetSellerOrder(ctx context.Context, exec database.DBExec, sellerID, orderID int64) (*order.SellerOrder, error) {
+	query := sellerOrderQuery + " AND s.order_id = $2"
+
+	o, err := scanSellerOrder(exec.QueryRowContext(ctx, query, sellerID, orderID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrOrderNotFound
+		}
+		return nil, err
+	}
+	return o, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSellerOrder(row rowScanner) (*order.SellerOrder, error) {
+	var (
+		o         = new(order.SellerOrder)
+		shippedAt sql.NullTime
+		ship      [6]sql.NullString
+	)
+	err := row.Scan(
+		&o.ID,
+		&o.SubOrderID,
+		&o.Status,
+		&o.OrderStatus,
+		&shippedAt,
+		&o.Total,
+		&o.CreatedAt,
+		&ship[0],
+		&ship[1],
+		&ship[2],
+		&ship[3],
+		&ship[4],
+		&ship[5],
+	)
+	if err != nil {
+		return nil, err
+	}
+	if shippedAt.Valid {
+		o.ShippedAt = &shippedAt.Time
+	}
+	if ship[0].Valid {
+		o.Shipping = &order.ShippingAddress{
+			Name:       ship[0].String,
+			Phone:      ship[1].String,
+			Address:    ship[2].String,
+			City:       ship[3].String,
+			PostalCode: ship[4].String,
+			Country:    ship[5].String,
+		}
+	}
+	return o, nil
+}
+
+func (r *orderRepository) GetSellerOrderItems(ctx context.Context, exec database.DBExec, sellerID int64, orderIDs []int64) ([]*order.SellerOrderItem, error) {
+	query := `
+		SELECT oi.id, oi.order_id, oi.product_id, p.name, p.sku, oi.quantity, oi.price, w.code
+		FROM order_items oi
+		INNER JOIN sub_orders s ON s.id = oi.sub_order_id AND s.seller_id = $1
+		INNER JOIN products p ON p.id = oi.product_id
+		LEFT JOIN warehouses w ON oi.warehouse_id = w.id
+		WHERE oi.order_id = ANY($2)
+		ORDER BY oi.order_id, oi.id
+	`
+	rows, err := exec.QueryContext(ctx, query, sellerID, pq.Array(orderIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]*order.SellerOrderItem, 0)
+	for rows.Next() {
+		var (
+			i             = new(order.SellerOrderItem)
+			warehouseCode sql.NullString
+		)
+		err = rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.ProductID,
+			&i.ProductName,
+			&i.ProductSKU,
+			&i.Quantity,
+			&i.Price,
+			&warehouseCode,
+		)
+		if err != nil {
+			return nil, err
+		}
+		i.Warehouse = warehouseCode.String
+		items = append(items, i)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// GetStatusForUpdate locks the order, sub-order changes of the same order
+// are serialized so its derived status stays right
+func (r *orderRepository) GetStatusForUpdate(ctx context.Context, tx *sql.Tx, orderID int64) (order.OrderStatus, error) {
+	query := `SELECT status FROM orders WHERE id = $1 FOR UPDATE`
+	var status order.OrderStatus
+	if err := tx.QueryRowContext(ctx, query, orderID).Scan(&status); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", errs.ErrOrderNotFound
+		}
+		return "", err
+	}
+	return status, nil
+}
+
+func (r *orderRepository) CreateSubOrder(ctx context.Context, tx *sql.Tx, input *order.SubOrder) (int64, error) {
+	query := `
+		INSERT INTO sub_orders (order_id, seller_id, total, status)
+		VALUES ($1, $2, $3, $4) RETURNING id
+	`
+	var id int64
+	err := tx.QueryRowContext(ctx, query, input.OrderID, input.SellerID, input.Total, input.Status).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *orderRepository) ListSubOrders(ctx context.Context, exec database.DBExec, orderID int64) ([]*order.SubOrder, error) {
+	query := `
+		SELECT id, order_id, seller_id, total, status, shipped_at, completed_at, created_at
+		FROM sub_orders WHERE order_id = $1
+		ORDER BY id
+	`
+	rows, err := exec.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := make([]*order.SubOrder, 0)
+	for rows.Next() {
+		var (
+			s           = new(order.SubOrder)
+			shippedAt   sql.NullTime
+			completedAt sql.NullTime
+		)
+		err = rows.Scan(
+			&s.ID,
+			&s.OrderID,
+			&s.SellerID,
+			&s.Total,
+			&s.Status,
+			&shippedAt,
+			&completedAt,
+			&s.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if shippedAt.Valid {
+			s.ShippedAt = &shippedAt.Time
+		}
+		if completedAt.Valid {
+			s.CompletedAt = &completedAt.Time
+		}
+		subs = append(subs, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// UpdateSubOrderStatus stamps shipped_at when the sub-order ships and
+// completed_at when it completes
+func (r *orderRepository) UpdateSubOrderStatus(ctx context.Context, tx *sql.Tx, subOrderID int64, status string) error {
+	query := `
+		UPDATE sub_orders
+		SET status = $1, updated_at = NOW(),
+			shipped_at = CASE WHEN $1 = 'shipped' THEN NOW() ELSE shipped_at END,
+			completed_at = CASE WHEN $1 = 'completed' THEN NOW() ELSE completed_at END
+		WHERE id = $2
+	`
+	res, err := tx.ExecContext(ctx, query, status, subOrderID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errs.ErrSubOrderNotFound
+	}
+	return nil
+}
+
+// InsertShipment ships what is left of the sub-order's lines after refunds
+func (r *orderRepository) InsertShipment(ctx context.Context, tx *sql.Tx, input *order.Shipment) error {
+	query := `
+		INSERT INTO shipments (order_id, sub_order_id, carrier, tracking_number, status)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, shipped_at
+	`
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		input.OrderID,
+		input.SubOrderID,
+		sql.NullString{String: input.Carrier, Valid: input.Carrier != ""},
+		sql.NullString{String: input.TrackingNumber, Valid: input.TrackingNumber != ""},
+		input.Status,
+	).Scan(&input.ID, &input.ShippedAt)
+	if err != nil {
+		return err
+	}
+
+	itemQuery := `
+		INSERT INTO shipment_items (shipment_id, order_item_id, quantity)
+		SELECT $1, id, quantity - refunded_quantity
+		FROM order_items
+		WHERE sub_order_id = $2 AND quantity > refunded_quantity
+		ORDER BY id
+		RETURNING order_item_id, quantity
+	`
+	rows, err := tx.QueryContext(ctx, itemQuery, input.ID, input.SubOrderID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	input.Items = make([]*order.ShipmentItem, 0)
+	for rows.Next() {
+		i := new(order.ShipmentItem)
+		if err := rows.Scan(&i.OrderItemID, &i.Quantity); err != nil {
+			return err
+		}
+		input.Items = append(input.Items, i)
+	}
+	return rows.Err()
+}
+
+const shipmentQuery = `
+	SELECT id, order_id, sub_order_id, carrier, tracking_number, status, shipped_at, delivered_at
+	FROM shipments
+`
+
+func scanShipments(rows *sql.Rows) ([]*order.Shipment, error) {
+	list := make([]*order.Shipment, 0)
+	for rows.Next() {
+		var (
+			s              = &order.Shipment{Items: make([]*order.ShipmentItem, 0)}
+			carrier        sql.NullString
+			trackingNumber sql.NullString
+			deliveredAt    sql.NullTime
+		)
+		err := rows.Scan(
+			&s.ID,
+			&s.OrderID,
+			&s.SubOrderID,
+			&carrier,
+			&trackingNumber,
+			&s.Status,
+			&s.ShippedAt,
+			&deliveredAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		s.Carrier = carrier.String
+		s.TrackingNumber = trackingNumber.String
+		if deliveredAt.Valid {
+			s.DeliveredAt = &deliveredAt.Time
+		}
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
+
+// ListShipments of the order with their items
+func (r *orderRepository) ListShipments(ctx context.Context, exec database.DBExec, orderID int64) ([]*order.Shipment, error) {
+	rows, err := exec.QueryContext(ctx, shipmentQuery+" WHERE order_id = $1 ORDER BY id", orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list, err := scanShipments(rows)
+	if err != nil || len(list) == 0 {
+		return list, err
+	}
+
+	byID := make(map[int64]*order.Shipment, len(list))
+	for _, s := range list {
+		byID[s.ID] = s
+	}
+
+	itemQuery := `
+		SELECT si.shipment_id, si.order_item_id, si.quantity
+		FROM shipment_items si
+		INNER JOIN shipments s ON s.id = si.shipment_id
+		WHERE s.order_id = $1
+		ORDER BY si.id
+	`
+	itemRows, err := exec.QueryContext(ctx, itemQuery, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var (
+			shipmentID int64
+			i          = new(order.ShipmentItem)
+		)
+		if err := itemRows.Scan(&shipmentID, &i.OrderItemID, &i.Quantity); err != nil {
+			return nil, err
+		}
+		byID[shipmentID].Items = append(byID[shipmentID].Items, i)
+	}
+	if err := itemRows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// ListShipmentsToTrack in transit with a tracking number, least recently
+// checked first, without their items
+func (r *orderRepository) ListShipmentsToTrack(ctx context.Context, limit int) ([]*order.Shipment, error) {
+	query := shipmentQuery + `
+		WHERE status = $1 AND tracking_number IS NOT NULL
+		ORDER BY last_checked_at NULLS FIRST, id
+		LIMIT $2
+	`
+	rows, err := r.db.QueryContext(ctx, query, order.ShipmentInTransit, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanShipments(rows)
+}
+
+func (r *orderRepository) SetShipmentChecked(ctx context.Context, shipmentID int64) error {
+	query := `UPDATE shipments SET last_checked_at = NOW() WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, shipmentID)
+	return err
+}
+
+// SetShipmentDelivered shipments still in transit only
+func (r *orderRepository) SetShipmentDelivered(ctx context.Context, tx *sql.Tx, shipmentID int64, deliveredAt time.Time) error {
+	query := `
+		UPDATE shipments
+		SET status = $1, delivered_at = $2, last_checked_at = NOW(), updated_at = NOW()
+		WHERE id = $3 AND status = $4
+	`
+	res, err := tx.ExecContext(ctx, query, order.ShipmentDelivered, deliveredAt, shipmentID, order.ShipmentInTransit)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errs.ErrShipmentNotFound
+	}
+	return nil
+}
+
+// SetShipmentTracking of a shipment still in transit, the tracking job polls
+// it first on its next run. Returned without its items.
+func (r *orderRepository) SetShipmentTracking(ctx context.Context, tx *sql.Tx, subOrderID int64, input *order.ShipmentInput) (*order.Shipment, error) {
+	query := `
+		UPDATE shipments
+		SET carrier = $1, tracking_number = $2, last_checked_at = NULL, updated_at = NOW()
+		WHERE sub_order_id = $3 AND status = $4
+		RETURNING id, order_id, sub_order_id, carrier, tracking_number, status, shipped_at, delivered_at
+	`
+	rows, err := tx.QueryContext(ctx, query, input.Carrier, input.TrackingNumber, subOrderID, order.ShipmentInTransit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list, err := scanShipments(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, errs.ErrShipmentNotFound
+	}
+	return list[0], nil
+}
@@ -0,0 +1,81 @@
+package orderusecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/codepnw/mini-ecommerce/internal/order"
+	"github.com/codepnw/mini-ecommerce/internal/user"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/pkg/auth"
+)
+
+func (u *orderUsecase) ListOrders(ctx context.Context, filter *order.AdminOrderFilter) ([]*order.Order, error) {
+	currentUser, err := auth.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, errs.ErrUnauthorized
+	}
+	if currentUser.Role != string(user.RoleAdmin) {
+		return nil, errs.ErrNoPermissions
+	}
+
+	// To is inclusive
+	if !filter.To.IsZero() {
+		filter.To = filter.To.Truncate(24*time.Hour).AddDate(0, 0, 1)
+		if !filter.From.IsZero() && !filter.From.Before(filter.To) {
+			return nil, errs.ErrInvalidDateRange
+		}
+	}
+	if filter.MaxTotal > 0 && filter.MinTotal > filter.MaxTotal {
+		return nil, errs.ErrInvalidTotalRange
+	}
+
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	return u.orderRepo.ListOrders(ctx, filter)
+}
+
+// BulkUpdateOrderStatus applies UpdateOrderStatus to each order, orders
+// that cannot make the change are reported and skipped
+func (u *orderUsecase) BulkUpdateOrderStatus(ctx context.Context, orderIDs []int64, newStatus order.OrderStatus) (*order.BulkStatusReport, error) {
+	currentUser, err := auth.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, errs.ErrUnauthorized
+	}
+	if currentUser.Role != string(user.RoleAdmin) {
+		return nil, errs.ErrNoPermissions
+	}
+	if len(orderIDs) > consts.MaxBulkOrders {
+		return nil, errs.ErrTooManyOrders
+	}
+
+	report := &order.BulkStatusReport{Results: make([]*order.BulkStatusResult, 0, len(orderIDs))}
+	seen := make(map[int64]bool, len(orderIDs))
+	for _, orderID := range orderIDs {
+		if seen[orderID] {
+			continue
+		}
+		seen[orderID] = true
+
+		result := &order.BulkStatusResult{OrderID: orderID}
+		// Shipped without tracking, the details differ per order
+		if err := u.updateOrderStatus(ctx, orderID, currentUser.ID, newStatus, nil); err != nil {
+			// Request cancelled, the rest would fail the same way
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			result.Error = err.Error()
+			report.Failed++
+		} else {
+			result.Updated = true
+			report.Updated++
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report, nil
+}
@@ -0,0 +1,120 @@
+package orderusecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/codepnw/mini-ecommerce/internal/order"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/pkg/auth"
+	"github.com/codepnw/mini-ecommerce/pkg/notify"
+)
+
+// GuestCheckout orders the session's guest cart, the lookup token is the
+// only way to see the order until it is claimed by an account
+func (u *orderUsecase) GuestCheckout(ctx context.Context, input *order.GuestCheckoutInput) (*GuestOrderView, error) {
+	if auth.GetUserID(ctx) > 0 {
+		return nil, errs.ErrGuestCheckoutSignedIn
+	}
+	sessionID := auth.GetSessionID(ctx)
+	if sessionID == "" {
+		return nil, errs.ErrCartIsEmpty
+	}
+
+	var newOrder *order.Order
+
+	err := u.tx.WithTransaction(ctx, func(tx *sql.Tx) error {
+		cartData, err := u.cartRepo.GetGuestCartBySessionID(ctx, tx, sessionID)
+		if err != nil {
+			return err
+		}
+
+		buyer := &order.Order{
+			GuestEmail: strings.ToLower(strings.TrimSpace(input.Email)),
+			Shipping:   input.Shipping,
+		}
+		newOrder, err = u.placeOrder(ctx, tx, cartData, buyer, input.Destination, input.QuoteID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := u.token.GenerateOrderLookupToken(newOrder.ID, newOrder.GuestEmail)
+	if err != nil {
+		return nil, err
+	}
+	return &GuestOrderView{Order: newOrder, LookupToken: token}, nil
+}
+
+// LookupGuestOrder tracking for guests, no account needed
+func (u *orderUsecase) LookupGuestOrder(ctx context.Context, token string) (*OrderView, error) {
+	claims, err := u.token.VerifyOrderLookupToken(token)
+	if err != nil {
+		return nil, errs.ErrOrderLookupInvalid
+	}
+
+	orderData, err := u.orderRepo.GetOrder(ctx, claims.OrderID)
+	if err != nil {
+		if errors.Is(err, errs.ErrOrderNotFound) {
+			return nil, errs.ErrOrderLookupInvalid
+		}
+		return nil, err
+	}
+	// Claimed orders are only shown in the account
+	if orderData.UserID != 0 || !strings.EqualFold(orderData.GuestEmail, claims.Email) {
+		return nil, errs.ErrOrderLookupInvalid
+	}
+	return u.orderView(ctx, orderData)
+}
+
+// RequestGuestOrderClaim mails a claim link to the account email, following
+// it proves the user owns the email the guest orders were placed with
+func (u *orderUsecase) RequestGuestOrderClaim(ctx context.Context) (int, error) {
+	currentUser, err := auth.GetCurrentUser(ctx)
+	if err != nil {
+		return 0, errs.ErrUnauthorized
+	}
+
+	count, err := u.orderRepo.CountGuestOrders(ctx, currentUser.Email)
+	if err != nil {
+		return 0, err
+	}
+	if count == 0 {
+		return 0, errs.ErrNoGuestOrders
+	}
+
+	token, err := u.token.GenerateOrderClaimToken(currentUser.ID, currentUser.Email)
+	if err != nil {
+		return 0, err
+	}
+	err = u.notifier.Send(ctx, &notify.Message{
+		To:      currentUser.Email,
+		Subject: "Add your guest orders to your account",
+		Body:    fmt.Sprintf("We found %d orders placed with this email, confirm to add them: %s?token=%s", count, u.claimURL, url.QueryEscape(token)),
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (u *orderUsecase) ConfirmGuestOrderClaim(ctx context.Context, token string) (int64, error) {
+	currentUser, err := auth.GetCurrentUser(ctx)
+	if err != nil {
+		return 0, errs.ErrUnauthorized
+	}
+
+	claims, err := u.token.VerifyOrderClaimToken(token)
+	if err != nil {
+		return 0, errs.ErrOrderClaimInvalid
+	}
+	if claims.UserID != currentUser.ID || !strings.EqualFold(claims.Email, currentUser.Email) {
+		return 0, errs.ErrOrderClaimInvalid
+	}
+	return u.orderRepo.ClaimGuestOrders(ctx, currentUser.ID, claims.Email)
+}
@@ -3,52 +3,105 @@ package orderusecase
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"math"
 	"time"
 
+	"github.com/codepnw/mini-ecommerce/internal/cart"
 	cartrepository "github.com/codepnw/mini-ecommerce/internal/cart/repository"
+	"github.com/codepnw/mini-ecommerce/internal/inventory"
+	inventoryrepository "github.com/codepnw/mini-ecommerce/internal/inventory/repository"
 	"github.com/codepnw/mini-ecommerce/internal/order"
 	orderrepository "github.com/codepnw/mini-ecommerce/internal/order/repository"
 	"github.com/codepnw/mini-ecommerce/internal/product"
 	productrepository "github.com/codepnw/mini-ecommerce/internal/product/repository"
+	"github.com/codepnw/mini-ecommerce/internal/shipping"
+	shippingusecase "github.com/codepnw/mini-ecommerce/internal/shipping/usecase"
 	"github.com/codepnw/mini-ecommerce/internal/user"
 	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
 	"github.com/codepnw/mini-ecommerce/pkg/auth"
+	"github.com/codepnw/mini-ecommerce/pkg/carrier"
 	"github.com/codepnw/mini-ecommerce/pkg/database"
+	"github.com/codepnw/mini-ecommerce/pkg/jwt"
+	"github.com/codepnw/mini-ecommerce/pkg/notify"
 )
 
 type OrderUsecase interface {
-	CreateOrder(ctx context.Context) (*order.Order, error)
+	CreateOrder(ctx context.Context, input *order.CreateOrderInput) (*order.Order, error)
+	Quote(ctx context.Context, input *order.QuoteInput) (*order.Quote, error)
 	GetOrderDetail(ctx context.Context, orderID int64) (*OrderView, error)
 	GetMyOrders(ctx context.Context) ([]*OrderListView, error)
 	CancelOrder(ctx context.Context, orderID int64) error
-	UpdateOrderStatus(ctx context.Context, orderID int64, newStatus order.OrderStatus) error
+	UpdateOrderStatus(ctx context.Context, orderID int64, newStatus order.OrderStatus, shipment *order.ShipmentInput) error
+	UpdateSubOrderStatus(ctx context.Context, orderID, subOrderID int64, newStatus order.OrderStatus, shipment *order.ShipmentInput) error
+
+	// Admin
+	ListOrders(ctx context.Context, filter *order.AdminOrderFilter) ([]*order.Order, error)
+	BulkUpdateOrderStatus(ctx context.Context, orderIDs []int64, newStatus order.OrderStatus) (*order.BulkStatusReport, error)
+
+	// Guest Orders
+	GuestCheckout(ctx context.Context, input *order.GuestCheckoutInput) (*GuestOrderView, error)
+	LookupGuestOrder(ctx context.Context, token string) (*OrderView, error)
+	RequestGuestOrderClaim(ctx context.Context) (int, error)
+	ConfirmGuestOrderClaim(ctx context.Context, token string) (int64, error)
+
+	// Seller
+	GetSellerOrders(ctx context.Context, filter *order.SellerOrderFilter) ([]*order.SellerOrder, error)
+	GetSellerOrder(ctx context.Context, orderID int64) (*order.SellerOrder, error)
+	ShipSellerOrder(ctx context.Context, orderID int64, shipment *order.ShipmentInput) (*order.SellerOrder, error)
+
+	// Shipments, tracking is added to parcels shipped without it
+	SetShipmentTracking(ctx context.Context, orderID, subOrderID int64, input *order.ShipmentInput) (*order.Shipment, error)
+	SetSellerShipmentTracking(ctx context.Context, orderID int64, input *order.ShipmentInput) (*order.Shipment, error)
+	TrackShipments(ctx context.Context) (int, error)
 }
 
 type orderUsecase struct {
-	orderRepo   orderrepository.OrderRepository
-	productRepo productrepository.ProductRepository
-	cartRepo    cartrepository.CartRepository
-	tx          database.TxManager
-	db          database.DBExec
+	orderRepo     orderrepository.OrderRepository
+	productRepo   productrepository.ProductRepository
+	cartRepo      cartrepository.CartRepository
+	inventoryRepo inventoryrepository.InventoryRepository
+	allocator     inventory.AllocationStrategy
+	token         *jwt.JWTToken
+	notifier      notify.Notifier
+	claimURL      string
+	tracker       carrier.CarrierTracker
+	shipping      shippingusecase.ShippingUsecase
+	tx            database.TxManager
+	db            database.DBExec
 }
 
 func NewOrderUsecase(
 	orderRepo orderrepository.OrderRepository,
 	productRepo productrepository.ProductRepository,
 	cartRepo cartrepository.CartRepository,
+	inventoryRepo inventoryrepository.InventoryRepository,
+	allocator inventory.AllocationStrategy,
+	token *jwt.JWTToken,
+	notifier notify.Notifier,
+	claimURL string,
+	tracker carrier.CarrierTracker,
+	shipping shippingusecase.ShippingUsecase,
 	tx database.TxManager,
 	db database.DBExec,
 ) OrderUsecase {
 	return &orderUsecase{
-		orderRepo:   orderRepo,
-		productRepo: productRepo,
-		cartRepo:    cartRepo,
-		tx:          tx,
-		db:          db,
+		orderRepo:     orderRepo,
+		productRepo:   productRepo,
+		cartRepo:      cartRepo,
+		inventoryRepo: inventoryRepo,
+		allocator:     allocator,
+		token:         token,
+		notifier:      notifier,
+		claimURL:      claimURL,
+		tracker:       tracker,
+		shipping:      shipping,
+		tx:            tx,
+		db:            db,
 	}
 }
 
-func (u *orderUsecase) CreateOrder(ctx context.Context) (*order.Order, error) {
+func (u *orderUsecase) CreateOrder(ctx context.Context, input *order.CreateOrderInput) (*order.Order, error) {
 	userID := auth.GetUserID(ctx)
 	if userID == 0 {
 		return nil, errs.ErrUnauthorized
@@ -62,77 +115,366 @@ func (u *orderUsecase) CreateOrder(ctx context.Context) (*order.Order, error) {
 			return err
 		}
 
-		items, err := u.cartRepo.GetCartItems(ctx, tx, cartData.ID)
+		var destination *inventory.GeoPoint
+		var quoteID string
+		if input != nil {
+			destination, quoteID = input.Destination, input.QuoteID
+		}
+		newOrder, err = u.placeOrder(ctx, tx, cartData, &order.Order{UserID: userID}, destination, quoteID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newOrder, nil
+}
+
+// Quote previews the checkout of the signed in user's cart or the session's
+// guest cart, nothing is locked or reserved
+func (u *orderUsecase) Quote(ctx context.Context, input *order.QuoteInput) (*order.Quote, error) {
+	userID := auth.GetUserID(ctx)
+	sessionID := auth.GetSessionID(ctx)
+	if userID == 0 && sessionID == "" {
+		return nil, errs.ErrCartIsEmpty
+	}
+
+	var quote *order.Quote
+
+	err := u.tx.WithTransaction(ctx, func(tx *sql.Tx) error {
+		var cartData *cart.Cart
+		var err error
+		if userID > 0 {
+			cartData, err = u.cartRepo.GetActiveCartByUserID(ctx, tx, userID)
+			if errors.Is(err, sql.ErrNoRows) {
+				err = errs.ErrCartIsEmpty
+			}
+		} else {
+			cartData, err = u.cartRepo.GetGuestCartBySessionID(ctx, tx, sessionID)
+		}
 		if err != nil {
 			return err
 		}
-		if len(items) == 0 {
-			return errs.ErrCartIsEmpty
+
+		priced, err := u.priceCart(ctx, tx, cartData, &order.Order{UserID: userID}, false)
+		if err != nil {
+			return err
+		}
+
+		// Same warehouse check as the order
+		var destination *inventory.GeoPoint
+		if input != nil {
+			destination = input.Destination
+		}
+		if _, err := u.allocate(ctx, tx, priced.items, destination); err != nil {
+			return err
+		}
+		quote = priced.quote
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	quote.ID, quote.ExpiresAt, err = u.token.GenerateQuoteToken(quote.CartID, quote.Digest())
+	if err != nil {
+		return nil, err
+	}
+	return quote, nil
+}
+
+// placeOrder buyer is the order header without totals, UserID is 0 for
+// guests. Stock, prices & purchase rules are checked under the product locks.
+func (u *orderUsecase) placeOrder(ctx context.Context, tx *sql.Tx, cartData *cart.Cart, buyer *order.Order, destination *inventory.GeoPoint, quoteID string) (*order.Order, error) {
+	userID := buyer.UserID
+
+	priced, err := u.priceCart(ctx, tx, cartData, buyer, true)
+	if err != nil {
+		return nil, err
+	}
+	if quoteID != "" {
+		if err := u.checkQuote(quoteID, priced.quote); err != nil {
+			return nil, err
 		}
+	}
+	items := priced.items
+	lockedProducts := priced.products
+	totalPrice := priced.quote.Total
+	shippingQuote := priced.quote.Shipping
 
-		lockedProducts := make(map[int64]*product.Product) // Map productID -> *Product
-		var totalPrice float64
+	// Pick Warehouses
+	allocation, err := u.allocate(ctx, tx, items, destination)
+	if err != nil {
+		return nil, err
+	}
 
-		// Total Price & Lock Current Product Data
-		for _, i := range items {
-			product, err := u.productRepo.FindByIDForUpdate(ctx, tx, i.ProductID)
-			if err != nil {
-				return err
-			}
-			if product.Stock < i.Quantity {
-				return errs.ErrProductNotEnough
-			}
+	// Create Order
+	orderHeader := buyer
+	orderHeader.Total = totalPrice
+	orderHeader.ShippingMethod = string(shippingQuote.Method)
+	orderHeader.ShippingAmount = shippingQuote.Cost
+	orderHeader.Status = string(order.StatusPending) // Default Status
+	newOrderID, err := u.orderRepo.CreateOrder(ctx, tx, orderHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	// New Order
+	orderHeader.ID = newOrderID
+
+	// Sub Orders (one per seller)
+	subOrderIDs, err := u.createSubOrders(ctx, tx, newOrderID, items, lockedProducts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create Order Items
+	for _, i := range items {
+		// Lock Product Data
+		lockedProduct := lockedProducts[i.ProductID]
 
-			totalPrice += (product.Price * float64(i.Quantity))
+		warehouseID := allocation[i.ProductID]
 
-			lockedProducts[i.ProductID] = product
+		oi := &order.OrderItem{
+			OrderID:         newOrderID,
+			SubOrderID:      subOrderIDs[lockedProduct.OwnerID],
+			ProductID:       i.ProductID,
+			Quantity:        i.Quantity,
+			PriceAtPurchase: lockedProduct.Price, // Current Price
+			WarehouseID:     warehouseID,
+		}
+		if err := u.orderRepo.CreateOrderItem(ctx, tx, oi); err != nil {
+			return nil, err
 		}
 
-		// Create Order
-		orderHeader := &order.Order{
-			UserID: userID,
-			Total:  totalPrice,
-			Status: string(order.StatusPending), // Default Status
+		// Decrease Stock
+		err = u.inventoryRepo.AdjustWarehouseStock(ctx, tx, warehouseID, i.ProductID, -i.Quantity)
+		if err != nil {
+			return nil, err
 		}
-		newOrderID, err := u.orderRepo.CreateOrder(ctx, tx, orderHeader)
+		if err := u.productRepo.DecreaseStock(ctx, tx, i.ProductID, i.Quantity); err != nil {
+			return nil, err
+		}
+		movement := &inventory.StockMovement{
+			ProductID:   i.ProductID,
+			Delta:       -i.Quantity,
+			Reason:      inventory.ReasonOrder,
+			RefType:     inventory.RefOrder,
+			RefID:       newOrderID,
+			ActorID:     userID,
+			WarehouseID: warehouseID,
+		}
+		if err := u.inventoryRepo.InsertMovement(ctx, tx, movement); err != nil {
+			return nil, err
+		}
+		if err := inventory.RecordStockEvents(ctx, tx, u.inventoryRepo, movement, lockedProduct.LowStockThreshold); err != nil {
+			return nil, err
+		}
+	}
+	// Clear Cart
+	if err := u.cartRepo.ClearCart(ctx, tx, cartData.ID); err != nil {
+		return nil, err
+	}
+	return orderHeader, nil
+}
+
+// createSubOrders returns the sub-order ID per seller
+func (u *orderUsecase) createSubOrders(ctx context.Context, tx *sql.Tx, orderID int64, items []*cartrepository.CartItemDB, products map[int64]*product.Product) (map[int64]int64, error) {
+	var subs []*order.SubOrder
+	bySeller := make(map[int64]*order.SubOrder)
+	for _, i := range items {
+		p := products[i.ProductID]
+		sub, ok := bySeller[p.OwnerID]
+		if !ok {
+			sub = &order.SubOrder{
+				OrderID:  orderID,
+				SellerID: p.OwnerID,
+				Status:   string(order.StatusPending),
+			}
+			bySeller[p.OwnerID] = sub
+			subs = append(subs, sub)
+		}
+		sub.Total += p.Price * float64(i.Quantity)
+	}
+
+	ids := make(map[int64]int64, len(subs))
+	for _, sub := range subs {
+		id, err := u.orderRepo.CreateSubOrder(ctx, tx, sub)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		ids[sub.SellerID] = id
+	}
+	return ids, nil
+}
 
-		// New Order
-		orderHeader.ID = newOrderID
-		newOrder = orderHeader
+type pricedCart struct {
+	items    []*cartrepository.CartItemDB
+	products map[int64]*product.Product // Map productID -> *Product
+	quote    *order.Quote
+}
 
-		// Create Order Items
-		for _, i := range items {
-			// Lock Product Data
-			lockedProduct := lockedProducts[i.ProductID]
+// priceCart the checkout computation shared by orders & quotes, products
+// are locked when placing the order and only read for a quote
+func (u *orderUsecase) priceCart(ctx context.Context, tx *sql.Tx, cartData *cart.Cart, buyer *order.Order, lock bool) (*pricedCart, error) {
+	userID := buyer.UserID
 
-			oi := &order.OrderItem{
-				OrderID:         newOrderID,
-				ProductID:       i.ProductID,
-				Quantity:        i.Quantity,
-				PriceAtPurchase: lockedProduct.Price, // Current Price
-			}
-			if err := u.orderRepo.CreateOrderItem(ctx, tx, oi); err != nil {
-				return err
-			}
+	items, err := u.cartRepo.GetCartItems(ctx, tx, cartData.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, errs.ErrCartIsEmpty
+	}
 
-			// Decrease Stock
-			if err := u.productRepo.DecreaseStock(ctx, tx, i.ProductID, i.Quantity); err != nil {
-				return err
+	priced := &pricedCart{
+		items:    items,
+		products: make(map[int64]*product.Product),
+		quote:    &order.Quote{CartID: cartData.ID, Lines: make([]*order.QuoteLine, 0, len(items))},
+	}
+	var changed []*cart.ChangedItem
+
+	// Total Price & Lock Current Product Data
+	for _, i := range items {
+		var product *product.Product
+		if lock {
+			product, err = u.productRepo.FindByIDForUpdate(ctx, tx, i.ProductID)
+		} else {
+			product, err = u.productRepo.FindByID(ctx, i.ProductID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !product.IsAvailable() {
+			return nil, errs.ErrProductUnavailable
+		}
+		// Stock held by other carts is not available,
+		// this cart's holds are released with ClearCart
+		reserved, err := u.inventoryRepo.ReservedQuantity(ctx, tx, i.ProductID, cartData.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		purchased := 0
+		if product.Rules.CustomerLimit > 0 {
+			if userID > 0 {
+				purchased, err = u.productRepo.PurchasedQuantity(ctx, tx, userID, i.ProductID)
+			} else if buyer.GuestEmail != "" {
+				purchased, err = u.productRepo.GuestPurchasedQuantity(ctx, tx, buyer.GuestEmail, i.ProductID)
+			}
+			if err != nil {
+				return nil, err
 			}
 		}
-		// Clear Cart
-		if err := u.cartRepo.ClearCart(ctx, tx, cartData.ID); err != nil {
-			return err
+		if err := product.Rules.Check(i.ProductID, i.Quantity, purchased); err != nil {
+			return nil, err
 		}
-		return nil
-	})
+
+		// Never charge a price or quantity the user has not seen,
+		// POST /cart/accept-changes takes the current values
+		available := product.Stock - reserved
+		if available < i.Quantity || math.Abs(i.PriceAtAdd-product.Price) > 0 {
+			changed = append(changed, &cart.ChangedItem{
+				CartItemID: i.CartItemID,
+				ProductID:  i.ProductID,
+				Name:       product.Name,
+				PriceAtAdd: i.PriceAtAdd,
+				Price:      product.Price,
+				Quantity:   i.Quantity,
+				Available:  max(available, 0),
+			})
+		}
+
+		lineTotal := product.Price * float64(i.Quantity)
+		priced.quote.Lines = append(priced.quote.Lines, &order.QuoteLine{
+			CartItemID: i.CartItemID,
+			ProductID:  i.ProductID,
+			Name:       product.Name,
+			Quantity:   i.Quantity,
+			UnitPrice:  product.Price,
+			LineTotal:  lineTotal,
+		})
+		priced.quote.Subtotal += lineTotal
+
+		priced.products[i.ProductID] = product
+	}
+	if len(changed) > 0 {
+		return nil, &cart.ChangesError{Items: changed}
+	}
+
+	// Shipping of the cart's method, guests ship to their address
+	priced.quote.Shipping, err = u.priceShipping(ctx, cartData, buyer, priced)
 	if err != nil {
 		return nil, err
 	}
-	return newOrder, nil
+
+	// No discount or tax rules yet, both are part of the digest so quotes
+	// priced before a rule is added no longer match
+	priced.quote.Discount = 0
+	priced.quote.Tax = 0
+	priced.quote.Total = priced.quote.Subtotal - priced.quote.Discount + priced.quote.Shipping.Cost + priced.quote.Tax
+	return priced, nil
+}
+
+func (u *orderUsecase) priceShipping(ctx context.Context, cartData *cart.Cart, buyer *order.Order, priced *pricedCart) (*shipping.Quote, error) {
+	req := &shipping.Request{
+		Method:   shipping.Method(cartData.ShippingMethod),
+		Country:  cartData.ShippingCountry.String,
+		Subtotal: priced.quote.Subtotal,
+	}
+	if buyer.Shipping != nil && buyer.Shipping.Country != "" {
+		req.Country = buyer.Shipping.Country
+	}
+	for _, i := range priced.items {
+		req.Items = append(req.Items, &shipping.Item{
+			Quantity: i.Quantity,
+			Parcel:   priced.products[i.ProductID].Parcel,
+		})
+	}
+	return u.shipping.Price(ctx, req)
+}
+
+// checkQuote the quote must be for this cart & still match its pricing
+func (u *orderUsecase) checkQuote(quoteID string, current *order.Quote) error {
+	claims, err := u.token.VerifyQuoteToken(quoteID)
+	if err != nil {
+		return errs.ErrQuoteInvalid
+	}
+	if claims.CartID != current.CartID {
+		return errs.ErrQuoteInvalid
+	}
+	if claims.Digest != current.Digest() {
+		return errs.ErrQuoteChanged
+	}
+	return nil
+}
+
+// allocate picks a warehouse per cart line, products are already locked
+// so the warehouse levels cannot change until commit
+func (u *orderUsecase) allocate(ctx context.Context, tx *sql.Tx, items []*cartrepository.CartItemDB, destination *inventory.GeoPoint) (inventory.Allocation, error) {
+	warehouses, err := u.inventoryRepo.ListWarehouses(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]inventory.AllocationLine, 0, len(items))
+	productIDs := make([]int64, 0, len(items))
+	for _, i := range items {
+		lines = append(lines, inventory.AllocationLine{ProductID: i.ProductID, Quantity: i.Quantity})
+		productIDs = append(productIDs, i.ProductID)
+	}
+
+	levels, err := u.inventoryRepo.StockLevels(ctx, tx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &inventory.AllocationRequest{
+		Lines:       lines,
+		Warehouses:  warehouses,
+		Levels:      levels,
+		Destination: destination,
+	}
+	return u.allocator.Allocate(req)
 }
 
 func (u *orderUsecase) GetOrderDetail(ctx context.Context, orderID int64) (*OrderView, error) {
@@ -150,7 +492,10 @@ func (u *orderUsecase) GetOrderDetail(ctx context.Context, orderID int64) (*Orde
 	if orderData.UserID != userID {
 		return nil, errs.ErrNoPermissions
 	}
+	return u.orderView(ctx, orderData)
+}
 
+func (u *orderUsecase) orderView(ctx context.Context, orderData *order.Order) (*OrderView, error) {
 	// Get Items
 	itemsData, err := u.orderRepo.GetOrderItems(ctx, u.db, orderData.ID)
 	if err != nil {
@@ -168,15 +513,44 @@ func (u *orderUsecase) GetOrderDetail(ctx context.Context, orderID int64) (*Orde
 			PriceAtPurchase: i.PriceAtPurchase,
 			Quantity:        i.Quantity,
 			Total:           i.PriceAtPurchase * float64(i.Quantity),
+			Warehouse:       i.WarehouseCode,
+			SubOrderID:      i.SubOrderID,
+		})
+	}
+
+	// Get Sub Orders
+	subsData, err := u.orderRepo.ListSubOrders(ctx, u.db, orderData.ID)
+	if err != nil {
+		return nil, err
+	}
+	subViews := make([]*SubOrderView, 0, len(subsData))
+	for _, s := range subsData {
+		subViews = append(subViews, &SubOrderView{
+			ID:       s.ID,
+			SellerID: s.SellerID,
+			Status:   s.Status,
+			Total:    s.Total,
 		})
 	}
 
+	// Get Shipments
+	shipments, err := u.orderRepo.ListShipments(ctx, u.db, orderData.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	return &OrderView{
 		ID:        orderData.ID,
 		Status:    orderData.Status,
 		Total:     orderData.Total,
 		CreatedAt: orderData.CreatedAt.Format(time.RFC3339),
 		Items:     itemViews,
+		SubOrders: subViews,
+		Shipments: shipments,
+		Shipping:  orderData.Shipping,
+
+		ShippingMethod: orderData.ShippingMethod,
+		ShippingAmount: orderData.ShippingAmount,
 	}, nil
 }
 
@@ -226,23 +600,47 @@ func (u *orderUsecase) CancelOrder(ctx context.Context, orderID int64) error {
 	}
 
 	return u.tx.WithTransaction(ctx, func(tx *sql.Tx) error {
-		// Update Order Status
-		err := u.orderRepo.UpdateStatus(ctx, tx, orderID, string(order.StatusCancelled))
+		// Paid in the meantime
+		status, err := u.orderRepo.GetStatusForUpdate(ctx, tx, orderID)
 		if err != nil {
 			return err
 		}
+		if status != order.StatusPending {
+			return errs.ErrCannotCancelOrder
+		}
 
-		// Return Items
-		err = u.returnItemToStock(ctx, tx, orderID)
-		if err != nil {
+		// Cancel Sub Orders & Return Items
+		return u.moveSubOrders(ctx, tx, orderID, userID, order.StatusCancelled, nil, nil)
+	})
+}
+
+// UpdateOrderStatus moves every sub-order that can make the change, e.g.
+// shipped ships the sub-orders still paid, each in a shipment
+func (u *orderUsecase) UpdateOrderStatus(ctx context.Context, orderID int64, newStatus order.OrderStatus, shipment *order.ShipmentInput) error {
+	// Check Permissions
+	currentUser, err := auth.GetCurrentUser(ctx)
+	if err != nil {
+		return errs.ErrUnauthorized
+	}
+	if currentUser.Role != string(user.RoleAdmin) {
+		return errs.ErrNoPermissions
+	}
+	return u.updateOrderStatus(ctx, orderID, currentUser.ID, newStatus, shipment)
+}
+
+// updateOrderStatus one order in its own transaction
+func (u *orderUsecase) updateOrderStatus(ctx context.Context, orderID, actorID int64, newStatus order.OrderStatus, shipment *order.ShipmentInput) error {
+	return u.tx.WithTransaction(ctx, func(tx *sql.Tx) error {
+		// Lock Order
+		if _, err := u.orderRepo.GetStatusForUpdate(ctx, tx, orderID); err != nil {
 			return err
 		}
-		return nil
+		return u.moveSubOrders(ctx, tx, orderID, actorID, newStatus, nil, shipment)
 	})
 }
 
-func (u *orderUsecase) UpdateOrderStatus(ctx context.Context, orderID int64, newStatus order.OrderStatus) error {
-	// Check Permissions
+// UpdateSubOrderStatus admin change of one seller's part
+func (u *orderUsecase) UpdateSubOrderStatus(ctx context.Context, orderID, subOrderID int64, newStatus order.OrderStatus, shipment *order.ShipmentInput) error {
 	currentUser, err := auth.GetCurrentUser(ctx)
 	if err != nil {
 		return errs.ErrUnauthorized
@@ -251,33 +649,81 @@ func (u *orderUsecase) UpdateOrderStatus(ctx context.Context, orderID int64, new
 		return errs.ErrNoPermissions
 	}
 
-	// Get Order
-	orderData, err := u.orderRepo.GetOrder(ctx, orderID)
+	return u.tx.WithTransaction(ctx, func(tx *sql.Tx) error {
+		if _, err := u.orderRepo.GetStatusForUpdate(ctx, tx, orderID); err != nil {
+			return err
+		}
+		return u.moveSubOrders(ctx, tx, orderID, currentUser.ID, newStatus, &subOrderID, shipment)
+	})
+}
+
+// moveSubOrders changes the sub-orders of a locked order, all of them or
+// only subOrderID, and derives the order status. Cancelled sub-orders go
+// back to stock, paid ones are left to the refunds that pay the customer
+// back. Shipped ones get a shipment. The optional details belong
+// to one parcel, they are only kept when a single sub-order ships.
+func (u *orderUsecase) moveSubOrders(ctx context.Context, tx *sql.Tx, orderID, actorID int64, newStatus order.OrderStatus, subOrderID *int64, shipment *order.ShipmentInput) error {
+	subs, err := u.orderRepo.ListSubOrders(ctx, tx, orderID)
 	if err != nil {
 		return err
 	}
 
-	// Validate Status
-	if !u.validateStatus(order.OrderStatus(orderData.Status), newStatus) {
+	found, paid := false, false
+	moved := make(map[int64]bool)
+	var shipped []*order.SubOrder
+	for _, s := range subs {
+		if subOrderID != nil && s.ID != *subOrderID {
+			continue
+		}
+		found = true
+
+		// Validate Status
+		if !u.validateStatus(order.OrderStatus(s.Status), newStatus) {
+			paid = paid || s.Status == string(order.StatusPaid)
+			continue
+		}
+		if err := u.orderRepo.UpdateSubOrderStatus(ctx, tx, s.ID, string(newStatus)); err != nil {
+			return err
+		}
+		s.Status = string(newStatus)
+		moved[s.ID] = true
+
+		if newStatus == order.StatusShipped {
+			shipped = append(shipped, s)
+		}
+	}
+	if !found {
+		if subOrderID != nil {
+			return errs.ErrSubOrderNotFound
+		}
+		return errs.ErrOrderNotFound
+	}
+	if len(moved) == 0 {
+		if paid && newStatus == order.StatusCancelled {
+			return errs.ErrCancelPaidOrder
+		}
 		return errs.ErrInvalidStatusChange
 	}
 
-	return u.tx.WithTransaction(ctx, func(tx *sql.Tx) error {
-		// Update Status
-		err := u.orderRepo.UpdateStatus(ctx, tx, orderID, string(newStatus))
-		if err != nil {
+	// Create Shipments (Shipped Only)
+	if len(shipped) > 1 {
+		shipment = nil
+	}
+	for _, s := range shipped {
+		if err := u.createShipment(ctx, tx, s, shipment); err != nil {
 			return err
 		}
+	}
 
-		// Return Items (Cancelled Only)
-		if newStatus == order.StatusCancelled {
-			err = u.returnItemToStock(ctx, tx, orderID)
-			if err != nil {
-				return err
-			}
+	// Return Items (Cancelled Only)
+	if newStatus == order.StatusCancelled {
+		if err := u.returnItemToStock(ctx, tx, orderID, actorID, moved); err != nil {
+			return err
 		}
-		return nil
-	})
+	}
+
+	// Update Order Status
+	return u.orderRepo.UpdateStatus(ctx, tx, orderID, string(order.DeriveStatus(subs)))
 }
 
 func (u *orderUsecase) validateStatus(oldStatus, newStatus order.OrderStatus) bool {
@@ -290,19 +736,20 @@ func (u *orderUsecase) validateStatus(oldStatus, newStatus order.OrderStatus) bo
 		// pending -> paid or cancelled
 		return newStatus == order.StatusPaid || newStatus == order.StatusCancelled
 	case order.StatusPaid:
-		// paid -> shipped or cancelled
-		return newStatus == order.StatusShipped || newStatus == order.StatusCancelled
+		// paid -> shipped, a full refund cancels it
+		return newStatus == order.StatusShipped
 	case order.StatusShipped:
 		// shipped -> completed
 		return newStatus == order.StatusCompleted
-	case order.StatusCancelled, order.StatusCompleted:
-		// cancelled, completed end process cannot update
+	case order.StatusCancelled, order.StatusCompleted, order.StatusRefunded:
+		// cancelled, completed, refunded end process cannot update
 		return false
 	}
 	return false
 }
 
-func (u *orderUsecase) returnItemToStock(ctx context.Context, tx *sql.Tx, orderID int64) error {
+// returnItemToStock items of the given sub-orders
+func (u *orderUsecase) returnItemToStock(ctx context.Context, tx *sql.Tx, orderID int64, actorID int64, subOrderIDs map[int64]bool) error {
 	// Get Items
 	items, err := u.orderRepo.GetOrderItems(ctx, tx, orderID)
 	if err != nil {
@@ -310,10 +757,44 @@ func (u *orderUsecase) returnItemToStock(ctx context.Context, tx *sql.Tx, orderI
 	}
 
 	for _, i := range items {
-		err := u.productRepo.IncreaseStock(ctx, tx, i.ProductID, i.Quantity)
+		if !subOrderIDs[i.SubOrderID] {
+			continue
+		}
+
+		// Back to the warehouse it was picked from
+		warehouseID := i.WarehouseID
+		if warehouseID == 0 {
+			w, err := u.inventoryRepo.DefaultWarehouse(ctx, tx)
+			if err != nil {
+				return err
+			}
+			warehouseID = w.ID
+		}
+		err := u.inventoryRepo.AdjustWarehouseStock(ctx, tx, warehouseID, i.ProductID, i.Quantity)
 		if err != nil {
 			return err
 		}
+
+		err = u.productRepo.IncreaseStock(ctx, tx, i.ProductID, i.Quantity)
+		if err != nil {
+			return err
+		}
+		movement := &inventory.StockMovement{
+			ProductID:   i.ProductID,
+			Delta:       i.Quantity,
+			Reason:      inventory.ReasonCancel,
+			RefType:     inventory.RefOrder,
+			RefID:       orderID,
+			ActorID:     actorID,
+			WarehouseID: warehouseID,
+		}
+		if err := u.inventoryRepo.InsertMovement(ctx, tx, movement); err != nil {
+			return err
+		}
+		// Increase only, the threshold is not needed
+		if err := inventory.RecordStockEvents(ctx, tx, u.inventoryRepo, movement, 0); err != nil {
+			return err
+		}
 	}
 	return nil
 }
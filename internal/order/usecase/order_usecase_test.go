@@ -9,14 +9,24 @@ import (
 
 	"github.com/codepnw/mini-ecommerce/internal/cart"
 	cartrepository "github.com/codepnw/mini-ecommerce/internal/cart/repository"
+	"github.com/codepnw/mini-ecommerce/internal/inventory"
+	inventoryrepository "github.com/codepnw/mini-ecommerce/internal/inventory/repository"
 	"github.com/codepnw/mini-ecommerce/internal/order"
 	orderrepository "github.com/codepnw/mini-ecommerce/internal/order/repository"
 	orderusecase "github.com/codepnw/mini-ecommerce/internal/order/usecase"
 	"github.com/codepnw/mini-ecommerce/internal/product"
 	productrepository "github.com/codepnw/mini-ecommerce/internal/product/repository"
+	"github.com/codepnw/mini-ecommerce/internal/shipping"
+	shippingrepository "github.com/codepnw/mini-ecommerce/internal/shipping/repository"
+	shippingusecase "github.com/codepnw/mini-ecommerce/internal/shipping/usecase"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
 	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
 	"github.com/codepnw/mini-ecommerce/pkg/auth"
+	"github.com/codepnw/mini-ecommerce/pkg/carrier"
+	"github.com/codepnw/mini-ecommerce/pkg/config"
+	"github.com/codepnw/mini-ecommerce/pkg/database"
 	"github.com/codepnw/mini-ecommerce/pkg/jwt"
+	"github.com/codepnw/mini-ecommerce/pkg/notify"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 )
@@ -38,8 +48,8 @@ func TestCreateOrder(t *testing.T) {
 				cartRepo.EXPECT().GetActiveCartByUserID(gomock.Any(), gomock.Any(), userID).Return(mockCart, nil).Times(1)
 
 				mockItems := []*cartrepository.CartItemDB{
-					{CartItemID: 100, ProductID: 1, Price: 100, Quantity: 2},
-					{CartItemID: 101, ProductID: 2, Price: 80, Quantity: 2},
+					{CartItemID: 100, ProductID: 1, Price: 100, PriceAtAdd: 100, Quantity: 2},
+					{CartItemID: 101, ProductID: 2, Price: 80, PriceAtAdd: 80, Quantity: 2},
 				}
 				cartRepo.EXPECT().GetCartItems(gomock.Any(), gomock.Any(), mockCart.ID).Return(mockItems, nil).Times(1)
 
@@ -47,9 +57,10 @@ func TestCreateOrder(t *testing.T) {
 
 				for _, i := range mockItems {
 					mockProduct := &product.Product{
-						ID:    i.ProductID,
-						Price: i.Price,
-						Stock: 100,
+						ID:     i.ProductID,
+						Price:  i.Price,
+						Stock:  100,
+						Status: product.StatusPublished,
 					}
 					prodRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), i.ProductID).Return(mockProduct, nil).Times(1)
 					expectedTotal += (i.Price * float64(i.Quantity))
@@ -60,16 +71,29 @@ func TestCreateOrder(t *testing.T) {
 					UserID: mockCart.UserID.Int64,
 					Total:  expectedTotal,
 					Status: string(order.StatusPending),
+
+					ShippingMethod: string(shipping.MethodStandard),
 				}
 				orderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), mockOrderHeader).Return(mockOrderID, nil).Times(1)
 
+				// Single seller, one sub-order
+				var mockSubOrderID int64 = 5
+				mockSubOrder := &order.SubOrder{
+					OrderID: mockOrderID,
+					Total:   expectedTotal,
+					Status:  string(order.StatusPending),
+				}
+				orderRepo.EXPECT().CreateSubOrder(gomock.Any(), gomock.Any(), mockSubOrder).Return(mockSubOrderID, nil).Times(1)
+
 				// Create Order Items
 				for _, i := range mockItems {
 					mockOI := &order.OrderItem{
 						OrderID:         mockOrderID,
+						SubOrderID:      mockSubOrderID,
 						ProductID:       i.ProductID,
 						Quantity:        i.Quantity,
 						PriceAtPurchase: i.Price,
+						WarehouseID:     mockWarehouse().ID,
 					}
 					orderRepo.EXPECT().CreateOrderItem(gomock.Any(), gomock.Any(), mockOI).Return(nil).Times(1)
 
@@ -87,15 +111,16 @@ func TestCreateOrder(t *testing.T) {
 				cartRepo.EXPECT().GetActiveCartByUserID(gomock.Any(), gomock.Any(), userID).Return(mockCart, nil).Times(1)
 
 				mockItems := []*cartrepository.CartItemDB{
-					{CartItemID: 100, ProductID: 1, Price: 100, Quantity: 2},
+					{CartItemID: 100, ProductID: 1, Price: 100, PriceAtAdd: 100, Quantity: 2},
 				}
 				cartRepo.EXPECT().GetCartItems(gomock.Any(), gomock.Any(), mockCart.ID).Return(mockItems, nil).Times(1)
 
 				for _, i := range mockItems {
 					mockProduct := &product.Product{
-						ID:    i.ProductID,
-						Price: i.Price,
-						Stock: 1,
+						ID:     i.ProductID,
+						Price:  i.Price,
+						Stock:  1,
+						Status: product.StatusPublished,
 					}
 					prodRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), i.ProductID).Return(mockProduct, nil).Times(1)
 				}
@@ -110,8 +135,8 @@ func TestCreateOrder(t *testing.T) {
 				cartRepo.EXPECT().GetActiveCartByUserID(gomock.Any(), gomock.Any(), userID).Return(mockCart, nil).Times(1)
 
 				mockItems := []*cartrepository.CartItemDB{
-					{CartItemID: 100, ProductID: 1, Price: 100, Quantity: 2},
-					{CartItemID: 101, ProductID: 2, Price: 80, Quantity: 2},
+					{CartItemID: 100, ProductID: 1, Price: 100, PriceAtAdd: 100, Quantity: 2},
+					{CartItemID: 101, ProductID: 2, Price: 80, PriceAtAdd: 80, Quantity: 2},
 				}
 				cartRepo.EXPECT().GetCartItems(gomock.Any(), gomock.Any(), mockCart.ID).Return(mockItems, nil).Times(1)
 
@@ -119,9 +144,10 @@ func TestCreateOrder(t *testing.T) {
 
 				for _, i := range mockItems {
 					mockProduct := &product.Product{
-						ID:    i.ProductID,
-						Price: i.Price,
-						Stock: 100,
+						ID:     i.ProductID,
+						Price:  i.Price,
+						Stock:  100,
+						Status: product.StatusPublished,
 					}
 					prodRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), i.ProductID).Return(mockProduct, nil).Times(1)
 					expectedTotal += (i.Price * float64(i.Quantity))
@@ -132,16 +158,29 @@ func TestCreateOrder(t *testing.T) {
 					UserID: mockCart.UserID.Int64,
 					Total:  expectedTotal,
 					Status: string(order.StatusPending),
+
+					ShippingMethod: string(shipping.MethodStandard),
 				}
 				orderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), mockOrderHeader).Return(mockOrderID, nil).Times(1)
 
+				// Single seller, one sub-order
+				var mockSubOrderID int64 = 5
+				mockSubOrder := &order.SubOrder{
+					OrderID: mockOrderID,
+					Total:   expectedTotal,
+					Status:  string(order.StatusPending),
+				}
+				orderRepo.EXPECT().CreateSubOrder(gomock.Any(), gomock.Any(), mockSubOrder).Return(mockSubOrderID, nil).Times(1)
+
 				// Create Order Items
 				for _, i := range mockItems {
 					mockOI := &order.OrderItem{
 						OrderID:         mockOrderID,
+						SubOrderID:      mockSubOrderID,
 						ProductID:       i.ProductID,
 						Quantity:        i.Quantity,
 						PriceAtPurchase: i.Price,
+						WarehouseID:     mockWarehouse().ID,
 					}
 					orderRepo.EXPECT().CreateOrderItem(gomock.Any(), gomock.Any(), mockOI).Return(nil).Times(1)
 
@@ -165,7 +204,7 @@ func TestCreateOrder(t *testing.T) {
 				ctx = auth.SetUserID(ctx, tc.userID)
 			}
 
-			result, err := uc.CreateOrder(ctx)
+			result, err := uc.CreateOrder(ctx, &order.CreateOrderInput{})
 
 			if tc.expectedErr != nil {
 				assert.Error(t, err)
@@ -177,6 +216,601 @@ func TestCreateOrder(t *testing.T) {
 	}
 }
 
+func TestCreateOrderSplitsBySeller(t *testing.T) {
+	uc, orderRepo, prodRepo, cartRepo := setup(t)
+
+	mockCart := &cart.Cart{ID: "cart-001", UserID: sql.NullInt64{Int64: 10}}
+	cartRepo.EXPECT().GetActiveCartByUserID(gomock.Any(), gomock.Any(), int64(10)).Return(mockCart, nil).Times(1)
+
+	mockItems := []*cartrepository.CartItemDB{
+		{CartItemID: 100, ProductID: 1, Price: 100, PriceAtAdd: 100, Quantity: 2},
+		{CartItemID: 101, ProductID: 2, Price: 80, PriceAtAdd: 80, Quantity: 1},
+		{CartItemID: 102, ProductID: 3, Price: 50, PriceAtAdd: 50, Quantity: 1},
+	}
+	cartRepo.EXPECT().GetCartItems(gomock.Any(), gomock.Any(), mockCart.ID).Return(mockItems, nil).Times(1)
+
+	// Product 1 & 3 seller 20, product 2 seller 21
+	owners := map[int64]int64{1: 20, 2: 21, 3: 20}
+	for _, i := range mockItems {
+		prodRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), i.ProductID).Return(&product.Product{
+			ID:      i.ProductID,
+			OwnerID: owners[i.ProductID],
+			Price:   i.Price,
+			Stock:   100,
+			Status:  product.StatusPublished,
+		}, nil).Times(1)
+		prodRepo.EXPECT().DecreaseStock(gomock.Any(), gomock.Any(), i.ProductID, i.Quantity).Return(nil).Times(1)
+	}
+
+	orderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil).Times(1)
+	orderRepo.EXPECT().CreateSubOrder(gomock.Any(), gomock.Any(), &order.SubOrder{
+		OrderID:  1,
+		SellerID: 20,
+		Total:    250,
+		Status:   string(order.StatusPending),
+	}).Return(int64(5), nil).Times(1)
+	orderRepo.EXPECT().CreateSubOrder(gomock.Any(), gomock.Any(), &order.SubOrder{
+		OrderID:  1,
+		SellerID: 21,
+		Total:    80,
+		Status:   string(order.StatusPending),
+	}).Return(int64(6), nil).Times(1)
+
+	expectedSubOrder := map[int64]int64{1: 5, 2: 6, 3: 5}
+	orderRepo.EXPECT().CreateOrderItem(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ *sql.Tx, oi *order.OrderItem) error {
+			assert.Equal(t, expectedSubOrder[oi.ProductID], oi.SubOrderID)
+			return nil
+		},
+	).Times(len(mockItems))
+	cartRepo.EXPECT().ClearCart(gomock.Any(), gomock.Any(), mockCart.ID).Return(nil).Times(1)
+
+	result, err := uc.CreateOrder(auth.SetUserID(context.Background(), 10), &order.CreateOrderInput{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, float64(330), result.Total)
+}
+
+func TestCreateOrderReservedByOtherCarts(t *testing.T) {
+	uc, _, prodRepo, cartRepo, invRepo := setupWithInventory(t)
+
+	mockCart := &cart.Cart{ID: "cart-001", UserID: sql.NullInt64{Int64: 10}}
+	cartRepo.EXPECT().GetActiveCartByUserID(gomock.Any(), gomock.Any(), int64(10)).Return(mockCart, nil).Times(1)
+
+	mockItems := []*cartrepository.CartItemDB{
+		{CartItemID: 100, ProductID: 1, Price: 100, PriceAtAdd: 100, Quantity: 2},
+	}
+	cartRepo.EXPECT().GetCartItems(gomock.Any(), gomock.Any(), mockCart.ID).Return(mockItems, nil).Times(1)
+
+	mockProduct := &product.Product{ID: 1, Price: 100, Stock: 5, Status: product.StatusPublished}
+	prodRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), int64(1)).Return(mockProduct, nil).Times(1)
+	// 4 of 5 held by other carts
+	invRepo.EXPECT().ReservedQuantity(gomock.Any(), gomock.Any(), int64(1), mockCart.ID).Return(4, nil).Times(1)
+
+	result, err := uc.CreateOrder(auth.SetUserID(context.Background(), 10), &order.CreateOrderInput{})
+
+	assert.ErrorIs(t, err, errs.ErrCartChanged)
+	assert.Nil(t, result)
+
+	var changesErr *cart.ChangesError
+	if assert.ErrorAs(t, err, &changesErr) {
+		assert.Len(t, changesErr.Items, 1)
+		assert.Equal(t, 1, changesErr.Items[0].Available)
+	}
+}
+
+func TestCreateOrderUnacceptedChanges(t *testing.T) {
+	uc, orderRepo, prodRepo, cartRepo, invRepo := setupWithInventory(t)
+
+	mockCart := &cart.Cart{ID: "cart-001", UserID: sql.NullInt64{Int64: 10}}
+	cartRepo.EXPECT().GetActiveCartByUserID(gomock.Any(), gomock.Any(), int64(10)).Return(mockCart, nil).Times(1)
+
+	mockItems := []*cartrepository.CartItemDB{
+		{CartItemID: 100, ProductID: 1, Price: 120, PriceAtAdd: 100, Quantity: 2},
+		{CartItemID: 101, ProductID: 2, Price: 80, PriceAtAdd: 80, Quantity: 2},
+	}
+	cartRepo.EXPECT().GetCartItems(gomock.Any(), gomock.Any(), mockCart.ID).Return(mockItems, nil).Times(1)
+
+	// Price went up after the item was added
+	prodRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), int64(1)).Return(
+		&product.Product{ID: 1, Name: "Macbook", Price: 120, Stock: 10, Status: product.StatusPublished}, nil,
+	).Times(1)
+	prodRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), int64(2)).Return(
+		&product.Product{ID: 2, Price: 80, Stock: 10, Status: product.StatusPublished}, nil,
+	).Times(1)
+	invRepo.EXPECT().ReservedQuantity(gomock.Any(), gomock.Any(), gomock.Any(), mockCart.ID).Return(0, nil).Times(2)
+	orderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	result, err := uc.CreateOrder(auth.SetUserID(context.Background(), 10), &order.CreateOrderInput{})
+
+	assert.Nil(t, result)
+	var changesErr *cart.ChangesError
+	if assert.ErrorAs(t, err, &changesErr) {
+		assert.Len(t, changesErr.Items, 1)
+		assert.Equal(t, int64(100), changesErr.Items[0].CartItemID)
+		assert.Equal(t, 100.0, changesErr.Items[0].PriceAtAdd)
+		assert.Equal(t, 120.0, changesErr.Items[0].Price)
+	}
+}
+
+func TestCreateOrderPurchaseRules(t *testing.T) {
+	uc, orderRepo, prodRepo, cartRepo, invRepo := setupWithInventory(t)
+
+	mockCart := &cart.Cart{ID: "cart-001", UserID: sql.NullInt64{Int64: 10}}
+	cartRepo.EXPECT().GetActiveCartByUserID(gomock.Any(), gomock.Any(), int64(10)).Return(mockCart, nil).Times(1)
+
+	mockItems := []*cartrepository.CartItemDB{
+		{CartItemID: 100, ProductID: 1, Price: 100, PriceAtAdd: 100, Quantity: 2},
+	}
+	cartRepo.EXPECT().GetCartItems(gomock.Any(), gomock.Any(), mockCart.ID).Return(mockItems, nil).Times(1)
+
+	// Bought 3 of 4 in earlier orders
+	prodRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), int64(1)).Return(
+		&product.Product{ID: 1, Price: 100, Stock: 10, Status: product.StatusPublished, Rules: product.PurchaseRules{CustomerLimit: 4}}, nil,
+	).Times(1)
+	invRepo.EXPECT().ReservedQuantity(gomock.Any(), gomock.Any(), gomock.Any(), mockCart.ID).Return(0, nil).Times(1)
+	prodRepo.EXPECT().PurchasedQuantity(gomock.Any(), gomock.Any(), int64(10), int64(1)).Return(3, nil).Times(1)
+	orderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	result, err := uc.CreateOrder(auth.SetUserID(context.Background(), 10), &order.CreateOrderInput{})
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, errs.ErrPurchaseRule)
+	var ruleErr *product.RuleError
+	if assert.ErrorAs(t, err, &ruleErr) {
+		assert.Equal(t, product.RuleCustomerLimit, ruleErr.Rule)
+		assert.Equal(t, 3, ruleErr.Purchased)
+	}
+}
+
+func TestCreateOrderShipping(t *testing.T) {
+	type testCase struct {
+		name           string
+		cart           *cart.Cart
+		expectedMethod string
+		expectedAmount float64
+		expectedErr    error
+	}
+
+	testCases := []testCase{
+		{
+			name: "success express",
+			cart: &cart.Cart{ID: "cart-001", ShippingMethod: string(shipping.MethodExpress)},
+			// 3 kg, 2 kg over the first
+			expectedMethod: string(shipping.MethodExpress),
+			expectedAmount: 160,
+		},
+		{
+			name: "success international",
+			cart: &cart.Cart{
+				ID:              "cart-001",
+				ShippingMethod:  string(shipping.MethodStandard),
+				ShippingCountry: sql.NullString{String: "US", Valid: true},
+			},
+			expectedMethod: string(shipping.MethodStandard),
+			expectedAmount: 1200,
+		},
+		{
+			name:        "fail method unavailable",
+			cart:        &cart.Cart{ID: "cart-001", ShippingMethod: string(shipping.MethodPickup), ShippingCountry: sql.NullString{String: "US", Valid: true}},
+			expectedErr: errs.ErrShippingUnavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, orderRepo, prodRepo, cartRepo := setup(t)
+
+			cartRepo.EXPECT().GetActiveCartByUserID(gomock.Any(), gomock.Any(), int64(10)).Return(tc.cart, nil).Times(1)
+			cartRepo.EXPECT().GetCartItems(gomock.Any(), gomock.Any(), tc.cart.ID).Return([]*cartrepository.CartItemDB{
+				{CartItemID: 100, ProductID: 1, Price: 100, PriceAtAdd: 100, Quantity: 2},
+			}, nil).Times(1)
+			prodRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), int64(1)).Return(&product.Product{
+				ID:     1,
+				Price:  100,
+				Stock:  10,
+				Status: product.StatusPublished,
+				Parcel: product.Parcel{WeightGrams: 1500},
+			}, nil).Times(1)
+
+			if tc.expectedErr != nil {
+				orderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+				result, err := uc.CreateOrder(auth.SetUserID(context.Background(), 10), &order.CreateOrderInput{})
+
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+				return
+			}
+
+			orderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ *sql.Tx, o *order.Order) (int64, error) {
+					assert.Equal(t, tc.expectedMethod, o.ShippingMethod)
+					assert.Equal(t, tc.expectedAmount, o.ShippingAmount)
+					assert.Equal(t, 200+tc.expectedAmount, o.Total)
+					return 1, nil
+				},
+			).Times(1)
+			orderRepo.EXPECT().CreateSubOrder(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(5), nil).Times(1)
+			orderRepo.EXPECT().CreateOrderItem(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			prodRepo.EXPECT().DecreaseStock(gomock.Any(), gomock.Any(), int64(1), 2).Return(nil).Times(1)
+			cartRepo.EXPECT().ClearCart(gomock.Any(), gomock.Any(), tc.cart.ID).Return(nil).Times(1)
+
+			result, err := uc.CreateOrder(auth.SetUserID(context.Background(), 10), &order.CreateOrderInput{})
+
+			assert.NoError(t, err)
+			assert.Equal(t, 200+tc.expectedAmount, result.Total)
+		})
+	}
+}
+
+func TestCheckoutQuote(t *testing.T) {
+	type testCase struct {
+		name        string
+		ctx         context.Context
+		mockFn      func(prodRepo *productrepository.MockProductRepository, cartRepo *cartrepository.MockCartRepository)
+		expectedErr error
+	}
+
+	mockCart := &cart.Cart{ID: "cart-001", UserID: sql.NullInt64{Int64: 10, Valid: true}}
+	mockItems := []*cartrepository.CartItemDB{
+		{CartItemID: 100, ProductID: 1, Price: 100, PriceAtAdd: 100, Quantity: 2},
+		{CartItemID: 101, ProductID: 2, Price: 80, PriceAtAdd: 80, Quantity: 1},
+	}
+
+	testCases := []testCase{
+		{
+			name: "success",
+			ctx:  auth.SetUserID(context.Background(), 10),
+			mockFn: func(prodRepo *productrepository.MockProductRepository, cartRepo *cartrepository.MockCartRepository) {
+				cartRepo.EXPECT().GetActiveCartByUserID(gomock.Any(), gomock.Any(), int64(10)).Return(mockCart, nil).Times(1)
+				cartRepo.EXPECT().GetCartItems(gomock.Any(), gomock.Any(), mockCart.ID).Return(mockItems, nil).Times(1)
+				for _, i := range mockItems {
+					// Quote does not lock
+					prodRepo.EXPECT().FindByID(gomock.Any(), i.ProductID).Return(
+						&product.Product{ID: i.ProductID, Price: i.Price, Stock: 10, Status: product.StatusPublished}, nil,
+					).Times(1)
+				}
+				prodRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+		},
+		{
+			name: "success guest cart",
+			ctx:  mockGuest(),
+			mockFn: func(prodRepo *productrepository.MockProductRepository, cartRepo *cartrepository.MockCartRepository) {
+				cartRepo.EXPECT().GetGuestCartBySessionID(gomock.Any(), gomock.Any(), "session-001").Return(mockCart, nil).Times(1)
+				cartRepo.EXPECT().GetCartItems(gomock.Any(), gomock.Any(), mockCart.ID).Return(mockItems, nil).Times(1)
+				for _, i := range mockItems {
+					prodRepo.EXPECT().FindByID(gomock.Any(), i.ProductID).Return(
+						&product.Product{ID: i.ProductID, Price: i.Price, Stock: 10, Status: product.StatusPublished}, nil,
+					).Times(1)
+				}
+			},
+		},
+		{
+			name: "fail no cart",
+			ctx:  auth.SetUserID(context.Background(), 10),
+			mockFn: func(prodRepo *productrepository.MockProductRepository, cartRepo *cartrepository.MockCartRepository) {
+				cartRepo.EXPECT().GetActiveCartByUserID(gomock.Any(), gomock.Any(), int64(10)).Return(nil, sql.ErrNoRows).Times(1)
+			},
+			expectedErr: errs.ErrCartIsEmpty,
+		},
+		{
+			name: "fail unaccepted changes",
+			ctx:  auth.SetUserID(context.Background(), 10),
+			mockFn: func(prodRepo *productrepository.MockProductRepository, cartRepo *cartrepository.MockCartRepository) {
+				cartRepo.EXPECT().GetActiveCartByUserID(gomock.Any(), gomock.Any(), int64(10)).Return(mockCart, nil).Times(1)
+				cartRepo.EXPECT().GetCartItems(gomock.Any(), gomock.Any(), mockCart.ID).Return(mockItems, nil).Times(1)
+				prodRepo.EXPECT().FindByID(gomock.Any(), int64(1)).Return(
+					&product.Product{ID: 1, Price: 120, Stock: 10, Status: product.StatusPublished}, nil,
+				).Times(1)
+				prodRepo.EXPECT().FindByID(gomock.Any(), int64(2)).Return(
+					&product.Product{ID: 2, Price: 80, Stock: 10, Status: product.StatusPublished}, nil,
+				).Times(1)
+			},
+			expectedErr: &cart.ChangesError{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, _, prodRepo, cartRepo := setup(t)
+
+			tc.mockFn(prodRepo, cartRepo)
+
+			result, err := uc.Quote(tc.ctx, &order.QuoteInput{})
+
+			if tc.expectedErr != nil {
+				var changesErr *cart.ChangesError
+				if errors.As(tc.expectedErr, &changesErr) {
+					assert.ErrorAs(t, err, &changesErr)
+				} else {
+					assert.ErrorIs(t, err, tc.expectedErr)
+				}
+				assert.Nil(t, result)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, result.Lines, 2)
+			assert.Equal(t, 280.0, result.Subtotal)
+			assert.Equal(t, 0.0, result.Discount)
+			assert.Equal(t, 0.0, result.Tax)
+			assert.Equal(t, 280.0, result.Total)
+			assert.WithinDuration(t, time.Now().Add(15*time.Minute), result.ExpiresAt, time.Minute)
+
+			claims, err := mockJWT(t).VerifyQuoteToken(result.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, mockCart.ID, claims.CartID)
+			assert.Equal(t, result.Digest(), claims.Digest)
+		})
+	}
+}
+
+func TestCreateOrderWithQuote(t *testing.T) {
+	mockCart := &cart.Cart{ID: "cart-001", UserID: sql.NullInt64{Int64: 10, Valid: true}}
+
+	// Quoted 2 x 100, free standard shipping
+	quoted := &order.Quote{
+		CartID:   mockCart.ID,
+		Lines:    []*order.QuoteLine{{CartItemID: 100, ProductID: 1, Quantity: 2, UnitPrice: 100, LineTotal: 200}},
+		Subtotal: 200,
+		Shipping: &shipping.Quote{Method: shipping.MethodStandard, Zone: shipping.ZoneDomestic},
+		Total:    200,
+	}
+	quoteID := func(t *testing.T, q *order.Quote) string {
+		s, _, err := mockJWT(t).GenerateQuoteToken(q.CartID, q.Digest())
+		assert.NoError(t, err)
+		return s
+	}
+
+	type testCase struct {
+		name        string
+		quoteID     func(t *testing.T) string
+		quantity    int
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name:     "success",
+			quoteID:  func(t *testing.T) string { return quoteID(t, quoted) },
+			quantity: 2,
+		},
+		{
+			name:        "fail quantity changed since the quote",
+			quoteID:     func(t *testing.T) string { return quoteID(t, quoted) },
+			quantity:    3,
+			expectedErr: errs.ErrQuoteChanged,
+		},
+		{
+			name: "fail quote priced with another discount",
+			quoteID: func(t *testing.T) string {
+				discounted := *quoted
+				discounted.Discount = 20
+				return quoteID(t, &discounted)
+			},
+			quantity:    2,
+			expectedErr: errs.ErrQuoteChanged,
+		},
+		{
+			name: "fail quote of another cart",
+			quoteID: func(t *testing.T) string {
+				other := *quoted
+				other.CartID = "cart-002"
+				return quoteID(t, &other)
+			},
+			quantity:    2,
+			expectedErr: errs.ErrQuoteInvalid,
+		},
+		{
+			name:        "fail invalid quote",
+			quoteID:     func(t *testing.T) string { return "invalid" },
+			quantity:    2,
+			expectedErr: errs.ErrQuoteInvalid,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, orderRepo, prodRepo, cartRepo := setup(t)
+
+			cartRepo.EXPECT().GetActiveCartByUserID(gomock.Any(), gomock.Any(), int64(10)).Return(mockCart, nil).Times(1)
+			cartRepo.EXPECT().GetCartItems(gomock.Any(), gomock.Any(), mockCart.ID).Return([]*cartrepository.CartItemDB{
+				{CartItemID: 100, ProductID: 1, Price: 100, PriceAtAdd: 100, Quantity: tc.quantity},
+			}, nil).Times(1)
+			prodRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), int64(1)).Return(
+				&product.Product{ID: 1, Price: 100, Stock: 10, Status: product.StatusPublished}, nil,
+			).Times(1)
+
+			if tc.expectedErr == nil {
+				orderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil).Times(1)
+				orderRepo.EXPECT().CreateSubOrder(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil).Times(1)
+				orderRepo.EXPECT().CreateOrderItem(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				prodRepo.EXPECT().DecreaseStock(gomock.Any(), gomock.Any(), int64(1), tc.quantity).Return(nil).Times(1)
+				cartRepo.EXPECT().ClearCart(gomock.Any(), gomock.Any(), mockCart.ID).Return(nil).Times(1)
+			} else {
+				orderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			}
+
+			result, err := uc.CreateOrder(auth.SetUserID(context.Background(), 10), &order.CreateOrderInput{QuoteID: tc.quoteID(t)})
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, quoted.Total, result.Total)
+		})
+	}
+}
+
+func TestCreateOrderAllocation(t *testing.T) {
+	bkk := &inventory.Warehouse{ID: 1, Code: "BKK", Priority: 1, IsActive: true, Latitude: ptr(13.7563), Longitude: ptr(100.5018)}
+	cnx := &inventory.Warehouse{ID: 2, Code: "CNX", Priority: 2, IsActive: true, Latitude: ptr(18.7883), Longitude: ptr(98.9853)}
+	chiangRai := &inventory.GeoPoint{Latitude: 19.9105, Longitude: 99.8406}
+
+	type testCase struct {
+		name        string
+		strategy    inventory.AllocationStrategy
+		destination *inventory.GeoPoint
+		levels      []*inventory.StockLevel
+		expected    map[int64]int64 // productID -> warehouseID
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name:     "success priority whole order",
+			strategy: inventory.PriorityStrategy{},
+			levels: []*inventory.StockLevel{
+				{WarehouseID: 1, ProductID: 1, Quantity: 5},
+				{WarehouseID: 1, ProductID: 2, Quantity: 5},
+				{WarehouseID: 2, ProductID: 1, Quantity: 5},
+				{WarehouseID: 2, ProductID: 2, Quantity: 5},
+			},
+			expected: map[int64]int64{1: 1, 2: 1},
+		},
+		{
+			name:     "success priority skips warehouse missing a line",
+			strategy: inventory.PriorityStrategy{},
+			levels: []*inventory.StockLevel{
+				{WarehouseID: 1, ProductID: 1, Quantity: 5},
+				{WarehouseID: 1, ProductID: 2, Quantity: 1},
+				{WarehouseID: 2, ProductID: 1, Quantity: 5},
+				{WarehouseID: 2, ProductID: 2, Quantity: 5},
+			},
+			expected: map[int64]int64{1: 2, 2: 2},
+		},
+		{
+			name:     "success split by line",
+			strategy: inventory.PriorityStrategy{},
+			levels: []*inventory.StockLevel{
+				{WarehouseID: 1, ProductID: 1, Quantity: 5},
+				{WarehouseID: 2, ProductID: 2, Quantity: 5},
+			},
+			expected: map[int64]int64{1: 1, 2: 2},
+		},
+		{
+			name:        "success nearest",
+			strategy:    inventory.NearestStrategy{},
+			destination: chiangRai,
+			levels: []*inventory.StockLevel{
+				{WarehouseID: 1, ProductID: 1, Quantity: 5},
+				{WarehouseID: 1, ProductID: 2, Quantity: 5},
+				{WarehouseID: 2, ProductID: 1, Quantity: 5},
+				{WarehouseID: 2, ProductID: 2, Quantity: 5},
+			},
+			expected: map[int64]int64{1: 2, 2: 2},
+		},
+		{
+			name:     "success nearest without destination uses priority",
+			strategy: inventory.NearestStrategy{},
+			levels: []*inventory.StockLevel{
+				{WarehouseID: 1, ProductID: 1, Quantity: 5},
+				{WarehouseID: 1, ProductID: 2, Quantity: 5},
+				{WarehouseID: 2, ProductID: 1, Quantity: 5},
+				{WarehouseID: 2, ProductID: 2, Quantity: 5},
+			},
+			expected: map[int64]int64{1: 1, 2: 1},
+		},
+		{
+			name:     "fail stock split across warehouses",
+			strategy: inventory.PriorityStrategy{},
+			levels: []*inventory.StockLevel{
+				{WarehouseID: 1, ProductID: 1, Quantity: 1},
+				{WarehouseID: 2, ProductID: 1, Quantity: 1},
+				{WarehouseID: 1, ProductID: 2, Quantity: 5},
+			},
+			expectedErr: errs.ErrProductNotEnough,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, orderRepo, prodRepo, cartRepo, invRepo := setupWithStrategy(t, tc.strategy)
+
+			mockCart := &cart.Cart{ID: "cart-001", UserID: sql.NullInt64{Int64: 10}}
+			cartRepo.EXPECT().GetActiveCartByUserID(gomock.Any(), gomock.Any(), int64(10)).Return(mockCart, nil).Times(1)
+
+			mockItems := []*cartrepository.CartItemDB{
+				{CartItemID: 100, ProductID: 1, Price: 100, PriceAtAdd: 100, Quantity: 2},
+				{CartItemID: 101, ProductID: 2, Price: 80, PriceAtAdd: 80, Quantity: 2},
+			}
+			cartRepo.EXPECT().GetCartItems(gomock.Any(), gomock.Any(), mockCart.ID).Return(mockItems, nil).Times(1)
+
+			for _, i := range mockItems {
+				mockProduct := &product.Product{ID: i.ProductID, Price: i.Price, Stock: 10, Status: product.StatusPublished}
+				prodRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), i.ProductID).Return(mockProduct, nil).Times(1)
+			}
+			invRepo.EXPECT().ReservedQuantity(gomock.Any(), gomock.Any(), gomock.Any(), mockCart.ID).Return(0, nil).AnyTimes()
+
+			invRepo.EXPECT().ListWarehouses(gomock.Any(), true).Return([]*inventory.Warehouse{cnx, bkk}, nil).Times(1)
+			invRepo.EXPECT().StockLevels(gomock.Any(), gomock.Any(), []int64{1, 2}).Return(tc.levels, nil).Times(1)
+
+			if tc.expectedErr == nil {
+				orderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil).Times(1)
+				orderRepo.EXPECT().CreateSubOrder(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil).Times(1)
+
+				for _, i := range mockItems {
+					warehouseID := tc.expected[i.ProductID]
+					orderRepo.EXPECT().CreateOrderItem(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+						func(_ context.Context, _ *sql.Tx, oi *order.OrderItem) error {
+							assert.Equal(t, tc.expected[oi.ProductID], oi.WarehouseID)
+							return nil
+						},
+					).Times(1)
+					invRepo.EXPECT().AdjustWarehouseStock(gomock.Any(), gomock.Any(), warehouseID, i.ProductID, -i.Quantity).Return(nil).Times(1)
+					prodRepo.EXPECT().DecreaseStock(gomock.Any(), gomock.Any(), i.ProductID, i.Quantity).Return(nil).Times(1)
+					invRepo.EXPECT().InsertMovement(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				}
+				cartRepo.EXPECT().ClearCart(gomock.Any(), gomock.Any(), mockCart.ID).Return(nil).Times(1)
+			}
+
+			input := &order.CreateOrderInput{Destination: tc.destination}
+			result, err := uc.CreateOrder(auth.SetUserID(context.Background(), 10), input)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestCancelOrderReturnsToWarehouse(t *testing.T) {
+	uc, orderRepo, prodRepo, _, invRepo := setupWithInventory(t)
+
+	o := mockOrder()
+	orderRepo.EXPECT().GetOrder(gomock.Any(), o.ID).Return(o, nil).Times(1)
+	orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), o.ID).Return(order.StatusPending, nil).Times(1)
+	orderRepo.EXPECT().ListSubOrders(gomock.Any(), gomock.Any(), o.ID).Return(mockSubOrders(o), nil).Times(1)
+	orderRepo.EXPECT().UpdateSubOrderStatus(gomock.Any(), gomock.Any(), int64(1), string(order.StatusCancelled)).Return(nil).Times(1)
+	orderRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), o.ID, string(order.StatusCancelled)).Return(nil).Times(1)
+
+	mockItems := []*orderrepository.OrderItemDetail{
+		{ID: 1, SubOrderID: 1, ProductID: 100, Quantity: 2, WarehouseID: 2},
+		// Placed before warehouses
+		{ID: 2, SubOrderID: 1, ProductID: 101, Quantity: 5},
+	}
+	orderRepo.EXPECT().GetOrderItems(gomock.Any(), gomock.Any(), o.ID).Return(mockItems, nil).Times(1)
+
+	invRepo.EXPECT().DefaultWarehouse(gomock.Any(), gomock.Any()).Return(mockWarehouse(), nil).Times(1)
+	invRepo.EXPECT().AdjustWarehouseStock(gomock.Any(), gomock.Any(), int64(2), int64(100), 2).Return(nil).Times(1)
+	invRepo.EXPECT().AdjustWarehouseStock(gomock.Any(), gomock.Any(), mockWarehouse().ID, int64(101), 5).Return(nil).Times(1)
+
+	for _, i := range mockItems {
+		prodRepo.EXPECT().IncreaseStock(gomock.Any(), gomock.Any(), i.ProductID, i.Quantity).Return(nil).Times(1)
+	}
+	invRepo.EXPECT().InsertMovement(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	err := uc.CancelOrder(auth.SetUserID(context.Background(), o.UserID), o.ID)
+	assert.NoError(t, err)
+}
+
 func TestGetOrderDetail(t *testing.T) {
 	type testCase struct {
 		name        string
@@ -210,6 +844,8 @@ func TestGetOrderDetail(t *testing.T) {
 					},
 				}
 				orderRepo.EXPECT().GetOrderItems(gomock.Any(), gomock.Any(), o.ID).Return(mockItems, nil).Times(1)
+				orderRepo.EXPECT().ListSubOrders(gomock.Any(), gomock.Any(), o.ID).Return(mockSubOrders(o), nil).Times(1)
+				orderRepo.EXPECT().ListShipments(gomock.Any(), gomock.Any(), o.ID).Return([]*order.Shipment{}, nil).Times(1)
 			},
 			expectedErr: nil,
 		},
@@ -356,12 +992,15 @@ func TestCancelOrder(t *testing.T) {
 			mockFn: func(orderRepo *orderrepository.MockOrderRepository, prodRepo *productrepository.MockProductRepository, cartRepo *cartrepository.MockCartRepository, orderID int64) {
 				o := mockOrder()
 				orderRepo.EXPECT().GetOrder(gomock.Any(), orderID).Return(o, nil).Times(1)
+				orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), o.ID).Return(order.StatusPending, nil).Times(1)
+				orderRepo.EXPECT().ListSubOrders(gomock.Any(), gomock.Any(), o.ID).Return(mockSubOrders(o), nil).Times(1)
+				orderRepo.EXPECT().UpdateSubOrderStatus(gomock.Any(), gomock.Any(), int64(1), string(order.StatusCancelled)).Return(nil).Times(1)
 
 				orderRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), int64(o.ID), string(order.StatusCancelled)).Return(nil).Times(1)
 
 				mockItems := []*orderrepository.OrderItemDetail{
-					{ID: 1, ProductID: 100, Quantity: 2},
-					{ID: 2, ProductID: 101, Quantity: 5},
+					{ID: 1, SubOrderID: 1, ProductID: 100, Quantity: 2},
+					{ID: 2, SubOrderID: 1, ProductID: 101, Quantity: 5},
 				}
 				orderRepo.EXPECT().GetOrderItems(gomock.Any(), gomock.Any(), o.ID).Return(mockItems, nil).Times(1)
 
@@ -397,8 +1036,9 @@ func TestCancelOrder(t *testing.T) {
 			mockFn: func(orderRepo *orderrepository.MockOrderRepository, prodRepo *productrepository.MockProductRepository, cartRepo *cartrepository.MockCartRepository, orderID int64) {
 				o := mockOrder()
 				orderRepo.EXPECT().GetOrder(gomock.Any(), orderID).Return(o, nil).Times(1)
-
-				orderRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), int64(o.ID), string(order.StatusCancelled)).Return(nil).Times(1)
+				orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), o.ID).Return(order.StatusPending, nil).Times(1)
+				orderRepo.EXPECT().ListSubOrders(gomock.Any(), gomock.Any(), o.ID).Return(mockSubOrders(o), nil).Times(1)
+				orderRepo.EXPECT().UpdateSubOrderStatus(gomock.Any(), gomock.Any(), int64(1), string(order.StatusCancelled)).Return(nil).Times(1)
 
 				orderRepo.EXPECT().GetOrderItems(gomock.Any(), gomock.Any(), o.ID).Return(nil, errors.New("db error")).Times(1)
 			},
@@ -447,19 +1087,11 @@ func TestUpdateOrderStatus(t *testing.T) {
 			},
 			mockFn: func(orderRepo *orderrepository.MockOrderRepository, prodRepo *productrepository.MockProductRepository, cartRepo *cartrepository.MockCartRepository, input *mockOrderInput) {
 				o := mockOrder()
-				orderRepo.EXPECT().GetOrder(gomock.Any(), input.orderID).Return(o, nil).Times(1)
+				orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), input.orderID).Return(order.StatusPending, nil).Times(1)
+				orderRepo.EXPECT().ListSubOrders(gomock.Any(), gomock.Any(), o.ID).Return(mockSubOrders(o), nil).Times(1)
+				orderRepo.EXPECT().UpdateSubOrderStatus(gomock.Any(), gomock.Any(), int64(1), input.status).Return(nil).Times(1)
 
 				orderRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), o.ID, input.status).Return(nil).Times(1)
-
-				mockItems := []*orderrepository.OrderItemDetail{
-					{ProductID: 100, Quantity: 2},
-					{ProductID: 101, Quantity: 1},
-				}
-				orderRepo.EXPECT().GetOrderItems(gomock.Any(), gomock.Any(), o.ID).Return(mockItems, nil).Times(1)
-
-				for _, i := range mockItems {
-					prodRepo.EXPECT().IncreaseStock(gomock.Any(), gomock.Any(), i.ProductID, i.Quantity).Return(nil).Times(1)
-				}
 			},
 			expectedErr: nil,
 		},
@@ -483,7 +1115,8 @@ func TestUpdateOrderStatus(t *testing.T) {
 			},
 			mockFn: func(orderRepo *orderrepository.MockOrderRepository, prodRepo *productrepository.MockProductRepository, cartRepo *cartrepository.MockCartRepository, input *mockOrderInput) {
 				o := mockOrder()
-				orderRepo.EXPECT().GetOrder(gomock.Any(), input.orderID).Return(o, nil).Times(1)
+				orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), input.orderID).Return(order.StatusPending, nil).Times(1)
+				orderRepo.EXPECT().ListSubOrders(gomock.Any(), gomock.Any(), o.ID).Return(mockSubOrders(o), nil).Times(1)
 			},
 			expectedErr: errs.ErrInvalidStatusChange,
 		},
@@ -501,7 +1134,7 @@ func TestUpdateOrderStatus(t *testing.T) {
 				ctx = auth.SetCurrentUser(ctx, tc.input.user)
 			}
 
-			err := uc.UpdateOrderStatus(ctx, tc.input.orderID, order.OrderStatus(tc.input.status))
+			err := uc.UpdateOrderStatus(ctx, tc.input.orderID, order.OrderStatus(tc.input.status), nil)
 
 			if tc.expectedErr != nil {
 				assert.Error(t, err)
@@ -512,39 +1145,1077 @@ func TestUpdateOrderStatus(t *testing.T) {
 	}
 }
 
-// =============== Helper ===================
-// ------------------------------------------
-func setup(t *testing.T) (orderusecase.OrderUsecase, *orderrepository.MockOrderRepository, *productrepository.MockProductRepository, *cartrepository.MockCartRepository) {
-	t.Helper()
+func TestUpdateOrderStatusShipment(t *testing.T) {
+	admin := auth.SetCurrentUser(context.Background(), &jwt.UserClaims{ID: 1, Role: "admin"})
+	tracking := &order.ShipmentInput{Carrier: "thaipost", TrackingNumber: "EF123456789TH"}
 
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
+	type testCase struct {
+		name             string
+		subs             []*order.SubOrder
+		expectedTracking []string
+	}
 
-	orderRepo := orderrepository.NewMockOrderRepository(ctrl)
-	cartRepo := cartrepository.NewMockCartRepository(ctrl)
-	prodRepo := productrepository.NewMockProductRepository(ctrl)
-	mockTx := &mockTxManager{}
-	mockDB := &mockDB{}
+	testCases := []testCase{
+		{
+			name: "success one parcel keeps tracking",
+			subs: []*order.SubOrder{
+				{ID: 1, OrderID: 100, SellerID: 20, Status: string(order.StatusPaid)},
+				{ID: 2, OrderID: 100, SellerID: 21, Status: string(order.StatusShipped)},
+			},
+			expectedTracking: []string{"EF123456789TH"},
+		},
+		{
+			name: "success many parcels drop tracking",
+			subs: []*order.SubOrder{
+				{ID: 1, OrderID: 100, SellerID: 20, Status: string(order.StatusPaid)},
+				{ID: 2, OrderID: 100, SellerID: 21, Status: string(order.StatusPaid)},
+			},
+			expectedTracking: []string{"", ""},
+		},
+	}
 
-	uc := orderusecase.NewOrderUsecase(orderRepo, prodRepo, cartRepo, mockTx, mockDB)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, orderRepo, _, _ := setup(t)
 
-	return uc, orderRepo, prodRepo, cartRepo
-}
+			orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(100)).Return(order.StatusPaid, nil).Times(1)
+			orderRepo.EXPECT().ListSubOrders(gomock.Any(), gomock.Any(), int64(100)).Return(tc.subs, nil).Times(1)
+			orderRepo.EXPECT().UpdateSubOrderStatus(gomock.Any(), gomock.Any(), gomock.Any(), "shipped").Return(nil).Times(len(tc.expectedTracking))
 
-type mockOrderInput struct {
-	user    *jwt.UserClaims
-	orderID int64
-	status  string
-}
+			var got []string
+			orderRepo.EXPECT().InsertShipment(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ *sql.Tx, s *order.Shipment) error {
+					got = append(got, s.TrackingNumber)
+					return nil
+				},
+			).Times(len(tc.expectedTracking))
+			orderRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), int64(100), "shipped").Return(nil).Times(1)
 
-func mockOrder() *order.Order {
-	return &order.Order{
+			err := uc.UpdateOrderStatus(admin, 100, order.StatusShipped, tracking)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedTracking, got)
+		})
+	}
+}
+
+func TestGuestCheckout(t *testing.T) {
+	type testCase struct {
+		name        string
+		ctx         context.Context
+		mockFn      func(orderRepo *orderrepository.MockOrderRepository, prodRepo *productrepository.MockProductRepository, cartRepo *cartrepository.MockCartRepository)
+		expectedErr error
+	}
+
+	mockCart := &cart.Cart{ID: "cart-guest", SessionID: sql.NullString{String: "session-001", Valid: true}}
+	mockItems := []*cartrepository.CartItemDB{
+		{CartItemID: 100, ProductID: 1, Price: 100, PriceAtAdd: 100, Quantity: 2},
+	}
+
+	testCases := []testCase{
+		{
+			name: "success",
+			ctx:  mockGuest(),
+			mockFn: func(orderRepo *orderrepository.MockOrderRepository, prodRepo *productrepository.MockProductRepository, cartRepo *cartrepository.MockCartRepository) {
+				cartRepo.EXPECT().GetGuestCartBySessionID(gomock.Any(), gomock.Any(), "session-001").Return(mockCart, nil).Times(1)
+				cartRepo.EXPECT().GetCartItems(gomock.Any(), gomock.Any(), mockCart.ID).Return(mockItems, nil).Times(1)
+				prodRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), int64(1)).Return(
+					&product.Product{ID: 1, Price: 100, Stock: 10, Status: product.StatusPublished}, nil,
+				).Times(1)
+
+				orderHeader := &order.Order{
+					GuestEmail: "guest@mail.com",
+					Shipping:   mockShipping(),
+					Total:      200,
+					Status:     string(order.StatusPending),
+
+					ShippingMethod: string(shipping.MethodStandard),
+				}
+				orderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), orderHeader).Return(int64(100), nil).Times(1)
+				orderRepo.EXPECT().CreateSubOrder(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil).Times(1)
+				orderRepo.EXPECT().CreateOrderItem(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				prodRepo.EXPECT().DecreaseStock(gomock.Any(), gomock.Any(), int64(1), 2).Return(nil).Times(1)
+				cartRepo.EXPECT().ClearCart(gomock.Any(), gomock.Any(), mockCart.ID).Return(nil).Times(1)
+			},
+		},
+		{
+			name: "fail signed in",
+			ctx:  auth.SetUserID(mockGuest(), 10),
+			mockFn: func(orderRepo *orderrepository.MockOrderRepository, prodRepo *productrepository.MockProductRepository, cartRepo *cartrepository.MockCartRepository) {
+			},
+			expectedErr: errs.ErrGuestCheckoutSignedIn,
+		},
+		{
+			name: "fail no session",
+			ctx:  context.Background(),
+			mockFn: func(orderRepo *orderrepository.MockOrderRepository, prodRepo *productrepository.MockProductRepository, cartRepo *cartrepository.MockCartRepository) {
+			},
+			expectedErr: errs.ErrCartIsEmpty,
+		},
+		{
+			name: "fail customer limit by email",
+			ctx:  mockGuest(),
+			mockFn: func(orderRepo *orderrepository.MockOrderRepository, prodRepo *productrepository.MockProductRepository, cartRepo *cartrepository.MockCartRepository) {
+				cartRepo.EXPECT().GetGuestCartBySessionID(gomock.Any(), gomock.Any(), "session-001").Return(mockCart, nil).Times(1)
+				cartRepo.EXPECT().GetCartItems(gomock.Any(), gomock.Any(), mockCart.ID).Return(mockItems, nil).Times(1)
+				prodRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), int64(1)).Return(
+					&product.Product{ID: 1, Price: 100, Stock: 10, Status: product.StatusPublished, Rules: product.PurchaseRules{CustomerLimit: 2}}, nil,
+				).Times(1)
+				prodRepo.EXPECT().GuestPurchasedQuantity(gomock.Any(), gomock.Any(), "guest@mail.com", int64(1)).Return(1, nil).Times(1)
+				orderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedErr: errs.ErrPurchaseRule,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, orderRepo, prodRepo, cartRepo := setup(t)
+
+			tc.mockFn(orderRepo, prodRepo, cartRepo)
+
+			input := &order.GuestCheckoutInput{Email: " Guest@Mail.com ", Shipping: mockShipping()}
+			result, err := uc.GuestCheckout(tc.ctx, input)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, int64(100), result.Order.ID)
+
+			claims, err := mockJWT(t).VerifyOrderLookupToken(result.LookupToken)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(100), claims.OrderID)
+			assert.Equal(t, "guest@mail.com", claims.Email)
+		})
+	}
+}
+
+func TestLookupGuestOrder(t *testing.T) {
+	type testCase struct {
+		name        string
+		token       func(token *jwt.JWTToken) string
+		mockFn      func(orderRepo *orderrepository.MockOrderRepository)
+		expectedErr error
+	}
+
+	guestOrder := func() *order.Order {
+		return &order.Order{ID: 100, GuestEmail: "guest@mail.com", Status: string(order.StatusPending), Shipping: mockShipping()}
+	}
+
+	testCases := []testCase{
+		{
+			name: "success",
+			token: func(token *jwt.JWTToken) string {
+				s, _ := token.GenerateOrderLookupToken(100, "guest@mail.com")
+				return s
+			},
+			mockFn: func(orderRepo *orderrepository.MockOrderRepository) {
+				orderRepo.EXPECT().GetOrder(gomock.Any(), int64(100)).Return(guestOrder(), nil).Times(1)
+				orderRepo.EXPECT().GetOrderItems(gomock.Any(), gomock.Any(), int64(100)).Return([]*orderrepository.OrderItemDetail{}, nil).Times(1)
+				orderRepo.EXPECT().ListSubOrders(gomock.Any(), gomock.Any(), int64(100)).Return(mockSubOrders(guestOrder()), nil).Times(1)
+				orderRepo.EXPECT().ListShipments(gomock.Any(), gomock.Any(), int64(100)).Return([]*order.Shipment{}, nil).Times(1)
+			},
+		},
+		{
+			name:        "fail invalid token",
+			token:       func(token *jwt.JWTToken) string { return "invalid" },
+			mockFn:      func(orderRepo *orderrepository.MockOrderRepository) {},
+			expectedErr: errs.ErrOrderLookupInvalid,
+		},
+		{
+			name: "fail claim token",
+			token: func(token *jwt.JWTToken) string {
+				s, _ := token.GenerateOrderClaimToken(100, "guest@mail.com")
+				return s
+			},
+			mockFn:      func(orderRepo *orderrepository.MockOrderRepository) {},
+			expectedErr: errs.ErrOrderLookupInvalid,
+		},
+		{
+			name: "fail order claimed by account",
+			token: func(token *jwt.JWTToken) string {
+				s, _ := token.GenerateOrderLookupToken(100, "guest@mail.com")
+				return s
+			},
+			mockFn: func(orderRepo *orderrepository.MockOrderRepository) {
+				o := guestOrder()
+				o.UserID = 10
+				orderRepo.EXPECT().GetOrder(gomock.Any(), int64(100)).Return(o, nil).Times(1)
+			},
+			expectedErr: errs.ErrOrderLookupInvalid,
+		},
+		{
+			name: "fail order not found",
+			token: func(token *jwt.JWTToken) string {
+				s, _ := token.GenerateOrderLookupToken(100, "guest@mail.com")
+				return s
+			},
+			mockFn: func(orderRepo *orderrepository.MockOrderRepository) {
+				orderRepo.EXPECT().GetOrder(gomock.Any(), int64(100)).Return(nil, errs.ErrOrderNotFound).Times(1)
+			},
+			expectedErr: errs.ErrOrderLookupInvalid,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, orderRepo, _, _ := setup(t)
+
+			tc.mockFn(orderRepo)
+
+			result, err := uc.LookupGuestOrder(context.Background(), tc.token(mockJWT(t)))
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, int64(100), result.ID)
+			assert.Equal(t, mockShipping(), result.Shipping)
+		})
+	}
+}
+
+func TestRequestGuestOrderClaim(t *testing.T) {
+	type testCase struct {
+		name        string
+		ctx         context.Context
+		mockFn      func(orderRepo *orderrepository.MockOrderRepository)
+		expected    int
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name: "success",
+			ctx:  mockMember(),
+			mockFn: func(orderRepo *orderrepository.MockOrderRepository) {
+				orderRepo.EXPECT().CountGuestOrders(gomock.Any(), "guest@mail.com").Return(2, nil).Times(1)
+			},
+			expected: 2,
+		},
+		{
+			name:        "fail unauthorized",
+			ctx:         context.Background(),
+			mockFn:      func(orderRepo *orderrepository.MockOrderRepository) {},
+			expectedErr: errs.ErrUnauthorized,
+		},
+		{
+			name: "fail no guest orders",
+			ctx:  mockMember(),
+			mockFn: func(orderRepo *orderrepository.MockOrderRepository) {
+				orderRepo.EXPECT().CountGuestOrders(gomock.Any(), "guest@mail.com").Return(0, nil).Times(1)
+			},
+			expectedErr: errs.ErrNoGuestOrders,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			notifier := &mockNotifier{}
+			uc, orderRepo, _, _, _ := setupWithNotifier(t, inventory.PriorityStrategy{}, notifier)
+
+			tc.mockFn(orderRepo)
+
+			count, err := uc.RequestGuestOrderClaim(tc.ctx)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, count)
+
+			// Absolute link to the claim page
+			if assert.Len(t, notifier.sent, 1) {
+				body := notifier.sent[0].Body
+				assert.Contains(t, body, "http://localhost:3000/orders/claim?token=")
+			}
+		})
+	}
+}
+
+func TestConfirmGuestOrderClaim(t *testing.T) {
+	type testCase struct {
+		name        string
+		token       func(token *jwt.JWTToken) string
+		mockFn      func(orderRepo *orderrepository.MockOrderRepository)
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name: "success",
+			token: func(token *jwt.JWTToken) string {
+				s, _ := token.GenerateOrderClaimToken(10, "guest@mail.com")
+				return s
+			},
+			mockFn: func(orderRepo *orderrepository.MockOrderRepository) {
+				orderRepo.EXPECT().ClaimGuestOrders(gomock.Any(), int64(10), "guest@mail.com").Return(int64(2), nil).Times(1)
+			},
+		},
+		{
+			name: "fail other user",
+			token: func(token *jwt.JWTToken) string {
+				s, _ := token.GenerateOrderClaimToken(11, "guest@mail.com")
+				return s
+			},
+			mockFn:      func(orderRepo *orderrepository.MockOrderRepository) {},
+			expectedErr: errs.ErrOrderClaimInvalid,
+		},
+		{
+			name: "fail lookup token",
+			token: func(token *jwt.JWTToken) string {
+				s, _ := token.GenerateOrderLookupToken(10, "guest@mail.com")
+				return s
+			},
+			mockFn:      func(orderRepo *orderrepository.MockOrderRepository) {},
+			expectedErr: errs.ErrOrderClaimInvalid,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, orderRepo, _, _ := setup(t)
+
+			tc.mockFn(orderRepo)
+
+			claimed, err := uc.ConfirmGuestOrderClaim(mockMember(), tc.token(mockJWT(t)))
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, int64(2), claimed)
+		})
+	}
+}
+
+func TestGetSellerOrders(t *testing.T) {
+	t.Run("success only seller lines", func(t *testing.T) {
+		uc, orderRepo, _, _ := setup(t)
+
+		orderRepo.EXPECT().ListSellerOrders(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, filter *order.SellerOrderFilter) ([]*order.SellerOrder, error) {
+				assert.Equal(t, int64(20), filter.SellerID)
+				assert.Equal(t, 1, filter.Page)
+				assert.Equal(t, 10, filter.Limit)
+				return []*order.SellerOrder{{ID: 100}, {ID: 101}}, nil
+			},
+		).Times(1)
+		orderRepo.EXPECT().GetSellerOrderItems(gomock.Any(), gomock.Any(), int64(20), []int64{100, 101}).Return([]*order.SellerOrderItem{
+			{ID: 1, OrderID: 100, ProductID: 1},
+			{ID: 2, OrderID: 101, ProductID: 1},
+			{ID: 3, OrderID: 101, ProductID: 2},
+		}, nil).Times(1)
+
+		result, err := uc.GetSellerOrders(auth.SetUserID(context.Background(), 20), &order.SellerOrderFilter{})
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 2)
+		assert.Len(t, result[0].Items, 1)
+		assert.Len(t, result[1].Items, 2)
+	})
+
+	t.Run("fail unauthorized", func(t *testing.T) {
+		uc, _, _, _ := setup(t)
+
+		result, err := uc.GetSellerOrders(context.Background(), &order.SellerOrderFilter{})
+
+		assert.ErrorIs(t, err, errs.ErrUnauthorized)
+		assert.Nil(t, result)
+	})
+}
+
+func TestShipSellerOrder(t *testing.T) {
+	type testCase struct {
+		name        string
+		mockFn      func(orderRepo *orderrepository.MockOrderRepository)
+		expectedErr error
+	}
+
+	sellerOrder := func(status order.OrderStatus) *order.SellerOrder {
+		return &order.SellerOrder{ID: 100, SubOrderID: 1, Status: string(status)}
+	}
+	subOrders := func(statuses ...order.OrderStatus) []*order.SubOrder {
+		subs := make([]*order.SubOrder, 0, len(statuses))
+		for i, s := range statuses {
+			subs = append(subs, &order.SubOrder{ID: int64(i + 1), OrderID: 100, SellerID: int64(20 + i), Status: string(s)})
+		}
+		return subs
+	}
+
+	testCases := []testCase{
+		{
+			name: "success last seller ships the order",
+			mockFn: func(orderRepo *orderrepository.MockOrderRepository) {
+				orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(100)).Return(order.StatusPartiallyShipped, nil).Times(1)
+				gomock.InOrder(
+					orderRepo.EXPECT().GetSellerOrder(gomock.Any(), gomock.Any(), int64(20), int64(100)).Return(sellerOrder(order.StatusPaid), nil),
+					orderRepo.EXPECT().GetSellerOrder(gomock.Any(), gomock.Any(), int64(20), int64(100)).Return(sellerOrder(order.StatusShipped), nil),
+				)
+				orderRepo.EXPECT().ListSubOrders(gomock.Any(), gomock.Any(), int64(100)).Return(subOrders(order.StatusPaid, order.StatusShipped), nil).Times(1)
+				orderRepo.EXPECT().UpdateSubOrderStatus(gomock.Any(), gomock.Any(), int64(1), string(order.StatusShipped)).Return(nil).Times(1)
+				orderRepo.EXPECT().InsertShipment(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ *sql.Tx, s *order.Shipment) error {
+						assert.Equal(t, int64(1), s.SubOrderID)
+						assert.Equal(t, "thaipost", s.Carrier)
+						assert.Equal(t, "EF123456789TH", s.TrackingNumber)
+						assert.Equal(t, order.ShipmentInTransit, s.Status)
+						return nil
+					},
+				).Times(1)
+				orderRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), int64(100), string(order.StatusShipped)).Return(nil).Times(1)
+				orderRepo.EXPECT().GetSellerOrderItems(gomock.Any(), gomock.Any(), int64(20), []int64{100}).Return([]*order.SellerOrderItem{}, nil).Times(1)
+			},
+		},
+		{
+			name: "success other sellers still paid",
+			mockFn: func(orderRepo *orderrepository.MockOrderRepository) {
+				orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(100)).Return(order.StatusPaid, nil).Times(1)
+				gomock.InOrder(
+					orderRepo.EXPECT().GetSellerOrder(gomock.Any(), gomock.Any(), int64(20), int64(100)).Return(sellerOrder(order.StatusPaid), nil),
+					orderRepo.EXPECT().GetSellerOrder(gomock.Any(), gomock.Any(), int64(20), int64(100)).Return(sellerOrder(order.StatusShipped), nil),
+				)
+				orderRepo.EXPECT().ListSubOrders(gomock.Any(), gomock.Any(), int64(100)).Return(subOrders(order.StatusPaid, order.StatusPaid), nil).Times(1)
+				orderRepo.EXPECT().UpdateSubOrderStatus(gomock.Any(), gomock.Any(), int64(1), string(order.StatusShipped)).Return(nil).Times(1)
+				orderRepo.EXPECT().UpdateSubOrderStatus(gomock.Any(), gomock.Any(), int64(2), gomock.Any()).Times(0)
+				orderRepo.EXPECT().InsertShipment(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				orderRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), int64(100), string(order.StatusPartiallyShipped)).Return(nil).Times(1)
+				orderRepo.EXPECT().GetSellerOrderItems(gomock.Any(), gomock.Any(), int64(20), []int64{100}).Return([]*order.SellerOrderItem{}, nil).Times(1)
+			},
+		},
+		{
+			name: "fail not paid",
+			mockFn: func(orderRepo *orderrepository.MockOrderRepository) {
+				orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(100)).Return(order.StatusPending, nil).Times(1)
+				orderRepo.EXPECT().GetSellerOrder(gomock.Any(), gomock.Any(), int64(20), int64(100)).Return(sellerOrder(order.StatusPending), nil).Times(1)
+				orderRepo.EXPECT().UpdateSubOrderStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedErr: errs.ErrOrderNotPaid,
+		},
+		{
+			name: "fail already shipped",
+			mockFn: func(orderRepo *orderrepository.MockOrderRepository) {
+				orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(100)).Return(order.StatusShipped, nil).Times(1)
+				orderRepo.EXPECT().GetSellerOrder(gomock.Any(), gomock.Any(), int64(20), int64(100)).Return(sellerOrder(order.StatusShipped), nil).Times(1)
+				orderRepo.EXPECT().ListSubOrders(gomock.Any(), gomock.Any(), int64(100)).Return(subOrders(order.StatusShipped), nil).Times(1)
+				orderRepo.EXPECT().UpdateSubOrderStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedErr: errs.ErrInvalidStatusChange,
+		},
+		{
+			name: "fail no sub-order of the seller",
+			mockFn: func(orderRepo *orderrepository.MockOrderRepository) {
+				orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(100)).Return(order.StatusPaid, nil).Times(1)
+				orderRepo.EXPECT().GetSellerOrder(gomock.Any(), gomock.Any(), int64(20), int64(100)).Return(nil, errs.ErrOrderNotFound).Times(1)
+			},
+			expectedErr: errs.ErrOrderNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, orderRepo, _, _ := setup(t)
+
+			tc.mockFn(orderRepo)
+
+			result, err := uc.ShipSellerOrder(auth.SetUserID(context.Background(), 20), 100, &order.ShipmentInput{Carrier: "thaipost", TrackingNumber: "EF123456789TH"})
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, string(order.StatusShipped), result.Status)
+		})
+	}
+}
+
+func TestUpdateSubOrderStatus(t *testing.T) {
+	type testCase struct {
+		name        string
+		subOrderID  int64
+		status      order.OrderStatus
+		mockFn      func(orderRepo *orderrepository.MockOrderRepository, prodRepo *productrepository.MockProductRepository)
+		expectedErr error
+	}
+
+	// Seller 20 & seller 21, both in status
+	subOrdersIn := func(status order.OrderStatus) []*order.SubOrder {
+		return []*order.SubOrder{
+			{ID: 1, OrderID: 100, SellerID: 20, Status: string(status)},
+			{ID: 2, OrderID: 100, SellerID: 21, Status: string(status)},
+		}
+	}
+	subOrders := func() []*order.SubOrder {
+		return subOrdersIn(order.StatusPaid)
+	}
+
+	testCases := []testCase{
+		{
+			name:       "success cancel one seller returns its items only",
+			subOrderID: 2,
+			status:     order.StatusCancelled,
+			mockFn: func(orderRepo *orderrepository.MockOrderRepository, prodRepo *productrepository.MockProductRepository) {
+				orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(100)).Return(order.StatusPending, nil).Times(1)
+				orderRepo.EXPECT().ListSubOrders(gomock.Any(), gomock.Any(), int64(100)).Return(subOrdersIn(order.StatusPending), nil).Times(1)
+				orderRepo.EXPECT().UpdateSubOrderStatus(gomock.Any(), gomock.Any(), int64(2), string(order.StatusCancelled)).Return(nil).Times(1)
+				orderRepo.EXPECT().GetOrderItems(gomock.Any(), gomock.Any(), int64(100)).Return([]*orderrepository.OrderItemDetail{
+					{ID: 1, SubOrderID: 1, ProductID: 100, Quantity: 2},
+					{ID: 2, SubOrderID: 2, ProductID: 101, Quantity: 1},
+				}, nil).Times(1)
+				prodRepo.EXPECT().IncreaseStock(gomock.Any(), gomock.Any(), int64(101), 1).Return(nil).Times(1)
+				prodRepo.EXPECT().IncreaseStock(gomock.Any(), gomock.Any(), int64(100), gomock.Any()).Times(0)
+				// Seller 20 still pending
+				orderRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), int64(100), string(order.StatusPending)).Return(nil).Times(1)
+			},
+		},
+		{
+			name:       "success ship one seller",
+			subOrderID: 1,
+			status:     order.StatusShipped,
+			mockFn: func(orderRepo *orderrepository.MockOrderRepository, prodRepo *productrepository.MockProductRepository) {
+				orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(100)).Return(order.StatusPaid, nil).Times(1)
+				orderRepo.EXPECT().ListSubOrders(gomock.Any(), gomock.Any(), int64(100)).Return(subOrders(), nil).Times(1)
+				orderRepo.EXPECT().UpdateSubOrderStatus(gomock.Any(), gomock.Any(), int64(1), string(order.StatusShipped)).Return(nil).Times(1)
+				// Only the shipped seller's shipment
+				orderRepo.EXPECT().InsertShipment(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ *sql.Tx, s *order.Shipment) error {
+						assert.Equal(t, int64(1), s.SubOrderID)
+						return nil
+					},
+				).Times(1)
+				orderRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), int64(100), string(order.StatusPartiallyShipped)).Return(nil).Times(1)
+			},
+		},
+		{
+			name:       "fail invalid status",
+			subOrderID: 1,
+			status:     order.StatusCompleted,
+			mockFn: func(orderRepo *orderrepository.MockOrderRepository, prodRepo *productrepository.MockProductRepository) {
+				orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(100)).Return(order.StatusPaid, nil).Times(1)
+				orderRepo.EXPECT().ListSubOrders(gomock.Any(), gomock.Any(), int64(100)).Return(subOrders(), nil).Times(1)
+				orderRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedErr: errs.ErrInvalidStatusChange,
+		},
+		{
+			name:       "success partially refunded order ships",
+			subOrderID: 1,
+			status:     order.StatusShipped,
+			mockFn: func(orderRepo *orderrepository.MockOrderRepository, prodRepo *productrepository.MockProductRepository) {
+				// Sub-order 2 was fully refunded, the refund status is left as is
+				subs := subOrders()
+				subs[1].Status = string(order.StatusRefunded)
+				orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(100)).Return(order.StatusPaid, nil).Times(1)
+				orderRepo.EXPECT().ListSubOrders(gomock.Any(), gomock.Any(), int64(100)).Return(subs, nil).Times(1)
+				orderRepo.EXPECT().UpdateSubOrderStatus(gomock.Any(), gomock.Any(), int64(1), string(order.StatusShipped)).Return(nil).Times(1)
+				orderRepo.EXPECT().InsertShipment(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				orderRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), int64(100), string(order.StatusShipped)).Return(nil).Times(1)
+				orderRepo.EXPECT().UpdateRefundStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+		},
+		{
+			name:       "fail cancel paid without refund",
+			subOrderID: 2,
+			status:     order.StatusCancelled,
+			mockFn: func(orderRepo *orderrepository.MockOrderRepository, prodRepo *productrepository.MockProductRepository) {
+				orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(100)).Return(order.StatusPaid, nil).Times(1)
+				orderRepo.EXPECT().ListSubOrders(gomock.Any(), gomock.Any(), int64(100)).Return(subOrders(), nil).Times(1)
+				orderRepo.EXPECT().UpdateSubOrderStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				prodRepo.EXPECT().IncreaseStock(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedErr: errs.ErrCancelPaidOrder,
+		},
+		{
+			name:       "fail sub-order of another order",
+			subOrderID: 9,
+			status:     order.StatusShipped,
+			mockFn: func(orderRepo *orderrepository.MockOrderRepository, prodRepo *productrepository.MockProductRepository) {
+				orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(100)).Return(order.StatusPaid, nil).Times(1)
+				orderRepo.EXPECT().ListSubOrders(gomock.Any(), gomock.Any(), int64(100)).Return(subOrders(), nil).Times(1)
+			},
+			expectedErr: errs.ErrSubOrderNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, orderRepo, prodRepo, _ := setup(t)
+
+			tc.mockFn(orderRepo, prodRepo)
+
+			ctx := auth.SetCurrentUser(context.Background(), &jwt.UserClaims{ID: 1, Role: "admin"})
+			err := uc.UpdateSubOrderStatus(ctx, 100, tc.subOrderID, tc.status, nil)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// =============== Helper ===================
+// ------------------------------------------
+func TestListOrders(t *testing.T) {
+	admin := auth.SetCurrentUser(context.Background(), &jwt.UserClaims{ID: 1, Role: "admin"})
+
+	type testCase struct {
+		name        string
+		ctx         context.Context
+		filter      *order.AdminOrderFilter
+		mockFn      func(orderRepo *orderrepository.MockOrderRepository)
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name: "success defaults & inclusive to",
+			ctx:  admin,
+			filter: &order.AdminOrderFilter{
+				Status: "paid",
+				From:   time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+				To:     time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC),
+				Limit:  1000,
+			},
+			mockFn: func(orderRepo *orderrepository.MockOrderRepository) {
+				orderRepo.EXPECT().ListOrders(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, f *order.AdminOrderFilter) ([]*order.Order, error) {
+						assert.Equal(t, 1, f.Page)
+						assert.Equal(t, 20, f.Limit)
+						assert.Equal(t, time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), f.To)
+						return []*order.Order{mockOrder()}, nil
+					},
+				).Times(1)
+			},
+		},
+		{
+			name:        "fail invalid date range",
+			ctx:         admin,
+			filter:      &order.AdminOrderFilter{From: time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC), To: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+			mockFn:      func(orderRepo *orderrepository.MockOrderRepository) {},
+			expectedErr: errs.ErrInvalidDateRange,
+		},
+		{
+			name:        "fail invalid total range",
+			ctx:         admin,
+			filter:      &order.AdminOrderFilter{MinTotal: 500, MaxTotal: 100},
+			mockFn:      func(orderRepo *orderrepository.MockOrderRepository) {},
+			expectedErr: errs.ErrInvalidTotalRange,
+		},
+		{
+			name:        "fail no permissions",
+			ctx:         mockMember(),
+			filter:      &order.AdminOrderFilter{},
+			mockFn:      func(orderRepo *orderrepository.MockOrderRepository) {},
+			expectedErr: errs.ErrNoPermissions,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, orderRepo, _, _ := setup(t)
+
+			tc.mockFn(orderRepo)
+
+			result, err := uc.ListOrders(tc.ctx, tc.filter)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, result, 1)
+		})
+	}
+}
+
+func TestBulkUpdateOrderStatus(t *testing.T) {
+	admin := auth.SetCurrentUser(context.Background(), &jwt.UserClaims{ID: 1, Role: "admin"})
+
+	t.Run("success per order results", func(t *testing.T) {
+		uc, orderRepo, _, _ := setup(t)
+
+		// 100 paid, ships
+		paid := &order.Order{ID: 100, Status: string(order.StatusPaid)}
+		orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(100)).Return(order.StatusPaid, nil).Times(1)
+		orderRepo.EXPECT().ListSubOrders(gomock.Any(), gomock.Any(), int64(100)).Return(mockSubOrders(paid), nil).Times(1)
+		orderRepo.EXPECT().UpdateSubOrderStatus(gomock.Any(), gomock.Any(), int64(1), "shipped").Return(nil).Times(1)
+		orderRepo.EXPECT().InsertShipment(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		orderRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), int64(100), "shipped").Return(nil).Times(1)
+		// 101 missing
+		orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(101)).Return(order.OrderStatus(""), errs.ErrOrderNotFound).Times(1)
+		// 102 still pending
+		pending := &order.Order{ID: 102, Status: string(order.StatusPending)}
+		orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(102)).Return(order.StatusPending, nil).Times(1)
+		orderRepo.EXPECT().ListSubOrders(gomock.Any(), gomock.Any(), int64(102)).Return(mockSubOrders(pending), nil).Times(1)
+
+		// Duplicate IDs are changed once
+		report, err := uc.BulkUpdateOrderStatus(admin, []int64{100, 101, 100, 102}, order.StatusShipped)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, report.Updated)
+		assert.Equal(t, 2, report.Failed)
+		assert.Equal(t, []*order.BulkStatusResult{
+			{OrderID: 100, Updated: true},
+			{OrderID: 101, Error: errs.ErrOrderNotFound.Error()},
+			{OrderID: 102, Error: errs.ErrInvalidStatusChange.Error()},
+		}, report.Results)
+	})
+
+	t.Run("fail too many orders", func(t *testing.T) {
+		uc, _, _, _ := setup(t)
+
+		report, err := uc.BulkUpdateOrderStatus(admin, make([]int64, consts.MaxBulkOrders+1), order.StatusShipped)
+
+		assert.ErrorIs(t, err, errs.ErrTooManyOrders)
+		assert.Nil(t, report)
+	})
+
+	t.Run("fail no permissions", func(t *testing.T) {
+		uc, _, _, _ := setup(t)
+
+		report, err := uc.BulkUpdateOrderStatus(mockMember(), []int64{100}, order.StatusShipped)
+
+		assert.ErrorIs(t, err, errs.ErrNoPermissions)
+		assert.Nil(t, report)
+	})
+}
+
+func TestTrackShipments(t *testing.T) {
+	type testCase struct {
+		name       string
+		shippedAgo time.Duration
+		mockFn     func(orderRepo *orderrepository.MockOrderRepository, shipment *order.Shipment)
+		expected   int
+	}
+
+	// Stub tracker delivers 72h after shipping
+	subOrders := func(status order.OrderStatus) []*order.SubOrder {
+		return []*order.SubOrder{
+			{ID: 1, OrderID: 100, SellerID: 20, Status: string(status)},
+			{ID: 2, OrderID: 100, SellerID: 21, Status: string(order.StatusCompleted)},
+		}
+	}
+
+	testCases := []testCase{
+		{
+			name:       "success delivered completes the sub-order",
+			shippedAgo: 96 * time.Hour,
+			mockFn: func(orderRepo *orderrepository.MockOrderRepository, shipment *order.Shipment) {
+				orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(100)).Return(order.StatusShipped, nil).Times(1)
+				orderRepo.EXPECT().SetShipmentDelivered(gomock.Any(), gomock.Any(), int64(5), shipment.ShippedAt.Add(72*time.Hour)).Return(nil).Times(1)
+				orderRepo.EXPECT().ListSubOrders(gomock.Any(), gomock.Any(), int64(100)).Return(subOrders(order.StatusShipped), nil).Times(1)
+				orderRepo.EXPECT().UpdateSubOrderStatus(gomock.Any(), gomock.Any(), int64(1), string(order.StatusCompleted)).Return(nil).Times(1)
+				orderRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), int64(100), string(order.StatusCompleted)).Return(nil).Times(1)
+			},
+			expected: 1,
+		},
+		{
+			name:       "success sub-order completed meanwhile",
+			shippedAgo: 96 * time.Hour,
+			mockFn: func(orderRepo *orderrepository.MockOrderRepository, shipment *order.Shipment) {
+				orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(100)).Return(order.StatusCompleted, nil).Times(1)
+				orderRepo.EXPECT().SetShipmentDelivered(gomock.Any(), gomock.Any(), int64(5), gomock.Any()).Return(nil).Times(1)
+				orderRepo.EXPECT().ListSubOrders(gomock.Any(), gomock.Any(), int64(100)).Return(subOrders(order.StatusCompleted), nil).Times(1)
+				orderRepo.EXPECT().UpdateSubOrderStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expected: 1,
+		},
+		{
+			name:       "success still in transit",
+			shippedAgo: time.Hour,
+			mockFn: func(orderRepo *orderrepository.MockOrderRepository, shipment *order.Shipment) {
+				orderRepo.EXPECT().SetShipmentChecked(gomock.Any(), int64(5)).Return(nil).Times(1)
+				orderRepo.EXPECT().SetShipmentDelivered(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expected: 0,
+		},
+		{
+			name:       "success delivered by another run",
+			shippedAgo: 96 * time.Hour,
+			mockFn: func(orderRepo *orderrepository.MockOrderRepository, shipment *order.Shipment) {
+				orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(100)).Return(order.StatusCompleted, nil).Times(1)
+				orderRepo.EXPECT().SetShipmentDelivered(gomock.Any(), gomock.Any(), int64(5), gomock.Any()).Return(errs.ErrShipmentNotFound).Times(1)
+			},
+			expected: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, orderRepo, _, _ := setup(t)
+
+			shipment := &order.Shipment{
+				ID:             5,
+				OrderID:        100,
+				SubOrderID:     1,
+				Carrier:        "thaipost",
+				TrackingNumber: "EF123456789TH",
+				Status:         order.ShipmentInTransit,
+				ShippedAt:      time.Now().Add(-tc.shippedAgo),
+			}
+			orderRepo.EXPECT().ListShipmentsToTrack(gomock.Any(), consts.ShipmentTrackBatch).Return([]*order.Shipment{shipment}, nil).Times(1)
+			tc.mockFn(orderRepo, shipment)
+
+			delivered, err := uc.TrackShipments(context.Background())
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, delivered)
+		})
+	}
+}
+
+func TestSetShipmentTracking(t *testing.T) {
+	type testCase struct {
+		name        string
+		ctx         context.Context
+		subOrderID  int64
+		mockFn      func(orderRepo *orderrepository.MockOrderRepository)
+		expectedErr error
+	}
+
+	admin := auth.SetCurrentUser(context.Background(), &jwt.UserClaims{ID: 1, Role: "admin"})
+	input := &order.ShipmentInput{Carrier: "thaipost", TrackingNumber: "EF123456789TH"}
+	subOrders := []*order.SubOrder{
+		{ID: 1, OrderID: 100, SellerID: 20, Status: string(order.StatusShipped)},
+		{ID: 2, OrderID: 100, SellerID: 21, Status: string(order.StatusShipped)},
+	}
+
+	testCases := []testCase{
+		{
+			name:       "success shipment shipped without tracking",
+			ctx:        admin,
+			subOrderID: 2,
+			mockFn: func(orderRepo *orderrepository.MockOrderRepository) {
+				orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(100)).Return(order.StatusShipped, nil).Times(1)
+				orderRepo.EXPECT().ListSubOrders(gomock.Any(), gomock.Any(), int64(100)).Return(subOrders, nil).Times(1)
+				orderRepo.EXPECT().SetShipmentTracking(gomock.Any(), gomock.Any(), int64(2), input).Return(
+					&order.Shipment{ID: 5, OrderID: 100, SubOrderID: 2, Carrier: input.Carrier, TrackingNumber: input.TrackingNumber, Status: order.ShipmentInTransit}, nil,
+				).Times(1)
+			},
+		},
+		{
+			name:       "fail shipment delivered",
+			ctx:        admin,
+			subOrderID: 1,
+			mockFn: func(orderRepo *orderrepository.MockOrderRepository) {
+				orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(100)).Return(order.StatusShipped, nil).Times(1)
+				orderRepo.EXPECT().ListSubOrders(gomock.Any(), gomock.Any(), int64(100)).Return(subOrders, nil).Times(1)
+				orderRepo.EXPECT().SetShipmentTracking(gomock.Any(), gomock.Any(), int64(1), input).Return(nil, errs.ErrShipmentNotFound).Times(1)
+			},
+			expectedErr: errs.ErrShipmentNotFound,
+		},
+		{
+			name:       "fail sub-order of another order",
+			ctx:        admin,
+			subOrderID: 9,
+			mockFn: func(orderRepo *orderrepository.MockOrderRepository) {
+				orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(100)).Return(order.StatusShipped, nil).Times(1)
+				orderRepo.EXPECT().ListSubOrders(gomock.Any(), gomock.Any(), int64(100)).Return(subOrders, nil).Times(1)
+				orderRepo.EXPECT().SetShipmentTracking(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedErr: errs.ErrSubOrderNotFound,
+		},
+		{
+			name:        "fail not admin",
+			ctx:         auth.SetCurrentUser(context.Background(), &jwt.UserClaims{ID: 20, Role: "seller"}),
+			subOrderID:  1,
+			mockFn:      func(orderRepo *orderrepository.MockOrderRepository) {},
+			expectedErr: errs.ErrNoPermissions,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, orderRepo, _, _ := setup(t)
+
+			tc.mockFn(orderRepo)
+
+			result, err := uc.SetShipmentTracking(tc.ctx, 100, tc.subOrderID, input)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, input.TrackingNumber, result.TrackingNumber)
+		})
+	}
+}
+
+func TestSetSellerShipmentTracking(t *testing.T) {
+	uc, orderRepo, _, _ := setup(t)
+	input := &order.ShipmentInput{Carrier: "kerry", TrackingNumber: "KEX0001"}
+
+	// The seller's own sub-order only
+	orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(100)).Return(order.StatusShipped, nil).Times(1)
+	orderRepo.EXPECT().GetSellerOrder(gomock.Any(), gomock.Any(), int64(20), int64(100)).Return(&order.SellerOrder{ID: 100, SubOrderID: 3}, nil).Times(1)
+	orderRepo.EXPECT().SetShipmentTracking(gomock.Any(), gomock.Any(), int64(3), input).Return(
+		&order.Shipment{ID: 5, OrderID: 100, SubOrderID: 3, Carrier: input.Carrier, TrackingNumber: input.TrackingNumber, Status: order.ShipmentInTransit}, nil,
+	).Times(1)
+
+	result, err := uc.SetSellerShipmentTracking(auth.SetUserID(context.Background(), 20), 100, input)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), result.SubOrderID)
+	assert.Equal(t, input.TrackingNumber, result.TrackingNumber)
+}
+
+func setup(t *testing.T) (orderusecase.OrderUsecase, *orderrepository.MockOrderRepository, *productrepository.MockProductRepository, *cartrepository.MockCartRepository) {
+	t.Helper()
+
+	uc, orderRepo, prodRepo, cartRepo, invRepo := setupWithInventory(t)
+	// No stock held by other carts
+	invRepo.EXPECT().ReservedQuantity(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(0, nil).AnyTimes()
+	invRepo.EXPECT().InsertMovement(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	// Single warehouse holding all stock
+	invRepo.EXPECT().ListWarehouses(gomock.Any(), true).Return([]*inventory.Warehouse{mockWarehouse()}, nil).AnyTimes()
+	invRepo.EXPECT().DefaultWarehouse(gomock.Any(), gomock.Any()).Return(mockWarehouse(), nil).AnyTimes()
+	invRepo.EXPECT().StockLevels(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ database.DBExec, productIDs []int64) ([]*inventory.StockLevel, error) {
+			levels := make([]*inventory.StockLevel, 0, len(productIDs))
+			for _, id := range productIDs {
+				levels = append(levels, &inventory.StockLevel{WarehouseID: mockWarehouse().ID, ProductID: id, Quantity: 1000})
+			}
+			return levels, nil
+		},
+	).AnyTimes()
+	invRepo.EXPECT().AdjustWarehouseStock(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	return uc, orderRepo, prodRepo, cartRepo
+}
+
+func setupWithInventory(t *testing.T) (orderusecase.OrderUsecase, *orderrepository.MockOrderRepository, *productrepository.MockProductRepository, *cartrepository.MockCartRepository, *inventoryrepository.MockInventoryRepository) {
+	t.Helper()
+
+	return setupWithStrategy(t, inventory.PriorityStrategy{})
+}
+
+func setupWithStrategy(t *testing.T, strategy inventory.AllocationStrategy) (orderusecase.OrderUsecase, *orderrepository.MockOrderRepository, *productrepository.MockProductRepository, *cartrepository.MockCartRepository, *inventoryrepository.MockInventoryRepository) {
+	t.Helper()
+
+	return setupWithNotifier(t, strategy, &mockNotifier{})
+}
+
+func setupWithNotifier(t *testing.T, strategy inventory.AllocationStrategy, notifier notify.Notifier) (orderusecase.OrderUsecase, *orderrepository.MockOrderRepository, *productrepository.MockProductRepository, *cartrepository.MockCartRepository, *inventoryrepository.MockInventoryRepository) {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	orderRepo := orderrepository.NewMockOrderRepository(ctrl)
+	cartRepo := cartrepository.NewMockCartRepository(ctrl)
+	prodRepo := productrepository.NewMockProductRepository(ctrl)
+	invRepo := inventoryrepository.NewMockInventoryRepository(ctrl)
+	shippingRepo := shippingrepository.NewMockShippingRepository(ctrl)
+	mockTx := &mockTxManager{}
+	mockDB := &mockDB{}
+
+	shippingRepo.EXPECT().GetRate(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(mockRate).AnyTimes()
+	shippingUc, err := shippingusecase.NewShippingUsecase(&shippingusecase.ShippingUsecaseConfig{
+		Repo:        shippingRepo,
+		HomeCountry: "TH",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uc := orderusecase.NewOrderUsecase(orderRepo, prodRepo, cartRepo, invRepo, strategy, mockJWT(t), notifier, "http://localhost:3000/orders/claim", carrier.NewStubTracker(72*time.Hour), shippingUc, mockTx, mockDB)
+
+	return uc, orderRepo, prodRepo, cartRepo, invRepo
+}
+
+type mockOrderInput struct {
+	user    *jwt.UserClaims
+	orderID int64
+	status  string
+}
+
+func mockOrder() *order.Order {
+	return &order.Order{
 		ID:     100,
 		UserID: 10,
 		Status: string(order.StatusPending),
 	}
 }
 
+// mockSubOrders single seller order
+func mockSubOrders(o *order.Order) []*order.SubOrder {
+	return []*order.SubOrder{
+		{ID: 1, OrderID: o.ID, SellerID: 20, Total: o.Total, Status: o.Status},
+	}
+}
+
+// mockRate standard domestic shipping is free, pickup is domestic only
+func mockRate(_ context.Context, method shipping.Method, zone shipping.Zone) (*shipping.Rate, error) {
+	rates := []*shipping.Rate{
+		{Method: shipping.MethodStandard, Zone: shipping.ZoneDomestic},
+		{Method: shipping.MethodExpress, Zone: shipping.ZoneDomestic, BaseFee: 120, PerKgFee: 20, MaxWeightGrams: 20000},
+		{Method: shipping.MethodPickup, Zone: shipping.ZoneDomestic},
+		{Method: shipping.MethodStandard, Zone: shipping.ZoneInternational, BaseFee: 600, PerKgFee: 300, FreeOver: 5000},
+	}
+	for _, r := range rates {
+		if r.Method == method && r.Zone == zone {
+			return r, nil
+		}
+	}
+	return nil, errs.ErrShippingRateNotFound
+}
+
+func mockWarehouse() *inventory.Warehouse {
+	return &inventory.Warehouse{ID: 1, Code: "MAIN", IsDefault: true, IsActive: true}
+}
+
+func mockShipping() *order.ShippingAddress {
+	return &order.ShippingAddress{
+		Name:       "Guest",
+		Phone:      "0800000000",
+		Address:    "1 Main Road",
+		City:       "Bangkok",
+		PostalCode: "10110",
+		Country:    "TH",
+	}
+}
+
+// mockGuest session of a guest cart
+func mockGuest() context.Context {
+	return context.WithValue(context.Background(), consts.SessionIDKey, "session-001")
+}
+
+// mockMember account with the email of the guest orders
+func mockMember() context.Context {
+	return auth.SetCurrentUser(context.Background(), &jwt.UserClaims{ID: 10, Email: "guest@mail.com", Role: "user"})
+}
+
+func mockJWT(t *testing.T) *jwt.JWTToken {
+	t.Helper()
+
+	token, err := jwt.InitJWT(config.JWTConfig{
+		SecretKey:  "mock_secret_key",
+		RefreshKey: "mock_refresh_key",
+	})
+	if err != nil {
+		t.Fatalf("init jwt failed: %v", err)
+	}
+	return token
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
 type mockTxManager struct{}
 
 func (m *mockTxManager) WithTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
@@ -564,3 +2235,12 @@ func (m *mockDB) QueryContext(ctx context.Context, query string, args ...any) (*
 func (m *mockDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
 	return nil, nil
 }
+
+type mockNotifier struct {
+	sent []*notify.Message
+}
+
+func (m *mockNotifier) Send(ctx context.Context, msg *notify.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
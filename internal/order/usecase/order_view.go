@@ -1,11 +1,28 @@
 package orderusecase
 
+import "github.com/codepnw/mini-ecommerce/internal/order"
+
 type OrderView struct {
-	ID        int64            `json:"id"`
-	Status    string           `json:"status"`
-	Total     float64          `json:"total"`
-	CreatedAt string           `json:"created_at"`
-	Items     []*OrderItemView `json:"items"`
+	ID        int64             `json:"id"`
+	Status    string            `json:"status"`
+	Total     float64           `json:"total"`
+	CreatedAt string            `json:"created_at"`
+	Items     []*OrderItemView  `json:"items"`
+	SubOrders []*SubOrderView   `json:"sub_orders"`
+	Shipments []*order.Shipment `json:"shipments"`
+
+	Shipping *order.ShippingAddress `json:"shipping,omitempty"`
+	// Included in Total
+	ShippingMethod string  `json:"shipping_method,omitempty"`
+	ShippingAmount float64 `json:"shipping_amount"`
+}
+
+// SubOrderView one seller's part, items point to it by SubOrderID
+type SubOrderView struct {
+	ID       int64   `json:"id"`
+	SellerID int64   `json:"seller_id"`
+	Status   string  `json:"status"`
+	Total    float64 `json:"total"`
 }
 
 type OrderItemView struct {
@@ -16,6 +33,8 @@ type OrderItemView struct {
 	ProductID       int64   `json:"product_id"`
 	ProductName     string  `json:"product_name"`
 	ProductSKU      string  `json:"product_sku"`
+	Warehouse       string  `json:"warehouse,omitempty"`
+	SubOrderID      int64   `json:"sub_order_id,omitempty"`
 }
 
 type OrderListView struct {
@@ -24,3 +43,9 @@ type OrderListView struct {
 	Total     float64 `json:"total"`
 	CreatedAt string  `json:"created_at"`
 }
+
+// GuestOrderView LookupToken is shown once, GET /guest/orders/lookup needs it
+type GuestOrderView struct {
+	Order       *order.Order `json:"order"`
+	LookupToken string       `json:"lookup_token"`
+}
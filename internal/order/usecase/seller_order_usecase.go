@@ -0,0 +1,111 @@
+package orderusecase
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/codepnw/mini-ecommerce/internal/order"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/pkg/auth"
+	"github.com/codepnw/mini-ecommerce/pkg/database"
+)
+
+// GetSellerOrders orders holding the current seller's products, other
+// sellers' lines are left out
+func (u *orderUsecase) GetSellerOrders(ctx context.Context, filter *order.SellerOrderFilter) ([]*order.SellerOrder, error) {
+	sellerID := auth.GetUserID(ctx)
+	if sellerID == 0 {
+		return nil, errs.ErrUnauthorized
+	}
+
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 10
+	}
+	filter.SellerID = sellerID
+
+	orders, err := u.orderRepo.ListSellerOrders(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return orders, nil
+	}
+
+	orderIDs := make([]int64, 0, len(orders))
+	for _, o := range orders {
+		orderIDs = append(orderIDs, o.ID)
+	}
+	items, err := u.orderRepo.GetSellerOrderItems(ctx, u.db, sellerID, orderIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byOrder := make(map[int64][]*order.SellerOrderItem)
+	for _, i := range items {
+		byOrder[i.OrderID] = append(byOrder[i.OrderID], i)
+	}
+	for _, o := range orders {
+		o.Items = byOrder[o.ID]
+	}
+	return orders, nil
+}
+
+func (u *orderUsecase) GetSellerOrder(ctx context.Context, orderID int64) (*order.SellerOrder, error) {
+	sellerID := auth.GetUserID(ctx)
+	if sellerID == 0 {
+		return nil, errs.ErrUnauthorized
+	}
+	return u.sellerOrder(ctx, u.db, sellerID, orderID)
+}
+
+// ShipSellerOrder ships the seller's sub-order of a paid order
+func (u *orderUsecase) ShipSellerOrder(ctx context.Context, orderID int64, shipment *order.ShipmentInput) (*order.SellerOrder, error) {
+	sellerID := auth.GetUserID(ctx)
+	if sellerID == 0 {
+		return nil, errs.ErrUnauthorized
+	}
+
+	var result *order.SellerOrder
+
+	err := u.tx.WithTransaction(ctx, func(tx *sql.Tx) error {
+		// Lock Order
+		if _, err := u.orderRepo.GetStatusForUpdate(ctx, tx, orderID); err != nil {
+			return err
+		}
+
+		current, err := u.orderRepo.GetSellerOrder(ctx, tx, sellerID, orderID)
+		if err != nil {
+			return err
+		}
+		if current.Status == string(order.StatusPending) {
+			return errs.ErrOrderNotPaid
+		}
+
+		err = u.moveSubOrders(ctx, tx, orderID, sellerID, order.StatusShipped, &current.SubOrderID, shipment)
+		if err != nil {
+			return err
+		}
+
+		result, err = u.sellerOrder(ctx, tx, sellerID, orderID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (u *orderUsecase) sellerOrder(ctx context.Context, exec database.DBExec, sellerID, orderID int64) (*order.SellerOrder, error) {
+	o, err := u.orderRepo.GetSellerOrder(ctx, exec, sellerID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	o.Items, err = u.orderRepo.GetSellerOrderItems(ctx, exec, sellerID, []int64{orderID})
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
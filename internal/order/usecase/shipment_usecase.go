@@ -0,0 +1,167 @@
+package orderusecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/codepnw/mini-ecommerce/internal/order"
+	"github.com/codepnw/mini-ecommerce/internal/user"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/pkg/auth"
+	"github.com/codepnw/mini-ecommerce/pkg/carrier"
+)
+
+// SetShipmentTracking admin change of the carrier & tracking number of a
+// sub-order's shipment in transit
+func (u *orderUsecase) SetShipmentTracking(ctx context.Context, orderID, subOrderID int64, input *order.ShipmentInput) (*order.Shipment, error) {
+	currentUser, err := auth.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, errs.ErrUnauthorized
+	}
+	if currentUser.Role != string(user.RoleAdmin) {
+		return nil, errs.ErrNoPermissions
+	}
+
+	var result *order.Shipment
+
+	err = u.tx.WithTransaction(ctx, func(tx *sql.Tx) error {
+		// Lock Order
+		if _, err := u.orderRepo.GetStatusForUpdate(ctx, tx, orderID); err != nil {
+			return err
+		}
+
+		subs, err := u.orderRepo.ListSubOrders(ctx, tx, orderID)
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, s := range subs {
+			found = found || s.ID == subOrderID
+		}
+		if !found {
+			return errs.ErrSubOrderNotFound
+		}
+
+		result, err = u.orderRepo.SetShipmentTracking(ctx, tx, subOrderID, input)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SetSellerShipmentTracking the seller's own shipment of the order
+func (u *orderUsecase) SetSellerShipmentTracking(ctx context.Context, orderID int64, input *order.ShipmentInput) (*order.Shipment, error) {
+	sellerID := auth.GetUserID(ctx)
+	if sellerID == 0 {
+		return nil, errs.ErrUnauthorized
+	}
+
+	var result *order.Shipment
+
+	err := u.tx.WithTransaction(ctx, func(tx *sql.Tx) error {
+		// Lock Order
+		if _, err := u.orderRepo.GetStatusForUpdate(ctx, tx, orderID); err != nil {
+			return err
+		}
+
+		current, err := u.orderRepo.GetSellerOrder(ctx, tx, sellerID, orderID)
+		if err != nil {
+			return err
+		}
+
+		result, err = u.orderRepo.SetShipmentTracking(ctx, tx, current.SubOrderID, input)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// TrackShipments polls the carrier for shipments in transit, delivered
+// ones complete their sub-order. Returns the number delivered, a failed
+// poll does not stop the others.
+func (u *orderUsecase) TrackShipments(ctx context.Context) (int, error) {
+	shipments, err := u.orderRepo.ListShipmentsToTrack(ctx, consts.ShipmentTrackBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	var failed []error
+	for _, s := range shipments {
+		result, err := u.tracker.Track(ctx, &carrier.TrackRequest{
+			Carrier:        s.Carrier,
+			TrackingNumber: s.TrackingNumber,
+			ShippedAt:      s.ShippedAt,
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return delivered, ctx.Err()
+			}
+			failed = append(failed, fmt.Errorf("shipment #%d: %w", s.ID, err))
+		}
+		if err != nil || result.Status != carrier.StatusDelivered {
+			// Others are polled first next run
+			if err := u.orderRepo.SetShipmentChecked(ctx, s.ID); err != nil {
+				return delivered, err
+			}
+			continue
+		}
+
+		deliveredAt := time.Now()
+		if result.DeliveredAt != nil {
+			deliveredAt = *result.DeliveredAt
+		}
+		err = u.deliverShipment(ctx, s, deliveredAt)
+		if errors.Is(err, errs.ErrShipmentNotFound) {
+			// Delivered by another run meanwhile
+			continue
+		}
+		if err != nil {
+			return delivered, err
+		}
+		delivered++
+	}
+	return delivered, errors.Join(failed...)
+}
+
+// deliverShipment marks the shipment delivered and completes its sub-order
+func (u *orderUsecase) deliverShipment(ctx context.Context, s *order.Shipment, deliveredAt time.Time) error {
+	return u.tx.WithTransaction(ctx, func(tx *sql.Tx) error {
+		// Lock Order
+		if _, err := u.orderRepo.GetStatusForUpdate(ctx, tx, s.OrderID); err != nil {
+			return err
+		}
+		if err := u.orderRepo.SetShipmentDelivered(ctx, tx, s.ID, deliveredAt); err != nil {
+			return err
+		}
+
+		err := u.moveSubOrders(ctx, tx, s.OrderID, 0, order.StatusCompleted, &s.SubOrderID, nil)
+		if errors.Is(err, errs.ErrInvalidStatusChange) {
+			// Completed by an admin or refunded meanwhile
+			return nil
+		}
+		return err
+	})
+}
+
+// createShipment of a sub-order that just shipped
+func (u *orderUsecase) createShipment(ctx context.Context, tx *sql.Tx, s *order.SubOrder, input *order.ShipmentInput) error {
+	shipment := &order.Shipment{
+		OrderID:    s.OrderID,
+		SubOrderID: s.ID,
+		Status:     order.ShipmentInTransit,
+	}
+	if input != nil {
+		shipment.Carrier = input.Carrier
+		shipment.TrackingNumber = input.TrackingNumber
+	}
+	return u.orderRepo.InsertShipment(ctx, tx, shipment)
+}
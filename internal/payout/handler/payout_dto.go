@@ -0,0 +1,13 @@
+package payouthandler
+
+type CommissionReq struct {
+	Scope    string  `json:"scope" binding:"required,oneof=default seller category"`
+	SellerID int64   `json:"seller_id" binding:"omitempty,gt=0"`
+	Category string  `json:"category" binding:"omitempty,max=50"`
+	Rate     float64 `json:"rate" binding:"gte=0,lte=1"`
+}
+
+type BatchStatusReq struct {
+	Status    string `json:"status" binding:"required,oneof=paid failed"`
+	Reference string `json:"reference" binding:"omitempty,max=100"`
+}
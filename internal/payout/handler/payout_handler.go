@@ -0,0 +1,241 @@
+package payouthandler
+
+import (
+	"github.com/codepnw/mini-ecommerce/internal/payout"
+	payoutusecase "github.com/codepnw/mini-ecommerce/internal/payout/usecase"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/internal/utils/helper"
+	"github.com/codepnw/mini-ecommerce/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+type payoutHandler struct {
+	uc payoutusecase.PayoutUsecase
+}
+
+func NewPayoutHandler(uc payoutusecase.PayoutUsecase) *payoutHandler {
+	return &payoutHandler{uc: uc}
+}
+
+func (h *payoutHandler) GetSellerPayouts(c *gin.Context) {
+	filter := new(payout.BatchFilter)
+	if err := c.ShouldBindQuery(filter); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.GetSellerPayouts(c.Request.Context(), filter)
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "", result)
+}
+
+func (h *payoutHandler) GetStatement(c *gin.Context) {
+	filter := new(payout.StatementFilter)
+	if err := c.ShouldBindQuery(filter); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.GetStatement(c.Request.Context(), filter)
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrInvalidDateRange:
+			response.BadRequest(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "", result)
+}
+
+func (h *payoutHandler) ListCommissionRules(c *gin.Context) {
+	result, err := h.uc.ListCommissionRules(c.Request.Context())
+	if err != nil {
+		switch err {
+		case errs.ErrNoPermissions:
+			response.Forbidden(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "", result)
+}
+
+func (h *payoutHandler) SetCommissionRule(c *gin.Context) {
+	req := new(CommissionReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	input := &payout.CommissionRule{
+		Scope:    payout.CommissionScope(req.Scope),
+		SellerID: req.SellerID,
+		Category: req.Category,
+		Rate:     req.Rate,
+	}
+
+	result, err := h.uc.SetCommissionRule(c.Request.Context(), input)
+	if err != nil {
+		switch err {
+		case errs.ErrNoPermissions:
+			response.Forbidden(c, err.Error())
+			return
+		case errs.ErrCommissionRateInvalid, errs.ErrCommissionScopeInvalid:
+			response.BadRequest(c, err.Error())
+			return
+		case errs.ErrUserNotFound:
+			response.NotFound(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "commission saved", result)
+}
+
+func (h *payoutHandler) DeleteCommissionRule(c *gin.Context) {
+	ruleID, err := helper.GetParamInt(c, consts.ParamCommissionID)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.uc.DeleteCommissionRule(c.Request.Context(), ruleID); err != nil {
+		switch err {
+		case errs.ErrNoPermissions:
+			response.Forbidden(c, err.Error())
+			return
+		case errs.ErrCommissionNotFound:
+			response.NotFound(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.NoContent(c)
+}
+
+func (h *payoutHandler) ListBatches(c *gin.Context) {
+	filter := new(payout.BatchFilter)
+	if err := c.ShouldBindQuery(filter); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.ListBatches(c.Request.Context(), filter)
+	if err != nil {
+		switch err {
+		case errs.ErrNoPermissions:
+			response.Forbidden(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "", result)
+}
+
+func (h *payoutHandler) GetBatch(c *gin.Context) {
+	batchID, err := helper.GetParamInt(c, consts.ParamBatchID)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.GetBatch(c.Request.Context(), batchID)
+	if err != nil {
+		switch err {
+		case errs.ErrNoPermissions:
+			response.Forbidden(c, err.Error())
+			return
+		case errs.ErrPayoutBatchNotFound:
+			response.NotFound(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "", result)
+}
+
+func (h *payoutHandler) CreateBatch(c *gin.Context) {
+	result, err := h.uc.CreateBatch(c.Request.Context())
+	if err != nil {
+		switch err {
+		case errs.ErrNoPermissions:
+			response.Forbidden(c, err.Error())
+			return
+		case errs.ErrNothingToPayout:
+			response.Conflict(c, err.Error(), nil)
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.Created(c, result)
+}
+
+func (h *payoutHandler) UpdateBatchStatus(c *gin.Context) {
+	batchID, err := helper.GetParamInt(c, consts.ParamBatchID)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	req := new(BatchStatusReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	input := &payout.BatchUpdate{
+		ID:        batchID,
+		Status:    payout.BatchStatus(req.Status),
+		Reference: req.Reference,
+	}
+
+	result, err := h.uc.UpdateBatchStatus(c.Request.Context(), input)
+	if err != nil {
+		switch err {
+		case errs.ErrNoPermissions:
+			response.Forbidden(c, err.Error())
+			return
+		case errs.ErrPayoutBatchNotFound:
+			response.NotFound(c, err.Error())
+			return
+		case errs.ErrInvalidStatusChange:
+			response.BadRequest(c, err.Error())
+			return
+		case errs.ErrPayoutBatchNotPending:
+			response.Conflict(c, err.Error(), nil)
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "payout batch updated", result)
+}
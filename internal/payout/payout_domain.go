@@ -0,0 +1,336 @@
+package payout
+
+import (
+	"math"
+	"time"
+)
+
+type CommissionScope string
+
+const (
+	ScopeDefault  CommissionScope = "default"
+	ScopeSeller   CommissionScope = "seller"
+	ScopeCategory CommissionScope = "category"
+)
+
+// CommissionRule Rate is a fraction of the sale, 0.10 is 10%
+type CommissionRule struct {
+	ID        int64           `json:"id"`
+	Scope     CommissionScope `json:"scope"`
+	SellerID  int64           `json:"seller_id,omitempty"`
+	Category  string          `json:"category,omitempty"`
+	Rate      float64         `json:"rate"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// CommissionRules rates by scope, a seller override wins over a category
+// override and a category override over the default
+type CommissionRules struct {
+	Default    float64
+	Sellers    map[int64]float64
+	Categories map[string]float64
+}
+
+func NewCommissionRules(rules []*CommissionRule) *CommissionRules {
+	r := &CommissionRules{
+		Sellers:    make(map[int64]float64),
+		Categories: make(map[string]float64),
+	}
+	for _, rule := range rules {
+		switch rule.Scope {
+		case ScopeDefault:
+			r.Default = rule.Rate
+		case ScopeSeller:
+			r.Sellers[rule.SellerID] = rule.Rate
+		case ScopeCategory:
+			r.Categories[rule.Category] = rule.Rate
+		}
+	}
+	return r
+}
+
+func (r *CommissionRules) RateFor(sellerID int64, category string) float64 {
+	if rate, ok := r.Sellers[sellerID]; ok {
+		return rate
+	}
+	if rate, ok := r.Categories[category]; ok && category != "" {
+		return rate
+	}
+	return r.Default
+}
+
+// Sale a completed sub-order waiting for its earning to be posted
+type Sale struct {
+	SubOrderID int64
+	OrderID    int64
+	SellerID   int64
+	Lines      []*SaleLine
+}
+
+type SaleLine struct {
+	ProductID int64
+	Category  string
+	Amount    float64
+}
+
+// Earning gross of the sale and the commission of each line
+func (s *Sale) Earning(rules *CommissionRules) (gross, fee float64) {
+	for _, l := range s.Lines {
+		gross += l.Amount
+		fee += l.Amount * rules.RateFor(s.SellerID, l.Category)
+	}
+	return roundCents(gross), roundCents(fee)
+}
+
+// Earning posted for a sub-order, refunds give back their share of Fee
+type Earning struct {
+	SubOrderID int64
+	SellerID   int64
+	Gross      float64
+	Fee        float64
+}
+
+type Account string
+
+const (
+	// Paid by customers, not yet split between seller & platform
+	AccountOrderClearing Account = "order_clearing"
+	// Owed to the seller, the seller balance
+	AccountSellerPayable Account = "seller_payable"
+	AccountPlatformFees  Account = "platform_fees"
+	// Batched for payout, not yet paid out
+	AccountPayoutsPayable Account = "payouts_payable"
+	AccountCash           Account = "cash"
+)
+
+type TransactionKind string
+
+const (
+	KindEarning        TransactionKind = "earning"
+	KindRefund         TransactionKind = "refund"
+	KindPayout         TransactionKind = "payout"
+	KindPayoutPaid     TransactionKind = "payout_paid"
+	KindPayoutReversal TransactionKind = "payout_reversal"
+)
+
+// Reference types of a ledger transaction
+const (
+	RefSubOrder = "sub_order"
+	RefRefund   = "refund"
+	RefPayout   = "payout"
+)
+
+type Entry struct {
+	Account Account `json:"account"`
+	Debit   float64 `json:"debit"`
+	Credit  float64 `json:"credit"`
+}
+
+// Transaction one posting, kind & ref are unique so an event posts once
+type Transaction struct {
+	ID        int64           `json:"id"`
+	Kind      TransactionKind `json:"kind"`
+	SellerID  int64           `json:"seller_id"`
+	RefType   string          `json:"ref_type"`
+	RefID     int64           `json:"ref_id"`
+	Memo      string          `json:"memo,omitempty"`
+	Entries   []*Entry        `json:"entries"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Balanced debits equal credits, compared in cents. A zero amount sale
+// posts without entries.
+func (t *Transaction) Balanced() bool {
+	var debit, credit int64
+	for _, e := range t.Entries {
+		debit += cents(e.Debit)
+		credit += cents(e.Credit)
+	}
+	return debit == credit
+}
+
+// transfer moves amount from the debited to the credited account,
+// zero amounts are left out
+func (t *Transaction) transfer(from, to Account, amount float64) {
+	if cents(amount) <= 0 {
+		return
+	}
+	t.Entries = append(t.Entries,
+		&Entry{Account: from, Debit: roundCents(amount)},
+		&Entry{Account: to, Credit: roundCents(amount)},
+	)
+}
+
+// NewEarning the seller is credited the gross and debited the commission
+func NewEarning(s *Sale, gross, fee float64) *Transaction {
+	t := &Transaction{Kind: KindEarning, SellerID: s.SellerID, RefType: RefSubOrder, RefID: s.SubOrderID}
+	t.transfer(AccountOrderClearing, AccountSellerPayable, gross)
+	t.transfer(AccountSellerPayable, AccountPlatformFees, fee)
+	return t
+}
+
+// NewRefund the seller is debited the refund and credited back the
+// commission taken on it
+func NewRefund(sellerID, refundID int64, amount, fee float64) *Transaction {
+	t := &Transaction{Kind: KindRefund, SellerID: sellerID, RefType: RefRefund, RefID: refundID}
+	t.transfer(AccountSellerPayable, AccountOrderClearing, amount)
+	t.transfer(AccountPlatformFees, AccountSellerPayable, fee)
+	return t
+}
+
+// RefundFee commission given back on a refund, the share of the
+// refund in the posted earning
+func RefundFee(amount, gross, fee float64) float64 {
+	if gross <= 0 {
+		return 0
+	}
+	return roundCents(fee * math.Min(amount, gross) / gross)
+}
+
+// NewPayout takes the amount out of the seller balance into a batch
+func NewPayout(p *Payout) *Transaction {
+	t := &Transaction{Kind: KindPayout, SellerID: p.SellerID, RefType: RefPayout, RefID: p.ID}
+	t.transfer(AccountSellerPayable, AccountPayoutsPayable, p.Amount)
+	return t
+}
+
+// NewPayoutSettled a paid batch leaves the platform, a failed batch goes
+// back to the seller balance
+func NewPayoutSettled(p *Payout, status BatchStatus) *Transaction {
+	if status == BatchFailed {
+		t := &Transaction{Kind: KindPayoutReversal, SellerID: p.SellerID, RefType: RefPayout, RefID: p.ID}
+		t.transfer(AccountPayoutsPayable, AccountSellerPayable, p.Amount)
+		return t
+	}
+	t := &Transaction{Kind: KindPayoutPaid, SellerID: p.SellerID, RefType: RefPayout, RefID: p.ID}
+	t.transfer(AccountPayoutsPayable, AccountCash, p.Amount)
+	return t
+}
+
+type BatchStatus string
+
+const (
+	BatchPending BatchStatus = "pending"
+	BatchPaid    BatchStatus = "paid"
+	BatchFailed  BatchStatus = "failed"
+)
+
+type Batch struct {
+	ID        int64       `json:"id"`
+	Status    BatchStatus `json:"status"`
+	Total     float64     `json:"total"`
+	Reference string      `json:"reference,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+	PaidAt    *time.Time  `json:"paid_at,omitempty"`
+	Payouts   []*Payout   `json:"payouts,omitempty"`
+}
+
+// Payout the seller's part of a batch, Status & PaidAt come from the batch
+type Payout struct {
+	ID        int64       `json:"id"`
+	BatchID   int64       `json:"batch_id"`
+	SellerID  int64       `json:"seller_id"`
+	Amount    float64     `json:"amount"`
+	Status    BatchStatus `json:"status"`
+	CreatedAt time.Time   `json:"created_at"`
+	PaidAt    *time.Time  `json:"paid_at,omitempty"`
+}
+
+// NewBatch pending batch paying out the balances
+func NewBatch(payouts []*Payout) *Batch {
+	b := &Batch{Status: BatchPending, Payouts: payouts}
+	for _, p := range payouts {
+		p.Status = BatchPending
+		b.Total += p.Amount
+	}
+	b.Total = roundCents(b.Total)
+	return b
+}
+
+// BatchUpdate settles a pending batch as paid or failed
+type BatchUpdate struct {
+	ID        int64
+	Status    BatchStatus
+	Reference string
+}
+
+type BatchFilter struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending paid failed"`
+	Page   int    `form:"page"`
+	Limit  int    `form:"limit"`
+}
+
+// Balance Available is the seller_payable balance, it is negative when
+// refunds exceed earnings since the last payout
+type Balance struct {
+	SellerID  int64   `json:"seller_id"`
+	Available float64 `json:"available"`
+	Pending   float64 `json:"pending"`
+	PaidOut   float64 `json:"paid_out"`
+}
+
+// SellerPayouts GET /seller/payouts
+type SellerPayouts struct {
+	Balance *Balance  `json:"balance"`
+	Payouts []*Payout `json:"payouts"`
+}
+
+type StatementFilter struct {
+	From     time.Time `form:"from" time_format:"2006-01-02"`
+	To       time.Time `form:"to" time_format:"2006-01-02"`
+	Page     int       `form:"page"`
+	Limit    int       `form:"limit"`
+	SellerID int64     `form:"-"`
+}
+
+type StatementLineType string
+
+const (
+	LineEarning   StatementLineType = "earning"
+	LineFee       StatementLineType = "fee"
+	LineRefund    StatementLineType = "refund"
+	LineFeeRefund StatementLineType = "fee_refund"
+	LinePayout    StatementLineType = "payout"
+	LineReversal  StatementLineType = "payout_reversal"
+)
+
+// StatementLine one seller_payable entry, Amount is positive for money
+// owed to the seller
+type StatementLine struct {
+	TransactionID int64             `json:"transaction_id"`
+	Type          StatementLineType `json:"type"`
+	RefType       string            `json:"ref_type"`
+	RefID         int64             `json:"ref_id"`
+	Amount        float64           `json:"amount"`
+	CreatedAt     time.Time         `json:"created_at"`
+}
+
+// LineType a transaction kind has at most one debit & one credit
+// line on the seller account
+func LineType(kind TransactionKind, credit bool) StatementLineType {
+	switch kind {
+	case KindEarning:
+		if credit {
+			return LineEarning
+		}
+		return LineFee
+	case KindRefund:
+		if credit {
+			return LineFeeRefund
+		}
+		return LineRefund
+	case KindPayoutReversal:
+		return LineReversal
+	}
+	return LinePayout
+}
+
+func cents(v float64) int64 {
+	return int64(math.Round(v * 100))
+}
+
+func roundCents(v float64) float64 {
+	return float64(cents(v)) / 100
+}
@@ -0,0 +1,343 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: payout_repository.go
+
+// Package payoutrepository is a generated GoMock package.
+package payoutrepository
+
+import (
+	context "context"
+	sql "database/sql"
+	reflect "reflect"
+
+	payout "github.com/codepnw/mini-ecommerce/internal/payout"
+	database "github.com/codepnw/mini-ecommerce/pkg/database"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockPayoutRepository is a mock of PayoutRepository interface.
+type MockPayoutRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockPayoutRepositoryMockRecorder
+}
+
+// MockPayoutRepositoryMockRecorder is the mock recorder for MockPayoutRepository.
+type MockPayoutRepositoryMockRecorder struct {
+	mock *MockPayoutRepository
+}
+
+// NewMockPayoutRepository creates a new mock instance.
+func NewMockPayoutRepository(ctrl *gomock.Controller) *MockPayoutRepository {
+	mock := &MockPayoutRepository{ctrl: ctrl}
+	mock.recorder = &MockPayoutRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPayoutRepository) EXPECT() *MockPayoutRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Balance mocks base method.
+func (m *MockPayoutRepository) Balance(ctx context.Context, sellerID int64) (*payout.Balance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Balance", ctx, sellerID)
+	ret0, _ := ret[0].(*payout.Balance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Balance indicates an expected call of Balance.
+func (mr *MockPayoutRepositoryMockRecorder) Balance(ctx, sellerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Balance", reflect.TypeOf((*MockPayoutRepository)(nil).Balance), ctx, sellerID)
+}
+
+// DeleteCommissionRule mocks base method.
+func (m *MockPayoutRepository) DeleteCommissionRule(ctx context.Context, ruleID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCommissionRule", ctx, ruleID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCommissionRule indicates an expected call of DeleteCommissionRule.
+func (mr *MockPayoutRepositoryMockRecorder) DeleteCommissionRule(ctx, ruleID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCommissionRule", reflect.TypeOf((*MockPayoutRepository)(nil).DeleteCommissionRule), ctx, ruleID)
+}
+
+// EarningOf mocks base method.
+func (m *MockPayoutRepository) EarningOf(ctx context.Context, exec database.DBExec, subOrderID int64) (*payout.Earning, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EarningOf", ctx, exec, subOrderID)
+	ret0, _ := ret[0].(*payout.Earning)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EarningOf indicates an expected call of EarningOf.
+func (mr *MockPayoutRepositoryMockRecorder) EarningOf(ctx, exec, subOrderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EarningOf", reflect.TypeOf((*MockPayoutRepository)(nil).EarningOf), ctx, exec, subOrderID)
+}
+
+// GetBatch mocks base method.
+func (m *MockPayoutRepository) GetBatch(ctx context.Context, exec database.DBExec, batchID int64) (*payout.Batch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBatch", ctx, exec, batchID)
+	ret0, _ := ret[0].(*payout.Batch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBatch indicates an expected call of GetBatch.
+func (mr *MockPayoutRepositoryMockRecorder) GetBatch(ctx, exec, batchID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBatch", reflect.TypeOf((*MockPayoutRepository)(nil).GetBatch), ctx, exec, batchID)
+}
+
+// GetBatchForUpdate mocks base method.
+func (m *MockPayoutRepository) GetBatchForUpdate(ctx context.Context, tx *sql.Tx, batchID int64) (*payout.Batch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBatchForUpdate", ctx, tx, batchID)
+	ret0, _ := ret[0].(*payout.Batch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBatchForUpdate indicates an expected call of GetBatchForUpdate.
+func (mr *MockPayoutRepositoryMockRecorder) GetBatchForUpdate(ctx, tx, batchID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBatchForUpdate", reflect.TypeOf((*MockPayoutRepository)(nil).GetBatchForUpdate), ctx, tx, batchID)
+}
+
+// InsertBatch mocks base method.
+func (m *MockPayoutRepository) InsertBatch(ctx context.Context, tx *sql.Tx, input *payout.Batch) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertBatch", ctx, tx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertBatch indicates an expected call of InsertBatch.
+func (mr *MockPayoutRepositoryMockRecorder) InsertBatch(ctx, tx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertBatch", reflect.TypeOf((*MockPayoutRepository)(nil).InsertBatch), ctx, tx, input)
+}
+
+// InsertPayout mocks base method.
+func (m *MockPayoutRepository) InsertPayout(ctx context.Context, tx *sql.Tx, input *payout.Payout) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertPayout", ctx, tx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertPayout indicates an expected call of InsertPayout.
+func (mr *MockPayoutRepositoryMockRecorder) InsertPayout(ctx, tx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertPayout", reflect.TypeOf((*MockPayoutRepository)(nil).InsertPayout), ctx, tx, input)
+}
+
+// InsertTransaction mocks base method.
+func (m *MockPayoutRepository) InsertTransaction(ctx context.Context, tx *sql.Tx, input *payout.Transaction) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertTransaction", ctx, tx, input)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertTransaction indicates an expected call of InsertTransaction.
+func (mr *MockPayoutRepositoryMockRecorder) InsertTransaction(ctx, tx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertTransaction", reflect.TypeOf((*MockPayoutRepository)(nil).InsertTransaction), ctx, tx, input)
+}
+
+// ListBatchPayouts mocks base method.
+func (m *MockPayoutRepository) ListBatchPayouts(ctx context.Context, exec database.DBExec, batchID int64) ([]*payout.Payout, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBatchPayouts", ctx, exec, batchID)
+	ret0, _ := ret[0].([]*payout.Payout)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBatchPayouts indicates an expected call of ListBatchPayouts.
+func (mr *MockPayoutRepositoryMockRecorder) ListBatchPayouts(ctx, exec, batchID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBatchPayouts", reflect.TypeOf((*MockPayoutRepository)(nil).ListBatchPayouts), ctx, exec, batchID)
+}
+
+// ListBatches mocks base method.
+func (m *MockPayoutRepository) ListBatches(ctx context.Context, filter *payout.BatchFilter) ([]*payout.Batch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBatches", ctx, filter)
+	ret0, _ := ret[0].([]*payout.Batch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBatches indicates an expected call of ListBatches.
+func (mr *MockPayoutRepositoryMockRecorder) ListBatches(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBatches", reflect.TypeOf((*MockPayoutRepository)(nil).ListBatches), ctx, filter)
+}
+
+// ListCommissionRules mocks base method.
+func (m *MockPayoutRepository) ListCommissionRules(ctx context.Context) ([]*payout.CommissionRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCommissionRules", ctx)
+	ret0, _ := ret[0].([]*payout.CommissionRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCommissionRules indicates an expected call of ListCommissionRules.
+func (mr *MockPayoutRepositoryMockRecorder) ListCommissionRules(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCommissionRules", reflect.TypeOf((*MockPayoutRepository)(nil).ListCommissionRules), ctx)
+}
+
+// ListSellerPayouts mocks base method.
+func (m *MockPayoutRepository) ListSellerPayouts(ctx context.Context, sellerID int64, filter *payout.BatchFilter) ([]*payout.Payout, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSellerPayouts", ctx, sellerID, filter)
+	ret0, _ := ret[0].([]*payout.Payout)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSellerPayouts indicates an expected call of ListSellerPayouts.
+func (mr *MockPayoutRepositoryMockRecorder) ListSellerPayouts(ctx, sellerID, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSellerPayouts", reflect.TypeOf((*MockPayoutRepository)(nil).ListSellerPayouts), ctx, sellerID, filter)
+}
+
+// ListStatement mocks base method.
+func (m *MockPayoutRepository) ListStatement(ctx context.Context, filter *payout.StatementFilter) ([]*payout.StatementLine, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListStatement", ctx, filter)
+	ret0, _ := ret[0].([]*payout.StatementLine)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListStatement indicates an expected call of ListStatement.
+func (mr *MockPayoutRepositoryMockRecorder) ListStatement(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStatement", reflect.TypeOf((*MockPayoutRepository)(nil).ListStatement), ctx, filter)
+}
+
+// LockPayouts mocks base method.
+func (m *MockPayoutRepository) LockPayouts(ctx context.Context, tx *sql.Tx) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockPayouts", ctx, tx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockPayouts indicates an expected call of LockPayouts.
+func (mr *MockPayoutRepositoryMockRecorder) LockPayouts(ctx, tx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockPayouts", reflect.TypeOf((*MockPayoutRepository)(nil).LockPayouts), ctx, tx)
+}
+
+// PayableBalances mocks base method.
+func (m *MockPayoutRepository) PayableBalances(ctx context.Context, tx *sql.Tx, minAmount float64) ([]*payout.Payout, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PayableBalances", ctx, tx, minAmount)
+	ret0, _ := ret[0].([]*payout.Payout)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PayableBalances indicates an expected call of PayableBalances.
+func (mr *MockPayoutRepositoryMockRecorder) PayableBalances(ctx, tx, minAmount interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PayableBalances", reflect.TypeOf((*MockPayoutRepository)(nil).PayableBalances), ctx, tx, minAmount)
+}
+
+// PendingSales mocks base method.
+func (m *MockPayoutRepository) PendingSales(ctx context.Context, limit int) ([]*payout.Sale, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PendingSales", ctx, limit)
+	ret0, _ := ret[0].([]*payout.Sale)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PendingSales indicates an expected call of PendingSales.
+func (mr *MockPayoutRepositoryMockRecorder) PendingSales(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PendingSales", reflect.TypeOf((*MockPayoutRepository)(nil).PendingSales), ctx, limit)
+}
+
+// UpdateBatch mocks base method.
+func (m *MockPayoutRepository) UpdateBatch(ctx context.Context, tx *sql.Tx, input *payout.Batch) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBatch", ctx, tx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateBatch indicates an expected call of UpdateBatch.
+func (mr *MockPayoutRepositoryMockRecorder) UpdateBatch(ctx, tx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBatch", reflect.TypeOf((*MockPayoutRepository)(nil).UpdateBatch), ctx, tx, input)
+}
+
+// UpsertCommissionRule mocks base method.
+func (m *MockPayoutRepository) UpsertCommissionRule(ctx context.Context, input *payout.CommissionRule) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertCommissionRule", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertCommissionRule indicates an expected call of UpsertCommissionRule.
+func (mr *MockPayoutRepositoryMockRecorder) UpsertCommissionRule(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertCommissionRule", reflect.TypeOf((*MockPayoutRepository)(nil).UpsertCommissionRule), ctx, input)
+}
+
+// MockrowScanner is a mock of rowScanner interface.
+type MockrowScanner struct {
+	ctrl     *gomock.Controller
+	recorder *MockrowScannerMockRecorder
+}
+
+// MockrowScannerMockRecorder is the mock recorder for MockrowScanner.
+type MockrowScannerMockRecorder struct {
+	mock *MockrowScanner
+}
+
+// NewMockrowScanner creates a new mock instance.
+func NewMockrowScanner(ctrl *gomock.Controller) *MockrowScanner {
+	mock := &MockrowScanner{ctrl: ctrl}
+	mock.recorder = &MockrowScannerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockrowScanner) EXPECT() *MockrowScannerMockRecorder {
+	return m.recorder
+}
+
+// Scan mocks base method.
+func (m *MockrowScanner) Scan(dest ...any) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range dest {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Scan", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Scan indicates an expected call of Scan.
+func (mr *MockrowScannerMockRecorder) Scan(dest ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockrowScanner)(nil).Scan), dest...)
+}
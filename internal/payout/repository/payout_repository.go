@@ -0,0 +1,514 @@
+package payoutrepository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/codepnw/mini-ecommerce/internal/payout"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/pkg/database"
+)
+
+//go:generate mockgen -source=payout_repository.go -destination=mock_payout_repository.go -package=payoutrepository
+
+type PayoutRepository interface {
+	// Commission
+	ListCommissionRules(ctx context.Context) ([]*payout.CommissionRule, error)
+	UpsertCommissionRule(ctx context.Context, input *payout.CommissionRule) error
+	DeleteCommissionRule(ctx context.Context, ruleID int64) error
+
+	// Ledger
+	PendingSales(ctx context.Context, limit int) ([]*payout.Sale, error)
+	EarningOf(ctx context.Context, exec database.DBExec, subOrderID int64) (*payout.Earning, error)
+	Balance(ctx context.Context, sellerID int64) (*payout.Balance, error)
+	ListStatement(ctx context.Context, filter *payout.StatementFilter) ([]*payout.StatementLine, error)
+
+	// Payouts
+	ListSellerPayouts(ctx context.Context, sellerID int64, filter *payout.BatchFilter) ([]*payout.Payout, error)
+	ListBatches(ctx context.Context, filter *payout.BatchFilter) ([]*payout.Batch, error)
+
+	// DB or Tx
+	GetBatch(ctx context.Context, exec database.DBExec, batchID int64) (*payout.Batch, error)
+	ListBatchPayouts(ctx context.Context, exec database.DBExec, batchID int64) ([]*payout.Payout, error)
+
+	// Transaction
+	InsertTransaction(ctx context.Context, tx *sql.Tx, input *payout.Transaction) (bool, error)
+	LockPayouts(ctx context.Context, tx *sql.Tx) error
+	PayableBalances(ctx context.Context, tx *sql.Tx, minAmount float64) ([]*payout.Payout, error)
+	InsertBatch(ctx context.Context, tx *sql.Tx, input *payout.Batch) error
+	InsertPayout(ctx context.Context, tx *sql.Tx, input *payout.Payout) error
+	GetBatchForUpdate(ctx context.Context, tx *sql.Tx, batchID int64) (*payout.Batch, error)
+	UpdateBatch(ctx context.Context, tx *sql.Tx, input *payout.Batch) error
+}
+
+type payoutRepository struct {
+	db *sql.DB
+}
+
+func NewPayoutRepository(db *sql.DB) PayoutRepository {
+	return &payoutRepository{db: db}
+}
+
+func (r *payoutRepository) ListCommissionRules(ctx context.Context) ([]*payout.CommissionRule, error) {
+	query := `
+		SELECT id, scope, seller_id, category, rate, created_at, updated_at
+		FROM commission_rules
+		ORDER BY scope, seller_id NULLS FIRST, category NULLS FIRST
+	`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := make([]*payout.CommissionRule, 0)
+	for rows.Next() {
+		var (
+			rule     = new(payout.CommissionRule)
+			sellerID sql.NullInt64
+			category sql.NullString
+		)
+		err := rows.Scan(&rule.ID, &rule.Scope, &sellerID, &category, &rule.Rate, &rule.CreatedAt, &rule.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		rule.SellerID = sellerID.Int64
+		rule.Category = category.String
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// UpsertCommissionRule one rule per scope, the rate is replaced
+func (r *payoutRepository) UpsertCommissionRule(ctx context.Context, input *payout.CommissionRule) error {
+	query := `
+		INSERT INTO commission_rules (scope, seller_id, category, rate)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (scope, COALESCE(seller_id, 0), COALESCE(category, ''))
+		DO UPDATE SET rate = EXCLUDED.rate, updated_at = NOW()
+		RETURNING id, created_at, updated_at
+	`
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		input.Scope,
+		sql.NullInt64{Int64: input.SellerID, Valid: input.SellerID > 0},
+		sql.NullString{String: input.Category, Valid: input.Category != ""},
+		input.Rate,
+	).Scan(&input.ID, &input.CreatedAt, &input.UpdatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "violates foreign key constraint") {
+			return errs.ErrUserNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteCommissionRule the default rate can be changed but not deleted
+func (r *payoutRepository) DeleteCommissionRule(ctx context.Context, ruleID int64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM commission_rules WHERE id = $1 AND scope <> 'default'`, ruleID)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errs.ErrCommissionNotFound
+	}
+	return nil
+}
+
+// PendingSales completed sub-orders without an earning, oldest first.
+// Lines refunded before the earning are left out of it.
+func (r *payoutRepository) PendingSales(ctx context.Context, limit int) ([]*payout.Sale, error) {
+	query := `
+		SELECT s.id, s.order_id, s.seller_id, oi.product_id, COALESCE(p.category, ''), oi.price * (oi.quantity - oi.refunded_quantity)
+		FROM (
+			SELECT id, order_id, seller_id FROM sub_orders
+			WHERE status = 'completed' AND NOT EXISTS (
+				SELECT 1 FROM ledger_transactions t
+				WHERE t.kind = 'earning' AND t.ref_type = 'sub_order' AND t.ref_id = sub_orders.id
+			)
+			ORDER BY id LIMIT $1
+		) s
+		INNER JOIN order_items oi ON oi.sub_order_id = s.id
+		INNER JOIN products p ON p.id = oi.product_id
+		ORDER BY s.id, oi.id
+	`
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sales := make([]*payout.Sale, 0)
+	var current *payout.Sale
+	for rows.Next() {
+		var (
+			s    payout.Sale
+			line = new(payout.SaleLine)
+		)
+		if err := rows.Scan(&s.SubOrderID, &s.OrderID, &s.SellerID, &line.ProductID, &line.Category, &line.Amount); err != nil {
+			return nil, err
+		}
+		if current == nil || current.SubOrderID != s.SubOrderID {
+			current = &s
+			sales = append(sales, current)
+		}
+		current.Lines = append(current.Lines, line)
+	}
+	return sales, rows.Err()
+}
+
+// EarningOf the posted earning of a sub-order, nil when not posted yet
+func (r *payoutRepository) EarningOf(ctx context.Context, exec database.DBExec, subOrderID int64) (*payout.Earning, error) {
+	query := `
+		SELECT t.seller_id,
+			COALESCE(SUM(e.credit) FILTER (WHERE e.account = 'seller_payable'), 0),
+			COALESCE(SUM(e.credit) FILTER (WHERE e.account = 'platform_fees'), 0)
+		FROM ledger_transactions t
+		LEFT JOIN ledger_entries e ON e.transaction_id = t.id
+		WHERE t.kind = 'earning' AND t.ref_type = 'sub_order' AND t.ref_id = $1
+		GROUP BY t.id, t.seller_id
+	`
+	e := &payout.Earning{SubOrderID: subOrderID}
+	err := exec.QueryRowContext(ctx, query, subOrderID).Scan(&e.SellerID, &e.Gross, &e.Fee)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return e, nil
+}
+
+func (r *payoutRepository) Balance(ctx context.Context, sellerID int64) (*payout.Balance, error) {
+	query := `
+		SELECT
+			COALESCE((
+				SELECT SUM(e.credit - e.debit) FROM ledger_entries e
+				INNER JOIN ledger_transactions t ON t.id = e.transaction_id
+				WHERE t.seller_id = $1 AND e.account = 'seller_payable'
+			), 0),
+			COALESCE((
+				SELECT SUM(p.amount) FROM payouts p
+				INNER JOIN payout_batches b ON b.id = p.batch_id
+				WHERE p.seller_id = $1 AND b.status = 'pending'
+			), 0),
+			COALESCE((
+				SELECT SUM(p.amount) FROM payouts p
+				INNER JOIN payout_batches b ON b.id = p.batch_id
+				WHERE p.seller_id = $1 AND b.status = 'paid'
+			), 0)
+	`
+	b := &payout.Balance{SellerID: sellerID}
+	if err := r.db.QueryRowContext(ctx, query, sellerID).Scan(&b.Available, &b.Pending, &b.PaidOut); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// ListStatement entries on the seller account, newest first
+func (r *payoutRepository) ListStatement(ctx context.Context, filter *payout.StatementFilter) ([]*payout.StatementLine, error) {
+	query := `
+		SELECT t.id, t.kind, t.ref_type, t.ref_id, e.debit, e.credit, t.created_at
+		FROM ledger_entries e
+		INNER JOIN ledger_transactions t ON t.id = e.transaction_id
+		WHERE t.seller_id = $1 AND e.account = 'seller_payable'
+	`
+	args := []any{filter.SellerID}
+	idx := 2
+
+	if !filter.From.IsZero() {
+		query += fmt.Sprintf(" AND t.created_at >= $%d", idx)
+		args = append(args, filter.From)
+		idx++
+	}
+	if !filter.To.IsZero() {
+		query += fmt.Sprintf(" AND t.created_at < $%d", idx)
+		args = append(args, filter.To)
+		idx++
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+
+	query += fmt.Sprintf(" ORDER BY t.created_at DESC, e.id DESC LIMIT $%d OFFSET $%d", idx, idx+1)
+	args = append(args, filter.Limit, offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := make([]*payout.StatementLine, 0)
+	for rows.Next() {
+		var (
+			l             = new(payout.StatementLine)
+			kind          payout.TransactionKind
+			debit, credit float64
+		)
+		if err := rows.Scan(&l.TransactionID, &kind, &l.RefType, &l.RefID, &debit, &credit, &l.CreatedAt); err != nil {
+			return nil, err
+		}
+		l.Type = payout.LineType(kind, credit > 0)
+		l.Amount = credit - debit
+		lines = append(lines, l)
+	}
+	return lines, rows.Err()
+}
+
+const payoutQuery = `
+	SELECT p.id, p.batch_id, p.seller_id, p.amount, b.status, p.created_at, b.paid_at
+	FROM payouts p
+	INNER JOIN payout_batches b ON b.id = p.batch_id
+`
+
+func (r *payoutRepository) ListSellerPayouts(ctx context.Context, sellerID int64, filter *payout.BatchFilter) ([]*payout.Payout, error) {
+	query := payoutQuery + " WHERE p.seller_id = $1"
+	args := []any{sellerID}
+	idx := 2
+
+	if filter.Status != "" {
+		query += fmt.Sprintf(" AND b.status = $%d", idx)
+		args = append(args, filter.Status)
+		idx++
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+
+	query += fmt.Sprintf(" ORDER BY p.created_at DESC, p.id DESC LIMIT $%d OFFSET $%d", idx, idx+1)
+	args = append(args, filter.Limit, offset)
+
+	return r.queryPayouts(ctx, r.db, query, args...)
+}
+
+func (r *payoutRepository) ListBatchPayouts(ctx context.Context, exec database.DBExec, batchID int64) ([]*payout.Payout, error) {
+	query := payoutQuery + " WHERE p.batch_id = $1 ORDER BY p.seller_id"
+	return r.queryPayouts(ctx, exec, query, batchID)
+}
+
+func (r *payoutRepository) queryPayouts(ctx context.Context, exec database.DBExec, query string, args ...any) ([]*payout.Payout, error) {
+	rows, err := exec.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payouts := make([]*payout.Payout, 0)
+	for rows.Next() {
+		var (
+			p      = new(payout.Payout)
+			paidAt sql.NullTime
+		)
+		if err := rows.Scan(&p.ID, &p.BatchID, &p.SellerID, &p.Amount, &p.Status, &p.CreatedAt, &paidAt); err != nil {
+			return nil, err
+		}
+		if paidAt.Valid {
+			p.PaidAt = &paidAt.Time
+		}
+		payouts = append(payouts, p)
+	}
+	return payouts, rows.Err()
+}
+
+const batchColumns = `id, status, total, reference, created_at, updated_at, paid_at`
+
+func (r *payoutRepository) ListBatches(ctx context.Context, filter *payout.BatchFilter) ([]*payout.Batch, error) {
+	query := fmt.Sprintf(`SELECT %s FROM payout_batches WHERE 1=1`, batchColumns)
+	var args []any
+	idx := 1
+
+	if filter.Status != "" {
+		query += fmt.Sprintf(" AND status = $%d", idx)
+		args = append(args, filter.Status)
+		idx++
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", idx, idx+1)
+	args = append(args, filter.Limit, offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	batches := make([]*payout.Batch, 0)
+	for rows.Next() {
+		b, err := scanBatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, b)
+	}
+	return batches, rows.Err()
+}
+
+func (r *payoutRepository) GetBatch(ctx context.Context, exec database.DBExec, batchID int64) (*payout.Batch, error) {
+	query := fmt.Sprintf(`SELECT %s FROM payout_batches WHERE id = $1`, batchColumns)
+	return r.getBatch(ctx, exec, query, batchID)
+}
+
+func (r *payoutRepository) GetBatchForUpdate(ctx context.Context, tx *sql.Tx, batchID int64) (*payout.Batch, error) {
+	query := fmt.Sprintf(`SELECT %s FROM payout_batches WHERE id = $1 FOR UPDATE`, batchColumns)
+	return r.getBatch(ctx, tx, query, batchID)
+}
+
+func (r *payoutRepository) getBatch(ctx context.Context, exec database.DBExec, query string, batchID int64) (*payout.Batch, error) {
+	b, err := scanBatch(exec.QueryRowContext(ctx, query, batchID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrPayoutBatchNotFound
+		}
+		return nil, err
+	}
+	return b, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanBatch(row rowScanner) (*payout.Batch, error) {
+	var (
+		b         = new(payout.Batch)
+		reference sql.NullString
+		paidAt    sql.NullTime
+	)
+	if err := row.Scan(&b.ID, &b.Status, &b.Total, &reference, &b.CreatedAt, &b.UpdatedAt, &paidAt); err != nil {
+		return nil, err
+	}
+	b.Reference = reference.String
+	if paidAt.Valid {
+		b.PaidAt = &paidAt.Time
+	}
+	return b, nil
+}
+
+// InsertTransaction false when the event was already posted
+func (r *payoutRepository) InsertTransaction(ctx context.Context, tx *sql.Tx, input *payout.Transaction) (bool, error) {
+	query := `
+		INSERT INTO ledger_transactions (kind, seller_id, ref_type, ref_id, memo)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (kind, ref_type, ref_id, seller_id) DO NOTHING
+		RETURNING id, created_at
+	`
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		input.Kind,
+		input.SellerID,
+		input.RefType,
+		input.RefID,
+		sql.NullString{String: input.Memo, Valid: input.Memo != ""},
+	).Scan(&input.ID, &input.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	for _, e := range input.Entries {
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO ledger_entries (transaction_id, account, debit, credit) VALUES ($1, $2, $3, $4)`,
+			input.ID,
+			e.Account,
+			e.Debit,
+			e.Credit,
+		)
+		if err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// LockPayouts one batch run at a time, held until the transaction ends
+func (r *payoutRepository) LockPayouts(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('payout_batches'))`)
+	return err
+}
+
+// PayableBalances sellers with a balance of at least minAmount
+func (r *payoutRepository) PayableBalances(ctx context.Context, tx *sql.Tx, minAmount float64) ([]*payout.Payout, error) {
+	query := `
+		SELECT t.seller_id, SUM(e.credit - e.debit)
+		FROM ledger_entries e
+		INNER JOIN ledger_transactions t ON t.id = e.transaction_id
+		WHERE e.account = 'seller_payable'
+		GROUP BY t.seller_id
+		HAVING SUM(e.credit - e.debit) > 0 AND SUM(e.credit - e.debit) >= $1
+		ORDER BY t.seller_id
+	`
+	rows, err := tx.QueryContext(ctx, query, minAmount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payouts := make([]*payout.Payout, 0)
+	for rows.Next() {
+		p := new(payout.Payout)
+		if err := rows.Scan(&p.SellerID, &p.Amount); err != nil {
+			return nil, err
+		}
+		payouts = append(payouts, p)
+	}
+	return payouts, rows.Err()
+}
+
+func (r *payoutRepository) InsertBatch(ctx context.Context, tx *sql.Tx, input *payout.Batch) error {
+	query := `
+		INSERT INTO payout_batches (status, total) VALUES ($1, $2)
+		RETURNING id, created_at, updated_at
+	`
+	return tx.QueryRowContext(ctx, query, input.Status, input.Total).Scan(&input.ID, &input.CreatedAt, &input.UpdatedAt)
+}
+
+func (r *payoutRepository) InsertPayout(ctx context.Context, tx *sql.Tx, input *payout.Payout) error {
+	query := `
+		INSERT INTO payouts (batch_id, seller_id, amount) VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`
+	return tx.QueryRowContext(ctx, query, input.BatchID, input.SellerID, input.Amount).Scan(&input.ID, &input.CreatedAt)
+}
+
+func (r *payoutRepository) UpdateBatch(ctx context.Context, tx *sql.Tx, input *payout.Batch) error {
+	query := `
+		UPDATE payout_batches
+		SET status = $1, total = $2, reference = $3, paid_at = $4, updated_at = NOW()
+		WHERE id = $5
+		RETURNING updated_at
+	`
+	var paidAt sql.NullTime
+	if input.PaidAt != nil {
+		paidAt = sql.NullTime{Time: *input.PaidAt, Valid: true}
+	}
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		input.Status,
+		input.Total,
+		sql.NullString{String: input.Reference, Valid: input.Reference != ""},
+		paidAt,
+		input.ID,
+	).Scan(&input.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errs.ErrPayoutBatchNotFound
+		}
+		return err
+	}
+	return nil
+}
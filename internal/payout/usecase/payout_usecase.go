@@ -0,0 +1,376 @@
+package payoutusecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/codepnw/mini-ecommerce/internal/payout"
+	payoutrepository "github.com/codepnw/mini-ecommerce/internal/payout/repository"
+	"github.com/codepnw/mini-ecommerce/internal/user"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/internal/utils/helper"
+	"github.com/codepnw/mini-ecommerce/pkg/auth"
+	"github.com/codepnw/mini-ecommerce/pkg/database"
+	"github.com/codepnw/mini-ecommerce/pkg/validate"
+)
+
+type PayoutUsecase interface {
+	// Background Jobs
+	PostEarnings(ctx context.Context) (int, error)
+	RunBatch(ctx context.Context) (*payout.Batch, error)
+
+	// Seller
+	GetSellerPayouts(ctx context.Context, filter *payout.BatchFilter) (*payout.SellerPayouts, error)
+	GetStatement(ctx context.Context, filter *payout.StatementFilter) ([]*payout.StatementLine, error)
+
+	// Admin
+	ListCommissionRules(ctx context.Context) ([]*payout.CommissionRule, error)
+	SetCommissionRule(ctx context.Context, input *payout.CommissionRule) (*payout.CommissionRule, error)
+	DeleteCommissionRule(ctx context.Context, ruleID int64) error
+	ListBatches(ctx context.Context, filter *payout.BatchFilter) ([]*payout.Batch, error)
+	GetBatch(ctx context.Context, batchID int64) (*payout.Batch, error)
+	CreateBatch(ctx context.Context) (*payout.Batch, error)
+	UpdateBatchStatus(ctx context.Context, input *payout.BatchUpdate) (*payout.Batch, error)
+}
+
+type PayoutUsecaseConfig struct {
+	Repo payoutrepository.PayoutRepository `validate:"required"`
+	Tx   database.TxManager                `validate:"required"`
+	DB   database.DBExec                   `validate:"required"`
+
+	// Smaller balances wait for the next batch
+	MinPayout float64 `validate:"gte=0"`
+}
+
+type payoutUsecase struct {
+	repo payoutrepository.PayoutRepository
+	tx   database.TxManager
+	db   database.DBExec
+
+	minPayout float64
+}
+
+func NewPayoutUsecase(cfg *PayoutUsecaseConfig) (PayoutUsecase, error) {
+	if err := validate.Struct(cfg); err != nil {
+		return nil, err
+	}
+	return &payoutUsecase{
+		repo:      cfg.Repo,
+		tx:        cfg.Tx,
+		db:        cfg.DB,
+		minPayout: cfg.MinPayout,
+	}, nil
+}
+
+// PostEarnings run by the payout job, each completed sub-order is posted
+// once at the commission rates of the time it is posted
+func (u *payoutUsecase) PostEarnings(ctx context.Context) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout*3)
+	defer cancel()
+
+	rules, err := u.repo.ListCommissionRules(ctx)
+	if err != nil {
+		return 0, err
+	}
+	commission := payout.NewCommissionRules(rules)
+
+	sales, err := u.repo.PendingSales(ctx, consts.EarningBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	posted := 0
+	for _, s := range sales {
+		gross, fee := s.Earning(commission)
+		t := payout.NewEarning(s, gross, fee)
+		t.Memo = fmt.Sprintf("order #%d", s.OrderID)
+
+		err := u.tx.WithTransaction(ctx, func(tx *sql.Tx) error {
+			return u.post(ctx, tx, t)
+		})
+		if err != nil {
+			return posted, err
+		}
+		posted++
+	}
+	return posted, nil
+}
+
+// RunBatch run by the payout job, nil when no seller is owed enough
+func (u *payoutUsecase) RunBatch(ctx context.Context) (*payout.Batch, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout*3)
+	defer cancel()
+
+	batch, err := u.createBatch(ctx)
+	if errors.Is(err, errs.ErrNothingToPayout) {
+		return nil, nil
+	}
+	return batch, err
+}
+
+func (u *payoutUsecase) GetSellerPayouts(ctx context.Context, filter *payout.BatchFilter) (*payout.SellerPayouts, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	sellerID := auth.GetUserID(ctx)
+	if sellerID == 0 {
+		return nil, errs.ErrUnauthorized
+	}
+	paginate(&filter.Page, &filter.Limit)
+
+	balance, err := u.repo.Balance(ctx, sellerID)
+	if err != nil {
+		return nil, err
+	}
+	payouts, err := u.repo.ListSellerPayouts(ctx, sellerID, filter)
+	if err != nil {
+		return nil, err
+	}
+	return &payout.SellerPayouts{Balance: balance, Payouts: payouts}, nil
+}
+
+// GetStatement the seller's ledger lines, To is inclusive
+func (u *payoutUsecase) GetStatement(ctx context.Context, filter *payout.StatementFilter) ([]*payout.StatementLine, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	sellerID := auth.GetUserID(ctx)
+	if sellerID == 0 {
+		return nil, errs.ErrUnauthorized
+	}
+	paginate(&filter.Page, &filter.Limit)
+	filter.SellerID = sellerID
+
+	if !filter.To.IsZero() {
+		filter.To = filter.To.Truncate(24*time.Hour).AddDate(0, 0, 1)
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return nil, errs.ErrInvalidDateRange
+	}
+	return u.repo.ListStatement(ctx, filter)
+}
+
+func (u *payoutUsecase) ListCommissionRules(ctx context.Context) ([]*payout.CommissionRule, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if !isAdmin(ctx) {
+		return nil, errs.ErrNoPermissions
+	}
+	return u.repo.ListCommissionRules(ctx)
+}
+
+// SetCommissionRule creates the rule of the scope or replaces its rate
+func (u *payoutUsecase) SetCommissionRule(ctx context.Context, input *payout.CommissionRule) (*payout.CommissionRule, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if !isAdmin(ctx) {
+		return nil, errs.ErrNoPermissions
+	}
+	if input.Rate < 0 || input.Rate > 1 {
+		return nil, errs.ErrCommissionRateInvalid
+	}
+
+	// Products store the category slugified
+	input.Category = helper.Slugify(input.Category)
+
+	switch input.Scope {
+	case payout.ScopeDefault:
+		input.SellerID, input.Category = 0, ""
+	case payout.ScopeSeller:
+		if input.SellerID <= 0 {
+			return nil, errs.ErrCommissionScopeInvalid
+		}
+		input.Category = ""
+	case payout.ScopeCategory:
+		if input.Category == "" {
+			return nil, errs.ErrCommissionScopeInvalid
+		}
+		input.SellerID = 0
+	default:
+		return nil, errs.ErrCommissionScopeInvalid
+	}
+
+	if err := u.repo.UpsertCommissionRule(ctx, input); err != nil {
+		return nil, err
+	}
+	return input, nil
+}
+
+func (u *payoutUsecase) DeleteCommissionRule(ctx context.Context, ruleID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if !isAdmin(ctx) {
+		return errs.ErrNoPermissions
+	}
+	return u.repo.DeleteCommissionRule(ctx, ruleID)
+}
+
+func (u *payoutUsecase) ListBatches(ctx context.Context, filter *payout.BatchFilter) ([]*payout.Batch, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if !isAdmin(ctx) {
+		return nil, errs.ErrNoPermissions
+	}
+	paginate(&filter.Page, &filter.Limit)
+	return u.repo.ListBatches(ctx, filter)
+}
+
+func (u *payoutUsecase) GetBatch(ctx context.Context, batchID int64) (*payout.Batch, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if !isAdmin(ctx) {
+		return nil, errs.ErrNoPermissions
+	}
+
+	batch, err := u.repo.GetBatch(ctx, u.db, batchID)
+	if err != nil {
+		return nil, err
+	}
+	batch.Payouts, err = u.repo.ListBatchPayouts(ctx, u.db, batchID)
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// CreateBatch runs a batch now instead of waiting for the job
+func (u *payoutUsecase) CreateBatch(ctx context.Context) (*payout.Batch, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if !isAdmin(ctx) {
+		return nil, errs.ErrNoPermissions
+	}
+	return u.createBatch(ctx)
+}
+
+// UpdateBatchStatus settles a pending batch, a failed batch goes back to
+// the seller balances and is paid out again by a later batch
+func (u *payoutUsecase) UpdateBatchStatus(ctx context.Context, input *payout.BatchUpdate) (*payout.Batch, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if !isAdmin(ctx) {
+		return nil, errs.ErrNoPermissions
+	}
+	if input.Status != payout.BatchPaid && input.Status != payout.BatchFailed {
+		return nil, errs.ErrInvalidStatusChange
+	}
+
+	var batch *payout.Batch
+
+	err := u.tx.WithTransaction(ctx, func(tx *sql.Tx) error {
+		current, err := u.repo.GetBatchForUpdate(ctx, tx, input.ID)
+		if err != nil {
+			return err
+		}
+		if current.Status != payout.BatchPending {
+			return errs.ErrPayoutBatchNotPending
+		}
+
+		payouts, err := u.repo.ListBatchPayouts(ctx, tx, current.ID)
+		if err != nil {
+			return err
+		}
+		for _, p := range payouts {
+			if err := u.post(ctx, tx, payout.NewPayoutSettled(p, input.Status)); err != nil {
+				return err
+			}
+		}
+
+		current.Status = input.Status
+		current.Reference = input.Reference
+		if input.Status == payout.BatchPaid {
+			now := time.Now()
+			current.PaidAt = &now
+		}
+		if err := u.repo.UpdateBatch(ctx, tx, current); err != nil {
+			return err
+		}
+
+		for _, p := range payouts {
+			p.Status, p.PaidAt = current.Status, current.PaidAt
+		}
+		current.Payouts = payouts
+		batch = current
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// createBatch pays out every seller balance of at least minPayout, the
+// amounts leave the seller balances in the same transaction
+func (u *payoutUsecase) createBatch(ctx context.Context) (*payout.Batch, error) {
+	var batch *payout.Batch
+
+	err := u.tx.WithTransaction(ctx, func(tx *sql.Tx) error {
+		if err := u.repo.LockPayouts(ctx, tx); err != nil {
+			return err
+		}
+
+		balances, err := u.repo.PayableBalances(ctx, tx, u.minPayout)
+		if err != nil {
+			return err
+		}
+		if len(balances) == 0 {
+			return errs.ErrNothingToPayout
+		}
+
+		batch = payout.NewBatch(balances)
+		if err := u.repo.InsertBatch(ctx, tx, batch); err != nil {
+			return err
+		}
+		for _, p := range batch.Payouts {
+			p.BatchID = batch.ID
+			if err := u.repo.InsertPayout(ctx, tx, p); err != nil {
+				return err
+			}
+			if err := u.post(ctx, tx, payout.NewPayout(p)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// post writes a balanced transaction, posting an event twice is a no-op
+func (u *payoutUsecase) post(ctx context.Context, tx *sql.Tx, t *payout.Transaction) error {
+	if !t.Balanced() {
+		return fmt.Errorf("ledger: %s %s #%d is not balanced", t.Kind, t.RefType, t.RefID)
+	}
+	_, err := u.repo.InsertTransaction(ctx, tx, t)
+	return err
+}
+
+func paginate(page, limit *int) {
+	if *page <= 0 {
+		*page = 1
+	}
+	if *limit <= 0 || *limit > 100 {
+		*limit = 20
+	}
+}
+
+func isAdmin(ctx context.Context) bool {
+	currentUser, err := auth.GetCurrentUser(ctx)
+	if err != nil {
+		return false
+	}
+	return currentUser.Role == string(user.RoleAdmin)
+}
@@ -0,0 +1,417 @@
+package payoutusecase_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/codepnw/mini-ecommerce/internal/payout"
+	payoutrepository "github.com/codepnw/mini-ecommerce/internal/payout/repository"
+	payoutusecase "github.com/codepnw/mini-ecommerce/internal/payout/usecase"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/pkg/auth"
+	"github.com/codepnw/mini-ecommerce/pkg/jwt"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostEarnings(t *testing.T) {
+	uc, mockRepo := setup(t)
+
+	rules := []*payout.CommissionRule{
+		{Scope: payout.ScopeDefault, Rate: 0.10},
+		{Scope: payout.ScopeCategory, Category: "accessories", Rate: 0.08},
+		{Scope: payout.ScopeSeller, SellerID: 30, Rate: 0.05},
+	}
+	sales := []*payout.Sale{
+		// Default & category rate
+		{SubOrderID: 1, OrderID: 100, SellerID: 20, Lines: []*payout.SaleLine{
+			{ProductID: 1, Category: "phones", Amount: 200},
+			{ProductID: 2, Category: "accessories", Amount: 50},
+		}},
+		// Seller rate wins over the category
+		{SubOrderID: 2, OrderID: 100, SellerID: 30, Lines: []*payout.SaleLine{
+			{ProductID: 3, Category: "accessories", Amount: 100},
+		}},
+	}
+
+	mockRepo.EXPECT().ListCommissionRules(gomock.Any()).Return(rules, nil).Times(1)
+	mockRepo.EXPECT().PendingSales(gomock.Any(), gomock.Any()).Return(sales, nil).Times(1)
+
+	var posted []*payout.Transaction
+	mockRepo.EXPECT().InsertTransaction(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ *sql.Tx, txn *payout.Transaction) (bool, error) {
+			posted = append(posted, txn)
+			return true, nil
+		},
+	).Times(2)
+
+	count, err := uc.PostEarnings(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	if assert.Len(t, posted, 2) {
+		// 200 * 0.10 + 50 * 0.08
+		assert.Equal(t, payout.KindEarning, posted[0].Kind)
+		assert.Equal(t, int64(1), posted[0].RefID)
+		assert.Equal(t, "order #100", posted[0].Memo)
+		assert.Equal(t, 250.0, posted[0].Entries[1].Credit)
+		assert.Equal(t, 24.0, posted[0].Entries[3].Credit)
+
+		assert.Equal(t, int64(30), posted[1].SellerID)
+		assert.Equal(t, 5.0, posted[1].Entries[3].Credit)
+	}
+}
+
+func TestCreateBatch(t *testing.T) {
+	type testCase struct {
+		name        string
+		ctx         context.Context
+		mockFn      func(mockRepo *payoutrepository.MockPayoutRepository)
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name: "success",
+			ctx:  mockAdmin(),
+			mockFn: func(mockRepo *payoutrepository.MockPayoutRepository) {
+				mockRepo.EXPECT().LockPayouts(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				mockRepo.EXPECT().PayableBalances(gomock.Any(), gomock.Any(), gomock.Any()).Return([]*payout.Payout{
+					{SellerID: 20, Amount: 226},
+					{SellerID: 30, Amount: 95},
+				}, nil).Times(1)
+				mockRepo.EXPECT().InsertBatch(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ *sql.Tx, b *payout.Batch) error {
+						b.ID = 7
+						return nil
+					},
+				).Times(1)
+				mockRepo.EXPECT().InsertPayout(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+				mockRepo.EXPECT().InsertTransaction(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ *sql.Tx, txn *payout.Transaction) (bool, error) {
+						assert.Equal(t, payout.KindPayout, txn.Kind)
+						assert.Equal(t, payout.AccountSellerPayable, txn.Entries[0].Account)
+						return true, nil
+					},
+				).Times(2)
+			},
+		},
+		{
+			name: "fail nothing to payout",
+			ctx:  mockAdmin(),
+			mockFn: func(mockRepo *payoutrepository.MockPayoutRepository) {
+				mockRepo.EXPECT().LockPayouts(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				mockRepo.EXPECT().PayableBalances(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
+				mockRepo.EXPECT().InsertBatch(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedErr: errs.ErrNothingToPayout,
+		},
+		{
+			name:        "fail not admin",
+			ctx:         mockSeller(),
+			mockFn:      func(mockRepo *payoutrepository.MockPayoutRepository) {},
+			expectedErr: errs.ErrNoPermissions,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, mockRepo := setup(t)
+
+			tc.mockFn(mockRepo)
+
+			result, err := uc.CreateBatch(tc.ctx)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, payout.BatchPending, result.Status)
+			assert.Equal(t, 321.0, result.Total)
+			for _, p := range result.Payouts {
+				assert.Equal(t, int64(7), p.BatchID)
+			}
+		})
+	}
+}
+
+func TestRunBatchNothingToPayout(t *testing.T) {
+	uc, mockRepo := setup(t)
+
+	mockRepo.EXPECT().LockPayouts(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	mockRepo.EXPECT().PayableBalances(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
+
+	result, err := uc.RunBatch(context.Background())
+
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func TestUpdateBatchStatus(t *testing.T) {
+	type testCase struct {
+		name        string
+		status      payout.BatchStatus
+		mockFn      func(mockRepo *payoutrepository.MockPayoutRepository)
+		expectedErr error
+	}
+
+	payouts := func() []*payout.Payout {
+		return []*payout.Payout{{ID: 1, BatchID: 7, SellerID: 20, Amount: 226}}
+	}
+
+	testCases := []testCase{
+		{
+			name:   "success paid",
+			status: payout.BatchPaid,
+			mockFn: func(mockRepo *payoutrepository.MockPayoutRepository) {
+				mockRepo.EXPECT().GetBatchForUpdate(gomock.Any(), gomock.Any(), int64(7)).Return(&payout.Batch{ID: 7, Status: payout.BatchPending}, nil).Times(1)
+				mockRepo.EXPECT().ListBatchPayouts(gomock.Any(), gomock.Any(), int64(7)).Return(payouts(), nil).Times(1)
+				mockRepo.EXPECT().InsertTransaction(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ *sql.Tx, txn *payout.Transaction) (bool, error) {
+						assert.Equal(t, payout.KindPayoutPaid, txn.Kind)
+						assert.Equal(t, payout.AccountCash, txn.Entries[1].Account)
+						return true, nil
+					},
+				).Times(1)
+				mockRepo.EXPECT().UpdateBatch(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+		},
+		{
+			name:   "success failed returns to balance",
+			status: payout.BatchFailed,
+			mockFn: func(mockRepo *payoutrepository.MockPayoutRepository) {
+				mockRepo.EXPECT().GetBatchForUpdate(gomock.Any(), gomock.Any(), int64(7)).Return(&payout.Batch{ID: 7, Status: payout.BatchPending}, nil).Times(1)
+				mockRepo.EXPECT().ListBatchPayouts(gomock.Any(), gomock.Any(), int64(7)).Return(payouts(), nil).Times(1)
+				mockRepo.EXPECT().InsertTransaction(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ *sql.Tx, txn *payout.Transaction) (bool, error) {
+						assert.Equal(t, payout.KindPayoutReversal, txn.Kind)
+						assert.Equal(t, payout.AccountSellerPayable, txn.Entries[1].Account)
+						return true, nil
+					},
+				).Times(1)
+				mockRepo.EXPECT().UpdateBatch(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+		},
+		{
+			name:   "fail batch already settled",
+			status: payout.BatchPaid,
+			mockFn: func(mockRepo *payoutrepository.MockPayoutRepository) {
+				mockRepo.EXPECT().GetBatchForUpdate(gomock.Any(), gomock.Any(), int64(7)).Return(&payout.Batch{ID: 7, Status: payout.BatchPaid}, nil).Times(1)
+				mockRepo.EXPECT().InsertTransaction(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedErr: errs.ErrPayoutBatchNotPending,
+		},
+		{
+			name:   "fail batch not found",
+			status: payout.BatchPaid,
+			mockFn: func(mockRepo *payoutrepository.MockPayoutRepository) {
+				mockRepo.EXPECT().GetBatchForUpdate(gomock.Any(), gomock.Any(), int64(7)).Return(nil, errs.ErrPayoutBatchNotFound).Times(1)
+			},
+			expectedErr: errs.ErrPayoutBatchNotFound,
+		},
+		{
+			name:        "fail back to pending",
+			status:      payout.BatchPending,
+			mockFn:      func(mockRepo *payoutrepository.MockPayoutRepository) {},
+			expectedErr: errs.ErrInvalidStatusChange,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, mockRepo := setup(t)
+
+			tc.mockFn(mockRepo)
+
+			result, err := uc.UpdateBatchStatus(mockAdmin(), &payout.BatchUpdate{ID: 7, Status: tc.status, Reference: "TRX-001"})
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.status, result.Status)
+			assert.Equal(t, "TRX-001", result.Reference)
+			assert.Equal(t, tc.status == payout.BatchPaid, result.PaidAt != nil)
+		})
+	}
+}
+
+func TestSetCommissionRule(t *testing.T) {
+	type testCase struct {
+		name        string
+		input       *payout.CommissionRule
+		mockFn      func(mockRepo *payoutrepository.MockPayoutRepository)
+		expected    *payout.CommissionRule
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name:  "success category normalized",
+			input: &payout.CommissionRule{Scope: payout.ScopeCategory, Category: "Smart Watches", SellerID: 20, Rate: 0.12},
+			mockFn: func(mockRepo *payoutrepository.MockPayoutRepository) {
+				mockRepo.EXPECT().UpsertCommissionRule(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+			expected: &payout.CommissionRule{Scope: payout.ScopeCategory, Category: "smart-watches", Rate: 0.12},
+		},
+		{
+			name:  "success seller",
+			input: &payout.CommissionRule{Scope: payout.ScopeSeller, SellerID: 20, Rate: 0.05},
+			mockFn: func(mockRepo *payoutrepository.MockPayoutRepository) {
+				mockRepo.EXPECT().UpsertCommissionRule(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+			expected: &payout.CommissionRule{Scope: payout.ScopeSeller, SellerID: 20, Rate: 0.05},
+		},
+		{
+			name:        "fail rate above one",
+			input:       &payout.CommissionRule{Scope: payout.ScopeDefault, Rate: 1.5},
+			mockFn:      func(mockRepo *payoutrepository.MockPayoutRepository) {},
+			expectedErr: errs.ErrCommissionRateInvalid,
+		},
+		{
+			name:        "fail seller scope without seller",
+			input:       &payout.CommissionRule{Scope: payout.ScopeSeller, Rate: 0.05},
+			mockFn:      func(mockRepo *payoutrepository.MockPayoutRepository) {},
+			expectedErr: errs.ErrCommissionScopeInvalid,
+		},
+		{
+			name:        "fail unknown scope",
+			input:       &payout.CommissionRule{Scope: "brand", Rate: 0.05},
+			mockFn:      func(mockRepo *payoutrepository.MockPayoutRepository) {},
+			expectedErr: errs.ErrCommissionScopeInvalid,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, mockRepo := setup(t)
+
+			tc.mockFn(mockRepo)
+
+			result, err := uc.SetCommissionRule(mockAdmin(), tc.input)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func TestGetStatement(t *testing.T) {
+	type testCase struct {
+		name        string
+		ctx         context.Context
+		filter      *payout.StatementFilter
+		mockFn      func(mockRepo *payoutrepository.MockPayoutRepository)
+		expectedErr error
+	}
+
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []testCase{
+		{
+			name:   "success to is inclusive",
+			ctx:    mockSeller(),
+			filter: &payout.StatementFilter{From: day, To: day},
+			mockFn: func(mockRepo *payoutrepository.MockPayoutRepository) {
+				mockRepo.EXPECT().ListStatement(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, f *payout.StatementFilter) ([]*payout.StatementLine, error) {
+						assert.Equal(t, int64(20), f.SellerID)
+						assert.Equal(t, day.AddDate(0, 0, 1), f.To)
+						return []*payout.StatementLine{}, nil
+					},
+				).Times(1)
+			},
+		},
+		{
+			name:        "fail from after to",
+			ctx:         mockSeller(),
+			filter:      &payout.StatementFilter{From: day.AddDate(0, 0, 2), To: day},
+			mockFn:      func(mockRepo *payoutrepository.MockPayoutRepository) {},
+			expectedErr: errs.ErrInvalidDateRange,
+		},
+		{
+			name:        "fail unauthorized",
+			ctx:         context.Background(),
+			filter:      &payout.StatementFilter{},
+			mockFn:      func(mockRepo *payoutrepository.MockPayoutRepository) {},
+			expectedErr: errs.ErrUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, mockRepo := setup(t)
+
+			tc.mockFn(mockRepo)
+
+			_, err := uc.GetStatement(tc.ctx, tc.filter)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// =============== Helper ===================
+// ------------------------------------------
+func setup(t *testing.T) (payoutusecase.PayoutUsecase, *payoutrepository.MockPayoutRepository) {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := payoutrepository.NewMockPayoutRepository(ctrl)
+
+	uc, err := payoutusecase.NewPayoutUsecase(&payoutusecase.PayoutUsecaseConfig{
+		Repo:      mockRepo,
+		Tx:        &mockTxManager{},
+		DB:        &mockDB{},
+		MinPayout: 10,
+	})
+	if err != nil {
+		t.Fatalf("init payout usecase failed: %v", err)
+	}
+	return uc, mockRepo
+}
+
+func mockAdmin() context.Context {
+	return auth.SetCurrentUser(context.Background(), &jwt.UserClaims{ID: 1, Role: "admin"})
+}
+
+func mockSeller() context.Context {
+	return auth.SetUserID(context.Background(), 20)
+}
+
+type mockTxManager struct{}
+
+func (m *mockTxManager) WithTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return fn(nil)
+}
+
+type mockDB struct{}
+
+func (m *mockDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return nil
+}
+
+func (m *mockDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return nil, nil
+}
+
+func (m *mockDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return nil, nil
+}
@@ -1,15 +1,128 @@
 package producthandler
 
+import (
+	"github.com/codepnw/mini-ecommerce/internal/product"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+)
+
 type ProductCreateReq struct {
-	Name  string  `json:"name" binding:"required,min=2"`
-	Price float64 `json:"price" binding:"required,gt=0"`
-	Stock int     `json:"stock" binding:"gt=0"`
-	SKU   string  `json:"sku" binding:"required,min=2,max=20"`
+	Name        string  `json:"name" binding:"required,min=2"`
+	Description string  `json:"description" binding:"max=5000"`
+	Slug        string  `json:"slug" binding:"omitempty,max=255"`
+	Status      string  `json:"status" binding:"omitempty,oneof=draft published archived"`
+	Price       float64 `json:"price" binding:"required,gt=0"`
+	Stock       int     `json:"stock" binding:"gt=0"`
+	SKU         string  `json:"sku" binding:"required,min=2,max=20"`
+	Category    string  `json:"category" binding:"omitempty,max=50"`
+
+	LowStockThreshold int `json:"low_stock_threshold" binding:"gte=0"`
+
+	// ReturnWindowDays nil is the default window
+	ReturnWindowDays *int `json:"return_window_days" binding:"omitempty,gte=0,lte=365"`
+
+	PurchaseRules *PurchaseRulesReq `json:"purchase_rules"`
+	Parcel        *ParcelReq        `json:"parcel"`
+}
+
+func (r *ProductCreateReq) returnWindowDays() int {
+	if r.ReturnWindowDays == nil {
+		return consts.DefaultReturnWindowDays
+	}
+	return *r.ReturnWindowDays
+}
+
+// ProductImportReq a row of an import file, as ProductCreateReq but sold out
+// products of an export can be imported again
+type ProductImportReq struct {
+	Name        string  `json:"name" binding:"required,min=2"`
+	Description string  `json:"description" binding:"max=5000"`
+	Slug        string  `json:"slug" binding:"omitempty,max=255"`
+	Status      string  `json:"status" binding:"omitempty,oneof=draft published archived"`
+	Price       float64 `json:"price" binding:"required,gt=0"`
+	Stock       int     `json:"stock" binding:"gte=0"`
+	SKU         string  `json:"sku" binding:"required,min=2,max=20"`
+	Category    string  `json:"category" binding:"omitempty,max=50"`
+
+	LowStockThreshold int `json:"low_stock_threshold" binding:"gte=0"`
+
+	// ReturnWindowDays nil is the default window
+	ReturnWindowDays *int `json:"return_window_days" binding:"omitempty,gte=0,lte=365"`
+
+	PurchaseRules *PurchaseRulesReq `json:"purchase_rules"`
+	Parcel        *ParcelReq        `json:"parcel"`
+}
+
+func (r *ProductImportReq) returnWindowDays() int {
+	if r.ReturnWindowDays == nil {
+		return consts.DefaultReturnWindowDays
+	}
+	return *r.ReturnWindowDays
 }
 
 type ProductUpdateReq struct {
-	Name  *string  `json:"name,omitempty" binding:"omitempty,min=2"`
-	Price *float64 `json:"price,omitempty" binding:"omitempty,gt=0"`
-	Stock *int     `json:"stock,omitempty" binding:"omitempty,gt=0"`
-	SKU   *string  `json:"sku,omitempty" binding:"omitempty,min=2,max=20"`
+	Name        *string  `json:"name,omitempty" binding:"omitempty,min=2"`
+	Description *string  `json:"description,omitempty" binding:"omitempty,max=5000"`
+	Slug        *string  `json:"slug,omitempty" binding:"omitempty,max=255"`
+	Status      *string  `json:"status,omitempty" binding:"omitempty,oneof=draft published archived"`
+	Price       *float64 `json:"price,omitempty" binding:"omitempty,gte=0"`
+	Stock       *int     `json:"stock,omitempty" binding:"omitempty,gte=0"`
+	SKU         *string  `json:"sku,omitempty" binding:"omitempty,min=2,max=20"`
+	Category    *string  `json:"category,omitempty" binding:"omitempty,max=50"`
+
+	LowStockThreshold *int `json:"low_stock_threshold,omitempty" binding:"omitempty,gte=0"`
+	ReturnWindowDays  *int `json:"return_window_days,omitempty" binding:"omitempty,gte=0,lte=365"`
+
+	PurchaseRules *PurchaseRulesReq `json:"purchase_rules,omitempty"`
+	Parcel        *ParcelReq        `json:"parcel,omitempty"`
+}
+
+// PurchaseRulesReq 0 turns a rule off
+type PurchaseRulesReq struct {
+	MinQuantity   int `json:"min_quantity" binding:"gte=0"`
+	MaxQuantity   int `json:"max_quantity" binding:"gte=0"`
+	QuantityStep  int `json:"quantity_step" binding:"gte=0"`
+	CustomerLimit int `json:"customer_limit" binding:"gte=0"`
+}
+
+// toDomain nil is no rules
+func (r *PurchaseRulesReq) toDomain() product.PurchaseRules {
+	if r == nil {
+		return product.PurchaseRules{}
+	}
+	return product.PurchaseRules{
+		MinQuantity:   r.MinQuantity,
+		MaxQuantity:   r.MaxQuantity,
+		QuantityStep:  r.QuantityStep,
+		CustomerLimit: r.CustomerLimit,
+	}
+}
+
+// ParcelReq shipping weight & dimensions of one unit, 0 is unknown
+type ParcelReq struct {
+	WeightGrams int `json:"weight_grams" binding:"gte=0"`
+	LengthCM    int `json:"length_cm" binding:"gte=0"`
+	WidthCM     int `json:"width_cm" binding:"gte=0"`
+	HeightCM    int `json:"height_cm" binding:"gte=0"`
+}
+
+// toDomain nil is unknown
+func (r *ParcelReq) toDomain() product.Parcel {
+	if r == nil {
+		return product.Parcel{}
+	}
+	return product.Parcel{
+		WeightGrams: r.WeightGrams,
+		LengthCM:    r.LengthCM,
+		WidthCM:     r.WidthCM,
+		HeightCM:    r.HeightCM,
+	}
+}
+
+type ProductImportQuery struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv ndjson"`
+	DryRun bool   `form:"dry_run"`
+}
+
+type ProductExportQuery struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv ndjson"`
 }
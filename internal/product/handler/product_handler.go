@@ -2,6 +2,11 @@ package producthandler
 
 import (
 	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/codepnw/mini-ecommerce/internal/product"
 	productusecase "github.com/codepnw/mini-ecommerce/internal/product/usecase"
@@ -36,11 +41,20 @@ func (h *productHandler) Create(c *gin.Context) {
 	}
 
 	input := &product.Product{
-		Name:    req.Name,
-		Price:   req.Price,
-		Stock:   req.Stock,
-		SKU:     req.SKU,
-		OwnerID: userCtx.ID,
+		Name:        req.Name,
+		Description: req.Description,
+		Slug:        req.Slug,
+		Status:      product.ProductStatus(req.Status),
+		Price:       req.Price,
+		Stock:       req.Stock,
+		SKU:         req.SKU,
+		Category:    req.Category,
+		OwnerID:     userCtx.ID,
+
+		LowStockThreshold: req.LowStockThreshold,
+		ReturnWindowDays:  req.returnWindowDays(),
+		Rules:             req.PurchaseRules.toDomain(),
+		Parcel:            req.Parcel.toDomain(),
 	}
 	resp, err := h.uc.Create(c.Request.Context(), input)
 	if err != nil {
@@ -48,12 +62,18 @@ func (h *productHandler) Create(c *gin.Context) {
 		case errs.ErrProductPriceInvalid:
 			response.BadRequest(c, err.Error())
 			return
-		case errs.ErrProductStockInvalid:
+		case errs.ErrProductStockInvalid, errs.ErrLowStockThresholdInvalid, errs.ErrReturnWindowInvalid:
+			response.BadRequest(c, err.Error())
+			return
+		case errs.ErrPurchaseRulesInvalid, errs.ErrParcelInvalid:
 			response.BadRequest(c, err.Error())
 			return
 		case errs.ErrProductSKUExists:
 			response.BadRequest(c, err.Error())
 			return
+		case errs.ErrProductSlugExists, errs.ErrProductSlugInvalid:
+			response.BadRequest(c, err.Error())
+			return
 		default:
 			response.InternalServerError(c, err)
 			return
@@ -78,6 +98,20 @@ func (h *productHandler) GetByID(c *gin.Context) {
 		response.InternalServerError(c, err)
 		return
 	}
+	c.Header("ETag", etag(resp.Version))
+	response.OK(c, "", resp)
+}
+
+func (h *productHandler) GetBySlug(c *gin.Context) {
+	resp, err := h.uc.GetBySlug(c.Request.Context(), c.Param(consts.ParamSlug))
+	if err != nil {
+		if errors.Is(err, errs.ErrProductNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.InternalServerError(c, err)
+		return
+	}
 	response.OK(c, "", resp)
 }
 
@@ -96,6 +130,25 @@ func (h *productHandler) List(c *gin.Context) {
 	response.OK(c, "", resp)
 }
 
+func (h *productHandler) ListMine(c *gin.Context) {
+	filter := new(product.ProductFilter)
+	if err := c.ShouldBindQuery(filter); err != nil {
+		response.BadRequest(c, "invalid filter params")
+		return
+	}
+
+	resp, err := h.uc.ListMine(c.Request.Context(), filter)
+	if err != nil {
+		if errors.Is(err, errs.ErrUnauthorized) {
+			response.Unauthorized(c, err.Error())
+			return
+		}
+		response.InternalServerError(c, err)
+		return
+	}
+	response.OK(c, "", resp)
+}
+
 func (h *productHandler) Update(c *gin.Context) {
 	productID, err := h.getParamID(c)
 	if err != nil {
@@ -109,31 +162,40 @@ func (h *productHandler) Update(c *gin.Context) {
 		return
 	}
 
-	var hasUpdate bool
+	input := &product.ProductUpdate{
+		ID:          productID,
+		Name:        req.Name,
+		Description: req.Description,
+		Slug:        req.Slug,
+		Price:       req.Price,
+		Stock:       req.Stock,
+		SKU:         req.SKU,
+		Category:    req.Category,
 
-	input := &product.Product{
-		ID: productID,
-	}
-	if req.Name != nil {
-		input.Name = *req.Name
-		hasUpdate = true
+		LowStockThreshold: req.LowStockThreshold,
+		ReturnWindowDays:  req.ReturnWindowDays,
 	}
-	if req.Price != nil {
-		input.Price = *req.Price
-		hasUpdate = true
+	if req.Status != nil {
+		status := product.ProductStatus(*req.Status)
+		input.Status = &status
 	}
-	if req.Stock != nil {
-		input.Stock = *req.Stock
-		hasUpdate = true
+	if req.PurchaseRules != nil {
+		rules := req.PurchaseRules.toDomain()
+		input.Rules = &rules
 	}
-	if req.SKU != nil {
-		input.SKU = *req.SKU
-		hasUpdate = true
+	if req.Parcel != nil {
+		parcel := req.Parcel.toDomain()
+		input.Parcel = &parcel
 	}
 
-	if !hasUpdate {
-		response.BadRequest(c, errs.ErrNoFieldsToUpdate.Error())
-		return
+	// Optional optimistic lock
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" {
+		version, err := parseETag(ifMatch)
+		if err != nil {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		input.Version = &version
 	}
 
 	resp, err := h.uc.Update(c.Request.Context(), input)
@@ -145,11 +207,29 @@ func (h *productHandler) Update(c *gin.Context) {
 		case errs.ErrNoPermissions:
 			response.Forbidden(c, err.Error())
 			return
+		case errs.ErrProductVersionMismatch:
+			response.PreconditionFailed(c, err.Error())
+			return
+		case errs.ErrNoFieldsToUpdate,
+			errs.ErrProductNameInvalid,
+			errs.ErrProductPriceInvalid,
+			errs.ErrProductStockInvalid,
+			errs.ErrLowStockThresholdInvalid,
+			errs.ErrReturnWindowInvalid,
+			errs.ErrPurchaseRulesInvalid,
+			errs.ErrParcelInvalid,
+			errs.ErrProductSKUExists,
+			errs.ErrProductSlugExists,
+			errs.ErrProductSlugInvalid,
+			errs.ErrWarehouseNotEnough:
+			response.BadRequest(c, err.Error())
+			return
 		default:
 			response.InternalServerError(c, err)
 			return
 		}
 	}
+	c.Header("ETag", etag(resp.Version))
 	response.OK(c, "", resp)
 }
 
@@ -176,6 +256,251 @@ func (h *productHandler) Delete(c *gin.Context) {
 	response.NoContent(c)
 }
 
+func (h *productHandler) Restore(c *gin.Context) {
+	productID, err := h.getParamID(c)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	resp, err := h.uc.Restore(c.Request.Context(), productID)
+	if err != nil {
+		switch err {
+		case errs.ErrProductNotFound:
+			response.NotFound(c, err.Error())
+			return
+		case errs.ErrNoPermissions:
+			response.Forbidden(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "product restored", resp)
+}
+
+func (h *productHandler) NotifyMe(c *gin.Context) {
+	productID, err := h.getParamID(c)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	resp, err := h.uc.Subscribe(c.Request.Context(), productID)
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrProductNotFound:
+			response.NotFound(c, err.Error())
+			return
+		case errs.ErrProductInStock, errs.ErrProductUnavailable:
+			response.BadRequest(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.Created(c, resp)
+}
+
+func (h *productHandler) CancelNotifyMe(c *gin.Context) {
+	productID, err := h.getParamID(c)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.uc.Unsubscribe(c.Request.Context(), productID); err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrSubscriptionNotFound:
+			response.NotFound(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.NoContent(c)
+}
+
+func (h *productHandler) ListDeleted(c *gin.Context) {
+	filter := new(product.ProductFilter)
+	if err := c.ShouldBindQuery(filter); err != nil {
+		response.BadRequest(c, "invalid filter params")
+		return
+	}
+
+	resp, err := h.uc.ListDeleted(c.Request.Context(), filter)
+	if err != nil {
+		if errors.Is(err, errs.ErrNoPermissions) {
+			response.Forbidden(c, err.Error())
+			return
+		}
+		response.InternalServerError(c, err)
+		return
+	}
+	response.OK(c, "", resp)
+}
+
+func (h *productHandler) UploadImage(c *gin.Context) {
+	productID, err := h.getParamID(c)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	fileHeader, err := c.FormFile(consts.FormImageKey)
+	if err != nil {
+		response.BadRequest(c, errs.ErrImageRequired.Error())
+		return
+	}
+	if fileHeader.Size > consts.MaxImageSize {
+		response.BadRequest(c, errs.ErrImageTooLarge.Error())
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		response.InternalServerError(c, err)
+		return
+	}
+	defer file.Close()
+
+	// Read one byte over the limit, so usecase can reject oversize files
+	data, err := io.ReadAll(io.LimitReader(file, consts.MaxImageSize+1))
+	if err != nil {
+		response.InternalServerError(c, err)
+		return
+	}
+
+	resp, err := h.uc.UploadImage(c.Request.Context(), productID, data)
+	if err != nil {
+		switch err {
+		case errs.ErrImageRequired, errs.ErrImageTooLarge, errs.ErrImageTypeInvalid, errs.ErrImagePixels:
+			response.BadRequest(c, err.Error())
+			return
+		case errs.ErrProductNotFound:
+			response.NotFound(c, err.Error())
+			return
+		case errs.ErrNoPermissions:
+			response.Forbidden(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.Created(c, resp)
+}
+
+func (h *productHandler) Import(c *gin.Context) {
+	query := new(ProductImportQuery)
+	if err := c.ShouldBindQuery(query); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	data, format, err := readImportFile(c, query.Format)
+	if err != nil {
+		switch err {
+		case errs.ErrImportFileRequired, errs.ErrImportFileTooLarge, errs.ErrImportFormatInvalid:
+			response.BadRequest(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+
+	rows, err := parseImport(data, format)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	resp, err := h.uc.Import(c.Request.Context(), rows, query.DryRun)
+	if err != nil {
+		if errors.Is(err, errs.ErrUnauthorized) {
+			response.Unauthorized(c, err.Error())
+			return
+		}
+		response.InternalServerError(c, err)
+		return
+	}
+	response.OK(c, "", resp)
+}
+
+func (h *productHandler) Export(c *gin.Context) {
+	query := new(ProductExportQuery)
+	if err := c.ShouldBindQuery(query); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	format := query.Format
+	if format == "" {
+		format = consts.FormatCSV
+	}
+	contentType := "text/csv"
+	if format == consts.FormatNDJSON {
+		contentType = "application/x-ndjson"
+	}
+
+	// Headers go out with the first row, errors before that still get JSON
+	started := false
+	start := func() {
+		if started {
+			return
+		}
+		started = true
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="products.%s"`, format))
+		c.Status(http.StatusOK)
+	}
+
+	exporter := newProductExporter(c.Writer, format)
+	err := h.uc.Export(c.Request.Context(), func(p *product.Product) error {
+		start()
+		return exporter.Write(p)
+	})
+	if err == nil {
+		start()
+		err = exporter.Flush()
+	}
+	if err != nil {
+		if started {
+			_ = c.Error(err)
+			return
+		}
+		if errors.Is(err, errs.ErrUnauthorized) {
+			response.Unauthorized(c, err.Error())
+			return
+		}
+		response.InternalServerError(c, err)
+	}
+}
+
 func (h *productHandler) getParamID(c *gin.Context) (int64, error) {
 	return helper.GetParamInt(c, consts.ParamProductID)
 }
+
+func etag(version int64) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// parseETag accepts "3", 3 and W/"3"
+func parseETag(value string) (int64, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "W/")
+	version, err := strconv.ParseInt(strings.Trim(value, `"`), 10, 64)
+	if err != nil || version <= 0 {
+		return 0, errs.ErrInvalidIfMatch
+	}
+	return version, nil
+}
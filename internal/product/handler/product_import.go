@@ -0,0 +1,405 @@
+package producthandler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/codepnw/mini-ecommerce/internal/product"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// csvColumns export order, import accepts any order by header name
+var csvColumns = []string{
+	"name", "description", "slug", "status", "price", "stock", "sku", "category",
+	"low_stock_threshold", "return_window_days",
+	"min_quantity", "max_quantity", "quantity_step", "customer_limit",
+	"weight_grams", "length_cm", "width_cm", "height_cm",
+}
+
+var requiredCSVColumns = []string{"name", "price", "stock", "sku"}
+
+// ruleCSVColumns any of them sets all purchase rules, the missing ones are off
+var ruleCSVColumns = []string{"min_quantity", "max_quantity", "quantity_step", "customer_limit"}
+
+// parcelCSVColumns any of them sets the whole parcel, the missing ones are unknown
+var parcelCSVColumns = []string{"weight_grams", "length_cm", "width_cm", "height_cm"}
+
+// readImportFile reads multipart "file" or the raw request body,
+// format comes from the query, then file extension or Content-Type.
+func readImportFile(c *gin.Context, format string) ([]byte, string, error) {
+	var (
+		r    io.Reader
+		name string
+	)
+
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fileHeader, err := c.FormFile(consts.FormImportKey)
+		if err != nil {
+			return nil, "", errs.ErrImportFileRequired
+		}
+		if fileHeader.Size > consts.MaxImportSize {
+			return nil, "", errs.ErrImportFileTooLarge
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			return nil, "", err
+		}
+		defer file.Close()
+
+		r = file
+		name = fileHeader.Filename
+	} else {
+		r = c.Request.Body
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, consts.MaxImportSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) == 0 {
+		return nil, "", errs.ErrImportFileRequired
+	}
+	if len(data) > consts.MaxImportSize {
+		return nil, "", errs.ErrImportFileTooLarge
+	}
+
+	if format == "" {
+		format = detectFormat(name, c.ContentType())
+	}
+	if format == "" {
+		return nil, "", errs.ErrImportFormatInvalid
+	}
+	return data, format, nil
+}
+
+func detectFormat(filename, contentType string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return consts.FormatCSV
+	case ".ndjson", ".jsonl":
+		return consts.FormatNDJSON
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv", "application/csv":
+		return consts.FormatCSV
+	case "application/x-ndjson", "application/ndjson", "application/jsonl":
+		return consts.FormatNDJSON
+	}
+	return ""
+}
+
+func parseImport(data []byte, format string) ([]*product.ImportRow, error) {
+	switch format {
+	case consts.FormatCSV:
+		return parseCSV(data)
+	case consts.FormatNDJSON:
+		return parseNDJSON(data)
+	default:
+		return nil, errs.ErrImportFormatInvalid
+	}
+}
+
+func parseCSV(data []byte) ([]*product.ImportRow, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.TrimLeadingSpace = true
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, errs.ErrImportHeaderInvalid
+	}
+	index := make(map[string]int, len(header))
+	for i, col := range header {
+		index[strings.ToLower(strings.TrimSpace(col))] = i
+	}
+	for _, col := range requiredCSVColumns {
+		if _, ok := index[col]; !ok {
+			return nil, errs.ErrImportHeaderInvalid
+		}
+	}
+	fields := make(map[string]bool, len(index))
+	for col := range index {
+		fields[col] = true
+	}
+	for _, col := range ruleCSVColumns {
+		if fields[col] {
+			fields["purchase_rules"] = true
+		}
+	}
+	for _, col := range parcelCSVColumns {
+		if fields[col] {
+			fields["parcel"] = true
+		}
+	}
+
+	var rows []*product.ImportRow
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if len(rows) >= consts.MaxImportRows {
+			return nil, errs.ErrImportTooManyRows
+		}
+
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			rows = append(rows, &product.ImportRow{Line: parseErr.Line, Err: parseErr.Err})
+			continue
+		}
+		line, _ := r.FieldPos(0)
+
+		get := func(col string) string {
+			i, ok := index[col]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		req := &ProductImportReq{
+			Name:        get("name"),
+			Description: get("description"),
+			Slug:        get("slug"),
+			Status:      get("status"),
+			SKU:         get("sku"),
+			Category:    get("category"),
+		}
+		if req.Price, err = strconv.ParseFloat(get("price"), 64); err != nil {
+			rows = append(rows, importRowError(line, req.SKU, fmt.Errorf("invalid price %q", get("price"))))
+			continue
+		}
+		if req.Stock, err = strconv.Atoi(get("stock")); err != nil {
+			rows = append(rows, importRowError(line, req.SKU, fmt.Errorf("invalid stock %q", get("stock"))))
+			continue
+		}
+
+		// Optional numbers, empty is 0
+		invalid := ""
+		number := func(col string) int {
+			v := get(col)
+			if v == "" || invalid != "" {
+				return 0
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				invalid = col
+			}
+			return n
+		}
+		req.LowStockThreshold = number("low_stock_threshold")
+		if get("return_window_days") != "" {
+			days := number("return_window_days")
+			req.ReturnWindowDays = &days
+		}
+		if fields["purchase_rules"] {
+			req.PurchaseRules = &PurchaseRulesReq{
+				MinQuantity:   number("min_quantity"),
+				MaxQuantity:   number("max_quantity"),
+				QuantityStep:  number("quantity_step"),
+				CustomerLimit: number("customer_limit"),
+			}
+		}
+		if fields["parcel"] {
+			req.Parcel = &ParcelReq{
+				WeightGrams: number("weight_grams"),
+				LengthCM:    number("length_cm"),
+				WidthCM:     number("width_cm"),
+				HeightCM:    number("height_cm"),
+			}
+		}
+		if invalid != "" {
+			rows = append(rows, importRowError(line, req.SKU, fmt.Errorf("invalid %s %q", invalid, get(invalid))))
+			continue
+		}
+		rows = append(rows, validateImportRow(line, req, fields))
+	}
+	return rows, nil
+}
+
+func parseNDJSON(data []byte) ([]*product.ImportRow, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	var rows []*product.ImportRow
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		if len(rows) >= consts.MaxImportRows {
+			return nil, errs.ErrImportTooManyRows
+		}
+
+		req := new(ProductImportReq)
+		if err := json.Unmarshal(text, req); err != nil {
+			rows = append(rows, &product.ImportRow{Line: line, Err: fmt.Errorf("invalid json: %w", err)})
+			continue
+		}
+		keys := make(map[string]json.RawMessage)
+		if err := json.Unmarshal(text, &keys); err != nil {
+			rows = append(rows, &product.ImportRow{Line: line, Err: fmt.Errorf("invalid json: %w", err)})
+			continue
+		}
+		fields := make(map[string]bool, len(keys))
+		for k := range keys {
+			fields[k] = true
+		}
+		rows = append(rows, validateImportRow(line, req, fields))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// validateImportRow same rules as POST /products except stock may be 0,
+// fields are the ones the file sets
+func validateImportRow(line int, req *ProductImportReq, fields map[string]bool) *product.ImportRow {
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		return importRowError(line, req.SKU, err)
+	}
+
+	return &product.ImportRow{
+		Line:   line,
+		Fields: fields,
+		Product: &product.Product{
+			Name:        req.Name,
+			Description: req.Description,
+			Slug:        req.Slug,
+			Status:      product.ProductStatus(req.Status),
+			Price:       req.Price,
+			Stock:       req.Stock,
+			SKU:         req.SKU,
+			Category:    req.Category,
+
+			LowStockThreshold: req.LowStockThreshold,
+			ReturnWindowDays:  req.returnWindowDays(),
+			Rules:             req.PurchaseRules.toDomain(),
+			Parcel:            req.Parcel.toDomain(),
+		},
+	}
+}
+
+// importRowError keeps the SKU for the report
+func importRowError(line int, sku string, err error) *product.ImportRow {
+	return &product.ImportRow{
+		Line:    line,
+		Product: &product.Product{SKU: sku},
+		Err:     err,
+	}
+}
+
+// productExporter writes the catalog in the import format
+type productExporter interface {
+	Write(p *product.Product) error
+	Flush() error
+}
+
+func newProductExporter(w io.Writer, format string) productExporter {
+	if format == consts.FormatNDJSON {
+		return &ndjsonExporter{enc: json.NewEncoder(w)}
+	}
+	return &csvExporter{w: csv.NewWriter(w)}
+}
+
+type csvExporter struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func (e *csvExporter) Write(p *product.Product) error {
+	if !e.wroteHeader {
+		if err := e.w.Write(csvColumns); err != nil {
+			return err
+		}
+		e.wroteHeader = true
+	}
+	return e.w.Write([]string{
+		p.Name,
+		p.Description,
+		p.Slug,
+		string(p.Status),
+		strconv.FormatFloat(p.Price, 'f', 2, 64),
+		strconv.Itoa(p.Stock),
+		p.SKU,
+		p.Category,
+		strconv.Itoa(p.LowStockThreshold),
+		strconv.Itoa(p.ReturnWindowDays),
+		strconv.Itoa(p.Rules.MinQuantity),
+		strconv.Itoa(p.Rules.MaxQuantity),
+		strconv.Itoa(p.Rules.QuantityStep),
+		strconv.Itoa(p.Rules.CustomerLimit),
+		strconv.Itoa(p.Parcel.WeightGrams),
+		strconv.Itoa(p.Parcel.LengthCM),
+		strconv.Itoa(p.Parcel.WidthCM),
+		strconv.Itoa(p.Parcel.HeightCM),
+	})
+}
+
+func (e *csvExporter) Flush() error {
+	// Empty catalog still gets a header
+	if !e.wroteHeader {
+		if err := e.w.Write(csvColumns); err != nil {
+			return err
+		}
+		e.wroteHeader = true
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type ndjsonExporter struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonExporter) Write(p *product.Product) error {
+	return e.enc.Encode(&ProductImportReq{
+		Name:        p.Name,
+		Description: p.Description,
+		Slug:        p.Slug,
+		Status:      string(p.Status),
+		Price:       p.Price,
+		Stock:       p.Stock,
+		SKU:         p.SKU,
+		Category:    p.Category,
+
+		LowStockThreshold: p.LowStockThreshold,
+		ReturnWindowDays:  &p.ReturnWindowDays,
+		PurchaseRules: &PurchaseRulesReq{
+			MinQuantity:   p.Rules.MinQuantity,
+			MaxQuantity:   p.Rules.MaxQuantity,
+			QuantityStep:  p.Rules.QuantityStep,
+			CustomerLimit: p.Rules.CustomerLimit,
+		},
+		Parcel: &ParcelReq{
+			WeightGrams: p.Parcel.WeightGrams,
+			LengthCM:    p.Parcel.LengthCM,
+			WidthCM:     p.Parcel.WidthCM,
+			HeightCM:    p.Parcel.HeightCM,
+		},
+	})
+}
+
+func (e *ndjsonExporter) Flush() error {
+	return nil
+}
@@ -0,0 +1,61 @@
+package producthandler
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/codepnw/mini-ecommerce/internal/product"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportImportRoundTrip(t *testing.T) {
+	products := []*product.Product{
+		{
+			Name:        "Coffee Beans",
+			Description: "Medium roast",
+			Slug:        "coffee-beans",
+			Status:      product.StatusPublished,
+			Price:       350,
+			Stock:       12,
+			SKU:         "CB-001",
+			Category:    "coffee",
+
+			LowStockThreshold: 5,
+			ReturnWindowDays:  14,
+			Rules:             product.PurchaseRules{MinQuantity: 2, MaxQuantity: 12, QuantityStep: 2, CustomerLimit: 24},
+			Parcel:            product.Parcel{WeightGrams: 500, LengthCM: 20, WidthCM: 10, HeightCM: 8},
+		},
+		{
+			Name:   "Sold Out Mug",
+			Slug:   "sold-out-mug",
+			Status: product.StatusPublished,
+			Price:  120,
+			Stock:  0,
+			SKU:    "MUG-002",
+
+			ReturnWindowDays: consts.DefaultReturnWindowDays,
+		},
+	}
+
+	for _, format := range []string{consts.FormatCSV, consts.FormatNDJSON} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			exporter := newProductExporter(&buf, format)
+			for _, p := range products {
+				assert.NoError(t, exporter.Write(p))
+			}
+			assert.NoError(t, exporter.Flush())
+
+			rows, err := parseImport(buf.Bytes(), format)
+
+			assert.NoError(t, err)
+			if assert.Len(t, rows, len(products)) {
+				for i, row := range rows {
+					assert.NoError(t, row.Err)
+					assert.Equal(t, products[i], row.Product)
+				}
+			}
+		})
+	}
+}
@@ -1,20 +1,169 @@
 package product
 
-import "time"
+import (
+	"time"
+
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+)
+
+type ProductStatus string
+
+const (
+	StatusDraft     ProductStatus = "draft"
+	StatusPublished ProductStatus = "published"
+	StatusArchived  ProductStatus = "archived"
+)
 
 type Product struct {
-	ID        int64     `json:"id"`
-	Name      string    `json:"name"`
-	Price     float64   `json:"price"`
-	Stock     int       `json:"stock"`
-	SKU       string    `json:"sku"`
-	OwnerID   int64     `json:"owner_id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID          int64         `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Slug        string        `json:"slug"`
+	Status      ProductStatus `json:"status"`
+	Price       float64       `json:"price"`
+	Stock       int           `json:"stock"`
+	SKU         string        `json:"sku"`
+	Category    string        `json:"category,omitempty"`
+	OwnerID     int64         `json:"owner_id"`
+	Version     int64         `json:"version"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	DeletedAt   *time.Time    `json:"deleted_at,omitempty"`
+
+	// LowStockThreshold alert the owner when stock drops to it, 0 is off
+	LowStockThreshold int `json:"low_stock_threshold"`
+
+	// ReturnWindowDays from the completed sub-order, 0 takes no returns
+	ReturnWindowDays int `json:"return_window_days"`
+
+	Rules PurchaseRules `json:"purchase_rules"`
+
+	Parcel Parcel `json:"parcel"`
+
+	Images []*ProductImage `json:"images"`
+}
+
+// IsAvailable published and not deleted, can be added to cart & ordered
+func (p *Product) IsAvailable() bool {
+	return p.DeletedAt == nil && p.Status == StatusPublished
+}
+
+// Parcel shipping weight & dimensions of one unit, 0 is unknown
+type Parcel struct {
+	WeightGrams int `json:"weight_grams"`
+	LengthCM    int `json:"length_cm"`
+	WidthCM     int `json:"width_cm"`
+	HeightCM    int `json:"height_cm"`
+}
+
+func (p Parcel) Validate() error {
+	if p.WeightGrams < 0 || p.LengthCM < 0 || p.WidthCM < 0 || p.HeightCM < 0 {
+		return errs.ErrParcelInvalid
+	}
+	return nil
+}
+
+// ProductUpdate partial update, nil fields are left unchanged
+type ProductUpdate struct {
+	ID          int64
+	Name        *string
+	Description *string
+	Slug        *string
+	Status      *ProductStatus
+	Price       *float64
+	Stock       *int
+	SKU         *string
+	Category    *string
+
+	LowStockThreshold *int
+	ReturnWindowDays  *int
+
+	// Rules replaces all purchase rules at once
+	Rules *PurchaseRules
+	// Parcel replaces weight & dimensions at once
+	Parcel *Parcel
+
+	// Version expected current version (If-Match), nil skips the check
+	Version *int64
+}
+
+func (u *ProductUpdate) HasChanges() bool {
+	return u.Name != nil ||
+		u.Description != nil ||
+		u.Slug != nil ||
+		u.Status != nil ||
+		u.Price != nil ||
+		u.Stock != nil ||
+		u.SKU != nil ||
+		u.Category != nil ||
+		u.LowStockThreshold != nil ||
+		u.ReturnWindowDays != nil ||
+		u.Rules != nil ||
+		u.Parcel != nil
+}
+
+type ProductImage struct {
+	ID           int64     `json:"id"`
+	ProductID    int64     `json:"product_id"`
+	ObjectKey    string    `json:"-"`
+	ThumbnailKey string    `json:"-"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	Position     int       `json:"position"`
+	CreatedAt    time.Time `json:"created_at"`
 }
 
 type ProductFilter struct {
 	Search string `form:"search"`
+	Status string `form:"status" binding:"omitempty,oneof=draft published archived"`
 	Page   int    `form:"page"`
 	Limit  int    `form:"limit"`
+
+	OwnerID int64 `form:"-"`
+	Deleted bool  `form:"-"`
+}
+
+type ImportAction string
+
+const (
+	ImportCreated   ImportAction = "created"
+	ImportUpdated   ImportAction = "updated"
+	ImportUnchanged ImportAction = "unchanged"
+	ImportFailed    ImportAction = "failed"
+)
+
+// ImportRow one parsed line of an import file, Err is set when the
+// row failed parsing or validation before reaching the usecase
+type ImportRow struct {
+	Line    int
+	Product *Product
+	Err     error
+
+	// Fields columns or keys present in the file, nil is all of them
+	Fields map[string]bool
+}
+
+// Has the file sets field, updates keep the current value of the others
+func (r *ImportRow) Has(field string) bool {
+	return r.Fields == nil || r.Fields[field]
+}
+
+type ImportRowResult struct {
+	Line      int          `json:"line"`
+	SKU       string       `json:"sku"`
+	Action    ImportAction `json:"action"`
+	ProductID int64        `json:"product_id,omitempty"`
+	Error     string       `json:"error,omitempty"`
+}
+
+type ImportReport struct {
+	DryRun    bool               `json:"dry_run"`
+	Total     int                `json:"total"`
+	Created   int                `json:"created"`
+	Updated   int                `json:"updated"`
+	Unchanged int                `json:"unchanged"`
+	Failed    int                `json:"failed"`
+	Rows      []*ImportRowResult `json:"rows"`
 }
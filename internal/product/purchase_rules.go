@@ -0,0 +1,116 @@
+package product
+
+import (
+	"fmt"
+
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+)
+
+type PurchaseRule string
+
+const (
+	RuleMinQuantity   PurchaseRule = "min_quantity"
+	RuleMaxQuantity   PurchaseRule = "max_quantity"
+	RuleQuantityStep  PurchaseRule = "quantity_step"
+	RuleCustomerLimit PurchaseRule = "customer_limit"
+)
+
+// PurchaseRules quantity limits of a product, 0 turns a rule off.
+// MinQuantity, MaxQuantity & QuantityStep apply to one order line,
+// CustomerLimit to everything a customer has ever bought.
+type PurchaseRules struct {
+	MinQuantity   int `json:"min_quantity"`
+	MaxQuantity   int `json:"max_quantity"`
+	QuantityStep  int `json:"quantity_step"`
+	CustomerLimit int `json:"customer_limit"`
+}
+
+func (r PurchaseRules) Validate() error {
+	if r.MinQuantity < 0 || r.MaxQuantity < 0 || r.QuantityStep < 0 || r.CustomerLimit < 0 {
+		return errs.ErrPurchaseRulesInvalid
+	}
+	if r.MaxQuantity > 0 && r.MinQuantity > r.MaxQuantity {
+		return errs.ErrPurchaseRulesInvalid
+	}
+	if r.CustomerLimit > 0 && r.MinQuantity > r.CustomerLimit {
+		return errs.ErrPurchaseRulesInvalid
+	}
+	// A min or max off the step could never be bought
+	if r.QuantityStep > 1 && (r.MinQuantity%r.QuantityStep != 0 || r.MaxQuantity%r.QuantityStep != 0) {
+		return errs.ErrPurchaseRulesInvalid
+	}
+	return nil
+}
+
+// Check quantity of a cart or order line, purchased is what the customer
+// already bought of the product (0 for guests)
+func (r PurchaseRules) Check(productID int64, quantity, purchased int) error {
+	violation := func(rule PurchaseRule, limit int) error {
+		return &RuleError{
+			ProductID: productID,
+			Rule:      rule,
+			Limit:     limit,
+			Quantity:  quantity,
+			Purchased: purchased,
+		}
+	}
+
+	if r.MinQuantity > 0 && quantity < r.MinQuantity {
+		return violation(RuleMinQuantity, r.MinQuantity)
+	}
+	if r.MaxQuantity > 0 && quantity > r.MaxQuantity {
+		return violation(RuleMaxQuantity, r.MaxQuantity)
+	}
+	if r.QuantityStep > 1 && quantity%r.QuantityStep != 0 {
+		return violation(RuleQuantityStep, r.QuantityStep)
+	}
+	if r.CustomerLimit > 0 && purchased+quantity > r.CustomerLimit {
+		return violation(RuleCustomerLimit, r.CustomerLimit)
+	}
+	return nil
+}
+
+// Allowed largest quantity up to quantity that passes Check, 0 when none does
+func (r PurchaseRules) Allowed(quantity, purchased int) int {
+	if r.MaxQuantity > 0 {
+		quantity = min(quantity, r.MaxQuantity)
+	}
+	if r.CustomerLimit > 0 {
+		quantity = min(quantity, r.CustomerLimit-purchased)
+	}
+	if r.QuantityStep > 1 {
+		quantity -= quantity % r.QuantityStep
+	}
+	if quantity <= 0 || quantity < r.MinQuantity {
+		return 0
+	}
+	return quantity
+}
+
+// RuleError broken purchase rule, shown next to the cart line.
+// errors.Is matches errs.ErrPurchaseRule
+type RuleError struct {
+	ProductID int64        `json:"product_id"`
+	Rule      PurchaseRule `json:"rule"`
+	Limit     int          `json:"limit"`
+	Quantity  int          `json:"quantity"`
+	Purchased int          `json:"purchased,omitempty"`
+}
+
+func (e *RuleError) Error() string {
+	switch e.Rule {
+	case RuleMinQuantity:
+		return fmt.Sprintf("minimum quantity is %d", e.Limit)
+	case RuleMaxQuantity:
+		return fmt.Sprintf("maximum quantity per order is %d", e.Limit)
+	case RuleQuantityStep:
+		return fmt.Sprintf("quantity must be a multiple of %d", e.Limit)
+	case RuleCustomerLimit:
+		return fmt.Sprintf("limit is %d per customer, %d left", e.Limit, max(e.Limit-e.Purchased, 0))
+	}
+	return errs.ErrPurchaseRule.Error()
+}
+
+func (e *RuleError) Unwrap() error {
+	return errs.ErrPurchaseRule
+}
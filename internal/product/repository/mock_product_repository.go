@@ -10,6 +10,7 @@ import (
 	reflect "reflect"
 
 	product "github.com/codepnw/mini-ecommerce/internal/product"
+	database "github.com/codepnw/mini-ecommerce/pkg/database"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -94,6 +95,51 @@ func (mr *MockProductRepositoryMockRecorder) FindByIDForUpdate(ctx, tx, id inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByIDForUpdate", reflect.TypeOf((*MockProductRepository)(nil).FindByIDForUpdate), ctx, tx, id)
 }
 
+// FindBySKU mocks base method.
+func (m *MockProductRepository) FindBySKU(ctx context.Context, sku string) (*product.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindBySKU", ctx, sku)
+	ret0, _ := ret[0].(*product.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindBySKU indicates an expected call of FindBySKU.
+func (mr *MockProductRepositoryMockRecorder) FindBySKU(ctx, sku interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindBySKU", reflect.TypeOf((*MockProductRepository)(nil).FindBySKU), ctx, sku)
+}
+
+// FindBySlug mocks base method.
+func (m *MockProductRepository) FindBySlug(ctx context.Context, slug string) (*product.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindBySlug", ctx, slug)
+	ret0, _ := ret[0].(*product.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindBySlug indicates an expected call of FindBySlug.
+func (mr *MockProductRepositoryMockRecorder) FindBySlug(ctx, slug interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindBySlug", reflect.TypeOf((*MockProductRepository)(nil).FindBySlug), ctx, slug)
+}
+
+// GuestPurchasedQuantity mocks base method.
+func (m *MockProductRepository) GuestPurchasedQuantity(ctx context.Context, exec database.DBExec, email string, productID int64) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GuestPurchasedQuantity", ctx, exec, email, productID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GuestPurchasedQuantity indicates an expected call of GuestPurchasedQuantity.
+func (mr *MockProductRepositoryMockRecorder) GuestPurchasedQuantity(ctx, exec, email, productID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GuestPurchasedQuantity", reflect.TypeOf((*MockProductRepository)(nil).GuestPurchasedQuantity), ctx, exec, email, productID)
+}
+
 // IncreaseStock mocks base method.
 func (m *MockProductRepository) IncreaseStock(ctx context.Context, tx *sql.Tx, productID int64, quantity int) error {
 	m.ctrl.T.Helper()
@@ -109,18 +155,33 @@ func (mr *MockProductRepositoryMockRecorder) IncreaseStock(ctx, tx, productID, q
 }
 
 // Insert mocks base method.
-func (m *MockProductRepository) Insert(ctx context.Context, input *product.Product) (*product.Product, error) {
+func (m *MockProductRepository) Insert(ctx context.Context, tx *sql.Tx, input *product.Product) (*product.Product, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Insert", ctx, input)
+	ret := m.ctrl.Call(m, "Insert", ctx, tx, input)
 	ret0, _ := ret[0].(*product.Product)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Insert indicates an expected call of Insert.
-func (mr *MockProductRepositoryMockRecorder) Insert(ctx, input interface{}) *gomock.Call {
+func (mr *MockProductRepositoryMockRecorder) Insert(ctx, tx, input interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockProductRepository)(nil).Insert), ctx, input)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockProductRepository)(nil).Insert), ctx, tx, input)
+}
+
+// InsertImage mocks base method.
+func (m *MockProductRepository) InsertImage(ctx context.Context, tx *sql.Tx, input *product.ProductImage) (*product.ProductImage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertImage", ctx, tx, input)
+	ret0, _ := ret[0].(*product.ProductImage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertImage indicates an expected call of InsertImage.
+func (mr *MockProductRepositoryMockRecorder) InsertImage(ctx, tx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertImage", reflect.TypeOf((*MockProductRepository)(nil).InsertImage), ctx, tx, input)
 }
 
 // List mocks base method.
@@ -138,6 +199,51 @@ func (mr *MockProductRepositoryMockRecorder) List(ctx, filter interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockProductRepository)(nil).List), ctx, filter)
 }
 
+// ListImages mocks base method.
+func (m *MockProductRepository) ListImages(ctx context.Context, productIDs []int64) ([]*product.ProductImage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListImages", ctx, productIDs)
+	ret0, _ := ret[0].([]*product.ProductImage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListImages indicates an expected call of ListImages.
+func (mr *MockProductRepositoryMockRecorder) ListImages(ctx, productIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListImages", reflect.TypeOf((*MockProductRepository)(nil).ListImages), ctx, productIDs)
+}
+
+// PurchasedQuantity mocks base method.
+func (m *MockProductRepository) PurchasedQuantity(ctx context.Context, exec database.DBExec, userID, productID int64) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurchasedQuantity", ctx, exec, userID, productID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurchasedQuantity indicates an expected call of PurchasedQuantity.
+func (mr *MockProductRepositoryMockRecorder) PurchasedQuantity(ctx, exec, userID, productID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurchasedQuantity", reflect.TypeOf((*MockProductRepository)(nil).PurchasedQuantity), ctx, exec, userID, productID)
+}
+
+// Restore mocks base method.
+func (m *MockProductRepository) Restore(ctx context.Context, id int64) (*product.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, id)
+	ret0, _ := ret[0].(*product.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockProductRepositoryMockRecorder) Restore(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockProductRepository)(nil).Restore), ctx, id)
+}
+
 // SKUExists mocks base method.
 func (m *MockProductRepository) SKUExists(ctx context.Context, sku string) (bool, error) {
 	m.ctrl.T.Helper()
@@ -153,17 +259,46 @@ func (mr *MockProductRepositoryMockRecorder) SKUExists(ctx, sku interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SKUExists", reflect.TypeOf((*MockProductRepository)(nil).SKUExists), ctx, sku)
 }
 
+// SlugExists mocks base method.
+func (m *MockProductRepository) SlugExists(ctx context.Context, slug string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SlugExists", ctx, slug)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SlugExists indicates an expected call of SlugExists.
+func (mr *MockProductRepositoryMockRecorder) SlugExists(ctx, slug interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SlugExists", reflect.TypeOf((*MockProductRepository)(nil).SlugExists), ctx, slug)
+}
+
+// StreamByOwner mocks base method.
+func (m *MockProductRepository) StreamByOwner(ctx context.Context, ownerID int64, fn func(*product.Product) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamByOwner", ctx, ownerID, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamByOwner indicates an expected call of StreamByOwner.
+func (mr *MockProductRepositoryMockRecorder) StreamByOwner(ctx, ownerID, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamByOwner", reflect.TypeOf((*MockProductRepository)(nil).StreamByOwner), ctx, ownerID, fn)
+}
+
 // Update mocks base method.
-func (m *MockProductRepository) Update(ctx context.Context, input *product.Product) (*product.Product, error) {
+func (m *MockProductRepository) Update(ctx context.Context, tx *sql.Tx, input *product.ProductUpdate) (*product.Product, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", ctx, input)
+	ret := m.ctrl.Call(m, "Update", ctx, tx, input)
 	ret0, _ := ret[0].(*product.Product)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Update indicates an expected call of Update.
-func (mr *MockProductRepositoryMockRecorder) Update(ctx, input interface{}) *gomock.Call {
+func (mr *MockProductRepositoryMockRecorder) Update(ctx, tx, input interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockProductRepository)(nil).Update), ctx, input)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockProductRepository)(nil).Update), ctx, tx, input)
 }
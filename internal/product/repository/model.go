@@ -8,41 +8,146 @@ import (
 )
 
 type productModel struct {
-	ID        int64          `db:"id"`
-	Name      string         `db:"name"`
-	Price     float64        `db:"price"`
-	Stock     int            `db:"stock"`
-	SKU       sql.NullString `db:"sku"`
-	OwnerID   sql.NullInt64  `db:"owner_id"`
-	CreatedAt time.Time      `db:"created_at"`
-	UpdatedAt time.Time      `db:"updated_at"`
+	ID          int64          `db:"id"`
+	Name        string         `db:"name"`
+	Description sql.NullString `db:"description"`
+	Slug        string         `db:"slug"`
+	Status      string         `db:"status"`
+	Price       float64        `db:"price"`
+	Stock       int            `db:"stock"`
+	Threshold   int            `db:"low_stock_threshold"`
+	ReturnDays  int            `db:"return_window_days"`
+	SKU         sql.NullString `db:"sku"`
+	Category    sql.NullString `db:"category"`
+	OwnerID     sql.NullInt64  `db:"owner_id"`
+	Version     int64          `db:"version"`
+	CreatedAt   time.Time      `db:"created_at"`
+	UpdatedAt   time.Time      `db:"updated_at"`
+	DeletedAt   sql.NullTime   `db:"deleted_at"`
+
+	// Purchase Rules
+	MinQuantity   int `db:"min_quantity"`
+	MaxQuantity   int `db:"max_quantity"`
+	QuantityStep  int `db:"quantity_step"`
+	CustomerLimit int `db:"customer_limit"`
+
+	// Parcel
+	WeightGrams int `db:"weight_grams"`
+	LengthCM    int `db:"length_cm"`
+	WidthCM     int `db:"width_cm"`
+	HeightCM    int `db:"height_cm"`
+}
+
+// productColumns must match the Scan order in scanProduct
+const productColumns = `id, name, description, slug, status, price, stock, low_stock_threshold, return_window_days, min_quantity, max_quantity, quantity_step, customer_limit, weight_grams, length_cm, width_cm, height_cm, sku, category, owner_id, version, created_at, updated_at, deleted_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func (r *productRepository) scanProduct(row rowScanner) (*product.Product, error) {
+	p := new(productModel)
+	err := row.Scan(
+		&p.ID,
+		&p.Name,
+		&p.Description,
+		&p.Slug,
+		&p.Status,
+		&p.Price,
+		&p.Stock,
+		&p.Threshold,
+		&p.ReturnDays,
+		&p.MinQuantity,
+		&p.MaxQuantity,
+		&p.QuantityStep,
+		&p.CustomerLimit,
+		&p.WeightGrams,
+		&p.LengthCM,
+		&p.WidthCM,
+		&p.HeightCM,
+		&p.SKU,
+		&p.Category,
+		&p.OwnerID,
+		&p.Version,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+		&p.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return r.modelToDomain(p), nil
 }
 
 func (r *productRepository) inputToModel(p *product.Product) *productModel {
+	nullDescription := sql.NullString{String: p.Description, Valid: p.Description != ""}
 	nullSKU := sql.NullString{String: p.SKU, Valid: p.SKU != ""}
+	nullCategory := sql.NullString{String: p.Category, Valid: p.Category != ""}
 	nullOwnerID := sql.NullInt64{Int64: p.OwnerID, Valid: p.OwnerID > 0}
 
 	return &productModel{
-		ID:        p.ID,
-		Name:      p.Name,
-		Price:     p.Price,
-		Stock:     p.Stock,
-		SKU:       nullSKU,
-		OwnerID:   nullOwnerID,
-		CreatedAt: p.CreatedAt,
-		UpdatedAt: p.UpdatedAt,
+		ID:          p.ID,
+		Name:        p.Name,
+		Description: nullDescription,
+		Slug:        p.Slug,
+		Status:      string(p.Status),
+		Price:       p.Price,
+		Stock:       p.Stock,
+		Threshold:   p.LowStockThreshold,
+		ReturnDays:  p.ReturnWindowDays,
+		SKU:         nullSKU,
+		Category:    nullCategory,
+		OwnerID:     nullOwnerID,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+
+		MinQuantity:   p.Rules.MinQuantity,
+		MaxQuantity:   p.Rules.MaxQuantity,
+		QuantityStep:  p.Rules.QuantityStep,
+		CustomerLimit: p.Rules.CustomerLimit,
+
+		WeightGrams: p.Parcel.WeightGrams,
+		LengthCM:    p.Parcel.LengthCM,
+		WidthCM:     p.Parcel.WidthCM,
+		HeightCM:    p.Parcel.HeightCM,
 	}
 }
 
 func (r *productRepository) modelToDomain(p *productModel) *product.Product {
+	var deletedAt *time.Time
+	if p.DeletedAt.Valid {
+		deletedAt = &p.DeletedAt.Time
+	}
+
 	return &product.Product{
-		ID:        p.ID,
-		Name:      p.Name,
-		Price:     p.Price,
-		Stock:     p.Stock,
-		SKU:       p.SKU.String,
-		OwnerID:   p.OwnerID.Int64,
-		CreatedAt: p.CreatedAt,
-		UpdatedAt: p.UpdatedAt,
+		ID:          p.ID,
+		Name:        p.Name,
+		Description: p.Description.String,
+		Slug:        p.Slug,
+		Status:      product.ProductStatus(p.Status),
+		Price:       p.Price,
+		Stock:       p.Stock,
+		SKU:         p.SKU.String,
+		Category:    p.Category.String,
+		OwnerID:     p.OwnerID.Int64,
+		Version:     p.Version,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+		DeletedAt:   deletedAt,
+
+		LowStockThreshold: p.Threshold,
+		ReturnWindowDays:  p.ReturnDays,
+		Rules: product.PurchaseRules{
+			MinQuantity:   p.MinQuantity,
+			MaxQuantity:   p.MaxQuantity,
+			QuantityStep:  p.QuantityStep,
+			CustomerLimit: p.CustomerLimit,
+		},
+		Parcel: product.Parcel{
+			WeightGrams: p.WeightGrams,
+			LengthCM:    p.LengthCM,
+			WidthCM:     p.WidthCM,
+			HeightCM:    p.HeightCM,
+		},
 	}
 }
@@ -5,27 +5,40 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"log"
-	"strings"
 
 	"github.com/codepnw/mini-ecommerce/internal/product"
 	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/pkg/database"
+	"github.com/lib/pq"
 )
 
 //go:generate mockgen -source=product_repository.go -destination=mock_product_repository.go -package=productrepository
 
 type ProductRepository interface {
-	Insert(ctx context.Context, input *product.Product) (*product.Product, error)
 	FindByID(ctx context.Context, id int64) (*product.Product, error)
+	FindBySlug(ctx context.Context, slug string) (*product.Product, error)
+	FindBySKU(ctx context.Context, sku string) (*product.Product, error)
 	List(ctx context.Context, filter *product.ProductFilter) ([]*product.Product, error)
-	Update(ctx context.Context, input *product.Product) (*product.Product, error)
+	StreamByOwner(ctx context.Context, ownerID int64, fn func(p *product.Product) error) error
 	Delete(ctx context.Context, id int64) error
+	Restore(ctx context.Context, id int64) (*product.Product, error)
 	SKUExists(ctx context.Context, sku string) (bool, error)
+	SlugExists(ctx context.Context, slug string) (bool, error)
+
+	// Images
+	ListImages(ctx context.Context, productIDs []int64) ([]*product.ProductImage, error)
 
 	// Transaction
+	Insert(ctx context.Context, tx *sql.Tx, input *product.Product) (*product.Product, error)
+	Update(ctx context.Context, tx *sql.Tx, input *product.ProductUpdate) (*product.Product, error)
 	FindByIDForUpdate(ctx context.Context, tx *sql.Tx, id int64) (*product.Product, error)
 	DecreaseStock(ctx context.Context, tx *sql.Tx, productID int64, qtyDecrease int) error
 	IncreaseStock(ctx context.Context, tx *sql.Tx, productID int64, quantity int) error
+	InsertImage(ctx context.Context, tx *sql.Tx, input *product.ProductImage) (*product.ProductImage, error)
+
+	// DB or Tx
+	PurchasedQuantity(ctx context.Context, exec database.DBExec, userID, productID int64) (int, error)
+	GuestPurchasedQuantity(ctx context.Context, exec database.DBExec, email string, productID int64) (int, error)
 }
 
 type productRepository struct {
@@ -36,21 +49,39 @@ func NewProductRepository(db *sql.DB) ProductRepository {
 	return &productRepository{db: db}
 }
 
-func (r *productRepository) Insert(ctx context.Context, input *product.Product) (*product.Product, error) {
+func (r *productRepository) Insert(ctx context.Context, tx *sql.Tx, input *product.Product) (*product.Product, error) {
 	m := r.inputToModel(input)
 	query := `
-		INSERT INTO products (name, price, stock, sku, owner_id)
-		VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at, updated_at
+		INSERT INTO products (
+			name, description, slug, status, price, stock, sku, owner_id, low_stock_threshold,
+			min_quantity, max_quantity, quantity_step, customer_limit, category, return_window_days,
+			weight_grams, length_cm, width_cm, height_cm
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19) RETURNING id, version, created_at, updated_at
 	`
-	err := r.db.QueryRowContext(
+	err := tx.QueryRowContext(
 		ctx,
 		query,
 		m.Name,
+		m.Description,
+		m.Slug,
+		m.Status,
 		m.Price,
 		m.Stock,
 		m.SKU,
 		m.OwnerID,
-	).Scan(&m.ID, &m.CreatedAt, &m.UpdatedAt)
+		m.Threshold,
+		m.MinQuantity,
+		m.MaxQuantity,
+		m.QuantityStep,
+		m.CustomerLimit,
+		m.Category,
+		m.ReturnDays,
+		m.WeightGrams,
+		m.LengthCM,
+		m.WidthCM,
+		m.HeightCM,
+	).Scan(&m.ID, &m.Version, &m.CreatedAt, &m.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -58,64 +89,63 @@ func (r *productRepository) Insert(ctx context.Context, input *product.Product)
 }
 
 func (r *productRepository) FindByID(ctx context.Context, id int64) (*product.Product, error) {
-	pd := new(product.Product)
-	p := r.inputToModel(pd)
+	query := fmt.Sprintf(`SELECT %s FROM products WHERE id = $1 LIMIT 1`, productColumns)
 
-	query := `
-		SELECT id, name, price, stock, sku, owner_id, created_at, updated_at
-		FROM products WHERE id = $1 LIMIT 1
-	`
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&p.ID,
-		&p.Name,
-		&p.Price,
-		&p.Stock,
-		&p.SKU,
-		&p.OwnerID,
-		&p.CreatedAt,
-		&p.UpdatedAt,
-	)
+	p, err := r.scanProduct(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrProductNotFound
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+func (r *productRepository) FindBySlug(ctx context.Context, slug string) (*product.Product, error) {
+	query := fmt.Sprintf(`SELECT %s FROM products WHERE slug = $1 LIMIT 1`, productColumns)
+
+	p, err := r.scanProduct(r.db.QueryRowContext(ctx, query, slug))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrProductNotFound
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+func (r *productRepository) FindBySKU(ctx context.Context, sku string) (*product.Product, error) {
+	query := fmt.Sprintf(`SELECT %s FROM products WHERE sku = $1 LIMIT 1`, productColumns)
+
+	p, err := r.scanProduct(r.db.QueryRowContext(ctx, query, sku))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, errs.ErrProductNotFound
 		}
 		return nil, err
 	}
-	return r.modelToDomain(p), nil
+	return p, nil
 }
 
 func (r *productRepository) FindByIDForUpdate(ctx context.Context, tx *sql.Tx, id int64) (*product.Product, error) {
-	pd := new(product.Product)
-	p := r.inputToModel(pd)
+	query := fmt.Sprintf(`SELECT %s FROM products WHERE id = $1 LIMIT 1 FOR UPDATE`, productColumns)
 
-	query := `
-		SELECT id, name, price, stock, sku, owner_id, created_at, updated_at
-		FROM products WHERE id = $1 LIMIT 1 FOR UPDATE
-	`
-	err := tx.QueryRowContext(ctx, query, id).Scan(
-		&p.ID,
-		&p.Name,
-		&p.Price,
-		&p.Stock,
-		&p.SKU,
-		&p.OwnerID,
-		&p.CreatedAt,
-		&p.UpdatedAt,
-	)
+	p, err := r.scanProduct(tx.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, errs.ErrProductNotFound
 		}
 		return nil, err
 	}
-	return r.modelToDomain(p), nil
+	return p, nil
 }
 
 func (r *productRepository) List(ctx context.Context, filter *product.ProductFilter) ([]*product.Product, error) {
-	query := `
-		SELECT id, name, price, stock, sku, owner_id, created_at, updated_at
-		FROM products WHERE 1=1
-	`
+	query := fmt.Sprintf(`SELECT %s FROM products WHERE deleted_at IS NULL`, productColumns)
+	if filter.Deleted {
+		query = fmt.Sprintf(`SELECT %s FROM products WHERE deleted_at IS NOT NULL`, productColumns)
+	}
+
 	var args []any
 	idx := 1
 
@@ -124,6 +154,16 @@ func (r *productRepository) List(ctx context.Context, filter *product.ProductFil
 		args = append(args, "%"+filter.Search+"%")
 		idx++
 	}
+	if filter.Status != "" {
+		query += fmt.Sprintf(" AND status = $%d", idx)
+		args = append(args, filter.Status)
+		idx++
+	}
+	if filter.OwnerID > 0 {
+		query += fmt.Sprintf(" AND owner_id = $%d", idx)
+		args = append(args, filter.OwnerID)
+		idx++
+	}
 
 	offset := (filter.Page - 1) * filter.Limit
 
@@ -138,17 +178,8 @@ func (r *productRepository) List(ctx context.Context, filter *product.ProductFil
 
 	var products []*product.Product
 	for rows.Next() {
-		p := new(product.Product)
-		if err = rows.Scan(
-			&p.ID,
-			&p.Name,
-			&p.Price,
-			&p.Stock,
-			&p.SKU,
-			&p.OwnerID,
-			&p.CreatedAt,
-			&p.UpdatedAt,
-		); err != nil {
+		p, err := r.scanProduct(rows)
+		if err != nil {
 			return nil, err
 		}
 		products = append(products, p)
@@ -160,63 +191,93 @@ func (r *productRepository) List(ctx context.Context, filter *product.ProductFil
 	return products, nil
 }
 
-func (r *productRepository) Update(ctx context.Context, input *product.Product) (*product.Product, error) {
-	var (
-		sb      strings.Builder
-		columns []string
-		values  []any
-		idx     = 1
-	)
-
-	sb.WriteString("UPDATE products SET updated_at = NOW()")
-	if input.Name != "" {
-		columns = append(columns, fmt.Sprintf("name = $%d", idx))
-		values = append(values, input.Name)
-		idx++
+// StreamByOwner calls fn row by row, the catalog is never held in memory
+func (r *productRepository) StreamByOwner(ctx context.Context, ownerID int64, fn func(p *product.Product) error) error {
+	query := fmt.Sprintf(`
+		SELECT %s FROM products
+		WHERE owner_id = $1 AND deleted_at IS NULL
+		ORDER BY id
+	`, productColumns)
+
+	rows, err := r.db.QueryContext(ctx, query, ownerID)
+	if err != nil {
+		return err
 	}
-	if input.Price != 0 {
-		columns = append(columns, fmt.Sprintf("price = $%d", idx))
-		values = append(values, input.Price)
-		idx++
+	defer rows.Close()
+
+	for rows.Next() {
+		p, err := r.scanProduct(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(p); err != nil {
+			return err
+		}
 	}
-	if input.Stock != 0 {
-		columns = append(columns, fmt.Sprintf("stock = $%d", idx))
-		values = append(values, input.Stock)
-		idx++
+	return rows.Err()
+}
+
+func (r *productRepository) Update(ctx context.Context, tx *sql.Tx, input *product.ProductUpdate) (*product.Product, error) {
+	b := newUpdateBuilder("products")
+
+	if input.Name != nil {
+		b.set("name", *input.Name)
 	}
-	if input.SKU != "" {
-		columns = append(columns, fmt.Sprintf("sku = $%d", idx))
-		values = append(values, input.SKU)
-		idx++
+	if input.Description != nil {
+		b.set("description", sql.NullString{String: *input.Description, Valid: *input.Description != ""})
 	}
-
-	if len(columns) > 0 {
-		sb.WriteString(", ") // for updated_at
-		sb.WriteString(strings.Join(columns, ", "))
+	if input.Slug != nil {
+		b.set("slug", *input.Slug)
 	}
+	if input.Status != nil {
+		b.set("status", string(*input.Status))
+	}
+	if input.Price != nil {
+		b.set("price", *input.Price)
+	}
+	if input.Stock != nil {
+		b.set("stock", *input.Stock)
+	}
+	if input.LowStockThreshold != nil {
+		b.set("low_stock_threshold", *input.LowStockThreshold)
+	}
+	if input.ReturnWindowDays != nil {
+		b.set("return_window_days", *input.ReturnWindowDays)
+	}
+	if input.Rules != nil {
+		b.set("min_quantity", input.Rules.MinQuantity)
+		b.set("max_quantity", input.Rules.MaxQuantity)
+		b.set("quantity_step", input.Rules.QuantityStep)
+		b.set("customer_limit", input.Rules.CustomerLimit)
+	}
+	if input.Parcel != nil {
+		b.set("weight_grams", input.Parcel.WeightGrams)
+		b.set("length_cm", input.Parcel.LengthCM)
+		b.set("width_cm", input.Parcel.WidthCM)
+		b.set("height_cm", input.Parcel.HeightCM)
+	}
+	if input.SKU != nil {
+		b.set("sku", *input.SKU)
+	}
+	if input.Category != nil {
+		b.set("category", sql.NullString{String: *input.Category, Valid: *input.Category != ""})
+	}
+	b.setRaw("version = version + 1").setRaw("updated_at = NOW()")
 
-	sb.WriteString(fmt.Sprintf(" WHERE id = $%d", idx))
-	values = append(values, input.ID)
-	idx++
-
-	sb.WriteString(" RETURNING id, name, price, stock, sku, owner_id, created_at, updated_at")
+	b.whereEq("id", input.ID).whereRaw("deleted_at IS NULL")
+	if input.Version != nil {
+		b.whereEq("version", *input.Version)
+	}
 
-	query := sb.String()
-	log.Println(query)
+	query, args := b.build(productColumns)
 
-	p := new(product.Product)
-	err := r.db.QueryRowContext(ctx, query, values...).Scan(
-		&p.ID,
-		&p.Name,
-		&p.Price,
-		&p.Stock,
-		&p.SKU,
-		&p.OwnerID,
-		&p.CreatedAt,
-		&p.UpdatedAt,
-	)
+	p, err := r.scanProduct(tx.QueryRowContext(ctx, query, args...))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
+			// Row exists but version moved on
+			if input.Version != nil {
+				return nil, errs.ErrProductVersionMismatch
+			}
 			return nil, errs.ErrProductNotFound
 		}
 		return nil, err
@@ -224,8 +285,14 @@ func (r *productRepository) Update(ctx context.Context, input *product.Product)
 	return p, nil
 }
 
+// Delete soft delete, keep the row for order history (order_items FK)
 func (r *productRepository) Delete(ctx context.Context, id int64) error {
-	res, err := r.db.ExecContext(ctx, "DELETE FROM products WHERE id = $1", id)
+	query := `
+		UPDATE products
+		SET deleted_at = NOW(), status = 'archived', version = version + 1, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	res, err := r.db.ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -241,6 +308,25 @@ func (r *productRepository) Delete(ctx context.Context, id int64) error {
 	return nil
 }
 
+// Restore brings a deleted product back as draft, owner publish again
+func (r *productRepository) Restore(ctx context.Context, id int64) (*product.Product, error) {
+	query := fmt.Sprintf(`
+		UPDATE products
+		SET deleted_at = NULL, status = 'draft', version = version + 1, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NOT NULL
+		RETURNING %s
+	`, productColumns)
+
+	p, err := r.scanProduct(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrProductNotFound
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
 func (r *productRepository) SKUExists(ctx context.Context, sku string) (bool, error) {
 	var exists int
 	query := `SELECT 1 FROM products WHERE sku = $1 LIMIT 1`
@@ -255,6 +341,20 @@ func (r *productRepository) SKUExists(ctx context.Context, sku string) (bool, er
 	return true, nil
 }
 
+func (r *productRepository) SlugExists(ctx context.Context, slug string) (bool, error) {
+	var exists int
+	query := `SELECT 1 FROM products WHERE slug = $1 LIMIT 1`
+
+	err := r.db.QueryRowContext(ctx, query, slug).Scan(&exists)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
 func (r *productRepository) DecreaseStock(ctx context.Context, tx *sql.Tx, productID int64, qtyDecrease int) error {
 	query := `
 		UPDATE products
@@ -296,3 +396,98 @@ func (r *productRepository) IncreaseStock(ctx context.Context, tx *sql.Tx, produ
 	}
 	return nil
 }
+
+// InsertImage at the next position, the product row must be locked
+func (r *productRepository) InsertImage(ctx context.Context, tx *sql.Tx, input *product.ProductImage) (*product.ProductImage, error) {
+	query := `
+		INSERT INTO product_images (product_id, object_key, thumbnail_key, content_type, size, position)
+		SELECT $1, $2, $3, $4, $5, COALESCE(MAX(position), 0) + 1
+		FROM product_images WHERE product_id = $1
+		RETURNING id, position, created_at
+	`
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		input.ProductID,
+		input.ObjectKey,
+		input.ThumbnailKey,
+		input.ContentType,
+		input.Size,
+	).Scan(&input.ID, &input.Position, &input.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return input, nil
+}
+
+func (r *productRepository) ListImages(ctx context.Context, productIDs []int64) ([]*product.ProductImage, error) {
+	query := `
+		SELECT id, product_id, object_key, thumbnail_key, content_type, size, position, created_at
+		FROM product_images
+		WHERE product_id = ANY($1)
+		ORDER BY product_id, position
+	`
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(productIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	images := make([]*product.ProductImage, 0)
+	for rows.Next() {
+		img := new(product.ProductImage)
+		if err = rows.Scan(
+			&img.ID,
+			&img.ProductID,
+			&img.ObjectKey,
+			&img.ThumbnailKey,
+			&img.ContentType,
+			&img.Size,
+			&img.Position,
+			&img.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		images = append(images, img)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+// PurchasedQuantity units of the product the user has ordered, unclaimed
+// guest orders with the account email included. Cancelled orders do not
+// count toward the customer limit
+func (r *productRepository) PurchasedQuantity(ctx context.Context, exec database.DBExec, userID, productID int64) (int, error) {
+	query := `
+		SELECT COALESCE(SUM(oi.quantity), 0)
+		FROM order_items oi
+		INNER JOIN orders o ON o.id = oi.order_id
+		WHERE oi.product_id = $2 AND o.status <> 'cancelled'
+			AND (o.user_id = $1 OR (o.user_id IS NULL AND LOWER(o.guest_email) = (SELECT LOWER(email) FROM users WHERE id = $1)))
+	`
+	var purchased int
+	if err := exec.QueryRowContext(ctx, query, userID, productID).Scan(&purchased); err != nil {
+		return 0, err
+	}
+	return purchased, nil
+}
+
+// GuestPurchasedQuantity same as PurchasedQuantity for a guest email, orders
+// of an account with that email included
+func (r *productRepository) GuestPurchasedQuantity(ctx context.Context, exec database.DBExec, email string, productID int64) (int, error) {
+	query := `
+		SELECT COALESCE(SUM(oi.quantity), 0)
+		FROM order_items oi
+		INNER JOIN orders o ON o.id = oi.order_id
+		WHERE oi.product_id = $2 AND o.status <> 'cancelled'
+			AND (LOWER(o.guest_email) = LOWER($1) OR o.user_id = (SELECT id FROM users WHERE LOWER(email) = LOWER($1)))
+	`
+	var purchased int
+	if err := exec.QueryRowContext(ctx, query, email, productID).Scan(&purchased); err != nil {
+		return 0, err
+	}
+	return purchased, nil
+}
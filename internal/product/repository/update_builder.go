@@ -0,0 +1,56 @@
+package productrepository
+
+import (
+	"fmt"
+	"strings"
+)
+
+// updateBuilder builds "UPDATE ... SET" with numbered placeholders,
+// only columns added with set are written.
+type updateBuilder struct {
+	table string
+	sets  []string
+	where []string
+	args  []any
+}
+
+func newUpdateBuilder(table string) *updateBuilder {
+	return &updateBuilder{table: table}
+}
+
+func (b *updateBuilder) set(column string, value any) *updateBuilder {
+	b.args = append(b.args, value)
+	b.sets = append(b.sets, fmt.Sprintf("%s = $%d", column, len(b.args)))
+	return b
+}
+
+// setRaw adds an expression without argument, e.g. "updated_at = NOW()"
+func (b *updateBuilder) setRaw(expr string) *updateBuilder {
+	b.sets = append(b.sets, expr)
+	return b
+}
+
+func (b *updateBuilder) whereEq(column string, value any) *updateBuilder {
+	b.args = append(b.args, value)
+	b.where = append(b.where, fmt.Sprintf("%s = $%d", column, len(b.args)))
+	return b
+}
+
+func (b *updateBuilder) whereRaw(expr string) *updateBuilder {
+	b.where = append(b.where, expr)
+	return b
+}
+
+func (b *updateBuilder) build(returning string) (string, []any) {
+	var sb strings.Builder
+
+	sb.WriteString("UPDATE " + b.table)
+	sb.WriteString(" SET " + strings.Join(b.sets, ", "))
+	if len(b.where) > 0 {
+		sb.WriteString(" WHERE " + strings.Join(b.where, " AND "))
+	}
+	if returning != "" {
+		sb.WriteString(" RETURNING " + returning)
+	}
+	return sb.String(), b.args
+}
@@ -1,31 +1,70 @@
 package productusecase
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
 
+	"github.com/codepnw/mini-ecommerce/internal/inventory"
+	inventoryrepository "github.com/codepnw/mini-ecommerce/internal/inventory/repository"
 	"github.com/codepnw/mini-ecommerce/internal/product"
 	productrepository "github.com/codepnw/mini-ecommerce/internal/product/repository"
 	"github.com/codepnw/mini-ecommerce/internal/user"
 	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
 	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/internal/utils/helper"
 	"github.com/codepnw/mini-ecommerce/pkg/auth"
+	"github.com/codepnw/mini-ecommerce/pkg/database"
+	"github.com/codepnw/mini-ecommerce/pkg/storage"
+	"github.com/codepnw/mini-ecommerce/pkg/thumbnail"
+	"github.com/google/uuid"
 )
 
 type ProductUsecase interface {
 	Create(ctx context.Context, input *product.Product) (*product.Product, error)
 	GetByID(ctx context.Context, id int64) (*product.Product, error)
+	GetBySlug(ctx context.Context, slug string) (*product.Product, error)
 	List(ctx context.Context, filter *product.ProductFilter) ([]*product.Product, error)
-	Update(ctx context.Context, input *product.Product) (*product.Product, error)
+	ListMine(ctx context.Context, filter *product.ProductFilter) ([]*product.Product, error)
+	ListByOwner(ctx context.Context, ownerID int64, filter *product.ProductFilter) ([]*product.Product, error)
+	Update(ctx context.Context, input *product.ProductUpdate) (*product.Product, error)
 	Delete(ctx context.Context, productID int64) error
+	Restore(ctx context.Context, productID int64) (*product.Product, error)
+	ListDeleted(ctx context.Context, filter *product.ProductFilter) ([]*product.Product, error)
+
+	UploadImage(ctx context.Context, productID int64, data []byte) (*product.ProductImage, error)
+
+	Import(ctx context.Context, rows []*product.ImportRow, dryRun bool) (*product.ImportReport, error)
+	Export(ctx context.Context, fn func(p *product.Product) error) error
+
+	// Back in stock
+	Subscribe(ctx context.Context, productID int64) (*inventory.Subscription, error)
+	Unsubscribe(ctx context.Context, productID int64) error
 }
 
 type productUsecase struct {
-	repo productrepository.ProductRepository
+	repo          productrepository.ProductRepository
+	inventoryRepo inventoryrepository.InventoryRepository
+	tx            database.TxManager
+	store         storage.BlobStore
 }
 
-func NewProductUsecase(repo productrepository.ProductRepository) ProductUsecase {
+func NewProductUsecase(
+	repo productrepository.ProductRepository,
+	inventoryRepo inventoryrepository.InventoryRepository,
+	tx database.TxManager,
+	store storage.BlobStore,
+) ProductUsecase {
 	return &productUsecase{
-		repo: repo,
+		repo:          repo,
+		inventoryRepo: inventoryRepo,
+		tx:            tx,
+		store:         store,
 	}
 }
 
@@ -39,6 +78,24 @@ func (u *productUsecase) Create(ctx context.Context, input *product.Product) (*p
 	if input.Price < 0 {
 		return nil, errs.ErrProductPriceInvalid
 	}
+	if input.LowStockThreshold < 0 {
+		return nil, errs.ErrLowStockThresholdInvalid
+	}
+	if input.ReturnWindowDays < 0 || input.ReturnWindowDays > consts.MaxReturnWindowDays {
+		return nil, errs.ErrReturnWindowInvalid
+	}
+	if err := input.Rules.Validate(); err != nil {
+		return nil, err
+	}
+	if err := input.Parcel.Validate(); err != nil {
+		return nil, err
+	}
+	// Commission overrides match on the normalized category
+	input.Category = helper.Slugify(input.Category)
+
+	if input.Status == "" {
+		input.Status = product.StatusDraft
+	}
 
 	// Check SKU
 	exists, err := u.repo.SKUExists(ctx, input.SKU)
@@ -49,7 +106,14 @@ func (u *productUsecase) Create(ctx context.Context, input *product.Product) (*p
 		return nil, errs.ErrProductSKUExists
 	}
 
-	productData, err := u.repo.Insert(ctx, input)
+	// Check Slug
+	slug, err := u.resolveSlug(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	input.Slug = slug
+
+	productData, err := u.insert(ctx, input)
 	if err != nil {
 		return nil, err
 	}
@@ -64,6 +128,32 @@ func (u *productUsecase) GetByID(ctx context.Context, id int64) (*product.Produc
 	if err != nil {
 		return nil, err
 	}
+	// Draft & Archived only visible to owner
+	if !u.isVisible(ctx, productData) {
+		return nil, errs.ErrProductNotFound
+	}
+
+	if err := u.attachImages(ctx, productData); err != nil {
+		return nil, err
+	}
+	return productData, nil
+}
+
+func (u *productUsecase) GetBySlug(ctx context.Context, slug string) (*product.Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	productData, err := u.repo.FindBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	if !u.isVisible(ctx, productData) {
+		return nil, errs.ErrProductNotFound
+	}
+
+	if err := u.attachImages(ctx, productData); err != nil {
+		return nil, err
+	}
 	return productData, nil
 }
 
@@ -71,6 +161,42 @@ func (u *productUsecase) List(ctx context.Context, filter *product.ProductFilter
 	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
 	defer cancel()
 
+	// Public only see published products
+	filter.Status = string(product.StatusPublished)
+	filter.OwnerID = 0
+	filter.Deleted = false
+
+	return u.list(ctx, filter)
+}
+
+func (u *productUsecase) ListMine(ctx context.Context, filter *product.ProductFilter) ([]*product.Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	currentUser, err := auth.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, errs.ErrUnauthorized
+	}
+	// Owner see all status (filter optional)
+	filter.OwnerID = currentUser.ID
+	filter.Deleted = false
+
+	return u.list(ctx, filter)
+}
+
+// ListByOwner published products of one seller, the store page
+func (u *productUsecase) ListByOwner(ctx context.Context, ownerID int64, filter *product.ProductFilter) ([]*product.Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	filter.Status = string(product.StatusPublished)
+	filter.OwnerID = ownerID
+	filter.Deleted = false
+
+	return u.list(ctx, filter)
+}
+
+func (u *productUsecase) list(ctx context.Context, filter *product.ProductFilter) ([]*product.Product, error) {
 	if filter.Page <= 0 {
 		filter.Page = 1
 	}
@@ -82,20 +208,40 @@ func (u *productUsecase) List(ctx context.Context, filter *product.ProductFilter
 	if err != nil {
 		return nil, err
 	}
+
+	if err := u.attachImages(ctx, products...); err != nil {
+		return nil, err
+	}
 	return products, nil
 }
 
-func (u *productUsecase) Update(ctx context.Context, input *product.Product) (*product.Product, error) {
+func (u *productUsecase) Update(ctx context.Context, input *product.ProductUpdate) (*product.Product, error) {
 	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
 	defer cancel()
 
 	// Check Admin & Product Owner
-	if err := u.checkPermissions(ctx, input.ID); err != nil {
+	current, err := u.checkPermissions(ctx, input.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check Version (If-Match)
+	if input.Version != nil && *input.Version != current.Version {
+		return nil, errs.ErrProductVersionMismatch
+	}
+
+	// Skip fields with the same value
+	dropUnchanged(current, input)
+	if !input.HasChanges() {
+		return nil, errs.ErrNoFieldsToUpdate
+	}
+
+	if err := u.validateUpdate(ctx, input); err != nil {
 		return nil, err
 	}
 
 	// Update Product
-	productData, err := u.repo.Update(ctx, input)
+	productData, err := u.update(ctx, input)
 	if err != nil {
 		return nil, err
 	}
@@ -106,35 +252,760 @@ func (u *productUsecase) Delete(ctx context.Context, productID int64) error {
 	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
 	defer cancel()
 
-	// TODO: check product in order
-
 	// Check Admin & Product Owner
-	if err := u.checkPermissions(ctx, productID); err != nil {
+	if _, err := u.checkPermissions(ctx, productID); err != nil {
 		return err
 	}
 
-	// Delete Product
+	// Soft Delete, products in orders still resolve
 	if err := u.repo.Delete(ctx, productID); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (u *productUsecase) checkPermissions(ctx context.Context, productID int64) error {
+// Subscribe notify-me for a sold out product, the email comes from the token
+func (u *productUsecase) Subscribe(ctx context.Context, productID int64) (*inventory.Subscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
 	currentUser, err := auth.GetCurrentUser(ctx)
 	if err != nil {
-		return err
+		return nil, errs.ErrUnauthorized
 	}
+
 	productData, err := u.repo.FindByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if !productData.IsAvailable() {
+		return nil, errs.ErrProductUnavailable
+	}
+	if productData.Stock > 0 {
+		return nil, errs.ErrProductInStock
+	}
+
+	sub := &inventory.Subscription{
+		ProductID: productID,
+		UserID:    currentUser.ID,
+		Email:     currentUser.Email,
+	}
+	if err := u.inventoryRepo.Subscribe(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (u *productUsecase) Unsubscribe(ctx context.Context, productID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	currentUser, err := auth.GetCurrentUser(ctx)
+	if err != nil {
+		return errs.ErrUnauthorized
+	}
+	return u.inventoryRepo.Unsubscribe(ctx, productID, currentUser.ID)
+}
+
+func (u *productUsecase) Restore(ctx context.Context, productID int64) (*product.Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if !u.isAdmin(ctx) {
+		return nil, errs.ErrNoPermissions
+	}
+
+	productData, err := u.repo.Restore(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	return productData, nil
+}
+
+func (u *productUsecase) ListDeleted(ctx context.Context, filter *product.ProductFilter) ([]*product.Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if !u.isAdmin(ctx) {
+		return nil, errs.ErrNoPermissions
+	}
+	filter.Deleted = true
+	filter.OwnerID = 0
+
+	return u.list(ctx, filter)
+}
+
+func (u *productUsecase) Import(ctx context.Context, rows []*product.ImportRow, dryRun bool) (*product.ImportReport, error) {
+	// Rows are written one by one, allow more time than a single request
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout*6)
+	defer cancel()
+
+	currentUser, err := auth.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, errs.ErrUnauthorized
+	}
+
+	report := &product.ImportReport{
+		DryRun: dryRun,
+		Total:  len(rows),
+		Rows:   make([]*product.ImportRowResult, 0, len(rows)),
+	}
+	seenSKUs := make(map[string]bool)
+	seenSlugs := make(map[string]bool)
+
+	for _, row := range rows {
+		result := &product.ImportRowResult{Line: row.Line}
+		if row.Product != nil {
+			result.SKU = row.Product.SKU
+		}
+
+		rowErr := row.Err
+		if rowErr == nil && seenSKUs[row.Product.SKU] {
+			rowErr = errs.ErrImportDuplicateSKU
+		}
+		if rowErr == nil {
+			seenSKUs[row.Product.SKU] = true
+			row.Product.OwnerID = currentUser.ID
+
+			result.Action, result.ProductID, rowErr = u.importRow(ctx, row, seenSlugs, dryRun)
+			if rowErr != nil && !isImportRowError(rowErr) {
+				return nil, rowErr
+			}
+		}
+
+		if rowErr != nil {
+			result.Action = product.ImportFailed
+			result.Error = rowErr.Error()
+		}
+
+		switch result.Action {
+		case product.ImportCreated:
+			report.Created++
+		case product.ImportUpdated:
+			report.Updated++
+		case product.ImportUnchanged:
+			report.Unchanged++
+		case product.ImportFailed:
+			report.Failed++
+		}
+		report.Rows = append(report.Rows, result)
+	}
+	return report, nil
+}
+
+// importRow upsert by SKU, only products of the same owner are updated.
+// Updates leave fields missing from the file as they are.
+func (u *productUsecase) importRow(ctx context.Context, row *product.ImportRow, seenSlugs map[string]bool, dryRun bool) (product.ImportAction, int64, error) {
+	input := row.Product
+	input.Category = helper.Slugify(input.Category)
+
+	existing, err := u.repo.FindBySKU(ctx, input.SKU)
+	if err != nil && err != errs.ErrProductNotFound {
+		return "", 0, err
+	}
+
+	// Create
+	if existing == nil {
+		if input.Stock < 0 {
+			return "", 0, errs.ErrProductStockInvalid
+		}
+		if input.Price < 0 {
+			return "", 0, errs.ErrProductPriceInvalid
+		}
+		if err := input.Rules.Validate(); err != nil {
+			return "", 0, err
+		}
+		if err := input.Parcel.Validate(); err != nil {
+			return "", 0, err
+		}
+		if input.Status == "" {
+			input.Status = product.StatusDraft
+		}
+
+		slug, err := u.resolveSlug(ctx, input)
+		if err != nil {
+			return "", 0, err
+		}
+		if seenSlugs[slug] {
+			return "", 0, errs.ErrProductSlugExists
+		}
+		seenSlugs[slug] = true
+		input.Slug = slug
+
+		if dryRun {
+			return product.ImportCreated, 0, nil
+		}
+		created, err := u.insert(ctx, input)
+		if err != nil {
+			return "", 0, err
+		}
+		return product.ImportCreated, created.ID, nil
+	}
+
+	// Update
+	if existing.OwnerID != input.OwnerID {
+		return "", 0, errs.ErrProductSKUExists
+	}
+	if existing.DeletedAt != nil {
+		return "", 0, errs.ErrProductUnavailable
+	}
+
+	update := &product.ProductUpdate{
+		ID:    existing.ID,
+		Name:  &input.Name,
+		Price: &input.Price,
+		Stock: &input.Stock,
+	}
+	if row.Has("description") {
+		update.Description = &input.Description
+	}
+	if input.Slug != "" {
+		update.Slug = &input.Slug
+	}
+	if input.Status != "" {
+		update.Status = &input.Status
+	}
+	if input.Category != "" {
+		update.Category = &input.Category
+	}
+	if row.Has("low_stock_threshold") {
+		update.LowStockThreshold = &input.LowStockThreshold
+	}
+	if row.Has("return_window_days") {
+		update.ReturnWindowDays = &input.ReturnWindowDays
+	}
+	if row.Has("purchase_rules") {
+		update.Rules = &input.Rules
+	}
+	if row.Has("parcel") {
+		update.Parcel = &input.Parcel
+	}
+
+	dropUnchanged(existing, update)
+	if !update.HasChanges() {
+		return product.ImportUnchanged, existing.ID, nil
+	}
+	if err := u.validateUpdate(ctx, update); err != nil {
+		return "", 0, err
+	}
+	if update.Slug != nil {
+		if seenSlugs[*update.Slug] {
+			return "", 0, errs.ErrProductSlugExists
+		}
+		seenSlugs[*update.Slug] = true
+	}
+
+	if dryRun {
+		return product.ImportUpdated, existing.ID, nil
+	}
+	if _, err := u.update(ctx, update); err != nil {
+		return "", 0, err
+	}
+	return product.ImportUpdated, existing.ID, nil
+}
+
+// Errors reported per row, anything else aborts the import
+var importRowErrors = []error{
+	errs.ErrProductNameInvalid,
+	errs.ErrProductPriceInvalid,
+	errs.ErrProductStockInvalid,
+	errs.ErrProductSKUExists,
+	errs.ErrProductSlugExists,
+	errs.ErrProductSlugInvalid,
+	errs.ErrProductUnavailable,
+	errs.ErrWarehouseNotEnough,
+	errs.ErrPurchaseRulesInvalid,
+	errs.ErrParcelInvalid,
+}
+
+func isImportRowError(err error) bool {
+	for _, e := range importRowErrors {
+		if err == e {
+			return true
+		}
+	}
+	return false
+}
+
+// Export has no timeout, the response is streamed and may be large
+func (u *productUsecase) Export(ctx context.Context, fn func(p *product.Product) error) error {
+	currentUser, err := auth.GetCurrentUser(ctx)
+	if err != nil {
+		return errs.ErrUnauthorized
+	}
+	return u.repo.StreamByOwner(ctx, currentUser.ID, fn)
+}
+
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+func (u *productUsecase) UploadImage(ctx context.Context, productID int64, data []byte) (*product.ProductImage, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	// Check Admin & Product Owner
+	if _, err := u.checkPermissions(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	// Validate File
+	if len(data) == 0 {
+		return nil, errs.ErrImageRequired
+	}
+	if len(data) > consts.MaxImageSize {
+		return nil, errs.ErrImageTooLarge
+	}
+	// Sniff content, never trust client Content-Type
+	contentType := http.DetectContentType(data)
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return nil, errs.ErrImageTypeInvalid
+	}
+
+	thumbData, thumbType, err := thumbnail.Generate(data, consts.ThumbnailSize, consts.MaxImagePixels)
+	if err != nil {
+		if errors.Is(err, thumbnail.ErrTooManyPixels) {
+			return nil, errs.ErrImagePixels
+		}
+		return nil, errs.ErrImageTypeInvalid
+	}
+
+	// Upload Files
+	name := uuid.NewString()
+	objectKey := fmt.Sprintf("products/%d/%s%s", productID, name, ext)
+	thumbKey := fmt.Sprintf("products/%d/thumb_%s%s", productID, name, imageExtensions[thumbType])
+
+	if err := u.store.Put(ctx, objectKey, bytes.NewReader(data), contentType); err != nil {
+		return nil, err
+	}
+	if err := u.store.Put(ctx, thumbKey, bytes.NewReader(thumbData), thumbType); err != nil {
+		u.deleteObjects(ctx, objectKey)
+		return nil, err
+	}
+
+	// Save Image
+	img := &product.ProductImage{
+		ProductID:    productID,
+		ObjectKey:    objectKey,
+		ThumbnailKey: thumbKey,
+		ContentType:  contentType,
+		Size:         int64(len(data)),
+	}
+	err = u.tx.WithTransaction(ctx, func(tx *sql.Tx) error {
+		// Lock Product, concurrent uploads take the next position in turn
+		if _, err := u.repo.FindByIDForUpdate(ctx, tx, productID); err != nil {
+			return err
+		}
+		img, err = u.repo.InsertImage(ctx, tx, img)
+		return err
+	})
+	if err != nil {
+		u.deleteObjects(ctx, objectKey, thumbKey)
+		return nil, err
+	}
+
+	img.URL = u.store.URL(img.ObjectKey)
+	img.ThumbnailURL = u.store.URL(img.ThumbnailKey)
+	return img, nil
+}
+
+// deleteObjects cleans up uploads of a failed request, failures only leave
+// orphaned files so they are logged
+func (u *productUsecase) deleteObjects(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if err := u.store.Delete(ctx, key); err != nil {
+			log.Printf("product: delete %s failed: %v", key, err)
+		}
+	}
+}
+
+// attachImages loads images of all products in one query, ordered by position
+func (u *productUsecase) attachImages(ctx context.Context, products ...*product.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(products))
+	byID := make(map[int64]*product.Product, len(products))
+	for _, p := range products {
+		p.Images = make([]*product.ProductImage, 0)
+		ids = append(ids, p.ID)
+		byID[p.ID] = p
+	}
+
+	images, err := u.repo.ListImages(ctx, ids)
 	if err != nil {
 		return err
 	}
 
+	for _, img := range images {
+		p, ok := byID[img.ProductID]
+		if !ok {
+			continue
+		}
+		img.URL = u.store.URL(img.ObjectKey)
+		img.ThumbnailURL = u.store.URL(img.ThumbnailKey)
+		p.Images = append(p.Images, img)
+	}
+	return nil
+}
+
+// resolveSlug validates a custom slug, or generates one from the name.
+// Generated slugs fall back to name + sku (sku is unique) on conflict.
+func (u *productUsecase) resolveSlug(ctx context.Context, input *product.Product) (string, error) {
+	if input.Slug != "" {
+		if !helper.IsSlug(input.Slug) {
+			return "", errs.ErrProductSlugInvalid
+		}
+		exists, err := u.repo.SlugExists(ctx, input.Slug)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return "", errs.ErrProductSlugExists
+		}
+		return input.Slug, nil
+	}
+
+	slug := helper.Slugify(input.Name)
+	if slug != "" {
+		exists, err := u.repo.SlugExists(ctx, slug)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return slug, nil
+		}
+	}
+
+	slug = helper.Slugify(input.Name + "-" + input.SKU)
+	exists, err := u.repo.SlugExists(ctx, slug)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return "", errs.ErrProductSlugExists
+	}
+	return slug, nil
+}
+
+// isVisible published products are public, others only for owner & admin
+// Deleted products only for admin
+func (u *productUsecase) isVisible(ctx context.Context, p *product.Product) bool {
+	if p.DeletedAt != nil {
+		return u.isAdmin(ctx)
+	}
+	if p.Status == product.StatusPublished {
+		return true
+	}
+	currentUser, err := auth.GetCurrentUser(ctx)
+	if err != nil {
+		return false
+	}
+	return currentUser.Role == string(user.RoleAdmin) || currentUser.ID == p.OwnerID
+}
+
+// insert creates the product, initial stock opens its ledger
+func (u *productUsecase) insert(ctx context.Context, input *product.Product) (*product.Product, error) {
+	var productData *product.Product
+
+	err := u.tx.WithTransaction(ctx, func(tx *sql.Tx) error {
+		created, err := u.repo.Insert(ctx, tx, input)
+		if err != nil {
+			return err
+		}
+		productData = created
+
+		if created.Stock == 0 {
+			return nil
+		}
+		warehouseID, err := u.adjustDefaultWarehouse(ctx, tx, created.ID, created.Stock)
+		if err != nil {
+			return err
+		}
+		return u.inventoryRepo.InsertMovement(ctx, tx, &inventory.StockMovement{
+			ProductID:   created.ID,
+			Delta:       created.Stock,
+			Reason:      inventory.ReasonRestock,
+			RefType:     inventory.RefProduct,
+			RefID:       created.ID,
+			ActorID:     created.OwnerID,
+			Note:        "initial stock",
+			WarehouseID: warehouseID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return productData, nil
+}
+
+// update writes the product, a stock change is recorded as manual adjust
+// per warehouse it moved in
+func (u *productUsecase) update(ctx context.Context, input *product.ProductUpdate) (*product.Product, error) {
+	var productData *product.Product
+
+	err := u.tx.WithTransaction(ctx, func(tx *sql.Tx) error {
+		// Lock current stock, orders may change it meanwhile
+		var before *product.Product
+		fields := *input
+		if input.Stock != nil {
+			locked, err := u.repo.FindByIDForUpdate(ctx, tx, input.ID)
+			if err != nil {
+				return err
+			}
+			before = locked
+
+			// Stock moves one warehouse at a time below, each ledger row
+			// keeps its own stock after
+			fields.Stock = nil
+		}
+
+		updated, err := u.repo.Update(ctx, tx, &fields)
+		if err != nil {
+			return err
+		}
+		productData = updated
+
+		if before == nil || *input.Stock == before.Stock {
+			return nil
+		}
+		currentUser, err := auth.GetCurrentUser(ctx)
+		if err != nil {
+			return err
+		}
+
+		moves, err := u.warehouseMoves(ctx, tx, updated.ID, *input.Stock-before.Stock)
+		if err != nil {
+			return err
+		}
+		for _, m := range moves {
+			if err := u.inventoryRepo.AdjustWarehouseStock(ctx, tx, m.WarehouseID, m.ProductID, m.Delta); err != nil {
+				return err
+			}
+			if m.Delta > 0 {
+				err = u.repo.IncreaseStock(ctx, tx, m.ProductID, m.Delta)
+			} else {
+				err = u.repo.DecreaseStock(ctx, tx, m.ProductID, -m.Delta)
+			}
+			if err != nil {
+				return err
+			}
+
+			m.Reason = inventory.ReasonAdjust
+			m.RefType = inventory.RefProduct
+			m.RefID = updated.ID
+			m.ActorID = currentUser.ID
+			if err := u.inventoryRepo.InsertMovement(ctx, tx, m); err != nil {
+				return err
+			}
+			if err := inventory.RecordStockEvents(ctx, tx, u.inventoryRepo, m, updated.LowStockThreshold); err != nil {
+				return err
+			}
+		}
+		productData.Stock = *input.Stock
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return productData, nil
+}
+
+// adjustDefaultWarehouse stock edited on the product itself belongs to the default warehouse,
+// other warehouses are managed in /admin/inventory
+func (u *productUsecase) adjustDefaultWarehouse(ctx context.Context, tx *sql.Tx, productID int64, delta int) (int64, error) {
+	w, err := u.inventoryRepo.DefaultWarehouse(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+	if err := u.inventoryRepo.AdjustWarehouseStock(ctx, tx, w.ID, productID, delta); err != nil {
+		return 0, err
+	}
+	return w.ID, nil
+}
+
+// warehouseMoves splits a stock edit made on the product itself. Increases go
+// to the default warehouse, decreases take from it first and then from the
+// others, least preferred first so allocation keeps its best warehouses.
+func (u *productUsecase) warehouseMoves(ctx context.Context, tx *sql.Tx, productID int64, delta int) ([]*inventory.StockMovement, error) {
+	def, err := u.inventoryRepo.DefaultWarehouse(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	if delta > 0 {
+		return []*inventory.StockMovement{{ProductID: productID, WarehouseID: def.ID, Delta: delta}}, nil
+	}
+
+	levels, err := u.inventoryRepo.StockLevels(ctx, tx, []int64{productID})
+	if err != nil {
+		return nil, err
+	}
+	// Levels come in priority order
+	order := make([]*inventory.StockLevel, 0, len(levels))
+	for _, l := range levels {
+		if l.WarehouseID == def.ID {
+			order = append(order, l)
+		}
+	}
+	for i := len(levels) - 1; i >= 0; i-- {
+		if levels[i].WarehouseID != def.ID {
+			order = append(order, levels[i])
+		}
+	}
+
+	remaining := -delta
+	var moves []*inventory.StockMovement
+	for _, l := range order {
+		if remaining == 0 {
+			break
+		}
+		take := min(remaining, l.Quantity)
+		if take <= 0 {
+			continue
+		}
+		moves = append(moves, &inventory.StockMovement{ProductID: productID, WarehouseID: l.WarehouseID, Delta: -take})
+		remaining -= take
+	}
+	if remaining > 0 {
+		return nil, errs.ErrWarehouseNotEnough
+	}
+	return moves, nil
+}
+
+func (u *productUsecase) validateUpdate(ctx context.Context, input *product.ProductUpdate) error {
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		if len(name) < 2 {
+			return errs.ErrProductNameInvalid
+		}
+		input.Name = &name
+	}
+	if input.Price != nil && *input.Price < 0 {
+		return errs.ErrProductPriceInvalid
+	}
+	if input.Stock != nil && *input.Stock < 0 {
+		return errs.ErrProductStockInvalid
+	}
+	if input.LowStockThreshold != nil && *input.LowStockThreshold < 0 {
+		return errs.ErrLowStockThresholdInvalid
+	}
+	if input.ReturnWindowDays != nil && (*input.ReturnWindowDays < 0 || *input.ReturnWindowDays > consts.MaxReturnWindowDays) {
+		return errs.ErrReturnWindowInvalid
+	}
+	if input.Rules != nil {
+		if err := input.Rules.Validate(); err != nil {
+			return err
+		}
+	}
+	if input.Parcel != nil {
+		if err := input.Parcel.Validate(); err != nil {
+			return err
+		}
+	}
+	if input.Category != nil {
+		category := helper.Slugify(*input.Category)
+		input.Category = &category
+	}
+
+	// Check SKU
+	if input.SKU != nil {
+		exists, err := u.repo.SKUExists(ctx, *input.SKU)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return errs.ErrProductSKUExists
+		}
+	}
+
+	// Check Slug
+	if input.Slug != nil {
+		if !helper.IsSlug(*input.Slug) {
+			return errs.ErrProductSlugInvalid
+		}
+		exists, err := u.repo.SlugExists(ctx, *input.Slug)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return errs.ErrProductSlugExists
+		}
+	}
+	return nil
+}
+
+// dropUnchanged clears fields equal to the current value, no-op writes
+// don't bump the version.
+func dropUnchanged(current *product.Product, input *product.ProductUpdate) {
+	if input.Name != nil && *input.Name == current.Name {
+		input.Name = nil
+	}
+	if input.Description != nil && *input.Description == current.Description {
+		input.Description = nil
+	}
+	if input.Slug != nil && *input.Slug == current.Slug {
+		input.Slug = nil
+	}
+	if input.Status != nil && *input.Status == current.Status {
+		input.Status = nil
+	}
+	if input.Price != nil && *input.Price == current.Price {
+		input.Price = nil
+	}
+	if input.Stock != nil && *input.Stock == current.Stock {
+		input.Stock = nil
+	}
+	if input.SKU != nil && *input.SKU == current.SKU {
+		input.SKU = nil
+	}
+	if input.Category != nil && *input.Category == current.Category {
+		input.Category = nil
+	}
+	if input.LowStockThreshold != nil && *input.LowStockThreshold == current.LowStockThreshold {
+		input.LowStockThreshold = nil
+	}
+	if input.ReturnWindowDays != nil && *input.ReturnWindowDays == current.ReturnWindowDays {
+		input.ReturnWindowDays = nil
+	}
+	if input.Rules != nil && *input.Rules == current.Rules {
+		input.Rules = nil
+	}
+	if input.Parcel != nil && *input.Parcel == current.Parcel {
+		input.Parcel = nil
+	}
+}
+
+func (u *productUsecase) isAdmin(ctx context.Context) bool {
+	currentUser, err := auth.GetCurrentUser(ctx)
+	if err != nil {
+		return false
+	}
+	return currentUser.Role == string(user.RoleAdmin)
+}
+
+func (u *productUsecase) checkPermissions(ctx context.Context, productID int64) (*product.Product, error) {
+	currentUser, err := auth.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	productData, err := u.repo.FindByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	// Deleted products are read only until restored
+	if productData.DeletedAt != nil {
+		return nil, errs.ErrProductNotFound
+	}
+
 	// Check Admin & Product Owner
 	if currentUser.Role != string(user.RoleAdmin) {
 		if currentUser.ID != productData.OwnerID {
-			return errs.ErrNoPermissions
+			return nil, errs.ErrNoPermissions
 		}
 	}
-	return nil
+	return productData, nil
 }
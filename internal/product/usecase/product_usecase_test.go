@@ -1,16 +1,27 @@
 package productusecase_test
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
+	"encoding/binary"
 	"errors"
+	"hash/crc32"
+	"image"
+	"image/png"
 	"testing"
+	"time"
 
+	"github.com/codepnw/mini-ecommerce/internal/inventory"
+	inventoryrepository "github.com/codepnw/mini-ecommerce/internal/inventory/repository"
 	"github.com/codepnw/mini-ecommerce/internal/product"
 	productrepository "github.com/codepnw/mini-ecommerce/internal/product/repository"
 	productusecase "github.com/codepnw/mini-ecommerce/internal/product/usecase"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
 	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
 	"github.com/codepnw/mini-ecommerce/pkg/auth"
 	"github.com/codepnw/mini-ecommerce/pkg/jwt"
+	"github.com/codepnw/mini-ecommerce/pkg/storage"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 )
@@ -37,7 +48,9 @@ func TestCreateUsecase(t *testing.T) {
 				p := mockProduct()
 				mockRepo.EXPECT().SKUExists(gomock.Any(), input.SKU).Return(false, nil).Times(1)
 
-				mockRepo.EXPECT().Insert(gomock.Any(), input).Return(p, nil).Times(1)
+				mockRepo.EXPECT().SlugExists(gomock.Any(), "iphone-17").Return(false, nil).Times(1)
+
+				mockRepo.EXPECT().Insert(gomock.Any(), gomock.Any(), input).Return(p, nil).Times(1)
 			},
 			expectedErr: nil,
 		},
@@ -49,6 +62,72 @@ func TestCreateUsecase(t *testing.T) {
 			},
 			expectedErr: errs.ErrProductSKUExists,
 		},
+		{
+			name: "success generated slug taken",
+			input: &product.Product{
+				Name:    "IPhone 17",
+				Price:   35900,
+				Stock:   10,
+				OwnerID: 10,
+				SKU:     "IP17-BLK",
+			},
+			mockFn: func(mockRepo *productrepository.MockProductRepository, input *product.Product) {
+				p := mockProduct()
+				mockRepo.EXPECT().SKUExists(gomock.Any(), input.SKU).Return(false, nil).Times(1)
+
+				mockRepo.EXPECT().SlugExists(gomock.Any(), "iphone-17").Return(true, nil).Times(1)
+				mockRepo.EXPECT().SlugExists(gomock.Any(), "iphone-17-ip17-blk").Return(false, nil).Times(1)
+
+				mockRepo.EXPECT().Insert(gomock.Any(), gomock.Any(), input).Return(p, nil).Times(1)
+			},
+			expectedErr: nil,
+		},
+		{
+			name: "fail custom slug exists",
+			input: &product.Product{
+				Name: "IPhone 17",
+				Slug: "iphone-17",
+				SKU:  "apple-iphone-17",
+			},
+			mockFn: func(mockRepo *productrepository.MockProductRepository, input *product.Product) {
+				mockRepo.EXPECT().SKUExists(gomock.Any(), input.SKU).Return(false, nil).Times(1)
+
+				mockRepo.EXPECT().SlugExists(gomock.Any(), input.Slug).Return(true, nil).Times(1)
+			},
+			expectedErr: errs.ErrProductSlugExists,
+		},
+		{
+			name: "fail custom slug invalid",
+			input: &product.Product{
+				Name: "IPhone 17",
+				Slug: "IPhone 17!",
+				SKU:  "apple-iphone-17",
+			},
+			mockFn: func(mockRepo *productrepository.MockProductRepository, input *product.Product) {
+				mockRepo.EXPECT().SKUExists(gomock.Any(), input.SKU).Return(false, nil).Times(1)
+			},
+			expectedErr: errs.ErrProductSlugInvalid,
+		},
+		{
+			name: "fail purchase rules min above max",
+			input: &product.Product{
+				Name:  "IPhone 17",
+				SKU:   "apple-iphone-17",
+				Rules: product.PurchaseRules{MinQuantity: 5, MaxQuantity: 2},
+			},
+			mockFn:      func(mockRepo *productrepository.MockProductRepository, input *product.Product) {},
+			expectedErr: errs.ErrPurchaseRulesInvalid,
+		},
+		{
+			name: "fail return window too long",
+			input: &product.Product{
+				Name:             "IPhone 17",
+				SKU:              "apple-iphone-17",
+				ReturnWindowDays: 400,
+			},
+			mockFn:      func(mockRepo *productrepository.MockProductRepository, input *product.Product) {},
+			expectedErr: errs.ErrReturnWindowInvalid,
+		},
 		{
 			name: "fail create product",
 			input: &product.Product{
@@ -61,7 +140,9 @@ func TestCreateUsecase(t *testing.T) {
 			mockFn: func(mockRepo *productrepository.MockProductRepository, input *product.Product) {
 				mockRepo.EXPECT().SKUExists(gomock.Any(), input.SKU).Return(false, nil).Times(1)
 
-				mockRepo.EXPECT().Insert(gomock.Any(), input).Return(nil, errDBMock).Times(1)
+				mockRepo.EXPECT().SlugExists(gomock.Any(), "iphone-17").Return(false, nil).Times(1)
+
+				mockRepo.EXPECT().Insert(gomock.Any(), gomock.Any(), input).Return(nil, errDBMock).Times(1)
 			},
 			expectedErr: errDBMock,
 		},
@@ -106,9 +187,21 @@ func TestGetProduct(t *testing.T) {
 			mockFn: func(mockRepo *productrepository.MockProductRepository, productID int64) {
 				p := mockProduct()
 				mockRepo.EXPECT().FindByID(gomock.Any(), productID).Return(p, nil).Times(1)
+
+				mockRepo.EXPECT().ListImages(gomock.Any(), []int64{p.ID}).Return(mockImages(p.ID), nil).Times(1)
 			},
 			expectedErr: nil,
 		},
+		{
+			name:      "fail draft hidden from public",
+			productID: 100,
+			mockFn: func(mockRepo *productrepository.MockProductRepository, productID int64) {
+				p := mockProduct()
+				p.Status = product.StatusDraft
+				mockRepo.EXPECT().FindByID(gomock.Any(), productID).Return(p, nil).Times(1)
+			},
+			expectedErr: errs.ErrProductNotFound,
+		},
 		{
 			name:      "fail not found",
 			productID: 100,
@@ -167,6 +260,8 @@ func TestGetProducts(t *testing.T) {
 					{ID: p.ID + 1},
 				}
 				mockRepo.EXPECT().List(gomock.Any(), gomock.Any()).Return(list, nil).Times(1)
+
+				mockRepo.EXPECT().ListImages(gomock.Any(), []int64{p.ID, p.ID + 1}).Return(mockImages(p.ID), nil).Times(1)
 			},
 			expectedErr: nil,
 		},
@@ -202,47 +297,291 @@ func TestGetProducts(t *testing.T) {
 	}
 }
 
+func TestGetProductBySlug(t *testing.T) {
+	type testCase struct {
+		name        string
+		slug        string
+		ctx         context.Context
+		mockFn      func(mockRepo *productrepository.MockProductRepository, slug string)
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name: "success",
+			slug: "macbook",
+			ctx:  context.Background(),
+			mockFn: func(mockRepo *productrepository.MockProductRepository, slug string) {
+				p := mockProduct()
+				mockRepo.EXPECT().FindBySlug(gomock.Any(), slug).Return(p, nil).Times(1)
+
+				mockRepo.EXPECT().ListImages(gomock.Any(), []int64{p.ID}).Return(nil, nil).Times(1)
+			},
+			expectedErr: nil,
+		},
+		{
+			name: "success owner see draft",
+			slug: "macbook",
+			ctx:  mockUserClaims(),
+			mockFn: func(mockRepo *productrepository.MockProductRepository, slug string) {
+				p := mockProduct()
+				p.Status = product.StatusDraft
+				mockRepo.EXPECT().FindBySlug(gomock.Any(), slug).Return(p, nil).Times(1)
+
+				mockRepo.EXPECT().ListImages(gomock.Any(), []int64{p.ID}).Return(nil, nil).Times(1)
+			},
+			expectedErr: nil,
+		},
+		{
+			name: "fail draft hidden from other user",
+			slug: "macbook",
+			ctx:  mockUserClaims(),
+			mockFn: func(mockRepo *productrepository.MockProductRepository, slug string) {
+				p := mockProduct()
+				p.Status = product.StatusDraft
+				p.OwnerID = 11
+				mockRepo.EXPECT().FindBySlug(gomock.Any(), slug).Return(p, nil).Times(1)
+			},
+			expectedErr: errs.ErrProductNotFound,
+		},
+		{
+			name: "fail not found",
+			slug: "unknown",
+			ctx:  context.Background(),
+			mockFn: func(mockRepo *productrepository.MockProductRepository, slug string) {
+				mockRepo.EXPECT().FindBySlug(gomock.Any(), slug).Return(nil, errs.ErrProductNotFound).Times(1)
+			},
+			expectedErr: errs.ErrProductNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, mockRepo := setup(t)
+
+			tc.mockFn(mockRepo, tc.slug)
+
+			result, err := uc.GetBySlug(tc.ctx, tc.slug)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
+	}
+}
+
+func TestListMyProducts(t *testing.T) {
+	type testCase struct {
+		name        string
+		ctx         context.Context
+		mockFn      func(mockRepo *productrepository.MockProductRepository)
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name: "success",
+			ctx:  mockUserClaims(),
+			mockFn: func(mockRepo *productrepository.MockProductRepository) {
+				p := mockProduct()
+				p.Status = product.StatusDraft
+				filter := &product.ProductFilter{Status: "draft", Page: 1, Limit: 10, OwnerID: 10}
+				mockRepo.EXPECT().List(gomock.Any(), filter).Return([]*product.Product{p}, nil).Times(1)
+
+				mockRepo.EXPECT().ListImages(gomock.Any(), []int64{p.ID}).Return(nil, nil).Times(1)
+			},
+			expectedErr: nil,
+		},
+		{
+			name:        "fail unauthorized",
+			ctx:         context.Background(),
+			mockFn:      func(mockRepo *productrepository.MockProductRepository) {},
+			expectedErr: errs.ErrUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, mockRepo := setup(t)
+
+			tc.mockFn(mockRepo)
+
+			result, err := uc.ListMine(tc.ctx, &product.ProductFilter{Status: "draft"})
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, result, 1)
+			}
+		})
+	}
+}
+
 func TestUpdateProduct(t *testing.T) {
 	type testCase struct {
 		name        string
-		input       *product.Product
-		mockFn      func(mockRepo *productrepository.MockProductRepository, input *product.Product)
+		input       *product.ProductUpdate
+		mockFn      func(mockRepo *productrepository.MockProductRepository, input *product.ProductUpdate)
 		expectedErr error
 	}
 
 	testCases := []testCase{
 		{
 			name: "success",
-			input: &product.Product{
+			input: &product.ProductUpdate{
+				ID:    1,
+				Stock: ptr(5),
+			},
+			mockFn: func(mockRepo *productrepository.MockProductRepository, input *product.ProductUpdate) {
+				p := mockProduct()
+				mockRepo.EXPECT().FindByID(gomock.Any(), input.ID).Return(p, nil).Times(1)
+
+				mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), input.ID).Return(p, nil).Times(1)
+				// Stock moves through the warehouses
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any(), &product.ProductUpdate{ID: input.ID}).Return(p, nil).Times(1)
+				mockRepo.EXPECT().DecreaseStock(gomock.Any(), gomock.Any(), p.ID, 15).Return(nil).Times(1)
+			},
+			expectedErr: nil,
+		},
+		{
+			name: "success set stock and price to zero",
+			input: &product.ProductUpdate{
+				ID:    1,
+				Stock: ptr(0),
+				Price: ptr(0.0),
+			},
+			mockFn: func(mockRepo *productrepository.MockProductRepository, input *product.ProductUpdate) {
+				p := mockProduct()
+				p.Price = 100
+				mockRepo.EXPECT().FindByID(gomock.Any(), input.ID).Return(p, nil).Times(1)
+
+				mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), input.ID).Return(p, nil).Times(1)
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ *sql.Tx, in *product.ProductUpdate) (*product.Product, error) {
+						assert.Nil(t, in.Stock)
+						assert.Equal(t, 0.0, *in.Price)
+						return p, nil
+					},
+				).Times(1)
+				mockRepo.EXPECT().DecreaseStock(gomock.Any(), gomock.Any(), p.ID, 20).Return(nil).Times(1)
+			},
+			expectedErr: nil,
+		},
+		{
+			name: "success with matching version",
+			input: &product.ProductUpdate{
 				ID:      1,
-				OwnerID: 10,
-				Stock:   20,
+				Name:    ptr("Macbook Pro"),
+				Version: ptr(int64(3)),
 			},
-			mockFn: func(mockRepo *productrepository.MockProductRepository, input *product.Product) {
+			mockFn: func(mockRepo *productrepository.MockProductRepository, input *product.ProductUpdate) {
 				p := mockProduct()
+				p.Version = 3
 				mockRepo.EXPECT().FindByID(gomock.Any(), input.ID).Return(p, nil).Times(1)
 
-				mockRepo.EXPECT().Update(gomock.Any(), input).Return(input, nil).Times(1)
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any(), input).Return(p, nil).Times(1)
 			},
 			expectedErr: nil,
 		},
+		{
+			name: "fail version mismatch",
+			input: &product.ProductUpdate{
+				ID:      1,
+				Name:    ptr("Macbook Pro"),
+				Version: ptr(int64(2)),
+			},
+			mockFn: func(mockRepo *productrepository.MockProductRepository, input *product.ProductUpdate) {
+				p := mockProduct()
+				p.Version = 3
+				mockRepo.EXPECT().FindByID(gomock.Any(), input.ID).Return(p, nil).Times(1)
+			},
+			expectedErr: errs.ErrProductVersionMismatch,
+		},
+		{
+			name: "fail no fields to update",
+			input: &product.ProductUpdate{
+				ID: 1,
+			},
+			mockFn: func(mockRepo *productrepository.MockProductRepository, input *product.ProductUpdate) {
+				p := mockProduct()
+				mockRepo.EXPECT().FindByID(gomock.Any(), input.ID).Return(p, nil).Times(1)
+			},
+			expectedErr: errs.ErrNoFieldsToUpdate,
+		},
+		{
+			name: "fail nothing changed",
+			input: &product.ProductUpdate{
+				ID:    1,
+				Name:  ptr("Macbook"),
+				Stock: ptr(20),
+			},
+			mockFn: func(mockRepo *productrepository.MockProductRepository, input *product.ProductUpdate) {
+				p := mockProduct()
+				mockRepo.EXPECT().FindByID(gomock.Any(), input.ID).Return(p, nil).Times(1)
+			},
+			expectedErr: errs.ErrNoFieldsToUpdate,
+		},
+		{
+			name: "fail stock invalid",
+			input: &product.ProductUpdate{
+				ID:    1,
+				Stock: ptr(-1),
+			},
+			mockFn: func(mockRepo *productrepository.MockProductRepository, input *product.ProductUpdate) {
+				p := mockProduct()
+				mockRepo.EXPECT().FindByID(gomock.Any(), input.ID).Return(p, nil).Times(1)
+			},
+			expectedErr: errs.ErrProductStockInvalid,
+		},
+		{
+			name: "fail purchase rules off step",
+			input: &product.ProductUpdate{
+				ID:    1,
+				Rules: &product.PurchaseRules{QuantityStep: 6, MaxQuantity: 10},
+			},
+			mockFn: func(mockRepo *productrepository.MockProductRepository, input *product.ProductUpdate) {
+				p := mockProduct()
+				mockRepo.EXPECT().FindByID(gomock.Any(), input.ID).Return(p, nil).Times(1)
+			},
+			expectedErr: errs.ErrPurchaseRulesInvalid,
+		},
+		{
+			name: "fail sku exists",
+			input: &product.ProductUpdate{
+				ID:  1,
+				SKU: ptr("other-sku"),
+			},
+			mockFn: func(mockRepo *productrepository.MockProductRepository, input *product.ProductUpdate) {
+				p := mockProduct()
+				mockRepo.EXPECT().FindByID(gomock.Any(), input.ID).Return(p, nil).Times(1)
+
+				mockRepo.EXPECT().SKUExists(gomock.Any(), "other-sku").Return(true, nil).Times(1)
+			},
+			expectedErr: errs.ErrProductSKUExists,
+		},
 		{
 			name: "fail product not found",
-			input: &product.Product{
+			input: &product.ProductUpdate{
 				ID: 1,
 			},
-			mockFn: func(mockRepo *productrepository.MockProductRepository, input *product.Product) {
+			mockFn: func(mockRepo *productrepository.MockProductRepository, input *product.ProductUpdate) {
 				mockRepo.EXPECT().FindByID(gomock.Any(), input.ID).Return(nil, errs.ErrProductNotFound).Times(1)
 			},
 			expectedErr: errs.ErrProductNotFound,
 		},
 		{
 			name: "fail no permission",
-			input: &product.Product{
-				ID:      1,
-				OwnerID: 10,
+			input: &product.ProductUpdate{
+				ID:    1,
+				Stock: ptr(5),
 			},
-			mockFn: func(mockRepo *productrepository.MockProductRepository, input *product.Product) {
+			mockFn: func(mockRepo *productrepository.MockProductRepository, input *product.ProductUpdate) {
 				p := mockProduct()
 				p.OwnerID = 11
 				mockRepo.EXPECT().FindByID(gomock.Any(), input.ID).Return(p, nil).Times(1)
@@ -251,38 +590,41 @@ func TestUpdateProduct(t *testing.T) {
 		},
 		{
 			name: "fail update product",
-			input: &product.Product{
-				ID:      1,
-				OwnerID: 10,
+			input: &product.ProductUpdate{
+				ID:    1,
+				Stock: ptr(5),
 			},
-			mockFn: func(mockRepo *productrepository.MockProductRepository, input *product.Product) {
+			mockFn: func(mockRepo *productrepository.MockProductRepository, input *product.ProductUpdate) {
 				p := mockProduct()
 				mockRepo.EXPECT().FindByID(gomock.Any(), input.ID).Return(p, nil).Times(1)
 
-				mockRepo.EXPECT().Update(gomock.Any(), input).Return(nil, errDBMock).Times(1)
+				mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), input.ID).Return(p, nil).Times(1)
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any(), &product.ProductUpdate{ID: input.ID}).Return(nil, errDBMock).Times(1)
 			},
 			expectedErr: errDBMock,
 		},
 	}
 
 	for _, tc := range testCases {
-		// Setup
-		uc, mockRepo := setup(t)
-		ctx := mockUserClaims()
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			uc, mockRepo := setup(t)
+			ctx := mockUserClaims()
 
-		tc.mockFn(mockRepo, tc.input)
+			tc.mockFn(mockRepo, tc.input)
 
-		// Update Usecase
-		result, err := uc.Update(ctx, tc.input)
+			// Update Usecase
+			result, err := uc.Update(ctx, tc.input)
 
-		if tc.expectedErr != nil {
-			assert.Error(t, err)
-			assert.True(t, errors.Is(err, tc.expectedErr) || err.Error() == tc.expectedErr.Error())
-			assert.Nil(t, result)
-		} else {
-			assert.NoError(t, err)
-			assert.NotNil(t, result)
-		}
+			if tc.expectedErr != nil {
+				assert.Error(t, err)
+				assert.True(t, errors.Is(err, tc.expectedErr) || err.Error() == tc.expectedErr.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			}
+		})
 	}
 }
 
@@ -336,6 +678,20 @@ func TestDeleteProduct(t *testing.T) {
 			},
 			expectedErr: errs.ErrNoPermissions,
 		},
+		{
+			name: "fail product already deleted",
+			input: &product.Product{
+				ID:      1,
+				OwnerID: 10,
+			},
+			mockFn: func(mockRepo *productrepository.MockProductRepository, input *product.Product) {
+				p := mockProduct()
+				deletedAt := time.Now()
+				p.DeletedAt = &deletedAt
+				mockRepo.EXPECT().FindByID(gomock.Any(), input.ID).Return(p, nil).Times(1)
+			},
+			expectedErr: errs.ErrProductNotFound,
+		},
 		{
 			name: "fail delete product",
 			input: &product.Product{
@@ -369,19 +725,690 @@ func TestDeleteProduct(t *testing.T) {
 	}
 }
 
-// ============ Helper ================
-// ------------------------------------
-func setup(t *testing.T) (productusecase.ProductUsecase, *productrepository.MockProductRepository) {
-	t.Helper()
-
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
-
-	mockRepo := productrepository.NewMockProductRepository(ctrl)
-	uc := productusecase.NewProductUsecase(mockRepo)
+func TestRestoreProduct(t *testing.T) {
+	type testCase struct {
+		name        string
+		ctx         context.Context
+		productID   int64
+		mockFn      func(mockRepo *productrepository.MockProductRepository, productID int64)
+		expectedErr error
+	}
 
-	return uc, mockRepo
-}
+	testCases := []testCase{
+		{
+			name:      "success",
+			ctx:       mockAdminClaims(),
+			productID: 100,
+			mockFn: func(mockRepo *productrepository.MockProductRepository, productID int64) {
+				p := mockProduct()
+				p.Status = product.StatusDraft
+				mockRepo.EXPECT().Restore(gomock.Any(), productID).Return(p, nil).Times(1)
+			},
+			expectedErr: nil,
+		},
+		{
+			name:        "fail not admin",
+			ctx:         mockUserClaims(),
+			productID:   100,
+			mockFn:      func(mockRepo *productrepository.MockProductRepository, productID int64) {},
+			expectedErr: errs.ErrNoPermissions,
+		},
+		{
+			name:      "fail product not found",
+			ctx:       mockAdminClaims(),
+			productID: 100,
+			mockFn: func(mockRepo *productrepository.MockProductRepository, productID int64) {
+				mockRepo.EXPECT().Restore(gomock.Any(), productID).Return(nil, errs.ErrProductNotFound).Times(1)
+			},
+			expectedErr: errs.ErrProductNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, mockRepo := setup(t)
+
+			tc.mockFn(mockRepo, tc.productID)
+
+			result, err := uc.Restore(tc.ctx, tc.productID)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, product.StatusDraft, result.Status)
+				assert.Nil(t, result.DeletedAt)
+			}
+		})
+	}
+}
+
+func TestImportProducts(t *testing.T) {
+	type testCase struct {
+		name        string
+		rows        []*product.ImportRow
+		dryRun      bool
+		mockFn      func(mockRepo *productrepository.MockProductRepository)
+		expected    *product.ImportReport
+		expectedErr error
+	}
+
+	newRow := func(line int, sku string) *product.ImportRow {
+		return &product.ImportRow{
+			Line:    line,
+			Product: &product.Product{Name: "iPhone " + sku, SKU: sku, Price: 100, Stock: 5},
+		}
+	}
+
+	testCases := []testCase{
+		{
+			name: "success create and update",
+			rows: []*product.ImportRow{newRow(2, "sku-new"), newRow(3, "sku-own")},
+			mockFn: func(mockRepo *productrepository.MockProductRepository) {
+				// Create
+				mockRepo.EXPECT().FindBySKU(gomock.Any(), "sku-new").Return(nil, errs.ErrProductNotFound).Times(1)
+				mockRepo.EXPECT().SlugExists(gomock.Any(), "iphone-sku-new").Return(false, nil).Times(1)
+				mockRepo.EXPECT().Insert(gomock.Any(), gomock.Any(), gomock.Any()).Return(&product.Product{ID: 1}, nil).Times(1)
+
+				// Update
+				existing := mockProduct()
+				existing.SKU = "sku-own"
+				mockRepo.EXPECT().FindBySKU(gomock.Any(), "sku-own").Return(existing, nil).Times(1)
+				mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), existing.ID).Return(existing, nil).Times(1)
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Return(existing, nil).Times(1)
+				mockRepo.EXPECT().DecreaseStock(gomock.Any(), gomock.Any(), existing.ID, 15).Return(nil).Times(1)
+			},
+			expected: &product.ImportReport{Total: 2, Created: 1, Updated: 1},
+		},
+		{
+			name:   "success dry run writes nothing",
+			rows:   []*product.ImportRow{newRow(2, "sku-new")},
+			dryRun: true,
+			mockFn: func(mockRepo *productrepository.MockProductRepository) {
+				mockRepo.EXPECT().FindBySKU(gomock.Any(), "sku-new").Return(nil, errs.ErrProductNotFound).Times(1)
+				mockRepo.EXPECT().SlugExists(gomock.Any(), "iphone-sku-new").Return(false, nil).Times(1)
+			},
+			expected: &product.ImportReport{DryRun: true, Total: 1, Created: 1},
+		},
+		{
+			name: "success unchanged row",
+			rows: []*product.ImportRow{{
+				Line:    2,
+				Product: &product.Product{Name: "Macbook", SKU: "mock-product", Stock: 20},
+			}},
+			mockFn: func(mockRepo *productrepository.MockProductRepository) {
+				mockRepo.EXPECT().FindBySKU(gomock.Any(), "mock-product").Return(mockProduct(), nil).Times(1)
+			},
+			expected: &product.ImportReport{Total: 1, Unchanged: 1},
+		},
+		{
+			name: "success update keeps columns missing from file",
+			rows: []*product.ImportRow{{
+				Line:    2,
+				Product: &product.Product{Name: "Macbook Pro", SKU: "mock-product", Stock: 20},
+				Fields:  map[string]bool{"name": true, "price": true, "stock": true, "sku": true},
+			}},
+			mockFn: func(mockRepo *productrepository.MockProductRepository) {
+				existing := mockProduct()
+				existing.Description = "Apple laptop"
+				mockRepo.EXPECT().FindBySKU(gomock.Any(), "mock-product").Return(existing, nil).Times(1)
+				mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), existing.ID).Return(existing, nil).Times(1)
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ *sql.Tx, input *product.ProductUpdate) (*product.Product, error) {
+						assert.Equal(t, "Macbook Pro", *input.Name)
+						assert.Nil(t, input.Description)
+						return existing, nil
+					},
+				).Times(1)
+			},
+			expected: &product.ImportReport{Total: 1, Updated: 1},
+		},
+		{
+			name: "success update sets seller settings from file",
+			rows: []*product.ImportRow{{
+				Line: 2,
+				Product: &product.Product{
+					Name: "Macbook Pro", SKU: "mock-product", Stock: 20,
+					LowStockThreshold: 3,
+					ReturnWindowDays:  7,
+					Rules:             product.PurchaseRules{MinQuantity: 2, QuantityStep: 2},
+					Parcel:            product.Parcel{WeightGrams: 900, LengthCM: 30},
+				},
+				Fields: map[string]bool{
+					"name": true, "price": true, "stock": true, "sku": true,
+					"low_stock_threshold": true, "return_window_days": true, "purchase_rules": true,
+					"parcel": true,
+				},
+			}},
+			mockFn: func(mockRepo *productrepository.MockProductRepository) {
+				existing := mockProduct()
+				existing.Name = "Macbook Pro"
+				mockRepo.EXPECT().FindBySKU(gomock.Any(), "mock-product").Return(existing, nil).Times(1)
+				mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), existing.ID).Return(existing, nil).Times(1)
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ *sql.Tx, input *product.ProductUpdate) (*product.Product, error) {
+						assert.Equal(t, 3, *input.LowStockThreshold)
+						assert.Equal(t, 7, *input.ReturnWindowDays)
+						assert.Equal(t, product.PurchaseRules{MinQuantity: 2, QuantityStep: 2}, *input.Rules)
+						assert.Equal(t, product.Parcel{WeightGrams: 900, LengthCM: 30}, *input.Parcel)
+						return existing, nil
+					},
+				).Times(1)
+			},
+			expected: &product.ImportReport{Total: 1, Updated: 1},
+		},
+		{
+			name: "rows failed are reported",
+			rows: []*product.ImportRow{
+				{Line: 2, Err: errors.New("invalid price")},
+				newRow(3, "sku-other"),
+				newRow(4, "sku-new"),
+				newRow(5, "sku-new"),
+			},
+			mockFn: func(mockRepo *productrepository.MockProductRepository) {
+				// SKU belongs to other seller
+				other := mockProduct()
+				other.OwnerID = 11
+				mockRepo.EXPECT().FindBySKU(gomock.Any(), "sku-other").Return(other, nil).Times(1)
+
+				mockRepo.EXPECT().FindBySKU(gomock.Any(), "sku-new").Return(nil, errs.ErrProductNotFound).Times(1)
+				mockRepo.EXPECT().SlugExists(gomock.Any(), "iphone-sku-new").Return(false, nil).Times(1)
+				mockRepo.EXPECT().Insert(gomock.Any(), gomock.Any(), gomock.Any()).Return(&product.Product{ID: 1}, nil).Times(1)
+			},
+			expected: &product.ImportReport{Total: 4, Created: 1, Failed: 3},
+		},
+		{
+			name: "fail db error aborts",
+			rows: []*product.ImportRow{newRow(2, "sku-new")},
+			mockFn: func(mockRepo *productrepository.MockProductRepository) {
+				mockRepo.EXPECT().FindBySKU(gomock.Any(), "sku-new").Return(nil, errDBMock).Times(1)
+			},
+			expectedErr: errDBMock,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, mockRepo := setup(t)
+			ctx := mockUserClaims()
+
+			tc.mockFn(mockRepo)
+
+			report, err := uc.Import(ctx, tc.rows, tc.dryRun)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, report)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected.DryRun, report.DryRun)
+			assert.Equal(t, tc.expected.Total, report.Total)
+			assert.Equal(t, tc.expected.Created, report.Created)
+			assert.Equal(t, tc.expected.Updated, report.Updated)
+			assert.Equal(t, tc.expected.Unchanged, report.Unchanged)
+			assert.Equal(t, tc.expected.Failed, report.Failed)
+			assert.Len(t, report.Rows, len(tc.rows))
+
+			for _, row := range report.Rows {
+				if row.Action == product.ImportFailed {
+					assert.NotEmpty(t, row.Error)
+				}
+			}
+		})
+	}
+}
+
+func TestExportProducts(t *testing.T) {
+	uc, mockRepo := setup(t)
+	ctx := mockUserClaims()
+
+	mockRepo.EXPECT().StreamByOwner(gomock.Any(), int64(10), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ int64, fn func(p *product.Product) error) error {
+			for _, p := range []*product.Product{mockProduct(), mockProduct()} {
+				if err := fn(p); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	).Times(1)
+
+	var exported []*product.Product
+	err := uc.Export(ctx, func(p *product.Product) error {
+		exported = append(exported, p)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, exported, 2)
+
+	// Unauthorized
+	err = uc.Export(context.Background(), func(p *product.Product) error { return nil })
+	assert.ErrorIs(t, err, errs.ErrUnauthorized)
+}
+
+func TestProductStockLedger(t *testing.T) {
+	t.Run("create opens ledger", func(t *testing.T) {
+		uc, mockRepo, mockInvRepo := setupWithInventory(t)
+
+		input := &product.Product{Name: "iPhone", SKU: "iphone-17", Price: 100, Stock: 7, OwnerID: 10}
+		mockRepo.EXPECT().SKUExists(gomock.Any(), input.SKU).Return(false, nil).Times(1)
+		mockRepo.EXPECT().SlugExists(gomock.Any(), "iphone").Return(false, nil).Times(1)
+
+		created := &product.Product{ID: 1, Stock: 7, OwnerID: 10}
+		mockRepo.EXPECT().Insert(gomock.Any(), gomock.Any(), input).Return(created, nil).Times(1)
+		mockInvRepo.EXPECT().DefaultWarehouse(gomock.Any(), gomock.Any()).Return(mockWarehouse(), nil).Times(1)
+		mockInvRepo.EXPECT().AdjustWarehouseStock(gomock.Any(), gomock.Any(), mockWarehouse().ID, int64(1), 7).Return(nil).Times(1)
+		mockInvRepo.EXPECT().InsertMovement(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ *sql.Tx, m *inventory.StockMovement) error {
+				assert.Equal(t, inventory.ReasonRestock, m.Reason)
+				assert.Equal(t, 7, m.Delta)
+				assert.Equal(t, int64(1), m.RefID)
+				assert.Equal(t, mockWarehouse().ID, m.WarehouseID)
+				return nil
+			},
+		).Times(1)
+
+		_, err := uc.Create(mockUserClaims(), input)
+		assert.NoError(t, err)
+	})
+
+	t.Run("stock update records adjust", func(t *testing.T) {
+		uc, mockRepo, mockInvRepo := setupWithInventory(t)
+
+		before := mockProduct()
+
+		input := &product.ProductUpdate{ID: before.ID, Stock: ptr(0)}
+		mockRepo.EXPECT().FindByID(gomock.Any(), before.ID).Return(before, nil).Times(1)
+		mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), before.ID).Return(before, nil).Times(1)
+		mockRepo.EXPECT().Update(gomock.Any(), gomock.Any(), &product.ProductUpdate{ID: before.ID}).Return(mockProduct(), nil).Times(1)
+		mockInvRepo.EXPECT().DefaultWarehouse(gomock.Any(), gomock.Any()).Return(mockWarehouse(), nil).Times(1)
+		mockInvRepo.EXPECT().StockLevels(gomock.Any(), gomock.Any(), []int64{before.ID}).Return([]*inventory.StockLevel{
+			{WarehouseID: mockWarehouse().ID, Quantity: 20},
+		}, nil).Times(1)
+		mockInvRepo.EXPECT().AdjustWarehouseStock(gomock.Any(), gomock.Any(), mockWarehouse().ID, before.ID, -20).Return(nil).Times(1)
+		mockRepo.EXPECT().DecreaseStock(gomock.Any(), gomock.Any(), before.ID, 20).Return(nil).Times(1)
+		mockInvRepo.EXPECT().InsertMovement(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ *sql.Tx, m *inventory.StockMovement) error {
+				assert.Equal(t, inventory.ReasonAdjust, m.Reason)
+				assert.Equal(t, -20, m.Delta)
+				assert.Equal(t, int64(10), m.ActorID)
+				return nil
+			},
+		).Times(1)
+
+		result, err := uc.Update(mockUserClaims(), input)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, result.Stock)
+	})
+
+	t.Run("ledger failure rolls back update", func(t *testing.T) {
+		uc, mockRepo, mockInvRepo := setupWithInventory(t)
+
+		before := mockProduct()
+
+		input := &product.ProductUpdate{ID: before.ID, Stock: ptr(25)}
+		mockRepo.EXPECT().FindByID(gomock.Any(), before.ID).Return(before, nil).Times(1)
+		mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), before.ID).Return(before, nil).Times(1)
+		mockRepo.EXPECT().Update(gomock.Any(), gomock.Any(), &product.ProductUpdate{ID: before.ID}).Return(mockProduct(), nil).Times(1)
+		mockInvRepo.EXPECT().DefaultWarehouse(gomock.Any(), gomock.Any()).Return(mockWarehouse(), nil).Times(1)
+		mockInvRepo.EXPECT().AdjustWarehouseStock(gomock.Any(), gomock.Any(), mockWarehouse().ID, before.ID, 5).Return(nil).Times(1)
+		mockRepo.EXPECT().IncreaseStock(gomock.Any(), gomock.Any(), before.ID, 5).Return(nil).Times(1)
+		mockInvRepo.EXPECT().InsertMovement(gomock.Any(), gomock.Any(), gomock.Any()).Return(errDBMock).Times(1)
+
+		result, err := uc.Update(mockUserClaims(), input)
+		assert.ErrorIs(t, err, errDBMock)
+		assert.Nil(t, result)
+	})
+
+	t.Run("decrease spreads over warehouses", func(t *testing.T) {
+		uc, mockRepo, mockInvRepo := setupWithInventory(t)
+
+		before := mockProduct()
+
+		input := &product.ProductUpdate{ID: before.ID, Stock: ptr(2)}
+		mockRepo.EXPECT().FindByID(gomock.Any(), before.ID).Return(before, nil).Times(1)
+		mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), before.ID).Return(before, nil).Times(1)
+		mockRepo.EXPECT().Update(gomock.Any(), gomock.Any(), &product.ProductUpdate{ID: before.ID}).Return(mockProduct(), nil).Times(1)
+		// Most of the stock sits in other warehouses, priority order
+		mockInvRepo.EXPECT().DefaultWarehouse(gomock.Any(), gomock.Any()).Return(mockWarehouse(), nil).Times(1)
+		mockInvRepo.EXPECT().StockLevels(gomock.Any(), gomock.Any(), []int64{before.ID}).Return([]*inventory.StockLevel{
+			{WarehouseID: mockWarehouse().ID, Quantity: 4},
+			{WarehouseID: 2, Quantity: 6},
+			{WarehouseID: 3, Quantity: 10},
+		}, nil).Times(1)
+
+		// Default first, then the least preferred
+		moves := []struct {
+			warehouseID int64
+			delta       int
+		}{{mockWarehouse().ID, -4}, {3, -10}, {2, -4}}
+		var calls []*gomock.Call
+		for _, m := range moves {
+			calls = append(calls,
+				mockInvRepo.EXPECT().AdjustWarehouseStock(gomock.Any(), gomock.Any(), m.warehouseID, before.ID, m.delta).Return(nil).Times(1),
+				mockRepo.EXPECT().DecreaseStock(gomock.Any(), gomock.Any(), before.ID, -m.delta).Return(nil).Times(1),
+			)
+		}
+		gomock.InOrder(calls...)
+		mockInvRepo.EXPECT().InsertMovement(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(3)
+
+		result, err := uc.Update(mockUserClaims(), input)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, result.Stock)
+	})
+
+	t.Run("fail warehouses not enough", func(t *testing.T) {
+		uc, mockRepo, mockInvRepo := setupWithInventory(t)
+
+		before := mockProduct()
+
+		input := &product.ProductUpdate{ID: before.ID, Stock: ptr(2)}
+		mockRepo.EXPECT().FindByID(gomock.Any(), before.ID).Return(before, nil).Times(1)
+		mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), before.ID).Return(before, nil).Times(1)
+		mockRepo.EXPECT().Update(gomock.Any(), gomock.Any(), &product.ProductUpdate{ID: before.ID}).Return(mockProduct(), nil).Times(1)
+		// Warehouses drifted below the product stock
+		mockInvRepo.EXPECT().DefaultWarehouse(gomock.Any(), gomock.Any()).Return(mockWarehouse(), nil).Times(1)
+		mockInvRepo.EXPECT().StockLevels(gomock.Any(), gomock.Any(), []int64{before.ID}).Return([]*inventory.StockLevel{
+			{WarehouseID: mockWarehouse().ID, Quantity: 4},
+			{WarehouseID: 2, Quantity: 6},
+		}, nil).Times(1)
+		mockInvRepo.EXPECT().AdjustWarehouseStock(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		result, err := uc.Update(mockUserClaims(), input)
+		assert.ErrorIs(t, err, errs.ErrWarehouseNotEnough)
+		assert.Nil(t, result)
+	})
+
+	t.Run("crossing threshold records low stock event", func(t *testing.T) {
+		uc, mockRepo, mockInvRepo := setupWithInventory(t)
+
+		before := mockProduct()
+		before.LowStockThreshold = 5
+		after := mockProduct()
+		after.LowStockThreshold = 5
+
+		input := &product.ProductUpdate{ID: before.ID, Stock: ptr(4)}
+		mockRepo.EXPECT().FindByID(gomock.Any(), before.ID).Return(before, nil).Times(1)
+		mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), before.ID).Return(before, nil).Times(1)
+		mockRepo.EXPECT().Update(gomock.Any(), gomock.Any(), &product.ProductUpdate{ID: before.ID}).Return(after, nil).Times(1)
+		mockInvRepo.EXPECT().DefaultWarehouse(gomock.Any(), gomock.Any()).Return(mockWarehouse(), nil).Times(1)
+		mockInvRepo.EXPECT().StockLevels(gomock.Any(), gomock.Any(), []int64{before.ID}).Return([]*inventory.StockLevel{
+			{WarehouseID: mockWarehouse().ID, Quantity: 20},
+		}, nil).Times(1)
+		mockInvRepo.EXPECT().AdjustWarehouseStock(gomock.Any(), gomock.Any(), mockWarehouse().ID, before.ID, -16).Return(nil).Times(1)
+		mockRepo.EXPECT().DecreaseStock(gomock.Any(), gomock.Any(), before.ID, 16).Return(nil).Times(1)
+		mockInvRepo.EXPECT().InsertMovement(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ *sql.Tx, m *inventory.StockMovement) error {
+				m.StockAfter = 4
+				return nil
+			},
+		).Times(1)
+		mockInvRepo.EXPECT().InsertEvent(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ *sql.Tx, e *inventory.StockEvent) error {
+				assert.Equal(t, inventory.EventLowStock, e.Kind)
+				assert.Equal(t, 4, e.Stock)
+				return nil
+			},
+		).Times(1)
+
+		_, err := uc.Update(mockUserClaims(), input)
+		assert.NoError(t, err)
+	})
+}
+
+func TestSubscribeBackInStock(t *testing.T) {
+	soldOut := func() *product.Product {
+		p := mockProduct()
+		p.Stock = 0
+		return p
+	}
+
+	type testCase struct {
+		name        string
+		ctx         context.Context
+		productID   int64
+		mockFn      func(mockRepo *productrepository.MockProductRepository, mockInvRepo *inventoryrepository.MockInventoryRepository, productID int64)
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name:      "success",
+			ctx:       mockUserClaims(),
+			productID: 100,
+			mockFn: func(mockRepo *productrepository.MockProductRepository, mockInvRepo *inventoryrepository.MockInventoryRepository, productID int64) {
+				mockRepo.EXPECT().FindByID(gomock.Any(), productID).Return(soldOut(), nil).Times(1)
+				mockInvRepo.EXPECT().Subscribe(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, s *inventory.Subscription) error {
+						assert.Equal(t, int64(10), s.UserID)
+						assert.Equal(t, "example@mail.com", s.Email)
+						s.ID = 1
+						return nil
+					},
+				).Times(1)
+			},
+		},
+		{
+			name:      "fail unauthorized",
+			ctx:       context.Background(),
+			productID: 100,
+			mockFn: func(mockRepo *productrepository.MockProductRepository, mockInvRepo *inventoryrepository.MockInventoryRepository, productID int64) {
+			},
+			expectedErr: errs.ErrUnauthorized,
+		},
+		{
+			name:      "fail product in stock",
+			ctx:       mockUserClaims(),
+			productID: 100,
+			mockFn: func(mockRepo *productrepository.MockProductRepository, mockInvRepo *inventoryrepository.MockInventoryRepository, productID int64) {
+				mockRepo.EXPECT().FindByID(gomock.Any(), productID).Return(mockProduct(), nil).Times(1)
+			},
+			expectedErr: errs.ErrProductInStock,
+		},
+		{
+			name:      "fail product unavailable",
+			ctx:       mockUserClaims(),
+			productID: 100,
+			mockFn: func(mockRepo *productrepository.MockProductRepository, mockInvRepo *inventoryrepository.MockInventoryRepository, productID int64) {
+				p := soldOut()
+				p.Status = product.StatusDraft
+				mockRepo.EXPECT().FindByID(gomock.Any(), productID).Return(p, nil).Times(1)
+			},
+			expectedErr: errs.ErrProductUnavailable,
+		},
+		{
+			name:      "fail product not found",
+			ctx:       mockUserClaims(),
+			productID: 100,
+			mockFn: func(mockRepo *productrepository.MockProductRepository, mockInvRepo *inventoryrepository.MockInventoryRepository, productID int64) {
+				mockRepo.EXPECT().FindByID(gomock.Any(), productID).Return(nil, errs.ErrProductNotFound).Times(1)
+			},
+			expectedErr: errs.ErrProductNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, mockRepo, mockInvRepo := setupWithInventory(t)
+
+			tc.mockFn(mockRepo, mockInvRepo, tc.productID)
+
+			result, err := uc.Subscribe(tc.ctx, tc.productID)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, int64(1), result.ID)
+			}
+		})
+	}
+}
+
+func TestUploadImage(t *testing.T) {
+	type testCase struct {
+		name        string
+		productID   int64
+		data        func(t *testing.T) []byte
+		mockFn      func(mockRepo *productrepository.MockProductRepository, productID int64)
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name:      "success",
+			productID: 100,
+			data: func(t *testing.T) []byte {
+				return mockPNG(t, 800, 600)
+			},
+			mockFn: func(mockRepo *productrepository.MockProductRepository, productID int64) {
+				p := mockProduct()
+				mockRepo.EXPECT().FindByID(gomock.Any(), productID).Return(p, nil).Times(1)
+
+				mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), productID).Return(p, nil).Times(1)
+				mockRepo.EXPECT().InsertImage(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ *sql.Tx, img *product.ProductImage) (*product.ProductImage, error) {
+						img.ID = 1
+						img.Position = 1
+						return img, nil
+					},
+				).Times(1)
+			},
+			expectedErr: nil,
+		},
+		{
+			name:      "fail no permission",
+			productID: 100,
+			data: func(t *testing.T) []byte {
+				return mockPNG(t, 10, 10)
+			},
+			mockFn: func(mockRepo *productrepository.MockProductRepository, productID int64) {
+				p := mockProduct()
+				p.OwnerID = 11
+				mockRepo.EXPECT().FindByID(gomock.Any(), productID).Return(p, nil).Times(1)
+			},
+			expectedErr: errs.ErrNoPermissions,
+		},
+		{
+			name:      "fail invalid type",
+			productID: 100,
+			data: func(t *testing.T) []byte {
+				return []byte("%PDF-1.4 not an image")
+			},
+			mockFn: func(mockRepo *productrepository.MockProductRepository, productID int64) {
+				p := mockProduct()
+				mockRepo.EXPECT().FindByID(gomock.Any(), productID).Return(p, nil).Times(1)
+			},
+			expectedErr: errs.ErrImageTypeInvalid,
+		},
+		{
+			name:      "fail too large",
+			productID: 100,
+			data: func(t *testing.T) []byte {
+				return make([]byte, consts.MaxImageSize+1)
+			},
+			mockFn: func(mockRepo *productrepository.MockProductRepository, productID int64) {
+				p := mockProduct()
+				mockRepo.EXPECT().FindByID(gomock.Any(), productID).Return(p, nil).Times(1)
+			},
+			expectedErr: errs.ErrImageTooLarge,
+		},
+		{
+			name:      "fail too many pixels",
+			productID: 100,
+			data: func(t *testing.T) []byte {
+				// Small file declaring 50000 x 50000
+				return mockPNGHeader(t, 50000, 50000)
+			},
+			mockFn: func(mockRepo *productrepository.MockProductRepository, productID int64) {
+				p := mockProduct()
+				mockRepo.EXPECT().FindByID(gomock.Any(), productID).Return(p, nil).Times(1)
+				mockRepo.EXPECT().InsertImage(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedErr: errs.ErrImagePixels,
+		},
+		{
+			name:      "fail insert image",
+			productID: 100,
+			data: func(t *testing.T) []byte {
+				return mockPNG(t, 10, 10)
+			},
+			mockFn: func(mockRepo *productrepository.MockProductRepository, productID int64) {
+				p := mockProduct()
+				mockRepo.EXPECT().FindByID(gomock.Any(), productID).Return(p, nil).Times(1)
+
+				mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), gomock.Any(), productID).Return(p, nil).Times(1)
+				mockRepo.EXPECT().InsertImage(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errDBMock).Times(1)
+			},
+			expectedErr: errDBMock,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, mockRepo := setup(t)
+			ctx := mockUserClaims()
+
+			tc.mockFn(mockRepo, tc.productID)
+
+			result, err := uc.UploadImage(ctx, tc.productID, tc.data(t))
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+				assert.Equal(t, "image/png", result.ContentType)
+				assert.Contains(t, result.URL, "http://localhost/uploads/products/100/")
+				assert.Contains(t, result.ThumbnailURL, "thumb_")
+			}
+		})
+	}
+}
+
+// ============ Helper ================
+// ------------------------------------
+func setup(t *testing.T) (productusecase.ProductUsecase, *productrepository.MockProductRepository) {
+	t.Helper()
+
+	uc, mockRepo, mockInvRepo := setupWithInventory(t)
+	// Ledger writes are checked in TestProductStockLedger
+	mockInvRepo.EXPECT().InsertMovement(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockInvRepo.EXPECT().DefaultWarehouse(gomock.Any(), gomock.Any()).Return(mockWarehouse(), nil).AnyTimes()
+	mockInvRepo.EXPECT().AdjustWarehouseStock(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockInvRepo.EXPECT().StockLevels(gomock.Any(), gomock.Any(), gomock.Any()).Return([]*inventory.StockLevel{
+		{WarehouseID: mockWarehouse().ID, Quantity: 20},
+	}, nil).AnyTimes()
+
+	return uc, mockRepo
+}
+
+func setupWithInventory(t *testing.T) (productusecase.ProductUsecase, *productrepository.MockProductRepository, *inventoryrepository.MockInventoryRepository) {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := productrepository.NewMockProductRepository(ctrl)
+	mockInvRepo := inventoryrepository.NewMockInventoryRepository(ctrl)
+	store, err := storage.NewLocalStore(t.TempDir(), "http://localhost/uploads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uc := productusecase.NewProductUsecase(mockRepo, mockInvRepo, &mockTxManager{}, store)
+
+	return uc, mockRepo, mockInvRepo
+}
+
+type mockTxManager struct{}
+
+func (m *mockTxManager) WithTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return fn(nil)
+}
 
 func mockUserClaims() context.Context {
 	mockUser := &jwt.UserClaims{
@@ -392,14 +1419,63 @@ func mockUserClaims() context.Context {
 	return auth.SetCurrentUser(context.Background(), mockUser)
 }
 
+func mockAdminClaims() context.Context {
+	mockUser := &jwt.UserClaims{
+		ID:    1,
+		Email: "admin@mail.com",
+		Role:  "admin",
+	}
+	return auth.SetCurrentUser(context.Background(), mockUser)
+}
+
+func mockWarehouse() *inventory.Warehouse {
+	return &inventory.Warehouse{ID: 1, Code: "MAIN", IsDefault: true, IsActive: true}
+}
+
 func mockProduct() *product.Product {
 	return &product.Product{
 		ID:      100,
 		OwnerID: 10,
 		Name:    "Macbook",
+		Slug:    "macbook",
+		Status:  product.StatusPublished,
 		Stock:   20,
 		SKU:     "mock-product",
 	}
 }
 
+func mockImages(productID int64) []*product.ProductImage {
+	return []*product.ProductImage{
+		{ID: 1, ProductID: productID, ObjectKey: "products/100/a.jpg", ThumbnailKey: "products/100/thumb_a.jpg", Position: 1},
+		{ID: 2, ProductID: productID, ObjectKey: "products/100/b.jpg", ThumbnailKey: "products/100/thumb_b.jpg", Position: 2},
+	}
+}
+
+func mockPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// mockPNGHeader a tiny PNG whose header declares w x h
+func mockPNGHeader(t *testing.T, w, h int) []byte {
+	t.Helper()
+
+	data := mockPNG(t, 1, 1)
+	// IHDR data follows the signature, chunk length & type
+	binary.BigEndian.PutUint32(data[16:], uint32(w))
+	binary.BigEndian.PutUint32(data[20:], uint32(h))
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
 var errDBMock = errors.New("db error")
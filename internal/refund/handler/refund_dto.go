@@ -0,0 +1,15 @@
+package refundhandler
+
+// RefundReq no lines refunds everything still refundable, a line without
+// quantity refunds what is left of it
+type RefundReq struct {
+	Lines   []RefundLineReq `json:"lines" binding:"omitempty,dive"`
+	Reason  string          `json:"reason" binding:"required,oneof=customer_request damaged defective wrong_item not_received other"`
+	Note    string          `json:"note" binding:"omitempty,max=500"`
+	Restock bool            `json:"restock"`
+}
+
+type RefundLineReq struct {
+	OrderItemID int64 `json:"order_item_id" binding:"required,gt=0"`
+	Quantity    int   `json:"quantity" binding:"gte=0"`
+}
@@ -0,0 +1,95 @@
+package refundhandler
+
+import (
+	"github.com/codepnw/mini-ecommerce/internal/refund"
+	refundusecase "github.com/codepnw/mini-ecommerce/internal/refund/usecase"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/internal/utils/helper"
+	"github.com/codepnw/mini-ecommerce/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+type refundHandler struct {
+	uc refundusecase.RefundUsecase
+}
+
+func NewRefundHandler(uc refundusecase.RefundUsecase) *refundHandler {
+	return &refundHandler{uc: uc}
+}
+
+func (h *refundHandler) CreateRefund(c *gin.Context) {
+	orderID, err := helper.GetParamInt(c, consts.ParamOrderID)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	req := new(RefundReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	input := &refund.Request{
+		OrderID: orderID,
+		Reason:  refund.Reason(req.Reason),
+		Note:    req.Note,
+		Restock: req.Restock,
+	}
+	for _, l := range req.Lines {
+		input.Lines = append(input.Lines, &refund.RequestLine{OrderItemID: l.OrderItemID, Quantity: l.Quantity})
+	}
+
+	result, err := h.uc.CreateRefund(c.Request.Context(), input)
+	if err != nil {
+		switch err {
+		case errs.ErrNoPermissions:
+			response.Forbidden(c, err.Error())
+			return
+		case errs.ErrOrderNotFound:
+			response.NotFound(c, err.Error())
+			return
+		case errs.ErrRefundLineNotFound, errs.ErrRefundQuantityExceeded:
+			response.BadRequest(c, err.Error())
+			return
+		case errs.ErrRefundNotAllowed, errs.ErrNothingToRefund:
+			response.Conflict(c, err.Error(), nil)
+			return
+		case errs.ErrRefundDeclined:
+			response.UnprocessableEntity(c, err.Error(), nil)
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.Created(c, result)
+}
+
+func (h *refundHandler) ListRefunds(c *gin.Context) {
+	orderID, err := helper.GetParamInt(c, consts.ParamOrderID)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.ListRefunds(c.Request.Context(), orderID)
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrNoPermissions:
+			response.Forbidden(c, err.Error())
+			return
+		case errs.ErrOrderNotFound:
+			response.NotFound(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "", result)
+}
@@ -0,0 +1,199 @@
+package refund
+
+import (
+	"math"
+	"time"
+
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+)
+
+type Reason string
+
+const (
+	ReasonCustomerRequest Reason = "customer_request"
+	ReasonDamaged         Reason = "damaged"
+	ReasonDefective       Reason = "defective"
+	ReasonWrongItem       Reason = "wrong_item"
+	ReasonNotReceived     Reason = "not_received"
+	ReasonOther           Reason = "other"
+)
+
+// Status of the provider payment. A refund is committed pending and settled
+// once the provider paid it. Declined refunds were not paid, their lines
+// stay refunded for an admin to pay back another way.
+type Status string
+
+const (
+	StatusPending  Status = "pending"
+	StatusSettled  Status = "settled"
+	StatusDeclined Status = "declined"
+)
+
+// Refund money paid back on lines of an order, Amount is the sum of its
+// items and the shipping paid back with the last line
+type Refund struct {
+	ID          int64     `json:"id"`
+	OrderID     int64     `json:"order_id"`
+	Amount      float64   `json:"amount"`
+	Shipping    float64   `json:"shipping_amount"`
+	Reason      Reason    `json:"reason"`
+	Note        string    `json:"note,omitempty"`
+	Restock     bool      `json:"restock"`
+	Status      Status    `json:"status"`
+	ProviderRef string    `json:"provider_ref,omitempty"`
+	CreatedBy   int64     `json:"created_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	Items       []*Item   `json:"items"`
+}
+
+type Item struct {
+	ID          int64   `json:"id"`
+	RefundID    int64   `json:"-"`
+	OrderItemID int64   `json:"order_item_id"`
+	SubOrderID  int64   `json:"sub_order_id,omitempty"`
+	ProductID   int64   `json:"product_id"`
+	Quantity    int     `json:"quantity"`
+	Amount      float64 `json:"amount"`
+}
+
+// Request no lines refunds everything still refundable
+type Request struct {
+	OrderID int64
+	Lines   []*RequestLine
+	Reason  Reason
+	Note    string
+	Restock bool
+}
+
+// RequestLine Quantity 0 refunds what is left of the line
+type RequestLine struct {
+	OrderItemID int64
+	Quantity    int
+}
+
+// Line an order line with its sub-order, locked while refunding
+type Line struct {
+	OrderItemID      int64
+	SubOrderID       int64
+	SellerID         int64
+	SubOrderStatus   string
+	ProductID        int64
+	WarehouseID      int64
+	Price            float64
+	Quantity         int
+	RefundedQuantity int
+}
+
+func (l *Line) Refundable() int {
+	return l.Quantity - l.RefundedQuantity
+}
+
+// Paid lines only, cancelled sub-orders were never charged or are already
+// back in stock
+func (l *Line) paid() bool {
+	switch l.SubOrderStatus {
+	case "paid", "shipped", "completed":
+		return true
+	}
+	return false
+}
+
+// Refundable some paid line of the order has units left to refund, the
+// order status does not matter as sub-orders are paid & shipped on their own
+func Refundable(lines []*Line) bool {
+	for _, l := range lines {
+		if l.paid() && l.Refundable() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// AllRefunded nothing charged is left, every line is refunded except the
+// ones of cancelled sub-orders
+func AllRefunded(lines []*Line) bool {
+	for _, l := range lines {
+		if l.SubOrderStatus != "cancelled" && l.Refundable() > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// AddShipping pays back the order's shipping with the refund
+func (r *Refund) AddShipping(amount float64) {
+	r.Shipping = amount
+	r.Amount = roundCents(r.Amount + amount)
+}
+
+// NewRefund the items of the request, checked against the order lines
+func NewRefund(req *Request, lines []*Line) (*Refund, error) {
+	byID := make(map[int64]*Line, len(lines))
+	for _, l := range lines {
+		byID[l.OrderItemID] = l
+	}
+
+	// Quantity per line, in request order
+	var ids []int64
+	requested := make(map[int64]int)
+	if len(req.Lines) == 0 {
+		for _, l := range lines {
+			if l.paid() && l.Refundable() > 0 {
+				ids = append(ids, l.OrderItemID)
+				requested[l.OrderItemID] = l.Refundable()
+			}
+		}
+	}
+	for _, rl := range req.Lines {
+		l, ok := byID[rl.OrderItemID]
+		if !ok {
+			return nil, errs.ErrRefundLineNotFound
+		}
+		if !l.paid() {
+			return nil, errs.ErrRefundNotAllowed
+		}
+		if _, ok := requested[l.OrderItemID]; !ok {
+			ids = append(ids, l.OrderItemID)
+		}
+		quantity := rl.Quantity
+		if quantity == 0 {
+			quantity = l.Refundable() - requested[l.OrderItemID]
+		}
+		requested[l.OrderItemID] += quantity
+	}
+
+	r := &Refund{
+		OrderID: req.OrderID,
+		Reason:  req.Reason,
+		Note:    req.Note,
+		Restock: req.Restock,
+		Status:  StatusPending,
+	}
+	for _, id := range ids {
+		l, quantity := byID[id], requested[id]
+		if quantity > l.Refundable() {
+			return nil, errs.ErrRefundQuantityExceeded
+		}
+		if quantity <= 0 {
+			continue
+		}
+		item := &Item{
+			OrderItemID: l.OrderItemID,
+			SubOrderID:  l.SubOrderID,
+			ProductID:   l.ProductID,
+			Quantity:    quantity,
+			Amount:      roundCents(l.Price * float64(quantity)),
+		}
+		r.Items = append(r.Items, item)
+		r.Amount += item.Amount
+	}
+	if len(r.Items) == 0 {
+		return nil, errs.ErrNothingToRefund
+	}
+	r.Amount = roundCents(r.Amount)
+	return r, nil
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,152 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: refund_repository.go
+
+// Package refundrepository is a generated GoMock package.
+package refundrepository
+
+import (
+	context "context"
+	sql "database/sql"
+	reflect "reflect"
+
+	refund "github.com/codepnw/mini-ecommerce/internal/refund"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockRefundRepository is a mock of RefundRepository interface.
+type MockRefundRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRefundRepositoryMockRecorder
+}
+
+// MockRefundRepositoryMockRecorder is the mock recorder for MockRefundRepository.
+type MockRefundRepositoryMockRecorder struct {
+	mock *MockRefundRepository
+}
+
+// NewMockRefundRepository creates a new mock instance.
+func NewMockRefundRepository(ctrl *gomock.Controller) *MockRefundRepository {
+	mock := &MockRefundRepository{ctrl: ctrl}
+	mock.recorder = &MockRefundRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRefundRepository) EXPECT() *MockRefundRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddRefundedQuantity mocks base method.
+func (m *MockRefundRepository) AddRefundedQuantity(ctx context.Context, tx *sql.Tx, orderItemID int64, quantity int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddRefundedQuantity", ctx, tx, orderItemID, quantity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddRefundedQuantity indicates an expected call of AddRefundedQuantity.
+func (mr *MockRefundRepositoryMockRecorder) AddRefundedQuantity(ctx, tx, orderItemID, quantity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRefundedQuantity", reflect.TypeOf((*MockRefundRepository)(nil).AddRefundedQuantity), ctx, tx, orderItemID, quantity)
+}
+
+// GetLinesForUpdate mocks base method.
+func (m *MockRefundRepository) GetLinesForUpdate(ctx context.Context, tx *sql.Tx, orderID int64) ([]*refund.Line, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLinesForUpdate", ctx, tx, orderID)
+	ret0, _ := ret[0].([]*refund.Line)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLinesForUpdate indicates an expected call of GetLinesForUpdate.
+func (mr *MockRefundRepositoryMockRecorder) GetLinesForUpdate(ctx, tx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLinesForUpdate", reflect.TypeOf((*MockRefundRepository)(nil).GetLinesForUpdate), ctx, tx, orderID)
+}
+
+// InsertRefund mocks base method.
+func (m *MockRefundRepository) InsertRefund(ctx context.Context, tx *sql.Tx, input *refund.Refund) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertRefund", ctx, tx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertRefund indicates an expected call of InsertRefund.
+func (mr *MockRefundRepositoryMockRecorder) InsertRefund(ctx, tx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertRefund", reflect.TypeOf((*MockRefundRepository)(nil).InsertRefund), ctx, tx, input)
+}
+
+// ListRefunds mocks base method.
+func (m *MockRefundRepository) ListRefunds(ctx context.Context, orderID int64) ([]*refund.Refund, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRefunds", ctx, orderID)
+	ret0, _ := ret[0].([]*refund.Refund)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRefunds indicates an expected call of ListRefunds.
+func (mr *MockRefundRepositoryMockRecorder) ListRefunds(ctx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRefunds", reflect.TypeOf((*MockRefundRepository)(nil).ListRefunds), ctx, orderID)
+}
+
+// MarkDeclined mocks base method.
+func (m *MockRefundRepository) MarkDeclined(ctx context.Context, refundID int64, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkDeclined", ctx, refundID, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkDeclined indicates an expected call of MarkDeclined.
+func (mr *MockRefundRepositoryMockRecorder) MarkDeclined(ctx, refundID, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkDeclined", reflect.TypeOf((*MockRefundRepository)(nil).MarkDeclined), ctx, refundID, reason)
+}
+
+// MarkFailed mocks base method.
+func (m *MockRefundRepository) MarkFailed(ctx context.Context, refundID int64, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkFailed", ctx, refundID, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkFailed indicates an expected call of MarkFailed.
+func (mr *MockRefundRepositoryMockRecorder) MarkFailed(ctx, refundID, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkFailed", reflect.TypeOf((*MockRefundRepository)(nil).MarkFailed), ctx, refundID, reason)
+}
+
+// MarkSettled mocks base method.
+func (m *MockRefundRepository) MarkSettled(ctx context.Context, refundID int64, ref string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkSettled", ctx, refundID, ref)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkSettled indicates an expected call of MarkSettled.
+func (mr *MockRefundRepositoryMockRecorder) MarkSettled(ctx, refundID, ref interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkSettled", reflect.TypeOf((*MockRefundRepository)(nil).MarkSettled), ctx, refundID, ref)
+}
+
+// PendingRefunds mocks base method.
+func (m *MockRefundRepository) PendingRefunds(ctx context.Context, limit, maxAttempts int) ([]*refund.Refund, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PendingRefunds", ctx, limit, maxAttempts)
+	ret0, _ := ret[0].([]*refund.Refund)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PendingRefunds indicates an expected call of PendingRefunds.
+func (mr *MockRefundRepositoryMockRecorder) PendingRefunds(ctx, limit, maxAttempts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PendingRefunds", reflect.TypeOf((*MockRefundRepository)(nil).PendingRefunds), ctx, limit, maxAttempts)
+}
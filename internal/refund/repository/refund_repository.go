@@ -0,0 +1,259 @@
+package refundrepository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/codepnw/mini-ecommerce/internal/refund"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/lib/pq"
+)
+
+//go:generate mockgen -source=refund_repository.go -destination=mock_refund_repository.go -package=refundrepository
+
+type RefundRepository interface {
+	ListRefunds(ctx context.Context, orderID int64) ([]*refund.Refund, error)
+	PendingRefunds(ctx context.Context, limit, maxAttempts int) ([]*refund.Refund, error)
+	MarkSettled(ctx context.Context, refundID int64, ref string) error
+	MarkDeclined(ctx context.Context, refundID int64, reason string) error
+	MarkFailed(ctx context.Context, refundID int64, reason string) error
+
+	// Transaction
+	GetLinesForUpdate(ctx context.Context, tx *sql.Tx, orderID int64) ([]*refund.Line, error)
+	InsertRefund(ctx context.Context, tx *sql.Tx, input *refund.Refund) error
+	AddRefundedQuantity(ctx context.Context, tx *sql.Tx, orderItemID int64, quantity int) error
+}
+
+type refundRepository struct {
+	db *sql.DB
+}
+
+func NewRefundRepository(db *sql.DB) RefundRepository {
+	return &refundRepository{db: db}
+}
+
+// ListRefunds of the order with their items, oldest first
+func (r *refundRepository) ListRefunds(ctx context.Context, orderID int64) ([]*refund.Refund, error) {
+	query := `
+		SELECT id, order_id, amount, shipping_amount, reason, note, restock, status, provider_ref, created_by, created_at
+		FROM refunds WHERE order_id = $1
+		ORDER BY id
+	`
+	rows, err := r.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refunds := make([]*refund.Refund, 0)
+	byID := make(map[int64]*refund.Refund)
+	var ids []int64
+	for rows.Next() {
+		var (
+			rf          = &refund.Refund{Items: make([]*refund.Item, 0)}
+			note        sql.NullString
+			providerRef sql.NullString
+			createdBy   sql.NullInt64
+		)
+		err := rows.Scan(
+			&rf.ID,
+			&rf.OrderID,
+			&rf.Amount,
+			&rf.Shipping,
+			&rf.Reason,
+			&note,
+			&rf.Restock,
+			&rf.Status,
+			&providerRef,
+			&createdBy,
+			&rf.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		rf.Note = note.String
+		rf.ProviderRef = providerRef.String
+		rf.CreatedBy = createdBy.Int64
+		refunds = append(refunds, rf)
+		byID[rf.ID] = rf
+		ids = append(ids, rf.ID)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return refunds, nil
+	}
+
+	itemQuery := `
+		SELECT ri.id, ri.refund_id, ri.order_item_id, oi.sub_order_id, oi.product_id, ri.quantity, ri.amount
+		FROM refund_items ri
+		INNER JOIN order_items oi ON oi.id = ri.order_item_id
+		WHERE ri.refund_id = ANY($1)
+		ORDER BY ri.id
+	`
+	itemRows, err := r.db.QueryContext(ctx, itemQuery, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var (
+			i          = new(refund.Item)
+			subOrderID sql.NullInt64
+		)
+		err := itemRows.Scan(&i.ID, &i.RefundID, &i.OrderItemID, &subOrderID, &i.ProductID, &i.Quantity, &i.Amount)
+		if err != nil {
+			return nil, err
+		}
+		i.SubOrderID = subOrderID.Int64
+		byID[i.RefundID].Items = append(byID[i.RefundID].Items, i)
+	}
+	return refunds, itemRows.Err()
+}
+
+// PendingRefunds not paid yet without their items, failed ones after the others
+func (r *refundRepository) PendingRefunds(ctx context.Context, limit, maxAttempts int) ([]*refund.Refund, error) {
+	query := `
+		SELECT id, order_id, amount, reason, status
+		FROM refunds
+		WHERE status = 'pending' AND attempts < $2
+		ORDER BY attempts, id
+		LIMIT $1
+	`
+	rows, err := r.db.QueryContext(ctx, query, limit, maxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refunds := make([]*refund.Refund, 0)
+	for rows.Next() {
+		rf := new(refund.Refund)
+		if err := rows.Scan(&rf.ID, &rf.OrderID, &rf.Amount, &rf.Reason, &rf.Status); err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, rf)
+	}
+	return refunds, rows.Err()
+}
+
+func (r *refundRepository) MarkSettled(ctx context.Context, refundID int64, ref string) error {
+	query := `UPDATE refunds SET status = 'settled', provider_ref = $2, last_error = NULL WHERE id = $1 AND status = 'pending'`
+	_, err := r.db.ExecContext(ctx, query, refundID, ref)
+	return err
+}
+
+func (r *refundRepository) MarkDeclined(ctx context.Context, refundID int64, reason string) error {
+	query := `UPDATE refunds SET status = 'declined', last_error = $2 WHERE id = $1 AND status = 'pending'`
+	_, err := r.db.ExecContext(ctx, query, refundID, reason)
+	return err
+}
+
+func (r *refundRepository) MarkFailed(ctx context.Context, refundID int64, reason string) error {
+	query := `UPDATE refunds SET attempts = attempts + 1, last_error = $2 WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, refundID, reason)
+	return err
+}
+
+// GetLinesForUpdate the order lines with their sub-order, the lines stay
+// locked until the refund commits
+func (r *refundRepository) GetLinesForUpdate(ctx context.Context, tx *sql.Tx, orderID int64) ([]*refund.Line, error) {
+	query := `
+		SELECT oi.id, s.id, s.seller_id, s.status, oi.product_id, oi.warehouse_id, oi.price, oi.quantity, oi.refunded_quantity
+		FROM order_items oi
+		INNER JOIN sub_orders s ON s.id = oi.sub_order_id
+		WHERE oi.order_id = $1
+		ORDER BY oi.id
+		FOR UPDATE OF oi
+	`
+	rows, err := tx.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := make([]*refund.Line, 0)
+	for rows.Next() {
+		var (
+			l           = new(refund.Line)
+			warehouseID sql.NullInt64
+		)
+		err := rows.Scan(
+			&l.OrderItemID,
+			&l.SubOrderID,
+			&l.SellerID,
+			&l.SubOrderStatus,
+			&l.ProductID,
+			&warehouseID,
+			&l.Price,
+			&l.Quantity,
+			&l.RefundedQuantity,
+		)
+		if err != nil {
+			return nil, err
+		}
+		l.WarehouseID = warehouseID.Int64
+		lines = append(lines, l)
+	}
+	return lines, rows.Err()
+}
+
+func (r *refundRepository) InsertRefund(ctx context.Context, tx *sql.Tx, input *refund.Refund) error {
+	query := `
+		INSERT INTO refunds (order_id, amount, shipping_amount, reason, note, restock, status, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		input.OrderID,
+		input.Amount,
+		input.Shipping,
+		input.Reason,
+		sql.NullString{String: input.Note, Valid: input.Note != ""},
+		input.Restock,
+		input.Status,
+		sql.NullInt64{Int64: input.CreatedBy, Valid: input.CreatedBy > 0},
+	).Scan(&input.ID, &input.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	for _, i := range input.Items {
+		i.RefundID = input.ID
+		err := tx.QueryRowContext(
+			ctx,
+			`INSERT INTO refund_items (refund_id, order_item_id, quantity, amount) VALUES ($1, $2, $3, $4) RETURNING id`,
+			i.RefundID,
+			i.OrderItemID,
+			i.Quantity,
+			i.Amount,
+		).Scan(&i.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddRefundedQuantity the check constraint keeps it within the quantity
+func (r *refundRepository) AddRefundedQuantity(ctx context.Context, tx *sql.Tx, orderItemID int64, quantity int) error {
+	query := `UPDATE order_items SET refunded_quantity = refunded_quantity + $1 WHERE id = $2`
+	res, err := tx.ExecContext(ctx, query, quantity, orderItemID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errs.ErrRefundLineNotFound
+	}
+	return nil
+}
@@ -0,0 +1,353 @@
+package refundusecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/codepnw/mini-ecommerce/internal/inventory"
+	inventoryrepository "github.com/codepnw/mini-ecommerce/internal/inventory/repository"
+	"github.com/codepnw/mini-ecommerce/internal/order"
+	orderrepository "github.com/codepnw/mini-ecommerce/internal/order/repository"
+	"github.com/codepnw/mini-ecommerce/internal/payout"
+	payoutrepository "github.com/codepnw/mini-ecommerce/internal/payout/repository"
+	productrepository "github.com/codepnw/mini-ecommerce/internal/product/repository"
+	"github.com/codepnw/mini-ecommerce/internal/refund"
+	refundrepository "github.com/codepnw/mini-ecommerce/internal/refund/repository"
+	"github.com/codepnw/mini-ecommerce/internal/user"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/pkg/auth"
+	"github.com/codepnw/mini-ecommerce/pkg/database"
+	"github.com/codepnw/mini-ecommerce/pkg/payment"
+	"github.com/codepnw/mini-ecommerce/pkg/validate"
+)
+
+type RefundUsecase interface {
+	CreateRefund(ctx context.Context, input *refund.Request) (*refund.Refund, error)
+	ListRefunds(ctx context.Context, orderID int64) ([]*refund.Refund, error)
+
+	// CreateRefundTx records a pending refund in the caller's transaction,
+	// the caller checks the permissions and calls PayRefund once committed
+	CreateRefundTx(ctx context.Context, tx *sql.Tx, input *refund.Request, actorID int64) (*refund.Refund, error)
+	PayRefund(ctx context.Context, r *refund.Refund) (*refund.Refund, error)
+
+	// Background Jobs
+	PayPendingRefunds(ctx context.Context) (int, error)
+}
+
+type RefundUsecaseConfig struct {
+	Repo          refundrepository.RefundRepository       `validate:"required"`
+	OrderRepo     orderrepository.OrderRepository         `validate:"required"`
+	ProductRepo   productrepository.ProductRepository     `validate:"required"`
+	InventoryRepo inventoryrepository.InventoryRepository `validate:"required"`
+	PayoutRepo    payoutrepository.PayoutRepository       `validate:"required"`
+	Provider      payment.Provider                        `validate:"required"`
+	Tx            database.TxManager                      `validate:"required"`
+}
+
+type refundUsecase struct {
+	repo          refundrepository.RefundRepository
+	orderRepo     orderrepository.OrderRepository
+	productRepo   productrepository.ProductRepository
+	inventoryRepo inventoryrepository.InventoryRepository
+	payoutRepo    payoutrepository.PayoutRepository
+	provider      payment.Provider
+	tx            database.TxManager
+}
+
+func NewRefundUsecase(cfg *RefundUsecaseConfig) (RefundUsecase, error) {
+	if err := validate.Struct(cfg); err != nil {
+		return nil, err
+	}
+	return &refundUsecase{
+		repo:          cfg.Repo,
+		orderRepo:     cfg.OrderRepo,
+		productRepo:   cfg.ProductRepo,
+		inventoryRepo: cfg.InventoryRepo,
+		payoutRepo:    cfg.PayoutRepo,
+		provider:      cfg.Provider,
+		tx:            cfg.Tx,
+	}, nil
+}
+
+// CreateRefund pays back lines of a paid order. The refund is committed
+// with the stock, statuses & ledger before the provider is called.
+func (u *refundUsecase) CreateRefund(ctx context.Context, input *refund.Request) (*refund.Refund, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	admin, err := auth.GetCurrentUser(ctx)
+	if err != nil || admin.Role != string(user.RoleAdmin) {
+		return nil, errs.ErrNoPermissions
+	}
+
+	var result *refund.Refund
+
+	err = u.tx.WithTransaction(ctx, func(tx *sql.Tx) error {
+		result, err = u.CreateRefundTx(ctx, tx, input, admin.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u.PayRefund(ctx, result)
+}
+
+func (u *refundUsecase) CreateRefundTx(ctx context.Context, tx *sql.Tx, input *refund.Request, actorID int64) (*refund.Refund, error) {
+	// Lock Order
+	if _, err := u.orderRepo.GetStatusForUpdate(ctx, tx, input.OrderID); err != nil {
+		return nil, err
+	}
+
+	lines, err := u.repo.GetLinesForUpdate(ctx, tx, input.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if !refund.Refundable(lines) {
+		return nil, errs.ErrRefundNotAllowed
+	}
+	r, err := refund.NewRefund(input, lines)
+	if err != nil {
+		return nil, err
+	}
+	r.CreatedBy = actorID
+
+	byID := make(map[int64]*refund.Line, len(lines))
+	for _, l := range lines {
+		byID[l.OrderItemID] = l
+	}
+	for _, i := range r.Items {
+		if err := u.repo.AddRefundedQuantity(ctx, tx, i.OrderItemID, i.Quantity); err != nil {
+			return nil, err
+		}
+		byID[i.OrderItemID].RefundedQuantity += i.Quantity
+	}
+
+	// Shipping goes back with the last line
+	if refund.AllRefunded(lines) {
+		orderData, err := u.orderRepo.GetOrder(ctx, input.OrderID)
+		if err != nil {
+			return nil, err
+		}
+		r.AddShipping(orderData.ShippingAmount)
+	}
+	if err := u.repo.InsertRefund(ctx, tx, r); err != nil {
+		return nil, err
+	}
+
+	if r.Restock {
+		if err := u.restock(ctx, tx, r, byID, actorID); err != nil {
+			return nil, err
+		}
+	}
+	if err := u.updateStatus(ctx, tx, input.OrderID, lines); err != nil {
+		return nil, err
+	}
+	if err := u.postLedger(ctx, tx, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// PayRefund sends a committed refund to the provider. A refund the provider
+// could not be reached for is returned pending, the refund job retries it.
+func (u *refundUsecase) PayRefund(ctx context.Context, r *refund.Refund) (*refund.Refund, error) {
+	err := u.pay(ctx, r)
+	if errors.Is(err, errs.ErrRefundDeclined) {
+		return nil, err
+	}
+	if err != nil {
+		log.Printf("refund: #%d stays pending: %v", r.ID, err)
+	}
+	return r, nil
+}
+
+// PayPendingRefunds run by the refund job, returns the number paid. A
+// failed refund does not stop the others.
+func (u *refundUsecase) PayPendingRefunds(ctx context.Context) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout*3)
+	defer cancel()
+
+	refunds, err := u.repo.PendingRefunds(ctx, consts.RefundBatch, consts.RefundMaxAttempts)
+	if err != nil {
+		return 0, err
+	}
+
+	paid := 0
+	var failed []error
+	for _, r := range refunds {
+		if err := u.pay(ctx, r); err != nil {
+			if ctx.Err() != nil {
+				return paid, ctx.Err()
+			}
+			failed = append(failed, fmt.Errorf("refund #%d: %w", r.ID, err))
+			continue
+		}
+		paid++
+	}
+	return paid, errors.Join(failed...)
+}
+
+// pay the refund ID is the idempotency key, a refund paid before its status
+// was saved is not paid again on retry
+func (u *refundUsecase) pay(ctx context.Context, r *refund.Refund) error {
+	res, err := u.provider.Refund(ctx, &payment.RefundRequest{
+		OrderID:  r.OrderID,
+		RefundID: r.ID,
+		Amount:   r.Amount,
+		Reason:   string(r.Reason),
+	})
+	if errors.Is(err, payment.ErrDeclined) {
+		if err := u.repo.MarkDeclined(ctx, r.ID, err.Error()); err != nil {
+			return err
+		}
+		r.Status = refund.StatusDeclined
+		return errs.ErrRefundDeclined
+	}
+	if err != nil {
+		if err := u.repo.MarkFailed(ctx, r.ID, err.Error()); err != nil {
+			return err
+		}
+		return err
+	}
+
+	if err := u.repo.MarkSettled(ctx, r.ID, res.Reference); err != nil {
+		return err
+	}
+	r.Status = refund.StatusSettled
+	r.ProviderRef = res.Reference
+	return nil
+}
+
+// ListRefunds for admins & the customer of the order
+func (u *refundUsecase) ListRefunds(ctx context.Context, orderID int64) ([]*refund.Refund, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	currentUser, err := auth.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, errs.ErrUnauthorized
+	}
+	if currentUser.Role != string(user.RoleAdmin) {
+		orderData, err := u.orderRepo.GetOrder(ctx, orderID)
+		if err != nil {
+			return nil, err
+		}
+		if orderData.UserID != currentUser.ID {
+			return nil, errs.ErrNoPermissions
+		}
+	}
+	return u.repo.ListRefunds(ctx, orderID)
+}
+
+// restock puts the refunded units back in the warehouse they were picked from
+func (u *refundUsecase) restock(ctx context.Context, tx *sql.Tx, r *refund.Refund, lines map[int64]*refund.Line, actorID int64) error {
+	for _, i := range r.Items {
+		warehouseID := lines[i.OrderItemID].WarehouseID
+		if warehouseID == 0 {
+			w, err := u.inventoryRepo.DefaultWarehouse(ctx, tx)
+			if err != nil {
+				return err
+			}
+			warehouseID = w.ID
+		}
+		if err := u.inventoryRepo.AdjustWarehouseStock(ctx, tx, warehouseID, i.ProductID, i.Quantity); err != nil {
+			return err
+		}
+		if err := u.productRepo.IncreaseStock(ctx, tx, i.ProductID, i.Quantity); err != nil {
+			return err
+		}
+
+		movement := &inventory.StockMovement{
+			ProductID:   i.ProductID,
+			Delta:       i.Quantity,
+			Reason:      inventory.ReasonReturn,
+			RefType:     inventory.RefRefund,
+			RefID:       r.ID,
+			ActorID:     actorID,
+			WarehouseID: warehouseID,
+		}
+		if err := u.inventoryRepo.InsertMovement(ctx, tx, movement); err != nil {
+			return err
+		}
+		// Increase only, the threshold is not needed
+		if err := inventory.RecordStockEvents(ctx, tx, u.inventoryRepo, movement, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateStatus sub-orders with every line refunded are refunded. The order
+// status is derived again, its refund status is full once nothing is left
+// active.
+func (u *refundUsecase) updateStatus(ctx context.Context, tx *sql.Tx, orderID int64, lines []*refund.Line) error {
+	open := make(map[int64]bool)
+	for _, l := range lines {
+		if l.Refundable() > 0 {
+			open[l.SubOrderID] = true
+		}
+	}
+
+	subs, err := u.orderRepo.ListSubOrders(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+	for _, s := range subs {
+		if open[s.ID] || s.Status == string(order.StatusCancelled) || s.Status == string(order.StatusRefunded) {
+			continue
+		}
+		if err := u.orderRepo.UpdateSubOrderStatus(ctx, tx, s.ID, string(order.StatusRefunded)); err != nil {
+			return err
+		}
+		s.Status = string(order.StatusRefunded)
+	}
+
+	status := order.DeriveStatus(subs)
+	if err := u.orderRepo.UpdateStatus(ctx, tx, orderID, string(status)); err != nil {
+		return err
+	}
+
+	refundStatus := order.RefundPartial
+	if status == order.StatusRefunded {
+		refundStatus = order.RefundFull
+	}
+	return u.orderRepo.UpdateRefundStatus(ctx, tx, orderID, string(refundStatus))
+}
+
+// postLedger takes the refund out of each seller balance whose earning is
+// posted, a later earning leaves out the refunded lines instead
+func (u *refundUsecase) postLedger(ctx context.Context, tx *sql.Tx, r *refund.Refund) error {
+	var subOrderIDs []int64
+	amounts := make(map[int64]float64)
+	for _, i := range r.Items {
+		if _, ok := amounts[i.SubOrderID]; !ok {
+			subOrderIDs = append(subOrderIDs, i.SubOrderID)
+		}
+		amounts[i.SubOrderID] += i.Amount
+	}
+
+	for _, subOrderID := range subOrderIDs {
+		earning, err := u.payoutRepo.EarningOf(ctx, tx, subOrderID)
+		if err != nil {
+			return err
+		}
+		if earning == nil {
+			continue
+		}
+
+		amount := amounts[subOrderID]
+		t := payout.NewRefund(earning.SellerID, r.ID, amount, payout.RefundFee(amount, earning.Gross, earning.Fee))
+		t.Memo = fmt.Sprintf("order #%d", r.OrderID)
+		if !t.Balanced() {
+			return fmt.Errorf("ledger: %s %s #%d is not balanced", t.Kind, t.RefType, t.RefID)
+		}
+		if _, err := u.payoutRepo.InsertTransaction(ctx, tx, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
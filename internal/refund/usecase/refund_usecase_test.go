@@ -0,0 +1,504 @@
+package refundusecase_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/codepnw/mini-ecommerce/internal/inventory"
+	inventoryrepository "github.com/codepnw/mini-ecommerce/internal/inventory/repository"
+	"github.com/codepnw/mini-ecommerce/internal/order"
+	orderrepository "github.com/codepnw/mini-ecommerce/internal/order/repository"
+	"github.com/codepnw/mini-ecommerce/internal/payout"
+	payoutrepository "github.com/codepnw/mini-ecommerce/internal/payout/repository"
+	productrepository "github.com/codepnw/mini-ecommerce/internal/product/repository"
+	"github.com/codepnw/mini-ecommerce/internal/refund"
+	refundrepository "github.com/codepnw/mini-ecommerce/internal/refund/repository"
+	refundusecase "github.com/codepnw/mini-ecommerce/internal/refund/usecase"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/pkg/auth"
+	"github.com/codepnw/mini-ecommerce/pkg/jwt"
+	"github.com/codepnw/mini-ecommerce/pkg/payment"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+const orderID = 100
+
+func TestCreateRefund(t *testing.T) {
+	type testCase struct {
+		name        string
+		ctx         context.Context
+		input       *refund.Request
+		providerErr error
+		mockFn      func(m *mocks)
+		expected    *refund.Refund
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name:  "success full refund",
+			ctx:   mockAdmin(),
+			input: &refund.Request{OrderID: orderID, Reason: refund.ReasonCustomerRequest},
+			mockFn: func(m *mocks) {
+				m.order.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(orderID)).Return(order.StatusPaid, nil).Times(1)
+				m.refund.EXPECT().GetLinesForUpdate(gomock.Any(), gomock.Any(), int64(orderID)).Return(mockLines("paid", "paid"), nil).Times(1)
+				m.refund.EXPECT().AddRefundedQuantity(gomock.Any(), gomock.Any(), int64(1), 1).Return(nil).Times(1)
+				m.refund.EXPECT().AddRefundedQuantity(gomock.Any(), gomock.Any(), int64(2), 2).Return(nil).Times(1)
+
+				// Nothing left, the shipping goes back too
+				m.order.EXPECT().GetOrder(gomock.Any(), int64(orderID)).Return(&order.Order{ID: orderID, ShippingAmount: 50}, nil).Times(1)
+				m.refund.EXPECT().InsertRefund(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(insertRefund).Times(1)
+
+				m.order.EXPECT().ListSubOrders(gomock.Any(), gomock.Any(), int64(orderID)).Return(mockSubOrders("paid", "paid"), nil).Times(1)
+				m.order.EXPECT().UpdateSubOrderStatus(gomock.Any(), gomock.Any(), gomock.Any(), string(order.StatusRefunded)).Return(nil).Times(2)
+				m.order.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), int64(orderID), string(order.StatusRefunded)).Return(nil).Times(1)
+				m.order.EXPECT().UpdateRefundStatus(gomock.Any(), gomock.Any(), int64(orderID), string(order.RefundFull)).Return(nil).Times(1)
+
+				// Earnings are posted on completed sub-orders only
+				m.payout.EXPECT().EarningOf(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(2)
+				m.refund.EXPECT().MarkSettled(gomock.Any(), int64(7), "fake_re_7").Return(nil).Times(1)
+			},
+			expected: &refund.Refund{ID: 7, OrderID: orderID, Amount: 1550, Shipping: 50, Status: refund.StatusSettled, ProviderRef: "fake_re_7"},
+		},
+		{
+			name: "success partial refund with restock",
+			ctx:  mockAdmin(),
+			input: &refund.Request{
+				OrderID: orderID,
+				Lines:   []*refund.RequestLine{{OrderItemID: 2, Quantity: 1}},
+				Reason:  refund.ReasonDamaged,
+				Restock: true,
+			},
+			mockFn: func(m *mocks) {
+				m.order.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(orderID)).Return(order.StatusCompleted, nil).Times(1)
+				m.refund.EXPECT().GetLinesForUpdate(gomock.Any(), gomock.Any(), int64(orderID)).Return(mockLines("completed", "completed"), nil).Times(1)
+				m.refund.EXPECT().InsertRefund(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(insertRefund).Times(1)
+				m.refund.EXPECT().AddRefundedQuantity(gomock.Any(), gomock.Any(), int64(2), 1).Return(nil).Times(1)
+
+				// Back to the warehouse it was picked from
+				m.inventory.EXPECT().AdjustWarehouseStock(gomock.Any(), gomock.Any(), int64(3), int64(20), 1).Return(nil).Times(1)
+				m.product.EXPECT().IncreaseStock(gomock.Any(), gomock.Any(), int64(20), 1).Return(nil).Times(1)
+				m.inventory.EXPECT().InsertMovement(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ *sql.Tx, mv *inventory.StockMovement) error {
+						assert.Equal(t, inventory.ReasonReturn, mv.Reason)
+						assert.Equal(t, inventory.RefRefund, mv.RefType)
+						assert.Equal(t, int64(7), mv.RefID)
+						return nil
+					},
+				).Times(1)
+				m.inventory.EXPECT().InsertEvent(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+				// Line 2 still has a unit, nothing is fully refunded
+				m.order.EXPECT().ListSubOrders(gomock.Any(), gomock.Any(), int64(orderID)).Return(mockSubOrders("completed", "completed"), nil).Times(1)
+				m.order.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), int64(orderID), string(order.StatusCompleted)).Return(nil).Times(1)
+				m.order.EXPECT().UpdateRefundStatus(gomock.Any(), gomock.Any(), int64(orderID), string(order.RefundPartial)).Return(nil).Times(1)
+
+				m.payout.EXPECT().EarningOf(gomock.Any(), gomock.Any(), int64(11)).Return(
+					&payout.Earning{SubOrderID: 11, SellerID: 30, Gross: 1000, Fee: 100}, nil,
+				).Times(1)
+				m.payout.EXPECT().InsertTransaction(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ *sql.Tx, txn *payout.Transaction) (bool, error) {
+						assert.Equal(t, payout.KindRefund, txn.Kind)
+						assert.Equal(t, int64(30), txn.SellerID)
+						assert.Equal(t, "order #100", txn.Memo)
+						// 500 of the 1000 gross gives back half the fee
+						assert.Equal(t, 500.0, txn.Entries[0].Debit)
+						assert.Equal(t, 50.0, txn.Entries[2].Debit)
+						return true, nil
+					},
+				).Times(1)
+				m.refund.EXPECT().MarkSettled(gomock.Any(), int64(7), "fake_re_7").Return(nil).Times(1)
+			},
+			expected: &refund.Refund{ID: 7, OrderID: orderID, Amount: 500, Status: refund.StatusSettled, ProviderRef: "fake_re_7"},
+		},
+		{
+			name:        "success provider unreachable stays pending",
+			ctx:         mockAdmin(),
+			input:       &refund.Request{OrderID: orderID, Lines: []*refund.RequestLine{{OrderItemID: 1}}, Reason: refund.ReasonOther},
+			providerErr: errors.New("connection reset"),
+			mockFn: func(m *mocks) {
+				m.order.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(orderID)).Return(order.StatusPaid, nil).Times(1)
+				m.refund.EXPECT().GetLinesForUpdate(gomock.Any(), gomock.Any(), int64(orderID)).Return(mockLines("paid", "paid"), nil).Times(1)
+				m.refund.EXPECT().InsertRefund(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(insertRefund).Times(1)
+				m.refund.EXPECT().AddRefundedQuantity(gomock.Any(), gomock.Any(), int64(1), 1).Return(nil).Times(1)
+				m.order.EXPECT().ListSubOrders(gomock.Any(), gomock.Any(), int64(orderID)).Return(mockSubOrders("paid", "paid"), nil).Times(1)
+				m.order.EXPECT().UpdateSubOrderStatus(gomock.Any(), gomock.Any(), int64(10), string(order.StatusRefunded)).Return(nil).Times(1)
+				m.order.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), int64(orderID), string(order.StatusPaid)).Return(nil).Times(1)
+				m.order.EXPECT().UpdateRefundStatus(gomock.Any(), gomock.Any(), int64(orderID), string(order.RefundPartial)).Return(nil).Times(1)
+				m.payout.EXPECT().EarningOf(gomock.Any(), gomock.Any(), int64(10)).Return(nil, nil).Times(1)
+
+				// Retried by the refund job with the same refund ID
+				m.refund.EXPECT().MarkFailed(gomock.Any(), int64(7), "connection reset").Return(nil).Times(1)
+				m.refund.EXPECT().MarkSettled(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expected: &refund.Refund{ID: 7, OrderID: orderID, Amount: 500, Status: refund.StatusPending},
+		},
+		{
+			name: "success last line pays back the shipping",
+			ctx:  mockAdmin(),
+			input: &refund.Request{
+				OrderID: orderID,
+				Lines:   []*refund.RequestLine{{OrderItemID: 2}},
+				Reason:  refund.ReasonNotReceived,
+			},
+			mockFn: func(m *mocks) {
+				// Line 1 was refunded before
+				lines := mockLines("refunded", "shipped")
+				lines[0].RefundedQuantity = 1
+				m.order.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(orderID)).Return(order.StatusShipped, nil).Times(1)
+				m.refund.EXPECT().GetLinesForUpdate(gomock.Any(), gomock.Any(), int64(orderID)).Return(lines, nil).Times(1)
+				m.refund.EXPECT().AddRefundedQuantity(gomock.Any(), gomock.Any(), int64(2), 2).Return(nil).Times(1)
+				m.order.EXPECT().GetOrder(gomock.Any(), int64(orderID)).Return(&order.Order{ID: orderID, ShippingAmount: 50}, nil).Times(1)
+				m.refund.EXPECT().InsertRefund(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ *sql.Tx, r *refund.Refund) error {
+						assert.Equal(t, 50.0, r.Shipping)
+						assert.Equal(t, 1050.0, r.Amount)
+						return insertRefund(nil, nil, r)
+					},
+				).Times(1)
+
+				m.order.EXPECT().ListSubOrders(gomock.Any(), gomock.Any(), int64(orderID)).Return(mockSubOrders("refunded", "shipped"), nil).Times(1)
+				m.order.EXPECT().UpdateSubOrderStatus(gomock.Any(), gomock.Any(), int64(11), string(order.StatusRefunded)).Return(nil).Times(1)
+				m.order.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), int64(orderID), string(order.StatusRefunded)).Return(nil).Times(1)
+				m.order.EXPECT().UpdateRefundStatus(gomock.Any(), gomock.Any(), int64(orderID), string(order.RefundFull)).Return(nil).Times(1)
+				m.payout.EXPECT().EarningOf(gomock.Any(), gomock.Any(), int64(11)).Return(nil, nil).Times(1)
+				m.refund.EXPECT().MarkSettled(gomock.Any(), int64(7), "fake_re_7").Return(nil).Times(1)
+			},
+			expected: &refund.Refund{ID: 7, OrderID: orderID, Amount: 1050, Shipping: 50, Status: refund.StatusSettled, ProviderRef: "fake_re_7"},
+		},
+		{
+			name:  "success paid sub-order of a pending order",
+			ctx:   mockAdmin(),
+			input: &refund.Request{OrderID: orderID, Reason: refund.ReasonCustomerRequest},
+			mockFn: func(m *mocks) {
+				m.order.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(orderID)).Return(order.StatusPending, nil).Times(1)
+				m.refund.EXPECT().GetLinesForUpdate(gomock.Any(), gomock.Any(), int64(orderID)).Return(mockLines("paid", "pending"), nil).Times(1)
+				m.refund.EXPECT().InsertRefund(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(insertRefund).Times(1)
+
+				// Only the paid line is refunded
+				m.refund.EXPECT().AddRefundedQuantity(gomock.Any(), gomock.Any(), int64(1), 1).Return(nil).Times(1)
+				m.order.EXPECT().ListSubOrders(gomock.Any(), gomock.Any(), int64(orderID)).Return(mockSubOrders("paid", "pending"), nil).Times(1)
+				m.order.EXPECT().UpdateSubOrderStatus(gomock.Any(), gomock.Any(), int64(10), string(order.StatusRefunded)).Return(nil).Times(1)
+				m.order.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), int64(orderID), string(order.StatusPending)).Return(nil).Times(1)
+				m.order.EXPECT().UpdateRefundStatus(gomock.Any(), gomock.Any(), int64(orderID), string(order.RefundPartial)).Return(nil).Times(1)
+				m.payout.EXPECT().EarningOf(gomock.Any(), gomock.Any(), int64(10)).Return(nil, nil).Times(1)
+				m.refund.EXPECT().MarkSettled(gomock.Any(), int64(7), "fake_re_7").Return(nil).Times(1)
+			},
+			expected: &refund.Refund{ID: 7, OrderID: orderID, Amount: 500, Status: refund.StatusSettled, ProviderRef: "fake_re_7"},
+		},
+		{
+			name: "fail quantity exceeded",
+			ctx:  mockAdmin(),
+			input: &refund.Request{
+				OrderID: orderID,
+				Lines:   []*refund.RequestLine{{OrderItemID: 1, Quantity: 2}},
+				Reason:  refund.ReasonOther,
+			},
+			mockFn: func(m *mocks) {
+				m.order.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(orderID)).Return(order.StatusPaid, nil).Times(1)
+				m.refund.EXPECT().GetLinesForUpdate(gomock.Any(), gomock.Any(), int64(orderID)).Return(mockLines("paid", "paid"), nil).Times(1)
+			},
+			expectedErr: errs.ErrRefundQuantityExceeded,
+		},
+		{
+			name: "fail line not in order",
+			ctx:  mockAdmin(),
+			input: &refund.Request{
+				OrderID: orderID,
+				Lines:   []*refund.RequestLine{{OrderItemID: 99}},
+				Reason:  refund.ReasonOther,
+			},
+			mockFn: func(m *mocks) {
+				m.order.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(orderID)).Return(order.StatusPaid, nil).Times(1)
+				m.refund.EXPECT().GetLinesForUpdate(gomock.Any(), gomock.Any(), int64(orderID)).Return(mockLines("paid", "paid"), nil).Times(1)
+			},
+			expectedErr: errs.ErrRefundLineNotFound,
+		},
+		{
+			name: "fail line of a cancelled sub-order",
+			ctx:  mockAdmin(),
+			input: &refund.Request{
+				OrderID: orderID,
+				Lines:   []*refund.RequestLine{{OrderItemID: 2}},
+				Reason:  refund.ReasonOther,
+			},
+			mockFn: func(m *mocks) {
+				m.order.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(orderID)).Return(order.StatusPaid, nil).Times(1)
+				m.refund.EXPECT().GetLinesForUpdate(gomock.Any(), gomock.Any(), int64(orderID)).Return(mockLines("paid", "cancelled"), nil).Times(1)
+			},
+			expectedErr: errs.ErrRefundNotAllowed,
+		},
+		{
+			name:  "fail order not paid",
+			ctx:   mockAdmin(),
+			input: &refund.Request{OrderID: orderID, Reason: refund.ReasonOther},
+			mockFn: func(m *mocks) {
+				m.order.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(orderID)).Return(order.StatusPending, nil).Times(1)
+				m.refund.EXPECT().GetLinesForUpdate(gomock.Any(), gomock.Any(), int64(orderID)).Return(mockLines("pending", "pending"), nil).Times(1)
+				m.refund.EXPECT().InsertRefund(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedErr: errs.ErrRefundNotAllowed,
+		},
+		{
+			name:  "fail order not found",
+			ctx:   mockAdmin(),
+			input: &refund.Request{OrderID: orderID, Reason: refund.ReasonOther},
+			mockFn: func(m *mocks) {
+				m.order.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(orderID)).Return(order.OrderStatus(""), errs.ErrOrderNotFound).Times(1)
+			},
+			expectedErr: errs.ErrOrderNotFound,
+		},
+		{
+			name:        "fail provider declined",
+			ctx:         mockAdmin(),
+			input:       &refund.Request{OrderID: orderID, Lines: []*refund.RequestLine{{OrderItemID: 1}}, Reason: refund.ReasonOther},
+			providerErr: payment.ErrDeclined,
+			mockFn: func(m *mocks) {
+				m.order.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(orderID)).Return(order.StatusPaid, nil).Times(1)
+				m.refund.EXPECT().GetLinesForUpdate(gomock.Any(), gomock.Any(), int64(orderID)).Return(mockLines("paid", "paid"), nil).Times(1)
+				m.refund.EXPECT().InsertRefund(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(insertRefund).Times(1)
+				m.refund.EXPECT().AddRefundedQuantity(gomock.Any(), gomock.Any(), int64(1), 1).Return(nil).Times(1)
+				m.order.EXPECT().ListSubOrders(gomock.Any(), gomock.Any(), int64(orderID)).Return(mockSubOrders("paid", "paid"), nil).Times(1)
+				m.order.EXPECT().UpdateSubOrderStatus(gomock.Any(), gomock.Any(), int64(10), string(order.StatusRefunded)).Return(nil).Times(1)
+				m.order.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), int64(orderID), string(order.StatusPaid)).Return(nil).Times(1)
+				m.order.EXPECT().UpdateRefundStatus(gomock.Any(), gomock.Any(), int64(orderID), string(order.RefundPartial)).Return(nil).Times(1)
+				m.payout.EXPECT().EarningOf(gomock.Any(), gomock.Any(), int64(10)).Return(nil, nil).Times(1)
+
+				// Committed before the provider is called, kept as declined
+				m.refund.EXPECT().MarkDeclined(gomock.Any(), int64(7), payment.ErrDeclined.Error()).Return(nil).Times(1)
+			},
+			expectedErr: errs.ErrRefundDeclined,
+		},
+		{
+			name:        "fail not admin",
+			ctx:         auth.SetCurrentUser(context.Background(), &jwt.UserClaims{ID: 2, Role: "user"}),
+			input:       &refund.Request{OrderID: orderID, Reason: refund.ReasonOther},
+			mockFn:      func(m *mocks) {},
+			expectedErr: errs.ErrNoPermissions,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, m := setup(t)
+			m.provider.Err = tc.providerErr
+
+			tc.mockFn(m)
+
+			result, err := uc.CreateRefund(tc.ctx, tc.input)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+				assert.Empty(t, m.provider.Refunds())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected.ID, result.ID)
+			assert.Equal(t, tc.expected.Amount, result.Amount)
+			assert.Equal(t, tc.expected.Shipping, result.Shipping)
+			assert.Equal(t, tc.expected.Status, result.Status)
+			assert.Equal(t, tc.expected.ProviderRef, result.ProviderRef)
+			assert.Equal(t, int64(1), result.CreatedBy)
+			if tc.providerErr == nil {
+				assert.Len(t, m.provider.Refunds(), 1)
+			}
+		})
+	}
+}
+
+func TestPayPendingRefunds(t *testing.T) {
+	type testCase struct {
+		name        string
+		providerErr error
+		mockFn      func(m *mocks)
+		expected    int
+		expectedErr error
+	}
+
+	pending := func() []*refund.Refund {
+		return []*refund.Refund{
+			{ID: 7, OrderID: orderID, Amount: 500, Reason: refund.ReasonDamaged, Status: refund.StatusPending},
+			{ID: 8, OrderID: orderID, Amount: 1000, Reason: refund.ReasonOther, Status: refund.StatusPending},
+		}
+	}
+
+	testCases := []testCase{
+		{
+			name: "success",
+			mockFn: func(m *mocks) {
+				m.refund.EXPECT().PendingRefunds(gomock.Any(), gomock.Any(), gomock.Any()).Return(pending(), nil).Times(1)
+				m.refund.EXPECT().MarkSettled(gomock.Any(), int64(7), "fake_re_7").Return(nil).Times(1)
+				m.refund.EXPECT().MarkSettled(gomock.Any(), int64(8), "fake_re_8").Return(nil).Times(1)
+			},
+			expected: 2,
+		},
+		{
+			name:        "fail provider unreachable keeps them pending",
+			providerErr: errors.New("connection reset"),
+			mockFn: func(m *mocks) {
+				m.refund.EXPECT().PendingRefunds(gomock.Any(), gomock.Any(), gomock.Any()).Return(pending(), nil).Times(1)
+				m.refund.EXPECT().MarkFailed(gomock.Any(), int64(7), "connection reset").Return(nil).Times(1)
+				m.refund.EXPECT().MarkFailed(gomock.Any(), int64(8), "connection reset").Return(nil).Times(1)
+			},
+			expectedErr: errors.New("connection reset"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, m := setup(t)
+			m.provider.Err = tc.providerErr
+
+			tc.mockFn(m)
+
+			paid, err := uc.PayPendingRefunds(context.Background())
+
+			if tc.expectedErr != nil {
+				assert.ErrorContains(t, err, tc.expectedErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expected, paid)
+			assert.Len(t, m.provider.Refunds(), tc.expected)
+		})
+	}
+}
+
+func TestListRefunds(t *testing.T) {
+	type testCase struct {
+		name        string
+		ctx         context.Context
+		mockFn      func(m *mocks)
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name: "success admin",
+			ctx:  mockAdmin(),
+			mockFn: func(m *mocks) {
+				m.refund.EXPECT().ListRefunds(gomock.Any(), int64(orderID)).Return([]*refund.Refund{{ID: 7}}, nil).Times(1)
+			},
+		},
+		{
+			name: "success owner",
+			ctx:  mockCustomer(2),
+			mockFn: func(m *mocks) {
+				m.order.EXPECT().GetOrder(gomock.Any(), int64(orderID)).Return(&order.Order{ID: orderID, UserID: 2}, nil).Times(1)
+				m.refund.EXPECT().ListRefunds(gomock.Any(), int64(orderID)).Return([]*refund.Refund{{ID: 7}}, nil).Times(1)
+			},
+		},
+		{
+			name: "fail order of another user",
+			ctx:  mockCustomer(3),
+			mockFn: func(m *mocks) {
+				m.order.EXPECT().GetOrder(gomock.Any(), int64(orderID)).Return(&order.Order{ID: orderID, UserID: 2}, nil).Times(1)
+			},
+			expectedErr: errs.ErrNoPermissions,
+		},
+		{
+			name:        "fail unauthorized",
+			ctx:         context.Background(),
+			mockFn:      func(m *mocks) {},
+			expectedErr: errs.ErrUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, m := setup(t)
+
+			tc.mockFn(m)
+
+			result, err := uc.ListRefunds(tc.ctx, orderID)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, result, 1)
+		})
+	}
+}
+
+// =============== Helper ===================
+// ------------------------------------------
+
+type mocks struct {
+	refund    *refundrepository.MockRefundRepository
+	order     *orderrepository.MockOrderRepository
+	product   *productrepository.MockProductRepository
+	inventory *inventoryrepository.MockInventoryRepository
+	payout    *payoutrepository.MockPayoutRepository
+	provider  *payment.FakeProvider
+}
+
+func setup(t *testing.T) (refundusecase.RefundUsecase, *mocks) {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := &mocks{
+		refund:    refundrepository.NewMockRefundRepository(ctrl),
+		order:     orderrepository.NewMockOrderRepository(ctrl),
+		product:   productrepository.NewMockProductRepository(ctrl),
+		inventory: inventoryrepository.NewMockInventoryRepository(ctrl),
+		payout:    payoutrepository.NewMockPayoutRepository(ctrl),
+		provider:  payment.NewFakeProvider(),
+	}
+
+	uc, err := refundusecase.NewRefundUsecase(&refundusecase.RefundUsecaseConfig{
+		Repo:          m.refund,
+		OrderRepo:     m.order,
+		ProductRepo:   m.product,
+		InventoryRepo: m.inventory,
+		PayoutRepo:    m.payout,
+		Provider:      m.provider,
+		Tx:            &mockTxManager{},
+	})
+	if err != nil {
+		t.Fatalf("init refund usecase failed: %v", err)
+	}
+	return uc, m
+}
+
+// mockLines one line per seller, 1 x 500 & 2 x 500
+func mockLines(firstStatus, secondStatus string) []*refund.Line {
+	return []*refund.Line{
+		{OrderItemID: 1, SubOrderID: 10, SellerID: 20, SubOrderStatus: firstStatus, ProductID: 10, WarehouseID: 3, Price: 500, Quantity: 1},
+		{OrderItemID: 2, SubOrderID: 11, SellerID: 30, SubOrderStatus: secondStatus, ProductID: 20, WarehouseID: 3, Price: 500, Quantity: 2},
+	}
+}
+
+func mockSubOrders(firstStatus, secondStatus string) []*order.SubOrder {
+	return []*order.SubOrder{
+		{ID: 10, OrderID: orderID, SellerID: 20, Status: firstStatus},
+		{ID: 11, OrderID: orderID, SellerID: 30, Status: secondStatus},
+	}
+}
+
+func insertRefund(_ context.Context, _ *sql.Tx, r *refund.Refund) error {
+	r.ID = 7
+	return nil
+}
+
+func mockAdmin() context.Context {
+	return auth.SetCurrentUser(context.Background(), &jwt.UserClaims{ID: 1, Role: "admin"})
+}
+
+func mockCustomer(userID int64) context.Context {
+	return auth.SetCurrentUser(context.Background(), &jwt.UserClaims{ID: userID, Role: "user"})
+}
+
+type mockTxManager struct{}
+
+func (m *mockTxManager) WithTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return fn(nil)
+}
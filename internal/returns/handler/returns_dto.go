@@ -0,0 +1,35 @@
+package returnshandler
+
+import (
+	"encoding/json"
+	"mime/multipart"
+)
+
+// ReturnReq JSON, or multipart with the photos and items as a JSON field
+type ReturnReq struct {
+	Items  ReturnItemsReq          `json:"items" form:"items" binding:"required,min=1,max=50,dive"`
+	Reason string                  `json:"reason" form:"reason" binding:"required,oneof=damaged defective wrong_item not_as_described changed_mind other"`
+	Note   string                  `json:"note" form:"note" binding:"max=500"`
+	Photos []*multipart.FileHeader `json:"-" form:"photos"`
+}
+
+// ReturnItemReq Quantity 0 returns what is left of the line
+type ReturnItemReq struct {
+	OrderItemID int64 `json:"order_item_id" binding:"required,gt=0"`
+	Quantity    int   `json:"quantity" binding:"gte=0"`
+}
+
+type ReturnItemsReq []*ReturnItemReq
+
+// UnmarshalParam the items field of a multipart form
+func (r *ReturnItemsReq) UnmarshalParam(param string) error {
+	return json.Unmarshal([]byte(param), r)
+}
+
+type RejectReq struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+type InspectReq struct {
+	Note string `json:"note" binding:"max=500"`
+}
@@ -0,0 +1,241 @@
+package returnshandler
+
+import (
+	"errors"
+	"io"
+
+	"github.com/codepnw/mini-ecommerce/internal/returns"
+	returnsusecase "github.com/codepnw/mini-ecommerce/internal/returns/usecase"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/internal/utils/helper"
+	"github.com/codepnw/mini-ecommerce/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+type returnsHandler struct {
+	uc returnsusecase.ReturnsUsecase
+}
+
+func NewReturnsHandler(uc returnsusecase.ReturnsUsecase) *returnsHandler {
+	return &returnsHandler{uc: uc}
+}
+
+func (h *returnsHandler) RequestReturn(c *gin.Context) {
+	orderID, err := helper.GetParamInt(c, consts.ParamOrderID)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	req := new(ReturnReq)
+	if err := c.ShouldBind(req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if len(req.Photos) > consts.MaxReturnPhotos {
+		response.BadRequest(c, errs.ErrTooManyReturnPhotos.Error())
+		return
+	}
+
+	input := &returns.Request{
+		OrderID: orderID,
+		Reason:  returns.Reason(req.Reason),
+		Note:    req.Note,
+	}
+	for _, i := range req.Items {
+		input.Items = append(input.Items, &returns.RequestItem{OrderItemID: i.OrderItemID, Quantity: i.Quantity})
+	}
+	for _, fileHeader := range req.Photos {
+		if fileHeader.Size > consts.MaxImageSize {
+			response.BadRequest(c, errs.ErrImageTooLarge.Error())
+			return
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			response.InternalServerError(c, err)
+			return
+		}
+		// Read one byte over the limit, so usecase can reject oversize files
+		data, err := io.ReadAll(io.LimitReader(file, consts.MaxImageSize+1))
+		file.Close()
+		if err != nil {
+			response.InternalServerError(c, err)
+			return
+		}
+		input.Photos = append(input.Photos, data)
+	}
+
+	result, err := h.uc.RequestReturn(c.Request.Context(), input)
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrNoPermissions:
+			response.Forbidden(c, err.Error())
+			return
+		case errs.ErrOrderNotFound:
+			response.NotFound(c, err.Error())
+			return
+		case errs.ErrReturnLineNotFound,
+			errs.ErrReturnQuantityExceeded,
+			errs.ErrReturnSellersMixed,
+			errs.ErrTooManyReturnPhotos,
+			errs.ErrImageRequired,
+			errs.ErrImageTooLarge,
+			errs.ErrImageTypeInvalid:
+			response.BadRequest(c, err.Error())
+			return
+		case errs.ErrReturnNotAllowed, errs.ErrReturnWindowClosed:
+			response.Conflict(c, err.Error(), nil)
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.Created(c, result)
+}
+
+func (h *returnsHandler) ListOrderReturns(c *gin.Context) {
+	orderID, err := helper.GetParamInt(c, consts.ParamOrderID)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.ListOrderReturns(c.Request.Context(), orderID)
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrNoPermissions:
+			response.Forbidden(c, err.Error())
+			return
+		case errs.ErrOrderNotFound:
+			response.NotFound(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "", result)
+}
+
+func (h *returnsHandler) ListSellerReturns(c *gin.Context) {
+	filter := new(returns.Filter)
+	if err := c.ShouldBindQuery(filter); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.ListSellerReturns(c.Request.Context(), filter)
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "", result)
+}
+
+func (h *returnsHandler) ListReturns(c *gin.Context) {
+	filter := new(returns.Filter)
+	if err := c.ShouldBindQuery(filter); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.ListReturns(c.Request.Context(), filter)
+	if err != nil {
+		switch err {
+		case errs.ErrNoPermissions:
+			response.Forbidden(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "", result)
+}
+
+func (h *returnsHandler) Approve(c *gin.Context) {
+	h.updateStatus(c, returns.StatusApproved, "")
+}
+
+func (h *returnsHandler) Reject(c *gin.Context) {
+	req := new(RejectReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	h.updateStatus(c, returns.StatusRejected, req.Reason)
+}
+
+func (h *returnsHandler) Receive(c *gin.Context) {
+	h.updateStatus(c, returns.StatusReceived, "")
+}
+
+// Inspect the note is optional
+func (h *returnsHandler) Inspect(c *gin.Context) {
+	req := new(InspectReq)
+	if err := c.ShouldBindJSON(req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	h.updateStatus(c, returns.StatusInspected, req.Note)
+}
+
+func (h *returnsHandler) Refund(c *gin.Context) {
+	h.updateStatus(c, returns.StatusRefunded, "")
+}
+
+func (h *returnsHandler) updateStatus(c *gin.Context, status returns.Status, note string) {
+	returnID, err := helper.GetParamInt(c, consts.ParamReturnID)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.UpdateStatus(c.Request.Context(), &returns.StatusUpdate{
+		ReturnID: returnID,
+		Status:   status,
+		Note:     note,
+	})
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrNoPermissions:
+			response.Forbidden(c, err.Error())
+			return
+		case errs.ErrReturnNotFound:
+			response.NotFound(c, err.Error())
+			return
+		case errs.ErrReturnStatusChange,
+			errs.ErrRefundNotAllowed,
+			errs.ErrRefundLineNotFound,
+			errs.ErrRefundQuantityExceeded,
+			errs.ErrNothingToRefund:
+			response.Conflict(c, err.Error(), nil)
+			return
+		case errs.ErrRefundDeclined:
+			response.UnprocessableEntity(c, err.Error(), nil)
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "", result)
+}
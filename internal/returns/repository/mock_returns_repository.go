@@ -0,0 +1,165 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: returns_repository.go
+
+// Package returnsrepository is a generated GoMock package.
+package returnsrepository
+
+import (
+	context "context"
+	sql "database/sql"
+	reflect "reflect"
+
+	returns "github.com/codepnw/mini-ecommerce/internal/returns"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockReturnsRepository is a mock of ReturnsRepository interface.
+type MockReturnsRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockReturnsRepositoryMockRecorder
+}
+
+// MockReturnsRepositoryMockRecorder is the mock recorder for MockReturnsRepository.
+type MockReturnsRepositoryMockRecorder struct {
+	mock *MockReturnsRepository
+}
+
+// NewMockReturnsRepository creates a new mock instance.
+func NewMockReturnsRepository(ctrl *gomock.Controller) *MockReturnsRepository {
+	mock := &MockReturnsRepository{ctrl: ctrl}
+	mock.recorder = &MockReturnsRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReturnsRepository) EXPECT() *MockReturnsRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetLines mocks base method.
+func (m *MockReturnsRepository) GetLines(ctx context.Context, tx *sql.Tx, orderID int64) ([]*returns.Line, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLines", ctx, tx, orderID)
+	ret0, _ := ret[0].([]*returns.Line)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLines indicates an expected call of GetLines.
+func (mr *MockReturnsRepositoryMockRecorder) GetLines(ctx, tx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLines", reflect.TypeOf((*MockReturnsRepository)(nil).GetLines), ctx, tx, orderID)
+}
+
+// GetReturnForUpdate mocks base method.
+func (m *MockReturnsRepository) GetReturnForUpdate(ctx context.Context, tx *sql.Tx, returnID int64) (*returns.Return, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReturnForUpdate", ctx, tx, returnID)
+	ret0, _ := ret[0].(*returns.Return)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReturnForUpdate indicates an expected call of GetReturnForUpdate.
+func (mr *MockReturnsRepositoryMockRecorder) GetReturnForUpdate(ctx, tx, returnID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReturnForUpdate", reflect.TypeOf((*MockReturnsRepository)(nil).GetReturnForUpdate), ctx, tx, returnID)
+}
+
+// InsertPhoto mocks base method.
+func (m *MockReturnsRepository) InsertPhoto(ctx context.Context, tx *sql.Tx, input *returns.Photo) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertPhoto", ctx, tx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertPhoto indicates an expected call of InsertPhoto.
+func (mr *MockReturnsRepositoryMockRecorder) InsertPhoto(ctx, tx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertPhoto", reflect.TypeOf((*MockReturnsRepository)(nil).InsertPhoto), ctx, tx, input)
+}
+
+// InsertReturn mocks base method.
+func (m *MockReturnsRepository) InsertReturn(ctx context.Context, tx *sql.Tx, input *returns.Return) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertReturn", ctx, tx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertReturn indicates an expected call of InsertReturn.
+func (mr *MockReturnsRepositoryMockRecorder) InsertReturn(ctx, tx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertReturn", reflect.TypeOf((*MockReturnsRepository)(nil).InsertReturn), ctx, tx, input)
+}
+
+// ListReturns mocks base method.
+func (m *MockReturnsRepository) ListReturns(ctx context.Context, filter *returns.Filter) ([]*returns.Return, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListReturns", ctx, filter)
+	ret0, _ := ret[0].([]*returns.Return)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListReturns indicates an expected call of ListReturns.
+func (mr *MockReturnsRepositoryMockRecorder) ListReturns(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReturns", reflect.TypeOf((*MockReturnsRepository)(nil).ListReturns), ctx, filter)
+}
+
+// UpdateReturn mocks base method.
+func (m *MockReturnsRepository) UpdateReturn(ctx context.Context, tx *sql.Tx, input *returns.Return) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateReturn", ctx, tx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateReturn indicates an expected call of UpdateReturn.
+func (mr *MockReturnsRepositoryMockRecorder) UpdateReturn(ctx, tx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateReturn", reflect.TypeOf((*MockReturnsRepository)(nil).UpdateReturn), ctx, tx, input)
+}
+
+// MockrowScanner is a mock of rowScanner interface.
+type MockrowScanner struct {
+	ctrl     *gomock.Controller
+	recorder *MockrowScannerMockRecorder
+}
+
+// MockrowScannerMockRecorder is the mock recorder for MockrowScanner.
+type MockrowScannerMockRecorder struct {
+	mock *MockrowScanner
+}
+
+// NewMockrowScanner creates a new mock instance.
+func NewMockrowScanner(ctrl *gomock.Controller) *MockrowScanner {
+	mock := &MockrowScanner{ctrl: ctrl}
+	mock.recorder = &MockrowScannerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockrowScanner) EXPECT() *MockrowScannerMockRecorder {
+	return m.recorder
+}
+
+// Scan mocks base method.
+func (m *MockrowScanner) Scan(dest ...any) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range dest {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Scan", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Scan indicates an expected call of Scan.
+func (mr *MockrowScannerMockRecorder) Scan(dest ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockrowScanner)(nil).Scan), dest...)
+}
@@ -0,0 +1,375 @@
+package returnsrepository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/codepnw/mini-ecommerce/internal/returns"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/pkg/database"
+	"github.com/lib/pq"
+)
+
+//go:generate mockgen -source=returns_repository.go -destination=mock_returns_repository.go -package=returnsrepository
+
+type ReturnsRepository interface {
+	ListReturns(ctx context.Context, filter *returns.Filter) ([]*returns.Return, error)
+
+	// Transaction
+	GetLines(ctx context.Context, tx *sql.Tx, orderID int64) ([]*returns.Line, error)
+	InsertReturn(ctx context.Context, tx *sql.Tx, input *returns.Return) error
+	InsertPhoto(ctx context.Context, tx *sql.Tx, input *returns.Photo) error
+	GetReturnForUpdate(ctx context.Context, tx *sql.Tx, returnID int64) (*returns.Return, error)
+	UpdateReturn(ctx context.Context, tx *sql.Tx, input *returns.Return) error
+}
+
+type returnsRepository struct {
+	db *sql.DB
+}
+
+func NewReturnsRepository(db *sql.DB) ReturnsRepository {
+	return &returnsRepository{db: db}
+}
+
+const returnQuery = `
+	SELECT r.id, r.order_id, r.sub_order_id, r.seller_id, r.user_id, r.status, r.reason,
+		r.note, r.reject_reason, r.inspection_note, r.refund_id, r.reviewed_by,
+		r.reviewed_at, r.received_at, r.inspected_at, r.created_at, r.updated_at
+	FROM returns r
+`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanReturn(row rowScanner) (*returns.Return, error) {
+	var (
+		r              = &returns.Return{Items: make([]*returns.Item, 0), Photos: make([]*returns.Photo, 0)}
+		note           sql.NullString
+		rejectReason   sql.NullString
+		inspectionNote sql.NullString
+		refundID       sql.NullInt64
+		reviewedBy     sql.NullInt64
+		reviewedAt     sql.NullTime
+		receivedAt     sql.NullTime
+		inspectedAt    sql.NullTime
+	)
+	err := row.Scan(
+		&r.ID,
+		&r.OrderID,
+		&r.SubOrderID,
+		&r.SellerID,
+		&r.UserID,
+		&r.Status,
+		&r.Reason,
+		&note,
+		&rejectReason,
+		&inspectionNote,
+		&refundID,
+		&reviewedBy,
+		&reviewedAt,
+		&receivedAt,
+		&inspectedAt,
+		&r.CreatedAt,
+		&r.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Note = note.String
+	r.RejectReason = rejectReason.String
+	r.InspectionNote = inspectionNote.String
+	if refundID.Valid {
+		r.RefundID = &refundID.Int64
+	}
+	if reviewedBy.Valid {
+		r.ReviewedBy = &reviewedBy.Int64
+	}
+	if reviewedAt.Valid {
+		r.ReviewedAt = &reviewedAt.Time
+	}
+	if receivedAt.Valid {
+		r.ReceivedAt = &receivedAt.Time
+	}
+	if inspectedAt.Valid {
+		r.InspectedAt = &inspectedAt.Time
+	}
+	return r, nil
+}
+
+// ListReturns newest first, with their items & photos
+func (r *returnsRepository) ListReturns(ctx context.Context, filter *returns.Filter) ([]*returns.Return, error) {
+	query := returnQuery + " WHERE TRUE"
+	args := []any{}
+	idx := 1
+
+	if filter.OrderID > 0 {
+		query += fmt.Sprintf(" AND r.order_id = $%d", idx)
+		args = append(args, filter.OrderID)
+		idx++
+	}
+	if filter.SellerID > 0 {
+		query += fmt.Sprintf(" AND r.seller_id = $%d", idx)
+		args = append(args, filter.SellerID)
+		idx++
+	}
+	if filter.Status != "" {
+		query += fmt.Sprintf(" AND r.status = $%d", idx)
+		args = append(args, filter.Status)
+		idx++
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+
+	query += fmt.Sprintf(" ORDER BY r.id DESC LIMIT $%d OFFSET $%d", idx, idx+1)
+	args = append(args, filter.Limit, offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]*returns.Return, 0)
+	for rows.Next() {
+		ret, err := scanReturn(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, ret)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.attachDetails(ctx, r.db, list...); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// GetLines the order lines with their sub-order & return window, the
+// caller locks the order so open returns can't change meanwhile
+func (r *returnsRepository) GetLines(ctx context.Context, tx *sql.Tx, orderID int64) ([]*returns.Line, error) {
+	query := `
+		SELECT oi.id, s.id, s.seller_id, s.status, s.completed_at, oi.product_id, p.return_window_days,
+			oi.quantity, oi.refunded_quantity,
+			COALESCE((
+				SELECT SUM(ri.quantity)
+				FROM return_items ri
+				INNER JOIN returns r ON r.id = ri.return_id
+				WHERE ri.order_item_id = oi.id AND r.status = ANY($2)
+			), 0)
+		FROM order_items oi
+		INNER JOIN sub_orders s ON s.id = oi.sub_order_id
+		INNER JOIN products p ON p.id = oi.product_id
+		WHERE oi.order_id = $1
+		ORDER BY oi.id
+	`
+	open := make(pq.StringArray, 0, len(returns.OpenStatuses))
+	for _, s := range returns.OpenStatuses {
+		open = append(open, string(s))
+	}
+
+	rows, err := tx.QueryContext(ctx, query, orderID, open)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := make([]*returns.Line, 0)
+	for rows.Next() {
+		var (
+			l           = new(returns.Line)
+			completedAt sql.NullTime
+		)
+		err := rows.Scan(
+			&l.OrderItemID,
+			&l.SubOrderID,
+			&l.SellerID,
+			&l.SubOrderStatus,
+			&completedAt,
+			&l.ProductID,
+			&l.WindowDays,
+			&l.Quantity,
+			&l.RefundedQuantity,
+			&l.ReturnedQuantity,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if completedAt.Valid {
+			l.CompletedAt = &completedAt.Time
+		}
+		lines = append(lines, l)
+	}
+	return lines, rows.Err()
+}
+
+func (r *returnsRepository) InsertReturn(ctx context.Context, tx *sql.Tx, input *returns.Return) error {
+	query := `
+		INSERT INTO returns (order_id, sub_order_id, seller_id, user_id, status, reason, note)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at
+	`
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		input.OrderID,
+		input.SubOrderID,
+		input.SellerID,
+		input.UserID,
+		input.Status,
+		input.Reason,
+		sql.NullString{String: input.Note, Valid: input.Note != ""},
+	).Scan(&input.ID, &input.CreatedAt, &input.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	for _, i := range input.Items {
+		i.ReturnID = input.ID
+		err := tx.QueryRowContext(
+			ctx,
+			`INSERT INTO return_items (return_id, order_item_id, quantity) VALUES ($1, $2, $3) RETURNING id`,
+			i.ReturnID,
+			i.OrderItemID,
+			i.Quantity,
+		).Scan(&i.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *returnsRepository) InsertPhoto(ctx context.Context, tx *sql.Tx, input *returns.Photo) error {
+	query := `
+		INSERT INTO return_photos (return_id, object_key, content_type, size)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+	return tx.QueryRowContext(
+		ctx,
+		query,
+		input.ReturnID,
+		input.ObjectKey,
+		input.ContentType,
+		input.Size,
+	).Scan(&input.ID, &input.CreatedAt)
+}
+
+// GetReturnForUpdate locks the return with its items & photos
+func (r *returnsRepository) GetReturnForUpdate(ctx context.Context, tx *sql.Tx, returnID int64) (*returns.Return, error) {
+	query := returnQuery + " WHERE r.id = $1 FOR UPDATE"
+
+	ret, err := scanReturn(tx.QueryRowContext(ctx, query, returnID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrReturnNotFound
+		}
+		return nil, err
+	}
+
+	if err := r.attachDetails(ctx, tx, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// UpdateReturn writes the status & the fields stamped along with it
+func (r *returnsRepository) UpdateReturn(ctx context.Context, tx *sql.Tx, input *returns.Return) error {
+	query := `
+		UPDATE returns
+		SET status = $1, reject_reason = $2, inspection_note = $3, refund_id = $4,
+			reviewed_by = $5, reviewed_at = $6, received_at = $7, inspected_at = $8, updated_at = NOW()
+		WHERE id = $9
+		RETURNING updated_at
+	`
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		input.Status,
+		sql.NullString{String: input.RejectReason, Valid: input.RejectReason != ""},
+		sql.NullString{String: input.InspectionNote, Valid: input.InspectionNote != ""},
+		input.RefundID,
+		input.ReviewedBy,
+		input.ReviewedAt,
+		input.ReceivedAt,
+		input.InspectedAt,
+		input.ID,
+	).Scan(&input.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errs.ErrReturnNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// attachDetails loads items & photos of all returns in two queries
+func (r *returnsRepository) attachDetails(ctx context.Context, exec database.DBExec, list ...*returns.Return) error {
+	if len(list) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(list))
+	byID := make(map[int64]*returns.Return, len(list))
+	for _, ret := range list {
+		ids = append(ids, ret.ID)
+		byID[ret.ID] = ret
+	}
+
+	itemQuery := `
+		SELECT ri.id, ri.return_id, ri.order_item_id, oi.product_id, oi.warehouse_id, ri.quantity
+		FROM return_items ri
+		INNER JOIN order_items oi ON oi.id = ri.order_item_id
+		WHERE ri.return_id = ANY($1)
+		ORDER BY ri.id
+	`
+	rows, err := exec.QueryContext(ctx, itemQuery, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			i           = new(returns.Item)
+			warehouseID sql.NullInt64
+		)
+		if err := rows.Scan(&i.ID, &i.ReturnID, &i.OrderItemID, &i.ProductID, &warehouseID, &i.Quantity); err != nil {
+			return err
+		}
+		i.WarehouseID = warehouseID.Int64
+		byID[i.ReturnID].Items = append(byID[i.ReturnID].Items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	photoQuery := `
+		SELECT id, return_id, object_key, content_type, size, created_at
+		FROM return_photos
+		WHERE return_id = ANY($1)
+		ORDER BY id
+	`
+	photoRows, err := exec.QueryContext(ctx, photoQuery, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer photoRows.Close()
+
+	for photoRows.Next() {
+		p := new(returns.Photo)
+		if err := photoRows.Scan(&p.ID, &p.ReturnID, &p.ObjectKey, &p.ContentType, &p.Size, &p.CreatedAt); err != nil {
+			return err
+		}
+		byID[p.ReturnID].Photos = append(byID[p.ReturnID].Photos, p)
+	}
+	return photoRows.Err()
+}
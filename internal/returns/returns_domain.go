@@ -0,0 +1,256 @@
+package returns
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/codepnw/mini-ecommerce/internal/refund"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+)
+
+type Status string
+
+const (
+	StatusRequested Status = "requested"
+	StatusApproved  Status = "approved"
+	StatusRejected  Status = "rejected"
+	StatusReceived  Status = "received"
+	StatusInspected Status = "inspected"
+	StatusRefunded  Status = "refunded"
+)
+
+// OpenStatuses hold the quantities of their items, the rest are done
+var OpenStatuses = []Status{StatusRequested, StatusApproved, StatusReceived, StatusInspected}
+
+// next statuses of each status, rejected & refunded are final
+var next = map[Status][]Status{
+	StatusRequested: {StatusApproved, StatusRejected},
+	StatusApproved:  {StatusReceived},
+	StatusReceived:  {StatusInspected},
+	StatusInspected: {StatusRefunded},
+}
+
+func (s Status) CanMoveTo(status Status) bool {
+	for _, n := range next[s] {
+		if n == status {
+			return true
+		}
+	}
+	return false
+}
+
+type Reason string
+
+const (
+	ReasonDamaged        Reason = "damaged"
+	ReasonDefective      Reason = "defective"
+	ReasonWrongItem      Reason = "wrong_item"
+	ReasonNotAsDescribed Reason = "not_as_described"
+	ReasonChangedMind    Reason = "changed_mind"
+	ReasonOther          Reason = "other"
+)
+
+// RefundReason the refund reason of the return
+func (r Reason) RefundReason() refund.Reason {
+	switch r {
+	case ReasonDamaged:
+		return refund.ReasonDamaged
+	case ReasonDefective:
+		return refund.ReasonDefective
+	case ReasonWrongItem:
+		return refund.ReasonWrongItem
+	}
+	return refund.ReasonCustomerRequest
+}
+
+// Return items of one seller sent back by the customer
+type Return struct {
+	ID             int64      `json:"id"`
+	OrderID        int64      `json:"order_id"`
+	SubOrderID     int64      `json:"sub_order_id"`
+	SellerID       int64      `json:"seller_id"`
+	UserID         int64      `json:"user_id"`
+	Status         Status     `json:"status"`
+	Reason         Reason     `json:"reason"`
+	Note           string     `json:"note,omitempty"`
+	RejectReason   string     `json:"reject_reason,omitempty"`
+	InspectionNote string     `json:"inspection_note,omitempty"`
+	RefundID       *int64     `json:"refund_id,omitempty"`
+	ReviewedBy     *int64     `json:"reviewed_by,omitempty"`
+	ReviewedAt     *time.Time `json:"reviewed_at,omitempty"`
+	ReceivedAt     *time.Time `json:"received_at,omitempty"`
+	InspectedAt    *time.Time `json:"inspected_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	Items  []*Item  `json:"items"`
+	Photos []*Photo `json:"photos"`
+}
+
+type Item struct {
+	ID          int64 `json:"id"`
+	ReturnID    int64 `json:"-"`
+	OrderItemID int64 `json:"order_item_id"`
+	ProductID   int64 `json:"product_id"`
+	WarehouseID int64 `json:"-"`
+	Quantity    int   `json:"quantity"`
+}
+
+type Photo struct {
+	ID          int64     `json:"id"`
+	ReturnID    int64     `json:"-"`
+	ObjectKey   string    `json:"-"`
+	URL         string    `json:"url"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Request a customer asking to return lines of an order
+type Request struct {
+	OrderID int64
+	Items   []*RequestItem
+	Reason  Reason
+	Note    string
+	Photos  [][]byte
+}
+
+// RequestItem Quantity 0 returns what is left of the line
+type RequestItem struct {
+	OrderItemID int64
+	Quantity    int
+}
+
+// StatusUpdate Note is the reject reason or the inspection note
+type StatusUpdate struct {
+	ReturnID int64
+	Status   Status
+	Note     string
+}
+
+type Filter struct {
+	Status string `form:"status" binding:"omitempty,oneof=requested approved rejected received inspected refunded"`
+	Page   int    `form:"page"`
+	Limit  int    `form:"limit"`
+
+	OrderID  int64 `form:"-"`
+	SellerID int64 `form:"-"`
+}
+
+// Line an order line with its return window, ReturnedQuantity is held by
+// open returns
+type Line struct {
+	OrderItemID      int64
+	SubOrderID       int64
+	SellerID         int64
+	SubOrderStatus   string
+	CompletedAt      *time.Time
+	ProductID        int64
+	WindowDays       int
+	Quantity         int
+	RefundedQuantity int
+	ReturnedQuantity int
+}
+
+func (l *Line) Returnable() int {
+	return l.Quantity - l.RefundedQuantity - l.ReturnedQuantity
+}
+
+// WindowOpen the sub-order completed less than the product's window ago
+func (l *Line) WindowOpen(now time.Time) bool {
+	if l.CompletedAt == nil || l.WindowDays == 0 {
+		return false
+	}
+	return now.Before(l.CompletedAt.AddDate(0, 0, l.WindowDays))
+}
+
+// NewReturn the requested return, checked against the order lines
+func NewReturn(req *Request, lines []*Line, now time.Time) (*Return, error) {
+	byID := make(map[int64]*Line, len(lines))
+	for _, l := range lines {
+		byID[l.OrderItemID] = l
+	}
+
+	r := &Return{
+		OrderID: req.OrderID,
+		Status:  StatusRequested,
+		Reason:  req.Reason,
+		Note:    req.Note,
+	}
+	items := make(map[int64]*Item)
+	for _, ri := range req.Items {
+		l, ok := byID[ri.OrderItemID]
+		if !ok {
+			return nil, errs.ErrReturnLineNotFound
+		}
+		if l.SubOrderStatus != "completed" {
+			return nil, errs.ErrReturnNotAllowed
+		}
+		if !l.WindowOpen(now) {
+			return nil, errs.ErrReturnWindowClosed
+		}
+		if r.SubOrderID == 0 {
+			r.SubOrderID, r.SellerID = l.SubOrderID, l.SellerID
+		}
+		if l.SubOrderID != r.SubOrderID {
+			return nil, errs.ErrReturnSellersMixed
+		}
+
+		item, ok := items[l.OrderItemID]
+		if !ok {
+			item = &Item{OrderItemID: l.OrderItemID, ProductID: l.ProductID}
+			items[l.OrderItemID] = item
+			r.Items = append(r.Items, item)
+		}
+		quantity := ri.Quantity
+		if quantity == 0 {
+			quantity = l.Returnable() - item.Quantity
+		}
+		item.Quantity += quantity
+		if quantity <= 0 || item.Quantity > l.Returnable() {
+			return nil, errs.ErrReturnQuantityExceeded
+		}
+	}
+	if len(r.Items) == 0 {
+		return nil, errs.ErrReturnLineNotFound
+	}
+	return r, nil
+}
+
+// Move to the next status, reviews by the seller or an admin are stamped
+func (r *Return) Move(input *StatusUpdate, actorID int64, now time.Time) error {
+	if !r.Status.CanMoveTo(input.Status) {
+		return errs.ErrReturnStatusChange
+	}
+
+	switch input.Status {
+	case StatusApproved, StatusRejected:
+		r.ReviewedBy = &actorID
+		r.ReviewedAt = &now
+		if input.Status == StatusRejected {
+			r.RejectReason = input.Note
+		}
+	case StatusReceived:
+		r.ReceivedAt = &now
+	case StatusInspected:
+		r.InspectionNote = input.Note
+		r.InspectedAt = &now
+	}
+	r.Status = input.Status
+	r.UpdatedAt = now
+	return nil
+}
+
+// RefundRequest pays back the returned items, they are back in stock
+// since the return was received
+func (r *Return) RefundRequest() *refund.Request {
+	req := &refund.Request{
+		OrderID: r.OrderID,
+		Reason:  r.Reason.RefundReason(),
+		Note:    fmt.Sprintf("return #%d", r.ID),
+	}
+	for _, i := range r.Items {
+		req.Lines = append(req.Lines, &refund.RequestLine{OrderItemID: i.OrderItemID, Quantity: i.Quantity})
+	}
+	return req
+}
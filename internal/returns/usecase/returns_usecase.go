@@ -0,0 +1,361 @@
+package returnsusecase
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/codepnw/mini-ecommerce/internal/inventory"
+	inventoryrepository "github.com/codepnw/mini-ecommerce/internal/inventory/repository"
+	orderrepository "github.com/codepnw/mini-ecommerce/internal/order/repository"
+	productrepository "github.com/codepnw/mini-ecommerce/internal/product/repository"
+	"github.com/codepnw/mini-ecommerce/internal/refund"
+	refundusecase "github.com/codepnw/mini-ecommerce/internal/refund/usecase"
+	"github.com/codepnw/mini-ecommerce/internal/returns"
+	returnsrepository "github.com/codepnw/mini-ecommerce/internal/returns/repository"
+	"github.com/codepnw/mini-ecommerce/internal/user"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/pkg/auth"
+	"github.com/codepnw/mini-ecommerce/pkg/database"
+	"github.com/codepnw/mini-ecommerce/pkg/storage"
+	"github.com/codepnw/mini-ecommerce/pkg/validate"
+	"github.com/google/uuid"
+)
+
+type ReturnsUsecase interface {
+	RequestReturn(ctx context.Context, input *returns.Request) (*returns.Return, error)
+	ListOrderReturns(ctx context.Context, orderID int64) ([]*returns.Return, error)
+	ListSellerReturns(ctx context.Context, filter *returns.Filter) ([]*returns.Return, error)
+	ListReturns(ctx context.Context, filter *returns.Filter) ([]*returns.Return, error)
+	UpdateStatus(ctx context.Context, input *returns.StatusUpdate) (*returns.Return, error)
+}
+
+type ReturnsUsecaseConfig struct {
+	Repo          returnsrepository.ReturnsRepository     `validate:"required"`
+	OrderRepo     orderrepository.OrderRepository         `validate:"required"`
+	ProductRepo   productrepository.ProductRepository     `validate:"required"`
+	InventoryRepo inventoryrepository.InventoryRepository `validate:"required"`
+	Refunds       refundusecase.RefundUsecase             `validate:"required"`
+	Store         storage.BlobStore                       `validate:"required"`
+	Tx            database.TxManager                      `validate:"required"`
+}
+
+type returnsUsecase struct {
+	repo          returnsrepository.ReturnsRepository
+	orderRepo     orderrepository.OrderRepository
+	productRepo   productrepository.ProductRepository
+	inventoryRepo inventoryrepository.InventoryRepository
+	refunds       refundusecase.RefundUsecase
+	store         storage.BlobStore
+	tx            database.TxManager
+}
+
+func NewReturnsUsecase(cfg *ReturnsUsecaseConfig) (ReturnsUsecase, error) {
+	if err := validate.Struct(cfg); err != nil {
+		return nil, err
+	}
+	return &returnsUsecase{
+		repo:          cfg.Repo,
+		orderRepo:     cfg.OrderRepo,
+		productRepo:   cfg.ProductRepo,
+		inventoryRepo: cfg.InventoryRepo,
+		refunds:       cfg.Refunds,
+		store:         cfg.Store,
+		tx:            cfg.Tx,
+	}, nil
+}
+
+// RequestReturn by the customer of the order, photos are uploaded first
+// and removed again when the return is refused
+func (u *returnsUsecase) RequestReturn(ctx context.Context, input *returns.Request) (*returns.Return, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	userID := auth.GetUserID(ctx)
+	if userID == 0 {
+		return nil, errs.ErrUnauthorized
+	}
+
+	orderData, err := u.orderRepo.GetOrder(ctx, input.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if orderData.UserID != userID {
+		return nil, errs.ErrNoPermissions
+	}
+
+	photos, err := u.uploadPhotos(ctx, input.OrderID, input.Photos)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *returns.Return
+
+	err = u.tx.WithTransaction(ctx, func(tx *sql.Tx) error {
+		// Lock Order, open returns of its lines can't change meanwhile
+		if _, err := u.orderRepo.GetStatusForUpdate(ctx, tx, input.OrderID); err != nil {
+			return err
+		}
+
+		lines, err := u.repo.GetLines(ctx, tx, input.OrderID)
+		if err != nil {
+			return err
+		}
+		r, err := returns.NewReturn(input, lines, time.Now())
+		if err != nil {
+			return err
+		}
+		r.UserID = userID
+		if err := u.repo.InsertReturn(ctx, tx, r); err != nil {
+			return err
+		}
+
+		for _, p := range photos {
+			p.ReturnID = r.ID
+			if err := u.repo.InsertPhoto(ctx, tx, p); err != nil {
+				return err
+			}
+		}
+		r.Photos = photos
+		result = r
+		return nil
+	})
+	if err != nil {
+		u.deletePhotos(ctx, photos)
+		return nil, err
+	}
+
+	u.attachURLs(result)
+	return result, nil
+}
+
+// ListOrderReturns for admins & the customer of the order
+func (u *returnsUsecase) ListOrderReturns(ctx context.Context, orderID int64) ([]*returns.Return, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	currentUser, err := auth.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, errs.ErrUnauthorized
+	}
+	if currentUser.Role != string(user.RoleAdmin) {
+		orderData, err := u.orderRepo.GetOrder(ctx, orderID)
+		if err != nil {
+			return nil, err
+		}
+		if orderData.UserID != currentUser.ID {
+			return nil, errs.ErrNoPermissions
+		}
+	}
+
+	return u.list(ctx, &returns.Filter{OrderID: orderID, Page: 1, Limit: 100})
+}
+
+// ListSellerReturns returns of the current seller's sub-orders
+func (u *returnsUsecase) ListSellerReturns(ctx context.Context, filter *returns.Filter) ([]*returns.Return, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	sellerID := auth.GetUserID(ctx)
+	if sellerID == 0 {
+		return nil, errs.ErrUnauthorized
+	}
+	filter.SellerID = sellerID
+
+	return u.list(ctx, filter)
+}
+
+func (u *returnsUsecase) ListReturns(ctx context.Context, filter *returns.Filter) ([]*returns.Return, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	admin, err := auth.GetCurrentUser(ctx)
+	if err != nil || admin.Role != string(user.RoleAdmin) {
+		return nil, errs.ErrNoPermissions
+	}
+
+	return u.list(ctx, filter)
+}
+
+// UpdateStatus moves the return on for the seller of its items or an
+// admin. Received items go back in stock, the refund is for admins only.
+func (u *returnsUsecase) UpdateStatus(ctx context.Context, input *returns.StatusUpdate) (*returns.Return, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	actor, err := auth.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, errs.ErrUnauthorized
+	}
+	isAdmin := actor.Role == string(user.RoleAdmin)
+	if input.Status == returns.StatusRefunded && !isAdmin {
+		return nil, errs.ErrNoPermissions
+	}
+
+	var (
+		result *returns.Return
+		rf     *refund.Refund
+	)
+
+	err = u.tx.WithTransaction(ctx, func(tx *sql.Tx) error {
+		r, err := u.repo.GetReturnForUpdate(ctx, tx, input.ReturnID)
+		if err != nil {
+			return err
+		}
+		if !isAdmin && r.SellerID != actor.ID {
+			return errs.ErrNoPermissions
+		}
+		if err := r.Move(input, actor.ID, time.Now()); err != nil {
+			return err
+		}
+
+		switch r.Status {
+		case returns.StatusReceived:
+			if err := u.restock(ctx, tx, r, actor.ID); err != nil {
+				return err
+			}
+		case returns.StatusRefunded:
+			// Already back in stock, the refund doesn't restock again
+			rf, err = u.refunds.CreateRefundTx(ctx, tx, r.RefundRequest(), actor.ID)
+			if err != nil {
+				return err
+			}
+			r.RefundID = &rf.ID
+		}
+
+		if err := u.repo.UpdateReturn(ctx, tx, r); err != nil {
+			return err
+		}
+		result = r
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Paid once the refund is committed
+	if rf != nil {
+		if _, err := u.refunds.PayRefund(ctx, rf); err != nil {
+			return nil, err
+		}
+	}
+
+	u.attachURLs(result)
+	return result, nil
+}
+
+func (u *returnsUsecase) list(ctx context.Context, filter *returns.Filter) ([]*returns.Return, error) {
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+
+	list, err := u.repo.ListReturns(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	u.attachURLs(list...)
+	return list, nil
+}
+
+// restock puts the received units back in the warehouse they were picked from
+func (u *returnsUsecase) restock(ctx context.Context, tx *sql.Tx, r *returns.Return, actorID int64) error {
+	for _, i := range r.Items {
+		warehouseID := i.WarehouseID
+		if warehouseID == 0 {
+			w, err := u.inventoryRepo.DefaultWarehouse(ctx, tx)
+			if err != nil {
+				return err
+			}
+			warehouseID = w.ID
+		}
+		if err := u.inventoryRepo.AdjustWarehouseStock(ctx, tx, warehouseID, i.ProductID, i.Quantity); err != nil {
+			return err
+		}
+		if err := u.productRepo.IncreaseStock(ctx, tx, i.ProductID, i.Quantity); err != nil {
+			return err
+		}
+
+		movement := &inventory.StockMovement{
+			ProductID:   i.ProductID,
+			Delta:       i.Quantity,
+			Reason:      inventory.ReasonReturn,
+			RefType:     inventory.RefReturn,
+			RefID:       r.ID,
+			ActorID:     actorID,
+			WarehouseID: warehouseID,
+		}
+		if err := u.inventoryRepo.InsertMovement(ctx, tx, movement); err != nil {
+			return err
+		}
+		// Increase only, the threshold is not needed
+		if err := inventory.RecordStockEvents(ctx, tx, u.inventoryRepo, movement, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var photoExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// uploadPhotos checks every photo before uploading any of them
+func (u *returnsUsecase) uploadPhotos(ctx context.Context, orderID int64, photos [][]byte) ([]*returns.Photo, error) {
+	if len(photos) > consts.MaxReturnPhotos {
+		return nil, errs.ErrTooManyReturnPhotos
+	}
+
+	// Sniff content, never trust client Content-Type
+	contentTypes := make([]string, 0, len(photos))
+	for _, data := range photos {
+		if len(data) == 0 {
+			return nil, errs.ErrImageRequired
+		}
+		if len(data) > consts.MaxImageSize {
+			return nil, errs.ErrImageTooLarge
+		}
+		contentType := http.DetectContentType(data)
+		if _, ok := photoExtensions[contentType]; !ok {
+			return nil, errs.ErrImageTypeInvalid
+		}
+		contentTypes = append(contentTypes, contentType)
+	}
+
+	uploaded := make([]*returns.Photo, 0, len(photos))
+	for i, data := range photos {
+		key := fmt.Sprintf("returns/%d/%s%s", orderID, uuid.NewString(), photoExtensions[contentTypes[i]])
+		if err := u.store.Put(ctx, key, bytes.NewReader(data), contentTypes[i]); err != nil {
+			u.deletePhotos(ctx, uploaded)
+			return nil, err
+		}
+		uploaded = append(uploaded, &returns.Photo{
+			ObjectKey:   key,
+			ContentType: contentTypes[i],
+			Size:        int64(len(data)),
+		})
+	}
+	return uploaded, nil
+}
+
+func (u *returnsUsecase) deletePhotos(ctx context.Context, photos []*returns.Photo) {
+	for _, p := range photos {
+		u.store.Delete(ctx, p.ObjectKey)
+	}
+}
+
+func (u *returnsUsecase) attachURLs(list ...*returns.Return) {
+	for _, r := range list {
+		for _, p := range r.Photos {
+			p.URL = u.store.URL(p.ObjectKey)
+		}
+	}
+}
@@ -0,0 +1,406 @@
+package returnsusecase_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/codepnw/mini-ecommerce/internal/inventory"
+	inventoryrepository "github.com/codepnw/mini-ecommerce/internal/inventory/repository"
+	"github.com/codepnw/mini-ecommerce/internal/order"
+	orderrepository "github.com/codepnw/mini-ecommerce/internal/order/repository"
+	productrepository "github.com/codepnw/mini-ecommerce/internal/product/repository"
+	"github.com/codepnw/mini-ecommerce/internal/refund"
+	"github.com/codepnw/mini-ecommerce/internal/returns"
+	returnsrepository "github.com/codepnw/mini-ecommerce/internal/returns/repository"
+	returnsusecase "github.com/codepnw/mini-ecommerce/internal/returns/usecase"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/pkg/auth"
+	"github.com/codepnw/mini-ecommerce/pkg/jwt"
+	"github.com/codepnw/mini-ecommerce/pkg/storage"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	orderID    = 100
+	customerID = 2
+	sellerID   = 20
+)
+
+var pngPhoto = []byte("\x89PNG\r\n\x1a\n0000")
+
+func TestRequestReturn(t *testing.T) {
+	type testCase struct {
+		name        string
+		ctx         context.Context
+		input       *returns.Request
+		mockFn      func(m *mocks)
+		expectedErr error
+	}
+
+	request := func(photos ...[]byte) *returns.Request {
+		return &returns.Request{
+			OrderID: orderID,
+			Items:   []*returns.RequestItem{{OrderItemID: 1, Quantity: 1}},
+			Reason:  returns.ReasonDamaged,
+			Photos:  photos,
+		}
+	}
+
+	testCases := []testCase{
+		{
+			name:  "success with photo",
+			ctx:   mockUser(customerID, "user"),
+			input: request(pngPhoto),
+			mockFn: func(m *mocks) {
+				m.order.EXPECT().GetOrder(gomock.Any(), int64(orderID)).Return(&order.Order{ID: orderID, UserID: customerID}, nil).Times(1)
+				m.order.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(orderID)).Return(order.StatusCompleted, nil).Times(1)
+				m.returns.EXPECT().GetLines(gomock.Any(), gomock.Any(), int64(orderID)).Return(mockLines(5), nil).Times(1)
+				m.returns.EXPECT().InsertReturn(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ *sql.Tx, r *returns.Return) error {
+						assert.Equal(t, int64(10), r.SubOrderID)
+						assert.Equal(t, int64(sellerID), r.SellerID)
+						assert.Equal(t, int64(customerID), r.UserID)
+						r.ID = 7
+						return nil
+					},
+				).Times(1)
+				m.returns.EXPECT().InsertPhoto(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ *sql.Tx, p *returns.Photo) error {
+						assert.Equal(t, int64(7), p.ReturnID)
+						assert.Equal(t, "image/png", p.ContentType)
+						return nil
+					},
+				).Times(1)
+			},
+		},
+		{
+			name:  "fail window closed",
+			ctx:   mockUser(customerID, "user"),
+			input: request(),
+			mockFn: func(m *mocks) {
+				m.order.EXPECT().GetOrder(gomock.Any(), int64(orderID)).Return(&order.Order{ID: orderID, UserID: customerID}, nil).Times(1)
+				m.order.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(orderID)).Return(order.StatusCompleted, nil).Times(1)
+				m.returns.EXPECT().GetLines(gomock.Any(), gomock.Any(), int64(orderID)).Return(mockLines(40), nil).Times(1)
+			},
+			expectedErr: errs.ErrReturnWindowClosed,
+		},
+		{
+			name: "fail items of two sellers",
+			ctx:  mockUser(customerID, "user"),
+			input: &returns.Request{
+				OrderID: orderID,
+				Items:   []*returns.RequestItem{{OrderItemID: 1}, {OrderItemID: 2}},
+				Reason:  returns.ReasonChangedMind,
+			},
+			mockFn: func(m *mocks) {
+				m.order.EXPECT().GetOrder(gomock.Any(), int64(orderID)).Return(&order.Order{ID: orderID, UserID: customerID}, nil).Times(1)
+				m.order.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(orderID)).Return(order.StatusCompleted, nil).Times(1)
+				m.returns.EXPECT().GetLines(gomock.Any(), gomock.Any(), int64(orderID)).Return(mockLines(5), nil).Times(1)
+			},
+			expectedErr: errs.ErrReturnSellersMixed,
+		},
+		{
+			name: "fail quantity held by an open return",
+			ctx:  mockUser(customerID, "user"),
+			input: &returns.Request{
+				OrderID: orderID,
+				Items:   []*returns.RequestItem{{OrderItemID: 1, Quantity: 2}},
+				Reason:  returns.ReasonDefective,
+			},
+			mockFn: func(m *mocks) {
+				lines := mockLines(5)
+				lines[0].ReturnedQuantity = 1
+
+				m.order.EXPECT().GetOrder(gomock.Any(), int64(orderID)).Return(&order.Order{ID: orderID, UserID: customerID}, nil).Times(1)
+				m.order.EXPECT().GetStatusForUpdate(gomock.Any(), gomock.Any(), int64(orderID)).Return(order.StatusCompleted, nil).Times(1)
+				m.returns.EXPECT().GetLines(gomock.Any(), gomock.Any(), int64(orderID)).Return(lines, nil).Times(1)
+			},
+			expectedErr: errs.ErrReturnQuantityExceeded,
+		},
+		{
+			name:  "fail photo not an image",
+			ctx:   mockUser(customerID, "user"),
+			input: request([]byte("plain text")),
+			mockFn: func(m *mocks) {
+				m.order.EXPECT().GetOrder(gomock.Any(), int64(orderID)).Return(&order.Order{ID: orderID, UserID: customerID}, nil).Times(1)
+			},
+			expectedErr: errs.ErrImageTypeInvalid,
+		},
+		{
+			name:  "fail order of another user",
+			ctx:   mockUser(3, "user"),
+			input: request(),
+			mockFn: func(m *mocks) {
+				m.order.EXPECT().GetOrder(gomock.Any(), int64(orderID)).Return(&order.Order{ID: orderID, UserID: customerID}, nil).Times(1)
+			},
+			expectedErr: errs.ErrNoPermissions,
+		},
+		{
+			name:        "fail unauthorized",
+			ctx:         context.Background(),
+			input:       request(),
+			mockFn:      func(m *mocks) {},
+			expectedErr: errs.ErrUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, m := setup(t)
+
+			tc.mockFn(m)
+
+			result, err := uc.RequestReturn(tc.ctx, tc.input)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, returns.StatusRequested, result.Status)
+			assert.Len(t, result.Items, 1)
+			assert.Len(t, result.Photos, len(tc.input.Photos))
+			for _, p := range result.Photos {
+				assert.Contains(t, p.URL, "http://localhost/uploads/returns/100/")
+			}
+		})
+	}
+}
+
+func TestUpdateStatus(t *testing.T) {
+	type testCase struct {
+		name        string
+		ctx         context.Context
+		input       *returns.StatusUpdate
+		mockFn      func(m *mocks)
+		expected    returns.Status
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name:  "success approve by the seller",
+			ctx:   mockUser(sellerID, "seller"),
+			input: &returns.StatusUpdate{ReturnID: 7, Status: returns.StatusApproved},
+			mockFn: func(m *mocks) {
+				m.returns.EXPECT().GetReturnForUpdate(gomock.Any(), gomock.Any(), int64(7)).Return(mockReturn(returns.StatusRequested), nil).Times(1)
+				m.returns.EXPECT().UpdateReturn(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ *sql.Tx, r *returns.Return) error {
+						assert.Equal(t, int64(sellerID), *r.ReviewedBy)
+						assert.NotNil(t, r.ReviewedAt)
+						return nil
+					},
+				).Times(1)
+			},
+			expected: returns.StatusApproved,
+		},
+		{
+			name:  "success receive restocks",
+			ctx:   mockUser(sellerID, "seller"),
+			input: &returns.StatusUpdate{ReturnID: 7, Status: returns.StatusReceived},
+			mockFn: func(m *mocks) {
+				m.returns.EXPECT().GetReturnForUpdate(gomock.Any(), gomock.Any(), int64(7)).Return(mockReturn(returns.StatusApproved), nil).Times(1)
+
+				// Back to the warehouse it was picked from
+				m.inventory.EXPECT().AdjustWarehouseStock(gomock.Any(), gomock.Any(), int64(3), int64(10), 1).Return(nil).Times(1)
+				m.product.EXPECT().IncreaseStock(gomock.Any(), gomock.Any(), int64(10), 1).Return(nil).Times(1)
+				m.inventory.EXPECT().InsertMovement(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ *sql.Tx, mv *inventory.StockMovement) error {
+						assert.Equal(t, inventory.ReasonReturn, mv.Reason)
+						assert.Equal(t, inventory.RefReturn, mv.RefType)
+						assert.Equal(t, int64(7), mv.RefID)
+						return nil
+					},
+				).Times(1)
+				m.inventory.EXPECT().InsertEvent(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+				m.returns.EXPECT().UpdateReturn(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+			expected: returns.StatusReceived,
+		},
+		{
+			name:  "success refund by admin",
+			ctx:   mockUser(1, "admin"),
+			input: &returns.StatusUpdate{ReturnID: 7, Status: returns.StatusRefunded},
+			mockFn: func(m *mocks) {
+				m.returns.EXPECT().GetReturnForUpdate(gomock.Any(), gomock.Any(), int64(7)).Return(mockReturn(returns.StatusInspected), nil).Times(1)
+				m.returns.EXPECT().UpdateReturn(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ *sql.Tx, r *returns.Return) error {
+						assert.Equal(t, int64(9), *r.RefundID)
+						return nil
+					},
+				).Times(1)
+			},
+			expected: returns.StatusRefunded,
+		},
+		{
+			name:        "fail refund by the seller",
+			ctx:         mockUser(sellerID, "seller"),
+			input:       &returns.StatusUpdate{ReturnID: 7, Status: returns.StatusRefunded},
+			mockFn:      func(m *mocks) {},
+			expectedErr: errs.ErrNoPermissions,
+		},
+		{
+			name:  "fail return of another seller",
+			ctx:   mockUser(30, "seller"),
+			input: &returns.StatusUpdate{ReturnID: 7, Status: returns.StatusApproved},
+			mockFn: func(m *mocks) {
+				m.returns.EXPECT().GetReturnForUpdate(gomock.Any(), gomock.Any(), int64(7)).Return(mockReturn(returns.StatusRequested), nil).Times(1)
+			},
+			expectedErr: errs.ErrNoPermissions,
+		},
+		{
+			name:  "fail receive before approved",
+			ctx:   mockUser(sellerID, "seller"),
+			input: &returns.StatusUpdate{ReturnID: 7, Status: returns.StatusReceived},
+			mockFn: func(m *mocks) {
+				m.returns.EXPECT().GetReturnForUpdate(gomock.Any(), gomock.Any(), int64(7)).Return(mockReturn(returns.StatusRequested), nil).Times(1)
+			},
+			expectedErr: errs.ErrReturnStatusChange,
+		},
+		{
+			name:  "fail not found",
+			ctx:   mockUser(1, "admin"),
+			input: &returns.StatusUpdate{ReturnID: 7, Status: returns.StatusApproved},
+			mockFn: func(m *mocks) {
+				m.returns.EXPECT().GetReturnForUpdate(gomock.Any(), gomock.Any(), int64(7)).Return(nil, errs.ErrReturnNotFound).Times(1)
+			},
+			expectedErr: errs.ErrReturnNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, m := setup(t)
+
+			tc.mockFn(m)
+
+			result, err := uc.UpdateStatus(tc.ctx, tc.input)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+				assert.Empty(t, m.refunds.requests)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, result.Status)
+			if tc.expected == returns.StatusRefunded {
+				assert.Len(t, m.refunds.requests, 1)
+				// Paid after the return is committed
+				assert.Equal(t, []int64{9}, m.refunds.paid)
+				assert.Equal(t, refund.ReasonDamaged, m.refunds.requests[0].Reason)
+				assert.False(t, m.refunds.requests[0].Restock)
+			}
+		})
+	}
+}
+
+// =============== Helper ===================
+// ------------------------------------------
+
+type mocks struct {
+	returns   *returnsrepository.MockReturnsRepository
+	order     *orderrepository.MockOrderRepository
+	product   *productrepository.MockProductRepository
+	inventory *inventoryrepository.MockInventoryRepository
+	refunds   *fakeRefunds
+}
+
+func setup(t *testing.T) (returnsusecase.ReturnsUsecase, *mocks) {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := &mocks{
+		returns:   returnsrepository.NewMockReturnsRepository(ctrl),
+		order:     orderrepository.NewMockOrderRepository(ctrl),
+		product:   productrepository.NewMockProductRepository(ctrl),
+		inventory: inventoryrepository.NewMockInventoryRepository(ctrl),
+		refunds:   &fakeRefunds{},
+	}
+	store, err := storage.NewLocalStore(t.TempDir(), "http://localhost/uploads")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uc, err := returnsusecase.NewReturnsUsecase(&returnsusecase.ReturnsUsecaseConfig{
+		Repo:          m.returns,
+		OrderRepo:     m.order,
+		ProductRepo:   m.product,
+		InventoryRepo: m.inventory,
+		Refunds:       m.refunds,
+		Store:         store,
+		Tx:            &mockTxManager{},
+	})
+	if err != nil {
+		t.Fatalf("init returns usecase failed: %v", err)
+	}
+	return uc, m
+}
+
+// mockLines one line per seller, completed days ago with a 30 days window
+func mockLines(days int) []*returns.Line {
+	completedAt := time.Now().AddDate(0, 0, -days)
+	return []*returns.Line{
+		{OrderItemID: 1, SubOrderID: 10, SellerID: sellerID, SubOrderStatus: "completed", CompletedAt: &completedAt, ProductID: 10, WindowDays: 30, Quantity: 2},
+		{OrderItemID: 2, SubOrderID: 11, SellerID: 30, SubOrderStatus: "completed", CompletedAt: &completedAt, ProductID: 20, WindowDays: 30, Quantity: 1},
+	}
+}
+
+func mockReturn(status returns.Status) *returns.Return {
+	return &returns.Return{
+		ID:         7,
+		OrderID:    orderID,
+		SubOrderID: 10,
+		SellerID:   sellerID,
+		UserID:     customerID,
+		Status:     status,
+		Reason:     returns.ReasonDamaged,
+		Items:      []*returns.Item{{ID: 1, ReturnID: 7, OrderItemID: 1, ProductID: 10, WarehouseID: 3, Quantity: 1}},
+	}
+}
+
+func mockUser(userID int64, role string) context.Context {
+	ctx := auth.SetUserID(context.Background(), userID)
+	return auth.SetCurrentUser(ctx, &jwt.UserClaims{ID: userID, Role: role})
+}
+
+// fakeRefunds records the refunds of returns, covered by the refund tests
+type fakeRefunds struct {
+	requests []*refund.Request
+	paid     []int64
+}
+
+func (f *fakeRefunds) CreateRefund(ctx context.Context, input *refund.Request) (*refund.Refund, error) {
+	return nil, errs.ErrNoPermissions
+}
+
+func (f *fakeRefunds) ListRefunds(ctx context.Context, orderID int64) ([]*refund.Refund, error) {
+	return nil, nil
+}
+
+func (f *fakeRefunds) CreateRefundTx(ctx context.Context, tx *sql.Tx, input *refund.Request, actorID int64) (*refund.Refund, error) {
+	f.requests = append(f.requests, input)
+	return &refund.Refund{ID: 9, OrderID: input.OrderID, Status: refund.StatusPending}, nil
+}
+
+func (f *fakeRefunds) PayRefund(ctx context.Context, r *refund.Refund) (*refund.Refund, error) {
+	f.paid = append(f.paid, r.ID)
+	r.Status = refund.StatusSettled
+	return r, nil
+}
+
+func (f *fakeRefunds) PayPendingRefunds(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+type mockTxManager struct{}
+
+func (m *mockTxManager) WithTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return fn(nil)
+}
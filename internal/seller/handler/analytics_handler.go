@@ -0,0 +1,65 @@
+package sellerhandler
+
+import (
+	"github.com/codepnw/mini-ecommerce/internal/seller"
+	sellerusecase "github.com/codepnw/mini-ecommerce/internal/seller/usecase"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+type analyticsHandler struct {
+	uc sellerusecase.AnalyticsUsecase
+}
+
+func NewAnalyticsHandler(uc sellerusecase.AnalyticsUsecase) *analyticsHandler {
+	return &analyticsHandler{uc: uc}
+}
+
+func (h *analyticsHandler) GetSalesReport(c *gin.Context) {
+	filter := new(seller.AnalyticsFilter)
+	if err := c.ShouldBindQuery(filter); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.GetSalesReport(c.Request.Context(), filter)
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrInvalidDateRange, errs.ErrAnalyticsRangeTooLarge:
+			response.BadRequest(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "", result)
+}
+
+func (h *analyticsHandler) GetTopProducts(c *gin.Context) {
+	filter := new(seller.AnalyticsFilter)
+	if err := c.ShouldBindQuery(filter); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.GetTopProducts(c.Request.Context(), filter)
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrInvalidDateRange:
+			response.BadRequest(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "", result)
+}
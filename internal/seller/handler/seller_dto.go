@@ -0,0 +1,17 @@
+package sellerhandler
+
+type ApplyReq struct {
+	StoreName   string `json:"store_name" binding:"required,max=100"`
+	StoreSlug   string `json:"store_slug" binding:"omitempty,max=120"`
+	Description string `json:"description" binding:"omitempty,max=2000"`
+}
+
+type UpdateStoreReq struct {
+	Name        *string `json:"name" binding:"omitempty,min=1,max=100"`
+	Slug        *string `json:"slug" binding:"omitempty,max=120"`
+	Description *string `json:"description" binding:"omitempty,max=2000"`
+}
+
+type RejectReq struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
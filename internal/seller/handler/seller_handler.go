@@ -0,0 +1,261 @@
+package sellerhandler
+
+import (
+	"io"
+
+	"github.com/codepnw/mini-ecommerce/internal/product"
+	"github.com/codepnw/mini-ecommerce/internal/seller"
+	sellerusecase "github.com/codepnw/mini-ecommerce/internal/seller/usecase"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/internal/utils/helper"
+	"github.com/codepnw/mini-ecommerce/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+type sellerHandler struct {
+	uc sellerusecase.SellerUsecase
+}
+
+func NewSellerHandler(uc sellerusecase.SellerUsecase) *sellerHandler {
+	return &sellerHandler{uc: uc}
+}
+
+func (h *sellerHandler) Apply(c *gin.Context) {
+	req := new(ApplyReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	input := &seller.Application{
+		StoreName:   req.StoreName,
+		StoreSlug:   req.StoreSlug,
+		Description: req.Description,
+	}
+
+	result, err := h.uc.Apply(c.Request.Context(), input)
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrStoreSlugInvalid:
+			response.BadRequest(c, err.Error())
+			return
+		case errs.ErrAlreadySeller, errs.ErrApplicationPending, errs.ErrStoreSlugExists:
+			response.Conflict(c, err.Error(), nil)
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.Created(c, result)
+}
+
+func (h *sellerHandler) GetMyApplication(c *gin.Context) {
+	result, err := h.uc.GetMyApplication(c.Request.Context())
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrApplicationNotFound:
+			response.NotFound(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "", result)
+}
+
+func (h *sellerHandler) GetMyStore(c *gin.Context) {
+	result, err := h.uc.GetMyStore(c.Request.Context())
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrStoreNotFound:
+			response.NotFound(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "", result)
+}
+
+func (h *sellerHandler) UpdateMyStore(c *gin.Context) {
+	req := new(UpdateStoreReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	input := &seller.StoreUpdate{
+		Name:        req.Name,
+		Slug:        req.Slug,
+		Description: req.Description,
+	}
+
+	result, err := h.uc.UpdateMyStore(c.Request.Context(), input)
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrNoFieldsToUpdate, errs.ErrStoreSlugInvalid:
+			response.BadRequest(c, err.Error())
+			return
+		case errs.ErrStoreNotFound:
+			response.NotFound(c, err.Error())
+			return
+		case errs.ErrStoreSlugExists:
+			response.Conflict(c, err.Error(), nil)
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "store updated", result)
+}
+
+func (h *sellerHandler) UploadLogo(c *gin.Context) {
+	fileHeader, err := c.FormFile(consts.FormImageKey)
+	if err != nil {
+		response.BadRequest(c, errs.ErrImageRequired.Error())
+		return
+	}
+	if fileHeader.Size > consts.MaxImageSize {
+		response.BadRequest(c, errs.ErrImageTooLarge.Error())
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		response.InternalServerError(c, err)
+		return
+	}
+	defer file.Close()
+
+	// Read one byte over the limit, so usecase can reject oversize files
+	data, err := io.ReadAll(io.LimitReader(file, consts.MaxImageSize+1))
+	if err != nil {
+		response.InternalServerError(c, err)
+		return
+	}
+
+	result, err := h.uc.UploadLogo(c.Request.Context(), data)
+	if err != nil {
+		switch err {
+		case errs.ErrUnauthorized:
+			response.Unauthorized(c, err.Error())
+			return
+		case errs.ErrImageRequired, errs.ErrImageTooLarge, errs.ErrImageTypeInvalid:
+			response.BadRequest(c, err.Error())
+			return
+		case errs.ErrStoreNotFound:
+			response.NotFound(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "logo uploaded", result)
+}
+
+func (h *sellerHandler) GetStorePage(c *gin.Context) {
+	filter := new(product.ProductFilter)
+	if err := c.ShouldBindQuery(filter); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.GetStorePage(c.Request.Context(), c.Param(consts.ParamSlug), filter)
+	if err != nil {
+		switch err {
+		case errs.ErrStoreNotFound:
+			response.NotFound(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "", result)
+}
+
+func (h *sellerHandler) ListApplications(c *gin.Context) {
+	filter := new(seller.ApplicationFilter)
+	if err := c.ShouldBindQuery(filter); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.uc.ListApplications(c.Request.Context(), filter)
+	if err != nil {
+		switch err {
+		case errs.ErrNoPermissions:
+			response.Forbidden(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "", result)
+}
+
+func (h *sellerHandler) ApproveApplication(c *gin.Context) {
+	applicationID, err := helper.GetParamInt(c, consts.ParamApplicationID)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	h.review(c, &seller.Review{ApplicationID: applicationID, Approve: true})
+}
+
+func (h *sellerHandler) RejectApplication(c *gin.Context) {
+	applicationID, err := helper.GetParamInt(c, consts.ParamApplicationID)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	req := new(RejectReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	h.review(c, &seller.Review{ApplicationID: applicationID, Reason: req.Reason})
+}
+
+func (h *sellerHandler) review(c *gin.Context, input *seller.Review) {
+	result, err := h.uc.ReviewApplication(c.Request.Context(), input)
+	if err != nil {
+		switch err {
+		case errs.ErrNoPermissions:
+			response.Forbidden(c, err.Error())
+			return
+		case errs.ErrApplicationNotFound, errs.ErrUserNotFound:
+			response.NotFound(c, err.Error())
+			return
+		case errs.ErrApplicationReviewed, errs.ErrAlreadySeller, errs.ErrStoreSlugExists:
+			response.Conflict(c, err.Error(), nil)
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "seller application "+string(result.Status), result)
+}
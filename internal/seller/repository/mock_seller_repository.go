@@ -0,0 +1,270 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: seller_repository.go
+
+// Package sellerrepository is a generated GoMock package.
+package sellerrepository
+
+import (
+	context "context"
+	sql "database/sql"
+	reflect "reflect"
+
+	seller "github.com/codepnw/mini-ecommerce/internal/seller"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockSellerRepository is a mock of SellerRepository interface.
+type MockSellerRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSellerRepositoryMockRecorder
+}
+
+// MockSellerRepositoryMockRecorder is the mock recorder for MockSellerRepository.
+type MockSellerRepositoryMockRecorder struct {
+	mock *MockSellerRepository
+}
+
+// NewMockSellerRepository creates a new mock instance.
+func NewMockSellerRepository(ctrl *gomock.Controller) *MockSellerRepository {
+	mock := &MockSellerRepository{ctrl: ctrl}
+	mock.recorder = &MockSellerRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSellerRepository) EXPECT() *MockSellerRepositoryMockRecorder {
+	return m.recorder
+}
+
+// FindStoreBySeller mocks base method.
+func (m *MockSellerRepository) FindStoreBySeller(ctx context.Context, sellerID int64) (*seller.Store, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindStoreBySeller", ctx, sellerID)
+	ret0, _ := ret[0].(*seller.Store)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindStoreBySeller indicates an expected call of FindStoreBySeller.
+func (mr *MockSellerRepositoryMockRecorder) FindStoreBySeller(ctx, sellerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindStoreBySeller", reflect.TypeOf((*MockSellerRepository)(nil).FindStoreBySeller), ctx, sellerID)
+}
+
+// FindStoreBySlug mocks base method.
+func (m *MockSellerRepository) FindStoreBySlug(ctx context.Context, slug string) (*seller.Store, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindStoreBySlug", ctx, slug)
+	ret0, _ := ret[0].(*seller.Store)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindStoreBySlug indicates an expected call of FindStoreBySlug.
+func (mr *MockSellerRepositoryMockRecorder) FindStoreBySlug(ctx, slug interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindStoreBySlug", reflect.TypeOf((*MockSellerRepository)(nil).FindStoreBySlug), ctx, slug)
+}
+
+// GetApplicationForUpdate mocks base method.
+func (m *MockSellerRepository) GetApplicationForUpdate(ctx context.Context, tx *sql.Tx, id int64) (*seller.Application, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetApplicationForUpdate", ctx, tx, id)
+	ret0, _ := ret[0].(*seller.Application)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetApplicationForUpdate indicates an expected call of GetApplicationForUpdate.
+func (mr *MockSellerRepositoryMockRecorder) GetApplicationForUpdate(ctx, tx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApplicationForUpdate", reflect.TypeOf((*MockSellerRepository)(nil).GetApplicationForUpdate), ctx, tx, id)
+}
+
+// InsertApplication mocks base method.
+func (m *MockSellerRepository) InsertApplication(ctx context.Context, input *seller.Application) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertApplication", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertApplication indicates an expected call of InsertApplication.
+func (mr *MockSellerRepositoryMockRecorder) InsertApplication(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertApplication", reflect.TypeOf((*MockSellerRepository)(nil).InsertApplication), ctx, input)
+}
+
+// InsertStore mocks base method.
+func (m *MockSellerRepository) InsertStore(ctx context.Context, tx *sql.Tx, input *seller.Store) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertStore", ctx, tx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertStore indicates an expected call of InsertStore.
+func (mr *MockSellerRepositoryMockRecorder) InsertStore(ctx, tx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertStore", reflect.TypeOf((*MockSellerRepository)(nil).InsertStore), ctx, tx, input)
+}
+
+// LatestApplication mocks base method.
+func (m *MockSellerRepository) LatestApplication(ctx context.Context, userID int64) (*seller.Application, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LatestApplication", ctx, userID)
+	ret0, _ := ret[0].(*seller.Application)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LatestApplication indicates an expected call of LatestApplication.
+func (mr *MockSellerRepositoryMockRecorder) LatestApplication(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LatestApplication", reflect.TypeOf((*MockSellerRepository)(nil).LatestApplication), ctx, userID)
+}
+
+// ListApplications mocks base method.
+func (m *MockSellerRepository) ListApplications(ctx context.Context, filter *seller.ApplicationFilter) ([]*seller.Application, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListApplications", ctx, filter)
+	ret0, _ := ret[0].([]*seller.Application)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListApplications indicates an expected call of ListApplications.
+func (mr *MockSellerRepositoryMockRecorder) ListApplications(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListApplications", reflect.TypeOf((*MockSellerRepository)(nil).ListApplications), ctx, filter)
+}
+
+// SalesSeries mocks base method.
+func (m *MockSellerRepository) SalesSeries(ctx context.Context, filter *seller.AnalyticsFilter) ([]*seller.SalesPoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SalesSeries", ctx, filter)
+	ret0, _ := ret[0].([]*seller.SalesPoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SalesSeries indicates an expected call of SalesSeries.
+func (mr *MockSellerRepositoryMockRecorder) SalesSeries(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SalesSeries", reflect.TypeOf((*MockSellerRepository)(nil).SalesSeries), ctx, filter)
+}
+
+// StoreSlugExists mocks base method.
+func (m *MockSellerRepository) StoreSlugExists(ctx context.Context, slug string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreSlugExists", ctx, slug)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StoreSlugExists indicates an expected call of StoreSlugExists.
+func (mr *MockSellerRepositoryMockRecorder) StoreSlugExists(ctx, slug interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreSlugExists", reflect.TypeOf((*MockSellerRepository)(nil).StoreSlugExists), ctx, slug)
+}
+
+// TopProducts mocks base method.
+func (m *MockSellerRepository) TopProducts(ctx context.Context, filter *seller.AnalyticsFilter) ([]*seller.TopProduct, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TopProducts", ctx, filter)
+	ret0, _ := ret[0].([]*seller.TopProduct)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TopProducts indicates an expected call of TopProducts.
+func (mr *MockSellerRepositoryMockRecorder) TopProducts(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TopProducts", reflect.TypeOf((*MockSellerRepository)(nil).TopProducts), ctx, filter)
+}
+
+// UpdateApplication mocks base method.
+func (m *MockSellerRepository) UpdateApplication(ctx context.Context, tx *sql.Tx, input *seller.Application) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateApplication", ctx, tx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateApplication indicates an expected call of UpdateApplication.
+func (mr *MockSellerRepositoryMockRecorder) UpdateApplication(ctx, tx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateApplication", reflect.TypeOf((*MockSellerRepository)(nil).UpdateApplication), ctx, tx, input)
+}
+
+// UpdateStore mocks base method.
+func (m *MockSellerRepository) UpdateStore(ctx context.Context, sellerID int64, input *seller.StoreUpdate) (*seller.Store, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStore", ctx, sellerID, input)
+	ret0, _ := ret[0].(*seller.Store)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateStore indicates an expected call of UpdateStore.
+func (mr *MockSellerRepositoryMockRecorder) UpdateStore(ctx, sellerID, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStore", reflect.TypeOf((*MockSellerRepository)(nil).UpdateStore), ctx, sellerID, input)
+}
+
+// UpdateStoreLogo mocks base method.
+func (m *MockSellerRepository) UpdateStoreLogo(ctx context.Context, sellerID int64, logoKey string) (*seller.Store, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStoreLogo", ctx, sellerID, logoKey)
+	ret0, _ := ret[0].(*seller.Store)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateStoreLogo indicates an expected call of UpdateStoreLogo.
+func (mr *MockSellerRepositoryMockRecorder) UpdateStoreLogo(ctx, sellerID, logoKey interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStoreLogo", reflect.TypeOf((*MockSellerRepository)(nil).UpdateStoreLogo), ctx, sellerID, logoKey)
+}
+
+// MockrowScanner is a mock of rowScanner interface.
+type MockrowScanner struct {
+	ctrl     *gomock.Controller
+	recorder *MockrowScannerMockRecorder
+}
+
+// MockrowScannerMockRecorder is the mock recorder for MockrowScanner.
+type MockrowScannerMockRecorder struct {
+	mock *MockrowScanner
+}
+
+// NewMockrowScanner creates a new mock instance.
+func NewMockrowScanner(ctrl *gomock.Controller) *MockrowScanner {
+	mock := &MockrowScanner{ctrl: ctrl}
+	mock.recorder = &MockrowScannerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockrowScanner) EXPECT() *MockrowScannerMockRecorder {
+	return m.recorder
+}
+
+// Scan mocks base method.
+func (m *MockrowScanner) Scan(dest ...any) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range dest {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Scan", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Scan indicates an expected call of Scan.
+func (mr *MockrowScannerMockRecorder) Scan(dest ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockrowScanner)(nil).Scan), dest...)
+}
@@ -0,0 +1,414 @@
+package sellerrepository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/codepnw/mini-ecommerce/internal/seller"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/lib/pq"
+)
+
+//go:generate mockgen -source=seller_repository.go -destination=mock_seller_repository.go -package=sellerrepository
+
+type SellerRepository interface {
+	// Applications
+	InsertApplication(ctx context.Context, input *seller.Application) error
+	LatestApplication(ctx context.Context, userID int64) (*seller.Application, error)
+	ListApplications(ctx context.Context, filter *seller.ApplicationFilter) ([]*seller.Application, error)
+
+	// Stores
+	FindStoreBySlug(ctx context.Context, slug string) (*seller.Store, error)
+	FindStoreBySeller(ctx context.Context, sellerID int64) (*seller.Store, error)
+	StoreSlugExists(ctx context.Context, slug string) (bool, error)
+	UpdateStore(ctx context.Context, sellerID int64, input *seller.StoreUpdate) (*seller.Store, error)
+	UpdateStoreLogo(ctx context.Context, sellerID int64, logoKey string) (*seller.Store, error)
+
+	// Analytics
+	SalesSeries(ctx context.Context, filter *seller.AnalyticsFilter) ([]*seller.SalesPoint, error)
+	TopProducts(ctx context.Context, filter *seller.AnalyticsFilter) ([]*seller.TopProduct, error)
+
+	// Transaction
+	GetApplicationForUpdate(ctx context.Context, tx *sql.Tx, id int64) (*seller.Application, error)
+	UpdateApplication(ctx context.Context, tx *sql.Tx, input *seller.Application) error
+	InsertStore(ctx context.Context, tx *sql.Tx, input *seller.Store) error
+}
+
+type sellerRepository struct {
+	db *sql.DB
+}
+
+func NewSellerRepository(db *sql.DB) SellerRepository {
+	return &sellerRepository{db: db}
+}
+
+const applicationColumns = `
+	id, user_id, store_name, store_slug, description, status, reject_reason,
+	reviewed_by, reviewed_at, created_at, updated_at
+`
+
+const storeColumns = `id, seller_id, name, slug, description, logo_key, created_at, updated_at`
+
+func (r *sellerRepository) InsertApplication(ctx context.Context, input *seller.Application) error {
+	query := `
+		INSERT INTO seller_applications (user_id, store_name, store_slug, description)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, status, created_at, updated_at
+	`
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		input.UserID,
+		input.StoreName,
+		input.StoreSlug,
+		sql.NullString{String: input.Description, Valid: input.Description != ""},
+	).Scan(&input.ID, &input.Status, &input.CreatedAt, &input.UpdatedAt)
+	if err != nil {
+		// One pending application per user
+		if strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
+			return errs.ErrApplicationPending
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *sellerRepository) LatestApplication(ctx context.Context, userID int64) (*seller.Application, error) {
+	query := fmt.Sprintf(`
+		SELECT %s FROM seller_applications
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1
+	`, applicationColumns)
+	a, err := scanApplication(r.db.QueryRowContext(ctx, query, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrApplicationNotFound
+		}
+		return nil, err
+	}
+	return a, nil
+}
+
+func (r *sellerRepository) ListApplications(ctx context.Context, filter *seller.ApplicationFilter) ([]*seller.Application, error) {
+	query := fmt.Sprintf(`SELECT %s FROM seller_applications WHERE 1=1`, applicationColumns)
+	var args []any
+	idx := 1
+
+	if filter.Status != "" {
+		query += fmt.Sprintf(" AND status = $%d", idx)
+		args = append(args, filter.Status)
+		idx++
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+
+	// Oldest first, the review queue
+	query += fmt.Sprintf(" ORDER BY created_at, id LIMIT $%d OFFSET $%d", idx, idx+1)
+	args = append(args, filter.Limit, offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applications := make([]*seller.Application, 0)
+	for rows.Next() {
+		a, err := scanApplication(rows)
+		if err != nil {
+			return nil, err
+		}
+		applications = append(applications, a)
+	}
+	return applications, rows.Err()
+}
+
+func (r *sellerRepository) GetApplicationForUpdate(ctx context.Context, tx *sql.Tx, id int64) (*seller.Application, error) {
+	query := fmt.Sprintf(`SELECT %s FROM seller_applications WHERE id = $1 FOR UPDATE`, applicationColumns)
+	a, err := scanApplication(tx.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrApplicationNotFound
+		}
+		return nil, err
+	}
+	return a, nil
+}
+
+func (r *sellerRepository) UpdateApplication(ctx context.Context, tx *sql.Tx, input *seller.Application) error {
+	query := `
+		UPDATE seller_applications
+		SET status = $1, reject_reason = $2, reviewed_by = $3, reviewed_at = $4, updated_at = NOW()
+		WHERE id = $5
+		RETURNING updated_at
+	`
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		input.Status,
+		sql.NullString{String: input.RejectReason, Valid: input.RejectReason != ""},
+		input.ReviewedBy,
+		input.ReviewedAt,
+		input.ID,
+	).Scan(&input.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errs.ErrApplicationNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *sellerRepository) InsertStore(ctx context.Context, tx *sql.Tx, input *seller.Store) error {
+	query := `
+		INSERT INTO stores (seller_id, name, slug, description)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		input.SellerID,
+		input.Name,
+		input.Slug,
+		sql.NullString{String: input.Description, Valid: input.Description != ""},
+	).Scan(&input.ID, &input.CreatedAt, &input.UpdatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
+			if strings.Contains(err.Error(), "seller_id") {
+				return errs.ErrAlreadySeller
+			}
+			return errs.ErrStoreSlugExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *sellerRepository) FindStoreBySlug(ctx context.Context, slug string) (*seller.Store, error) {
+	query := fmt.Sprintf(`SELECT %s FROM stores WHERE slug = $1`, storeColumns)
+	return r.findStore(ctx, query, slug)
+}
+
+func (r *sellerRepository) FindStoreBySeller(ctx context.Context, sellerID int64) (*seller.Store, error) {
+	query := fmt.Sprintf(`SELECT %s FROM stores WHERE seller_id = $1`, storeColumns)
+	return r.findStore(ctx, query, sellerID)
+}
+
+func (r *sellerRepository) findStore(ctx context.Context, query string, arg any) (*seller.Store, error) {
+	s, err := scanStore(r.db.QueryRowContext(ctx, query, arg))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrStoreNotFound
+		}
+		return nil, err
+	}
+	return s, nil
+}
+
+func (r *sellerRepository) StoreSlugExists(ctx context.Context, slug string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM stores WHERE slug = $1)`
+	if err := r.db.QueryRowContext(ctx, query, slug).Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+func (r *sellerRepository) UpdateStore(ctx context.Context, sellerID int64, input *seller.StoreUpdate) (*seller.Store, error) {
+	query := fmt.Sprintf(`
+		UPDATE stores
+		SET name = COALESCE($1, name),
+			slug = COALESCE($2, slug),
+			description = COALESCE($3, description),
+			updated_at = NOW()
+		WHERE seller_id = $4
+		RETURNING %s
+	`, storeColumns)
+	s, err := scanStore(r.db.QueryRowContext(ctx, query, input.Name, input.Slug, input.Description, sellerID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrStoreNotFound
+		}
+		if strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
+			return nil, errs.ErrStoreSlugExists
+		}
+		return nil, err
+	}
+	return s, nil
+}
+
+func (r *sellerRepository) UpdateStoreLogo(ctx context.Context, sellerID int64, logoKey string) (*seller.Store, error) {
+	query := fmt.Sprintf(`
+		UPDATE stores SET logo_key = $1, updated_at = NOW()
+		WHERE seller_id = $2
+		RETURNING %s
+	`, storeColumns)
+	s, err := scanStore(r.db.QueryRowContext(ctx, query, logoKey, sellerID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrStoreNotFound
+		}
+		return nil, err
+	}
+	return s, nil
+}
+
+// sellerLines the seller's order lines placed in [$2, $3), the status is
+// the seller's sub-order status, lines from before the split fall back to
+// the order status. Refunded units are left out. Starts from
+// idx_products_owner_id and idx_order_items_product_order.
+const sellerLines = `
+	SELECT o.id AS order_id, o.created_at, oi.product_id,
+		oi.quantity - oi.refunded_quantity AS quantity,
+		oi.price * (oi.quantity - oi.refunded_quantity) AS amount,
+		COALESCE(so.status, o.status) AS status
+	FROM products p
+	INNER JOIN order_items oi ON oi.product_id = p.id
+	INNER JOIN orders o ON o.id = oi.order_id
+	LEFT JOIN sub_orders so ON so.id = oi.sub_order_id
+	WHERE p.owner_id = $1 AND o.created_at >= $2 AND o.created_at < $3
+`
+
+// soldStatuses paid orders, pending orders are not sales yet
+var soldStatuses = pq.StringArray{"paid", "partially_shipped", "shipped", "completed"}
+
+// SalesSeries one row per bucket from From to To, buckets without sales
+// are zero
+func (r *sellerRepository) SalesSeries(ctx context.Context, filter *seller.AnalyticsFilter) ([]*seller.SalesPoint, error) {
+	query := fmt.Sprintf(`
+		WITH lines AS (%s),
+		buckets AS (
+			SELECT generate_series(
+				date_trunc($4::text, $2::timestamptz),
+				$3::timestamptz - INTERVAL '1 microsecond',
+				('1 ' || $4::text)::interval
+			) AS bucket
+		)
+		SELECT b.bucket,
+			COALESCE(SUM(l.amount) FILTER (WHERE l.status = ANY($5)), 0),
+			COALESCE(SUM(l.quantity) FILTER (WHERE l.status = ANY($5)), 0),
+			COUNT(DISTINCT l.order_id) FILTER (WHERE l.status = ANY($5)),
+			COUNT(DISTINCT l.order_id) FILTER (WHERE l.status = 'cancelled')
+		FROM buckets b
+		LEFT JOIN lines l ON date_trunc($4::text, l.created_at) = b.bucket
+		GROUP BY b.bucket
+		ORDER BY b.bucket
+	`, sellerLines)
+
+	rows, err := r.db.QueryContext(ctx, query, filter.SellerID, filter.From, filter.To, string(filter.Bucket), soldStatuses)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	series := make([]*seller.SalesPoint, 0)
+	for rows.Next() {
+		p := new(seller.SalesPoint)
+		if err := rows.Scan(&p.Bucket, &p.Revenue, &p.Units, &p.Orders, &p.CancelledOrders); err != nil {
+			return nil, err
+		}
+		series = append(series, p)
+	}
+	return series, rows.Err()
+}
+
+// TopProducts by revenue of sold lines, ties go to the most units
+func (r *sellerRepository) TopProducts(ctx context.Context, filter *seller.AnalyticsFilter) ([]*seller.TopProduct, error) {
+	query := fmt.Sprintf(`
+		WITH lines AS (%s)
+		SELECT l.product_id, p.name, SUM(l.quantity), SUM(l.amount), COUNT(DISTINCT l.order_id)
+		FROM lines l
+		INNER JOIN products p ON p.id = l.product_id
+		WHERE l.status = ANY($4)
+		GROUP BY l.product_id, p.name
+		ORDER BY SUM(l.amount) DESC, SUM(l.quantity) DESC, l.product_id
+		LIMIT $5
+	`, sellerLines)
+
+	rows, err := r.db.QueryContext(ctx, query, filter.SellerID, filter.From, filter.To, soldStatuses, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make([]*seller.TopProduct, 0)
+	for rows.Next() {
+		p := new(seller.TopProduct)
+		if err := rows.Scan(&p.ProductID, &p.Name, &p.Units, &p.Revenue, &p.Orders); err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+	return products, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanApplication(row rowScanner) (*seller.Application, error) {
+	var (
+		a            = new(seller.Application)
+		description  sql.NullString
+		rejectReason sql.NullString
+		reviewedBy   sql.NullInt64
+		reviewedAt   sql.NullTime
+	)
+	err := row.Scan(
+		&a.ID,
+		&a.UserID,
+		&a.StoreName,
+		&a.StoreSlug,
+		&description,
+		&a.Status,
+		&rejectReason,
+		&reviewedBy,
+		&reviewedAt,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	a.Description = description.String
+	a.RejectReason = rejectReason.String
+	if reviewedBy.Valid {
+		a.ReviewedBy = &reviewedBy.Int64
+	}
+	if reviewedAt.Valid {
+		a.ReviewedAt = &reviewedAt.Time
+	}
+	return a, nil
+}
+
+func scanStore(row rowScanner) (*seller.Store, error) {
+	var (
+		s           = new(seller.Store)
+		description sql.NullString
+		logoKey     sql.NullString
+	)
+	err := row.Scan(
+		&s.ID,
+		&s.SellerID,
+		&s.Name,
+		&s.Slug,
+		&description,
+		&logoKey,
+		&s.CreatedAt,
+		&s.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Description = description.String
+	s.LogoKey = logoKey.String
+	return s, nil
+}
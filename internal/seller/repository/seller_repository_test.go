@@ -0,0 +1,224 @@
+package sellerrepository_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/codepnw/mini-ecommerce/internal/seller"
+	sellerrepository "github.com/codepnw/mini-ecommerce/internal/seller/repository"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+// Seeded products (scripts/02_seed.sql), 1 & 2 belong to the admin
+const (
+	sellerID  = 3
+	keyboard  = 3 // 2500
+	mouse     = 4 // 1200
+	monitor   = 5 // 8900
+	adminItem = 1
+)
+
+func TestSalesSeries(t *testing.T) {
+	repo, db := setupSeededDB(t)
+	seedOrders(t, db)
+
+	type testCase struct {
+		name     string
+		bucket   seller.Bucket
+		expected []*seller.SalesPoint
+	}
+
+	testCases := []testCase{
+		{
+			name:   "by month",
+			bucket: seller.BucketMonth,
+			expected: []*seller.SalesPoint{
+				{Bucket: day(2026, 3, 1), Revenue: 6200, Units: 3, Orders: 2, CancelledOrders: 2},
+			},
+		},
+		{
+			name:   "by week from monday",
+			bucket: seller.BucketWeek,
+			expected: []*seller.SalesPoint{
+				{Bucket: day(2026, 2, 23)},
+				{Bucket: day(2026, 3, 2), Revenue: 6200, Units: 3, Orders: 2},
+				{Bucket: day(2026, 3, 9), CancelledOrders: 2},
+				{Bucket: day(2026, 3, 16)},
+				{Bucket: day(2026, 3, 23)},
+				{Bucket: day(2026, 3, 30)},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			series, err := repo.SalesSeries(context.Background(), marchFilter(tc.bucket))
+
+			assert.NoError(t, err)
+			if assert.Len(t, series, len(tc.expected)) {
+				for i, p := range series {
+					assert.True(t, tc.expected[i].Bucket.Equal(p.Bucket), "bucket %d: %s", i, p.Bucket)
+					p.Bucket = tc.expected[i].Bucket
+					assert.Equal(t, tc.expected[i], p)
+				}
+			}
+		})
+	}
+
+	t.Run("by day has every day", func(t *testing.T) {
+		filter := marchFilter(seller.BucketDay)
+		series, err := repo.SalesSeries(context.Background(), filter)
+
+		assert.NoError(t, err)
+		assert.Len(t, series, 31)
+		assert.LessOrEqual(t, len(series), filter.BucketCount())
+
+		report := seller.NewSalesReport(filter, series)
+		assert.Equal(t, 6200.0, report.Summary.Revenue)
+		assert.Equal(t, 3100.0, report.Summary.AverageOrderValue)
+		assert.Equal(t, 0.5, report.Summary.CancellationRate)
+	})
+}
+
+func TestTopProducts(t *testing.T) {
+	repo, db := setupSeededDB(t)
+	seedOrders(t, db)
+
+	filter := marchFilter(seller.BucketDay)
+	filter.Limit = 10
+
+	result, err := repo.TopProducts(context.Background(), filter)
+
+	assert.NoError(t, err)
+	// Cancelled monitor & the admin's product are left out
+	assert.Equal(t, []*seller.TopProduct{
+		{ProductID: keyboard, Name: "Mechanical Keyboard", Units: 2, Revenue: 5000, Orders: 1},
+		{ProductID: mouse, Name: "Gaming Mouse", Units: 1, Revenue: 1200, Orders: 1},
+	}, result)
+}
+
+// =============== Helper ===================
+// ------------------------------------------
+
+// setupSeededDB resets the schema of TEST_DATABASE_URL and loads the
+// scripts, never point it to a database you want to keep
+func setupSeededDB(t *testing.T) (sellerrepository.SellerRepository, *sql.DB) {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	// One connection, the session time zone sets the buckets
+	db.SetMaxOpenConns(1)
+
+	for _, stmt := range []string{
+		"SET TIME ZONE 'UTC'",
+		"DROP SCHEMA public CASCADE",
+		"CREATE SCHEMA public",
+		readScript(t, "01_schema.sql"),
+		readScript(t, "02_seed.sql"),
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("setup database: %v", err)
+		}
+	}
+	return sellerrepository.NewSellerRepository(db), db
+}
+
+func readScript(t *testing.T, name string) string {
+	t.Helper()
+
+	data, err := os.ReadFile("../../../scripts/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+type orderLine struct {
+	productID int64
+	quantity  int
+	price     float64
+}
+
+// seedOrders March 2026 for the seller, plus orders outside the range
+func seedOrders(t *testing.T, db *sql.DB) {
+	t.Helper()
+
+	// Sold
+	insertOrder(t, db, "paid", "2026-03-02 10:00", "", orderLine{keyboard, 2, 2500}, orderLine{adminItem, 1, 41900})
+	insertOrder(t, db, "completed", "2026-03-02 15:00", "", orderLine{mouse, 1, 1200})
+	// Cancelled, by the order & by the seller's sub-order
+	insertOrder(t, db, "cancelled", "2026-03-10 09:00", "", orderLine{monitor, 1, 8900})
+	insertOrder(t, db, "paid", "2026-03-12 09:00", "cancelled", orderLine{monitor, 1, 8900})
+	// Not paid
+	insertOrder(t, db, "pending", "2026-03-11 09:00", "", orderLine{keyboard, 1, 2500})
+	// Outside the range
+	insertOrder(t, db, "shipped", "2026-04-01 00:00", "", orderLine{monitor, 1, 8900})
+	insertOrder(t, db, "shipped", "2026-02-28 23:59", "", orderLine{monitor, 1, 8900})
+}
+
+// insertOrder subStatus adds a sub-order of the seller for the lines
+func insertOrder(t *testing.T, db *sql.DB, status, placedAt, subStatus string, lines ...orderLine) {
+	t.Helper()
+
+	var total float64
+	for _, l := range lines {
+		total += l.price * float64(l.quantity)
+	}
+
+	var orderID int64
+	err := db.QueryRow(
+		`INSERT INTO orders (user_id, total, status, created_at) VALUES (2, $1, $2, $3) RETURNING id`,
+		total, status, placedAt,
+	).Scan(&orderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var subOrderID sql.NullInt64
+	if subStatus != "" {
+		err = db.QueryRow(
+			`INSERT INTO sub_orders (order_id, seller_id, total, status) VALUES ($1, $2, $3, $4) RETURNING id`,
+			orderID, sellerID, total, subStatus,
+		).Scan(&subOrderID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, l := range lines {
+		_, err := db.Exec(
+			`INSERT INTO order_items (order_id, sub_order_id, product_id, quantity, price) VALUES ($1, $2, $3, $4, $5)`,
+			orderID, subOrderID, l.productID, l.quantity, l.price,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// marchFilter usecase form of from=2026-03-01&to=2026-03-31
+func marchFilter(bucket seller.Bucket) *seller.AnalyticsFilter {
+	return &seller.AnalyticsFilter{
+		SellerID: sellerID,
+		From:     day(2026, 3, 1),
+		To:       day(2026, 4, 1),
+		Bucket:   bucket,
+	}
+}
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
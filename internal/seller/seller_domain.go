@@ -0,0 +1,166 @@
+package seller
+
+import (
+	"math"
+	"time"
+
+	"github.com/codepnw/mini-ecommerce/internal/product"
+)
+
+type ApplicationStatus string
+
+const (
+	ApplicationPending  ApplicationStatus = "pending"
+	ApplicationApproved ApplicationStatus = "approved"
+	ApplicationRejected ApplicationStatus = "rejected"
+)
+
+// Application a user asking to sell, approving it creates the store
+// and upgrades the user role to seller
+type Application struct {
+	ID           int64             `json:"id"`
+	UserID       int64             `json:"user_id"`
+	StoreName    string            `json:"store_name"`
+	StoreSlug    string            `json:"store_slug"`
+	Description  string            `json:"description,omitempty"`
+	Status       ApplicationStatus `json:"status"`
+	RejectReason string            `json:"reject_reason,omitempty"`
+	ReviewedBy   *int64            `json:"reviewed_by,omitempty"`
+	ReviewedAt   *time.Time        `json:"reviewed_at,omitempty"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+// Review admin decision on a pending application
+type Review struct {
+	ApplicationID int64
+	Approve       bool
+	Reason        string
+}
+
+type ApplicationFilter struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending approved rejected"`
+	Page   int    `form:"page"`
+	Limit  int    `form:"limit"`
+}
+
+type Store struct {
+	ID          int64     `json:"id"`
+	SellerID    int64     `json:"seller_id"`
+	Name        string    `json:"name"`
+	Slug        string    `json:"slug"`
+	Description string    `json:"description,omitempty"`
+	LogoKey     string    `json:"-"`
+	LogoURL     string    `json:"logo_url,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// StoreUpdate nil fields are left unchanged
+type StoreUpdate struct {
+	Name        *string
+	Slug        *string
+	Description *string
+}
+
+// StorePage GET /stores/:slug, only published products are listed
+type StorePage struct {
+	Store    *Store             `json:"store"`
+	Products []*product.Product `json:"products"`
+}
+
+type Bucket string
+
+const (
+	BucketDay   Bucket = "day"
+	BucketWeek  Bucket = "week"
+	BucketMonth Bucket = "month"
+)
+
+// AnalyticsFilter To is inclusive, Limit is for top products
+type AnalyticsFilter struct {
+	From   time.Time `form:"from" time_format:"2006-01-02"`
+	To     time.Time `form:"to" time_format:"2006-01-02"`
+	Bucket Bucket    `form:"bucket" binding:"omitempty,oneof=day week month"`
+	Limit  int       `form:"limit"`
+
+	SellerID int64 `form:"-"`
+}
+
+// BucketCount upper bound of the buckets in [From, To), the first and
+// last bucket may be partial
+func (f *AnalyticsFilter) BucketCount() int {
+	last := f.To.Add(-time.Nanosecond)
+	days := int(math.Ceil(f.To.Sub(f.From).Hours() / 24))
+
+	switch f.Bucket {
+	case BucketMonth:
+		return (last.Year()-f.From.Year())*12 + int(last.Month()-f.From.Month()) + 1
+	case BucketWeek:
+		return days/7 + 2
+	}
+	return days
+}
+
+// SalesPoint one bucket of the seller's lines, orders are counted by the
+// time they were placed
+type SalesPoint struct {
+	Bucket          time.Time `json:"bucket"`
+	Revenue         float64   `json:"revenue"`
+	Units           int       `json:"units"`
+	Orders          int       `json:"orders"`
+	CancelledOrders int       `json:"cancelled_orders"`
+}
+
+// SalesSummary CancellationRate is cancelled / (sold + cancelled) orders,
+// unpaid orders are left out of both
+type SalesSummary struct {
+	Revenue           float64 `json:"revenue"`
+	Units             int     `json:"units"`
+	Orders            int     `json:"orders"`
+	AverageOrderValue float64 `json:"average_order_value"`
+	CancelledOrders   int     `json:"cancelled_orders"`
+	CancellationRate  float64 `json:"cancellation_rate"`
+}
+
+type SalesReport struct {
+	From    time.Time     `json:"from"`
+	To      time.Time     `json:"to"`
+	Bucket  Bucket        `json:"bucket"`
+	Summary *SalesSummary `json:"summary"`
+	Series  []*SalesPoint `json:"series"`
+}
+
+// NewSalesReport totals of the series, an order falls in a single bucket
+func NewSalesReport(filter *AnalyticsFilter, series []*SalesPoint) *SalesReport {
+	s := new(SalesSummary)
+	for _, p := range series {
+		s.Revenue += p.Revenue
+		s.Units += p.Units
+		s.Orders += p.Orders
+		s.CancelledOrders += p.CancelledOrders
+	}
+	s.Revenue = math.Round(s.Revenue*100) / 100
+	if s.Orders > 0 {
+		s.AverageOrderValue = math.Round(s.Revenue/float64(s.Orders)*100) / 100
+	}
+	if total := s.Orders + s.CancelledOrders; total > 0 {
+		s.CancellationRate = math.Round(float64(s.CancelledOrders)/float64(total)*10000) / 10000
+	}
+
+	return &SalesReport{
+		From:    filter.From,
+		To:      filter.To,
+		Bucket:  filter.Bucket,
+		Summary: s,
+		Series:  series,
+	}
+}
+
+type TopProduct struct {
+	ProductID int64   `json:"product_id"`
+	Name      string  `json:"name"`
+	Units     int     `json:"units"`
+	Revenue   float64 `json:"revenue"`
+	Orders    int     `json:"orders"`
+}
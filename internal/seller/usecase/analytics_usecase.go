@@ -0,0 +1,81 @@
+package sellerusecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/codepnw/mini-ecommerce/internal/seller"
+	sellerrepository "github.com/codepnw/mini-ecommerce/internal/seller/repository"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/pkg/auth"
+)
+
+type AnalyticsUsecase interface {
+	GetSalesReport(ctx context.Context, filter *seller.AnalyticsFilter) (*seller.SalesReport, error)
+	GetTopProducts(ctx context.Context, filter *seller.AnalyticsFilter) ([]*seller.TopProduct, error)
+}
+
+type analyticsUsecase struct {
+	repo sellerrepository.SellerRepository
+}
+
+func NewAnalyticsUsecase(repo sellerrepository.SellerRepository) AnalyticsUsecase {
+	return &analyticsUsecase{repo: repo}
+}
+
+func (u *analyticsUsecase) GetSalesReport(ctx context.Context, filter *seller.AnalyticsFilter) (*seller.SalesReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if err := prepareFilter(ctx, filter); err != nil {
+		return nil, err
+	}
+	if filter.BucketCount() > consts.MaxAnalyticsBuckets {
+		return nil, errs.ErrAnalyticsRangeTooLarge
+	}
+
+	series, err := u.repo.SalesSeries(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return seller.NewSalesReport(filter, series), nil
+}
+
+func (u *analyticsUsecase) GetTopProducts(ctx context.Context, filter *seller.AnalyticsFilter) ([]*seller.TopProduct, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if err := prepareFilter(ctx, filter); err != nil {
+		return nil, err
+	}
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = consts.TopProductsLimit
+	}
+	return u.repo.TopProducts(ctx, filter)
+}
+
+// prepareFilter scopes the filter to the seller, default last 30 days by
+// day, To is inclusive
+func prepareFilter(ctx context.Context, filter *seller.AnalyticsFilter) error {
+	filter.SellerID = auth.GetUserID(ctx)
+	if filter.SellerID == 0 {
+		return errs.ErrUnauthorized
+	}
+	if filter.Bucket == "" {
+		filter.Bucket = seller.BucketDay
+	}
+
+	to := filter.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+	filter.To = to.Truncate(24*time.Hour).AddDate(0, 0, 1)
+	if filter.From.IsZero() {
+		filter.From = filter.To.AddDate(0, 0, -30)
+	}
+	if !filter.From.Before(filter.To) {
+		return errs.ErrInvalidDateRange
+	}
+	return nil
+}
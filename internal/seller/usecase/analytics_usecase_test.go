@@ -0,0 +1,139 @@
+package sellerusecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/codepnw/mini-ecommerce/internal/seller"
+	sellerrepository "github.com/codepnw/mini-ecommerce/internal/seller/repository"
+	sellerusecase "github.com/codepnw/mini-ecommerce/internal/seller/usecase"
+	"github.com/codepnw/mini-ecommerce/internal/user"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSalesReport(t *testing.T) {
+	type testCase struct {
+		name        string
+		ctx         context.Context
+		filter      *seller.AnalyticsFilter
+		mockFn      func(mockRepo *sellerrepository.MockSellerRepository)
+		expected    *seller.SalesSummary
+		expectedErr error
+	}
+
+	march := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []testCase{
+		{
+			name:   "success summary of the series",
+			ctx:    mockUser(user.RoleSeller),
+			filter: &seller.AnalyticsFilter{From: march, To: march.AddDate(0, 0, 30), Bucket: seller.BucketWeek},
+			mockFn: func(mockRepo *sellerrepository.MockSellerRepository) {
+				mockRepo.EXPECT().SalesSeries(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, f *seller.AnalyticsFilter) ([]*seller.SalesPoint, error) {
+						assert.Equal(t, int64(10), f.SellerID)
+						// To is inclusive
+						assert.Equal(t, march.AddDate(0, 1, 0), f.To)
+						return []*seller.SalesPoint{
+							{Bucket: march, Revenue: 5000, Units: 2, Orders: 1},
+							{Bucket: march.AddDate(0, 0, 7), Revenue: 1200.5, Units: 1, Orders: 2, CancelledOrders: 1},
+						}, nil
+					},
+				).Times(1)
+			},
+			expected: &seller.SalesSummary{
+				Revenue:           6200.5,
+				Units:             3,
+				Orders:            3,
+				AverageOrderValue: 2066.83,
+				CancelledOrders:   1,
+				CancellationRate:  0.25,
+			},
+		},
+		{
+			name:   "success no sales",
+			ctx:    mockUser(user.RoleSeller),
+			filter: &seller.AnalyticsFilter{},
+			mockFn: func(mockRepo *sellerrepository.MockSellerRepository) {
+				mockRepo.EXPECT().SalesSeries(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, f *seller.AnalyticsFilter) ([]*seller.SalesPoint, error) {
+						// Default last 30 days by day
+						assert.Equal(t, seller.BucketDay, f.Bucket)
+						assert.Equal(t, f.To.AddDate(0, 0, -30), f.From)
+						return []*seller.SalesPoint{}, nil
+					},
+				).Times(1)
+			},
+			expected: &seller.SalesSummary{},
+		},
+		{
+			name:        "fail too many day buckets",
+			ctx:         mockUser(user.RoleSeller),
+			filter:      &seller.AnalyticsFilter{From: march.AddDate(-2, 0, 0), To: march},
+			mockFn:      func(mockRepo *sellerrepository.MockSellerRepository) {},
+			expectedErr: errs.ErrAnalyticsRangeTooLarge,
+		},
+		{
+			name:        "fail from after to",
+			ctx:         mockUser(user.RoleSeller),
+			filter:      &seller.AnalyticsFilter{From: march.AddDate(0, 0, 5), To: march},
+			mockFn:      func(mockRepo *sellerrepository.MockSellerRepository) {},
+			expectedErr: errs.ErrInvalidDateRange,
+		},
+		{
+			name:        "fail unauthorized",
+			ctx:         context.Background(),
+			filter:      &seller.AnalyticsFilter{},
+			mockFn:      func(mockRepo *sellerrepository.MockSellerRepository) {},
+			expectedErr: errs.ErrUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, mockRepo := setupAnalytics(t)
+
+			tc.mockFn(mockRepo)
+
+			result, err := uc.GetSalesReport(tc.ctx, tc.filter)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, result.Summary)
+		})
+	}
+}
+
+func TestGetTopProducts(t *testing.T) {
+	uc, mockRepo := setupAnalytics(t)
+
+	mockRepo.EXPECT().TopProducts(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, f *seller.AnalyticsFilter) ([]*seller.TopProduct, error) {
+			assert.Equal(t, consts.TopProductsLimit, f.Limit)
+			return []*seller.TopProduct{{ProductID: 3, Units: 2, Revenue: 5000, Orders: 1}}, nil
+		},
+	).Times(1)
+
+	result, err := uc.GetTopProducts(mockUser(user.RoleSeller), &seller.AnalyticsFilter{Limit: 500})
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+}
+
+func setupAnalytics(t *testing.T) (sellerusecase.AnalyticsUsecase, *sellerrepository.MockSellerRepository) {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := sellerrepository.NewMockSellerRepository(ctrl)
+	return sellerusecase.NewAnalyticsUsecase(mockRepo), mockRepo
+}
@@ -0,0 +1,355 @@
+package sellerusecase
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/codepnw/mini-ecommerce/internal/product"
+	productusecase "github.com/codepnw/mini-ecommerce/internal/product/usecase"
+	"github.com/codepnw/mini-ecommerce/internal/seller"
+	sellerrepository "github.com/codepnw/mini-ecommerce/internal/seller/repository"
+	"github.com/codepnw/mini-ecommerce/internal/user"
+	userrepository "github.com/codepnw/mini-ecommerce/internal/user/repository"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/internal/utils/helper"
+	"github.com/codepnw/mini-ecommerce/pkg/auth"
+	"github.com/codepnw/mini-ecommerce/pkg/database"
+	"github.com/codepnw/mini-ecommerce/pkg/storage"
+	"github.com/codepnw/mini-ecommerce/pkg/validate"
+	"github.com/google/uuid"
+)
+
+type SellerUsecase interface {
+	// Applicant
+	Apply(ctx context.Context, input *seller.Application) (*seller.Application, error)
+	GetMyApplication(ctx context.Context) (*seller.Application, error)
+
+	// Seller
+	GetMyStore(ctx context.Context) (*seller.Store, error)
+	UpdateMyStore(ctx context.Context, input *seller.StoreUpdate) (*seller.Store, error)
+	UploadLogo(ctx context.Context, data []byte) (*seller.Store, error)
+
+	// Public
+	GetStorePage(ctx context.Context, slug string, filter *product.ProductFilter) (*seller.StorePage, error)
+
+	// Admin
+	ListApplications(ctx context.Context, filter *seller.ApplicationFilter) ([]*seller.Application, error)
+	ReviewApplication(ctx context.Context, input *seller.Review) (*seller.Application, error)
+}
+
+type SellerUsecaseConfig struct {
+	Repo     sellerrepository.SellerRepository `validate:"required"`
+	UserRepo userrepository.UserRepository     `validate:"required"`
+	Products productusecase.ProductUsecase     `validate:"required"`
+	Store    storage.BlobStore                 `validate:"required"`
+	Tx       database.TxManager                `validate:"required"`
+}
+
+type sellerUsecase struct {
+	repo     sellerrepository.SellerRepository
+	userRepo userrepository.UserRepository
+	products productusecase.ProductUsecase
+	store    storage.BlobStore
+	tx       database.TxManager
+}
+
+func NewSellerUsecase(cfg *SellerUsecaseConfig) (SellerUsecase, error) {
+	if err := validate.Struct(cfg); err != nil {
+		return nil, err
+	}
+	return &sellerUsecase{
+		repo:     cfg.Repo,
+		userRepo: cfg.UserRepo,
+		products: cfg.Products,
+		store:    cfg.Store,
+		tx:       cfg.Tx,
+	}, nil
+}
+
+func (u *sellerUsecase) Apply(ctx context.Context, input *seller.Application) (*seller.Application, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	currentUser, err := auth.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, errs.ErrUnauthorized
+	}
+	// Sellers & admins can already list products
+	if currentUser.Role != string(user.RoleUser) {
+		return nil, errs.ErrAlreadySeller
+	}
+
+	input.StoreName = strings.TrimSpace(input.StoreName)
+	slug, err := u.resolveSlug(ctx, input.StoreSlug, input.StoreName)
+	if err != nil {
+		return nil, err
+	}
+	input.StoreSlug = slug
+	input.UserID = currentUser.ID
+
+	if err := u.repo.InsertApplication(ctx, input); err != nil {
+		return nil, err
+	}
+	return input, nil
+}
+
+func (u *sellerUsecase) GetMyApplication(ctx context.Context) (*seller.Application, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	userID := auth.GetUserID(ctx)
+	if userID == 0 {
+		return nil, errs.ErrUnauthorized
+	}
+	return u.repo.LatestApplication(ctx, userID)
+}
+
+func (u *sellerUsecase) GetMyStore(ctx context.Context) (*seller.Store, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	sellerID := auth.GetUserID(ctx)
+	if sellerID == 0 {
+		return nil, errs.ErrUnauthorized
+	}
+
+	store, err := u.repo.FindStoreBySeller(ctx, sellerID)
+	if err != nil {
+		return nil, err
+	}
+	return u.withLogo(store), nil
+}
+
+func (u *sellerUsecase) UpdateMyStore(ctx context.Context, input *seller.StoreUpdate) (*seller.Store, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	sellerID := auth.GetUserID(ctx)
+	if sellerID == 0 {
+		return nil, errs.ErrUnauthorized
+	}
+	if input.Name == nil && input.Slug == nil && input.Description == nil {
+		return nil, errs.ErrNoFieldsToUpdate
+	}
+
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		input.Name = &name
+	}
+	if input.Slug != nil {
+		current, err := u.repo.FindStoreBySeller(ctx, sellerID)
+		if err != nil {
+			return nil, err
+		}
+		// Keeping the own slug is not a conflict
+		if *input.Slug != current.Slug {
+			slug, err := u.resolveSlug(ctx, *input.Slug, "")
+			if err != nil {
+				return nil, err
+			}
+			input.Slug = &slug
+		}
+	}
+
+	store, err := u.repo.UpdateStore(ctx, sellerID, input)
+	if err != nil {
+		return nil, err
+	}
+	return u.withLogo(store), nil
+}
+
+// UploadLogo replaces the store logo, the old file is removed once the
+// store points to the new one
+func (u *sellerUsecase) UploadLogo(ctx context.Context, data []byte) (*seller.Store, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	sellerID := auth.GetUserID(ctx)
+	if sellerID == 0 {
+		return nil, errs.ErrUnauthorized
+	}
+
+	// Validate File
+	if len(data) == 0 {
+		return nil, errs.ErrImageRequired
+	}
+	if len(data) > consts.MaxImageSize {
+		return nil, errs.ErrImageTooLarge
+	}
+	// Sniff content, never trust client Content-Type
+	contentType := http.DetectContentType(data)
+	ext, ok := logoExtensions[contentType]
+	if !ok {
+		return nil, errs.ErrImageTypeInvalid
+	}
+
+	current, err := u.repo.FindStoreBySeller(ctx, sellerID)
+	if err != nil {
+		return nil, err
+	}
+
+	logoKey := fmt.Sprintf("stores/%d/logo_%s%s", current.ID, uuid.NewString(), ext)
+	if err := u.store.Put(ctx, logoKey, bytes.NewReader(data), contentType); err != nil {
+		return nil, err
+	}
+
+	store, err := u.repo.UpdateStoreLogo(ctx, sellerID, logoKey)
+	if err != nil {
+		u.store.Delete(ctx, logoKey)
+		return nil, err
+	}
+	if current.LogoKey != "" {
+		u.store.Delete(ctx, current.LogoKey)
+	}
+	return u.withLogo(store), nil
+}
+
+func (u *sellerUsecase) GetStorePage(ctx context.Context, slug string, filter *product.ProductFilter) (*seller.StorePage, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	store, err := u.repo.FindStoreBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	products, err := u.products.ListByOwner(ctx, store.SellerID, filter)
+	if err != nil {
+		return nil, err
+	}
+	if products == nil {
+		products = make([]*product.Product, 0)
+	}
+	return &seller.StorePage{Store: u.withLogo(store), Products: products}, nil
+}
+
+func (u *sellerUsecase) ListApplications(ctx context.Context, filter *seller.ApplicationFilter) ([]*seller.Application, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if !isAdmin(ctx) {
+		return nil, errs.ErrNoPermissions
+	}
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	return u.repo.ListApplications(ctx, filter)
+}
+
+// ReviewApplication approving creates the store and upgrades the user to
+// seller in one transaction, the new role is in the next access token
+func (u *sellerUsecase) ReviewApplication(ctx context.Context, input *seller.Review) (*seller.Application, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	admin, err := auth.GetCurrentUser(ctx)
+	if err != nil || admin.Role != string(user.RoleAdmin) {
+		return nil, errs.ErrNoPermissions
+	}
+
+	var application *seller.Application
+
+	err = u.tx.WithTransaction(ctx, func(tx *sql.Tx) error {
+		current, err := u.repo.GetApplicationForUpdate(ctx, tx, input.ApplicationID)
+		if err != nil {
+			return err
+		}
+		if current.Status != seller.ApplicationPending {
+			return errs.ErrApplicationReviewed
+		}
+
+		now := time.Now()
+		current.ReviewedBy = &admin.ID
+		current.ReviewedAt = &now
+
+		if input.Approve {
+			current.Status = seller.ApplicationApproved
+			if err := u.approve(ctx, tx, current); err != nil {
+				return err
+			}
+		} else {
+			current.Status = seller.ApplicationRejected
+			current.RejectReason = strings.TrimSpace(input.Reason)
+		}
+
+		if err := u.repo.UpdateApplication(ctx, tx, current); err != nil {
+			return err
+		}
+		application = current
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return application, nil
+}
+
+func (u *sellerUsecase) approve(ctx context.Context, tx *sql.Tx, application *seller.Application) error {
+	applicant, err := u.userRepo.FindByID(ctx, application.UserID)
+	if err != nil {
+		return err
+	}
+	// An admin keeps the admin role
+	if applicant.Role == string(user.RoleUser) {
+		if err := u.userRepo.UpdateRole(ctx, tx, applicant.ID, user.RoleSeller); err != nil {
+			return err
+		}
+	}
+
+	return u.repo.InsertStore(ctx, tx, &seller.Store{
+		SellerID:    application.UserID,
+		Name:        application.StoreName,
+		Slug:        application.StoreSlug,
+		Description: application.Description,
+	})
+}
+
+// resolveSlug the given slug or one made from the store name, the store
+// slug is checked again when the store is created
+func (u *sellerUsecase) resolveSlug(ctx context.Context, slug, name string) (string, error) {
+	if slug == "" {
+		slug = helper.Slugify(name)
+	}
+	if slug == "" || !helper.IsSlug(slug) {
+		return "", errs.ErrStoreSlugInvalid
+	}
+
+	exists, err := u.repo.StoreSlugExists(ctx, slug)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return "", errs.ErrStoreSlugExists
+	}
+	return slug, nil
+}
+
+func (u *sellerUsecase) withLogo(store *seller.Store) *seller.Store {
+	if store.LogoKey != "" {
+		store.LogoURL = u.store.URL(store.LogoKey)
+	}
+	return store
+}
+
+var logoExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+func isAdmin(ctx context.Context) bool {
+	currentUser, err := auth.GetCurrentUser(ctx)
+	if err != nil {
+		return false
+	}
+	return currentUser.Role == string(user.RoleAdmin)
+}
@@ -0,0 +1,370 @@
+package sellerusecase_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/codepnw/mini-ecommerce/internal/product"
+	productrepository "github.com/codepnw/mini-ecommerce/internal/product/repository"
+	productusecase "github.com/codepnw/mini-ecommerce/internal/product/usecase"
+	"github.com/codepnw/mini-ecommerce/internal/seller"
+	sellerrepository "github.com/codepnw/mini-ecommerce/internal/seller/repository"
+	sellerusecase "github.com/codepnw/mini-ecommerce/internal/seller/usecase"
+	"github.com/codepnw/mini-ecommerce/internal/user"
+	userrepository "github.com/codepnw/mini-ecommerce/internal/user/repository"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/pkg/auth"
+	"github.com/codepnw/mini-ecommerce/pkg/jwt"
+	"github.com/codepnw/mini-ecommerce/pkg/storage"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApply(t *testing.T) {
+	type testCase struct {
+		name        string
+		ctx         context.Context
+		input       *seller.Application
+		mockFn      func(mockRepo *sellerrepository.MockSellerRepository)
+		expected    string
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name:  "success slug from store name",
+			ctx:   mockUser(user.RoleUser),
+			input: &seller.Application{StoreName: "  Gadget Corner "},
+			mockFn: func(mockRepo *sellerrepository.MockSellerRepository) {
+				mockRepo.EXPECT().StoreSlugExists(gomock.Any(), "gadget-corner").Return(false, nil).Times(1)
+				mockRepo.EXPECT().InsertApplication(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, a *seller.Application) error {
+						assert.Equal(t, int64(10), a.UserID)
+						assert.Equal(t, "Gadget Corner", a.StoreName)
+						a.ID, a.Status = 1, seller.ApplicationPending
+						return nil
+					},
+				).Times(1)
+			},
+			expected: "gadget-corner",
+		},
+		{
+			name:        "fail already seller",
+			ctx:         mockUser(user.RoleSeller),
+			input:       &seller.Application{StoreName: "Gadget Corner"},
+			mockFn:      func(mockRepo *sellerrepository.MockSellerRepository) {},
+			expectedErr: errs.ErrAlreadySeller,
+		},
+		{
+			name:        "fail invalid slug",
+			ctx:         mockUser(user.RoleUser),
+			input:       &seller.Application{StoreName: "Gadget Corner", StoreSlug: "Gadget Corner"},
+			mockFn:      func(mockRepo *sellerrepository.MockSellerRepository) {},
+			expectedErr: errs.ErrStoreSlugInvalid,
+		},
+		{
+			name:  "fail slug taken",
+			ctx:   mockUser(user.RoleUser),
+			input: &seller.Application{StoreName: "Gadget Corner", StoreSlug: "gadgets"},
+			mockFn: func(mockRepo *sellerrepository.MockSellerRepository) {
+				mockRepo.EXPECT().StoreSlugExists(gomock.Any(), "gadgets").Return(true, nil).Times(1)
+				mockRepo.EXPECT().InsertApplication(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedErr: errs.ErrStoreSlugExists,
+		},
+		{
+			name:  "fail application pending",
+			ctx:   mockUser(user.RoleUser),
+			input: &seller.Application{StoreName: "Gadget Corner"},
+			mockFn: func(mockRepo *sellerrepository.MockSellerRepository) {
+				mockRepo.EXPECT().StoreSlugExists(gomock.Any(), "gadget-corner").Return(false, nil).Times(1)
+				mockRepo.EXPECT().InsertApplication(gomock.Any(), gomock.Any()).Return(errs.ErrApplicationPending).Times(1)
+			},
+			expectedErr: errs.ErrApplicationPending,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, mockRepo, _, _ := setup(t)
+
+			tc.mockFn(mockRepo)
+
+			result, err := uc.Apply(tc.ctx, tc.input)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, result.StoreSlug)
+			assert.Equal(t, seller.ApplicationPending, result.Status)
+		})
+	}
+}
+
+func TestReviewApplication(t *testing.T) {
+	type testCase struct {
+		name        string
+		ctx         context.Context
+		input       *seller.Review
+		mockFn      func(mockRepo *sellerrepository.MockSellerRepository, mockUserRepo *userrepository.MockUserRepository)
+		expected    seller.ApplicationStatus
+		expectedErr error
+	}
+
+	pending := func() *seller.Application {
+		return &seller.Application{ID: 1, UserID: 10, StoreName: "Gadget Corner", StoreSlug: "gadget-corner", Status: seller.ApplicationPending}
+	}
+
+	testCases := []testCase{
+		{
+			name:  "success approve upgrades role",
+			ctx:   mockUser(user.RoleAdmin),
+			input: &seller.Review{ApplicationID: 1, Approve: true},
+			mockFn: func(mockRepo *sellerrepository.MockSellerRepository, mockUserRepo *userrepository.MockUserRepository) {
+				mockRepo.EXPECT().GetApplicationForUpdate(gomock.Any(), gomock.Any(), int64(1)).Return(pending(), nil).Times(1)
+				mockUserRepo.EXPECT().FindByID(gomock.Any(), int64(10)).Return(&user.User{ID: 10, Role: string(user.RoleUser)}, nil).Times(1)
+				mockUserRepo.EXPECT().UpdateRole(gomock.Any(), gomock.Any(), int64(10), user.RoleSeller).Return(nil).Times(1)
+				mockRepo.EXPECT().InsertStore(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ *sql.Tx, s *seller.Store) error {
+						assert.Equal(t, int64(10), s.SellerID)
+						assert.Equal(t, "gadget-corner", s.Slug)
+						return nil
+					},
+				).Times(1)
+				mockRepo.EXPECT().UpdateApplication(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+			expected: seller.ApplicationApproved,
+		},
+		{
+			name:  "success reject",
+			ctx:   mockUser(user.RoleAdmin),
+			input: &seller.Review{ApplicationID: 1, Reason: "incomplete details"},
+			mockFn: func(mockRepo *sellerrepository.MockSellerRepository, mockUserRepo *userrepository.MockUserRepository) {
+				mockRepo.EXPECT().GetApplicationForUpdate(gomock.Any(), gomock.Any(), int64(1)).Return(pending(), nil).Times(1)
+				mockUserRepo.EXPECT().UpdateRole(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				mockRepo.EXPECT().InsertStore(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				mockRepo.EXPECT().UpdateApplication(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+			expected: seller.ApplicationRejected,
+		},
+		{
+			name:  "fail slug taken since applying",
+			ctx:   mockUser(user.RoleAdmin),
+			input: &seller.Review{ApplicationID: 1, Approve: true},
+			mockFn: func(mockRepo *sellerrepository.MockSellerRepository, mockUserRepo *userrepository.MockUserRepository) {
+				mockRepo.EXPECT().GetApplicationForUpdate(gomock.Any(), gomock.Any(), int64(1)).Return(pending(), nil).Times(1)
+				mockUserRepo.EXPECT().FindByID(gomock.Any(), int64(10)).Return(&user.User{ID: 10, Role: string(user.RoleUser)}, nil).Times(1)
+				mockUserRepo.EXPECT().UpdateRole(gomock.Any(), gomock.Any(), int64(10), user.RoleSeller).Return(nil).Times(1)
+				mockRepo.EXPECT().InsertStore(gomock.Any(), gomock.Any(), gomock.Any()).Return(errs.ErrStoreSlugExists).Times(1)
+				mockRepo.EXPECT().UpdateApplication(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedErr: errs.ErrStoreSlugExists,
+		},
+		{
+			name:  "fail already reviewed",
+			ctx:   mockUser(user.RoleAdmin),
+			input: &seller.Review{ApplicationID: 1, Approve: true},
+			mockFn: func(mockRepo *sellerrepository.MockSellerRepository, mockUserRepo *userrepository.MockUserRepository) {
+				reviewed := pending()
+				reviewed.Status = seller.ApplicationRejected
+				mockRepo.EXPECT().GetApplicationForUpdate(gomock.Any(), gomock.Any(), int64(1)).Return(reviewed, nil).Times(1)
+				mockRepo.EXPECT().InsertStore(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedErr: errs.ErrApplicationReviewed,
+		},
+		{
+			name:  "fail not admin",
+			ctx:   mockUser(user.RoleSeller),
+			input: &seller.Review{ApplicationID: 1, Approve: true},
+			mockFn: func(mockRepo *sellerrepository.MockSellerRepository, mockUserRepo *userrepository.MockUserRepository) {
+			},
+			expectedErr: errs.ErrNoPermissions,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, mockRepo, mockUserRepo, _ := setup(t)
+
+			tc.mockFn(mockRepo, mockUserRepo)
+
+			result, err := uc.ReviewApplication(tc.ctx, tc.input)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, result.Status)
+			assert.Equal(t, int64(1), *result.ReviewedBy)
+			assert.NotNil(t, result.ReviewedAt)
+		})
+	}
+}
+
+func TestUpdateMyStore(t *testing.T) {
+	type testCase struct {
+		name        string
+		input       *seller.StoreUpdate
+		mockFn      func(mockRepo *sellerrepository.MockSellerRepository)
+		expectedErr error
+	}
+
+	current := &seller.Store{ID: 1, SellerID: 10, Name: "Gadget Corner", Slug: "gadget-corner"}
+
+	testCases := []testCase{
+		{
+			name:  "success own slug kept",
+			input: &seller.StoreUpdate{Name: ptr("Gadget Corner TH"), Slug: ptr("gadget-corner")},
+			mockFn: func(mockRepo *sellerrepository.MockSellerRepository) {
+				mockRepo.EXPECT().FindStoreBySeller(gomock.Any(), int64(10)).Return(current, nil).Times(1)
+				mockRepo.EXPECT().StoreSlugExists(gomock.Any(), gomock.Any()).Times(0)
+				mockRepo.EXPECT().UpdateStore(gomock.Any(), int64(10), gomock.Any()).Return(current, nil).Times(1)
+			},
+		},
+		{
+			name:  "fail slug taken",
+			input: &seller.StoreUpdate{Slug: ptr("gadgets")},
+			mockFn: func(mockRepo *sellerrepository.MockSellerRepository) {
+				mockRepo.EXPECT().FindStoreBySeller(gomock.Any(), int64(10)).Return(current, nil).Times(1)
+				mockRepo.EXPECT().StoreSlugExists(gomock.Any(), "gadgets").Return(true, nil).Times(1)
+				mockRepo.EXPECT().UpdateStore(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedErr: errs.ErrStoreSlugExists,
+		},
+		{
+			name:        "fail no fields",
+			input:       &seller.StoreUpdate{},
+			mockFn:      func(mockRepo *sellerrepository.MockSellerRepository) {},
+			expectedErr: errs.ErrNoFieldsToUpdate,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, mockRepo, _, _ := setup(t)
+
+			tc.mockFn(mockRepo)
+
+			result, err := uc.UpdateMyStore(mockUser(user.RoleSeller), tc.input)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, result)
+		})
+	}
+}
+
+func TestGetStorePage(t *testing.T) {
+	type testCase struct {
+		name        string
+		slug        string
+		mockFn      func(mockRepo *sellerrepository.MockSellerRepository, mockProdRepo *productrepository.MockProductRepository)
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name: "success published products of the seller",
+			slug: "gadget-corner",
+			mockFn: func(mockRepo *sellerrepository.MockSellerRepository, mockProdRepo *productrepository.MockProductRepository) {
+				mockRepo.EXPECT().FindStoreBySlug(gomock.Any(), "gadget-corner").Return(&seller.Store{ID: 1, SellerID: 10, Slug: "gadget-corner", LogoKey: "stores/1/logo.png"}, nil).Times(1)
+				mockProdRepo.EXPECT().List(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, f *product.ProductFilter) ([]*product.Product, error) {
+						assert.Equal(t, int64(10), f.OwnerID)
+						assert.Equal(t, string(product.StatusPublished), f.Status)
+						return []*product.Product{{ID: 3, OwnerID: 10, Status: product.StatusPublished}}, nil
+					},
+				).Times(1)
+				mockProdRepo.EXPECT().ListImages(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+			},
+		},
+		{
+			name: "fail store not found",
+			slug: "unknown",
+			mockFn: func(mockRepo *sellerrepository.MockSellerRepository, mockProdRepo *productrepository.MockProductRepository) {
+				mockRepo.EXPECT().FindStoreBySlug(gomock.Any(), "unknown").Return(nil, errs.ErrStoreNotFound).Times(1)
+				mockProdRepo.EXPECT().List(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedErr: errs.ErrStoreNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, mockRepo, _, mockProdRepo := setup(t)
+
+			tc.mockFn(mockRepo, mockProdRepo)
+
+			// Draft filter from the query is ignored
+			result, err := uc.GetStorePage(context.Background(), tc.slug, &product.ProductFilter{Status: "draft"})
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, result.Products, 1)
+			assert.Equal(t, "http://localhost/uploads/stores/1/logo.png", result.Store.LogoURL)
+		})
+	}
+}
+
+// =============== Helper ===================
+// ------------------------------------------
+func setup(t *testing.T) (sellerusecase.SellerUsecase, *sellerrepository.MockSellerRepository, *userrepository.MockUserRepository, *productrepository.MockProductRepository) {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := sellerrepository.NewMockSellerRepository(ctrl)
+	mockUserRepo := userrepository.NewMockUserRepository(ctrl)
+	mockProdRepo := productrepository.NewMockProductRepository(ctrl)
+	store, err := storage.NewLocalStore(t.TempDir(), "http://localhost/uploads")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uc, err := sellerusecase.NewSellerUsecase(&sellerusecase.SellerUsecaseConfig{
+		Repo:     mockRepo,
+		UserRepo: mockUserRepo,
+		Products: productusecase.NewProductUsecase(mockProdRepo, nil, &mockTxManager{}, store),
+		Store:    store,
+		Tx:       &mockTxManager{},
+	})
+	if err != nil {
+		t.Fatalf("init seller usecase failed: %v", err)
+	}
+	return uc, mockRepo, mockUserRepo, mockProdRepo
+}
+
+// mockUser ID 1 is the admin, ID 10 the applicant or seller
+func mockUser(role user.RoleType) context.Context {
+	id := int64(10)
+	if role == user.RoleAdmin {
+		id = 1
+	}
+	ctx := auth.SetCurrentUser(context.Background(), &jwt.UserClaims{ID: id, Role: string(role)})
+	return auth.SetUserID(ctx, id)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+type mockTxManager struct{}
+
+func (m *mockTxManager) WithTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return fn(nil)
+}
@@ -0,0 +1,11 @@
+package shippinghandler
+
+// RateReq MaxWeightGrams 0 is no limit, FreeOver 0 is never free
+type RateReq struct {
+	Method         string  `json:"method" binding:"required,oneof=standard express pickup"`
+	Zone           string  `json:"zone" binding:"required,oneof=domestic international"`
+	BaseFee        float64 `json:"base_fee" binding:"gte=0"`
+	PerKgFee       float64 `json:"per_kg_fee" binding:"gte=0"`
+	MaxWeightGrams int     `json:"max_weight_grams" binding:"gte=0"`
+	FreeOver       float64 `json:"free_over" binding:"gte=0"`
+}
@@ -0,0 +1,90 @@
+package shippinghandler
+
+import (
+	"github.com/codepnw/mini-ecommerce/internal/shipping"
+	shippingusecase "github.com/codepnw/mini-ecommerce/internal/shipping/usecase"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/internal/utils/helper"
+	"github.com/codepnw/mini-ecommerce/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+type shippingHandler struct {
+	uc shippingusecase.ShippingUsecase
+}
+
+func NewShippingHandler(uc shippingusecase.ShippingUsecase) *shippingHandler {
+	return &shippingHandler{uc: uc}
+}
+
+func (h *shippingHandler) ListRates(c *gin.Context) {
+	result, err := h.uc.ListRates(c.Request.Context())
+	if err != nil {
+		switch err {
+		case errs.ErrNoPermissions:
+			response.Forbidden(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "", result)
+}
+
+func (h *shippingHandler) SetRate(c *gin.Context) {
+	req := new(RateReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	input := &shipping.Rate{
+		Method:         shipping.Method(req.Method),
+		Zone:           shipping.Zone(req.Zone),
+		BaseFee:        req.BaseFee,
+		PerKgFee:       req.PerKgFee,
+		MaxWeightGrams: req.MaxWeightGrams,
+		FreeOver:       req.FreeOver,
+	}
+
+	result, err := h.uc.SetRate(c.Request.Context(), input)
+	if err != nil {
+		switch err {
+		case errs.ErrNoPermissions:
+			response.Forbidden(c, err.Error())
+			return
+		case errs.ErrShippingRateInvalid:
+			response.BadRequest(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.OK(c, "shipping rate saved", result)
+}
+
+func (h *shippingHandler) DeleteRate(c *gin.Context) {
+	rateID, err := helper.GetParamInt(c, consts.ParamRateID)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.uc.DeleteRate(c.Request.Context(), rateID); err != nil {
+		switch err {
+		case errs.ErrNoPermissions:
+			response.Forbidden(c, err.Error())
+			return
+		case errs.ErrShippingRateNotFound:
+			response.NotFound(c, err.Error())
+			return
+		default:
+			response.InternalServerError(c, err)
+			return
+		}
+	}
+	response.NoContent(c)
+}
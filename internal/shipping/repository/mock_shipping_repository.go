@@ -0,0 +1,135 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: shipping_repository.go
+
+// Package shippingrepository is a generated GoMock package.
+package shippingrepository
+
+import (
+	context "context"
+	reflect "reflect"
+
+	shipping "github.com/codepnw/mini-ecommerce/internal/shipping"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockShippingRepository is a mock of ShippingRepository interface.
+type MockShippingRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockShippingRepositoryMockRecorder
+}
+
+// MockShippingRepositoryMockRecorder is the mock recorder for MockShippingRepository.
+type MockShippingRepositoryMockRecorder struct {
+	mock *MockShippingRepository
+}
+
+// NewMockShippingRepository creates a new mock instance.
+func NewMockShippingRepository(ctrl *gomock.Controller) *MockShippingRepository {
+	mock := &MockShippingRepository{ctrl: ctrl}
+	mock.recorder = &MockShippingRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockShippingRepository) EXPECT() *MockShippingRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteRate mocks base method.
+func (m *MockShippingRepository) DeleteRate(ctx context.Context, rateID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRate", ctx, rateID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRate indicates an expected call of DeleteRate.
+func (mr *MockShippingRepositoryMockRecorder) DeleteRate(ctx, rateID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRate", reflect.TypeOf((*MockShippingRepository)(nil).DeleteRate), ctx, rateID)
+}
+
+// GetRate mocks base method.
+func (m *MockShippingRepository) GetRate(ctx context.Context, method shipping.Method, zone shipping.Zone) (*shipping.Rate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRate", ctx, method, zone)
+	ret0, _ := ret[0].(*shipping.Rate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRate indicates an expected call of GetRate.
+func (mr *MockShippingRepositoryMockRecorder) GetRate(ctx, method, zone interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRate", reflect.TypeOf((*MockShippingRepository)(nil).GetRate), ctx, method, zone)
+}
+
+// ListRates mocks base method.
+func (m *MockShippingRepository) ListRates(ctx context.Context) ([]*shipping.Rate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRates", ctx)
+	ret0, _ := ret[0].([]*shipping.Rate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRates indicates an expected call of ListRates.
+func (mr *MockShippingRepositoryMockRecorder) ListRates(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRates", reflect.TypeOf((*MockShippingRepository)(nil).ListRates), ctx)
+}
+
+// UpsertRate mocks base method.
+func (m *MockShippingRepository) UpsertRate(ctx context.Context, input *shipping.Rate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertRate", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertRate indicates an expected call of UpsertRate.
+func (mr *MockShippingRepositoryMockRecorder) UpsertRate(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertRate", reflect.TypeOf((*MockShippingRepository)(nil).UpsertRate), ctx, input)
+}
+
+// MockrowScanner is a mock of rowScanner interface.
+type MockrowScanner struct {
+	ctrl     *gomock.Controller
+	recorder *MockrowScannerMockRecorder
+}
+
+// MockrowScannerMockRecorder is the mock recorder for MockrowScanner.
+type MockrowScannerMockRecorder struct {
+	mock *MockrowScanner
+}
+
+// NewMockrowScanner creates a new mock instance.
+func NewMockrowScanner(ctrl *gomock.Controller) *MockrowScanner {
+	mock := &MockrowScanner{ctrl: ctrl}
+	mock.recorder = &MockrowScannerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockrowScanner) EXPECT() *MockrowScannerMockRecorder {
+	return m.recorder
+}
+
+// Scan mocks base method.
+func (m *MockrowScanner) Scan(dest ...any) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range dest {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Scan", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Scan indicates an expected call of Scan.
+func (mr *MockrowScannerMockRecorder) Scan(dest ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockrowScanner)(nil).Scan), dest...)
+}
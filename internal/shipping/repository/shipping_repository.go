@@ -0,0 +1,127 @@
+package shippingrepository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/codepnw/mini-ecommerce/internal/shipping"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+)
+
+//go:generate mockgen -source=shipping_repository.go -destination=mock_shipping_repository.go -package=shippingrepository
+
+type ShippingRepository interface {
+	ListRates(ctx context.Context) ([]*shipping.Rate, error)
+	GetRate(ctx context.Context, method shipping.Method, zone shipping.Zone) (*shipping.Rate, error)
+	UpsertRate(ctx context.Context, input *shipping.Rate) error
+	DeleteRate(ctx context.Context, rateID int64) error
+}
+
+type shippingRepository struct {
+	db *sql.DB
+}
+
+func NewShippingRepository(db *sql.DB) ShippingRepository {
+	return &shippingRepository{db: db}
+}
+
+const rateColumns = "id, method, zone, base_fee, per_kg_fee, max_weight_grams, free_over, created_at, updated_at"
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRate(row rowScanner) (*shipping.Rate, error) {
+	r := new(shipping.Rate)
+	err := row.Scan(
+		&r.ID,
+		&r.Method,
+		&r.Zone,
+		&r.BaseFee,
+		&r.PerKgFee,
+		&r.MaxWeightGrams,
+		&r.FreeOver,
+		&r.CreatedAt,
+		&r.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *shippingRepository) ListRates(ctx context.Context) ([]*shipping.Rate, error) {
+	query := `SELECT ` + rateColumns + ` FROM shipping_rates ORDER BY zone, base_fee, method`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := make([]*shipping.Rate, 0)
+	for rows.Next() {
+		rate, err := scanRate(rows)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+	return rates, rows.Err()
+}
+
+func (r *shippingRepository) GetRate(ctx context.Context, method shipping.Method, zone shipping.Zone) (*shipping.Rate, error) {
+	query := `SELECT ` + rateColumns + ` FROM shipping_rates WHERE method = $1 AND zone = $2`
+
+	rate, err := scanRate(r.db.QueryRowContext(ctx, query, method, zone))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrShippingRateNotFound
+		}
+		return nil, err
+	}
+	return rate, nil
+}
+
+// UpsertRate one rate per method & zone, the fees are replaced
+func (r *shippingRepository) UpsertRate(ctx context.Context, input *shipping.Rate) error {
+	query := `
+		INSERT INTO shipping_rates (method, zone, base_fee, per_kg_fee, max_weight_grams, free_over)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (method, zone)
+		DO UPDATE SET
+			base_fee = EXCLUDED.base_fee,
+			per_kg_fee = EXCLUDED.per_kg_fee,
+			max_weight_grams = EXCLUDED.max_weight_grams,
+			free_over = EXCLUDED.free_over,
+			updated_at = NOW()
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		input.Method,
+		input.Zone,
+		input.BaseFee,
+		input.PerKgFee,
+		input.MaxWeightGrams,
+		input.FreeOver,
+	).Scan(&input.ID, &input.CreatedAt, &input.UpdatedAt)
+}
+
+// DeleteRate the method is no longer offered to the zone
+func (r *shippingRepository) DeleteRate(ctx context.Context, rateID int64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM shipping_rates WHERE id = $1`, rateID)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errs.ErrShippingRateNotFound
+	}
+	return nil
+}
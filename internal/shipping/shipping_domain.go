@@ -0,0 +1,115 @@
+package shipping
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/codepnw/mini-ecommerce/internal/product"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+)
+
+type Method string
+
+const (
+	MethodStandard Method = "standard"
+	MethodExpress  Method = "express"
+	MethodPickup   Method = "pickup"
+)
+
+type Zone string
+
+const (
+	ZoneDomestic      Zone = "domestic"
+	ZoneInternational Zone = "international"
+)
+
+// ZoneOf the destination country, no country is the home country
+func ZoneOf(country, homeCountry string) Zone {
+	if country == "" || strings.EqualFold(country, homeCountry) {
+		return ZoneDomestic
+	}
+	return ZoneInternational
+}
+
+// VolumetricDivisor cm³ per kg, bulky parcels are charged by their volume
+const VolumetricDivisor = 5000
+
+// Rate price of a method to a zone. BaseFee covers the first kg and PerKgFee
+// every started kg after it. MaxWeightGrams 0 is no limit, FreeOver 0 is
+// never free.
+type Rate struct {
+	ID             int64     `json:"id"`
+	Method         Method    `json:"method"`
+	Zone           Zone      `json:"zone"`
+	BaseFee        float64   `json:"base_fee"`
+	PerKgFee       float64   `json:"per_kg_fee"`
+	MaxWeightGrams int       `json:"max_weight_grams"`
+	FreeOver       float64   `json:"free_over"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func (r *Rate) Validate() error {
+	if r.BaseFee < 0 || r.PerKgFee < 0 || r.MaxWeightGrams < 0 || r.FreeOver < 0 {
+		return errs.ErrShippingRateInvalid
+	}
+	return nil
+}
+
+// Price of a parcel of weightGrams, free once the subtotal reaches FreeOver
+func (r *Rate) Price(weightGrams int, subtotal float64) (*Quote, error) {
+	if r.MaxWeightGrams > 0 && weightGrams > r.MaxWeightGrams {
+		return nil, errs.ErrShippingTooHeavy
+	}
+
+	q := &Quote{Method: r.Method, Zone: r.Zone, WeightGrams: weightGrams}
+	if r.FreeOver > 0 && subtotal >= r.FreeOver {
+		q.Free = true
+		return q, nil
+	}
+	extraKg := 0
+	if weightGrams > 1000 {
+		extraKg = (weightGrams - 1000 + 999) / 1000
+	}
+	q.Cost = math.Round((r.BaseFee+r.PerKgFee*float64(extraKg))*100) / 100
+	return q, nil
+}
+
+// BillableGrams of one unit, the actual or volumetric weight whichever is higher
+func BillableGrams(p product.Parcel) int {
+	volumetric := p.LengthCM * p.WidthCM * p.HeightCM * 1000 / VolumetricDivisor
+	return max(p.WeightGrams, volumetric)
+}
+
+// Request shipping of cart lines, Method defaults to standard. Subtotal of
+// the lines decides free shipping.
+type Request struct {
+	Method   Method
+	Country  string
+	Items    []*Item
+	Subtotal float64
+}
+
+type Item struct {
+	Quantity int
+	Parcel   product.Parcel
+}
+
+// WeightGrams billable weight of all the items
+func (r *Request) WeightGrams() int {
+	total := 0
+	for _, i := range r.Items {
+		total += BillableGrams(i.Parcel) * i.Quantity
+	}
+	return total
+}
+
+// Quote shipping cost of a request with one method
+type Quote struct {
+	Method      Method  `json:"method"`
+	Zone        Zone    `json:"zone"`
+	WeightGrams int     `json:"weight_grams"`
+	Cost        float64 `json:"cost"`
+	Free        bool    `json:"free"`
+}
@@ -0,0 +1,142 @@
+package shippingusecase
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/codepnw/mini-ecommerce/internal/shipping"
+	shippingrepository "github.com/codepnw/mini-ecommerce/internal/shipping/repository"
+	"github.com/codepnw/mini-ecommerce/internal/user"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/pkg/auth"
+	"github.com/codepnw/mini-ecommerce/pkg/validate"
+)
+
+type ShippingUsecase interface {
+	// Checkout
+	Price(ctx context.Context, req *shipping.Request) (*shipping.Quote, error)
+	Options(ctx context.Context, req *shipping.Request) ([]*shipping.Quote, error)
+
+	// Admin
+	ListRates(ctx context.Context) ([]*shipping.Rate, error)
+	SetRate(ctx context.Context, input *shipping.Rate) (*shipping.Rate, error)
+	DeleteRate(ctx context.Context, rateID int64) error
+}
+
+type ShippingUsecaseConfig struct {
+	Repo shippingrepository.ShippingRepository `validate:"required"`
+
+	// Other countries are international
+	HomeCountry string `validate:"required,len=2"`
+}
+
+type shippingUsecase struct {
+	repo        shippingrepository.ShippingRepository
+	homeCountry string
+}
+
+func NewShippingUsecase(cfg *ShippingUsecaseConfig) (ShippingUsecase, error) {
+	if err := validate.Struct(cfg); err != nil {
+		return nil, err
+	}
+	return &shippingUsecase{
+		repo:        cfg.Repo,
+		homeCountry: strings.ToUpper(cfg.HomeCountry),
+	}, nil
+}
+
+// Price of the request's method, ErrShippingUnavailable when the method has
+// no rate to the destination
+func (u *shippingUsecase) Price(ctx context.Context, req *shipping.Request) (*shipping.Quote, error) {
+	method := req.Method
+	if method == "" {
+		method = shipping.MethodStandard
+	}
+
+	rate, err := u.repo.GetRate(ctx, method, shipping.ZoneOf(req.Country, u.homeCountry))
+	if err != nil {
+		if errors.Is(err, errs.ErrShippingRateNotFound) {
+			return nil, errs.ErrShippingUnavailable
+		}
+		return nil, err
+	}
+	return rate.Price(req.WeightGrams(), req.Subtotal)
+}
+
+// Options every method available to the destination, cheapest first.
+// Methods the parcel is too heavy for are left out.
+func (u *shippingUsecase) Options(ctx context.Context, req *shipping.Request) ([]*shipping.Quote, error) {
+	rates, err := u.repo.ListRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	zone := shipping.ZoneOf(req.Country, u.homeCountry)
+	weight := req.WeightGrams()
+
+	options := make([]*shipping.Quote, 0, len(rates))
+	for _, r := range rates {
+		if r.Zone != zone {
+			continue
+		}
+		q, err := r.Price(weight, req.Subtotal)
+		if errors.Is(err, errs.ErrShippingTooHeavy) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, q)
+	}
+	sort.SliceStable(options, func(i, j int) bool { return options[i].Cost < options[j].Cost })
+	return options, nil
+}
+
+func (u *shippingUsecase) ListRates(ctx context.Context) ([]*shipping.Rate, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if !isAdmin(ctx) {
+		return nil, errs.ErrNoPermissions
+	}
+	return u.repo.ListRates(ctx)
+}
+
+// SetRate creates the rate of the method & zone or replaces its fees
+func (u *shippingUsecase) SetRate(ctx context.Context, input *shipping.Rate) (*shipping.Rate, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if !isAdmin(ctx) {
+		return nil, errs.ErrNoPermissions
+	}
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := u.repo.UpsertRate(ctx, input); err != nil {
+		return nil, err
+	}
+	return input, nil
+}
+
+func (u *shippingUsecase) DeleteRate(ctx context.Context, rateID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, consts.ContextTimeout)
+	defer cancel()
+
+	if !isAdmin(ctx) {
+		return errs.ErrNoPermissions
+	}
+	return u.repo.DeleteRate(ctx, rateID)
+}
+
+func isAdmin(ctx context.Context) bool {
+	currentUser, err := auth.GetCurrentUser(ctx)
+	if err != nil {
+		return false
+	}
+	return currentUser.Role == string(user.RoleAdmin)
+}
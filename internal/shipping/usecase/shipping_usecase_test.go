@@ -0,0 +1,259 @@
+package shippingusecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codepnw/mini-ecommerce/internal/product"
+	"github.com/codepnw/mini-ecommerce/internal/shipping"
+	shippingrepository "github.com/codepnw/mini-ecommerce/internal/shipping/repository"
+	shippingusecase "github.com/codepnw/mini-ecommerce/internal/shipping/usecase"
+	"github.com/codepnw/mini-ecommerce/internal/utils/errs"
+	"github.com/codepnw/mini-ecommerce/pkg/auth"
+	"github.com/codepnw/mini-ecommerce/pkg/jwt"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrice(t *testing.T) {
+	type testCase struct {
+		name         string
+		req          *shipping.Request
+		rate         *shipping.Rate
+		rateErr      error
+		expectedZone shipping.Zone
+		expectedCost float64
+		expectedFree bool
+		expectedErr  error
+	}
+
+	standard := &shipping.Rate{
+		Method:         shipping.MethodStandard,
+		Zone:           shipping.ZoneDomestic,
+		BaseFee:        50,
+		PerKgFee:       10,
+		MaxWeightGrams: 30000,
+		FreeOver:       1000,
+	}
+	parcel := product.Parcel{WeightGrams: 500, LengthCM: 10, WidthCM: 10, HeightCM: 10}
+
+	testCases := []testCase{
+		{
+			name: "success base fee",
+			req: &shipping.Request{
+				Items:    []*shipping.Item{{Quantity: 2, Parcel: parcel}},
+				Subtotal: 200,
+			},
+			rate:         standard,
+			expectedZone: shipping.ZoneDomestic,
+			expectedCost: 50,
+		},
+		{
+			name: "success per started kg",
+			req: &shipping.Request{
+				Method:   shipping.MethodStandard,
+				Country:  "th",
+				Items:    []*shipping.Item{{Quantity: 5, Parcel: parcel}},
+				Subtotal: 500,
+			},
+			rate:         standard,
+			expectedZone: shipping.ZoneDomestic,
+			// 2.5 kg, 2 kg over the first
+			expectedCost: 70,
+		},
+		{
+			name: "success volumetric weight",
+			req: &shipping.Request{
+				Items: []*shipping.Item{{Quantity: 1, Parcel: product.Parcel{
+					WeightGrams: 200, LengthCM: 40, WidthCM: 30, HeightCM: 20,
+				}}},
+				Subtotal: 100,
+			},
+			rate:         standard,
+			expectedZone: shipping.ZoneDomestic,
+			// 4.8 kg volumetric
+			expectedCost: 90,
+		},
+		{
+			name: "success free over threshold",
+			req: &shipping.Request{
+				Items:    []*shipping.Item{{Quantity: 1, Parcel: parcel}},
+				Subtotal: 1000,
+			},
+			rate:         standard,
+			expectedZone: shipping.ZoneDomestic,
+			expectedFree: true,
+		},
+		{
+			name: "success international",
+			req: &shipping.Request{
+				Method:   shipping.MethodExpress,
+				Country:  "US",
+				Items:    []*shipping.Item{{Quantity: 1, Parcel: parcel}},
+				Subtotal: 100,
+			},
+			rate: &shipping.Rate{
+				Method:   shipping.MethodExpress,
+				Zone:     shipping.ZoneInternational,
+				BaseFee:  1500,
+				PerKgFee: 500,
+			},
+			expectedZone: shipping.ZoneInternational,
+			expectedCost: 1500,
+		},
+		{
+			name: "fail too heavy",
+			req: &shipping.Request{
+				Items:    []*shipping.Item{{Quantity: 61, Parcel: parcel}},
+				Subtotal: 100,
+			},
+			rate:         standard,
+			expectedZone: shipping.ZoneDomestic,
+			expectedErr:  errs.ErrShippingTooHeavy,
+		},
+		{
+			name: "fail unavailable",
+			req: &shipping.Request{
+				Method:  shipping.MethodPickup,
+				Country: "US",
+				Items:   []*shipping.Item{{Quantity: 1, Parcel: parcel}},
+			},
+			rateErr:      errs.ErrShippingRateNotFound,
+			expectedZone: shipping.ZoneInternational,
+			expectedErr:  errs.ErrShippingUnavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, mockRepo := setup(t)
+
+			method := tc.req.Method
+			if method == "" {
+				method = shipping.MethodStandard
+			}
+			mockRepo.EXPECT().GetRate(gomock.Any(), method, tc.expectedZone).Return(tc.rate, tc.rateErr).Times(1)
+
+			result, err := uc.Price(context.Background(), tc.req)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, method, result.Method)
+			assert.Equal(t, tc.expectedZone, result.Zone)
+			assert.Equal(t, tc.expectedCost, result.Cost)
+			assert.Equal(t, tc.expectedFree, result.Free)
+		})
+	}
+}
+
+func TestOptions(t *testing.T) {
+	uc, mockRepo := setup(t)
+
+	mockRepo.EXPECT().ListRates(gomock.Any()).Return([]*shipping.Rate{
+		{Method: shipping.MethodStandard, Zone: shipping.ZoneDomestic, BaseFee: 50, PerKgFee: 10, MaxWeightGrams: 30000},
+		{Method: shipping.MethodExpress, Zone: shipping.ZoneDomestic, BaseFee: 120, PerKgFee: 20, MaxWeightGrams: 20000},
+		{Method: shipping.MethodPickup, Zone: shipping.ZoneDomestic},
+		{Method: shipping.MethodStandard, Zone: shipping.ZoneInternational, BaseFee: 600, PerKgFee: 300},
+	}, nil).Times(1)
+
+	result, err := uc.Options(context.Background(), &shipping.Request{
+		Items: []*shipping.Item{{Quantity: 1, Parcel: product.Parcel{WeightGrams: 25000}}},
+	})
+
+	assert.NoError(t, err)
+	// Express is too heavy, international left out
+	if assert.Len(t, result, 2) {
+		assert.Equal(t, shipping.MethodPickup, result[0].Method)
+		assert.Equal(t, 0.0, result[0].Cost)
+		assert.Equal(t, shipping.MethodStandard, result[1].Method)
+		assert.Equal(t, 290.0, result[1].Cost)
+	}
+}
+
+func TestSetRate(t *testing.T) {
+	type testCase struct {
+		name        string
+		ctx         context.Context
+		input       *shipping.Rate
+		mockFn      func(mockRepo *shippingrepository.MockShippingRepository)
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name:  "success",
+			ctx:   mockAdmin(),
+			input: &shipping.Rate{Method: shipping.MethodExpress, Zone: shipping.ZoneDomestic, BaseFee: 120, PerKgFee: 20},
+			mockFn: func(mockRepo *shippingrepository.MockShippingRepository) {
+				mockRepo.EXPECT().UpsertRate(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, r *shipping.Rate) error {
+						r.ID = 2
+						return nil
+					},
+				).Times(1)
+			},
+		},
+		{
+			name:  "fail negative fee",
+			ctx:   mockAdmin(),
+			input: &shipping.Rate{Method: shipping.MethodExpress, Zone: shipping.ZoneDomestic, BaseFee: -1},
+			mockFn: func(mockRepo *shippingrepository.MockShippingRepository) {
+				mockRepo.EXPECT().UpsertRate(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedErr: errs.ErrShippingRateInvalid,
+		},
+		{
+			name:        "fail not admin",
+			ctx:         context.Background(),
+			input:       &shipping.Rate{Method: shipping.MethodExpress, Zone: shipping.ZoneDomestic},
+			mockFn:      func(mockRepo *shippingrepository.MockShippingRepository) {},
+			expectedErr: errs.ErrNoPermissions,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, mockRepo := setup(t)
+
+			tc.mockFn(mockRepo)
+
+			result, err := uc.SetRate(tc.ctx, tc.input)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, int64(2), result.ID)
+		})
+	}
+}
+
+// =================== Helper ==============
+// -----------------------------------------
+func setup(t *testing.T) (shippingusecase.ShippingUsecase, *shippingrepository.MockShippingRepository) {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := shippingrepository.NewMockShippingRepository(ctrl)
+
+	uc, err := shippingusecase.NewShippingUsecase(&shippingusecase.ShippingUsecaseConfig{
+		Repo:        mockRepo,
+		HomeCountry: "TH",
+	})
+	if err != nil {
+		t.Fatalf("init shipping usecase failed: %v", err)
+	}
+	return uc, mockRepo
+}
+
+func mockAdmin() context.Context {
+	return auth.SetCurrentUser(context.Background(), &jwt.UserClaims{ID: 1, Role: "admin"})
+}
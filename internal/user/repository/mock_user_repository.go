@@ -109,6 +109,20 @@ func (mr *MockUserRepositoryMockRecorder) SaveRefreshToken(ctx, db, input interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveRefreshToken", reflect.TypeOf((*MockUserRepository)(nil).SaveRefreshToken), ctx, db, input)
 }
 
+// UpdateRole mocks base method.
+func (m *MockUserRepository) UpdateRole(ctx context.Context, db database.DBExec, userID int64, role user.RoleType) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRole", ctx, db, userID, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRole indicates an expected call of UpdateRole.
+func (mr *MockUserRepositoryMockRecorder) UpdateRole(ctx, db, userID, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRole", reflect.TypeOf((*MockUserRepository)(nil).UpdateRole), ctx, db, userID, role)
+}
+
 // ValidateRefreshToken mocks base method.
 func (m *MockUserRepository) ValidateRefreshToken(ctx context.Context, token string) (int64, error) {
 	m.ctrl.T.Helper()
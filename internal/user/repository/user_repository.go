@@ -23,6 +23,7 @@ type UserRepository interface {
 	Insert(ctx context.Context, db database.DBExec, input *user.User) (*user.User, error)
 	SaveRefreshToken(ctx context.Context, db database.DBExec, input *user.Auth) error
 	RevokedRefreshToken(ctx context.Context, db database.DBExec, token string) error
+	UpdateRole(ctx context.Context, db database.DBExec, userID int64, role user.RoleType) error
 }
 
 type userRepository struct {
@@ -125,6 +126,26 @@ func (r *userRepository) RevokedRefreshToken(ctx context.Context, db database.DB
 	return nil
 }
 
+// UpdateRole takes effect on the next access token, the refresh token
+// reads the role again
+func (r *userRepository) UpdateRole(ctx context.Context, db database.DBExec, userID int64, role user.RoleType) error {
+	query := `UPDATE users SET role = $1, updated_at = NOW() WHERE id = $2`
+	res, err := db.ExecContext(ctx, query, role, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errs.ErrUserNotFound
+	}
+	return nil
+}
+
 func (r *userRepository) ValidateRefreshToken(ctx context.Context, token string) (int64, error) {
 	var (
 		userID    int64
@@ -7,13 +7,88 @@ const (
 
 	AccessTokenDuration  = time.Hour
 	RefreshTokenDuration = time.Hour * 24 * 7
+
+	OrderLookupTokenDuration = time.Hour * 24 * 90
+	OrderClaimTokenDuration  = time.Hour * 24
+	CheckoutQuoteDuration    = time.Minute * 15
+)
+
+// Product Images
+const (
+	MaxImageSize   = 5 << 20    // 5 MB
+	ThumbnailSize  = 300        // px
+	MaxImagePixels = 24_000_000 // 24 MP, checked before decoding
+	FormImageKey   = "image"
+)
+
+// Product Import & Export
+const (
+	MaxImportSize = 10 << 20 // 10 MB
+	MaxImportRows = 5000
+	FormImportKey = "file"
+	FormatCSV     = "csv"
+	FormatNDJSON  = "ndjson"
+)
+
+// Stock Alerts
+const (
+	StockEventBatch = 100
+	// StockEventMaxAttempts failed events are given up after
+	StockEventMaxAttempts = 5
+)
+
+// Abandoned Carts
+const (
+	CartRecoveryBatch = 100
+	// CartRecoveryMaxAttempts failed notifications are given up after
+	CartRecoveryMaxAttempts = 5
+)
+
+// Seller Payouts
+const (
+	EarningBatch = 100
+)
+
+// Refunds
+const (
+	RefundBatch = 100
+	// RefundMaxAttempts provider failures a pending refund is given up after
+	RefundMaxAttempts = 5
+)
+
+// Admin Orders
+const (
+	MaxBulkOrders = 500
+)
+
+// Shipments
+const (
+	// ShipmentTrackBatch shipments polled per tracking run
+	ShipmentTrackBatch = 100
+)
+
+// Returns
+const (
+	DefaultReturnWindowDays = 30
+	MaxReturnWindowDays     = 365
+	MaxReturnPhotos         = 5
 )
 
 // Params Key
 const (
 	ParamProductID = "product_id"
+	ParamSlug      = "slug"
 	CartItemID     = "cart_item_id"
 	ParamOrderID   = "order_id"
+
+	ParamWarehouseID   = "warehouse_id"
+	ParamWishlistID    = "wishlist_id"
+	ParamSubOrderID    = "sub_order_id"
+	ParamCommissionID  = "commission_id"
+	ParamBatchID       = "batch_id"
+	ParamApplicationID = "application_id"
+	ParamReturnID      = "return_id"
+	ParamRateID        = "rate_id"
 )
 
 // Context Key
@@ -24,3 +99,9 @@ const (
 	UserIDKey     contextKey = "user-id-key"
 	SessionIDKey  contextKey = "session-id-key"
 )
+
+// Seller Analytics
+const (
+	MaxAnalyticsBuckets = 366
+	TopProductsLimit    = 10
+)
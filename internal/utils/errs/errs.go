@@ -24,6 +24,33 @@ var (
 	ErrProductPriceInvalid = errors.New("product price greater than zero")
 	ErrProductSKUExists    = errors.New("sku already exists")
 	ErrProductNotEnough    = errors.New("product not enough stock")
+	ErrProductUnavailable  = errors.New("product is not available")
+	ErrProductSlugExists   = errors.New("slug already exists")
+	ErrProductSlugInvalid  = errors.New("slug must be lowercase letters, numbers and hyphens")
+	ErrProductNameInvalid  = errors.New("product name must be at least 2 characters")
+
+	ErrLowStockThresholdInvalid = errors.New("low stock threshold must not be negative")
+	ErrReturnWindowInvalid      = errors.New("return window must be between 0 and 365 days")
+	ErrProductInStock           = errors.New("product is in stock")
+
+	ErrPurchaseRule         = errors.New("purchase rule violated")
+	ErrPurchaseRulesInvalid = errors.New("purchase rules are invalid, min and max must fit the step and limits")
+	ErrParcelInvalid        = errors.New("weight and dimensions must not be negative")
+
+	ErrProductVersionMismatch = errors.New("product has been modified, reload and try again")
+	ErrInvalidIfMatch         = errors.New("invalid If-Match header")
+
+	ErrImportFileRequired  = errors.New("import file is required")
+	ErrImportFileTooLarge  = errors.New("import file too large")
+	ErrImportFormatInvalid = errors.New("format must be csv or ndjson")
+	ErrImportHeaderInvalid = errors.New("csv header must include name, price, stock and sku")
+	ErrImportTooManyRows   = errors.New("import file has too many rows")
+	ErrImportDuplicateSKU  = errors.New("duplicate sku in import file")
+
+	ErrImageRequired    = errors.New("image file is required")
+	ErrImageTooLarge    = errors.New("image file too large")
+	ErrImageTypeInvalid = errors.New("image type must be jpeg, png or gif")
+	ErrImagePixels      = errors.New("image dimensions too large")
 )
 
 // Cart
@@ -31,6 +58,14 @@ var (
 	ErrInvalidQuantity = errors.New("invalid quantity")
 	ErrItemNotInCart   = errors.New("item not in cart")
 	ErrCartIsEmpty     = errors.New("cart is empty")
+	ErrCartChanged     = errors.New("cart has changed, accept the changes before checkout")
+
+	ErrWishlistNotFound     = errors.New("wishlist not found")
+	ErrWishlistNameExists   = errors.New("wishlist name already exists")
+	ErrWishlistNameRequired = errors.New("wishlist name is required")
+
+	ErrCartRecoveryInvalid = errors.New("cart recovery link is invalid or expired")
+	ErrInvalidDateRange    = errors.New("from must be before to")
 )
 
 // Order
@@ -38,4 +73,85 @@ var (
 	ErrOrderNotFound       = errors.New("order not found")
 	ErrCannotCancelOrder   = errors.New("cannot cancel order")
 	ErrInvalidStatusChange = errors.New("invalid status change")
+	ErrCancelPaidOrder     = errors.New("paid orders are cancelled with a refund")
+
+	ErrGuestCheckoutSignedIn = errors.New("signed in, use the account checkout")
+	ErrOrderLookupInvalid    = errors.New("order lookup token is invalid or expired")
+	ErrOrderClaimInvalid     = errors.New("order claim token is invalid or expired")
+	ErrNoGuestOrders         = errors.New("no guest orders for this email")
+
+	ErrQuoteInvalid = errors.New("checkout quote is invalid or expired")
+	ErrQuoteChanged = errors.New("cart changed since the quote, request a new quote")
+
+	ErrOrderNotPaid     = errors.New("order is not paid")
+	ErrSubOrderNotFound = errors.New("sub-order not found")
+	ErrTooManyOrders    = errors.New("too many orders in one request")
+
+	ErrInvalidTotalRange = errors.New("min total must not be above max total")
+
+	ErrShipmentNotFound = errors.New("shipment not found")
+)
+
+// Inventory
+var (
+	ErrWarehouseNotFound        = errors.New("warehouse not found")
+	ErrWarehouseCodeExists      = errors.New("warehouse code already exists")
+	ErrWarehouseDefault         = errors.New("default warehouse cannot be deactivated")
+	ErrWarehouseNotEnough       = errors.New("warehouse not enough stock")
+	ErrWarehouseLocationInvalid = errors.New("latitude and longitude must be set together")
+
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+)
+
+// Payout
+var (
+	ErrCommissionNotFound     = errors.New("commission rule not found")
+	ErrCommissionRateInvalid  = errors.New("commission rate must be between 0 and 1")
+	ErrCommissionScopeInvalid = errors.New("seller rules need a seller_id, category rules a category")
+
+	ErrPayoutBatchNotFound   = errors.New("payout batch not found")
+	ErrPayoutBatchNotPending = errors.New("payout batch is already settled")
+	ErrNothingToPayout       = errors.New("no seller balance to pay out")
+)
+
+// Seller
+var (
+	ErrAlreadySeller       = errors.New("user is already a seller")
+	ErrApplicationPending  = errors.New("seller application is already pending review")
+	ErrApplicationNotFound = errors.New("seller application not found")
+	ErrApplicationReviewed = errors.New("seller application is already reviewed")
+	ErrStoreNotFound       = errors.New("store not found")
+	ErrStoreSlugExists     = errors.New("store slug already exists")
+	ErrStoreSlugInvalid    = errors.New("store slug must be lowercase letters, numbers and hyphens")
+
+	ErrAnalyticsRangeTooLarge = errors.New("date range has too many buckets, use a larger bucket")
+)
+
+// Refund
+var (
+	ErrRefundNotAllowed       = errors.New("order cannot be refunded in its status")
+	ErrRefundLineNotFound     = errors.New("refund line is not an item of the order")
+	ErrRefundQuantityExceeded = errors.New("refund quantity exceeds the refundable quantity")
+	ErrNothingToRefund        = errors.New("nothing left to refund")
+	ErrRefundDeclined         = errors.New("refund declined by the payment provider")
+)
+
+// Return
+var (
+	ErrReturnNotFound         = errors.New("return not found")
+	ErrReturnNotAllowed       = errors.New("item cannot be returned before its order is completed")
+	ErrReturnWindowClosed     = errors.New("return window of the item has closed")
+	ErrReturnLineNotFound     = errors.New("return item is not an item of the order")
+	ErrReturnQuantityExceeded = errors.New("return quantity exceeds the returnable quantity")
+	ErrReturnSellersMixed     = errors.New("items of a return must come from one seller")
+	ErrReturnStatusChange     = errors.New("return cannot move to this status")
+	ErrTooManyReturnPhotos    = errors.New("too many return photos")
+)
+
+// Shipping
+var (
+	ErrShippingRateNotFound = errors.New("shipping rate not found")
+	ErrShippingRateInvalid  = errors.New("shipping fees, max weight and free over must not be negative")
+	ErrShippingUnavailable  = errors.New("shipping method is not available for this destination")
+	ErrShippingTooHeavy     = errors.New("cart is too heavy for the shipping method")
 )
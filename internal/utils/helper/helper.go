@@ -1,7 +1,9 @@
 package helper
 
 import (
+	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -13,3 +15,19 @@ func GetParamInt(c *gin.Context, key string) (int64, error) {
 	}
 	return result, nil
 }
+
+var (
+	slugInvalidChars = regexp.MustCompile(`[^a-z0-9]+`)
+	slugPattern      = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+)
+
+// Slugify "4K Monitor 27\"" -> "4k-monitor-27"
+func Slugify(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = slugInvalidChars.ReplaceAllString(s, "-")
+	return strings.Trim(s, "-")
+}
+
+func IsSlug(s string) bool {
+	return len(s) <= 255 && slugPattern.MatchString(s)
+}
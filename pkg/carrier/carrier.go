@@ -0,0 +1,42 @@
+package carrier
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/codepnw/mini-ecommerce/pkg/config"
+)
+
+type Status string
+
+const (
+	StatusInTransit Status = "in_transit"
+	StatusDelivered Status = "delivered"
+)
+
+type TrackRequest struct {
+	Carrier        string
+	TrackingNumber string
+	ShippedAt      time.Time
+}
+
+// TrackResult DeliveredAt is set once delivered
+type TrackResult struct {
+	Status      Status
+	DeliveredAt *time.Time
+}
+
+// CarrierTracker polls the tracking status of a parcel
+type CarrierTracker interface {
+	Track(ctx context.Context, req *TrackRequest) (*TrackResult, error)
+}
+
+func NewTracker(cfg config.CarrierConfig) (CarrierTracker, error) {
+	switch cfg.Driver {
+	case "stub":
+		return NewStubTracker(cfg.StubDeliverAfter), nil
+	default:
+		return nil, fmt.Errorf("unknown carrier driver: %s", cfg.Driver)
+	}
+}
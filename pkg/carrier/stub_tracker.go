@@ -0,0 +1,24 @@
+package carrier
+
+import (
+	"context"
+	"time"
+)
+
+// StubTracker delivers every parcel DeliverAfter after it shipped, for
+// development & tests
+type StubTracker struct {
+	DeliverAfter time.Duration
+}
+
+func NewStubTracker(deliverAfter time.Duration) *StubTracker {
+	return &StubTracker{DeliverAfter: deliverAfter}
+}
+
+func (t *StubTracker) Track(ctx context.Context, req *TrackRequest) (*TrackResult, error) {
+	deliveredAt := req.ShippedAt.Add(t.DeliverAfter)
+	if time.Now().Before(deliveredAt) {
+		return &TrackResult{Status: StatusInTransit}, nil
+	}
+	return &TrackResult{Status: StatusDelivered, DeliveredAt: &deliveredAt}, nil
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 	"github.com/codepnw/mini-ecommerce/pkg/validate"
@@ -13,6 +14,16 @@ type EnvConfig struct {
 	APP AppConfig `envPrefix:"APP_"`
 	DB  DBConfig  `envPrefix:"DB_"`
 	JWT JWTConfig `envPrefix:"JWT_"`
+
+	Storage   StorageConfig   `envPrefix:"STORAGE_"`
+	Inventory InventoryConfig `envPrefix:"INVENTORY_"`
+	Notify    NotifyConfig    `envPrefix:"NOTIFY_"`
+	Cart      CartConfig      `envPrefix:"CART_"`
+	Order     OrderConfig     `envPrefix:"ORDER_"`
+	Payout    PayoutConfig    `envPrefix:"PAYOUT_"`
+	Payment   PaymentConfig   `envPrefix:"PAYMENT_"`
+	Carrier   CarrierConfig   `envPrefix:"CARRIER_"`
+	Shipping  ShippingConfig  `envPrefix:"SHIPPING_"`
 }
 
 type AppConfig struct {
@@ -34,6 +45,79 @@ type JWTConfig struct {
 	RefreshKey string `env:"REFRESH_KEY" validate:"required"`
 }
 
+type StorageConfig struct {
+	Driver   string `env:"DRIVER" envDefault:"local" validate:"oneof=local s3"`
+	BaseURL  string `env:"BASE_URL" envDefault:"http://localhost:8080/uploads"`
+	LocalDir string `env:"LOCAL_DIR" envDefault:"./uploads"`
+	// S3 Compatible (AWS S3, MinIO)
+	S3Endpoint  string `env:"S3_ENDPOINT"`
+	S3Region    string `env:"S3_REGION" envDefault:"us-east-1"`
+	S3Bucket    string `env:"S3_BUCKET"`
+	S3AccessKey string `env:"S3_ACCESS_KEY"`
+	S3SecretKey string `env:"S3_SECRET_KEY"`
+	S3PublicURL string `env:"S3_PUBLIC_URL"`
+}
+
+type InventoryConfig struct {
+	// Hold stock at add-to-cart, released after ReservationTTL
+	ReservationEnabled bool          `env:"RESERVATION_ENABLED" envDefault:"false"`
+	ReservationTTL     time.Duration `env:"RESERVATION_TTL" envDefault:"15m" validate:"gt=0"`
+	SweepInterval      time.Duration `env:"SWEEP_INTERVAL" envDefault:"1m" validate:"gt=0"`
+	// Warehouse picked per order line: priority or nearest
+	AllocationStrategy string `env:"ALLOCATION_STRATEGY" envDefault:"priority" validate:"oneof=priority nearest"`
+	// Low stock & back in stock notifications
+	EventInterval time.Duration `env:"EVENT_INTERVAL" envDefault:"30s" validate:"gt=0"`
+}
+
+type CartConfig struct {
+	// Idle user carts are marked abandoned and sent a recovery link
+	AbandonAfter time.Duration `env:"ABANDON_AFTER" envDefault:"24h" validate:"gt=0"`
+	RecoveryURL  string        `env:"RECOVERY_URL" envDefault:"http://localhost:3000/cart/recover" validate:"url"`
+	RecoveryTTL  time.Duration `env:"RECOVERY_TTL" envDefault:"168h" validate:"gt=0"`
+	// Idle guest carts are deleted with their items
+	GuestTTL    time.Duration `env:"GUEST_TTL" envDefault:"720h" validate:"gtfield=AbandonAfter"`
+	JobInterval time.Duration `env:"JOB_INTERVAL" envDefault:"10m" validate:"gt=0"`
+}
+
+type OrderConfig struct {
+	// Page that confirms a guest order claim, the link carries ?token=
+	ClaimURL string `env:"CLAIM_URL" envDefault:"http://localhost:3000/orders/claim" validate:"url"`
+}
+
+type PayoutConfig struct {
+	// Completed sub-orders are posted to the seller ledger
+	JobInterval time.Duration `env:"JOB_INTERVAL" envDefault:"10m" validate:"gt=0"`
+	// Seller balances of at least MinAmount are batched for payout
+	BatchInterval time.Duration `env:"BATCH_INTERVAL" envDefault:"168h" validate:"gt=0"`
+	MinAmount     float64       `env:"MIN_AMOUNT" envDefault:"10" validate:"gte=0"`
+}
+
+// PaymentConfig fake records refunds in memory until a provider is set up
+type PaymentConfig struct {
+	Driver string `env:"DRIVER" envDefault:"fake" validate:"oneof=fake"`
+	// Refunds the provider could not be reached for are retried
+	JobInterval time.Duration `env:"JOB_INTERVAL" envDefault:"5m" validate:"gt=0"`
+}
+
+// CarrierConfig stub delivers parcels StubDeliverAfter after shipping until
+// a carrier is set up
+type CarrierConfig struct {
+	Driver           string        `env:"DRIVER" envDefault:"stub" validate:"oneof=stub"`
+	StubDeliverAfter time.Duration `env:"STUB_DELIVER_AFTER" envDefault:"72h" validate:"gt=0"`
+	// Shipments in transit are tracked, delivered ones complete their sub-order
+	JobInterval time.Duration `env:"JOB_INTERVAL" envDefault:"30m" validate:"gt=0"`
+}
+
+type ShippingConfig struct {
+	// ISO country code, other destinations use the international rates
+	HomeCountry string `env:"HOME_COUNTRY" envDefault:"TH" validate:"len=2"`
+}
+
+type NotifyConfig struct {
+	Driver string `env:"DRIVER" envDefault:"log" validate:"oneof=log"`
+	From   string `env:"FROM" envDefault:"no-reply@mini-ecommerce.local"`
+}
+
 func LoadConfig(path string) (*EnvConfig, error) {
 	if err := godotenv.Load(path); err != nil {
 		/*
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS product_images (
+    id BIGSERIAL PRIMARY KEY,
+    product_id BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    object_key TEXT NOT NULL,
+    thumbnail_key TEXT NOT NULL,
+    content_type VARCHAR(50) NOT NULL,
+    size BIGINT NOT NULL,
+    position INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(product_id, position)
+);
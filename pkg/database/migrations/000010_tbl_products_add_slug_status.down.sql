@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_products_owner_id;
+DROP INDEX IF EXISTS idx_products_status;
+
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_slug_key;
+ALTER TABLE products DROP COLUMN IF EXISTS status;
+ALTER TABLE products DROP COLUMN IF EXISTS slug;
+ALTER TABLE products DROP COLUMN IF EXISTS description;
+
+DROP TYPE IF EXISTS product_status;
@@ -0,0 +1,18 @@
+CREATE TYPE product_status AS ENUM ('draft', 'published', 'archived');
+
+ALTER TABLE products
+ADD COLUMN IF NOT EXISTS description TEXT,
+ADD COLUMN slug VARCHAR(255),
+ADD COLUMN status product_status NOT NULL DEFAULT 'published';
+
+-- Existing products stay visible, slug generated from name + id
+UPDATE products
+SET slug = TRIM(BOTH '-' FROM LOWER(REGEXP_REPLACE(name, '[^a-zA-Z0-9]+', '-', 'g'))) || '-' || id;
+
+ALTER TABLE products
+ALTER COLUMN slug SET NOT NULL,
+ALTER COLUMN status SET DEFAULT 'draft',
+ADD CONSTRAINT products_slug_key UNIQUE (slug);
+
+CREATE INDEX idx_products_status ON products(status);
+CREATE INDEX idx_products_owner_id ON products(owner_id);
@@ -0,0 +1,4 @@
+ALTER TABLE products ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- Listing only reads live products
+CREATE INDEX idx_products_live ON products(id) WHERE deleted_at IS NULL;
@@ -0,0 +1,2 @@
+-- Optimistic concurrency, bumped on every write (ETag / If-Match)
+ALTER TABLE products ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,13 @@
+-- Timed stock holds, one per cart line (removed with the cart item)
+CREATE TABLE IF NOT EXISTS inventory_reservations (
+    id BIGSERIAL PRIMARY KEY,
+    cart_item_id BIGINT UNIQUE NOT NULL REFERENCES cart_items(id) ON DELETE CASCADE,
+    cart_id UUID NOT NULL REFERENCES carts(id) ON DELETE CASCADE,
+    product_id BIGINT NOT NULL REFERENCES products(id),
+    quantity INT NOT NULL CHECK (quantity > 0),
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_inventory_reservations_product ON inventory_reservations(product_id, expires_at);
+CREATE INDEX IF NOT EXISTS idx_inventory_reservations_expires ON inventory_reservations(expires_at);
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS trg_stock_movements_append_only ON stock_movements;
+DROP FUNCTION IF EXISTS stock_movements_append_only();
+DROP TABLE IF EXISTS stock_movements;
+DROP TYPE IF EXISTS stock_movement_reason;
@@ -0,0 +1,32 @@
+CREATE TYPE stock_movement_reason AS ENUM ('order', 'cancel', 'restock', 'adjust', 'return');
+
+-- Append-only ledger, every change of products.stock writes one row
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id BIGSERIAL PRIMARY KEY,
+    product_id BIGINT NOT NULL REFERENCES products(id),
+    delta INT NOT NULL CHECK (delta <> 0),
+    stock_after INT NOT NULL,
+    reason stock_movement_reason NOT NULL,
+    ref_type VARCHAR(50),
+    ref_id BIGINT,
+    actor_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    note TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_product ON stock_movements(product_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_ref ON stock_movements(ref_type, ref_id);
+
+CREATE OR REPLACE FUNCTION stock_movements_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'stock_movements is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_stock_movements_append_only
+BEFORE UPDATE OR DELETE ON stock_movements
+FOR EACH ROW EXECUTE FUNCTION stock_movements_append_only();
+
+-- Opening balance, the ledger starts from the current stock
+INSERT INTO stock_movements (product_id, delta, stock_after, reason, ref_type, ref_id, note)
+SELECT id, stock, stock, 'adjust', 'product', id, 'opening balance'
+FROM products WHERE stock <> 0;
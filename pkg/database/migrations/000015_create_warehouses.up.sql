@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS warehouses (
+    id BIGSERIAL PRIMARY KEY,
+    code VARCHAR(50) UNIQUE NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    latitude DOUBLE PRECISION,
+    longitude DOUBLE PRECISION,
+    priority INT NOT NULL DEFAULT 0,
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- Only one default warehouse
+CREATE UNIQUE INDEX IF NOT EXISTS idx_warehouses_default ON warehouses(is_default) WHERE is_default;
+
+-- Stock per warehouse, products.stock stays the total of all warehouses
+CREATE TABLE IF NOT EXISTS warehouse_stock (
+    warehouse_id BIGINT NOT NULL REFERENCES warehouses(id),
+    product_id BIGINT NOT NULL REFERENCES products(id),
+    quantity INT NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (warehouse_id, product_id)
+);
+CREATE INDEX IF NOT EXISTS idx_warehouse_stock_product ON warehouse_stock(product_id);
+
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS warehouse_id BIGINT REFERENCES warehouses(id);
+ALTER TABLE stock_movements ADD COLUMN IF NOT EXISTS warehouse_id BIGINT REFERENCES warehouses(id);
+
+-- Existing stock moves into the default warehouse
+INSERT INTO warehouses (code, name, is_default) VALUES ('MAIN', 'Main Warehouse', TRUE);
+
+INSERT INTO warehouse_stock (warehouse_id, product_id, quantity)
+SELECT w.id, p.id, p.stock
+FROM products p CROSS JOIN warehouses w
+WHERE w.is_default AND p.stock > 0;
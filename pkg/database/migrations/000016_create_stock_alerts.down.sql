@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS stock_subscriptions;
+DROP TABLE IF EXISTS stock_events;
+ALTER TABLE products DROP COLUMN IF EXISTS low_stock_threshold;
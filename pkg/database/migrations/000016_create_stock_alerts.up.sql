@@ -0,0 +1,24 @@
+-- 0 turns the low-stock alert off
+ALTER TABLE products ADD COLUMN IF NOT EXISTS low_stock_threshold INT NOT NULL DEFAULT 0 CHECK (low_stock_threshold >= 0);
+
+-- Written in the stock change transaction, processed by a background job
+CREATE TABLE IF NOT EXISTS stock_events (
+    id BIGSERIAL PRIMARY KEY,
+    product_id BIGINT NOT NULL REFERENCES products(id),
+    kind VARCHAR(50) NOT NULL,
+    stock INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    processed_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_stock_events_pending ON stock_events(id) WHERE processed_at IS NULL;
+
+-- Back-in-stock subscriptions, notified once
+CREATE TABLE IF NOT EXISTS stock_subscriptions (
+    id BIGSERIAL PRIMARY KEY,
+    product_id BIGINT NOT NULL REFERENCES products(id),
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    notified_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_subscriptions_active ON stock_subscriptions(product_id, user_id) WHERE notified_at IS NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_carts_wishlist_name;
+DROP INDEX IF EXISTS idx_carts_saved_for_later;
+ALTER TABLE carts DROP COLUMN IF EXISTS name;
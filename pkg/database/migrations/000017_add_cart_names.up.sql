@@ -0,0 +1,5 @@
+-- Saved carts: the saved-for-later list has no name, wishlists are named
+ALTER TABLE carts ADD COLUMN IF NOT EXISTS name VARCHAR(100);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_carts_saved_for_later ON carts(user_id) WHERE status = 'saved' AND name IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_carts_wishlist_name ON carts(user_id, name) WHERE status = 'saved' AND name IS NOT NULL;
@@ -0,0 +1,5 @@
+ALTER TABLE products
+    DROP COLUMN IF EXISTS customer_limit,
+    DROP COLUMN IF EXISTS quantity_step,
+    DROP COLUMN IF EXISTS max_quantity,
+    DROP COLUMN IF EXISTS min_quantity;
@@ -0,0 +1,6 @@
+-- Purchase rules, 0 turns a rule off
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS min_quantity INT NOT NULL DEFAULT 0 CHECK (min_quantity >= 0),
+    ADD COLUMN IF NOT EXISTS max_quantity INT NOT NULL DEFAULT 0 CHECK (max_quantity >= 0),
+    ADD COLUMN IF NOT EXISTS quantity_step INT NOT NULL DEFAULT 0 CHECK (quantity_step >= 0),
+    ADD COLUMN IF NOT EXISTS customer_limit INT NOT NULL DEFAULT 0 CHECK (customer_limit >= 0);
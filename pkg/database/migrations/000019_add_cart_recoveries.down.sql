@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS cart_recoveries;
+-- Enum values can not be dropped, 'abandoned' stays in cart_status
@@ -0,0 +1,16 @@
+-- 'abandoned' was only in the schema script, the value can not be used
+-- in the transaction that adds it
+ALTER TYPE cart_status ADD VALUE IF NOT EXISTS 'abandoned';
+
+-- One row per abandoned user cart, recovery stats are counted here
+CREATE TABLE IF NOT EXISTS cart_recoveries (
+    id BIGSERIAL PRIMARY KEY,
+    cart_id UUID NOT NULL REFERENCES carts(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    abandoned_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    notified_at TIMESTAMPTZ,
+    recovered_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_cart_recoveries_cart_id ON cart_recoveries(cart_id);
+CREATE INDEX IF NOT EXISTS idx_cart_recoveries_abandoned_at ON cart_recoveries(abandoned_at);
+CREATE INDEX IF NOT EXISTS idx_cart_recoveries_pending ON cart_recoveries(id) WHERE notified_at IS NULL;
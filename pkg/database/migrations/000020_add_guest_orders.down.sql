@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_orders_guest_email;
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_buyer_check;
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS ship_country,
+    DROP COLUMN IF EXISTS ship_postal_code,
+    DROP COLUMN IF EXISTS ship_city,
+    DROP COLUMN IF EXISTS ship_address,
+    DROP COLUMN IF EXISTS ship_phone,
+    DROP COLUMN IF EXISTS ship_name,
+    DROP COLUMN IF EXISTS guest_email;
@@ -0,0 +1,14 @@
+-- Guest orders have no user, the email identifies the buyer until the
+-- order is claimed by an account
+ALTER TABLE orders ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS guest_email VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS ship_name VARCHAR(100),
+    ADD COLUMN IF NOT EXISTS ship_phone VARCHAR(30),
+    ADD COLUMN IF NOT EXISTS ship_address TEXT,
+    ADD COLUMN IF NOT EXISTS ship_city VARCHAR(100),
+    ADD COLUMN IF NOT EXISTS ship_postal_code VARCHAR(20),
+    ADD COLUMN IF NOT EXISTS ship_country VARCHAR(2);
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_buyer_check;
+ALTER TABLE orders ADD CONSTRAINT orders_buyer_check CHECK (user_id IS NOT NULL OR guest_email IS NOT NULL);
+CREATE INDEX IF NOT EXISTS idx_orders_guest_email ON orders(LOWER(guest_email)) WHERE guest_email IS NOT NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_order_items_product_id;
+DROP INDEX IF EXISTS idx_order_items_order_id;
+DROP TABLE IF EXISTS order_fulfilments;
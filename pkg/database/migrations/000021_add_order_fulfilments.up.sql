@@ -0,0 +1,11 @@
+-- Each seller ships its own lines of an order, a missing row is pending
+CREATE TABLE IF NOT EXISTS order_fulfilments (
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    seller_id BIGINT NOT NULL REFERENCES users(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    shipped_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (order_id, seller_id)
+);
+CREATE INDEX IF NOT EXISTS idx_order_items_order_id ON order_items(order_id);
+CREATE INDEX IF NOT EXISTS idx_order_items_product_id ON order_items(product_id);
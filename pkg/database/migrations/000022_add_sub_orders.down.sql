@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS order_fulfilments (
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    seller_id BIGINT NOT NULL REFERENCES users(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    shipped_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (order_id, seller_id)
+);
+INSERT INTO order_fulfilments (order_id, seller_id, status, shipped_at)
+SELECT order_id, seller_id, 'shipped', shipped_at
+FROM sub_orders WHERE status IN ('shipped', 'completed')
+ON CONFLICT (order_id, seller_id) DO NOTHING;
+
+UPDATE orders SET status = 'paid' WHERE status = 'partially_shipped';
+
+DROP INDEX IF EXISTS idx_order_items_sub_order_id;
+ALTER TABLE order_items DROP COLUMN IF EXISTS sub_order_id;
+DROP TABLE IF EXISTS sub_orders;
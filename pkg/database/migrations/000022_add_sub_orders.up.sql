@@ -0,0 +1,40 @@
+-- One sub-order per seller of an order with its own status, the order
+-- status is derived from its sub-orders
+CREATE TABLE IF NOT EXISTS sub_orders (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    seller_id BIGINT NOT NULL REFERENCES users(id),
+    total DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    status VARCHAR(50) NOT NULL DEFAULT 'pending',
+    shipped_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (order_id, seller_id)
+);
+CREATE INDEX IF NOT EXISTS idx_sub_orders_seller_id ON sub_orders(seller_id, created_at);
+
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS sub_order_id BIGINT REFERENCES sub_orders(id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS idx_order_items_sub_order_id ON order_items(sub_order_id);
+
+-- Split existing orders, parts a seller already shipped stay shipped
+INSERT INTO sub_orders (order_id, seller_id, total, status, shipped_at, created_at)
+SELECT o.id, p.owner_id, SUM(oi.price * oi.quantity),
+    CASE WHEN o.status = 'paid' AND f.status = 'shipped' THEN 'shipped' ELSE o.status END,
+    f.shipped_at, o.created_at
+FROM orders o
+INNER JOIN order_items oi ON oi.order_id = o.id
+INNER JOIN products p ON p.id = oi.product_id
+LEFT JOIN order_fulfilments f ON f.order_id = o.id AND f.seller_id = p.owner_id
+GROUP BY o.id, p.owner_id, f.status, f.shipped_at
+ON CONFLICT (order_id, seller_id) DO NOTHING;
+
+UPDATE order_items oi SET sub_order_id = s.id
+FROM products p, sub_orders s
+WHERE p.id = oi.product_id AND s.order_id = oi.order_id AND s.seller_id = p.owner_id;
+
+UPDATE orders o SET status = 'partially_shipped'
+WHERE o.status = 'paid' AND EXISTS (
+    SELECT 1 FROM sub_orders s WHERE s.order_id = o.id AND s.status = 'shipped'
+);
+
+DROP TABLE IF EXISTS order_fulfilments;
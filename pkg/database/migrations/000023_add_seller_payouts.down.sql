@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS payouts;
+DROP TABLE IF EXISTS payout_batches;
+DROP TABLE IF EXISTS ledger_entries;
+DROP TABLE IF EXISTS ledger_transactions;
+DROP TABLE IF EXISTS commission_rules;
+
+DROP INDEX IF EXISTS idx_products_category;
+ALTER TABLE products DROP COLUMN IF EXISTS category;
@@ -0,0 +1,70 @@
+-- Per-category commission overrides
+ALTER TABLE products ADD COLUMN IF NOT EXISTS category VARCHAR(50);
+CREATE INDEX IF NOT EXISTS idx_products_category ON products(category);
+
+-- Commission rate per scope, seller overrides win over category overrides
+-- and category overrides over the default
+CREATE TABLE IF NOT EXISTS commission_rules (
+    id BIGSERIAL PRIMARY KEY,
+    scope VARCHAR(20) NOT NULL CHECK (scope IN ('default', 'seller', 'category')),
+    seller_id BIGINT REFERENCES users(id) ON DELETE CASCADE,
+    category VARCHAR(50),
+    rate DECIMAL(5, 4) NOT NULL CHECK (rate >= 0 AND rate <= 1),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (
+        (scope = 'default' AND seller_id IS NULL AND category IS NULL) OR
+        (scope = 'seller' AND seller_id IS NOT NULL AND category IS NULL) OR
+        (scope = 'category' AND seller_id IS NULL AND category IS NOT NULL)
+    )
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_commission_rules_scope ON commission_rules(scope, COALESCE(seller_id, 0), COALESCE(category, ''));
+
+INSERT INTO commission_rules (scope, rate) VALUES ('default', 0.10)
+ON CONFLICT DO NOTHING;
+
+-- Double-entry ledger, the entries of a transaction balance
+CREATE TABLE IF NOT EXISTS ledger_transactions (
+    id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('earning', 'refund', 'payout', 'payout_paid', 'payout_reversal')),
+    seller_id BIGINT NOT NULL REFERENCES users(id),
+    ref_type VARCHAR(20) NOT NULL,
+    ref_id BIGINT NOT NULL,
+    memo TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- Posting the same event twice is a no-op
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_transactions_ref ON ledger_transactions(kind, ref_type, ref_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_transactions_seller_id ON ledger_transactions(seller_id, created_at);
+
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id BIGSERIAL PRIMARY KEY,
+    transaction_id BIGINT NOT NULL REFERENCES ledger_transactions(id),
+    account VARCHAR(30) NOT NULL CHECK (account IN ('order_clearing', 'seller_payable', 'platform_fees', 'payouts_payable', 'cash')),
+    debit DECIMAL(12, 2) NOT NULL DEFAULT 0 CHECK (debit >= 0),
+    credit DECIMAL(12, 2) NOT NULL DEFAULT 0 CHECK (credit >= 0),
+    CHECK ((debit = 0) <> (credit = 0))
+);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_transaction_id ON ledger_entries(transaction_id);
+
+-- Payout batches, one payout per seller with a positive balance
+CREATE TABLE IF NOT EXISTS payout_batches (
+    id BIGSERIAL PRIMARY KEY,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'paid', 'failed')),
+    total DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    reference VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    paid_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_payout_batches_status ON payout_batches(status, created_at);
+
+CREATE TABLE IF NOT EXISTS payouts (
+    id BIGSERIAL PRIMARY KEY,
+    batch_id BIGINT NOT NULL REFERENCES payout_batches(id),
+    seller_id BIGINT NOT NULL REFERENCES users(id),
+    amount DECIMAL(12, 2) NOT NULL CHECK (amount > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (batch_id, seller_id)
+);
+CREATE INDEX IF NOT EXISTS idx_payouts_seller_id ON payouts(seller_id, created_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS seller_applications;
+DROP TABLE IF EXISTS stores;
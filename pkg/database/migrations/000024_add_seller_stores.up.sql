@@ -0,0 +1,35 @@
+-- One store per seller, created when the seller application is approved
+CREATE TABLE IF NOT EXISTS stores (
+    id BIGSERIAL PRIMARY KEY,
+    seller_id BIGINT NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    slug VARCHAR(120) NOT NULL UNIQUE,
+    description TEXT,
+    logo_key VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS seller_applications (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    store_name VARCHAR(100) NOT NULL,
+    store_slug VARCHAR(120) NOT NULL,
+    description TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    reject_reason TEXT,
+    reviewed_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- A user has at most one application waiting for review
+CREATE UNIQUE INDEX IF NOT EXISTS idx_seller_applications_pending ON seller_applications(user_id) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_seller_applications_status ON seller_applications(status, created_at);
+
+-- Sellers set up before the application flow get a store of their own
+INSERT INTO stores (seller_id, name, slug)
+SELECT id, COALESCE(NULLIF(TRIM(CONCAT_WS(' ', first_name, last_name)), ''), 'Store ' || id), 'store-' || id
+FROM users
+WHERE role = 'seller'
+ON CONFLICT DO NOTHING;
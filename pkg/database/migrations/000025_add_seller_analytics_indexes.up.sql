@@ -0,0 +1,4 @@
+-- Seller analytics walk products of the owner to their order lines, the
+-- lines are read from the index alone
+CREATE INDEX IF NOT EXISTS idx_order_items_product_order ON order_items(product_id, order_id) INCLUDE (quantity, price, sub_order_id);
+CREATE INDEX IF NOT EXISTS idx_orders_created_at ON orders(created_at);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_orders_user_id;
+DROP INDEX IF EXISTS idx_orders_status_created_at;
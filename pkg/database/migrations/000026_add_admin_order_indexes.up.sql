@@ -0,0 +1,3 @@
+-- Admin order list, filtered by status or user and sorted by date
+CREATE INDEX IF NOT EXISTS idx_orders_status_created_at ON orders(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_orders_user_id ON orders(user_id, created_at);
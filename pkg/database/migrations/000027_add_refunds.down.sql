@@ -0,0 +1,12 @@
+-- Fails while a refund of several sellers is posted, the ledger is kept
+DROP INDEX IF EXISTS idx_ledger_transactions_ref;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_transactions_ref ON ledger_transactions(kind, ref_type, ref_id);
+
+DROP TABLE IF EXISTS refund_items;
+DROP TABLE IF EXISTS refunds;
+
+DROP INDEX IF EXISTS idx_order_items_product_order;
+CREATE INDEX IF NOT EXISTS idx_order_items_product_order ON order_items(product_id, order_id) INCLUDE (quantity, price, sub_order_id);
+
+ALTER TABLE order_items DROP CONSTRAINT IF EXISTS order_items_refunded_quantity_check;
+ALTER TABLE order_items DROP COLUMN IF EXISTS refunded_quantity;
@@ -0,0 +1,32 @@
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS refunded_quantity INT NOT NULL DEFAULT 0;
+ALTER TABLE order_items ADD CONSTRAINT order_items_refunded_quantity_check CHECK (refunded_quantity BETWEEN 0 AND quantity);
+
+-- Seller analytics net out refunded units
+DROP INDEX IF EXISTS idx_order_items_product_order;
+CREATE INDEX IF NOT EXISTS idx_order_items_product_order ON order_items(product_id, order_id) INCLUDE (quantity, refunded_quantity, price, sub_order_id);
+
+CREATE TABLE IF NOT EXISTS refunds (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    amount DECIMAL(10, 2) NOT NULL CHECK (amount > 0),
+    reason VARCHAR(30) NOT NULL CHECK (reason IN ('customer_request', 'damaged', 'defective', 'wrong_item', 'not_received', 'other')),
+    note TEXT,
+    restock BOOLEAN NOT NULL DEFAULT FALSE,
+    provider_ref VARCHAR(100),
+    created_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_refunds_order_id ON refunds(order_id);
+
+CREATE TABLE IF NOT EXISTS refund_items (
+    id BIGSERIAL PRIMARY KEY,
+    refund_id BIGINT NOT NULL REFERENCES refunds(id) ON DELETE CASCADE,
+    order_item_id BIGINT NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    amount DECIMAL(10, 2) NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_refund_items_refund_id ON refund_items(refund_id);
+
+-- A refund of several sellers posts once per seller
+DROP INDEX IF EXISTS idx_ledger_transactions_ref;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_transactions_ref ON ledger_transactions(kind, ref_type, ref_id, seller_id);
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS return_photos;
+DROP TABLE IF EXISTS return_items;
+DROP TABLE IF EXISTS returns;
+
+ALTER TABLE sub_orders DROP COLUMN IF EXISTS completed_at;
+
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_return_window_days_check;
+ALTER TABLE products DROP COLUMN IF EXISTS return_window_days;
@@ -0,0 +1,48 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS return_window_days INT NOT NULL DEFAULT 30;
+ALTER TABLE products ADD CONSTRAINT products_return_window_days_check CHECK (return_window_days BETWEEN 0 AND 365);
+
+ALTER TABLE sub_orders ADD COLUMN IF NOT EXISTS completed_at TIMESTAMPTZ;
+-- Completed before the column, the last update is the closest
+UPDATE sub_orders SET completed_at = updated_at WHERE status = 'completed';
+
+CREATE TABLE IF NOT EXISTS returns (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    sub_order_id BIGINT NOT NULL REFERENCES sub_orders(id) ON DELETE CASCADE,
+    seller_id BIGINT NOT NULL REFERENCES users(id),
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'requested' CHECK (status IN ('requested', 'approved', 'rejected', 'received', 'inspected', 'refunded')),
+    reason VARCHAR(30) NOT NULL CHECK (reason IN ('damaged', 'defective', 'wrong_item', 'not_as_described', 'changed_mind', 'other')),
+    note TEXT,
+    reject_reason TEXT,
+    inspection_note TEXT,
+    refund_id BIGINT REFERENCES refunds(id) ON DELETE SET NULL,
+    reviewed_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMPTZ,
+    received_at TIMESTAMPTZ,
+    inspected_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_returns_order_id ON returns(order_id);
+CREATE INDEX IF NOT EXISTS idx_returns_seller_id ON returns(seller_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_returns_status ON returns(status, created_at);
+
+CREATE TABLE IF NOT EXISTS return_items (
+    id BIGSERIAL PRIMARY KEY,
+    return_id BIGINT NOT NULL REFERENCES returns(id) ON DELETE CASCADE,
+    order_item_id BIGINT NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    quantity INT NOT NULL CHECK (quantity > 0)
+);
+CREATE INDEX IF NOT EXISTS idx_return_items_return_id ON return_items(return_id);
+CREATE INDEX IF NOT EXISTS idx_return_items_order_item_id ON return_items(order_item_id);
+
+CREATE TABLE IF NOT EXISTS return_photos (
+    id BIGSERIAL PRIMARY KEY,
+    return_id BIGINT NOT NULL REFERENCES returns(id) ON DELETE CASCADE,
+    object_key TEXT NOT NULL,
+    content_type VARCHAR(50) NOT NULL,
+    size BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_return_photos_return_id ON return_photos(return_id);
@@ -0,0 +1,40 @@
+-- One shipment per sub-order, created when the sub-order ships
+CREATE TABLE IF NOT EXISTS shipments (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    sub_order_id BIGINT NOT NULL UNIQUE REFERENCES sub_orders(id) ON DELETE CASCADE,
+    carrier VARCHAR(50),
+    tracking_number VARCHAR(100),
+    status VARCHAR(20) NOT NULL DEFAULT 'in_transit' CHECK (status IN ('in_transit', 'delivered')),
+    shipped_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMPTZ,
+    last_checked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_shipments_order_id ON shipments(order_id);
+CREATE INDEX IF NOT EXISTS idx_shipments_tracking ON shipments(last_checked_at NULLS FIRST) WHERE status = 'in_transit' AND tracking_number IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS shipment_items (
+    id BIGSERIAL PRIMARY KEY,
+    shipment_id BIGINT NOT NULL REFERENCES shipments(id) ON DELETE CASCADE,
+    order_item_id BIGINT NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    quantity INT NOT NULL CHECK (quantity > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_shipment_items_shipment_id ON shipment_items(shipment_id);
+
+-- Shipped before tracking, no tracking number so never polled
+INSERT INTO shipments (order_id, sub_order_id, status, shipped_at, delivered_at)
+SELECT order_id, id,
+    CASE WHEN status = 'completed' THEN 'delivered' ELSE 'in_transit' END,
+    shipped_at,
+    completed_at
+FROM sub_orders
+WHERE shipped_at IS NOT NULL;
+
+INSERT INTO shipment_items (shipment_id, order_item_id, quantity)
+SELECT s.id, oi.id, oi.quantity
+FROM shipments s
+INNER JOIN order_items oi ON oi.sub_order_id = s.sub_order_id;
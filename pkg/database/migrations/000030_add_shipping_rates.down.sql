@@ -0,0 +1,15 @@
+DROP TABLE IF EXISTS shipping_rates;
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS shipping_amount,
+    DROP COLUMN IF EXISTS shipping_method;
+
+ALTER TABLE carts
+    DROP COLUMN IF EXISTS shipping_country,
+    DROP COLUMN IF EXISTS shipping_method;
+
+ALTER TABLE products
+    DROP COLUMN IF EXISTS height_cm,
+    DROP COLUMN IF EXISTS width_cm,
+    DROP COLUMN IF EXISTS length_cm,
+    DROP COLUMN IF EXISTS weight_grams;
@@ -0,0 +1,38 @@
+-- Shipping weight & dimensions of one unit, 0 is unknown
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS weight_grams INT NOT NULL DEFAULT 0 CHECK (weight_grams >= 0),
+    ADD COLUMN IF NOT EXISTS length_cm INT NOT NULL DEFAULT 0 CHECK (length_cm >= 0),
+    ADD COLUMN IF NOT EXISTS width_cm INT NOT NULL DEFAULT 0 CHECK (width_cm >= 0),
+    ADD COLUMN IF NOT EXISTS height_cm INT NOT NULL DEFAULT 0 CHECK (height_cm >= 0);
+
+-- Shipping chosen in the cart, no country is the home country
+ALTER TABLE carts
+    ADD COLUMN IF NOT EXISTS shipping_method VARCHAR(20) NOT NULL DEFAULT 'standard',
+    ADD COLUMN IF NOT EXISTS shipping_country VARCHAR(2);
+
+-- Orders placed before shipping rates have no method
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS shipping_method VARCHAR(20),
+    ADD COLUMN IF NOT EXISTS shipping_amount DECIMAL(10, 2) NOT NULL DEFAULT 0 CHECK (shipping_amount >= 0);
+
+-- Price of a method per zone, a method without a rate is not offered
+CREATE TABLE IF NOT EXISTS shipping_rates (
+    id BIGSERIAL PRIMARY KEY,
+    method VARCHAR(20) NOT NULL CHECK (method IN ('standard', 'express', 'pickup')),
+    zone VARCHAR(20) NOT NULL CHECK (zone IN ('domestic', 'international')),
+    base_fee DECIMAL(10, 2) NOT NULL CHECK (base_fee >= 0),
+    per_kg_fee DECIMAL(10, 2) NOT NULL DEFAULT 0 CHECK (per_kg_fee >= 0),
+    max_weight_grams INT NOT NULL DEFAULT 0 CHECK (max_weight_grams >= 0),
+    free_over DECIMAL(10, 2) NOT NULL DEFAULT 0 CHECK (free_over >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (method, zone)
+);
+
+INSERT INTO shipping_rates (method, zone, base_fee, per_kg_fee, max_weight_grams, free_over) VALUES
+('standard', 'domestic',      50.00,   10.00,  30000, 1000.00),
+('express',  'domestic',      120.00,  20.00,  20000, 0),
+('pickup',   'domestic',      0,       0,      0,     0),
+('standard', 'international', 600.00,  300.00, 20000, 0),
+('express',  'international', 1500.00, 500.00, 20000, 0)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_stock_events_pending;
+CREATE INDEX IF NOT EXISTS idx_stock_events_pending ON stock_events(id) WHERE processed_at IS NULL;
+
+ALTER TABLE stock_events DROP COLUMN IF EXISTS last_error;
+ALTER TABLE stock_events DROP COLUMN IF EXISTS attempts;
@@ -0,0 +1,6 @@
+-- Failed events are retried after the others and given up after a few attempts
+ALTER TABLE stock_events ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE stock_events ADD COLUMN IF NOT EXISTS last_error TEXT;
+
+DROP INDEX IF EXISTS idx_stock_events_pending;
+CREATE INDEX IF NOT EXISTS idx_stock_events_pending ON stock_events(attempts, id) WHERE processed_at IS NULL;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_cart_recoveries_pending;
+CREATE INDEX IF NOT EXISTS idx_cart_recoveries_pending ON cart_recoveries(id) WHERE notified_at IS NULL;
+
+ALTER TABLE cart_recoveries DROP COLUMN IF EXISTS last_error;
+ALTER TABLE cart_recoveries DROP COLUMN IF EXISTS attempts;
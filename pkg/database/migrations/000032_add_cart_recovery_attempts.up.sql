@@ -0,0 +1,6 @@
+-- Failed notifications are retried after the others and given up after a few attempts
+ALTER TABLE cart_recoveries ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE cart_recoveries ADD COLUMN IF NOT EXISTS last_error TEXT;
+
+DROP INDEX IF EXISTS idx_cart_recoveries_pending;
+CREATE INDEX IF NOT EXISTS idx_cart_recoveries_pending ON cart_recoveries(attempts, id) WHERE notified_at IS NULL;
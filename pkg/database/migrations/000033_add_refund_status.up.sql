@@ -0,0 +1,8 @@
+-- Refunds are committed pending, then paid by the provider with the refund
+-- id as idempotency key. Pending refunds are retried by the refund job.
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'settled' CHECK (status IN ('pending', 'settled', 'declined'));
+ALTER TABLE refunds ALTER COLUMN status SET DEFAULT 'pending';
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS last_error TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_refunds_pending ON refunds(attempts, id) WHERE status = 'pending';
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_orders_refund_status;
+
+UPDATE orders SET status = 'partially_refunded' WHERE refund_status = 'partial';
+
+ALTER TABLE orders DROP COLUMN IF EXISTS refund_status;
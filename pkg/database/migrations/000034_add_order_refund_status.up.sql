@@ -0,0 +1,27 @@
+-- Refunds are tracked apart from the fulfilment status, a partially refunded
+-- order still ships & completes
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS refund_status VARCHAR(20) NOT NULL DEFAULT 'none' CHECK (refund_status IN ('none', 'partial', 'full'));
+
+UPDATE orders SET refund_status = 'full' WHERE status = 'refunded';
+UPDATE orders SET refund_status = 'partial' WHERE status = 'partially_refunded';
+
+-- Partially refunded orders get back the status of their active sub-orders
+UPDATE orders o SET status = CASE
+		WHEN a.statuses = 1 THEN a.first_status
+		WHEN a.pending THEN 'pending'
+		WHEN a.paid THEN 'partially_shipped'
+		ELSE 'shipped'
+	END
+FROM (
+	SELECT s.order_id,
+		COUNT(DISTINCT s.status) AS statuses,
+		MIN(s.status) AS first_status,
+		BOOL_OR(s.status = 'pending') AS pending,
+		BOOL_OR(s.status = 'paid') AS paid
+	FROM sub_orders s
+	WHERE s.status NOT IN ('cancelled', 'refunded')
+	GROUP BY s.order_id
+) a
+WHERE a.order_id = o.id AND o.status = 'partially_refunded';
+
+CREATE INDEX IF NOT EXISTS idx_orders_refund_status ON orders(refund_status, created_at) WHERE refund_status <> 'none';
@@ -0,0 +1,2 @@
+-- Shipping paid back with the refund of the last line, included in amount
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS shipping_amount DECIMAL(10, 2) NOT NULL DEFAULT 0 CHECK (shipping_amount >= 0);
@@ -1,6 +1,8 @@
 package jwt
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"time"
@@ -87,3 +89,159 @@ func (t *JWTToken) verifyToken(key, tokenStr string) (*UserClaims, error) {
 	}
 	return claims, nil
 }
+
+// Link tokens are signed with a key per purpose, derived from the secret
+// key, so one is never accepted as another or as an access token
+const (
+	purposeCartRecovery = "cart-recovery"
+	purposeOrderLookup  = "order-lookup"
+	purposeOrderClaim   = "order-claim"
+	purposeQuote        = "checkout-quote"
+)
+
+// CartRecoveryClaims deep link of the abandoned cart notification
+type CartRecoveryClaims struct {
+	CartID string
+	UserID int64
+	*jwt.RegisteredClaims
+}
+
+// OrderLookupClaims guest order tracking without an account
+type OrderLookupClaims struct {
+	OrderID int64
+	Email   string
+	*jwt.RegisteredClaims
+}
+
+// OrderClaimClaims sent to the account email, proves the user owns it
+type OrderClaimClaims struct {
+	UserID int64
+	Email  string
+	*jwt.RegisteredClaims
+}
+
+func (t *JWTToken) GenerateCartRecoveryToken(cartID string, userID int64, duration time.Duration) (string, error) {
+	return t.signLinkToken(purposeCartRecovery, &CartRecoveryClaims{
+		CartID:           cartID,
+		UserID:           userID,
+		RegisteredClaims: registeredClaims(duration),
+	})
+}
+
+func (t *JWTToken) VerifyCartRecoveryToken(tokenStr string) (*CartRecoveryClaims, error) {
+	claims := new(CartRecoveryClaims)
+	if err := t.parseLinkToken(purposeCartRecovery, tokenStr, claims); err != nil {
+		return nil, err
+	}
+	if claims.CartID == "" {
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
+}
+
+func (t *JWTToken) GenerateOrderLookupToken(orderID int64, email string) (string, error) {
+	return t.signLinkToken(purposeOrderLookup, &OrderLookupClaims{
+		OrderID:          orderID,
+		Email:            email,
+		RegisteredClaims: registeredClaims(consts.OrderLookupTokenDuration),
+	})
+}
+
+func (t *JWTToken) VerifyOrderLookupToken(tokenStr string) (*OrderLookupClaims, error) {
+	claims := new(OrderLookupClaims)
+	if err := t.parseLinkToken(purposeOrderLookup, tokenStr, claims); err != nil {
+		return nil, err
+	}
+	if claims.OrderID == 0 || claims.Email == "" {
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
+}
+
+func (t *JWTToken) GenerateOrderClaimToken(userID int64, email string) (string, error) {
+	return t.signLinkToken(purposeOrderClaim, &OrderClaimClaims{
+		UserID:           userID,
+		Email:            email,
+		RegisteredClaims: registeredClaims(consts.OrderClaimTokenDuration),
+	})
+}
+
+func (t *JWTToken) VerifyOrderClaimToken(tokenStr string) (*OrderClaimClaims, error) {
+	claims := new(OrderClaimClaims)
+	if err := t.parseLinkToken(purposeOrderClaim, tokenStr, claims); err != nil {
+		return nil, err
+	}
+	if claims.UserID == 0 || claims.Email == "" {
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
+}
+
+// QuoteClaims Digest covers the priced cart lines, a drifted cart
+// no longer matches
+type QuoteClaims struct {
+	CartID string
+	Digest string
+	*jwt.RegisteredClaims
+}
+
+func (t *JWTToken) GenerateQuoteToken(cartID, digest string) (string, time.Time, error) {
+	claims := &QuoteClaims{
+		CartID:           cartID,
+		Digest:           digest,
+		RegisteredClaims: registeredClaims(consts.CheckoutQuoteDuration),
+	}
+	ss, err := t.signLinkToken(purposeQuote, claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return ss, claims.ExpiresAt.Time, nil
+}
+
+func (t *JWTToken) VerifyQuoteToken(tokenStr string) (*QuoteClaims, error) {
+	claims := new(QuoteClaims)
+	if err := t.parseLinkToken(purposeQuote, tokenStr, claims); err != nil {
+		return nil, err
+	}
+	if claims.CartID == "" || claims.Digest == "" {
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
+}
+
+func registeredClaims(duration time.Duration) *jwt.RegisteredClaims {
+	return &jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		Issuer:    "mini-ecommerce",
+	}
+}
+
+func (t *JWTToken) signLinkToken(purpose string, claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+
+	ss, err := token.SignedString(t.linkKey(purpose))
+	if err != nil {
+		return "", fmt.Errorf("signed token failed: %w", err)
+	}
+	return ss, nil
+}
+
+func (t *JWTToken) parseLinkToken(purpose, tokenStr string, claims jwt.Claims) error {
+	token, err := jwt.ParseWithClaims(tokenStr, claims, func(*jwt.Token) (any, error) {
+		return t.linkKey(purpose), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return err
+	}
+	if !token.Valid {
+		return errors.New("invalid token")
+	}
+	return nil
+}
+
+func (t *JWTToken) linkKey(purpose string) []byte {
+	mac := hmac.New(sha256.New, []byte(t.secretKey))
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
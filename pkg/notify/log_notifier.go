@@ -0,0 +1,20 @@
+package notify
+
+import (
+	"context"
+	"log"
+)
+
+// LogNotifier writes messages to the log, for development until a mail provider is set up
+type LogNotifier struct {
+	from string
+}
+
+func NewLogNotifier(from string) *LogNotifier {
+	return &LogNotifier{from: from}
+}
+
+func (n *LogNotifier) Send(ctx context.Context, msg *Message) error {
+	log.Printf("notify: from=%s to=%s subject=%q body=%q", n.from, msg.To, msg.Subject, msg.Body)
+	return nil
+}
@@ -0,0 +1,27 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/codepnw/mini-ecommerce/pkg/config"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Notifier interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+func NewNotifier(cfg config.NotifyConfig) (Notifier, error) {
+	switch cfg.Driver {
+	case "log":
+		return NewLogNotifier(cfg.From), nil
+	default:
+		return nil, fmt.Errorf("unknown notify driver: %s", cfg.Driver)
+	}
+}
@@ -0,0 +1,49 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+)
+
+// FakeProvider accepts every refund and keeps it in memory, for development
+// & tests. Err makes the next refunds fail.
+type FakeProvider struct {
+	Err error
+
+	mu      sync.Mutex
+	refunds map[int64]*RefundRequest
+}
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{refunds: make(map[int64]*RefundRequest)}
+}
+
+func (p *FakeProvider) Refund(ctx context.Context, req *RefundRequest) (*RefundResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.Err != nil {
+		return nil, p.Err
+	}
+	if _, ok := p.refunds[req.RefundID]; !ok {
+		log.Printf("payment: refund #%d of order #%d amount=%.2f reason=%s", req.RefundID, req.OrderID, req.Amount, req.Reason)
+		p.refunds[req.RefundID] = req
+	}
+	return &RefundResult{Reference: fmt.Sprintf("fake_re_%d", req.RefundID)}, nil
+}
+
+// Refunds paid back so far, once per RefundID in RefundID order
+func (p *FakeProvider) Refunds() []*RefundRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	refunds := make([]*RefundRequest, 0, len(p.refunds))
+	for _, r := range p.refunds {
+		refunds = append(refunds, r)
+	}
+	sort.Slice(refunds, func(i, j int) bool { return refunds[i].RefundID < refunds[j].RefundID })
+	return refunds
+}
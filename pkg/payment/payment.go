@@ -0,0 +1,38 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/codepnw/mini-ecommerce/pkg/config"
+)
+
+// ErrDeclined the provider refused the refund, nothing was paid back
+var ErrDeclined = errors.New("payment provider declined the refund")
+
+// RefundRequest RefundID is the idempotency key, the refund is committed
+// before it is sent so a retried refund is not paid twice
+type RefundRequest struct {
+	OrderID  int64
+	RefundID int64
+	Amount   float64
+	Reason   string
+}
+
+type RefundResult struct {
+	Reference string
+}
+
+type Provider interface {
+	Refund(ctx context.Context, req *RefundRequest) (*RefundResult, error)
+}
+
+func NewProvider(cfg config.PaymentConfig) (Provider, error) {
+	switch cfg.Driver {
+	case "fake":
+		return NewFakeProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown payment driver: %s", cfg.Driver)
+	}
+}
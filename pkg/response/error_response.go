@@ -10,6 +10,7 @@ type ErrorResponse struct {
 	Code    int    `json:"code"`
 	Type    string `json:"type"`
 	Message string `json:"message"`
+	Details any    `json:"details,omitempty"`
 }
 
 func BadRequest(c *gin.Context, message string) {
@@ -61,3 +62,35 @@ func InternalServerError(c *gin.Context, err error) {
 		},
 	})
 }
+
+func PreconditionFailed(c *gin.Context, message string) {
+	c.JSON(http.StatusPreconditionFailed, gin.H{
+		"error": ErrorResponse{
+			Code:    http.StatusPreconditionFailed,
+			Type:    "PRECONDITION_FAILED",
+			Message: message,
+		},
+	})
+}
+
+func Conflict(c *gin.Context, message string, details any) {
+	c.JSON(http.StatusConflict, gin.H{
+		"error": ErrorResponse{
+			Code:    http.StatusConflict,
+			Type:    "CONFLICT",
+			Message: message,
+			Details: details,
+		},
+	})
+}
+
+func UnprocessableEntity(c *gin.Context, message string, details any) {
+	c.JSON(http.StatusUnprocessableEntity, gin.H{
+		"error": ErrorResponse{
+			Code:    http.StatusUnprocessableEntity,
+			Type:    "UNPROCESSABLE_ENTITY",
+			Message: message,
+			Details: details,
+		},
+	})
+}
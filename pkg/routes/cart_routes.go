@@ -1,22 +1,46 @@
 package routes
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"time"
 
 	carthandler "github.com/codepnw/mini-ecommerce/internal/cart/handler"
 	cartrepository "github.com/codepnw/mini-ecommerce/internal/cart/repository"
 	cartusecase "github.com/codepnw/mini-ecommerce/internal/cart/usecase"
+	inventoryrepository "github.com/codepnw/mini-ecommerce/internal/inventory/repository"
 	productrepository "github.com/codepnw/mini-ecommerce/internal/product/repository"
+	"github.com/codepnw/mini-ecommerce/internal/user"
 	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+	"github.com/codepnw/mini-ecommerce/pkg/scheduler"
 )
 
-func (cfg *routeConfig) CartRoutes() {
+func (cfg *routeConfig) CartRoutes() error {
 	prodRepo := productrepository.NewProductRepository(cfg.db)
 	cartRepo := cartrepository.NewCartRepository(cfg.db)
-	uc := cartusecase.NewCartUsecase(cartRepo, prodRepo, cfg.tx, cfg.db)
+	invRepo := inventoryrepository.NewInventoryRepository(cfg.db)
+
+	// Reservation Mode (Optional)
+	var holdTTL time.Duration
+	if cfg.config.Inventory.ReservationEnabled {
+		holdTTL = cfg.config.Inventory.ReservationTTL
+	}
+	shippingUc, err := cfg.shippingUsecase()
+	if err != nil {
+		return err
+	}
+	uc := cartusecase.NewCartUsecase(cartRepo, prodRepo, invRepo, shippingUc, cfg.tx, cfg.db, holdTTL)
 	handler := carthandler.NewCartHandler(uc)
 
+	recoveryUc, err := cfg.recoveryUsecase(cartRepo, uc)
+	if err != nil {
+		return err
+	}
+	recovery := carthandler.NewRecoveryHandler(recoveryUc)
+
 	cartItemID := fmt.Sprintf("/items/:%s", consts.CartItemID)
+	savedItemID := fmt.Sprintf("/saved/:%s", consts.CartItemID)
 	cartRoutes := cfg.router.Group("/cart")
 
 	// Session or Authorized
@@ -26,5 +50,98 @@ func (cfg *routeConfig) CartRoutes() {
 		cartRoutes.GET("/", handler.GetCart)
 		cartRoutes.PATCH(cartItemID, handler.UpdateItemQuantity)
 		cartRoutes.DELETE(cartItemID, handler.RemoveItemFromCart)
+		cartRoutes.POST("/accept-changes", handler.AcceptChanges)
+		cartRoutes.GET("/shipping", handler.ShippingOptions)
+		cartRoutes.PUT("/shipping", handler.SetShipping)
+		// Saved for later (Authorized)
+		cartRoutes.POST(cartItemID+"/save-for-later", handler.SaveForLater)
+		cartRoutes.GET("/saved", handler.GetSavedItems)
+		cartRoutes.POST(savedItemID+"/move-to-cart", handler.MoveSavedToCart)
+		cartRoutes.DELETE(savedItemID, handler.RemoveSavedItem)
+	}
+
+	wishlistID := fmt.Sprintf("/:%s", consts.ParamWishlistID)
+	wishlistItemID := fmt.Sprintf("%s/items/:%s", wishlistID, consts.CartItemID)
+	wishlistRoutes := cfg.router.Group("/wishlists", cfg.auth.AuthorizedMiddleware())
+	{
+		wishlistRoutes.GET("/", handler.ListWishlists)
+		wishlistRoutes.POST("/", handler.CreateWishlist)
+		wishlistRoutes.GET(wishlistID, handler.GetWishlist)
+		wishlistRoutes.PATCH(wishlistID, handler.RenameWishlist)
+		wishlistRoutes.DELETE(wishlistID, handler.DeleteWishlist)
+		wishlistRoutes.POST(wishlistID+"/items", handler.AddWishlistItem)
+		wishlistRoutes.DELETE(wishlistItemID, handler.RemoveWishlistItem)
+		wishlistRoutes.POST(wishlistItemID+"/move-to-cart", handler.MoveWishlistItemToCart)
+	}
+
+	// Abandoned cart link (Authorized)
+	cfg.router.POST("/cart/recover", cfg.auth.AuthorizedMiddleware(), recovery.RecoverCart)
+
+	// For Admin
+	admin := cfg.router.Group("/admin/carts")
+	admin.Use(cfg.auth.AuthorizedMiddleware(), cfg.auth.RolesRequired(user.RoleAdmin))
+	{
+		admin.GET("/recovery-stats", recovery.RecoveryStats)
 	}
+	return nil
+}
+
+// CartJobs starts the abandoned cart job and the guest cart purge
+func (cfg *routeConfig) CartJobs(ctx context.Context) error {
+	prodRepo := productrepository.NewProductRepository(cfg.db)
+	cartRepo := cartrepository.NewCartRepository(cfg.db)
+	invRepo := inventoryrepository.NewInventoryRepository(cfg.db)
+	shippingUc, err := cfg.shippingUsecase()
+	if err != nil {
+		return err
+	}
+	carts := cartusecase.NewCartUsecase(cartRepo, prodRepo, invRepo, shippingUc, cfg.tx, cfg.db, 0)
+
+	uc, err := cfg.recoveryUsecase(cartRepo, carts)
+	if err != nil {
+		return err
+	}
+
+	scheduler.Every(ctx, "abandoned carts", cfg.config.Cart.JobInterval, func(ctx context.Context) error {
+		abandoned, err := uc.AbandonIdleCarts(ctx)
+		if err != nil {
+			return err
+		}
+		if abandoned > 0 {
+			log.Printf("cart: marked %d carts abandoned", abandoned)
+		}
+
+		// Also retries notifications that failed on an earlier run
+		sent, err := uc.SendRecoveryNotifications(ctx)
+		if sent > 0 {
+			log.Printf("cart: sent %d recovery notifications", sent)
+		}
+		return err
+	})
+
+	scheduler.Every(ctx, "purge guest carts", cfg.config.Cart.JobInterval, func(ctx context.Context) error {
+		purged, err := uc.PurgeGuestCarts(ctx)
+		if err != nil {
+			return err
+		}
+		if purged > 0 {
+			log.Printf("cart: purged %d guest carts", purged)
+		}
+		return nil
+	})
+	return nil
+}
+
+func (cfg *routeConfig) recoveryUsecase(cartRepo cartrepository.CartRepository, carts cartusecase.CartUsecase) (cartusecase.RecoveryUsecase, error) {
+	return cartusecase.NewRecoveryUsecase(&cartusecase.RecoveryUsecaseConfig{
+		CartRepo:     cartRepo,
+		Carts:        carts,
+		Notifier:     cfg.notify,
+		Token:        cfg.token,
+		Tx:           cfg.tx,
+		AbandonAfter: cfg.config.Cart.AbandonAfter,
+		GuestTTL:     cfg.config.Cart.GuestTTL,
+		RecoveryTTL:  cfg.config.Cart.RecoveryTTL,
+		RecoveryURL:  cfg.config.Cart.RecoveryURL,
+	})
 }
@@ -0,0 +1,69 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	inventoryhandler "github.com/codepnw/mini-ecommerce/internal/inventory/handler"
+	inventoryrepository "github.com/codepnw/mini-ecommerce/internal/inventory/repository"
+	inventoryusecase "github.com/codepnw/mini-ecommerce/internal/inventory/usecase"
+	productrepository "github.com/codepnw/mini-ecommerce/internal/product/repository"
+	"github.com/codepnw/mini-ecommerce/internal/user"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+	"github.com/codepnw/mini-ecommerce/pkg/scheduler"
+)
+
+func (cfg *routeConfig) InventoryRoutes() {
+	repo := inventoryrepository.NewInventoryRepository(cfg.db)
+	prodRepo := productrepository.NewProductRepository(cfg.db)
+	uc := inventoryusecase.NewInventoryUsecase(repo, prodRepo, cfg.notify, cfg.tx, cfg.db)
+	handler := inventoryhandler.NewInventoryHandler(uc)
+
+	warehouseID := fmt.Sprintf("/:%s", consts.ParamWarehouseID)
+	productID := fmt.Sprintf("/:%s", consts.ParamProductID)
+
+	// For Admin
+	admin := cfg.router.Group("/admin/inventory")
+	admin.Use(cfg.auth.AuthorizedMiddleware(), cfg.auth.RolesRequired(user.RoleAdmin))
+	{
+		admin.GET("/movements", handler.ListMovements)
+		admin.GET("/reconcile", handler.Reconcile)
+
+		// Warehouses
+		admin.GET("/warehouses", handler.ListWarehouses)
+		admin.POST("/warehouses", handler.CreateWarehouse)
+		admin.PATCH("/warehouses"+warehouseID, handler.UpdateWarehouse)
+		admin.PUT("/warehouses"+warehouseID+"/stock", handler.SetStock)
+		admin.GET("/products"+productID+"/stock", handler.GetStockLevels)
+	}
+}
+
+// InventoryJobs starts the stock event job and the reservation sweeper (reservation mode only)
+func (cfg *routeConfig) InventoryJobs(ctx context.Context) {
+	repo := inventoryrepository.NewInventoryRepository(cfg.db)
+	prodRepo := productrepository.NewProductRepository(cfg.db)
+	uc := inventoryusecase.NewInventoryUsecase(repo, prodRepo, cfg.notify, cfg.tx, cfg.db)
+
+	scheduler.Every(ctx, "process stock events", cfg.config.Inventory.EventInterval, func(ctx context.Context) error {
+		processed, err := uc.ProcessStockEvents(ctx)
+		if processed > 0 {
+			log.Printf("inventory: processed %d stock events", processed)
+		}
+		return err
+	})
+
+	if !cfg.config.Inventory.ReservationEnabled {
+		return
+	}
+	scheduler.Every(ctx, "release expired reservations", cfg.config.Inventory.SweepInterval, func(ctx context.Context) error {
+		released, err := uc.ReleaseExpiredReservations(ctx)
+		if err != nil {
+			return err
+		}
+		if released > 0 {
+			log.Printf("inventory: released %d expired reservations", released)
+		}
+		return nil
+	})
+}
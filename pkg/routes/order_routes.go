@@ -1,23 +1,27 @@
 package routes
 
 import (
+	"context"
 	"fmt"
+	"log"
 
 	cartrepository "github.com/codepnw/mini-ecommerce/internal/cart/repository"
+	"github.com/codepnw/mini-ecommerce/internal/inventory"
+	inventoryrepository "github.com/codepnw/mini-ecommerce/internal/inventory/repository"
 	orderhandler "github.com/codepnw/mini-ecommerce/internal/order/handler"
 	orderrepository "github.com/codepnw/mini-ecommerce/internal/order/repository"
 	orderusecase "github.com/codepnw/mini-ecommerce/internal/order/usecase"
 	productrepository "github.com/codepnw/mini-ecommerce/internal/product/repository"
 	"github.com/codepnw/mini-ecommerce/internal/user"
 	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+	"github.com/codepnw/mini-ecommerce/pkg/scheduler"
 )
 
-func (cfg *routeConfig) OrderRoutes() {
-	prodRepo := productrepository.NewProductRepository(cfg.db)
-	cartRepo := cartrepository.NewCartRepository(cfg.db)
-	orderRepo := orderrepository.NewOrderRepository(cfg.db)
-
-	uc := orderusecase.NewOrderUsecase(orderRepo, prodRepo, cartRepo, cfg.tx, cfg.db)
+func (cfg *routeConfig) OrderRoutes() error {
+	uc, err := cfg.orderUsecase()
+	if err != nil {
+		return err
+	}
 	handler := orderhandler.NewOrderHandler(uc)
 
 	orderID := fmt.Sprintf("/:%s", consts.ParamOrderID)
@@ -28,12 +32,88 @@ func (cfg *routeConfig) OrderRoutes() {
 		r.GET(orderID, handler.GetOrderDetail)
 		r.GET("/", handler.GetMyOrders)
 		r.POST(orderID, handler.CancelOrder)
+		// Guest orders with the account email
+		r.POST("/claim", handler.RequestGuestOrderClaim)
+		r.POST("/claim/confirm", handler.ConfirmGuestOrderClaim)
+	}
+
+	// Checkout Preview (Session or Authorized)
+	checkout := cfg.router.Group("/checkout", cfg.auth.SessionMiddleware())
+	{
+		checkout.POST("/quote", handler.CheckoutQuote)
+	}
+
+	// Guest Checkout
+	guest := cfg.router.Group("/guest/orders")
+	{
+		guest.POST("/", cfg.auth.SessionMiddleware(), handler.GuestCheckout)
+		guest.GET("/lookup", handler.LookupGuestOrder)
+	}
+
+	// For Seller (own lines only)
+	seller := cfg.router.Group("/seller/orders")
+	seller.Use(cfg.auth.AuthorizedMiddleware(), cfg.auth.RolesRequired(user.RoleSeller, user.RoleAdmin))
+	{
+		seller.GET("/", handler.GetSellerOrders)
+		seller.GET(orderID, handler.GetSellerOrder)
+		// Optional carrier & tracking number
+		seller.POST(fmt.Sprintf("%s/ship", orderID), handler.ShipSellerOrder)
+		// Tracking of a parcel shipped without it
+		seller.PATCH(fmt.Sprintf("%s/shipment", orderID), handler.SetSellerShipmentTracking)
 	}
 
 	// For Admin
 	admin := cfg.router.Group("/admin/orders")
 	admin.Use(cfg.auth.AuthorizedMiddleware(), cfg.auth.RolesRequired(user.RoleAdmin))
 	{
+		admin.GET("/", handler.ListOrders)
+		admin.POST("/bulk-status", handler.BulkUpdateOrderStatus)
 		admin.PATCH(fmt.Sprintf("%s/status", orderID), handler.UpdateOrderStatus)
+		admin.PATCH(fmt.Sprintf("%s/sub-orders/:%s/status", orderID, consts.ParamSubOrderID), handler.UpdateSubOrderStatus)
+		admin.PATCH(fmt.Sprintf("%s/sub-orders/:%s/shipment", orderID, consts.ParamSubOrderID), handler.SetShipmentTracking)
+	}
+	return nil
+}
+
+// OrderJobs starts the shipment tracking
+func (cfg *routeConfig) OrderJobs(ctx context.Context) error {
+	uc, err := cfg.orderUsecase()
+	if err != nil {
+		return err
+	}
+
+	scheduler.Every(ctx, "track shipments", cfg.config.Carrier.JobInterval, func(ctx context.Context) error {
+		delivered, err := uc.TrackShipments(ctx)
+		if delivered > 0 {
+			log.Printf("order: %d shipments delivered", delivered)
+		}
+		return err
+	})
+	return nil
+}
+
+func (cfg *routeConfig) orderUsecase() (orderusecase.OrderUsecase, error) {
+	allocator, err := inventory.NewAllocationStrategy(cfg.config.Inventory.AllocationStrategy)
+	if err != nil {
+		return nil, err
 	}
+	shipping, err := cfg.shippingUsecase()
+	if err != nil {
+		return nil, err
+	}
+
+	return orderusecase.NewOrderUsecase(
+		orderrepository.NewOrderRepository(cfg.db),
+		productrepository.NewProductRepository(cfg.db),
+		cartrepository.NewCartRepository(cfg.db),
+		inventoryrepository.NewInventoryRepository(cfg.db),
+		allocator,
+		cfg.token,
+		cfg.notify,
+		cfg.config.Order.ClaimURL,
+		cfg.tracker,
+		shipping,
+		cfg.tx,
+		cfg.db,
+	), nil
 }
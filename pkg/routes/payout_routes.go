@@ -0,0 +1,91 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	payouthandler "github.com/codepnw/mini-ecommerce/internal/payout/handler"
+	payoutrepository "github.com/codepnw/mini-ecommerce/internal/payout/repository"
+	payoutusecase "github.com/codepnw/mini-ecommerce/internal/payout/usecase"
+	"github.com/codepnw/mini-ecommerce/internal/user"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+	"github.com/codepnw/mini-ecommerce/pkg/scheduler"
+)
+
+func (cfg *routeConfig) PayoutRoutes() error {
+	uc, err := cfg.payoutUsecase()
+	if err != nil {
+		return err
+	}
+	handler := payouthandler.NewPayoutHandler(uc)
+
+	// For Seller (own balance only)
+	seller := cfg.router.Group("/seller/payouts")
+	seller.Use(cfg.auth.AuthorizedMiddleware(), cfg.auth.RolesRequired(user.RoleSeller, user.RoleAdmin))
+	{
+		seller.GET("/", handler.GetSellerPayouts)
+		seller.GET("/statement", handler.GetStatement)
+	}
+
+	// For Admin
+	commissionID := fmt.Sprintf("/:%s", consts.ParamCommissionID)
+	commissions := cfg.router.Group("/admin/commissions")
+	commissions.Use(cfg.auth.AuthorizedMiddleware(), cfg.auth.RolesRequired(user.RoleAdmin))
+	{
+		commissions.GET("/", handler.ListCommissionRules)
+		commissions.PUT("/", handler.SetCommissionRule)
+		commissions.DELETE(commissionID, handler.DeleteCommissionRule)
+	}
+
+	batchID := fmt.Sprintf("/:%s", consts.ParamBatchID)
+	batches := cfg.router.Group("/admin/payouts/batches")
+	batches.Use(cfg.auth.AuthorizedMiddleware(), cfg.auth.RolesRequired(user.RoleAdmin))
+	{
+		batches.GET("/", handler.ListBatches)
+		batches.POST("/", handler.CreateBatch)
+		batches.GET(batchID, handler.GetBatch)
+		batches.PATCH(batchID+"/status", handler.UpdateBatchStatus)
+	}
+	return nil
+}
+
+// PayoutJobs starts the earning posting and the payout batches
+func (cfg *routeConfig) PayoutJobs(ctx context.Context) error {
+	uc, err := cfg.payoutUsecase()
+	if err != nil {
+		return err
+	}
+
+	scheduler.Every(ctx, "post earnings", cfg.config.Payout.JobInterval, func(ctx context.Context) error {
+		posted, err := uc.PostEarnings(ctx)
+		if err != nil {
+			return err
+		}
+		if posted > 0 {
+			log.Printf("payout: posted %d seller earnings", posted)
+		}
+		return nil
+	})
+
+	scheduler.Every(ctx, "payout batches", cfg.config.Payout.BatchInterval, func(ctx context.Context) error {
+		batch, err := uc.RunBatch(ctx)
+		if err != nil {
+			return err
+		}
+		if batch != nil {
+			log.Printf("payout: batch #%d of %d sellers, total %.2f", batch.ID, len(batch.Payouts), batch.Total)
+		}
+		return nil
+	})
+	return nil
+}
+
+func (cfg *routeConfig) payoutUsecase() (payoutusecase.PayoutUsecase, error) {
+	return payoutusecase.NewPayoutUsecase(&payoutusecase.PayoutUsecaseConfig{
+		Repo:      payoutrepository.NewPayoutRepository(cfg.db),
+		Tx:        cfg.tx,
+		DB:        cfg.db,
+		MinPayout: cfg.config.Payout.MinAmount,
+	})
+}
@@ -3,6 +3,7 @@ package routes
 import (
 	"fmt"
 
+	inventoryrepository "github.com/codepnw/mini-ecommerce/internal/inventory/repository"
 	producthandler "github.com/codepnw/mini-ecommerce/internal/product/handler"
 	productrepository "github.com/codepnw/mini-ecommerce/internal/product/repository"
 	productusecase "github.com/codepnw/mini-ecommerce/internal/product/usecase"
@@ -12,11 +13,13 @@ import (
 
 func (cfg *routeConfig) ProductRoutes() {
 	repo := productrepository.NewProductRepository(cfg.db)
-	uc := productusecase.NewProductUsecase(repo)
+	invRepo := inventoryrepository.NewInventoryRepository(cfg.db)
+	uc := productusecase.NewProductUsecase(repo, invRepo, cfg.tx, cfg.store)
 	handler := producthandler.NewProductHandler(uc)
 
 	paramID := fmt.Sprintf("/:%s", consts.ParamProductID)
-	public := cfg.router.Group("/products")
+	slug := fmt.Sprintf("/slug/:%s", consts.ParamSlug)
+	public := cfg.router.Group("/products", cfg.auth.OptionalAuthMiddleware())
 	private := cfg.router.Group(
 		"/products",
 		cfg.auth.AuthorizedMiddleware(),
@@ -26,9 +29,29 @@ func (cfg *routeConfig) ProductRoutes() {
 		// Public
 		public.GET("/", handler.List)
 		public.GET(paramID, handler.GetByID)
+		public.GET(slug, handler.GetBySlug)
 		// Admin & Seller
+		private.GET("/me", handler.ListMine)
+		private.GET("/export", handler.Export)
 		private.POST("/", handler.Create)
+		private.POST("/import", handler.Import)
 		private.PATCH(paramID, handler.Update)
 		private.DELETE(paramID, handler.Delete)
+		private.POST(paramID+"/images", handler.UploadImage)
+	}
+
+	// Any User
+	member := cfg.router.Group("/products", cfg.auth.AuthorizedMiddleware())
+	{
+		member.POST(paramID+"/notify-me", handler.NotifyMe)
+		member.DELETE(paramID+"/notify-me", handler.CancelNotifyMe)
+	}
+
+	// For Admin
+	admin := cfg.router.Group("/admin/products")
+	admin.Use(cfg.auth.AuthorizedMiddleware(), cfg.auth.RolesRequired(user.RoleAdmin))
+	{
+		admin.GET("/deleted", handler.ListDeleted)
+		admin.POST(fmt.Sprintf("%s/restore", paramID), handler.Restore)
 	}
 }
@@ -0,0 +1,74 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	inventoryrepository "github.com/codepnw/mini-ecommerce/internal/inventory/repository"
+	orderrepository "github.com/codepnw/mini-ecommerce/internal/order/repository"
+	payoutrepository "github.com/codepnw/mini-ecommerce/internal/payout/repository"
+	productrepository "github.com/codepnw/mini-ecommerce/internal/product/repository"
+	refundhandler "github.com/codepnw/mini-ecommerce/internal/refund/handler"
+	refundrepository "github.com/codepnw/mini-ecommerce/internal/refund/repository"
+	refundusecase "github.com/codepnw/mini-ecommerce/internal/refund/usecase"
+	"github.com/codepnw/mini-ecommerce/internal/user"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+	"github.com/codepnw/mini-ecommerce/pkg/scheduler"
+)
+
+func (cfg *routeConfig) RefundRoutes() error {
+	uc, err := cfg.refundUsecase()
+	if err != nil {
+		return err
+	}
+	handler := refundhandler.NewRefundHandler(uc)
+
+	refunds := fmt.Sprintf("/:%s/refunds", consts.ParamOrderID)
+
+	// Own orders
+	r := cfg.router.Group("/orders")
+	r.Use(cfg.auth.AuthorizedMiddleware())
+	{
+		r.GET(refunds, handler.ListRefunds)
+	}
+
+	// For Admin
+	admin := cfg.router.Group("/admin/orders")
+	admin.Use(cfg.auth.AuthorizedMiddleware(), cfg.auth.RolesRequired(user.RoleAdmin))
+	{
+		admin.GET(refunds, handler.ListRefunds)
+		admin.POST(refunds, handler.CreateRefund)
+	}
+	return nil
+}
+
+// RefundJobs starts retrying refunds the provider could not be reached for
+func (cfg *routeConfig) RefundJobs(ctx context.Context) error {
+	uc, err := cfg.refundUsecase()
+	if err != nil {
+		return err
+	}
+
+	scheduler.Every(ctx, "pay pending refunds", cfg.config.Payment.JobInterval, func(ctx context.Context) error {
+		paid, err := uc.PayPendingRefunds(ctx)
+		if paid > 0 {
+			log.Printf("refund: paid %d pending refunds", paid)
+		}
+		return err
+	})
+	return nil
+}
+
+// refundUsecase also refunds received returns
+func (cfg *routeConfig) refundUsecase() (refundusecase.RefundUsecase, error) {
+	return refundusecase.NewRefundUsecase(&refundusecase.RefundUsecaseConfig{
+		Repo:          refundrepository.NewRefundRepository(cfg.db),
+		OrderRepo:     orderrepository.NewOrderRepository(cfg.db),
+		ProductRepo:   productrepository.NewProductRepository(cfg.db),
+		InventoryRepo: inventoryrepository.NewInventoryRepository(cfg.db),
+		PayoutRepo:    payoutrepository.NewPayoutRepository(cfg.db),
+		Provider:      cfg.payment,
+		Tx:            cfg.tx,
+	})
+}
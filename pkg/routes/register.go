@@ -1,22 +1,32 @@
 package routes
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
 	"github.com/codepnw/mini-ecommerce/internal/middleware"
+	"github.com/codepnw/mini-ecommerce/pkg/carrier"
 	"github.com/codepnw/mini-ecommerce/pkg/config"
 	"github.com/codepnw/mini-ecommerce/pkg/database"
 	"github.com/codepnw/mini-ecommerce/pkg/jwt"
+	"github.com/codepnw/mini-ecommerce/pkg/notify"
+	"github.com/codepnw/mini-ecommerce/pkg/payment"
+	"github.com/codepnw/mini-ecommerce/pkg/storage"
 	"github.com/gin-gonic/gin"
 )
 
 type routeConfig struct {
-	router *gin.Engine
-	db     *sql.DB
-	token  *jwt.JWTToken
-	tx     database.TxManager
-	auth   *middleware.AuthMiddleware
+	config  *config.EnvConfig
+	router  *gin.Engine
+	db      *sql.DB
+	token   *jwt.JWTToken
+	tx      database.TxManager
+	auth    *middleware.AuthMiddleware
+	store   storage.BlobStore
+	notify  notify.Notifier
+	payment payment.Provider
+	tracker carrier.CarrierTracker
 }
 
 func RegisterRoutes(cfg *config.EnvConfig) error {
@@ -40,13 +50,40 @@ func RegisterRoutes(cfg *config.EnvConfig) error {
 		return err
 	}
 
+	store, err := storage.NewBlobStore(cfg.Storage)
+	if err != nil {
+		return err
+	}
+	notifier, err := notify.NewNotifier(cfg.Notify)
+	if err != nil {
+		return err
+	}
+	provider, err := payment.NewProvider(cfg.Payment)
+	if err != nil {
+		return err
+	}
+	tracker, err := carrier.NewTracker(cfg.Carrier)
+	if err != nil {
+		return err
+	}
+
+	// Serve Uploaded Files (Local Only)
+	if cfg.Storage.Driver == "local" {
+		router.Static("/uploads", cfg.Storage.LocalDir)
+	}
+
 	// Register Routes
 	routeCfg := &routeConfig{
-		router: router,
-		db:     db,
-		token:  token,
-		tx:     tx,
-		auth:   auth,
+		config:  cfg,
+		router:  router,
+		db:      db,
+		token:   token,
+		tx:      tx,
+		auth:    auth,
+		store:   store,
+		notify:  notifier,
+		payment: provider,
+		tracker: tracker,
 	}
 
 	// User Routes
@@ -58,10 +95,59 @@ func RegisterRoutes(cfg *config.EnvConfig) error {
 	routeCfg.ProductRoutes()
 
 	// Cart Routes
-	routeCfg.CartRoutes()
+	if err = routeCfg.CartRoutes(); err != nil {
+		return err
+	}
 
 	// Order Routes
-	routeCfg.OrderRoutes()
+	if err = routeCfg.OrderRoutes(); err != nil {
+		return err
+	}
+
+	// Refund Routes
+	if err = routeCfg.RefundRoutes(); err != nil {
+		return err
+	}
+
+	// Return Routes
+	if err = routeCfg.ReturnsRoutes(); err != nil {
+		return err
+	}
+
+	// Inventory Routes
+	routeCfg.InventoryRoutes()
+
+	// Seller Routes
+	if err = routeCfg.SellerRoutes(); err != nil {
+		return err
+	}
+
+	// Payout Routes
+	if err = routeCfg.PayoutRoutes(); err != nil {
+		return err
+	}
+
+	// Shipping Routes
+	if err = routeCfg.ShippingRoutes(); err != nil {
+		return err
+	}
+
+	// Background Jobs
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	routeCfg.InventoryJobs(jobsCtx)
+	if err = routeCfg.CartJobs(jobsCtx); err != nil {
+		return err
+	}
+	if err = routeCfg.PayoutJobs(jobsCtx); err != nil {
+		return err
+	}
+	if err = routeCfg.OrderJobs(jobsCtx); err != nil {
+		return err
+	}
+	if err = routeCfg.RefundJobs(jobsCtx); err != nil {
+		return err
+	}
 
 	port := fmt.Sprintf(":%d", cfg.APP.Port)
 	return router.Run(port)
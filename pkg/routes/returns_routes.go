@@ -0,0 +1,70 @@
+package routes
+
+import (
+	"fmt"
+
+	inventoryrepository "github.com/codepnw/mini-ecommerce/internal/inventory/repository"
+	orderrepository "github.com/codepnw/mini-ecommerce/internal/order/repository"
+	productrepository "github.com/codepnw/mini-ecommerce/internal/product/repository"
+	returnshandler "github.com/codepnw/mini-ecommerce/internal/returns/handler"
+	returnsrepository "github.com/codepnw/mini-ecommerce/internal/returns/repository"
+	returnsusecase "github.com/codepnw/mini-ecommerce/internal/returns/usecase"
+	"github.com/codepnw/mini-ecommerce/internal/user"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+)
+
+func (cfg *routeConfig) ReturnsRoutes() error {
+	refunds, err := cfg.refundUsecase()
+	if err != nil {
+		return err
+	}
+
+	uc, err := returnsusecase.NewReturnsUsecase(&returnsusecase.ReturnsUsecaseConfig{
+		Repo:          returnsrepository.NewReturnsRepository(cfg.db),
+		OrderRepo:     orderrepository.NewOrderRepository(cfg.db),
+		ProductRepo:   productrepository.NewProductRepository(cfg.db),
+		InventoryRepo: inventoryrepository.NewInventoryRepository(cfg.db),
+		Refunds:       refunds,
+		Store:         cfg.store,
+		Tx:            cfg.tx,
+	})
+	if err != nil {
+		return err
+	}
+	handler := returnshandler.NewReturnsHandler(uc)
+
+	orderReturns := fmt.Sprintf("/:%s/returns", consts.ParamOrderID)
+	returnID := fmt.Sprintf("/:%s", consts.ParamReturnID)
+
+	// Own orders
+	r := cfg.router.Group("/orders")
+	r.Use(cfg.auth.AuthorizedMiddleware())
+	{
+		r.POST(orderReturns, handler.RequestReturn)
+		r.GET(orderReturns, handler.ListOrderReturns)
+	}
+
+	// For Seller (own sub-orders only)
+	seller := cfg.router.Group("/seller/returns")
+	seller.Use(cfg.auth.AuthorizedMiddleware(), cfg.auth.RolesRequired(user.RoleSeller, user.RoleAdmin))
+	{
+		seller.GET("/", handler.ListSellerReturns)
+		seller.POST(returnID+"/approve", handler.Approve)
+		seller.POST(returnID+"/reject", handler.Reject)
+		seller.POST(returnID+"/receive", handler.Receive)
+		seller.POST(returnID+"/inspect", handler.Inspect)
+	}
+
+	// For Admin
+	admin := cfg.router.Group("/admin/returns")
+	admin.Use(cfg.auth.AuthorizedMiddleware(), cfg.auth.RolesRequired(user.RoleAdmin))
+	{
+		admin.GET("/", handler.ListReturns)
+		admin.POST(returnID+"/approve", handler.Approve)
+		admin.POST(returnID+"/reject", handler.Reject)
+		admin.POST(returnID+"/receive", handler.Receive)
+		admin.POST(returnID+"/inspect", handler.Inspect)
+		admin.POST(returnID+"/refund", handler.Refund)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package routes
+
+import (
+	"fmt"
+
+	inventoryrepository "github.com/codepnw/mini-ecommerce/internal/inventory/repository"
+	productrepository "github.com/codepnw/mini-ecommerce/internal/product/repository"
+	productusecase "github.com/codepnw/mini-ecommerce/internal/product/usecase"
+	sellerhandler "github.com/codepnw/mini-ecommerce/internal/seller/handler"
+	sellerrepository "github.com/codepnw/mini-ecommerce/internal/seller/repository"
+	sellerusecase "github.com/codepnw/mini-ecommerce/internal/seller/usecase"
+	"github.com/codepnw/mini-ecommerce/internal/user"
+	userrepository "github.com/codepnw/mini-ecommerce/internal/user/repository"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+)
+
+func (cfg *routeConfig) SellerRoutes() error {
+	prodRepo := productrepository.NewProductRepository(cfg.db)
+	invRepo := inventoryrepository.NewInventoryRepository(cfg.db)
+	products := productusecase.NewProductUsecase(prodRepo, invRepo, cfg.tx, cfg.store)
+
+	repo := sellerrepository.NewSellerRepository(cfg.db)
+	uc, err := sellerusecase.NewSellerUsecase(&sellerusecase.SellerUsecaseConfig{
+		Repo:     repo,
+		UserRepo: userrepository.NewUserRepository(cfg.db),
+		Products: products,
+		Store:    cfg.store,
+		Tx:       cfg.tx,
+	})
+	if err != nil {
+		return fmt.Errorf("seller usecase config: %w", err)
+	}
+	handler := sellerhandler.NewSellerHandler(uc)
+	analytics := sellerhandler.NewAnalyticsHandler(sellerusecase.NewAnalyticsUsecase(repo))
+
+	// Public
+	cfg.router.GET(fmt.Sprintf("/stores/:%s", consts.ParamSlug), handler.GetStorePage)
+
+	// Any User
+	applicant := cfg.router.Group("/seller", cfg.auth.AuthorizedMiddleware())
+	{
+		applicant.POST("/apply", handler.Apply)
+		applicant.GET("/application", handler.GetMyApplication)
+	}
+
+	// For Seller (own store only)
+	store := cfg.router.Group("/seller/store")
+	store.Use(cfg.auth.AuthorizedMiddleware(), cfg.auth.RolesRequired(user.RoleSeller, user.RoleAdmin))
+	{
+		store.GET("/", handler.GetMyStore)
+		store.PATCH("/", handler.UpdateMyStore)
+		store.POST("/logo", handler.UploadLogo)
+	}
+
+	// For Seller (own products only)
+	stats := cfg.router.Group("/seller/analytics")
+	stats.Use(cfg.auth.AuthorizedMiddleware(), cfg.auth.RolesRequired(user.RoleSeller, user.RoleAdmin))
+	{
+		stats.GET("/sales", analytics.GetSalesReport)
+		stats.GET("/top-products", analytics.GetTopProducts)
+	}
+
+	// For Admin
+	applicationID := fmt.Sprintf("/:%s", consts.ParamApplicationID)
+	admin := cfg.router.Group("/admin/seller-applications")
+	admin.Use(cfg.auth.AuthorizedMiddleware(), cfg.auth.RolesRequired(user.RoleAdmin))
+	{
+		admin.GET("/", handler.ListApplications)
+		admin.POST(applicationID+"/approve", handler.ApproveApplication)
+		admin.POST(applicationID+"/reject", handler.RejectApplication)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"fmt"
+
+	shippinghandler "github.com/codepnw/mini-ecommerce/internal/shipping/handler"
+	shippingrepository "github.com/codepnw/mini-ecommerce/internal/shipping/repository"
+	shippingusecase "github.com/codepnw/mini-ecommerce/internal/shipping/usecase"
+	"github.com/codepnw/mini-ecommerce/internal/user"
+	"github.com/codepnw/mini-ecommerce/internal/utils/consts"
+)
+
+func (cfg *routeConfig) ShippingRoutes() error {
+	uc, err := cfg.shippingUsecase()
+	if err != nil {
+		return err
+	}
+	handler := shippinghandler.NewShippingHandler(uc)
+
+	// For Admin
+	rateID := fmt.Sprintf("/:%s", consts.ParamRateID)
+	rates := cfg.router.Group("/admin/shipping/rates")
+	rates.Use(cfg.auth.AuthorizedMiddleware(), cfg.auth.RolesRequired(user.RoleAdmin))
+	{
+		rates.GET("/", handler.ListRates)
+		rates.PUT("/", handler.SetRate)
+		rates.DELETE(rateID, handler.DeleteRate)
+	}
+	return nil
+}
+
+func (cfg *routeConfig) shippingUsecase() (shippingusecase.ShippingUsecase, error) {
+	return shippingusecase.NewShippingUsecase(&shippingusecase.ShippingUsecaseConfig{
+		Repo:        shippingrepository.NewShippingRepository(cfg.db),
+		HomeCountry: cfg.config.Shipping.HomeCountry,
+	})
+}
@@ -0,0 +1,27 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Every runs fn on each tick until ctx is done, errors are logged and
+// the job keeps running
+func Every(ctx context.Context, name string, interval time.Duration, fn func(ctx context.Context) error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := fn(ctx); err != nil {
+					log.Printf("scheduler: %s failed: %v", name, err)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type localStore struct {
+	dir     string
+	baseURL string
+}
+
+func NewLocalStore(dir, baseURL string) (BlobStore, error) {
+	if dir == "" {
+		return nil, errors.New("local storage dir is empty")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create storage dir failed: %w", err)
+	}
+	return &localStore{
+		dir:     dir,
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}, nil
+}
+
+func (s *localStore) Put(ctx context.Context, key string, body io.Reader, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	// Write to temp file then rename, readers never see a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *localStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *localStore) URL(key string) string {
+	return fmt.Sprintf("%s/%s", s.baseURL, key)
+}
+
+// path prevents keys from escaping the storage dir (e.g. "../../etc")
+func (s *localStore) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" {
+		return "", errors.New("invalid storage key")
+	}
+	return filepath.Join(s.dir, clean), nil
+}
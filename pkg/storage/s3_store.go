@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/codepnw/mini-ecommerce/pkg/validate"
+)
+
+type S3Config struct {
+	Endpoint  string `validate:"required"`
+	Region    string
+	Bucket    string `validate:"required"`
+	AccessKey string `validate:"required"`
+	SecretKey string `validate:"required"`
+	// Optional public URL (CDN), default endpoint/bucket
+	PublicURL string
+	// Optional, default http.DefaultClient
+	Client *http.Client
+}
+
+// s3Store talks to any S3 compatible API (AWS S3, MinIO) using
+// path-style requests signed with AWS Signature Version 4.
+type s3Store struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	publicURL string
+	client    *http.Client
+}
+
+func NewS3Store(cfg *S3Config) (BlobStore, error) {
+	if err := validate.Struct(cfg); err != nil {
+		return nil, fmt.Errorf("s3 storage config: %w", err)
+	}
+	endpoint, err := url.Parse(strings.TrimRight(cfg.Endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint: %w", err)
+	}
+
+	region := cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	client := cfg.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	publicURL := strings.TrimRight(cfg.PublicURL, "/")
+	if publicURL == "" {
+		publicURL = fmt.Sprintf("%s/%s", endpoint.String(), cfg.Bucket)
+	}
+
+	return &s3Store{
+		endpoint:  endpoint,
+		region:    region,
+		bucket:    cfg.Bucket,
+		accessKey: cfg.AccessKey,
+		secretKey: cfg.SecretKey,
+		publicURL: publicURL,
+		client:    client,
+	}, nil
+}
+
+func (s *s3Store) Put(ctx context.Context, key string, body io.Reader, contentType string) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := s.newRequest(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	return s.do(req)
+}
+
+func (s *s3Store) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	return s.do(req)
+}
+
+func (s *s3Store) URL(key string) string {
+	return fmt.Sprintf("%s/%s", s.publicURL, key)
+}
+
+func (s *s3Store) do(req *http.Request) error {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("s3 request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("s3 %s %s: %d %s", req.Method, req.URL.Path, resp.StatusCode, msg)
+	}
+	return nil
+}
+
+func (s *s3Store) newRequest(ctx context.Context, method, key string, data []byte) (*http.Request, error) {
+	u := *s.endpoint
+	u.Path = fmt.Sprintf("%s/%s/%s", strings.TrimRight(u.Path, "/"), s.bucket, strings.TrimLeft(key, "/"))
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(data))
+
+	s.sign(req, data, time.Now().UTC())
+	return req, nil
+}
+
+func (s *s3Store) sign(req *http.Request, payload []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+	payloadHash := hashHex(payload)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := fmt.Sprintf(
+		"host:%s\nx-amz-content-sha256:%s\nx-amz-date:%s\n",
+		req.URL.Host,
+		payloadHash,
+		amzDate,
+	)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", dateStamp, s.region)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), dateStamp)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey,
+		scope,
+		signedHeaders,
+		signature,
+	))
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,142 @@
+package storage_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/codepnw/mini-ecommerce/pkg/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3 is a tiny MinIO-style stand-in: PUT/GET/DELETE objects by path
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Method != http.MethodGet {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=access-key/") ||
+			!strings.Contains(auth, "/us-east-1/s3/aws4_request") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		sum := sha256.Sum256(body)
+		if r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.objects[r.URL.Path] = body
+		f.types[r.URL.Path] = r.Header.Get("Content-Type")
+	case http.MethodGet:
+		body, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", f.types[r.URL.Path])
+		w.Write(body)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	fake := &fakeS3{objects: map[string][]byte{}, types: map[string]string{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store, err := storage.NewS3Store(&storage.S3Config{
+		Endpoint:  server.URL,
+		Bucket:    "products",
+		AccessKey: "access-key",
+		SecretKey: "secret-key",
+	})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	key := "products/1/image.png"
+
+	// Put
+	err = store.Put(ctx, key, strings.NewReader("png-bytes"), "image/png")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("png-bytes"), fake.objects["/products/"+key])
+
+	// Public URL
+	url := store.URL(key)
+	assert.Equal(t, server.URL+"/products/"+key, url)
+
+	resp, err := http.Get(url)
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "png-bytes", string(body))
+	assert.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+
+	// Delete
+	err = store.Delete(ctx, key)
+	assert.NoError(t, err)
+	assert.NotContains(t, fake.objects, "/products/"+key)
+}
+
+func TestS3StoreRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	store, err := storage.NewS3Store(&storage.S3Config{
+		Endpoint:  server.URL,
+		Bucket:    "products",
+		AccessKey: "access-key",
+		SecretKey: "secret-key",
+	})
+	assert.NoError(t, err)
+
+	err = store.Put(context.Background(), "a.png", strings.NewReader("x"), "image/png")
+	assert.Error(t, err)
+}
+
+func TestLocalStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := storage.NewLocalStore(dir, "http://localhost/uploads/")
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+
+	err = store.Put(ctx, "products/1/a.png", strings.NewReader("data"), "image/png")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost/uploads/products/1/a.png", store.URL("products/1/a.png"))
+
+	// Keys cannot escape the storage dir
+	err = store.Put(ctx, "../../escape.png", strings.NewReader("data"), "image/png")
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "escape.png"))
+	assert.NoError(t, err)
+
+	err = store.Delete(ctx, "products/1/a.png")
+	assert.NoError(t, err)
+	// Delete missing file is not an error
+	err = store.Delete(ctx, "products/1/a.png")
+	assert.NoError(t, err)
+}
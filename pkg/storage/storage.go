@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/codepnw/mini-ecommerce/pkg/config"
+)
+
+type BlobStore interface {
+	Put(ctx context.Context, key string, body io.Reader, contentType string) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+func NewBlobStore(cfg config.StorageConfig) (BlobStore, error) {
+	switch cfg.Driver {
+	case "local":
+		return NewLocalStore(cfg.LocalDir, cfg.BaseURL)
+	case "s3":
+		return NewS3Store(&S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PublicURL: cfg.S3PublicURL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage driver: %s", cfg.Driver)
+	}
+}
@@ -0,0 +1,100 @@
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+
+	_ "image/gif"
+)
+
+const jpegQuality = 85
+
+// ErrTooManyPixels the declared dimensions are over the pixel cap, the
+// image is never decoded
+var ErrTooManyPixels = errors.New("image dimensions too large")
+
+// Generate decodes src and returns an encoded thumbnail that fits inside
+// maxSize x maxSize, keeping the aspect ratio. PNG stays PNG (alpha),
+// everything else is encoded as JPEG. Images over maxPixels are rejected
+// from their header before decoding.
+func Generate(src []byte, maxSize, maxPixels int) ([]byte, string, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(src))
+	if err != nil {
+		return nil, "", fmt.Errorf("decode image config failed: %w", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > maxPixels/cfg.Height {
+		return nil, "", ErrTooManyPixels
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(src))
+	if err != nil {
+		return nil, "", fmt.Errorf("decode image failed: %w", err)
+	}
+
+	thumb := resize(img, maxSize)
+
+	buf := new(bytes.Buffer)
+	if format == "png" {
+		if err := png.Encode(buf, thumb); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/png", nil
+	}
+
+	if err := jpeg.Encode(buf, thumb, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "image/jpeg", nil
+}
+
+// resize downscales with a box filter, each target pixel is the average
+// of the source pixels it covers. Images already smaller are kept as is.
+func resize(src image.Image, maxSize int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSize && h <= maxSize {
+		return src
+	}
+
+	dw, dh := maxSize, maxSize
+	if w > h {
+		dh = max(1, h*maxSize/w)
+	} else {
+		dw = max(1, w*maxSize/h)
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		sy0 := b.Min.Y + y*h/dh
+		sy1 := max(sy0+1, b.Min.Y+(y+1)*h/dh)
+
+		for x := 0; x < dw; x++ {
+			sx0 := b.Min.X + x*w/dw
+			sx1 := max(sx0+1, b.Min.X+(x+1)*w/dw)
+
+			var r, g, bl, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					c := color.NRGBAModel.Convert(src.At(sx, sy)).(color.NRGBA)
+					r += uint64(c.R)
+					g += uint64(c.G)
+					bl += uint64(c.B)
+					a += uint64(c.A)
+					n++
+				}
+			}
+			dst.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(r / n),
+				G: uint8(g / n),
+				B: uint8(bl / n),
+				A: uint8(a / n),
+			})
+		}
+	}
+	return dst
+}
@@ -3,8 +3,14 @@ CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
 DROP TYPE IF EXISTS user_roles;
 CREATE TYPE user_roles AS ENUM ('admin', 'seller', 'user');
 
+DROP TYPE IF EXISTS product_status;
+CREATE TYPE product_status AS ENUM ('draft', 'published', 'archived');
+
+DROP TYPE IF EXISTS stock_movement_reason;
+CREATE TYPE stock_movement_reason AS ENUM ('order', 'cancel', 'restock', 'adjust', 'return');
+
 DROP TYPE IF EXISTS cart_status;
-CREATE TYPE cart_status AS ENUM ('active', 'guest', 'ordered', 'abandoned');
+CREATE TYPE cart_status AS ENUM ('active', 'guest', 'saved', 'ordered', 'abandoned');
 
 -- Create Table Users
 CREATE TABLE IF NOT EXISTS users (
@@ -36,13 +42,76 @@ CREATE TABLE IF NOT EXISTS products (
     id BIGSERIAL PRIMARY KEY,
     name VARCHAR(255) NOT NULL,
     description TEXT,
+    slug VARCHAR(255) UNIQUE NOT NULL,
+    status product_status NOT NULL DEFAULT 'draft',
     price DECIMAL(10, 2) NOT NULL CHECK (price >= 0),
     stock INT NOT NULL CHECK (stock >= 0),
+    low_stock_threshold INT NOT NULL DEFAULT 0 CHECK (low_stock_threshold >= 0),
+    -- Days from the completed sub-order, 0 takes no returns
+    return_window_days INT NOT NULL DEFAULT 30 CONSTRAINT products_return_window_days_check CHECK (return_window_days BETWEEN 0 AND 365),
+    -- Purchase rules, 0 turns a rule off
+    min_quantity INT NOT NULL DEFAULT 0 CHECK (min_quantity >= 0),
+    max_quantity INT NOT NULL DEFAULT 0 CHECK (max_quantity >= 0),
+    quantity_step INT NOT NULL DEFAULT 0 CHECK (quantity_step >= 0),
+    customer_limit INT NOT NULL DEFAULT 0 CHECK (customer_limit >= 0),
+    -- Shipping weight & dimensions of one unit, 0 is unknown
+    weight_grams INT NOT NULL DEFAULT 0 CHECK (weight_grams >= 0),
+    length_cm INT NOT NULL DEFAULT 0 CHECK (length_cm >= 0),
+    width_cm INT NOT NULL DEFAULT 0 CHECK (width_cm >= 0),
+    height_cm INT NOT NULL DEFAULT 0 CHECK (height_cm >= 0),
     sku VARCHAR(100) UNIQUE NOT NULL,
+    category VARCHAR(50),
     owner_id BIGINT NOT NULL REFERENCES users(id),
+    version BIGINT NOT NULL DEFAULT 1,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ
+);
+-- Indexes
+CREATE INDEX IF NOT EXISTS idx_products_status ON products(status);
+CREATE INDEX IF NOT EXISTS idx_products_owner_id ON products(owner_id);
+CREATE INDEX IF NOT EXISTS idx_products_category ON products(category);
+CREATE INDEX IF NOT EXISTS idx_products_live ON products(id) WHERE deleted_at IS NULL;
+
+-- Create Table Product Images
+CREATE TABLE IF NOT EXISTS product_images (
+    id BIGSERIAL PRIMARY KEY,
+    product_id BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    object_key TEXT NOT NULL,
+    thumbnail_key TEXT NOT NULL,
+    content_type VARCHAR(50) NOT NULL,
+    size BIGINT NOT NULL,
+    position INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(product_id, position)
+);
+
+-- Create Table Warehouses
+CREATE TABLE IF NOT EXISTS warehouses (
+    id BIGSERIAL PRIMARY KEY,
+    code VARCHAR(50) UNIQUE NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    latitude DOUBLE PRECISION,
+    longitude DOUBLE PRECISION,
+    priority INT NOT NULL DEFAULT 0,
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
     updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 );
+-- Index (Only one default warehouse)
+CREATE UNIQUE INDEX IF NOT EXISTS idx_warehouses_default ON warehouses(is_default) WHERE is_default;
+
+-- Create Table Warehouse Stock (products.stock is the total)
+CREATE TABLE IF NOT EXISTS warehouse_stock (
+    warehouse_id BIGINT NOT NULL REFERENCES warehouses(id),
+    product_id BIGINT NOT NULL REFERENCES products(id),
+    quantity INT NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (warehouse_id, product_id)
+);
+-- Index
+CREATE INDEX IF NOT EXISTS idx_warehouse_stock_product ON warehouse_stock(product_id);
 
 -- Cresate Table Carts
 CREATE TABLE IF NOT EXISTS carts (
@@ -50,12 +119,17 @@ CREATE TABLE IF NOT EXISTS carts (
     user_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
     session_id UUID,
     status cart_status NOT NULL DEFAULT 'guest',
+    name VARCHAR(100), -- Wishlists, NULL for the saved-for-later list
+    shipping_method VARCHAR(20) NOT NULL DEFAULT 'standard',
+    shipping_country VARCHAR(2), -- NULL is the home country
     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
     updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 );
 -- Indexes
 CREATE INDEX IF NOT EXISTS idx_carts_user_id ON carts(user_id);
 CREATE INDEX IF NOT EXISTS idx_carts_session_id ON carts(session_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_carts_saved_for_later ON carts(user_id) WHERE status = 'saved' AND name IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_carts_wishlist_name ON carts(user_id, name) WHERE status = 'saved' AND name IS NOT NULL;
 
 -- Create Table Cart Items
 CREATE TABLE IF NOT EXISTS cart_items (
@@ -69,22 +143,371 @@ CREATE TABLE IF NOT EXISTS cart_items (
     UNIQUE(cart_id, product_id) -- For UPSERT
 );
 
+-- Create Table Inventory Reservations
+CREATE TABLE IF NOT EXISTS inventory_reservations (
+    id BIGSERIAL PRIMARY KEY,
+    cart_item_id BIGINT UNIQUE NOT NULL REFERENCES cart_items(id) ON DELETE CASCADE,
+    cart_id UUID NOT NULL REFERENCES carts(id) ON DELETE CASCADE,
+    product_id BIGINT NOT NULL REFERENCES products(id),
+    quantity INT NOT NULL CHECK (quantity > 0),
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- Indexes
+CREATE INDEX IF NOT EXISTS idx_inventory_reservations_product ON inventory_reservations(product_id, expires_at);
+CREATE INDEX IF NOT EXISTS idx_inventory_reservations_expires ON inventory_reservations(expires_at);
+
 -- Create Table Orders
 CREATE TABLE IF NOT EXISTS orders (
     id BIGSERIAL PRIMARY KEY,
-    user_id BIGINT NOT NULL REFERENCES users(id),
+    user_id BIGINT REFERENCES users(id), -- NULL for guest orders
+    guest_email VARCHAR(255),
     total DECIMAL(10, 2) NOT NULL DEFAULT 0,
     status VARCHAR(50) NOT NULL DEFAULT 'pending',
+    -- Shipping Address
+    ship_name VARCHAR(100),
+    ship_phone VARCHAR(30),
+    ship_address TEXT,
+    ship_city VARCHAR(100),
+    ship_postal_code VARCHAR(20),
+    ship_country VARCHAR(2),
+    -- Shipping (included in total), NULL method before shipping rates
+    shipping_method VARCHAR(20),
+    shipping_amount DECIMAL(10, 2) NOT NULL DEFAULT 0 CHECK (shipping_amount >= 0),
+    -- Refunds apart from the fulfilment status
+    refund_status VARCHAR(20) NOT NULL DEFAULT 'none' CHECK (refund_status IN ('none', 'partial', 'full')),
     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT orders_buyer_check CHECK (user_id IS NOT NULL OR guest_email IS NOT NULL)
 );
+CREATE INDEX IF NOT EXISTS idx_orders_guest_email ON orders(LOWER(guest_email)) WHERE guest_email IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_orders_created_at ON orders(created_at);
+CREATE INDEX IF NOT EXISTS idx_orders_status_created_at ON orders(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_orders_user_id ON orders(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_orders_refund_status ON orders(refund_status, created_at) WHERE refund_status <> 'none';
+
+-- Create Table Sub Orders (one per seller, the order status is derived)
+CREATE TABLE IF NOT EXISTS sub_orders (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    seller_id BIGINT NOT NULL REFERENCES users(id),
+    total DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    status VARCHAR(50) NOT NULL DEFAULT 'pending',
+    shipped_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (order_id, seller_id)
+);
+CREATE INDEX IF NOT EXISTS idx_sub_orders_seller_id ON sub_orders(seller_id, created_at);
 
 -- Create Table Order Items
 CREATE TABLE IF NOT EXISTS order_items (
     id BIGSERIAL PRIMARY KEY,
     order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    sub_order_id BIGINT REFERENCES sub_orders(id) ON DELETE CASCADE,
     product_id BIGINT NOT NULL REFERENCES products(id),
     quantity INT NOT NULL,
     price DECIMAL(10, 2) NOT NULL,
+    warehouse_id BIGINT REFERENCES warehouses(id),
+    refunded_quantity INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT order_items_refunded_quantity_check CHECK (refunded_quantity BETWEEN 0 AND quantity)
+);
+CREATE INDEX IF NOT EXISTS idx_order_items_order_id ON order_items(order_id);
+CREATE INDEX IF NOT EXISTS idx_order_items_sub_order_id ON order_items(sub_order_id);
+CREATE INDEX IF NOT EXISTS idx_order_items_product_id ON order_items(product_id);
+-- Seller analytics (Index-only lines per product)
+CREATE INDEX IF NOT EXISTS idx_order_items_product_order ON order_items(product_id, order_id) INCLUDE (quantity, refunded_quantity, price, sub_order_id);
+
+-- Create Table Stock Movements (Append-only)
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id BIGSERIAL PRIMARY KEY,
+    product_id BIGINT NOT NULL REFERENCES products(id),
+    delta INT NOT NULL CHECK (delta <> 0),
+    stock_after INT NOT NULL,
+    reason stock_movement_reason NOT NULL,
+    ref_type VARCHAR(50),
+    ref_id BIGINT,
+    actor_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    note TEXT,
+    warehouse_id BIGINT REFERENCES warehouses(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- Indexes
+CREATE INDEX IF NOT EXISTS idx_stock_movements_product ON stock_movements(product_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_ref ON stock_movements(ref_type, ref_id);
+
+CREATE OR REPLACE FUNCTION stock_movements_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'stock_movements is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_stock_movements_append_only ON stock_movements;
+CREATE TRIGGER trg_stock_movements_append_only
+BEFORE UPDATE OR DELETE ON stock_movements
+FOR EACH ROW EXECUTE FUNCTION stock_movements_append_only();
+
+-- Create Table Stock Events (Low stock, Back in stock)
+CREATE TABLE IF NOT EXISTS stock_events (
+    id BIGSERIAL PRIMARY KEY,
+    product_id BIGINT NOT NULL REFERENCES products(id),
+    kind VARCHAR(50) NOT NULL,
+    stock INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    processed_at TIMESTAMPTZ,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT
+);
+-- Index
+CREATE INDEX IF NOT EXISTS idx_stock_events_pending ON stock_events(attempts, id) WHERE processed_at IS NULL;
+
+-- Create Table Stock Subscriptions (Notify me)
+CREATE TABLE IF NOT EXISTS stock_subscriptions (
+    id BIGSERIAL PRIMARY KEY,
+    product_id BIGINT NOT NULL REFERENCES products(id),
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    notified_at TIMESTAMPTZ
+);
+-- Index (One active subscription per user)
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_subscriptions_active ON stock_subscriptions(product_id, user_id) WHERE notified_at IS NULL;
+
+-- Create Table Cart Recoveries (Abandoned user carts)
+CREATE TABLE IF NOT EXISTS cart_recoveries (
+    id BIGSERIAL PRIMARY KEY,
+    cart_id UUID NOT NULL REFERENCES carts(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    abandoned_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    notified_at TIMESTAMPTZ,
+    recovered_at TIMESTAMPTZ,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_cart_recoveries_cart_id ON cart_recoveries(cart_id);
+CREATE INDEX IF NOT EXISTS idx_cart_recoveries_abandoned_at ON cart_recoveries(abandoned_at);
+CREATE INDEX IF NOT EXISTS idx_cart_recoveries_pending ON cart_recoveries(attempts, id) WHERE notified_at IS NULL;
+
+-- Create Table Commission Rules (seller > category > default)
+CREATE TABLE IF NOT EXISTS commission_rules (
+    id BIGSERIAL PRIMARY KEY,
+    scope VARCHAR(20) NOT NULL CHECK (scope IN ('default', 'seller', 'category')),
+    seller_id BIGINT REFERENCES users(id) ON DELETE CASCADE,
+    category VARCHAR(50),
+    rate DECIMAL(5, 4) NOT NULL CHECK (rate >= 0 AND rate <= 1),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (
+        (scope = 'default' AND seller_id IS NULL AND category IS NULL) OR
+        (scope = 'seller' AND seller_id IS NOT NULL AND category IS NULL) OR
+        (scope = 'category' AND seller_id IS NULL AND category IS NOT NULL)
+    )
+);
+-- Index (One rule per scope)
+CREATE UNIQUE INDEX IF NOT EXISTS idx_commission_rules_scope ON commission_rules(scope, COALESCE(seller_id, 0), COALESCE(category, ''));
+
+-- Create Table Ledger Transactions (Double-entry, Append-only)
+CREATE TABLE IF NOT EXISTS ledger_transactions (
+    id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('earning', 'refund', 'payout', 'payout_paid', 'payout_reversal')),
+    seller_id BIGINT NOT NULL REFERENCES users(id),
+    ref_type VARCHAR(20) NOT NULL,
+    ref_id BIGINT NOT NULL,
+    memo TEXT,
     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 );
+-- Indexes (An event is posted once per seller)
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_transactions_ref ON ledger_transactions(kind, ref_type, ref_id, seller_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_transactions_seller_id ON ledger_transactions(seller_id, created_at);
+
+-- Create Table Ledger Entries (Debits = Credits per transaction)
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id BIGSERIAL PRIMARY KEY,
+    transaction_id BIGINT NOT NULL REFERENCES ledger_transactions(id),
+    account VARCHAR(30) NOT NULL CHECK (account IN ('order_clearing', 'seller_payable', 'platform_fees', 'payouts_payable', 'cash')),
+    debit DECIMAL(12, 2) NOT NULL DEFAULT 0 CHECK (debit >= 0),
+    credit DECIMAL(12, 2) NOT NULL DEFAULT 0 CHECK (credit >= 0),
+    CHECK ((debit = 0) <> (credit = 0))
+);
+-- Index
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_transaction_id ON ledger_entries(transaction_id);
+
+-- Create Table Payout Batches
+CREATE TABLE IF NOT EXISTS payout_batches (
+    id BIGSERIAL PRIMARY KEY,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'paid', 'failed')),
+    total DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    reference VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    paid_at TIMESTAMPTZ
+);
+-- Index
+CREATE INDEX IF NOT EXISTS idx_payout_batches_status ON payout_batches(status, created_at);
+
+-- Create Table Payouts (One per seller per batch)
+CREATE TABLE IF NOT EXISTS payouts (
+    id BIGSERIAL PRIMARY KEY,
+    batch_id BIGINT NOT NULL REFERENCES payout_batches(id),
+    seller_id BIGINT NOT NULL REFERENCES users(id),
+    amount DECIMAL(12, 2) NOT NULL CHECK (amount > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (batch_id, seller_id)
+);
+-- Index
+CREATE INDEX IF NOT EXISTS idx_payouts_seller_id ON payouts(seller_id, created_at);
+
+-- Create Table Stores (One per seller)
+CREATE TABLE IF NOT EXISTS stores (
+    id BIGSERIAL PRIMARY KEY,
+    seller_id BIGINT NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    slug VARCHAR(120) NOT NULL UNIQUE,
+    description TEXT,
+    logo_key VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create Table Seller Applications
+CREATE TABLE IF NOT EXISTS seller_applications (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    store_name VARCHAR(100) NOT NULL,
+    store_slug VARCHAR(120) NOT NULL,
+    description TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    reject_reason TEXT,
+    reviewed_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- Indexes (One pending application per user)
+CREATE UNIQUE INDEX IF NOT EXISTS idx_seller_applications_pending ON seller_applications(user_id) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_seller_applications_status ON seller_applications(status, created_at);
+
+-- Create Table Refunds (Amount is the sum of its items)
+CREATE TABLE IF NOT EXISTS refunds (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    amount DECIMAL(10, 2) NOT NULL CHECK (amount > 0),
+    -- Shipping paid back with the last line, included in amount
+    shipping_amount DECIMAL(10, 2) NOT NULL DEFAULT 0 CHECK (shipping_amount >= 0),
+    reason VARCHAR(30) NOT NULL CHECK (reason IN ('customer_request', 'damaged', 'defective', 'wrong_item', 'not_received', 'other')),
+    note TEXT,
+    restock BOOLEAN NOT NULL DEFAULT FALSE,
+    provider_ref VARCHAR(100),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'settled', 'declined')),
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- Index
+CREATE INDEX IF NOT EXISTS idx_refunds_order_id ON refunds(order_id);
+CREATE INDEX IF NOT EXISTS idx_refunds_pending ON refunds(attempts, id) WHERE status = 'pending';
+
+-- Create Table Refund Items
+CREATE TABLE IF NOT EXISTS refund_items (
+    id BIGSERIAL PRIMARY KEY,
+    refund_id BIGINT NOT NULL REFERENCES refunds(id) ON DELETE CASCADE,
+    order_item_id BIGINT NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    amount DECIMAL(10, 2) NOT NULL
+);
+-- Index
+CREATE INDEX IF NOT EXISTS idx_refund_items_refund_id ON refund_items(refund_id);
+
+-- Create Table Returns (One seller per return, open returns hold their quantities)
+CREATE TABLE IF NOT EXISTS returns (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    sub_order_id BIGINT NOT NULL REFERENCES sub_orders(id) ON DELETE CASCADE,
+    seller_id BIGINT NOT NULL REFERENCES users(id),
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'requested' CHECK (status IN ('requested', 'approved', 'rejected', 'received', 'inspected', 'refunded')),
+    reason VARCHAR(30) NOT NULL CHECK (reason IN ('damaged', 'defective', 'wrong_item', 'not_as_described', 'changed_mind', 'other')),
+    note TEXT,
+    reject_reason TEXT,
+    inspection_note TEXT,
+    refund_id BIGINT REFERENCES refunds(id) ON DELETE SET NULL,
+    reviewed_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMPTZ,
+    received_at TIMESTAMPTZ,
+    inspected_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- Indexes
+CREATE INDEX IF NOT EXISTS idx_returns_order_id ON returns(order_id);
+CREATE INDEX IF NOT EXISTS idx_returns_seller_id ON returns(seller_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_returns_status ON returns(status, created_at);
+
+-- Create Table Return Items
+CREATE TABLE IF NOT EXISTS return_items (
+    id BIGSERIAL PRIMARY KEY,
+    return_id BIGINT NOT NULL REFERENCES returns(id) ON DELETE CASCADE,
+    order_item_id BIGINT NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    quantity INT NOT NULL CHECK (quantity > 0)
+);
+-- Indexes
+CREATE INDEX IF NOT EXISTS idx_return_items_return_id ON return_items(return_id);
+CREATE INDEX IF NOT EXISTS idx_return_items_order_item_id ON return_items(order_item_id);
+
+-- Create Table Return Photos
+CREATE TABLE IF NOT EXISTS return_photos (
+    id BIGSERIAL PRIMARY KEY,
+    return_id BIGINT NOT NULL REFERENCES returns(id) ON DELETE CASCADE,
+    object_key TEXT NOT NULL,
+    content_type VARCHAR(50) NOT NULL,
+    size BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- Index
+CREATE INDEX IF NOT EXISTS idx_return_photos_return_id ON return_photos(return_id);
+
+-- Create Table Shipments (One per sub-order, created when it ships)
+CREATE TABLE IF NOT EXISTS shipments (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    sub_order_id BIGINT NOT NULL UNIQUE REFERENCES sub_orders(id) ON DELETE CASCADE,
+    carrier VARCHAR(50),
+    tracking_number VARCHAR(100),
+    status VARCHAR(20) NOT NULL DEFAULT 'in_transit' CHECK (status IN ('in_transit', 'delivered')),
+    shipped_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMPTZ,
+    last_checked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- Index
+CREATE INDEX IF NOT EXISTS idx_shipments_order_id ON shipments(order_id);
+CREATE INDEX IF NOT EXISTS idx_shipments_tracking ON shipments(last_checked_at NULLS FIRST) WHERE status = 'in_transit' AND tracking_number IS NOT NULL;
+
+-- Create Table Shipment Items
+CREATE TABLE IF NOT EXISTS shipment_items (
+    id BIGSERIAL PRIMARY KEY,
+    shipment_id BIGINT NOT NULL REFERENCES shipments(id) ON DELETE CASCADE,
+    order_item_id BIGINT NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    quantity INT NOT NULL CHECK (quantity > 0)
+);
+-- Index
+CREATE INDEX IF NOT EXISTS idx_shipment_items_shipment_id ON shipment_items(shipment_id);
+
+-- Create Table Shipping Rates (A method without a rate is not offered)
+CREATE TABLE IF NOT EXISTS shipping_rates (
+    id BIGSERIAL PRIMARY KEY,
+    method VARCHAR(20) NOT NULL CHECK (method IN ('standard', 'express', 'pickup')),
+    zone VARCHAR(20) NOT NULL CHECK (zone IN ('domestic', 'international')),
+    base_fee DECIMAL(10, 2) NOT NULL CHECK (base_fee >= 0),
+    per_kg_fee DECIMAL(10, 2) NOT NULL DEFAULT 0 CHECK (per_kg_fee >= 0),
+    max_weight_grams INT NOT NULL DEFAULT 0 CHECK (max_weight_grams >= 0), -- 0 is no limit
+    free_over DECIMAL(10, 2) NOT NULL DEFAULT 0 CHECK (free_over >= 0), -- 0 is never free
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (method, zone)
+);
@@ -1,5 +1,5 @@
 -- Clear Data & Reset ID
-TRUNCATE TABLE users, products, carts, cart_items, orders, order_items RESTART IDENTITY CASCADE;
+TRUNCATE TABLE users, products, carts, cart_items, orders, order_items, warehouses, commission_rules, shipping_rates, stores RESTART IDENTITY CASCADE;
 
 -- Create Users (Password: 123456)
 INSERT INTO users (email, password, first_name, last_name, role) VALUES
@@ -10,13 +10,67 @@ INSERT INTO users (email, password, first_name, last_name, role) VALUES
 -- ID 2 = User
 -- ID 3 = Seller
 
+-- Create Stores
+INSERT INTO stores (seller_id, name, slug, description) VALUES
+(3, 'Shop Owner Gear', 'shop-owner-gear', 'Keyboards, mice & monitors');
+
 -- Create Products
-INSERT INTO products (name, description, price, stock, sku, owner_id) VALUES
+INSERT INTO products (name, description, slug, status, price, stock, sku, owner_id) VALUES
 -- Product Admin (ID 1)
-('iPhone 15 Pro',    'Titanium design, A17 Pro chip', 41900.00, 10, 'IP15-PRO-TI', 1),
-('MacBook Air M3',   'Supercharged by M3',            39900.00, 5,  'MAC-AIR-M3',  1),
+('iPhone 15 Pro',    'Titanium design, A17 Pro chip', 'iphone-15-pro',  'published', 41900.00, 10, 'IP15-PRO-TI', 1),
+('MacBook Air M3',   'Supercharged by M3',            'macbook-air-m3', 'published', 39900.00, 5,  'MAC-AIR-M3',  1),
 
 -- Product Seller (ID 3)
-('Mechanical Keyboard', 'Blue Switch, RGB Light',      2500.00,  20, 'KEY-MECH-RGB', 3),
-('Gaming Mouse',        'Wireless, 20000 DPI',         1200.00,  15, 'MSE-GAME-WL',  3),
-('4K Monitor 27"',      'IPS Panel, 144Hz',            8900.00,  8,  'MON-4K-27',    3);
+('Mechanical Keyboard', 'Blue Switch, RGB Light',      'mechanical-keyboard', 'published', 2500.00,  20, 'KEY-MECH-RGB', 3),
+('Gaming Mouse',        'Wireless, 20000 DPI',         'gaming-mouse',        'published', 1200.00,  15, 'MSE-GAME-WL',  3),
+('4K Monitor 27"',      'IPS Panel, 144Hz',            '4k-monitor-27',       'published', 8900.00,  8,  'MON-4K-27',    3);
+
+-- Low Stock Alerts
+UPDATE products SET low_stock_threshold = 3 WHERE sku IN ('IP15-PRO-TI', 'MAC-AIR-M3', 'MON-4K-27');
+
+-- Purchase Rules
+UPDATE products SET max_quantity = 2, customer_limit = 4 WHERE sku = 'IP15-PRO-TI';
+
+-- Categories & Commission (10% default, accessories 8%)
+UPDATE products SET category = 'phones' WHERE sku = 'IP15-PRO-TI';
+UPDATE products SET category = 'laptops' WHERE sku = 'MAC-AIR-M3';
+UPDATE products SET category = 'accessories' WHERE sku IN ('KEY-MECH-RGB', 'MSE-GAME-WL');
+UPDATE products SET category = 'monitors' WHERE sku = 'MON-4K-27';
+INSERT INTO commission_rules (scope, category, rate) VALUES
+('default', NULL, 0.10),
+('category', 'accessories', 0.08);
+
+-- Shipping Weight & Dimensions
+UPDATE products SET weight_grams = 187,  length_cm = 15, width_cm = 7,  height_cm = 1 WHERE sku = 'IP15-PRO-TI';
+UPDATE products SET weight_grams = 1240, length_cm = 31, width_cm = 22, height_cm = 2 WHERE sku = 'MAC-AIR-M3';
+UPDATE products SET weight_grams = 900,  length_cm = 45, width_cm = 15, height_cm = 4 WHERE sku = 'KEY-MECH-RGB';
+UPDATE products SET weight_grams = 95,   length_cm = 13, width_cm = 7,  height_cm = 4 WHERE sku = 'MSE-GAME-WL';
+UPDATE products SET weight_grams = 6500, length_cm = 70, width_cm = 50, height_cm = 15 WHERE sku = 'MON-4K-27';
+
+-- Shipping Rates (Free standard over 1,000, pickup is domestic only)
+INSERT INTO shipping_rates (method, zone, base_fee, per_kg_fee, max_weight_grams, free_over) VALUES
+('standard', 'domestic',      50.00,   10.00,  30000, 1000.00),
+('express',  'domestic',      120.00,  20.00,  20000, 0),
+('pickup',   'domestic',      0,       0,      0,     0),
+('standard', 'international', 600.00,  300.00, 20000, 0),
+('express',  'international', 1500.00, 500.00, 20000, 0);
+
+-- Create Warehouses
+INSERT INTO warehouses (code, name, latitude, longitude, priority, is_default) VALUES
+('BKK', 'Bangkok Warehouse',    13.7563, 100.5018, 1, TRUE),
+('CNX', 'Chiang Mai Warehouse', 18.7883, 98.9853,  2, FALSE);
+-- ID 1 = BKK (Default)
+-- ID 2 = CNX
+
+-- Stock per Warehouse (Sum = products.stock)
+INSERT INTO warehouse_stock (warehouse_id, product_id, quantity) VALUES
+(1, 1, 6), (2, 1, 4),
+(1, 2, 5),
+(1, 3, 12), (2, 3, 8),
+(2, 4, 15),
+(1, 5, 8);
+
+-- Opening Stock (Ledger)
+INSERT INTO stock_movements (product_id, delta, stock_after, reason, ref_type, ref_id, note)
+SELECT id, stock, stock, 'restock', 'product', id, 'initial stock'
+FROM products WHERE stock <> 0;